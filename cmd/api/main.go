@@ -1,39 +1,141 @@
 package main
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"bookwork-api/internal/analytics"
+	"bookwork-api/internal/archive"
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/audit"
 	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/availability"
+	"bookwork-api/internal/avatars"
+	"bookwork-api/internal/bounces"
+	"bookwork-api/internal/captcha"
+	"bookwork-api/internal/capture"
+	"bookwork-api/internal/changes"
 	"bookwork-api/internal/config"
+	"bookwork-api/internal/contributions"
+	"bookwork-api/internal/corrections"
 	"bookwork-api/internal/database"
+	"bookwork-api/internal/dataexport"
+	"bookwork-api/internal/deliveryhealth"
+	"bookwork-api/internal/dues"
 	"bookwork-api/internal/handlers"
+	"bookwork-api/internal/health"
+	"bookwork-api/internal/integrations"
+	"bookwork-api/internal/lifecycle"
+	"bookwork-api/internal/logging"
 	customMiddleware "bookwork-api/internal/middleware"
 	"bookwork-api/internal/migrations"
+	"bookwork-api/internal/netacl"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/oauth"
+	"bookwork-api/internal/outbox"
+	"bookwork-api/internal/partnerships"
+	"bookwork-api/internal/polls"
+	"bookwork-api/internal/posters"
+	"bookwork-api/internal/publisher"
+	"bookwork-api/internal/recommend"
+	"bookwork-api/internal/sandbox"
+	"bookwork-api/internal/scaling"
+	"bookwork-api/internal/shadow"
+	"bookwork-api/internal/signedurl"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/tags"
+	"bookwork-api/internal/templates"
+	"bookwork-api/internal/tlsserve"
+	"bookwork-api/internal/vocab"
+	"bookwork-api/internal/webhooks"
+	"bookwork-api/internal/yearbook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 )
 
 func main() {
-	// Load configuration
+	checkConfig := flag.Bool("check-config", false, "validate the configuration, print any problems and exit")
+	flag.Parse()
+
+	// Load configuration, refusing to start with any invalid value
 	cfg, err := config.Load()
+	if *checkConfig {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid")
+		os.Exit(0)
+	}
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		slog.Error("invalid configuration", "problems", invalid.Problems)
+		os.Exit(1)
+	}
+	if err != nil {
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Structured logging for the rest of the process
+	logger := logging.New(os.Stdout, cfg.Logging.Level, cfg.Logging.Format)
+	slog.SetDefault(logger)
+
+	// Features keeping state in process memory break with several instances
+	// and no session affinity; clustered mode refuses to start with them
+	deploymentMode, err := scaling.ParseMode(cfg.Deployment.Mode)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		logger.Error("invalid DEPLOYMENT_MODE", "error", err)
+		os.Exit(1)
+	}
+	scalingReport := scaling.Audit(deploymentMode, scaling.Features(cfg), map[scaling.Backend]bool{
+		scaling.Redis: cfg.Deployment.RedisURL != "",
+		scaling.NATS:  cfg.Deployment.NATSURL != "",
+	})
+	if cfg.Deployment.AuditOnly {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(scalingReport)
+		if !scalingReport.Ready {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
+	scalingReport.Log(logger)
+	if !scalingReport.Ready {
+		logger.Error("refusing to start in clustered mode while features keep state in process memory",
+			"blocking", len(scalingReport.Blocking), "missing_backends", scalingReport.MissingBackends)
+		os.Exit(1)
+	}
+
+	// Background jobs and the shutdown sequence: HTTP, clients, jobs,
+	// outbound deliveries, then storage
+	lifecycleManager := lifecycle.New(logger)
 
 	// Initialize database based on environment variable
 	var db *database.DB
+	var stores *store.Stores
+	var readinessChecks []health.Check
 	isMockMode := os.Getenv("BOOKWORK_API_MOCK_DATA") == "true"
 
 	if isMockMode {
-		log.Println("INFO: Initializing with MOCK data store")
+		logger.Info("initializing with mock data store")
 		db = database.NewMock()
+		stores = store.NewMemory().Stores()
 	} else {
-		log.Println("INFO: Initializing with PostgreSQL data store")
+		logger.Info("initializing with PostgreSQL data store")
 		// Your existing logic to connect to PostgreSQL
 		realDB, err := database.New(database.Config{
 			Host:            cfg.Database.Host,
@@ -49,29 +151,299 @@ func main() {
 			PgBouncerAddr:   cfg.Database.PgBouncerAddr,
 		})
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			logger.Error("failed to connect to database", "error", err)
+			os.Exit(1)
+		}
+		if len(cfg.Database.ReadReplicaDSNs) > 0 {
+			err := realDB.OpenReplicas(cfg.Database.ReadReplicaDSNs, database.Config{
+				MaxOpenConns:    cfg.Database.MaxOpenConns,
+				MaxIdleConns:    cfg.Database.MaxIdleConns,
+				ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+				ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+			}, cfg.Database.ReplicaMaxLag)
+			if err != nil {
+				logger.Error("failed to open read replicas", "error", err)
+				os.Exit(1)
+			}
+			// Replicas are only read from once a check has found them healthy
+			realDB.CheckReplicas(context.Background())
+			lifecycleManager.Go("read replica health", func(ctx context.Context) {
+				realDB.MonitorReplicas(ctx, cfg.Database.ReplicaCheckInterval)
+			})
+			logger.Info("read replicas configured", "count", len(cfg.Database.ReadReplicaDSNs))
 		}
 		db = realDB
+		stores = store.NewPostgres(realDB)
 
 		// Run database migrations for real database only
-		migrator := migrations.NewMigrator(realDB.DB)
+		migrator := migrations.NewMigrator(realDB.DB).WithContract(cfg.Database.AllowContractMigrations)
 		if err := migrator.RunMigrations(); err != nil {
-			log.Fatalf("Failed to run migrations: %v", err)
+			if errors.Is(err, migrations.ErrContractNotAllowed) {
+				logger.Error("refusing to run contract migrations; set ALLOW_CONTRACT_MIGRATIONS=true once old app versions are retired", "error", err)
+				os.Exit(1)
+			}
+			logger.Error("failed to run migrations", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("database migrations completed successfully")
+
+		// Ready once the database answers and every migration is applied, which
+		// also covers replicas of this version started before the one migrating
+		readinessChecks = append(readinessChecks,
+			health.Check{Name: "database", Run: func(ctx context.Context) error {
+				if err := realDB.PingContext(ctx); err != nil {
+					logger.Warn("readiness check failed", "check", "database", "error", err)
+					return errors.New("database is unreachable")
+				}
+				return nil
+			}},
+			health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+				pending, err := migrator.Pending(ctx)
+				if err != nil {
+					logger.Warn("readiness check failed", "check", "migrations", "error", err)
+					return errors.New("migration status is unavailable")
+				}
+				if pending > 0 {
+					return fmt.Errorf("%d migrations pending", pending)
+				}
+				return nil
+			}},
+		)
+
+		// Expired sandbox data is purged even if sandbox registration is later disabled
+		purger := sandbox.NewPurger(realDB, cfg.Sandbox.PurgeInterval, logger)
+		lifecycleManager.Go("sandbox purger", purger.Run)
+
+		// Availability summaries are served from counters; correct any drift
+		repairer := availability.NewRepairer(stores.Availability, cfg.Availability.SummaryRepairInterval, logger)
+		lifecycleManager.Go("availability repair", repairer.Run)
+
+		// "Clubs like this" compares every pair of public clubs, so it runs offline
+		recommender := recommend.NewJob(realDB, cfg.Recommend.PerClub, cfg.Recommend.Interval, logger)
+		lifecycleManager.Go("similar clubs", recommender.Run)
+
+		// Analytics read materialized summaries instead of aggregating live
+		analyticsRefresher := analytics.NewRefresher(analytics.NewStore(realDB), cfg.Analytics.RefreshInterval, logger)
+		lifecycleManager.Go("analytics views", analyticsRefresher.Run)
+
+		// Archiving moves data, so it only runs when explicitly configured
+		if cfg.Archive.AfterYears > 0 {
+			archiver := archive.NewArchiver(realDB, cfg.Archive.AfterYears, cfg.Archive.DetachAfterYears, cfg.Archive.Interval, logger)
+			lifecycleManager.Go("archiver", archiver.Run)
 		}
-		log.Println("Database migrations completed successfully")
 	}
-	defer db.Close()
-	log.Println("Database migrations completed successfully")
+	lifecycleManager.OnShutdown(lifecycle.PhaseStorage, "database", func(ctx context.Context) error {
+		return db.Close()
+	})
 
-	// Initialize auth service
+	// Initialize auth service and club role authorization
 	authService := auth.NewService(cfg.JWT.SecretKey, cfg.JWT.Issuer)
+	if cfg.JWT.SigningKeyFile != "" {
+		keyRing, err := newKeyRing(cfg.JWT)
+		if err != nil {
+			logger.Error("invalid JWT signing keys", "error", err)
+			os.Exit(1)
+		}
+		authService.WithKeyRing(keyRing)
+		logger.Info("signing tokens with key", "kid", keyRing.Signing().ID, "alg", keyRing.Signing().Algorithm())
+	}
+	// Club roles are looked up on every club request. Cached, they are dropped
+	// by the handlers that change memberships; custom roles are dropped by the
+	// cache itself when they change.
+	var roleCache *store.CachedClubs
+	if cfg.Security.RoleCacheTTL > 0 {
+		roleCache = store.NewCachedClubs(stores.Clubs, cfg.Security.RoleCacheSize, cfg.Security.RoleCacheTTL)
+		stores.Clubs = roleCache
+		stores.ClubRoles = store.NewCachedClubRoles(stores.ClubRoles, cfg.Security.RoleCacheSize, cfg.Security.RoleCacheTTL).WithMembers(roleCache)
+	}
+	authorizer := authz.New(stores)
+	requireMember := authorizer.RequireClubRole()
+	requireClubManager := authorizer.RequireClubCapability(authz.ManageClub)
+	requireMemberManager := authorizer.RequireClubCapability(authz.ManageMembers)
+	requireEventManager := authorizer.RequireClubCapability(authz.ManageEvents)
+
+	// Notifications are written in bulk; delivery to external providers is queued
+	// and batched. No push or email provider is registered yet, so only the
+	// in-app feed receives them. Outcomes at each provider are recorded for
+	// club delivery health reports. Addresses that bounced are not emailed, and
+	// users are not reached through channels they turned off.
+	deliveryHealth := deliveryhealth.NewStore(db)
+	suppressions := bounces.NewStore(db)
+	dispatcher := notify.NewDispatcher(logger).
+		WithRecorder(deliveryHealth).
+		WithSuppressor(notify.ProviderEmail, suppressions).
+		WithSuppressor(notify.ProviderEmail, notify.EmailOptOuts(db)).
+		WithSuppressor(notify.ProviderPush, notify.PushOptOuts(db))
+	lifecycleManager.Go("notification dispatcher", dispatcher.Run)
+	lifecycleManager.OnShutdown(lifecycle.PhaseDeliveries, "notification deliveries", dispatcher.Drain)
+	// Clients long-polling on this instance are woken when their notifications are written
+	notificationHub := notify.NewHub(cfg.Notifications.PollMaxWaiters, cfg.Notifications.PollMaxPerUser)
+	notifier := notify.NewNotifier(db, dispatcher).WithHub(notificationHub)
+
+	// Schema refactors shadowed with dual writes and compared reads
+	shadows := shadow.NewRegistry()
+	eventStartsAtMode, err := shadow.ParseMode(cfg.Shadow.EventStartsAt)
+	if err != nil {
+		logger.Error("invalid SHADOW_EVENT_STARTS_AT", "error", err)
+		os.Exit(1)
+	}
+	eventStartsAt := shadows.Register("events.starts_at", eventStartsAtMode)
+
+	// CAPTCHA checks for the public endpoints that require one
+	var captchaVerifier captcha.Verifier
+	if cfg.Captcha.Secret != "" {
+		verifier, err := captcha.New(cfg.Captcha.Provider, cfg.Captcha.Secret, cfg.Captcha.VerifyURL)
+		if err != nil {
+			logger.Error("invalid CAPTCHA configuration", "error", err)
+			os.Exit(1)
+		}
+		captchaVerifier = verifier
+	}
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db, authService)
-	clubHandler := handlers.NewClubHandler(db)
-	eventHandler := handlers.NewEventHandler(db)
-	eventItemHandler := handlers.NewEventItemHandler(db)
-	availabilityHandler := handlers.NewAvailabilityHandler(db)
+	authHandler := handlers.NewAuthHandler(db, stores.Users, authService).
+		WithRegistrationPolicy(cfg.Registration.MinimumAge, cfg.Registration.TermsVersion).
+		WithSandbox(cfg.Sandbox.Enabled, time.Duration(cfg.Sandbox.RetentionDays)*24*time.Hour).
+		WithOAuth(
+			oauth.NewRegistry(
+				oauth.NewGoogle(cfg.OAuth.GoogleClientID, cfg.OAuth.GoogleClientSecret),
+				oauth.NewGitHub(cfg.OAuth.GitHubClientID, cfg.OAuth.GitHubClientSecret),
+			),
+			signedurl.NewSigner(cfg.JWT.SecretKey, "oauth-state"),
+			cfg.OAuth.StateTTL, cfg.OAuth.CallbackBaseURL, cfg.OAuth.FrontendRedirectURL,
+		)
+	if captchaVerifier != nil && cfg.Captcha.Requires("register") {
+		authHandler.WithCaptcha(captchaVerifier)
+	}
+	userHandler := handlers.NewUserHandler(db)
+	clubHandler := handlers.NewClubHandler(db).WithNotifier(notifier).WithRemovals(stores.Removals)
+	if captchaVerifier != nil && cfg.Captcha.Requires("contact") {
+		clubHandler.WithCaptcha(captchaVerifier)
+	}
+	// Event types and item categories each club picks from
+	vocabulary := vocab.NewStore(db)
+	eventTemplates := templates.NewStore(db)
+	eventHandler := handlers.NewEventHandler(db).WithNotifier(notifier).WithStartsAtShadow(eventStartsAt).WithStores(stores).
+		WithVocabulary(vocabulary).WithTemplates(eventTemplates)
+	pollHandler := handlers.NewPollHandler(db).WithNotifier(notifier)
+	eventItemHandler := handlers.NewEventItemHandler(stores).WithVocabulary(vocabulary).WithNotifier(notifier)
+	availabilityHandler := handlers.NewAvailabilityHandler(stores)
+	announcementHandler := handlers.NewAnnouncementHandler(db)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(stores)
+	trashHandler := handlers.NewTrashHandler(db, stores.Removals)
+	if roleCache != nil {
+		clubHandler.WithRoleCache(roleCache)
+		trashHandler.WithRoleCache(roleCache)
+	}
+	notificationPollHandler := handlers.NewNotificationPollHandler(notify.NewFeed(db), notificationHub)
+
+	// Keys for sites embedding club widgets; signed requests use the publisher's quota
+	publishers := publisher.NewRegistry(db, publisher.Settings{
+		DefaultQuota:        cfg.Publishers.DefaultQuota,
+		DefaultReplayWindow: cfg.Publishers.DefaultReplayWindow,
+		RefreshInterval:     cfg.Publishers.RefreshInterval,
+	}, logger)
+	lifecycleManager.Go("publisher keys", publishers.Run)
+	publisherHandler := handlers.NewPublisherHandler(publishers)
+
+	// Membership dues, paid to treasurers or through Stripe Checkout
+	duesLedger := dues.NewLedger(db)
+	duesHandler := handlers.NewDuesHandler(duesLedger, stores.Clubs)
+	// One-off contributions towards event costs, recorded by treasurers or through Stripe
+	contributionLedger := contributions.NewLedger(db)
+	contributionHandler := handlers.NewContributionHandler(contributionLedger, stores.Clubs)
+	billingHandler := handlers.NewBillingHandler(cfg.Billing.StripeWebhookSecret).
+		WithRecorder(dues.PurposeDues, duesLedger).
+		WithRecorder(contributions.PurposeContribution, contributionLedger)
+	emailWebhookHandler := handlers.NewEmailWebhookHandler(cfg.Email.WebhookSecret, suppressions).WithNotifier(notifier)
+	// Endpoints clubs register to receive their events, signed and retried through the outbox
+	clubRoleHandler := handlers.NewClubRoleHandler(stores.ClubRoles)
+	clubWebhooks := webhooks.NewStore(db)
+	clubWebhookHandler := handlers.NewClubWebhookHandler(clubWebhooks, cfg.Webhooks.AllowInsecure)
+	// Slack and Discord webhooks events are announced in
+	chatIntegrations := integrations.NewStore(db)
+	clubIntegrationHandler := handlers.NewClubIntegrationHandler(chatIntegrations)
+	// Clubs' changes, streamed to clients as Server-Sent Events
+	streamHub := notify.NewHub(cfg.Streams.MaxClients, cfg.Streams.MaxPerClub)
+	changeLog := changes.NewLog(db, streamHub, cfg.Streams.ChangeRetention, logger)
+	clubStreamHandler := handlers.NewClubStreamHandler(changeLog, streamHub)
+	if !isMockMode {
+		availabilityHandler.WithDues(duesLedger).WithWebhooks(clubWebhooks).WithChanges(changeLog)
+		eventHandler.WithWebhooks(clubWebhooks).WithIntegrations(chatIntegrations).WithChanges(changeLog)
+		eventItemHandler.WithChanges(changeLog)
+		clubHandler.WithWebhooks(clubWebhooks)
+		lifecycleManager.Go("change log", changeLog.Run)
+	}
+
+	// Recent requests by X-Request-ID for support lookups
+	requestRecorder := customMiddleware.NewRequestRecorder(5000)
+	supportHandler := handlers.NewSupportHandler(requestRecorder)
+	// Sampled request/response pairs for debugging, when enabled
+	var trafficCapture *capture.Recorder
+	if cfg.Capture.Enabled {
+		trafficCapture = capture.NewRecorder(cfg.Capture.BufferSize, cfg.Capture.SamplePercent, cfg.Capture.MaxBodyBytes)
+		supportHandler.WithCapture(trafficCapture)
+		logger.Warn("traffic capture enabled", "sample_percent", cfg.Capture.SamplePercent, "buffer_size", cfg.Capture.BufferSize)
+	}
+	// Every successful POST/PUT/DELETE is written to the audit log
+	auditLog := audit.NewLog(db)
+	analyticsHandler := handlers.NewAnalyticsHandler(analytics.NewStore(db))
+	// Requests are counted per route pattern so unused API surfaces can be found
+	var usageCounter *analytics.UsageCounter
+	if cfg.Analytics.UsageEnabled {
+		usageCounter = analytics.NewUsageCounter(db, cfg.Analytics.UsageFlushInterval, logger)
+		lifecycleManager.Go("endpoint usage", usageCounter.Run)
+	}
+	tagHandler := handlers.NewTagHandler(tags.NewStore(db))
+	vocabularyHandler := handlers.NewVocabularyHandler(vocabulary)
+	correctionHandler := handlers.NewCorrectionHandler(corrections.NewStore(db)).WithNotifier(notifier)
+	partnershipHandler := handlers.NewPartnershipHandler(partnerships.NewStore(db)).WithNotifier(notifier)
+	deliveryHealthHandler := handlers.NewDeliveryHealthHandler(deliveryHealth)
+	eventTemplateHandler := handlers.NewEventTemplateHandler(eventTemplates).WithVocabulary(vocabulary)
+	adminHandler := handlers.NewAdminHandler(db.DB, requestRecorder, dispatcher).WithAuditLog(auditLog).WithShadows(shadows)
+	tokenGuard := customMiddleware.NewTokenGuard(db, customMiddleware.TokenGuardLimits{
+		MaxFailures: cfg.Security.TokenMaxFailures,
+		Window:      cfg.Security.TokenLockout,
+		Lockout:     cfg.Security.TokenLockout,
+	})
+	helperLinkHandler := handlers.NewHelperLinkHandler(db, signedurl.NewSigner(cfg.JWT.SecretKey, "event-helper-link"))
+
+	// Uploaded files live outside the database, on local disk or an S3-compatible bucket
+	attachmentStorage, err := newAttachmentStorage(cfg.Attachments)
+	if err != nil {
+		logger.Error("failed to initialize attachment storage", "error", err)
+		os.Exit(1)
+	}
+	attachmentHandler := handlers.NewAttachmentHandler(db, attachmentStorage,
+		signedurl.NewSigner(cfg.JWT.SecretKey, "attachment-download"),
+		cfg.Attachments.MaxSizeBytes, cfg.Attachments.AllowedTypes, cfg.Attachments.URLTTL)
+
+	// Profile pictures, resized into attachment storage
+	avatarHandler := handlers.NewAvatarHandler(attachmentStorage, avatars.NewStore(db), cfg.Avatars.PublicBaseURL, cfg.Avatars.MaxSizeBytes)
+
+	// End-of-year club reports, compiled in the background into attachment storage
+	yearbooks := yearbook.NewStore(db)
+	yearbookLinks := yearbook.NewLinks(signedurl.NewSigner(cfg.JWT.SecretKey, "yearbook-download"), cfg.Yearbooks.LinkTTL)
+	yearbookHandler := handlers.NewYearbookHandler(yearbooks, attachmentStorage, yearbookLinks)
+	posterLinks := posters.NewLinks(signedurl.NewSigner(cfg.JWT.SecretKey, "event-guest-link"), cfg.Posters.GuestPageURL)
+	posterHandler := handlers.NewPosterHandler(posters.NewStore(db), posterLinks)
+	if !isMockMode {
+		yearbookCompiler := yearbook.NewCompiler(yearbooks, attachmentStorage, yearbookLinks, cfg.Yearbooks.PollInterval, logger).
+			WithNotifier(notifier)
+		lifecycleManager.Go("yearbook compiler", yearbookCompiler.Run)
+		yearbookHandler.WithCompiler(yearbookCompiler)
+	}
+
+	// Archives of everything stored about a user, compiled in the background and deleted after the retention period
+	dataExports := dataexport.NewStore(db)
+	dataExportLinks := dataexport.NewLinks(signedurl.NewSigner(cfg.JWT.SecretKey, "data-export-download"))
+	dataExportHandler := handlers.NewDataExportHandler(dataExports, attachmentStorage, dataExportLinks)
+	if !isMockMode {
+		dataExportCompiler := dataexport.NewCompiler(dataExports, attachmentStorage, dataExportLinks, cfg.Exports.Retention, cfg.Exports.PollInterval, logger).
+			WithNotifier(notifier)
+		lifecycleManager.Go("data export compiler", dataExportCompiler.Run)
+		dataExportHandler.WithCompiler(dataExportCompiler)
+	}
 
 	// Create health handler - pass nil for mock mode since db.DB will be nil
 	var healthHandler *handlers.HealthHandler
@@ -81,96 +453,553 @@ func main() {
 		healthHandler = handlers.NewHealthHandler(db.DB)
 	}
 
+	// Messages written to the outbox with the change they report, such as
+	// notifications of new events, are published once that change commits
+	if !isMockMode {
+		outboxPoller := outbox.NewPoller(db, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, cfg.Outbox.Retention, logger)
+		notifier.HandleOutbox(outboxPoller)
+		deliverer := webhooks.NewDeliverer(clubWebhooks, cfg.Webhooks.Timeout, cfg.Webhooks.MaxAttempts, cfg.Webhooks.AllowInsecure, logger)
+		outboxPoller.HandleWithBackoff(webhooks.TopicDeliver, deliverer.Deliver, cfg.Webhooks.RetryBackoff)
+		poster := integrations.NewPoster(chatIntegrations, cfg.Integrations.Timeout, cfg.Integrations.MaxAttempts, logger)
+		outboxPoller.HandleWithBackoff(integrations.TopicAnnounce, poster.Announce, cfg.Integrations.RetryBackoff)
+		lifecycleManager.Go("outbox", outboxPoller.Run)
+	}
+
+	// Chat integrations are reminded of meetings ahead of time
+	if !isMockMode {
+		reminder := integrations.NewReminder(db, cfg.Integrations.ReminderInterval, logger)
+		lifecycleManager.Go("event reminders", reminder.Run)
+	}
+
+	// Polls close at their deadline even when nobody is looking at them
+	if !isMockMode {
+		pollCloser := polls.NewCloser(db, cfg.Polls.CloseInterval, logger).OnClose(pollHandler.NotifyClosed)
+		lifecycleManager.Go("poll closer", pollCloser.Run)
+	}
+
+	// Network ranges refused everywhere or allowed on the admin routes
+	networkACL, err := netacl.New(db, cfg.NetworkACL.Deny, cfg.NetworkACL.AdminAllow, cfg.NetworkACL.TrustProxy, cfg.NetworkACL.RefreshInterval, logger)
+	if err != nil {
+		logger.Error("invalid network ACL configuration", "error", err)
+		os.Exit(1)
+	}
+	if !isMockMode {
+		lifecycleManager.Go("network rules", networkACL.Run)
+	}
+	networkRuleHandler := handlers.NewNetworkRuleHandler(networkACL)
+	requireAdmin := func(next http.Handler) http.Handler {
+		return networkACL.RequireAdminNetwork(authService.RequireRole(authz.AdminRole)(next))
+	}
+	// Database metrics are for admins, or scrapers presenting METRICS_TOKEN,
+	// from the admin networks
+	metricsToken := customMiddleware.StaticTokenOr(cfg.Security.MetricsToken, func(next http.Handler) http.Handler {
+		return authService.AuthMiddleware(authService.RequireRole(authz.AdminRole)(next))
+	})
+	requireMetricsAccess := func(next http.Handler) http.Handler {
+		return networkACL.RequireAdminNetwork(metricsToken(next))
+	}
+
 	// Setup router
 	r := chi.NewRouter()
 
+	// Probes are answered ahead of logging and rate limiting, which they would
+	// flood and trip. Readiness fails as soon as shutdown starts.
+	probes := health.NewChecker(cfg.Server.ReadinessCacheTTL, readinessChecks...)
+	lifecycleManager.OnShutdown(lifecycle.PhaseHTTP, "readiness", probes.Drain)
+	r.Use(probes.Middleware)
+
+	// Client addresses for throttling and auditing come from the proxy's
+	// X-Forwarded-For entry only when a proxy is trusted
+	r.Use(customMiddleware.ClientAddress(cfg.NetworkACL.TrustProxy))
+
+	// Request IDs first so every later middleware and handler can log with them
+	r.Use(customMiddleware.RequestID(logger))
+	r.Use(customMiddleware.RequestLogger)
+	r.Use(requestRecorder.Middleware)
+	r.Use(trafficCapture.Middleware)
+	if cfg.Logging.DebugBodies {
+		logger.Warn("request and response bodies are being logged", "max_body_bytes", cfg.Logging.DebugBodyMaxBytes)
+		r.Use(capture.LogBodies(cfg.Logging.DebugBodyMaxBytes))
+	}
+	if usageCounter != nil {
+		r.Use(usageCounter.Middleware)
+	}
+
 	// Security middleware with configuration
 	r.Use(customMiddleware.SecurityHeadersWithConfig(
 		customMiddleware.SecurityConfig{
 			EnableHSTS:      cfg.Security.EnableHSTS,
 			HSTSMaxAge:      cfg.Security.HSTSMaxAge,
 			EnableHTTPSOnly: cfg.Security.EnableHTTPSOnly,
+			PublicHost:      cfg.Security.PublicHost,
 		},
 	))
 
+	// Denied networks are refused before they touch any other state
+	r.Use(networkACL.Middleware)
+
+	// Publisher attribution before rate limiting, so signed widget traffic is
+	// throttled by its publisher quota rather than per client
+	r.Use(publishers.Middleware("/api/public/"))
+
 	// Rate limiting (100 requests per minute)
-	rateLimiter := customMiddleware.NewRateLimiter(100, time.Minute)
+	rateLimiter := customMiddleware.NewRateLimiter(100, time.Minute).WithSkip(publisher.Signed)
 	r.Use(rateLimiter.Middleware)
 
 	// Standard middleware
-	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(middleware.Heartbeat("/healthz"))
 
 	// CORS configuration
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   cfg.CORS.AllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: cfg.CORS.AllowCredentials,
-		MaxAge:           cfg.CORS.MaxAge,
-	}))
+	corsMiddleware, err := customMiddleware.CORS(customMiddleware.CORSConfig{
+		AllowedOrigins:            cfg.CORS.AllowedOrigins,
+		AllowCredentials:          cfg.CORS.AllowCredentials,
+		OriginsWithoutCredentials: cfg.CORS.OriginsWithoutCredentials,
+		AllowedMethods:            []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:            []string{"Accept", "Authorization", "Content-Type", "If-Match", "X-CSRF-Token", logging.RequestIDHeader, publisher.KeyHeader, publisher.TimestampHeader, publisher.SignatureHeader},
+		ExposedHeaders:            []string{"ETag", "Link", logging.RequestIDHeader, auth.SandboxHeader},
+		MaxAge:                    cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		logger.Error("invalid CORS configuration", "error", err)
+		os.Exit(1)
+	}
+	r.Use(corsMiddleware)
 
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		r.Use(audit.Middleware(auditLog))
+
 		// Health and monitoring routes (no auth required)
-		r.Mount("/", healthHandler.RegisterRoutes())
+		r.Mount("/", healthHandler.RegisterRoutes(requireMetricsAccess))
 
 		// Public authentication routes
 		r.Route("/auth", func(r chi.Router) {
+			r.Post("/register", authHandler.Register)
 			r.Post("/login", authHandler.Login)
 			r.Post("/refresh", authHandler.Refresh)
 
+			// Sign-in with Google or GitHub
+			r.Get("/oauth/providers", authHandler.OAuthProviders)
+			r.Get("/oauth/{provider}/start", authHandler.OAuthStart)
+			r.Get("/oauth/{provider}/callback", authHandler.OAuthCallback)
+
 			// Protected auth routes
 			r.Group(func(r chi.Router) {
 				r.Use(authService.AuthMiddleware)
 				r.Post("/validate", authHandler.Validate)
 				r.Post("/logout", authHandler.Logout)
+				r.Post("/oauth/{provider}/link", authHandler.OAuthLink)
+			})
+		})
+
+		// Public club pages, cacheable by browsers and CDNs
+		publicCache := customMiddleware.NewResponseCache(time.Minute, 1000)
+		// The contact form is unauthenticated, so it is limited by client address
+		contactLimiter := customMiddleware.NewRateLimiter(cfg.Contact.PerClientLimit, time.Hour)
+		r.Route("/public", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddleware.CacheControl(customMiddleware.PublicCache(time.Minute, 5*time.Minute)))
+				r.Use(publicCache.Middleware)
+				r.Get("/clubs/{clubId}", clubHandler.GetPublicClub)
 			})
+
+			// Messages to club owners from prospective members
+			r.With(contactLimiter.Middleware).Post("/clubs/{clubId}/contact", clubHandler.ContactClub)
 		})
 
+		// Signed helper links for non-members (the token is the credential)
+		r.Route("/helper/{token}", func(r chi.Router) {
+			r.Use(tokenGuard.Middleware("token"))
+			r.Get("/", helperLinkHandler.GetHelperView)
+			r.Put("/items/{itemId}", helperLinkHandler.UpdateHelperItem)
+		})
+
+		// Public events behind poster QR codes (the token is the credential)
+		r.With(tokenGuard.Middleware("token")).Get("/guest/events/{token}", posterHandler.GetGuestEvent)
+
+		// Club invite previews (the token is the credential)
+		r.With(tokenGuard.Middleware("token")).Get("/invites/{token}", clubHandler.PreviewInvite)
+
+		// Stripe payment notifications (the signature is the credential)
+		r.Post("/webhooks/stripe", billingHandler.StripeWebhook)
+
+		// Email bounce and complaint reports (the signature is the credential)
+		r.Post("/webhooks/email", emailWebhookHandler.EmailWebhook)
+
+		// Signed attachment downloads (the token is the credential)
+		r.With(tokenGuard.Middleware("token")).Get("/attachments/{token}", attachmentHandler.Download)
+		r.With(tokenGuard.Middleware("token")).Get("/yearbooks/{token}", yearbookHandler.Download)
+		r.With(tokenGuard.Middleware("token")).Get("/exports/{token}", dataExportHandler.Download)
+
+		// Uploaded avatars (public like any profile picture link)
+		r.Get("/avatars/{userId}/{version}/{file}", avatarHandler.ServeAvatar)
+
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(authService.AuthMiddleware)
+			r.Use(customMiddleware.SparseFields)
+
+			// Current user profile and preferences
+			r.Get("/users/me", userHandler.GetProfile)
+			r.Patch("/users/me", userHandler.UpdateProfile)
+			r.Put("/users/me/preferences", userHandler.UpdatePreferences)
+			// Guesses at the current password are limited per user
+			passwordLimiter := customMiddleware.NewRateLimiter(10, time.Hour).WithKey(func(r *http.Request) string {
+				userID, _ := auth.GetUserIDFromContext(r.Context())
+				return userID.String()
+			})
+			r.With(passwordLimiter.Middleware).Post("/users/me/password", authHandler.ChangePassword)
+			r.Post("/users/me/avatar", avatarHandler.UploadAvatar)
+			r.Delete("/users/me/avatar", avatarHandler.DeleteAvatar)
+			r.Get("/users/me/export", dataExportHandler.GetExport)
+			r.Post("/users/me/export", dataExportHandler.RequestExport)
+
+			// Typeahead for club tags
+			r.Get("/tags/suggest", tagHandler.SuggestTags)
+
+			// Event templates clubs share with each other
+			r.Get("/event-templates/library", eventTemplateHandler.GetTemplateLibrary)
+
+			// Notification feed and platform announcement banner
+			r.Get("/notifications", announcementHandler.GetNotifications)
+			r.Get("/notifications/poll", notificationPollHandler.PollNotifications)
+			r.Post("/notifications/announcements/{announcementId}/read", announcementHandler.MarkRead)
+			r.Post("/notifications/{notificationId}/read", announcementHandler.MarkNotificationRead)
+			r.Get("/announcements/banner", announcementHandler.GetBanner)
+
+			// Platform administration (global admins only)
+			r.Route("/admin/announcements", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", announcementHandler.ListAnnouncements)
+				r.Post("/", announcementHandler.CreateAnnouncement)
+				r.Put("/{announcementId}", announcementHandler.UpdateAnnouncement)
+				r.Delete("/{announcementId}", announcementHandler.DeleteAnnouncement)
+			})
+
+			// Support tooling (global admins only)
+			r.Route("/admin/requests", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/{requestId}", supportHandler.LookupRequest)
+			})
+			r.Route("/admin/captures", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", supportHandler.GetCaptures)
+				r.Delete("/", supportHandler.ClearCaptures)
+				r.Get("/{captureId}", supportHandler.GetCapture)
+			})
+
+			// Operational overview for the internal ops dashboard, the audit log and shadowed refactors (global admins only)
+			r.With(requireAdmin).Get("/admin/overview", adminHandler.GetOverview)
+			r.With(requireAdmin).Get("/admin/audit", adminHandler.GetAuditLog)
+			r.With(requireAdmin).Get("/admin/shadow", adminHandler.GetShadowReports)
+
+			// Canonical club tags, their aliases, renames and merges (global admins only)
+			r.Route("/admin/tags", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", tagHandler.ListTags)
+				r.Post("/", tagHandler.CreateTag)
+				r.Put("/{tagId}", tagHandler.RenameTag)
+				r.Post("/{tagId}/merge", tagHandler.MergeTag)
+				r.Post("/{tagId}/aliases", tagHandler.AddAlias)
+				r.Delete("/{tagId}/aliases/{alias}", tagHandler.RemoveAlias)
+			})
+
+			// Activity summaries across clubs (global admins only)
+			r.With(requireAdmin).Get("/admin/analytics", analyticsHandler.GetPlatformAnalytics)
+
+			// Exchange rates for converting item costs into club currencies (global admins only)
+			r.Route("/admin/exchange-rates", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", exchangeRateHandler.ListRates)
+				r.Put("/", exchangeRateHandler.SetRate)
+			})
+
+			// Addresses email is no longer sent to after bounces or complaints (global admins only)
+			r.With(requireAdmin).Delete("/admin/email-suppressions/{email}", emailWebhookHandler.LiftSuppression)
+
+			// Soft-deleted events and clubs (global admins only)
+			r.Route("/admin/deleted", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/events", trashHandler.ListEvents)
+				r.Post("/events/{eventId}/restore", trashHandler.RestoreEvent)
+				r.Delete("/events/{eventId}", trashHandler.PurgeEvent)
+				r.Get("/clubs", trashHandler.ListClubs)
+				r.Post("/clubs/{clubId}/restore", trashHandler.RestoreClub)
+				r.Delete("/clubs/{clubId}", trashHandler.PurgeClub)
+			})
+
+			// Club verification review queue and badges (global admins only)
+			r.Route("/admin/verification-requests", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", clubHandler.ListVerificationRequests)
+				r.Post("/{requestId}/approve", clubHandler.ApproveVerificationRequest)
+				r.Post("/{requestId}/reject", clubHandler.RejectVerificationRequest)
+			})
+			r.Route("/admin/clubs/{clubId}/verification", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Put("/", clubHandler.SetVerification)
+				r.Delete("/", clubHandler.RemoveVerification)
+			})
+
+			// Network deny list and admin allow list (global admins only)
+			r.Route("/admin/network-rules", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", networkRuleHandler.ListRules)
+				r.Post("/", networkRuleHandler.CreateRule)
+				r.Delete("/{ruleId}", networkRuleHandler.DeleteRule)
+			})
+
+			// Publisher keys for embedded widgets and their usage (global admins only)
+			r.Route("/admin/publishers", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", publisherHandler.ListPublishers)
+				r.Post("/", publisherHandler.CreatePublisher)
+				r.Delete("/{publisherId}", publisherHandler.RevokePublisher)
+				r.Get("/{publisherId}/usage", publisherHandler.GetUsage)
+			})
+
+			// Club discovery
+			r.With(customMiddleware.CacheControl(customMiddleware.PrivateCache)).Get("/clubs", clubHandler.ListClubs)
+
+			// Throwaway clubs for sandbox accounts
+			r.Post("/sandbox/clubs", clubHandler.CreateSandboxClub)
+
+			// Lists that clients poll answer 304 Not Modified when unchanged
+			revalidate := chi.Chain(customMiddleware.CacheControl(customMiddleware.PrivateRevalidate), customMiddleware.ConditionalGET)
 
 			// Club member management
 			r.Route("/club/{clubId}/members", func(r chi.Router) {
-				r.Get("/", clubHandler.GetMembers)
-				r.Post("/", clubHandler.AddMember)
-				r.Put("/{memberId}", clubHandler.UpdateMember)
-				r.Delete("/{memberId}", clubHandler.RemoveMember)
+				r.With(requireMember).With(revalidate...).Get("/", clubHandler.GetMembers)
+				r.With(requireMemberManager).Post("/", clubHandler.AddMember)
+				r.With(requireMemberManager).Put("/{memberId}", clubHandler.UpdateMember)
+				r.With(requireMemberManager).Patch("/{memberId}", clubHandler.PatchMember)
+				r.With(requireMemberManager).Delete("/{memberId}", clubHandler.RemoveMember)
+			})
+
+			// Custom club roles and the capabilities they grant
+			r.Route("/club/{clubId}/roles", func(r chi.Router) {
+				r.With(requireMember).Get("/", clubRoleHandler.ListRoles)
+				r.With(requireMemberManager).Post("/", clubRoleHandler.CreateRole)
+				r.With(requireMemberManager).Put("/{roleId}", clubRoleHandler.UpdateRole)
+				r.With(requireMemberManager).Delete("/{roleId}", clubRoleHandler.DeleteRole)
+			})
+
+			// Club deletion (owner only; soft delete)
+			r.With(requireMember).Delete("/club/{clubId}", clubHandler.DeleteClub)
+
+			// Self-service membership and approval queue
+			r.Post("/club/{clubId}/join", clubHandler.JoinClub)
+			r.Post("/club/{clubId}/leave", clubHandler.LeaveClub)
+			r.Route("/club/{clubId}/join-requests", func(r chi.Router) {
+				r.Use(requireMemberManager)
+				r.Get("/", clubHandler.GetJoinRequests)
+				r.Post("/{requestId}/approve", clubHandler.ApproveJoinRequest)
+				r.Post("/{requestId}/reject", clubHandler.RejectJoinRequest)
+			})
+
+			// Shareable invite links
+			r.Route("/club/{clubId}/invites", func(r chi.Router) {
+				r.Use(requireMemberManager)
+				r.Get("/", clubHandler.GetInvites)
+				r.Post("/", clubHandler.CreateInvite)
+				r.Delete("/{inviteId}", clubHandler.RevokeInvite)
+			})
+			r.With(tokenGuard.Middleware("token")).Post("/invites/{token}/accept", clubHandler.AcceptInvite)
+
+			// Applying for a verified badge
+			r.Route("/club/{clubId}/verification", func(r chi.Router) {
+				r.Use(requireClubManager)
+				r.Get("/", clubHandler.GetVerification)
+				r.Post("/", clubHandler.ApplyForVerification)
+			})
+
+			// Book polls
+			r.Route("/club/{clubId}/polls", func(r chi.Router) {
+				r.With(requireMember).Get("/", pollHandler.GetPolls)
+				r.With(requireEventManager).Post("/", pollHandler.CreatePoll)
+				r.With(requireMember).Get("/{pollId}", pollHandler.GetPoll)
+				r.With(requireMember).Post("/{pollId}/vote", pollHandler.Vote)
+				r.With(requireEventManager).Post("/{pollId}/close", pollHandler.ClosePoll)
+			})
+
+			// Club activity summaries
+			r.With(requireClubManager).Get("/club/{clubId}/analytics", analyticsHandler.GetClubAnalytics)
+
+			// Corrections members ask for to their books read count and attendance
+			r.Route("/club/{clubId}/corrections", func(r chi.Router) {
+				r.Use(requireMember)
+				r.Get("/", correctionHandler.GetCorrections)
+				r.Post("/", correctionHandler.CreateCorrection)
+				r.Delete("/{correctionId}", correctionHandler.CancelCorrection)
+				r.With(requireMemberManager).Post("/{correctionId}/approve", correctionHandler.ApproveCorrection)
+				r.With(requireMemberManager).Post("/{correctionId}/reject", correctionHandler.RejectCorrection)
+			})
+
+			// Joint reads with other clubs
+			r.Route("/club/{clubId}/partnerships", func(r chi.Router) {
+				r.Use(requireMember)
+				r.Get("/", partnershipHandler.GetPartnerships)
+				r.With(requireEventManager).Post("/", partnershipHandler.ProposePartnership)
+				r.Get("/{partnershipId}", partnershipHandler.GetPartnership)
+				r.With(requireEventManager).Put("/{partnershipId}", partnershipHandler.UpdatePartnershipBook)
+				r.With(requireEventManager).Post("/{partnershipId}/accept", partnershipHandler.AcceptPartnership)
+				r.With(requireEventManager).Post("/{partnershipId}/decline", partnershipHandler.DeclinePartnership)
+				r.With(requireEventManager).Post("/{partnershipId}/end", partnershipHandler.EndPartnership)
+				r.Get("/{partnershipId}/events", partnershipHandler.GetPartnershipEvents)
+				r.With(requireEventManager).Post("/{partnershipId}/events", partnershipHandler.SharePartnershipEvent)
+				r.With(requireEventManager).Delete("/{partnershipId}/events/{eventId}", partnershipHandler.UnsharePartnershipEvent)
+				r.Get("/{partnershipId}/messages", partnershipHandler.GetPartnershipMessages)
+				r.Post("/{partnershipId}/messages", partnershipHandler.PostPartnershipMessage)
+				r.Delete("/{partnershipId}/messages/{messageId}", partnershipHandler.DeletePartnershipMessage)
+			})
+
+			// How the club's notifications fared at each delivery provider. Owners
+			// are moderators in club_members, so this goes by capability.
+			r.With(requireClubManager).Get("/club/{clubId}/delivery-health", deliveryHealthHandler.GetDeliveryHealth)
+
+			// End-of-year reports
+			r.Route("/club/{clubId}/yearbooks", func(r chi.Router) {
+				r.With(requireMember).Get("/{year}", yearbookHandler.GetYearbook)
+				r.With(requireClubManager).Post("/{year}", yearbookHandler.RequestYearbook)
+			})
+
+			// Club settings
+			r.Route("/club/{clubId}/settings", func(r chi.Router) {
+				r.With(requireMember).Get("/", clubHandler.GetSettings)
+				r.With(requireClubManager).Put("/", clubHandler.UpdateSettings)
+			})
+
+			// Club webhooks and their delivery logs
+			r.Route("/club/{clubId}/webhooks", func(r chi.Router) {
+				r.Use(requireClubManager)
+				r.Get("/", clubWebhookHandler.ListWebhooks)
+				r.Post("/", clubWebhookHandler.CreateWebhook)
+				r.Put("/{webhookId}", clubWebhookHandler.UpdateWebhook)
+				r.Delete("/{webhookId}", clubWebhookHandler.DeleteWebhook)
+				r.Get("/{webhookId}/deliveries", clubWebhookHandler.GetDeliveries)
+			})
+
+			// Live changes to the club's events, items and availability
+			r.With(requireMember).Get("/club/{clubId}/stream", clubStreamHandler.StreamChanges)
+
+			// Slack and Discord announcements of the club's events
+			r.Route("/club/{clubId}/integrations", func(r chi.Router) {
+				r.Use(authorizer.RequireClubCapability(authz.PostAnnouncements))
+				r.Get("/", clubIntegrationHandler.ListIntegrations)
+				r.Post("/", clubIntegrationHandler.CreateIntegration)
+				r.Put("/{integrationId}", clubIntegrationHandler.UpdateIntegration)
+				r.Delete("/{integrationId}", clubIntegrationHandler.DeleteIntegration)
+			})
+
+			// Club event types and item categories
+			r.Route("/club/{clubId}/vocabularies", func(r chi.Router) {
+				r.With(requireMember).Get("/", vocabularyHandler.GetVocabularies)
+				r.With(requireClubManager).Post("/{kind}", vocabularyHandler.AddTerm)
+				r.With(requireClubManager).Delete("/{kind}", vocabularyHandler.ResetVocabulary)
+				r.With(requireClubManager).Put("/{kind}/{value}", vocabularyHandler.RelabelTerm)
+				r.With(requireClubManager).Delete("/{kind}/{value}", vocabularyHandler.RemoveTerm)
+			})
+
+			// Membership dues
+			r.Route("/club/{clubId}/dues", func(r chi.Router) {
+				requireTreasurer := authorizer.RequireClubCapability(authz.ManageDues)
+				r.With(requireMember).Get("/", duesHandler.GetDues)
+				r.With(requireTreasurer).Put("/", duesHandler.UpdateDues)
+				r.With(requireTreasurer).Get("/members", duesHandler.GetMemberStatuses)
+				r.With(requireTreasurer).Get("/payments", duesHandler.GetPayments)
+				r.With(requireTreasurer).Post("/payments", duesHandler.RecordPayment)
+			})
+
+			// Club resources such as venue maps and handouts
+			r.Route("/club/{clubId}/resources", func(r chi.Router) {
+				r.With(requireMember).Get("/", attachmentHandler.GetClubResources)
+				r.With(requireClubManager).Post("/", attachmentHandler.UploadClubResource)
+				r.With(requireClubManager).Delete("/{attachmentId}", attachmentHandler.DeleteClubResource)
 			})
 
 			// Club events
 			r.Route("/club/{clubId}/events", func(r chi.Router) {
-				r.Get("/", eventHandler.GetEvents)
-				r.Post("/", eventHandler.CreateEvent)
+				r.With(requireMember).With(revalidate...).Get("/", eventHandler.GetEvents)
+				r.With(requireMember).Get("/archive", eventHandler.GetArchivedEvents)
+				r.With(requireEventManager).Post("/", eventHandler.CreateEvent)
 			})
 
-			// Event management
+			// Club event templates; shared ones can be read and applied by any club
+			r.Route("/club/{clubId}/event-templates", func(r chi.Router) {
+				r.With(requireMember).Get("/", eventTemplateHandler.GetClubTemplates)
+				r.With(requireEventManager).Post("/", eventTemplateHandler.CreateTemplate)
+				r.With(requireMember).Get("/{templateId}", eventTemplateHandler.GetTemplate)
+				r.With(requireEventManager).Put("/{templateId}", eventTemplateHandler.UpdateTemplate)
+				r.With(requireEventManager).Delete("/{templateId}", eventTemplateHandler.DeleteTemplate)
+			})
+
+			// Event management, for members of the event's club
 			r.Route("/events/{eventId}", func(r chi.Router) {
+				r.Use(authorizer.RequireEventRole())
+
+				r.Get("/", eventHandler.GetEvent)
 				r.Put("/", eventHandler.UpdateEvent)
+				r.Patch("/", eventHandler.UpdateEvent)
 				r.Delete("/", eventHandler.DeleteEvent)
+				r.Get("/attendees/print.pdf", eventHandler.PrintAttendees)
+				r.Get("/poster", posterHandler.GetEventPoster)
+
+				// Recorded headcount, which feeds the club's attendance forecasts
+				r.Put("/attendance", eventHandler.RecordAttendance)
 
 				// Event items
 				r.Route("/items", func(r chi.Router) {
 					r.Get("/", eventItemHandler.GetItems)
 					r.Post("/", eventItemHandler.CreateItem)
 					r.Put("/{itemId}", eventItemHandler.UpdateItem)
+					r.Patch("/{itemId}", eventItemHandler.PatchItem)
 					r.Delete("/{itemId}", eventItemHandler.DeleteItem)
+					r.Post("/{itemId}/claim", eventItemHandler.ClaimItem)
+					r.Patch("/order", eventItemHandler.ReorderItems)
+					r.Post("/bulk", eventItemHandler.BulkItems)
+
+					r.Route("/{itemId}/attachments", func(r chi.Router) {
+						r.Get("/", attachmentHandler.GetItemAttachments)
+						r.Post("/", attachmentHandler.UploadItemAttachment)
+						r.Delete("/{attachmentId}", attachmentHandler.DeleteItemAttachment)
+					})
+				})
+
+				// Consolidated shopping list of the event's food items
+				r.Get("/shopping-list", eventItemHandler.GetShoppingList)
+				r.Put("/shopping-list/assignee", eventItemHandler.AssignShoppingList)
+
+				// Helper links for non-members
+				r.Route("/helper-links", func(r chi.Router) {
+					r.Get("/", helperLinkHandler.GetLinks)
+					r.Post("/", helperLinkHandler.CreateLink)
+					r.Delete("/{linkId}", helperLinkHandler.RevokeLink)
+				})
+
+				// Contributions towards the event's costs
+				r.Route("/contributions", func(r chi.Router) {
+					r.Get("/", contributionHandler.GetContributions)
+					r.Post("/", contributionHandler.RecordContribution)
+					r.Get("/progress", contributionHandler.GetProgress)
+					r.Put("/goal", contributionHandler.UpdateGoal)
 				})
 
 				// Event availability
 				r.Route("/availability", func(r chi.Router) {
-					r.Get("/", availabilityHandler.GetAvailability)
+					r.With(revalidate...).Get("/", availabilityHandler.GetAvailability)
+					r.Get("/summary", availabilityHandler.GetAvailabilitySummary)
 					r.Post("/", availabilityHandler.UpdateAvailability)
+					r.Get("/export.pdf", availabilityHandler.ExportPDF)
 				})
 			})
 		})
 	})
 
+	// Public keys other services check access tokens with
+	r.Get("/.well-known/jwks.json", authHandler.GetJWKS)
+
 	// Health check endpoint
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -186,12 +1015,128 @@ func main() {
 	})
 
 	// Start server
+	// Admin reports list every route, so routes nobody calls show up too
+	if usageCounter != nil {
+		routes, err := analytics.Routes(r)
+		if err != nil {
+			logger.Error("failed to list routes", "error", err)
+			os.Exit(1)
+		}
+		analyticsHandler.WithUsage(analytics.NewStore(db), routes)
+	}
+
 	addr := ":" + cfg.Server.Port
-	log.Printf("Starting server on %s", addr)
-	log.Printf("Health check available at http://localhost%s/healthz", addr)
-	log.Printf("API base URL: http://localhost%s/api", addr)
+	server := &http.Server{Addr: addr, Handler: r}
+	// Long polls answer as soon as shutdown starts rather than holding it up
+	server.RegisterOnShutdown(notificationHub.Close)
+	server.RegisterOnShutdown(streamHub.Close)
+	lifecycleManager.OnShutdown(lifecycle.PhaseHTTP, "http server", server.Shutdown)
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Without a load balancer in front, the API terminates TLS itself and
+	// redirects plain HTTP on a second port
+	scheme := "http"
+	var redirectServer *http.Server
+	if cfg.TLS.Enabled() {
+		tlsSetup, err := tlsserve.New(tlsserve.Options{
+			CertFile:         cfg.TLS.CertFile,
+			KeyFile:          cfg.TLS.KeyFile,
+			AutocertDomains:  cfg.TLS.AutocertDomains,
+			AutocertCacheDir: cfg.TLS.AutocertCacheDir,
+			AutocertEmail:    cfg.TLS.AutocertEmail,
+		}, cfg.Server.Port)
+		if err != nil {
+			logger.Error("invalid TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		scheme = "https"
+		server.TLSConfig = tlsSetup.TLSConfig
+		if cfg.TLS.HTTPPort != "" {
+			redirectServer = &http.Server{Addr: ":" + cfg.TLS.HTTPPort, Handler: tlsSetup.HTTPHandler, ReadHeaderTimeout: 10 * time.Second}
+			lifecycleManager.OnShutdown(lifecycle.PhaseHTTP, "http redirect server", redirectServer.Shutdown)
+		}
+	}
+
+	logger.Info("starting server",
+		"addr", addr,
+		"tls", cfg.TLS.Enabled(),
+		"health_check", scheme+"://localhost"+addr+"/healthz",
+		"api_base_url", scheme+"://localhost"+addr+"/api",
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		if server.TLSConfig != nil {
+			serverErr <- server.ListenAndServeTLS("", "")
+			return
+		}
+		serverErr <- server.ListenAndServe()
+	}()
+	if redirectServer != nil {
+		logger.Info("redirecting plain HTTP to HTTPS", "addr", redirectServer.Addr)
+		go func() {
+			serverErr <- redirectServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		logger.Error("server failed to start", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// A second signal kills the process as usual
+	logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := lifecycleManager.Shutdown(shutdownCtx); err != nil {
+		logger.Error("shutdown incomplete", "error", err)
+		cancel()
+		os.Exit(1)
+	}
+	logger.Info("shutdown complete")
+}
+
+// newAttachmentStorage builds the configured attachment backend
+func newAttachmentStorage(cfg config.AttachmentsConfig) (attachments.Storage, error) {
+	switch cfg.Backend {
+	case "s3":
+		return attachments.NewS3Storage(attachments.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	case "local", "":
+		return attachments.NewLocalStorage(cfg.LocalDir)
+	default:
+		return nil, fmt.Errorf("unknown attachments backend %q", cfg.Backend)
+	}
+}
+
+// newKeyRing signs tokens with the configured private key and accepts those
+// signed with the retired keys and, unless turned off, the shared secret
+func newKeyRing(cfg config.JWTConfig) (*auth.KeyRing, error) {
+	signing, err := auth.LoadPrivateKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	var retired []auth.Key
+	for _, path := range cfg.VerificationKeyFiles {
+		key, err := auth.LoadPublicKey(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		retired = append(retired, key)
+	}
+	if cfg.AcceptSecret {
+		retired = append(retired, auth.SecretKey(cfg.SecretKey))
 	}
+	return auth.NewKeyRing(signing, retired...)
 }
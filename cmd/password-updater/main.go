@@ -4,11 +4,11 @@ import (
 	"database/sql"
 	"log"
 
-	_ "github.com/lib/pq"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 func main() {
-	db, err := sql.Open("postgres", "user=postgres password=postgres host=localhost port=5433 dbname=bookwork sslmode=disable")
+	db, err := sql.Open("pgx", "user=postgres password=postgres host=localhost port=5433 dbname=bookwork sslmode=disable")
 	if err != nil {
 		log.Fatal(err)
 	}
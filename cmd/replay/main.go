@@ -0,0 +1,229 @@
+// Command replay re-issues captured requests against another instance,
+// typically staging, and compares the status codes with the captured ones.
+//
+// Captures are read from a running instance's /api/admin/captures endpoint or
+// from a file holding that endpoint's response (or a plain JSON array of
+// captures). Credentials were redacted at capture time, so requests are sent
+// with the -token given here instead. Only safe methods are replayed unless
+// -writes is set, and requests whose bodies were cut off are skipped.
+//
+//	go run ./cmd/replay -source https://api.example.com -source-token $ADMIN_JWT \
+//	    -target https://staging.example.com -token $STAGING_JWT -method GET
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/capture"
+)
+
+// skippedHeaders are set by the HTTP client or tied to the original connection
+var skippedHeaders = map[string]bool{
+	"Authorization":     true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Cookie":            true,
+	"Host":              true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Proto": true,
+	"X-Real-Ip":         true,
+	"X-Request-Id":      true,
+}
+
+func main() {
+	source := flag.String("source", "", "base URL of the instance to read captures from")
+	sourceToken := flag.String("source-token", "", "admin bearer token for -source")
+	file := flag.String("file", "", "read captures from this JSON file instead of -source")
+	target := flag.String("target", "", "base URL of the instance to replay against (required)")
+	token := flag.String("token", "", "bearer token sent with every replayed request")
+	ids := flag.String("id", "", "comma-separated capture IDs to replay (default: all)")
+	method := flag.String("method", "", "only replay captures with this method")
+	writes := flag.Bool("writes", false, "also replay POST, PUT, PATCH and DELETE requests")
+	delay := flag.Duration("delay", 100*time.Millisecond, "pause between requests")
+	dryRun := flag.Bool("dry-run", false, "list the requests without sending them")
+	flag.Parse()
+
+	if *target == "" || (*source == "") == (*file == "") {
+		fmt.Fprintln(os.Stderr, "usage: replay (-source URL -source-token TOKEN | -file PATH) -target URL [-token TOKEN]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if *source != "" && strings.TrimRight(*source, "/") == strings.TrimRight(*target, "/") {
+		log.Fatal("refusing to replay captures against the instance they were captured on")
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var exchanges []capture.Exchange
+	var err error
+	if *file != "" {
+		exchanges, err = readFile(*file)
+	} else {
+		exchanges, err = fetch(client, *source, *sourceToken)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+
+	// Captures are listed newest first; replay them in the order they happened
+	var replayed, matched, skipped, failed int
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		exchange := exchanges[i]
+		if len(wanted) > 0 && !wanted[exchange.ID.String()] {
+			continue
+		}
+		if *method != "" && !strings.EqualFold(exchange.Method, *method) {
+			continue
+		}
+		if reason := skipReason(exchange, *writes); reason != "" {
+			log.Printf("skip   %s %s (%s)", exchange.Method, exchange.Path, reason)
+			skipped++
+			continue
+		}
+		if *dryRun {
+			log.Printf("would  %s %s", exchange.Method, requestURI(exchange))
+			continue
+		}
+
+		status, err := replay(client, *target, *token, exchange)
+		replayed++
+		switch {
+		case err != nil:
+			log.Printf("error  %s %s: %v", exchange.Method, requestURI(exchange), err)
+			failed++
+		case status == exchange.Status:
+			log.Printf("match  %s %s -> %d", exchange.Method, requestURI(exchange), status)
+			matched++
+		default:
+			log.Printf("differ %s %s -> %d, captured %d (capture %s)", exchange.Method, requestURI(exchange), status, exchange.Status, exchange.ID)
+		}
+		time.Sleep(*delay)
+	}
+
+	log.Printf("replayed %d, matching status %d, differing %d, failed %d, skipped %d",
+		replayed, matched, replayed-matched-failed, failed, skipped)
+}
+
+// skipReason explains why an exchange cannot be replayed faithfully, if so
+func skipReason(exchange capture.Exchange, writes bool) string {
+	switch exchange.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !writes {
+			return "writes not enabled"
+		}
+	}
+	if exchange.Truncated && exchange.RequestBody != "" {
+		return "request body was cut off at capture"
+	}
+	if exchange.RequestBody == capture.Redacted {
+		return "request body was redacted"
+	}
+	return ""
+}
+
+func requestURI(exchange capture.Exchange) string {
+	if exchange.Query == "" {
+		return exchange.Path
+	}
+	return exchange.Path + "?" + exchange.Query
+}
+
+func replay(client *http.Client, target, token string, exchange capture.Exchange) (int, error) {
+	var body io.Reader
+	if exchange.RequestBody != "" {
+		body = strings.NewReader(exchange.RequestBody)
+	}
+	req, err := http.NewRequest(exchange.Method, strings.TrimRight(target, "/")+requestURI(exchange), body)
+	if err != nil {
+		return 0, err
+	}
+
+	for name, value := range exchange.RequestHeaders {
+		if skippedHeaders[http.CanonicalHeaderKey(name)] || value == capture.Redacted {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	// Lets the replayed request be found in the target's logs
+	req.Header.Set("X-Request-ID", "replay-"+exchange.ID.String())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// fetch reads the captures held by a running instance
+func fetch(client *http.Client, source, token string) ([]capture.Exchange, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(source, "/")+"/api/admin/captures?"+url.Values{"limit": {"500"}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch captures: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch captures: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch captures: %w", err)
+	}
+	return parse(data)
+}
+
+func readFile(path string) ([]capture.Exchange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+// parse accepts the captures endpoint's response or a plain array of captures
+func parse(data []byte) ([]capture.Exchange, error) {
+	var exchanges []capture.Exchange
+	if err := json.Unmarshal(data, &exchanges); err == nil {
+		return exchanges, nil
+	}
+
+	var response struct {
+		Data struct {
+			Captures []capture.Exchange `json:"captures"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse captures: %w", err)
+	}
+	return response.Data.Captures, nil
+}
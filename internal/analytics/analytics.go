@@ -0,0 +1,255 @@
+// Package analytics serves daily and weekly activity summaries.
+//
+// The summaries are materialized views (see migration 039) rather than live
+// aggregates over events and availability, which grow with every club's
+// history. Refresher brings them up to date on an interval; readers see the
+// previous contents until a refresh finishes, and the report says how old
+// they are.
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// Views are the materialized views behind the reports, in refresh order
+var Views = []string{"events_per_day", "rsvps_per_day", "active_members_per_week"}
+
+// EventsDay counts the events held on a day
+type EventsDay struct {
+	Date   string `json:"date"`
+	Events int    `json:"events"`
+}
+
+// RSVPsDay counts the RSVPs last given on a day, by answer
+type RSVPsDay struct {
+	Date        string `json:"date"`
+	Available   int    `json:"available"`
+	Maybe       int    `json:"maybe"`
+	Unavailable int    `json:"unavailable"`
+}
+
+// ActiveWeek counts the members who gave an RSVP during a week
+type ActiveWeek struct {
+	WeekStart string `json:"weekStart"` // Monday
+	Members   int    `json:"members"`
+}
+
+// Report holds the summaries for a date range. Days and weeks without
+// activity are included with zero counts.
+type Report struct {
+	From                 string       `json:"from"`
+	To                   string       `json:"to"`
+	RefreshedAt          *time.Time   `json:"refreshedAt"` // oldest refresh of the views read
+	EventsPerDay         []EventsDay  `json:"eventsPerDay"`
+	RSVPsPerDay          []RSVPsDay   `json:"rsvpsPerDay"`
+	ActiveMembersPerWeek []ActiveWeek `json:"activeMembersPerWeek"`
+
+	// EndpointUsage is only reported platform-wide, as requests are not counted per club
+	EndpointUsage []EndpointUsage `json:"endpointUsage,omitempty"`
+}
+
+// Store reads and refreshes the summary views
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// Report summarizes activity between from and to inclusive, for one club or,
+// with a nil clubID, across all clubs
+func (s *Store) Report(ctx context.Context, clubID *uuid.UUID, from, to time.Time) (*Report, error) {
+	report := &Report{From: timeutil.FormatDate(from), To: timeutil.FormatDate(to)}
+
+	events := map[string]EventsDay{}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT day, SUM(events) FROM events_per_day
+		WHERE day BETWEEN $1 AND $2 AND ($3::UUID IS NULL OR club_id = $3)
+		GROUP BY day`, from, to, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events per day: %w", err)
+	}
+	err = scanAll(rows, func() error {
+		var day time.Time
+		var d EventsDay
+		if err := rows.Scan(&day, &d.Events); err != nil {
+			return err
+		}
+		d.Date = timeutil.FormatDate(day)
+		events[d.Date] = d
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events per day: %w", err)
+	}
+
+	rsvps := map[string]RSVPsDay{}
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT day, SUM(available), SUM(maybe), SUM(unavailable) FROM rsvps_per_day
+		WHERE day BETWEEN $1 AND $2 AND ($3::UUID IS NULL OR club_id = $3)
+		GROUP BY day`, from, to, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query RSVPs per day: %w", err)
+	}
+	err = scanAll(rows, func() error {
+		var day time.Time
+		var d RSVPsDay
+		if err := rows.Scan(&day, &d.Available, &d.Maybe, &d.Unavailable); err != nil {
+			return err
+		}
+		d.Date = timeutil.FormatDate(day)
+		rsvps[d.Date] = d
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RSVPs per day: %w", err)
+	}
+
+	// Without a club, the NULL club_id row counts members of several clubs once
+	active := map[string]ActiveWeek{}
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT week, members FROM active_members_per_week
+		WHERE week BETWEEN DATE_TRUNC('week', $1::DATE)::DATE AND $2 AND club_id IS NOT DISTINCT FROM $3`, from, to, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active members per week: %w", err)
+	}
+	err = scanAll(rows, func() error {
+		var week time.Time
+		var w ActiveWeek
+		if err := rows.Scan(&week, &w.Members); err != nil {
+			return err
+		}
+		w.WeekStart = timeutil.FormatDate(week)
+		active[w.WeekStart] = w
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read active members per week: %w", err)
+	}
+
+	var refreshedAt sql.NullTime
+	if err := s.db.QueryRowContext(ctx, `SELECT MIN(refreshed_at) FROM analytics_refreshes`).Scan(&refreshedAt); err != nil {
+		return nil, fmt.Errorf("failed to query analytics refresh time: %w", err)
+	}
+	if refreshedAt.Valid {
+		report.RefreshedAt = &refreshedAt.Time
+	}
+
+	for _, day := range Days(from, to) {
+		date := timeutil.FormatDate(day)
+		e, ok := events[date]
+		if !ok {
+			e = EventsDay{Date: date}
+		}
+		r, ok := rsvps[date]
+		if !ok {
+			r = RSVPsDay{Date: date}
+		}
+		report.EventsPerDay = append(report.EventsPerDay, e)
+		report.RSVPsPerDay = append(report.RSVPsPerDay, r)
+	}
+	for _, week := range Weeks(from, to) {
+		start := timeutil.FormatDate(week)
+		w, ok := active[start]
+		if !ok {
+			w = ActiveWeek{WeekStart: start}
+		}
+		report.ActiveMembersPerWeek = append(report.ActiveMembersPerWeek, w)
+	}
+	return report, nil
+}
+
+// Refresh recomputes every view without blocking readers and records when
+func (s *Store) Refresh(ctx context.Context) error {
+	for _, view := range Views {
+		// The names come from Views, never from input
+		if _, err := s.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO analytics_refreshes (view_name, refreshed_at) VALUES ($1, CURRENT_TIMESTAMP)
+			ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at`, view)
+		if err != nil {
+			return fmt.Errorf("failed to record refresh of %s: %w", view, err)
+		}
+	}
+	return nil
+}
+
+// Days lists the days from from to to inclusive
+func Days(from, to time.Time) []time.Time {
+	days := []time.Time{}
+	for day := truncateDay(from); !day.After(truncateDay(to)); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// Weeks lists the Mondays starting the weeks that overlap from to to
+func Weeks(from, to time.Time) []time.Time {
+	start := truncateDay(from)
+	start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+
+	weeks := []time.Time{}
+	for week := start; !week.After(truncateDay(to)); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// scanAll calls scan for each row and closes rows
+func scanAll(rows *sql.Rows, scan func() error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Refresher periodically refreshes the summary views
+type Refresher struct {
+	store    *Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewRefresher(store *Store, interval time.Duration, logger *slog.Logger) *Refresher {
+	return &Refresher{store: store, interval: interval, logger: logger}
+}
+
+// Run refreshes immediately and then every interval until ctx is cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		started := time.Now()
+		if err := r.store.Refresh(ctx); err != nil {
+			r.logger.Error("error refreshing analytics views", "error", err)
+		} else {
+			r.logger.Info("refreshed analytics views", "duration_ms", time.Since(started).Milliseconds())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
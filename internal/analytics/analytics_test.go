@@ -0,0 +1,37 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"bookwork-api/internal/timeutil"
+)
+
+func TestDays(t *testing.T) {
+	days := Days(time.Date(2030, 2, 27, 15, 0, 0, 0, time.UTC), time.Date(2030, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	expected := []string{"2030-02-27", "2030-02-28", "2030-03-01", "2030-03-02"}
+	if len(days) != len(expected) {
+		t.Fatalf("Expected %d days, got %d", len(expected), len(days))
+	}
+	for i, day := range days {
+		if timeutil.FormatDate(day) != expected[i] {
+			t.Errorf("Day %d: expected %s, got %s", i, expected[i], timeutil.FormatDate(day))
+		}
+	}
+}
+
+func TestWeeksStartOnMonday(t *testing.T) {
+	// Sunday 3 March to Monday 11 March 2030
+	weeks := Weeks(time.Date(2030, 3, 3, 0, 0, 0, 0, time.UTC), time.Date(2030, 3, 11, 0, 0, 0, 0, time.UTC))
+
+	expected := []string{"2030-02-25", "2030-03-04", "2030-03-11"}
+	if len(weeks) != len(expected) {
+		t.Fatalf("Expected %d weeks, got %d", len(expected), len(weeks))
+	}
+	for i, week := range weeks {
+		if week.Weekday() != time.Monday || timeutil.FormatDate(week) != expected[i] {
+			t.Errorf("Week %d: expected Monday %s, got %s %s", i, expected[i], week.Weekday(), timeutil.FormatDate(week))
+		}
+	}
+}
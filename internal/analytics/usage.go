@@ -0,0 +1,278 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// unroutedRoute counts requests answered before routing or matching no route
+const unroutedRoute = "*"
+
+// EndpointUsage counts the requests to one route pattern and method over a
+// report's range, split by the feature-flag variants they were served with.
+// Only route patterns are kept, never paths, callers or query strings.
+type EndpointUsage struct {
+	Method       string `json:"method"`
+	Route        string `json:"route"`
+	Variant      string `json:"variant"` // flag=variant pairs joined by commas, empty without flags
+	Requests     int64  `json:"requests"`
+	ClientErrors int64  `json:"clientErrors"` // 4xx responses
+	ServerErrors int64  `json:"serverErrors"` // 5xx responses
+	LastDay      string `json:"lastDay,omitempty"`
+}
+
+// Route is a method and route pattern the router serves
+type Route struct {
+	Method string
+	Route  string
+}
+
+type usageKey struct {
+	day     string
+	method  string
+	route   string
+	variant string
+}
+
+type usageCounts struct {
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+}
+
+// UsageCounter counts requests per route pattern in memory and adds them to
+// endpoint_usage_daily every flush interval, so counting costs no database
+// round trip per request
+type UsageCounter struct {
+	db       *database.DB
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	pending map[usageKey]*usageCounts
+}
+
+func NewUsageCounter(db *database.DB, interval time.Duration, logger *slog.Logger) *UsageCounter {
+	return &UsageCounter{db: db, interval: interval, logger: logger, pending: make(map[usageKey]*usageCounts)}
+}
+
+// variantsKey holds the request's feature-flag variants in its context
+type variantsKey struct{}
+
+type variants struct {
+	mu    sync.Mutex
+	flags map[string]string
+}
+
+// TagVariant records that the request in ctx was served with the given
+// variant of a feature flag. It does nothing outside a counted request.
+func TagVariant(ctx context.Context, flag, variant string) {
+	v, ok := ctx.Value(variantsKey{}).(*variants)
+	if !ok {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.flags[flag] = variant
+}
+
+// String lists the variants as sorted flag=variant pairs
+func (v *variants) String() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	pairs := make([]string, 0, len(v.flags))
+	for flag, variant := range v.flags {
+		pairs = append(pairs, flag+"="+variant)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Middleware counts each request under the route pattern it matched
+func (c *UsageCounter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tagged := &variants{flags: map[string]string{}}
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), variantsKey{}, tagged)))
+
+		// The route pattern is only known once the router has matched the request
+		route := unroutedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		c.record(time.Now(), r.Method, route, tagged.String(), ww.Status())
+	})
+}
+
+// record adds one request to the pending counters
+func (c *UsageCounter) record(now time.Time, method, route, variant string, status int) {
+	key := usageKey{day: timeutil.FormatDate(now.UTC()), method: method, route: route, variant: variant}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.pending[key]
+	if !ok {
+		counts = &usageCounts{}
+		c.pending[key] = counts
+	}
+	counts.requests++
+	switch {
+	case status >= 500:
+		counts.serverErrors++
+	case status >= 400:
+		counts.clientErrors++
+	}
+}
+
+// Flush adds the pending counters to endpoint_usage_daily. Counters that
+// could not be written are kept for the next flush.
+func (c *UsageCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[usageKey]*usageCounts)
+	c.mu.Unlock()
+
+	query := `
+		INSERT INTO endpoint_usage_daily (day, method, route, variant, requests, client_errors, server_errors)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (day, method, route, variant) DO UPDATE SET
+			requests = endpoint_usage_daily.requests + EXCLUDED.requests,
+			client_errors = endpoint_usage_daily.client_errors + EXCLUDED.client_errors,
+			server_errors = endpoint_usage_daily.server_errors + EXCLUDED.server_errors`
+
+	var firstErr error
+	for key, counts := range pending {
+		_, err := c.db.ExecContext(ctx, query, key.day, key.method, key.route, key.variant,
+			counts.requests, counts.clientErrors, counts.serverErrors)
+		if err == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to write endpoint usage: %w", err)
+		}
+		c.restore(key, counts)
+	}
+	return firstErr
+}
+
+// restore merges unwritten counters back into the pending ones
+func (c *UsageCounter) restore(key usageKey, unwritten *usageCounts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.pending[key]
+	if !ok {
+		c.pending[key] = unwritten
+		return
+	}
+	counts.requests += unwritten.requests
+	counts.clientErrors += unwritten.clientErrors
+	counts.serverErrors += unwritten.serverErrors
+}
+
+// Run writes the pending counters every interval until ctx is cancelled, and
+// once more on the way out
+func (c *UsageCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.Flush(context.Background()); err != nil {
+				c.logger.Error("error writing endpoint usage", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				c.logger.Error("error writing endpoint usage", "error", err)
+			}
+		}
+	}
+}
+
+// EndpointUsage totals the usage written between from and to inclusive, most
+// used first. Routes the router serves that saw no requests are listed with
+// zero counts, as those are the surfaces that could change most freely.
+func (s *Store) EndpointUsage(ctx context.Context, from, to time.Time, routes []Route) ([]EndpointUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT method, route, variant, SUM(requests), SUM(client_errors), SUM(server_errors), MAX(day)
+		FROM endpoint_usage_daily
+		WHERE day BETWEEN $1 AND $2
+		GROUP BY method, route, variant`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query endpoint usage: %w", err)
+	}
+
+	usage := []EndpointUsage{}
+	seen := map[Route]bool{}
+	err = scanAll(rows, func() error {
+		var u EndpointUsage
+		var lastDay time.Time
+		if err := rows.Scan(&u.Method, &u.Route, &u.Variant, &u.Requests, &u.ClientErrors, &u.ServerErrors, &lastDay); err != nil {
+			return err
+		}
+		u.LastDay = timeutil.FormatDate(lastDay)
+		usage = append(usage, u)
+		seen[Route{Method: u.Method, Route: u.Route}] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoint usage: %w", err)
+	}
+
+	return mergeUnused(usage, seen, routes), nil
+}
+
+// mergeUnused adds the routes not in seen with zero counts and sorts the
+// usage by requests, then route and method
+func mergeUnused(usage []EndpointUsage, seen map[Route]bool, routes []Route) []EndpointUsage {
+	for _, route := range routes {
+		if !seen[route] {
+			usage = append(usage, EndpointUsage{Method: route.Method, Route: route.Route})
+			seen[route] = true
+		}
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Variant < b.Variant
+	})
+	return usage
+}
+
+// Routes lists the method and pattern of every route router serves, written
+// the way the middleware sees them, without a trailing slash
+func Routes(router chi.Routes) ([]Route, error) {
+	routes := []Route{}
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes = append(routes, Route{Method: method, Route: route})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+	return routes, nil
+}
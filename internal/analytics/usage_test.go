@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestUsageCounterCountsRoutePatterns(t *testing.T) {
+	counter := NewUsageCounter(nil, time.Minute, slog.Default())
+
+	router := chi.NewRouter()
+	router.Use(counter.Middleware)
+	router.Route("/clubs/{clubId}", func(r chi.Router) {
+		r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("new") != "" {
+				TagVariant(r.Context(), "events.starts_at", "cutover")
+			}
+		})
+		r.Post("/events", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+	})
+
+	for _, target := range []string{"/clubs/a/events", "/clubs/b/events", "/clubs/c/events?new=1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/clubs/a/events", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	day := timeutil.FormatDate(time.Now().UTC())
+	expected := map[usageKey]usageCounts{
+		{day: day, method: "GET", route: "/clubs/{clubId}/events"}:                                      {requests: 2},
+		{day: day, method: "GET", route: "/clubs/{clubId}/events", variant: "events.starts_at=cutover"}: {requests: 1},
+		{day: day, method: "POST", route: "/clubs/{clubId}/events"}:                                     {requests: 1, clientErrors: 1},
+		{day: day, method: "GET", route: unroutedRoute}:                                                 {requests: 1, clientErrors: 1},
+	}
+	if len(counter.pending) != len(expected) {
+		t.Errorf("Expected %d counters, got %d", len(expected), len(counter.pending))
+	}
+	for key, want := range expected {
+		got, ok := counter.pending[key]
+		if !ok || *got != want {
+			t.Errorf("%+v: expected %+v, got %+v", key, want, got)
+		}
+	}
+}
+
+func TestTagVariantOutsideCountedRequest(t *testing.T) {
+	// Handlers tag variants whether or not the counter is installed
+	TagVariant(httptest.NewRequest("GET", "/", nil).Context(), "flag", "on")
+}
+
+func TestEndpointUsageListsUnusedRoutes(t *testing.T) {
+	router := chi.NewRouter()
+	router.Route("/clubs/{clubId}", func(r chi.Router) {
+		r.Get("/events", func(http.ResponseWriter, *http.Request) {})
+		r.Delete("/", func(http.ResponseWriter, *http.Request) {})
+	})
+	routes, err := Routes(router)
+	if err != nil {
+		t.Fatalf("Failed to list routes: %v", err)
+	}
+
+	used := []EndpointUsage{{Method: "GET", Route: "/clubs/{clubId}/events", Requests: 5}}
+	usage := mergeUnused(used, map[Route]bool{{Method: "GET", Route: "/clubs/{clubId}/events"}: true}, routes)
+
+	if len(usage) != 2 {
+		t.Fatalf("Expected the used route and one unused route, got %+v", usage)
+	}
+	if usage[0].Requests != 5 || usage[1].Method != "DELETE" || usage[1].Route != "/clubs/{clubId}" || usage[1].Requests != 0 {
+		t.Errorf("Expected used routes first, then unused ones, got %+v", usage)
+	}
+}
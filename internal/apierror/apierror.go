@@ -0,0 +1,283 @@
+// Package apierror is the catalog of errors the API answers with.
+//
+// Every error response has the same shape: a stable code clients switch on, a
+// message for people, the HTTP status and, where they help, details such as the
+// fields that failed validation. Each code is listed here with the one status
+// it is sent with, so handlers and middleware name a code and leave the status
+// and the JSON to Write.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/timeutil"
+)
+
+// Code is the machine-readable identifier of an error
+type Code string
+
+// Codes shared by every endpoint
+const (
+	CodeValidation   Code = "VALIDATION_ERROR"
+	CodeUnauthorized Code = "UNAUTHORIZED"
+	CodeForbidden    Code = "FORBIDDEN"
+	CodeNotFound     Code = "NOT_FOUND"
+	CodeConflict     Code = "CONFLICT"
+	CodeInternal     Code = "INTERNAL_ERROR"
+)
+
+// Codes for request bodies that cannot be read
+const (
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRequestTooLarge      Code = "REQUEST_TOO_LARGE"
+	CodeJSONTooDeep          Code = "JSON_TOO_DEEP"
+	CodeJSONArrayTooLong     Code = "JSON_ARRAY_TOO_LONG"
+	CodeUnknownFields        Code = "UNKNOWN_FIELDS"
+)
+
+// Codes for writes the database refuses; see package dberrors
+const (
+	CodeAlreadyExists     Code = "ALREADY_EXISTS"
+	CodeOverlapsExisting  Code = "OVERLAPS_EXISTING"
+	CodeStillReferenced   Code = "STILL_REFERENCED"
+	CodeReferenceNotFound Code = "REFERENCE_NOT_FOUND"
+	CodeMissingValue      Code = "MISSING_VALUE"
+	CodeValueTooLong      Code = "VALUE_TOO_LONG"
+	CodeInvalidValue      Code = "INVALID_VALUE"
+)
+
+// Codes for signing in, registering and who may call what
+const (
+	CodeAccessDenied              Code = "ACCESS_DENIED"
+	CodeAgeRequirementNotMet      Code = "AGE_REQUIREMENT_NOT_MET"
+	CodeEmailNotVerified          Code = "EMAIL_NOT_VERIFIED"
+	CodeRegistrationRequired      Code = "REGISTRATION_REQUIRED"
+	CodeTermsNotAccepted          Code = "TERMS_NOT_ACCEPTED"
+	CodeTermsVersionMismatch      Code = "TERMS_VERSION_MISMATCH"
+	CodeTokenReused               Code = "TOKEN_REUSED"
+	CodeCaptchaFailed             Code = "CAPTCHA_FAILED"
+	CodeCaptchaUnavailable        Code = "CAPTCHA_UNAVAILABLE"
+	CodeInvalidOAuthState         Code = "INVALID_OAUTH_STATE"
+	CodeOAuthDenied               Code = "OAUTH_DENIED"
+	CodeOAuthNoEmail              Code = "OAUTH_NO_EMAIL"
+	CodeOAuthProviderError        Code = "OAUTH_PROVIDER_ERROR"
+	CodeOAuthLinkRequired         Code = "OAUTH_LINK_REQUIRED"
+	CodeOAuthIdentityTaken        Code = "OAUTH_IDENTITY_TAKEN"
+	CodeSandboxDisabled           Code = "SANDBOX_DISABLED"
+	CodeSandboxMismatch           Code = "SANDBOX_MISMATCH"
+	CodeInvalidSignature          Code = "INVALID_SIGNATURE"
+	CodeInvalidPublisherKey       Code = "INVALID_PUBLISHER_KEY"
+	CodeInvalidPublisherSignature Code = "INVALID_PUBLISHER_SIGNATURE"
+	CodeLinkExpired               Code = "LINK_EXPIRED"
+	CodeDuesUnpaid                Code = "DUES_UNPAID"
+	CodeTooManyAttempts           Code = "TOO_MANY_ATTEMPTS"
+	CodePublisherQuotaExceeded    Code = "PUBLISHER_QUOTA_EXCEEDED"
+
+	// CodeRateLimited keeps the spelling clients have always been sent
+	CodeRateLimited Code = "RateLimitExceeded"
+)
+
+// Codes for requests the current state of a resource rules out
+const (
+	CodeAdminLockout            Code = "ADMIN_LOCKOUT"
+	CodeAlreadyAssigned         Code = "ALREADY_ASSIGNED"
+	CodeAlreadyVerified         Code = "ALREADY_VERIFIED"
+	CodeClubDeleted             Code = "CLUB_DELETED"
+	CodeClubFull                Code = "CLUB_FULL"
+	CodeCorrectionPending       Code = "CORRECTION_PENDING"
+	CodeCurrencyInUse           Code = "CURRENCY_IN_USE"
+	CodeDuesNotConfigured       Code = "DUES_NOT_CONFIGURED"
+	CodeEventNotPublic          Code = "EVENT_NOT_PUBLIC"
+	CodeEventOver               Code = "EVENT_OVER"
+	CodeInvalidStatus           Code = "INVALID_STATUS"
+	CodeItemsChanged            Code = "ITEMS_CHANGED"
+	CodeLastTerm                Code = "LAST_TERM"
+	CodeNoChange                Code = "NO_CHANGE"
+	CodeNoContributionGoal      Code = "NO_CONTRIBUTION_GOAL"
+	CodePartnershipNotActive    Code = "PARTNERSHIP_NOT_ACTIVE"
+	CodePartnershipOpen         Code = "PARTNERSHIP_OPEN"
+	CodePollClosed              Code = "POLL_CLOSED"
+	CodeRecordGone              Code = "RECORD_GONE"
+	CodeTagTaken                Code = "TAG_TAKEN"
+	CodeTermTaken               Code = "TERM_TAKEN"
+	CodeVersionConflict         Code = "VERSION_CONFLICT"
+	CodeVocabularyFull          Code = "VOCABULARY_FULL"
+	CodeExchangeRateUnavailable Code = "EXCHANGE_RATE_UNAVAILABLE"
+	CodeNotApplied              Code = "NOT_APPLIED"
+)
+
+// Codes for live updates and per-club quotas
+const (
+	CodeStreamingUnavailable Code = "STREAMING_UNAVAILABLE"
+	CodeStreamingUnsupported Code = "STREAMING_UNSUPPORTED"
+	CodePollingUnavailable   Code = "POLLING_UNAVAILABLE"
+	CodeTooManyStreams       Code = "TOO_MANY_STREAMS"
+	CodeTooManyPolls         Code = "TOO_MANY_POLLS"
+	CodeTooManyMessages      Code = "TOO_MANY_MESSAGES"
+)
+
+// statuses is the HTTP status each code is sent with
+var statuses = map[Code]int{
+	CodeValidation:   http.StatusBadRequest,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodeInternal:     http.StatusInternalServerError,
+
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeRequestTooLarge:      http.StatusRequestEntityTooLarge,
+	CodeJSONTooDeep:          http.StatusBadRequest,
+	CodeJSONArrayTooLong:     http.StatusBadRequest,
+	CodeUnknownFields:        http.StatusUnprocessableEntity,
+
+	CodeAlreadyExists:     http.StatusConflict,
+	CodeOverlapsExisting:  http.StatusConflict,
+	CodeStillReferenced:   http.StatusConflict,
+	CodeReferenceNotFound: http.StatusUnprocessableEntity,
+	CodeMissingValue:      http.StatusUnprocessableEntity,
+	CodeValueTooLong:      http.StatusUnprocessableEntity,
+	CodeInvalidValue:      http.StatusUnprocessableEntity,
+
+	CodeAccessDenied:              http.StatusForbidden,
+	CodeAgeRequirementNotMet:      http.StatusForbidden,
+	CodeEmailNotVerified:          http.StatusForbidden,
+	CodeRegistrationRequired:      http.StatusForbidden,
+	CodeTermsNotAccepted:          http.StatusBadRequest,
+	CodeTermsVersionMismatch:      http.StatusBadRequest,
+	CodeTokenReused:               http.StatusUnauthorized,
+	CodeCaptchaFailed:             http.StatusBadRequest,
+	CodeCaptchaUnavailable:        http.StatusServiceUnavailable,
+	CodeInvalidOAuthState:         http.StatusBadRequest,
+	CodeOAuthDenied:               http.StatusUnauthorized,
+	CodeOAuthNoEmail:              http.StatusBadRequest,
+	CodeOAuthProviderError:        http.StatusBadGateway,
+	CodeOAuthLinkRequired:         http.StatusForbidden,
+	CodeOAuthIdentityTaken:        http.StatusConflict,
+	CodeSandboxDisabled:           http.StatusForbidden,
+	CodeSandboxMismatch:           http.StatusForbidden,
+	CodeInvalidSignature:          http.StatusBadRequest,
+	CodeInvalidPublisherKey:       http.StatusUnauthorized,
+	CodeInvalidPublisherSignature: http.StatusUnauthorized,
+	CodeLinkExpired:               http.StatusGone,
+	CodeDuesUnpaid:                http.StatusForbidden,
+	CodeTooManyAttempts:           http.StatusTooManyRequests,
+	CodePublisherQuotaExceeded:    http.StatusTooManyRequests,
+	CodeRateLimited:               http.StatusTooManyRequests,
+
+	CodeAdminLockout:            http.StatusConflict,
+	CodeAlreadyAssigned:         http.StatusConflict,
+	CodeAlreadyVerified:         http.StatusConflict,
+	CodeClubDeleted:             http.StatusConflict,
+	CodeClubFull:                http.StatusConflict,
+	CodeCorrectionPending:       http.StatusConflict,
+	CodeCurrencyInUse:           http.StatusConflict,
+	CodeDuesNotConfigured:       http.StatusConflict,
+	CodeEventNotPublic:          http.StatusConflict,
+	CodeEventOver:               http.StatusConflict,
+	CodeInvalidStatus:           http.StatusConflict,
+	CodeItemsChanged:            http.StatusConflict,
+	CodeLastTerm:                http.StatusConflict,
+	CodeNoChange:                http.StatusConflict,
+	CodeNoContributionGoal:      http.StatusConflict,
+	CodePartnershipNotActive:    http.StatusConflict,
+	CodePartnershipOpen:         http.StatusConflict,
+	CodePollClosed:              http.StatusConflict,
+	CodeRecordGone:              http.StatusConflict,
+	CodeTagTaken:                http.StatusConflict,
+	CodeTermTaken:               http.StatusConflict,
+	CodeVersionConflict:         http.StatusConflict,
+	CodeVocabularyFull:          http.StatusConflict,
+	CodeExchangeRateUnavailable: http.StatusUnprocessableEntity,
+	CodeNotApplied:              http.StatusFailedDependency,
+
+	CodeStreamingUnavailable: http.StatusServiceUnavailable,
+	CodeStreamingUnsupported: http.StatusInternalServerError,
+	CodePollingUnavailable:   http.StatusServiceUnavailable,
+	CodeTooManyStreams:       http.StatusTooManyRequests,
+	CodeTooManyPolls:         http.StatusTooManyRequests,
+	CodeTooManyMessages:      http.StatusTooManyRequests,
+}
+
+// Status returns the HTTP status code is sent with. Codes missing from the
+// catalog are a bug in the caller and are sent as 500s.
+func Status(code Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error response: what went wrong and how it is sent
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// New returns the error for code, with the status the catalog gives it
+func New(code Code, message string, details map[string]interface{}) *Error {
+	return &Error{Status: Status(code), Code: code, Message: message, Details: details}
+}
+
+// Validation rejects a request whose input is malformed; details name the
+// offending fields, as built by models.InvalidField and friends
+func Validation(message string, details map[string]interface{}) *Error {
+	return New(CodeValidation, message, details)
+}
+
+// Unauthorized rejects a request that is not signed in
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message, nil)
+}
+
+// Forbidden rejects a caller who may not do what they asked
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message, nil)
+}
+
+// NotFound reports that the resource asked for does not exist, or is not the
+// caller's to see
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message, nil)
+}
+
+// Conflict refuses a request the resource's current state rules out
+func Conflict(message string) *Error {
+	return New(CodeConflict, message, nil)
+}
+
+// Internal reports a failure that is the server's, not the client's
+func Internal(message string) *Error {
+	return New(CodeInternal, message, nil)
+}
+
+// Write sends err as the response, stamped with now and the request's ID
+func Write(w http.ResponseWriter, now time.Time, err *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+
+	response := &models.FrontendErrorResponse{
+		Error:      string(err.Code),
+		Code:       string(err.Code),
+		Message:    err.Message,
+		StatusCode: err.Status,
+		Timestamp:  timeutil.FormatTimestamp(now),
+		RequestID:  w.Header().Get(logging.RequestIDHeader),
+	}
+	// A nil map must stay a nil interface for details to be omitted
+	if err.Details != nil {
+		response.Details = err.Details
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
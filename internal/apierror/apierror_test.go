@@ -0,0 +1,70 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		code   Code
+		status int
+	}{
+		{CodeValidation, http.StatusBadRequest},
+		{CodeNotFound, http.StatusNotFound},
+		{CodeVersionConflict, http.StatusConflict},
+		{CodeUnknownFields, http.StatusUnprocessableEntity},
+		{CodeRateLimited, http.StatusTooManyRequests},
+		{"NOT_IN_THE_CATALOG", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := Status(tt.code); got != tt.status {
+			t.Errorf("Status(%s) = %d, want %d", tt.code, got, tt.status)
+		}
+	}
+
+	for code, status := range statuses {
+		if status < 400 || status > 599 {
+			t.Errorf("%s has status %d, want an error status", code, status)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	now := time.Date(2030, 1, 15, 19, 30, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	w.Header().Set(logging.RequestIDHeader, "req-1")
+	Write(w, now, Validation("Invalid club ID", models.InvalidID("clubId")))
+
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a 400 JSON response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var response models.FrontendErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != "VALIDATION_ERROR" || response.Code != "VALIDATION_ERROR" || response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected error fields: %+v", response)
+	}
+	if response.Message != "Invalid club ID" || response.RequestID != "req-1" || response.Timestamp != "2030-01-15T19:30:00Z" {
+		t.Errorf("Unexpected message, request ID or timestamp: %+v", response)
+	}
+	if response.Details == nil {
+		t.Error("Expected details naming the invalid field")
+	}
+
+	w = httptest.NewRecorder()
+	Write(w, now, NotFound("Event not found"))
+	var body map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&body)
+	if _, ok := body["details"]; ok || w.Code != http.StatusNotFound {
+		t.Errorf("Expected a 404 without details, got %d: %v", w.Code, body)
+	}
+}
@@ -0,0 +1,95 @@
+// Package archive moves past events into yearly cold-storage partitions.
+//
+// Events dated before the start of the year AfterYears ago are moved, with
+// their availability, from the hot tables into events_archive and
+// availability_archive, which are partitioned by year. Events with
+// contribution records stay in the hot tables. Yearly partitions older
+// than DetachAfterYears are detached into standalone tables so operators can
+// dump or drop them without touching live data.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/timeutil"
+)
+
+// Result counts the work done by one archive run
+type Result struct {
+	Events     int
+	Detached   int
+	Cutoff     time.Time
+	DetachYear int
+}
+
+// Archiver periodically archives old events and detaches old partitions
+type Archiver struct {
+	db               *database.DB
+	afterYears       int
+	detachAfterYears int
+	interval         time.Duration
+	logger           *slog.Logger
+}
+
+// NewArchiver archives events older than afterYears whole years; with
+// detachAfterYears of zero, archive partitions are never detached
+func NewArchiver(db *database.DB, afterYears, detachAfterYears int, interval time.Duration, logger *slog.Logger) *Archiver {
+	return &Archiver{
+		db:               db,
+		afterYears:       afterYears,
+		detachAfterYears: detachAfterYears,
+		interval:         interval,
+		logger:           logger,
+	}
+}
+
+// Cutoff returns the first day of the oldest year kept in the hot tables
+func Cutoff(now time.Time, afterYears int) time.Time {
+	return time.Date(now.Year()-afterYears, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Archive moves events dated before the cutoff into the archive, then detaches expired partitions
+func (a *Archiver) Archive(ctx context.Context, now time.Time) (Result, error) {
+	result := Result{Cutoff: Cutoff(now, a.afterYears)}
+
+	err := a.db.QueryRowContext(ctx, `SELECT archive_events_before($1)`, result.Cutoff).Scan(&result.Events)
+	if err != nil {
+		return result, fmt.Errorf("failed to archive events: %w", err)
+	}
+
+	if a.detachAfterYears > 0 {
+		result.DetachYear = now.Year() - a.detachAfterYears
+		err := a.db.QueryRowContext(ctx, `SELECT detach_event_archive_partitions($1)`, result.DetachYear).Scan(&result.Detached)
+		if err != nil {
+			return result, fmt.Errorf("failed to detach archive partitions: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Run archives immediately and then every interval until ctx is cancelled
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := a.Archive(ctx, time.Now())
+		if err != nil {
+			a.logger.Error("error archiving events", "error", err)
+		} else if result.Events > 0 || result.Detached > 0 {
+			a.logger.Info("archived past events",
+				"events", result.Events, "cutoff", timeutil.FormatDate(result.Cutoff), "detached_partitions", result.Detached)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
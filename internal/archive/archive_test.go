@@ -0,0 +1,25 @@
+package archive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCutoff(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		afterYears int
+		expected   string
+	}{
+		{0, "2026-01-01"},
+		{1, "2025-01-01"},
+		{2, "2024-01-01"},
+	}
+
+	for _, tt := range tests {
+		if cutoff := Cutoff(now, tt.afterYears).Format("2006-01-02"); cutoff != tt.expected {
+			t.Errorf("Cutoff with %d years: expected %s, got %s", tt.afterYears, tt.expected, cutoff)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package attachments stores uploaded files for event items and club resources.
+//
+// File contents live in a Storage backend (local disk or an S3-compatible
+// bucket); the database only keeps metadata and the storage key. Uploads are
+// validated by size and by their sniffed content type, never by the type the
+// client claims.
+package attachments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is returned when no object exists under a key
+var ErrNotFound = errors.New("attachment not found in storage")
+
+// ErrTypeNotAllowed is returned when an upload's content type is not on the allow list
+var ErrTypeNotAllowed = errors.New("attachment type not allowed")
+
+// Storage keeps attachment contents under opaque keys such as "clubs/<id>/<id>"
+type Storage interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DefaultAllowedTypes are the content types accepted when none are configured:
+// documents such as discussion guides, and images such as venue maps
+var DefaultAllowedTypes = []string{
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"text/plain",
+}
+
+// DetectType sniffs the content type of an upload and checks it against allowed
+func DetectType(content []byte, allowed []string) (string, error) {
+	contentType := http.DetectContentType(content)
+	// Drop parameters such as "; charset=utf-8"
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+
+	for _, t := range allowed {
+		if strings.EqualFold(strings.TrimSpace(t), contentType) {
+			return contentType, nil
+		}
+	}
+	return contentType, ErrTypeNotAllowed
+}
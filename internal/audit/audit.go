@@ -0,0 +1,144 @@
+// Package audit records who changed what through the API.
+//
+// Middleware writes one entry for every successful POST, PUT, PATCH or DELETE
+// with the actor, route, client IP and status. Handlers add the entity and a
+// field-level diff with Describe where they have them; otherwise the entity is
+// taken from the last ID parameter in the route (e.g. {itemId} -> "item").
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// redacted replaces the values of secret-looking fields in diffs
+const redacted = "[redacted]"
+
+// FieldChange is one field's value before and after a change. From is null for
+// new values and To is null for removed ones.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Changes maps field names to their changes
+type Changes map[string]FieldChange
+
+// Entry is one recorded change
+type Entry struct {
+	ID         int64      `json:"id"`
+	ActorID    *uuid.UUID `json:"actorId"`
+	Method     string     `json:"method"`
+	Route      string     `json:"route"`
+	Status     int        `json:"status"`
+	EntityType string     `json:"entityType,omitempty"`
+	EntityID   string     `json:"entityId,omitempty"`
+	Changes    Changes    `json:"changes,omitempty"`
+	IP         string     `json:"ip"`
+	RequestID  string     `json:"requestId,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// pending collects what handlers learn about a request while it runs
+type pending struct {
+	actor      *uuid.UUID
+	described  bool
+	entityType string
+	entityID   string
+	changes    Changes
+}
+
+type contextKey struct{}
+
+func withPending(ctx context.Context, p *pending) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+func pendingFromContext(ctx context.Context) *pending {
+	p, _ := ctx.Value(contextKey{}).(*pending)
+	return p
+}
+
+// SetActor attributes the request's audit entry to userID. It is a no-op
+// outside the audit middleware.
+func SetActor(ctx context.Context, userID uuid.UUID) {
+	if p := pendingFromContext(ctx); p != nil {
+		p.actor = &userID
+	}
+}
+
+// Describe names the entity the request changed and what changed about it.
+// changes may be nil, e.g. for deletions. It is a no-op outside the audit middleware.
+func Describe(ctx context.Context, entityType, entityID string, changes Changes) {
+	if p := pendingFromContext(ctx); p != nil {
+		p.described = true
+		p.entityType = entityType
+		p.entityID = entityID
+		p.changes = changes
+	}
+}
+
+// Diff compares the JSON forms of before and after and returns the fields
+// that differ. Either side may be nil: Diff(nil, req) lists the fields a
+// request sets and Diff(old, nil) the fields of something deleted.
+func Diff(before, after interface{}) Changes {
+	from, to := toFields(before), toFields(after)
+
+	changes := Changes{}
+	for key, value := range from {
+		if other, ok := to[key]; !ok || !reflect.DeepEqual(value, other) {
+			changes[key] = FieldChange{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok {
+			changes[key] = FieldChange{To: value}
+		}
+	}
+
+	for key, change := range changes {
+		if isSecret(key) {
+			if change.From != nil {
+				change.From = redacted
+			}
+			if change.To != nil {
+				change.To = redacted
+			}
+			changes[key] = change
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func toFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	// Non-objects (and null pointers) have no fields to compare
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func isSecret(field string) bool {
+	field = strings.ToLower(field)
+	for _, word := range []string{"password", "token", "secret"} {
+		if strings.Contains(field, word) {
+			return true
+		}
+	}
+	return false
+}
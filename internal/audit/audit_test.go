@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type recordedEntries struct {
+	entries []Entry
+}
+
+func (r *recordedEntries) Record(ctx context.Context, entry Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestDiff(t *testing.T) {
+	type settings struct {
+		Name     string  `json:"name"`
+		Color    *string `json:"color,omitempty"`
+		Password string  `json:"password,omitempty"`
+	}
+	red := "#ff0000"
+
+	changes := Diff(settings{Name: "Readers"}, settings{Name: "Book Worms", Color: &red, Password: "hunter2"})
+	expected := Changes{
+		"name":     {From: "Readers", To: "Book Worms"},
+		"color":    {To: red},
+		"password": {To: redacted},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+	for key, change := range expected {
+		if changes[key] != change {
+			t.Errorf("Field %s: expected %+v, got %+v", key, change, changes[key])
+		}
+	}
+
+	if changes := Diff(settings{Name: "Same"}, settings{Name: "Same"}); changes != nil {
+		t.Errorf("Expected no changes for equal values, got %+v", changes)
+	}
+
+	deleted := Diff(settings{Name: "Gone"}, nil)
+	if deleted["name"] != (FieldChange{From: "Gone"}) {
+		t.Errorf("Expected deleted field to have only a from value, got %+v", deleted)
+	}
+}
+
+func TestMiddlewareRecordsSuccessfulMutations(t *testing.T) {
+	recorder := &recordedEntries{}
+	actorID := uuid.New()
+
+	router := chi.NewRouter()
+	router.Use(Middleware(recorder))
+	router.Get("/clubs/{clubId}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Put("/clubs/{clubId}/members/{memberId}", func(w http.ResponseWriter, r *http.Request) {
+		SetActor(r.Context(), actorID)
+	})
+	router.Put("/clubs/{clubId}/settings", func(w http.ResponseWriter, r *http.Request) {
+		Describe(r.Context(), "club", chi.URLParam(r, "clubId"), Changes{"youthMode": {From: false, To: true}})
+	})
+	router.Delete("/clubs/{clubId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/clubs/c1", nil),
+		httptest.NewRequest(http.MethodPut, "/clubs/c1/members/m1", nil),
+		httptest.NewRequest(http.MethodPut, "/clubs/c1/settings", nil),
+		httptest.NewRequest(http.MethodDelete, "/clubs/c1", nil),
+	} {
+		req.RemoteAddr = "203.0.113.7:5000"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(recorder.entries) != 2 {
+		t.Fatalf("Expected 2 entries (reads and rejected requests skipped), got %+v", recorder.entries)
+	}
+
+	member := recorder.entries[0]
+	if member.Route != "/clubs/{clubId}/members/{memberId}" || member.EntityType != "member" || member.EntityID != "m1" {
+		t.Errorf("Expected the entity inferred from the route, got %+v", member)
+	}
+	if member.ActorID == nil || *member.ActorID != actorID {
+		t.Errorf("Expected actor %s, got %v", actorID, member.ActorID)
+	}
+	if member.IP != "203.0.113.7" || member.Status != http.StatusOK {
+		t.Errorf("Unexpected IP or status: %+v", member)
+	}
+
+	settings := recorder.entries[1]
+	if settings.EntityType != "club" || settings.EntityID != "c1" || settings.Changes["youthMode"].To != true {
+		t.Errorf("Expected the handler's description, got %+v", settings)
+	}
+	if settings.ActorID != nil {
+		t.Errorf("Expected no actor for an anonymous request, got %v", settings.ActorID)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{"item": "item", "joinRequest": "join_request", "attachment": "attachment"}
+	for input, expected := range tests {
+		if got := snakeCase(input); got != expected {
+			t.Errorf("snakeCase(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// Filter narrows List results; zero fields match everything
+type Filter struct {
+	ActorID    *uuid.UUID
+	EntityType string
+	EntityID   string
+	Method     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Log persists audit entries to the audit_log table
+type Log struct {
+	db *database.DB
+}
+
+func NewLog(db *database.DB) *Log {
+	return &Log{db: db}
+}
+
+// Record stores an entry
+func (l *Log) Record(ctx context.Context, entry Entry) error {
+	var changes interface{}
+	if len(entry.Changes) > 0 {
+		data, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		changes = data
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, method, route, status, entity_type, entity_id, changes, ip_address, request_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, NULLIF($9, ''))`
+
+	_, err := l.db.ExecContext(ctx, query,
+		entry.ActorID, entry.Method, entry.Route, entry.Status,
+		entry.EntityType, entry.EntityID, changes, entry.IP, entry.RequestID,
+	)
+	return err
+}
+
+// List returns matching entries, newest first
+func (l *Log) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	query := `
+		SELECT id, actor_id, method, route, status, COALESCE(entity_type, ''), COALESCE(entity_id, ''),
+		       changes, COALESCE(ip_address, ''), COALESCE(request_id, ''), created_at
+		FROM audit_log
+		WHERE 1 = 1`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if filter.ActorID != nil {
+		argCount++
+		query += ` AND actor_id = $` + strconv.Itoa(argCount)
+		args = append(args, *filter.ActorID)
+	}
+
+	if filter.EntityType != "" {
+		argCount++
+		query += ` AND entity_type = $` + strconv.Itoa(argCount)
+		args = append(args, filter.EntityType)
+	}
+
+	if filter.EntityID != "" {
+		argCount++
+		query += ` AND entity_id = $` + strconv.Itoa(argCount)
+		args = append(args, filter.EntityID)
+	}
+
+	if filter.Method != "" {
+		argCount++
+		query += ` AND method = $` + strconv.Itoa(argCount)
+		args = append(args, strings.ToUpper(filter.Method))
+	}
+
+	if filter.From != nil {
+		argCount++
+		query += ` AND created_at >= $` + strconv.Itoa(argCount)
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		argCount++
+		query += ` AND created_at < $` + strconv.Itoa(argCount)
+		args = append(args, *filter.To)
+	}
+
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(argCount+1) + ` OFFSET $` + strconv.Itoa(argCount+2)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var actorID uuid.NullUUID
+		var changes []byte
+
+		err := rows.Scan(
+			&entry.ID, &actorID, &entry.Method, &entry.Route, &entry.Status, &entry.EntityType,
+			&entry.EntityID, &changes, &entry.IP, &entry.RequestID, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if actorID.Valid {
+			entry.ActorID = &actorID.UUID
+		}
+		if len(changes) > 0 {
+			if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Recorder stores audit entries; *Log is the production implementation
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+// Middleware records every successful mutating request once it completes.
+// Rejected requests change nothing and are left to the request log.
+func Middleware(recorder Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p := &pending{}
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(withPending(r.Context(), p)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusBadRequest {
+				return
+			}
+
+			entry := Entry{
+				ActorID:    p.actor,
+				Method:     r.Method,
+				Status:     status,
+				EntityType: p.entityType,
+				EntityID:   p.entityID,
+				Changes:    p.changes,
+				IP:         middleware.ClientIP(r),
+				RequestID:  logging.RequestIDFromContext(r.Context()),
+			}
+
+			// Routes are recorded as patterns: paths can carry signed-link tokens
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				entry.Route = rctx.RoutePattern()
+				if !p.described {
+					entry.EntityType, entry.EntityID = entityFromRoute(rctx)
+				}
+			}
+			if entry.Route == "" {
+				entry.Route = r.URL.Path
+			}
+
+			// The response is already written, so a failed write is only logged
+			if err := recorder.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+				logging.FromContext(r.Context()).Error("error recording audit entry", "error", err)
+			}
+		})
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// entityFromRoute takes the innermost "...Id" parameter, e.g. {itemId} -> ("item", value)
+func entityFromRoute(rctx *chi.Context) (string, string) {
+	keys, values := rctx.URLParams.Keys, rctx.URLParams.Values
+	for i := len(keys) - 1; i >= 0; i-- {
+		if strings.HasSuffix(keys[i], "Id") && len(keys[i]) > 2 && values[i] != "" {
+			return snakeCase(strings.TrimSuffix(keys[i], "Id")), values[i]
+		}
+	}
+	return "", ""
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
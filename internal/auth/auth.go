@@ -5,11 +5,13 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/logging"
 	"bookwork-api/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,26 +19,46 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// RefreshTokenTTL is how long a refresh token stays valid
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// SandboxHeader is set on responses to requests made by sandbox accounts
+const SandboxHeader = "X-Sandbox"
+
 type Service struct {
-	secretKey []byte
-	issuer    string
+	keys   *KeyRing
+	issuer string
 }
 
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
-	Type   string    `json:"type"` // "access" or "refresh"
+	UserID  uuid.UUID `json:"user_id"`
+	Email   string    `json:"email"`
+	Role    string    `json:"role"`
+	Type    string    `json:"type"`              // "access" or "refresh"
+	Sandbox bool      `json:"sandbox,omitempty"` // throwaway sandbox account
 	jwt.RegisteredClaims
 }
 
+// NewService signs tokens with the shared secret (HS256)
 func NewService(secretKey, issuer string) *Service {
+	keys, _ := NewKeyRing(SecretKey(secretKey))
 	return &Service{
-		secretKey: []byte(secretKey),
-		issuer:    issuer,
+		keys:   keys,
+		issuer: issuer,
 	}
 }
 
+// WithKeyRing signs and checks tokens with the keys of ring instead of the shared secret
+func (s *Service) WithKeyRing(ring *KeyRing) *Service {
+	s.keys = ring
+	return s
+}
+
+// KeyRing returns the keys tokens are signed and checked with
+func (s *Service) KeyRing() *KeyRing {
+	return s.keys
+}
+
 func (s *Service) HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -52,32 +74,37 @@ func (s *Service) VerifyPassword(hashedPassword, password string) bool {
 
 func (s *Service) GenerateTokens(user *models.User) (*models.TokenResponse, error) {
 	// Generate access token (30 minutes)
-	accessToken, err := s.generateToken(user, "access", 30*time.Minute)
+	accessToken, err := s.generateToken(user, "access", 30*time.Minute, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	// Generate refresh token (7 days)
-	refreshToken, err := s.generateToken(user, "refresh", 7*24*time.Hour)
+	// Generate refresh token (7 days), identified by its token ID so it can be
+	// rotated and revoked on its own
+	refreshTokenID := uuid.New()
+	refreshToken, err := s.generateToken(user, "refresh", RefreshTokenTTL, refreshTokenID.String())
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
 	return &models.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    1800, // 30 minutes in seconds
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		RefreshTokenID: refreshTokenID,
+		ExpiresIn:      1800, // 30 minutes in seconds
 	}, nil
 }
 
-func (s *Service) generateToken(user *models.User, tokenType string, duration time.Duration) (string, error) {
+func (s *Service) generateToken(user *models.User, tokenType string, duration time.Duration, tokenID string) (string, error) {
 	now := time.Now()
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
-		Type:   tokenType,
+		UserID:  user.ID,
+		Email:   user.Email,
+		Role:    user.Role,
+		Type:    tokenType,
+		Sandbox: user.IsSandbox,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			Issuer:    s.issuer,
 			Subject:   user.ID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -86,17 +113,11 @@ func (s *Service) generateToken(user *models.User, tokenType string, duration ti
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	return s.keys.sign(claims)
 }
 
 func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keys.verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -122,61 +143,104 @@ func (s *Service) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			s.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing authorization header", nil)
+			apierror.Write(w, time.Now(), apierror.Unauthorized("Missing authorization header"))
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			s.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid authorization header format", nil)
+			apierror.Write(w, time.Now(), apierror.Unauthorized("Invalid authorization header format"))
 			return
 		}
 
 		claims, err := s.ValidateToken(parts[1])
 		if err != nil {
-			log.Printf("Token validation failed: %v", err)
-			s.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid or expired token", nil)
+			logging.FromContext(r.Context()).Warn("token validation failed", "error", err)
+			apierror.Write(w, time.Now(), apierror.Unauthorized("Invalid or expired token"))
 			return
 		}
 
 		if claims.Type != "access" {
-			s.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token type", nil)
+			apierror.Write(w, time.Now(), apierror.Unauthorized("Invalid token type"))
 			return
 		}
 
-		// Add user context to request
-		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-		ctx = context.WithValue(ctx, "user_email", claims.Email)
-		ctx = context.WithValue(ctx, "user_role", claims.Role)
+		// Add the caller to the request
+		ctx := NewContext(r.Context(), claims.Principal())
+
+		// Clearly mark every response made on behalf of a sandbox account
+		if claims.Sandbox {
+			w.Header().Set(SandboxHeader, "true")
+		}
+
+		// Tag the request logger so handler logs can be traced back to the user
+		ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("user_id", claims.UserID.String()))
+		audit.SetActor(ctx, claims.UserID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (s *Service) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+// RequireRole only lets through users whose platform role is one of roles.
+// It must run after AuthMiddleware.
+func (s *Service) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok {
+				apierror.Write(w, time.Now(), apierror.Unauthorized("User not found in context"))
+				return
+			}
+
+			for _, allowed := range roles {
+				if principal.Role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			apierror.Write(w, time.Now(), apierror.Forbidden("Insufficient permissions"))
+		})
+	}
+}
+
+// Principal is the authenticated caller, as stated by their access token
+type Principal struct {
+	UserID  uuid.UUID
+	Email   string
+	Role    string // platform role, e.g. "admin"
+	Sandbox bool   // throwaway sandbox account
+}
+
+// Principal returns the caller the claims describe
+func (c *Claims) Principal() Principal {
+	return Principal{UserID: c.UserID, Email: c.Email, Role: c.Role, Sandbox: c.Sandbox}
+}
+
+type principalKey struct{}
 
-	// Create frontend-compatible error response
-	jsonResponse := fmt.Sprintf(`{"error":"%s","message":"%s","statusCode":%d,"timestamp":"%s"}`,
-		code, message, statusCode, time.Now().UTC().Format(time.RFC3339))
+// NewContext returns a copy of ctx carrying the caller
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
 
-	w.Write([]byte(jsonResponse))
+// FromContext returns the caller stored by AuthMiddleware
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
 }
 
-// Helper functions to extract user info from context
+// GetUserIDFromContext returns the caller's user ID
 func GetUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
-	userID, ok := ctx.Value("user_id").(uuid.UUID)
+	principal, ok := FromContext(ctx)
 	if !ok {
 		return uuid.Nil, fmt.Errorf("user ID not found in context")
 	}
-	return userID, nil
+	return principal.UserID, nil
 }
 
-func GetUserRoleFromContext(ctx context.Context) (string, error) {
-	role, ok := ctx.Value("user_role").(string)
-	if !ok {
-		return "", fmt.Errorf("user role not found in context")
-	}
-	return role, nil
+// IsSandboxFromContext reports whether the caller is a sandbox account
+func IsSandboxFromContext(ctx context.Context) bool {
+	principal, _ := FromContext(ctx)
+	return principal.Sandbox
 }
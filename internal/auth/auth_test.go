@@ -2,6 +2,9 @@ package auth
 
 import (
 	"bookwork-api/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -55,6 +58,32 @@ func TestJWTTokenGeneration(t *testing.T) {
 	}
 }
 
+func TestRefreshTokenHasUniqueTokenID(t *testing.T) {
+	service := NewService("test-secret", "test-issuer")
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Role: "member"}
+
+	first, err := service.GenerateTokens(user)
+	if err != nil {
+		t.Fatalf("Failed to generate tokens: %v", err)
+	}
+	second, err := service.GenerateTokens(user)
+	if err != nil {
+		t.Fatalf("Failed to generate tokens: %v", err)
+	}
+
+	claims, err := service.ValidateToken(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to validate refresh token: %v", err)
+	}
+	if claims.Type != "refresh" || claims.ID != first.RefreshTokenID.String() {
+		t.Errorf("Expected refresh token with ID %s, got type %q and ID %q", first.RefreshTokenID, claims.Type, claims.ID)
+	}
+
+	if first.RefreshTokenID == second.RefreshTokenID || first.RefreshToken == second.RefreshToken {
+		t.Error("Expected each refresh token to get its own token ID")
+	}
+}
+
 func TestJWTTokenValidation(t *testing.T) {
 	service := NewService("test-secret", "test-issuer")
 
@@ -142,3 +171,96 @@ func TestVerifyPassword(t *testing.T) {
 		t.Error("Password verification should fail for empty password")
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	service := NewService("test-secret", "test-issuer")
+	handler := service.RequireRole("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		role     interface{}
+		expected int
+	}{
+		{"admin allowed", "admin", http.StatusOK},
+		{"member forbidden", "member", http.StatusForbidden},
+		{"missing role", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.role != nil {
+				req = req.WithContext(NewContext(req.Context(), Principal{Role: tt.role.(string)}))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareMarksSandboxResponses(t *testing.T) {
+	service := NewService("test-secret", "test-issuer")
+
+	var sawSandbox bool
+	handler := service.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawSandbox = IsSandboxFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, sandbox := range []bool{true, false} {
+		user := &models.User{ID: uuid.New(), Email: "dev@example.com", Role: "member", IsSandbox: sandbox}
+		tokens, err := service.GenerateTokens(user)
+		if err != nil {
+			t.Fatalf("Failed to generate tokens: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if sawSandbox != sandbox {
+			t.Errorf("Expected sandbox %t in context, got %t", sandbox, sawSandbox)
+		}
+		if marked := w.Header().Get(SandboxHeader) == "true"; marked != sandbox {
+			t.Errorf("Expected sandbox header %t, got %t", sandbox, marked)
+		}
+	}
+}
+
+func TestAuthMiddlewareStoresPrincipal(t *testing.T) {
+	service := NewService("test-secret", "test-issuer")
+	user := &models.User{ID: uuid.New(), Email: "admin@example.com", Role: "admin"}
+	tokens, err := service.GenerateTokens(user)
+	if err != nil {
+		t.Fatalf("Failed to generate tokens: %v", err)
+	}
+
+	var principal Principal
+	var found bool
+	handler := service.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, found = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := Principal{UserID: user.ID, Email: user.Email, Role: "admin"}
+	if !found || principal != want {
+		t.Errorf("Expected %+v in context, got %+v (found %t)", want, principal, found)
+	}
+
+	if _, ok := FromContext(context.WithValue(context.Background(), "user_id", user.ID)); ok {
+		t.Error("Expected a raw string key not to count as a principal")
+	}
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA key accepted for signing tokens
+const minRSABits = 2048
+
+// Key is a key tokens are signed or checked with. Asymmetric keys are named by
+// their RFC 7638 thumbprint, which tokens carry in their kid header; the
+// shared secret has no name, as tokens signed with it never carried one.
+type Key struct {
+	ID      string
+	method  jwt.SigningMethod
+	signing interface{} // nil for keys that only check tokens
+	public  crypto.PublicKey
+}
+
+// Algorithm is the JWS algorithm of the key: HS256, RS256 or EdDSA
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// SecretKey is the HS256 shared secret
+func SecretKey(secret string) Key {
+	return Key{method: jwt.SigningMethodHS256, signing: []byte(secret), public: []byte(secret)}
+}
+
+// ParsePrivateKey reads an RSA (RS256) or Ed25519 (EdDSA) private key from
+// PEM, in PKCS #8 or, for RSA, PKCS #1
+func ParsePrivateKey(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key, err := publicKey(&private.PublicKey)
+		key.signing = private
+		return key, err
+	case ed25519.PrivateKey:
+		key, err := publicKey(private.Public())
+		key.signing = private
+		return key, err
+	default:
+		return Key{}, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// ParsePublicKey reads an RSA or Ed25519 public key from PEM (PKIX), for a
+// retired signing key whose tokens are still accepted
+func ParsePublicKey(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return Key{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return publicKey(parsed)
+}
+
+// LoadPrivateKey reads ParsePrivateKey's PEM from a file
+func LoadPrivateKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// LoadPublicKey reads ParsePublicKey's PEM from a file
+func LoadPublicKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read verification key: %w", err)
+	}
+	key, err := ParsePublicKey(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func publicKey(public crypto.PublicKey) (Key, error) {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("RSA keys must have at least %d bits", minRSABits)
+		}
+		jwk := rsaJWK(public)
+		return Key{ID: thumbprint(`{"e":"` + jwk.E + `","kty":"RSA","n":"` + jwk.N + `"}`), method: jwt.SigningMethodRS256, public: public}, nil
+	case ed25519.PublicKey:
+		x := base64.RawURLEncoding.EncodeToString(public)
+		return Key{ID: thumbprint(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`), method: jwt.SigningMethodEdDSA, public: public}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// thumbprint hashes a key's required JWK members, in the canonical form RFC 7638 sets out
+func thumbprint(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// KeyRing signs tokens with one key and checks them with any of its keys, so
+// a new signing key can be brought in while tokens signed with the old one
+// are still in use
+type KeyRing struct {
+	signing Key
+	keys    map[string]Key
+}
+
+// NewKeyRing signs with signing, which must hold a private key or secret,
+// and also accepts tokens signed with any of retired
+func NewKeyRing(signing Key, retired ...Key) (*KeyRing, error) {
+	if signing.signing == nil {
+		return nil, errors.New("signing key has no private key")
+	}
+	ring := &KeyRing{signing: signing, keys: map[string]Key{signing.ID: signing}}
+	for _, key := range retired {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+	return ring, nil
+}
+
+// Signing is the key new tokens are signed with
+func (r *KeyRing) Signing() Key {
+	return r.signing
+}
+
+// sign signs claims with the signing key, naming it in the kid header
+func (r *KeyRing) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.signing.method, claims)
+	if r.signing.ID != "" {
+		token.Header["kid"] = r.signing.ID
+	}
+	return token.SignedString(r.signing.signing)
+}
+
+// verificationKey finds the key a token names, refusing any algorithm other
+// than that key's, so a public key cannot be passed off as an HMAC secret
+func (r *KeyRing) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWK is a public key as published in a JWK Set (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP curve
+	X         string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKSet is the document other services fetch to check tokens
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS publishes the public keys of the ring, the signing key first. The
+// shared secret is never published; while it signs, the set is empty.
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		if id != r.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range append([]string{r.signing.ID}, ids...) {
+		key := r.keys[id]
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk := rsaJWK(public)
+			jwk.KeyID = key.ID
+			set.Keys = append(set.Keys, jwk)
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType: "OKP", Use: "sig", Algorithm: "EdDSA", KeyID: key.ID,
+				Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
+
+func rsaJWK(public *rsa.PublicKey) JWK {
+	return JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+	}
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func rsaPEM(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	public, _ := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+}
+
+func ed25519PEM(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(private)
+	publicDER, _ := x509.MarshalPKIXPublicKey(public)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func mustRing(t *testing.T, signing Key, retired ...Key) *KeyRing {
+	t.Helper()
+	ring, err := NewKeyRing(signing, retired...)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	return ring
+}
+
+func testUser() *models.User {
+	return &models.User{ID: uuid.New(), Email: "test@example.com", Role: "member"}
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	for name, generate := range map[string]func(*testing.T) ([]byte, []byte){"RS256": rsaPEM, "EdDSA": ed25519PEM} {
+		privatePEM, _ := generate(t)
+		key, err := ParsePrivateKey(privatePEM)
+		if err != nil {
+			t.Fatalf("%s: ParsePrivateKey failed: %v", name, err)
+		}
+		if key.Algorithm() != name {
+			t.Fatalf("expected %s, got %s", name, key.Algorithm())
+		}
+
+		service := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, key))
+		tokens, err := service.GenerateTokens(testUser())
+		if err != nil {
+			t.Fatalf("%s: GenerateTokens failed: %v", name, err)
+		}
+
+		token, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &Claims{})
+		if err != nil {
+			t.Fatalf("%s: failed to parse token: %v", name, err)
+		}
+		if token.Header["alg"] != name || token.Header["kid"] != key.ID {
+			t.Fatalf("%s: unexpected header %v", name, token.Header)
+		}
+		if _, err := service.ValidateToken(tokens.AccessToken); err != nil {
+			t.Fatalf("%s: ValidateToken failed: %v", name, err)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldPrivate, oldPublic := rsaPEM(t)
+	newPrivate, _ := ed25519PEM(t)
+	oldKey, _ := ParsePrivateKey(oldPrivate)
+	newKey, _ := ParsePrivateKey(newPrivate)
+	retired, err := ParsePublicKey(oldPublic)
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if retired.ID != oldKey.ID {
+		t.Fatalf("public and private key should share their ID")
+	}
+
+	before := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, oldKey))
+	oldTokens, _ := before.GenerateTokens(testUser())
+
+	// Tokens of the retired key still work after the switch
+	after := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, newKey, retired))
+	if _, err := after.ValidateToken(oldTokens.AccessToken); err != nil {
+		t.Fatalf("retired key's token should validate: %v", err)
+	}
+
+	// ...until the retired key is dropped
+	dropped := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, newKey))
+	if _, err := dropped.ValidateToken(oldTokens.AccessToken); err == nil {
+		t.Fatal("token of a dropped key should not validate")
+	}
+
+	if _, err := NewKeyRing(retired); err == nil {
+		t.Fatal("a public key should not be accepted as the signing key")
+	}
+}
+
+func TestKeyRingSecret(t *testing.T) {
+	privatePEM, publicPEM := rsaPEM(t)
+	signing, _ := ParsePrivateKey(privatePEM)
+	legacy := NewService("test-secret", "test-issuer")
+	legacyTokens, _ := legacy.GenerateTokens(testUser())
+
+	accepting := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, signing, SecretKey("test-secret")))
+	if _, err := accepting.ValidateToken(legacyTokens.AccessToken); err != nil {
+		t.Fatalf("secret-signed token should validate while the secret is accepted: %v", err)
+	}
+
+	strict := NewService("test-secret", "test-issuer").WithKeyRing(mustRing(t, signing))
+	if _, err := strict.ValidateToken(legacyTokens.AccessToken); err == nil {
+		t.Fatal("secret-signed token should not validate once the secret is dropped")
+	}
+
+	// An HS256 token keyed with the published public key must not pass
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: uuid.New(), Type: "access", RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+	forged.Header["kid"] = signing.ID
+	forgedToken, _ := forged.SignedString(publicPEM)
+	if _, err := accepting.ValidateToken(forgedToken); err == nil {
+		t.Fatal("HS256 token naming an RSA key should not validate")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaPrivate, _ := rsaPEM(t)
+	_, edPublic := ed25519PEM(t)
+	signing, _ := ParsePrivateKey(rsaPrivate)
+	retired, _ := ParsePublicKey(edPublic)
+
+	set := mustRing(t, signing, retired, SecretKey("test-secret")).JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("expected the two public keys, got %+v", set.Keys)
+	}
+	first, second := set.Keys[0], set.Keys[1]
+	if first.KeyID != signing.ID || first.KeyType != "RSA" || first.Algorithm != "RS256" || first.E != "AQAB" || first.N == "" {
+		t.Errorf("unexpected signing key %+v", first)
+	}
+	if second.KeyID != retired.ID || second.KeyType != "OKP" || second.Curve != "Ed25519" || second.Algorithm != "EdDSA" {
+		t.Errorf("unexpected retired key %+v", second)
+	}
+
+	if keys := NewService("test-secret", "test-issuer").KeyRing().JWKS().Keys; len(keys) != 0 {
+		t.Errorf("the shared secret should never be published, got %+v", keys)
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 8037, appendix A.3
+	x, _ := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	key, err := publicKey(ed25519.PublicKey(x))
+	if err != nil {
+		t.Fatalf("publicKey failed: %v", err)
+	}
+	if key.ID != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("unexpected thumbprint %s", key.ID)
+	}
+}
+
+func TestParsePrivateKeyRejectsSmallRSA(t *testing.T) {
+	private, _ := rsa.GenerateKey(rand.Reader, 1024)
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	if _, err := ParsePrivateKey(data); err == nil || !strings.Contains(err.Error(), "2048") {
+		t.Fatalf("expected small RSA keys to be refused, got %v", err)
+	}
+}
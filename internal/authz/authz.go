@@ -0,0 +1,297 @@
+// Package authz authorizes requests against the capabilities of the caller's
+// club role.
+//
+// Built-in roles grant fixed capabilities; clubs can define custom roles with
+// any set of them. Route middleware resolves the caller's membership once and
+// stores it in the request context; handlers then only check rules that
+// depend on the resource (e.g. "the event creator may edit it") via Can and
+// EventFromContext. Global admins (the "admin" role claim in the JWT) pass
+// every club check.
+package authz
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// AdminRole is the platform role that bypasses club role checks
+const AdminRole = "admin"
+
+// Club roles
+const (
+	RoleOwner     = "owner"
+	RoleModerator = "moderator"
+	RoleTreasurer = "treasurer"
+)
+
+// Capability is something a club role allows its holders to do
+type Capability string
+
+// Club capabilities
+const (
+	ManageClub          Capability = "manage_club"          // settings, vocabularies, webhooks, resources, reports
+	ManageMembers       Capability = "manage_members"       // members, invites, join requests, roles, corrections
+	ManageEvents        Capability = "manage_events"        // any event, templates, polls, partnerships
+	ManageItems         Capability = "manage_items"         // the items of any event
+	PostAnnouncements   Capability = "post_announcements"   // Slack and Discord announcements
+	ManageDues          Capability = "manage_dues"          // dues and who has paid them
+	ManageContributions Capability = "manage_contributions" // contribution goals and records
+)
+
+// Capabilities lists every capability, in the order they are documented
+var Capabilities = []Capability{
+	ManageClub, ManageMembers, ManageEvents, ManageItems, PostAnnouncements, ManageDues, ManageContributions,
+}
+
+// BuiltInRoles maps the roles every club has to the capabilities they grant.
+// Custom roles cannot take their names.
+var BuiltInRoles = map[string][]Capability{
+	RoleOwner:     Capabilities,
+	RoleModerator: {ManageClub, ManageMembers, ManageEvents, ManageItems, PostAnnouncements, ManageContributions},
+	RoleTreasurer: {ManageDues, ManageContributions},
+	"admin":       nil,
+	"member":      nil,
+	"guest":       nil,
+}
+
+type contextKey string
+
+const (
+	membershipKey contextKey = "club_membership"
+	eventKey      contextKey = "club_event"
+)
+
+// Membership is the caller's standing in a club
+type Membership struct {
+	ClubID       uuid.UUID
+	Role         string       // empty when the caller is not an active member
+	Admin        bool         // global admin, passes every role check
+	Capabilities []Capability // granted by a custom role; built-in roles grant theirs by name
+}
+
+// Can reports whether the membership grants capability
+func (m Membership) Can(capability Capability) bool {
+	if m.Admin {
+		return true
+	}
+	if m.Role == "" {
+		return false
+	}
+	granted := m.Capabilities
+	if builtIn, ok := BuiltInRoles[m.Role]; ok {
+		granted = builtIn
+	}
+	for _, c := range granted {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns those of capabilities the membership does not grant. A
+// caller may only hand out capabilities they hold themselves.
+func (m Membership) Missing(capabilities []Capability) []Capability {
+	var missing []Capability
+	for _, c := range capabilities {
+		if !m.Can(c) {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// Has reports whether the membership satisfies any of roles.
+// With no roles it only requires active membership.
+func (m Membership) Has(roles ...string) bool {
+	if m.Admin {
+		return true
+	}
+	if m.Role == "" {
+		return false
+	}
+	if len(roles) == 0 {
+		return true
+	}
+	for _, role := range roles {
+		if m.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer resolves club memberships for route middleware
+type Authorizer struct {
+	clubs  store.ClubStore
+	roles  store.ClubRoleStore
+	events store.EventStore
+}
+
+func New(stores *store.Stores) *Authorizer {
+	return &Authorizer{clubs: stores.Clubs, roles: stores.ClubRoles, events: stores.Events}
+}
+
+// Resolve returns the caller's membership in a club, honoring the global admin bypass
+func (a *Authorizer) Resolve(ctx context.Context, clubID, userID uuid.UUID) (Membership, error) {
+	membership := Membership{ClubID: clubID, Admin: IsAdmin(ctx)}
+
+	role, err := a.clubs.MemberRole(ctx, clubID, userID)
+	if err != nil && err != store.ErrNotFound {
+		return membership, err
+	}
+
+	membership.Role = role
+	if _, builtIn := BuiltInRoles[role]; role != "" && !builtIn {
+		custom, err := a.roles.Get(ctx, clubID, role)
+		if err != nil && err != store.ErrNotFound {
+			return membership, err
+		}
+		if custom != nil {
+			for _, c := range custom.Capabilities {
+				membership.Capabilities = append(membership.Capabilities, Capability(c))
+			}
+		}
+	}
+	return membership, nil
+}
+
+// RequireClubRole only lets through callers holding one of roles in the {clubId} club,
+// or any active member when no roles are given. It must run after auth.AuthMiddleware.
+func (a *Authorizer) RequireClubRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+			if err != nil {
+				apierror.Write(w, time.Now(), apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+				return
+			}
+
+			a.authorize(w, r, next, clubID, nil, roles)
+		})
+	}
+}
+
+// RequireClubCapability only lets through callers whose role in the {clubId}
+// club grants capability. It must run after auth.AuthMiddleware.
+func (a *Authorizer) RequireClubCapability(capability Capability) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+			if err != nil {
+				apierror.Write(w, time.Now(), apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+				return
+			}
+
+			a.authorizeWith(w, r, next, clubID, nil, func(m Membership) bool { return m.Can(capability) })
+		})
+	}
+}
+
+// RequireEventRole is RequireClubRole for {eventId} routes: the caller's role is checked
+// in the event's club, and the event is stored in the context for EventFromContext
+func (a *Authorizer) RequireEventRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+			if err != nil {
+				apierror.Write(w, time.Now(), apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+				return
+			}
+
+			event, err := a.events.GetByID(r.Context(), eventID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					apierror.Write(w, time.Now(), apierror.NotFound("Event not found"))
+					return
+				}
+				logging.FromContext(r.Context()).Error("error getting event", "error", err)
+				apierror.Write(w, time.Now(), apierror.Internal("Failed to authorize request"))
+				return
+			}
+
+			a.authorize(w, r, next, event.ClubID, event, roles)
+		})
+	}
+}
+
+func (a *Authorizer) authorize(w http.ResponseWriter, r *http.Request, next http.Handler, clubID uuid.UUID, event *models.Event, roles []string) {
+	a.authorizeWith(w, r, next, clubID, event, func(m Membership) bool { return m.Has(roles...) })
+}
+
+// authorizeWith lets through active members whose membership satisfies allowed
+func (a *Authorizer) authorizeWith(w http.ResponseWriter, r *http.Request, next http.Handler, clubID uuid.UUID, event *models.Event, allowed func(Membership) bool) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		apierror.Write(w, time.Now(), apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	membership, err := a.Resolve(r.Context(), clubID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error resolving club membership", "error", err)
+		apierror.Write(w, time.Now(), apierror.Internal("Failed to authorize request"))
+		return
+	}
+
+	if !allowed(membership) {
+		if membership.Role == "" {
+			apierror.Write(w, time.Now(), apierror.Forbidden("You are not a member of this club"))
+			return
+		}
+		apierror.Write(w, time.Now(), apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	ctx := NewContext(r.Context(), membership)
+	if event != nil {
+		ctx = context.WithValue(ctx, eventKey, event)
+	}
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// IsAdmin reports whether the caller has the global admin role claim
+func IsAdmin(ctx context.Context) bool {
+	principal, ok := auth.FromContext(ctx)
+	return ok && principal.Role == AdminRole
+}
+
+// NewContext returns a copy of ctx carrying the caller's membership
+func NewContext(ctx context.Context, membership Membership) context.Context {
+	return context.WithValue(ctx, membershipKey, membership)
+}
+
+// FromContext returns the membership stored by RequireClubRole or RequireEventRole
+func FromContext(ctx context.Context) (Membership, bool) {
+	membership, ok := ctx.Value(membershipKey).(Membership)
+	return membership, ok
+}
+
+// HasRole reports whether the membership in ctx satisfies any of roles
+func HasRole(ctx context.Context, roles ...string) bool {
+	membership, ok := FromContext(ctx)
+	return ok && membership.Has(roles...)
+}
+
+// Can reports whether the membership in ctx grants capability
+func Can(ctx context.Context, capability Capability) bool {
+	membership, ok := FromContext(ctx)
+	return ok && membership.Can(capability)
+}
+
+// EventFromContext returns the event loaded by RequireEventRole
+func EventFromContext(ctx context.Context) (*models.Event, bool) {
+	event, ok := ctx.Value(eventKey).(*models.Event)
+	return event, ok
+}
@@ -0,0 +1,179 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type authzFixture struct {
+	router      chi.Router
+	clubID      uuid.UUID
+	eventID     uuid.UUID
+	ownerID     uuid.UUID
+	memberID    uuid.UUID
+	hostID      uuid.UUID // holds the custom role host
+	outsiderID  uuid.UUID
+	seenRole    string
+	seenEventID uuid.UUID
+}
+
+func setupAuthzTest() *authzFixture {
+	f := &authzFixture{
+		clubID:     uuid.New(),
+		eventID:    uuid.New(),
+		ownerID:    uuid.New(),
+		memberID:   uuid.New(),
+		hostID:     uuid.New(),
+		outsiderID: uuid.New(),
+	}
+
+	mem := store.NewMemory()
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: f.clubID, UserID: f.ownerID, Role: RoleOwner, IsActive: true})
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: f.clubID, UserID: f.memberID, Role: "member", IsActive: true})
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: f.clubID, UserID: f.hostID, Role: "host", IsActive: true})
+	mem.Stores().ClubRoles.Create(context.Background(), &models.ClubRole{
+		ID: uuid.New(), ClubID: f.clubID, Name: "host", Capabilities: models.StringArray{string(ManageEvents), string(ManageItems)},
+	})
+	mem.PutEvent(models.Event{ID: f.eventID, ClubID: f.clubID, Title: "Book Night", CreatedBy: f.ownerID})
+
+	authorizer := New(mem.Stores())
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		membership, _ := FromContext(r.Context())
+		f.seenRole = membership.Role
+		if event, ok := EventFromContext(r.Context()); ok {
+			f.seenEventID = event.ID
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	f.router = chi.NewRouter()
+	f.router.With(authorizer.RequireClubRole()).Get("/club/{clubId}", handler)
+	f.router.With(authorizer.RequireClubRole(RoleOwner)).Put("/club/{clubId}", handler)
+	f.router.With(authorizer.RequireClubCapability(ManageEvents)).Post("/club/{clubId}/events", handler)
+	f.router.With(authorizer.RequireClubCapability(ManageDues)).Post("/club/{clubId}/dues", handler)
+	f.router.With(authorizer.RequireEventRole()).Get("/events/{eventId}", handler)
+	return f
+}
+
+func (f *authzFixture) serve(method, path string, userID uuid.UUID, role string) int {
+	req := httptest.NewRequest(method, path, nil)
+	ctx := auth.NewContext(req.Context(), auth.Principal{UserID: userID, Role: role})
+
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req.WithContext(ctx))
+	return w.Code
+}
+
+func TestRequireClubRole(t *testing.T) {
+	f := setupAuthzTest()
+	clubPath := "/club/" + f.clubID.String()
+
+	tests := []struct {
+		name     string
+		method   string
+		userID   uuid.UUID
+		role     string
+		expected int
+	}{
+		{"member can view", "GET", f.memberID, "member", http.StatusOK},
+		{"outsider cannot view", "GET", f.outsiderID, "member", http.StatusForbidden},
+		{"owner can manage", "PUT", f.ownerID, "member", http.StatusOK},
+		{"member cannot manage", "PUT", f.memberID, "member", http.StatusForbidden},
+		{"custom role is not a built-in one", "PUT", f.hostID, "member", http.StatusForbidden},
+		{"global admin bypasses membership", "PUT", f.outsiderID, AdminRole, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := f.serve(tt.method, clubPath, tt.userID, tt.role); code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+
+	if code := f.serve("GET", "/club/not-a-uuid", f.memberID, "member"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid club ID, got %d", code)
+	}
+}
+
+func TestRequireEventRole(t *testing.T) {
+	f := setupAuthzTest()
+
+	if code := f.serve("GET", "/events/"+f.eventID.String(), f.ownerID, "member"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if f.seenRole != RoleOwner || f.seenEventID != f.eventID {
+		t.Errorf("Expected owner membership and event in context, got role %q and event %s", f.seenRole, f.seenEventID)
+	}
+
+	if code := f.serve("GET", "/events/"+f.eventID.String(), f.outsiderID, "member"); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for non-member, got %d", code)
+	}
+
+	if code := f.serve("GET", "/events/"+uuid.New().String(), f.ownerID, "member"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown event, got %d", code)
+	}
+}
+
+func TestRequireClubCapability(t *testing.T) {
+	f := setupAuthzTest()
+	eventsPath := "/club/" + f.clubID.String() + "/events"
+	duesPath := "/club/" + f.clubID.String() + "/dues"
+
+	tests := []struct {
+		name     string
+		path     string
+		userID   uuid.UUID
+		role     string
+		expected int
+	}{
+		{"owner has every capability", duesPath, f.ownerID, "member", http.StatusOK},
+		{"custom role grants its capabilities", eventsPath, f.hostID, "member", http.StatusOK},
+		{"custom role grants no others", duesPath, f.hostID, "member", http.StatusForbidden},
+		{"member has no capabilities", eventsPath, f.memberID, "member", http.StatusForbidden},
+		{"outsider is not a member", eventsPath, f.outsiderID, "member", http.StatusForbidden},
+		{"global admin bypasses capabilities", duesPath, f.outsiderID, AdminRole, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := f.serve("POST", tt.path, tt.userID, tt.role); code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestMembershipCan(t *testing.T) {
+	tests := []struct {
+		membership Membership
+		capability Capability
+		expected   bool
+	}{
+		{Membership{Role: RoleModerator}, ManageMembers, true},
+		{Membership{Role: RoleModerator}, ManageDues, false},
+		{Membership{Role: RoleTreasurer}, ManageDues, true},
+		{Membership{Role: RoleTreasurer}, ManageEvents, false},
+		{Membership{Role: "host", Capabilities: []Capability{ManageEvents}}, ManageEvents, true},
+		{Membership{Role: "host", Capabilities: []Capability{ManageEvents}}, ManageItems, false},
+		// Built-in roles grant only their own capabilities, whatever else is set
+		{Membership{Role: "member", Capabilities: []Capability{ManageEvents}}, ManageEvents, false},
+		{Membership{Capabilities: []Capability{ManageEvents}}, ManageEvents, false},
+		{Membership{Admin: true}, ManageDues, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.membership.Can(tt.capability); got != tt.expected {
+			t.Errorf("%+v Can(%s) = %v, expected %v", tt.membership, tt.capability, got, tt.expected)
+		}
+	}
+}
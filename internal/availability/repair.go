@@ -0,0 +1,48 @@
+// Package availability keeps the precomputed availability summaries honest.
+//
+// Summaries are served from per-event, per-status counters maintained as
+// responses change. Counters can drift (manual data fixes, bulk imports),
+// so a repair job periodically recomputes the ones that disagree with the
+// responses.
+package availability
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/store"
+)
+
+// Repairer periodically corrects drifted availability counters
+type Repairer struct {
+	availability store.AvailabilityStore
+	interval     time.Duration
+	logger       *slog.Logger
+}
+
+func NewRepairer(availability store.AvailabilityStore, interval time.Duration, logger *slog.Logger) *Repairer {
+	return &Repairer{availability: availability, interval: interval, logger: logger}
+}
+
+// Run repairs immediately and then every interval until ctx is cancelled
+func (r *Repairer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		repaired, err := r.availability.RepairSummaries(ctx)
+		if err != nil {
+			r.logger.Error("error repairing availability summaries", "error", err)
+		} else if repaired > 0 {
+			// Drift means a write bypassed the counters; worth investigating
+			r.logger.Warn("repaired drifted availability summaries", "counters", repaired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
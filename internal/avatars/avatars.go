@@ -0,0 +1,325 @@
+// Package avatars turns uploaded profile pictures into square JPEGs of the
+// standard sizes.
+//
+// Uploads are decoded, flattened onto white, cropped to their centre square,
+// resized and turned upright according to their EXIF orientation. Encoding
+// them afresh drops EXIF and every other kind of metadata, including the GPS
+// position phones record. The results are stored in the attachment storage
+// under keys that include a hash of the upload, so each version has its own
+// URL that can be cached forever.
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+	"regexp"
+	"strconv"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Sizes are the widths, in pixels, every avatar is stored at
+var Sizes = []int{64, 128, 256, 512}
+
+// DefaultSize is the size a user's avatar URL points at
+const DefaultSize = 256
+
+// AllowedTypes are the upload types that can be decoded
+var AllowedTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// MaxPixels bounds the dimensions of an upload, so a small file that
+// decodes to a huge image cannot exhaust memory
+const MaxPixels = 16_000_000
+
+// jpegQuality is the quality avatars are encoded at
+const jpegQuality = 85
+
+var (
+	// ErrUndecodable means an upload is not an image that can be read
+	ErrUndecodable = errors.New("image cannot be decoded")
+	// ErrTooLarge means an upload's dimensions exceed MaxPixels
+	ErrTooLarge = errors.New("image dimensions too large")
+)
+
+// Version names an upload by a hash of its contents
+func Version(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+var versionPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// ValidVersion reports whether version could have come from Version
+func ValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// ValidSize reports whether size is one of Sizes
+func ValidSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Key is the storage key of one size of a user's avatar version
+func Key(userID uuid.UUID, version string, size int) string {
+	return "avatars/" + userID.String() + "/" + version + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+// Path is the URL path an avatar is served at, below the API prefix
+func Path(userID uuid.UUID, version string, size int) string {
+	return "/avatars/" + userID.String() + "/" + version + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+// Process decodes an upload and returns it as a JPEG of each of Sizes
+func Process(content []byte) (map[int][]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, ErrUndecodable
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrUndecodable
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, ErrUndecodable
+	}
+	square := cropSquare(flatten(img))
+	orientation := jpegOrientation(content)
+
+	results := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var out bytes.Buffer
+		if err := jpeg.Encode(&out, orient(resize(square, size), orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		results[size] = out.Bytes()
+	}
+	return results, nil
+}
+
+// flatten draws img onto white, dropping transparency, which JPEG cannot hold
+func flatten(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// cropSquare returns the centre square of img
+func cropSquare(img *image.RGBA) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	side := w
+	if h < side {
+		side = h
+	}
+	x0, y0 := (w-side)/2, (h-side)/2
+	return img.SubImage(image.Rect(x0, y0, x0+side, y0+side)).(*image.RGBA)
+}
+
+// resize scales a square image to size × size. Shrinking averages the source
+// pixels each output pixel covers; enlarging repeats the nearest one.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		sy0, sy1 := span(y, side, size)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := span(x, side, size)
+
+			var r, g, b, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				offset := src.PixOffset(bounds.Min.X+sx0, bounds.Min.Y+sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					n++
+					offset += 4
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) output pixel i of size covers
+// in a source of side pixels; at least one
+func span(i, side, size int) (from, to int) {
+	from = i * side / size
+	to = (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// orient turns a square image upright according to its EXIF orientation
+// (1 to 8); other values leave it as it is
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	n := img.Bounds().Dx()
+	out := image.NewRGBA(image.Rect(0, 0, n, n))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = n-1-x, y
+			case 3: // upside down
+				sx, sy = n-1-x, n-1-y
+			case 4: // upside down and mirrored
+				sx, sy = x, n-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // turned a quarter anticlockwise
+				sx, sy = y, n-1-x
+			case 7: // transversed
+				sx, sy = n-1-y, n-1-x
+			case 8: // turned a quarter clockwise
+				sx, sy = n-1-y, x
+			}
+			copy(out.Pix[out.PixOffset(x, y):out.PixOffset(x, y)+4], img.Pix[img.PixOffset(sx, sy):img.PixOffset(sx, sy)+4])
+		}
+	}
+	return out
+}
+
+// jpegOrientation reads the EXIF orientation tag of a JPEG, or returns 1 for
+// other images and JPEGs without one
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 { // image data starts; no more metadata
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xe1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in the first IFD of a TIFF structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+// ErrUserNotFound means the user does not exist or is deactivated
+var ErrUserNotFound = errors.New("user not found")
+
+// Store records which avatar version a user has
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// Set makes an uploaded version the user's avatar, linked at url, and returns
+// the version it replaces, if any, so its files can be removed
+func (s *Store) Set(ctx context.Context, userID uuid.UUID, version, url string) (previous string, err error) {
+	query := `
+		UPDATE users u SET avatar = $3, avatar_version = $2, updated_at = NOW()
+		FROM (SELECT id, avatar_version FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id AND u.is_active = true
+		RETURNING COALESCE(old.avatar_version, '')`
+
+	err = s.db.QueryRowContext(ctx, query, userID, version, url).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to set avatar: %w", err)
+	}
+	return previous, nil
+}
+
+// Clear removes the user's avatar and returns the uploaded version it was, if any
+func (s *Store) Clear(ctx context.Context, userID uuid.UUID) (previous string, err error) {
+	query := `
+		UPDATE users u SET avatar = NULL, avatar_version = NULL, updated_at = NOW()
+		FROM (SELECT id, avatar_version FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id AND u.is_active = true
+		RETURNING COALESCE(old.avatar_version, '')`
+
+	err = s.db.QueryRowContext(ctx, query, userID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to clear avatar: %w", err)
+	}
+	return previous, nil
+}
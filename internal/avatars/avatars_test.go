@@ -0,0 +1,174 @@
+package avatars
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an APP1 EXIF segment holding orientation after a JPEG's SOI marker
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)           // one entry
+	tiff = append(tiff, 0x01, 0x12, 0x00, 0x03, 0, 0, 0, 1) // orientation, SHORT, count 1
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0, 0, 0) // padding, next IFD
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xff, 0xd8, 0xff, 0xe1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestProcessCropsAndResizesToEverySize(t *testing.T) {
+	// A wide image: red on the left and right thirds, blue in the middle
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 100 && x < 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	images, err := Process(encodePNG(t, src))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	for _, size := range Sizes {
+		img, err := jpeg.Decode(bytes.NewReader(images[size]))
+		if err != nil {
+			t.Fatalf("size %d is not a JPEG: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("Expected %dx%d, got %v", size, size, b)
+		}
+		// Only the blue centre square is kept
+		r, _, b, _ := img.At(0, 0).RGBA()
+		if b>>8 < 200 || r>>8 > 60 {
+			t.Errorf("size %d: expected the blue centre, got corner r=%d b=%d", size, r>>8, b>>8)
+		}
+	}
+}
+
+func TestProcessFlattensTransparencyOntoWhite(t *testing.T) {
+	images, err := Process(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 10, 10))))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	img, _ := jpeg.Decode(bytes.NewReader(images[64]))
+	if r, g, b, _ := img.At(32, 32).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("Expected white, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestProcessRejectsBadImages(t *testing.T) {
+	if _, err := Process([]byte("not an image")); err != ErrUndecodable {
+		t.Errorf("Expected ErrUndecodable, got %v", err)
+	}
+
+	// The header claims 5000x5000 pixels; it is rejected before decoding
+	var buf bytes.Buffer
+	gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil)
+	header := buf.Bytes()
+	binary.LittleEndian.PutUint16(header[6:], 5000)
+	binary.LittleEndian.PutUint16(header[8:], 5000)
+	if _, err := Process(header); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestProcessStripsMetadataAndAppliesOrientation(t *testing.T) {
+	// Top half black, bottom half white
+	src := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 32; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, src, nil)
+	upload := withOrientation(buf.Bytes(), 3) // stored upside down
+
+	if got := jpegOrientation(upload); got != 3 {
+		t.Fatalf("Expected orientation 3, got %d", got)
+	}
+
+	images, err := Process(upload)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if bytes.Contains(images[64], []byte("Exif")) {
+		t.Error("Expected EXIF to be stripped")
+	}
+	img, _ := jpeg.Decode(bytes.NewReader(images[64]))
+	if top, _, _, _ := img.At(32, 4).RGBA(); top>>8 < 200 {
+		t.Errorf("Expected the image turned upright with white on top, got %d", top>>8)
+	}
+}
+
+func TestOrientationOfOtherImages(t *testing.T) {
+	if got := jpegOrientation([]byte("\x89PNG")); got != 1 {
+		t.Errorf("Expected 1 for a PNG, got %d", got)
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2)), nil)
+	if got := jpegOrientation(buf.Bytes()); got != 1 {
+		t.Errorf("Expected 1 without EXIF, got %d", got)
+	}
+}
+
+func TestSpanCoversEverySourcePixel(t *testing.T) {
+	for _, c := range []struct{ side, size int }{{1000, 64}, {100, 512}, {256, 256}} {
+		next := 0
+		for i := 0; i < c.size; i++ {
+			from, to := span(i, c.side, c.size)
+			if to <= from || to > c.side {
+				t.Fatalf("side %d size %d: bad span [%d, %d) at %d", c.side, c.size, from, to, i)
+			}
+			if c.side >= c.size && from != next {
+				t.Fatalf("side %d size %d: span at %d starts at %d, expected %d", c.side, c.size, i, from, next)
+			}
+			next = to
+		}
+		if c.side >= c.size && next != c.side {
+			t.Errorf("side %d size %d: spans end at %d", c.side, c.size, next)
+		}
+	}
+}
+
+func TestKeysAndVersions(t *testing.T) {
+	userID := uuid.MustParse("2b1f3c4d-0000-4000-8000-000000000001")
+	version := Version([]byte("image"))
+	if !ValidVersion(version) || ValidVersion("../etc") || ValidVersion(version+"0") {
+		t.Errorf("Unexpected version validation for %q", version)
+	}
+	if Version([]byte("image")) != version || Version([]byte("other")) == version {
+		t.Error("Expected versions to follow the content")
+	}
+	want := "avatars/" + userID.String() + "/" + version + "/128.jpg"
+	if got := Key(userID, version, 128); got != want {
+		t.Errorf("Expected key %q, got %q", want, got)
+	}
+	if !ValidSize(512) || ValidSize(100) {
+		t.Error("Unexpected size validation")
+	}
+}
@@ -0,0 +1,118 @@
+// Package billing receives payments from Stripe.
+//
+// The API never talks to Stripe directly: clubs send members to Stripe Payment
+// Links or Checkout sessions created with metadata naming what the payment is
+// for, and Stripe reports completed payments to the webhook. Events are
+// authenticated with the endpoint's signing secret before they are trusted.
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/money"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid Stripe signature")
+	ErrStaleSignature   = errors.New("Stripe signature timestamp outside the tolerance")
+	// ErrAlreadyRecorded is returned by recorders for a payment Stripe delivered before
+	ErrAlreadyRecorded = errors.New("payment already recorded")
+)
+
+// SignatureTolerance is how old a signed event may be, limiting replays
+const SignatureTolerance = 5 * time.Minute
+
+// Event is a Stripe webhook event
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// Checkout session events that mean the customer has paid. Delayed payment
+// methods (e.g. bank debits) complete unpaid and succeed later.
+const (
+	EventCheckoutCompleted      = "checkout.session.completed"
+	EventCheckoutAsyncSucceeded = "checkout.session.async_payment_succeeded"
+)
+
+// CheckoutSession is the part of a Stripe Checkout session the API uses
+type CheckoutSession struct {
+	ID              string            `json:"id"`
+	AmountTotal     int64             `json:"amount_total"`
+	Currency        string            `json:"currency"`
+	PaymentStatus   string            `json:"payment_status"`
+	Metadata        map[string]string `json:"metadata"`
+	ClientReference string            `json:"client_reference_id"` // from ?client_reference_id= on a Payment Link URL
+}
+
+// Purpose is the metadata key naming what a payment is for, e.g. "dues"
+const Purpose = "purpose"
+
+// VerifySignature checks a Stripe-Signature header ("t=<unix>,v1=<hex>,...")
+// against the raw request body, as described in Stripe's webhook docs
+func VerifySignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+				return ErrStaleSignature
+			}
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// PaidCheckout returns the checkout session of an event reporting a completed
+// payment; ok is false for every other event
+func PaidCheckout(event Event) (session CheckoutSession, ok bool, err error) {
+	if event.Type != EventCheckoutCompleted && event.Type != EventCheckoutAsyncSucceeded {
+		return CheckoutSession{}, false, nil
+	}
+	if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+		return CheckoutSession{}, false, err
+	}
+	return session, session.PaymentStatus == "paid", nil
+}
+
+// Amount is the amount paid. Stripe reports amounts in the currency's minor
+// unit, which is what money.Money holds.
+func (s CheckoutSession) Amount() (money.Money, error) {
+	c, ok := money.LookupCurrency(s.Currency)
+	if !ok {
+		return money.Money{}, money.ErrUnknownCurrency
+	}
+	return money.New(s.AmountTotal, c.Code), nil
+}
@@ -0,0 +1,72 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(payload []byte, secret string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+
+	if err := VerifySignature(payload, sign(payload, "whsec_test", now), "whsec_test", now); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	// Stripe sends one v1 signature per active secret while a secret is rolled
+	header := sign(payload, "whsec_test", now) + ",v1=" + hex.EncodeToString([]byte("old"))
+	if err := VerifySignature(payload, header, "whsec_test", now); err != nil {
+		t.Errorf("Expected any matching v1 signature to pass, got %v", err)
+	}
+
+	tests := map[string]struct {
+		header string
+		err    error
+	}{
+		"wrong secret":    {sign(payload, "whsec_other", now), ErrInvalidSignature},
+		"tampered body":   {sign([]byte(`{"id":"evt_2"}`), "whsec_test", now), ErrInvalidSignature},
+		"replayed":        {sign(payload, "whsec_test", now.Add(-time.Hour)), ErrStaleSignature},
+		"missing header":  {"", ErrInvalidSignature},
+		"no v1 signature": {"t=" + strconv.FormatInt(now.Unix(), 10), ErrInvalidSignature},
+	}
+	for name, tt := range tests {
+		if err := VerifySignature(payload, tt.header, "whsec_test", now); err != tt.err {
+			t.Errorf("%s: expected %v, got %v", name, tt.err, err)
+		}
+	}
+}
+
+func TestPaidCheckout(t *testing.T) {
+	var event Event
+	json.Unmarshal([]byte(`{
+		"id": "evt_1",
+		"type": "checkout.session.completed",
+		"data": {"object": {"id": "cs_1", "amount_total": 2500, "currency": "eur", "payment_status": "paid", "metadata": {"purpose": "dues"}}}
+	}`), &event)
+
+	session, ok, err := PaidCheckout(event)
+	if err != nil || !ok {
+		t.Fatalf("Expected a paid checkout, got %v, %v", ok, err)
+	}
+	amount, err := session.Amount()
+	if err != nil || amount.String() != "25.00" || amount.Currency != "EUR" || session.Metadata[Purpose] != "dues" {
+		t.Errorf("Unexpected session %+v, amount %+v, %v", session, amount, err)
+	}
+
+	event.Type = "customer.created"
+	if _, ok, _ := PaidCheckout(event); ok {
+		t.Error("Expected other events to be ignored")
+	}
+}
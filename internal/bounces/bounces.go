@@ -0,0 +1,251 @@
+// Package bounces receives bounce and complaint reports from the email
+// service provider and keeps the addresses that must not be mailed again.
+//
+// The provider (or a small relay in front of it) posts batches of events to
+// the email webhook, signed like Stripe's: an HMAC-SHA256 of
+// "<unix time>.<body>" keyed with the shared secret, sent as
+// "X-Webhook-Signature: t=<unix time>,v1=<hex>". Hard bounces and complaints
+// suppress the address; soft bounces are transient and only acknowledged.
+package bounces
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleSignature   = errors.New("webhook signature timestamp outside the tolerance")
+	// ErrNotFound means an address is not suppressed
+	ErrNotFound = errors.New("suppression not found")
+)
+
+// SignatureTolerance is how old a signed batch may be, limiting replays
+const SignatureTolerance = 5 * time.Minute
+
+// SignatureHeader carries the signature of a webhook body
+const SignatureHeader = "X-Webhook-Signature"
+
+// Event types and bounce types
+const (
+	TypeBounce    = "bounce"
+	TypeComplaint = "complaint"
+
+	BounceHard = "hard"
+	BounceSoft = "soft"
+)
+
+// MaxBatchEvents bounds the events of one webhook call
+const MaxBatchEvents = 500
+
+// Event is one bounce or complaint reported by the provider. Events of other
+// types, such as deliveries, are ignored.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	BounceType string    `json:"bounceType"` // hard or soft
+	Email      string    `json:"email"`
+	Reason     string    `json:"reason"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// Batch is the body of a webhook call
+type Batch struct {
+	Events []Event `json:"events"`
+}
+
+// Suppresses reports whether an event makes its address undeliverable. A
+// bounce without a type is taken as hard, as most providers only report those.
+func (e Event) Suppresses() bool {
+	if strings.TrimSpace(e.Email) == "" {
+		return false
+	}
+	return e.Type == TypeComplaint || (e.Type == TypeBounce && e.BounceType != BounceSoft)
+}
+
+// Suppression is an address email is no longer sent to
+type Suppression struct {
+	Email        string    `json:"email"`
+	Reason       string    `json:"reason"` // bounce or complaint
+	Detail       string    `json:"detail,omitempty"`
+	SuppressedAt time.Time `json:"suppressedAt"`
+}
+
+// Recipient is the user an address belongs to, with the clubs they are an
+// active member of
+type Recipient struct {
+	UserID  uuid.UUID
+	Name    string
+	ClubIDs []uuid.UUID
+}
+
+// VerifySignature checks a signature header ("t=<unix>,v1=<hex>,...")
+// against the raw request body
+func VerifySignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(payload, secret, time.Unix(seconds, 0))
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+				return ErrStaleSignature
+			}
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Sign returns the signature of payload sent at at
+func Sign(payload []byte, secret string, at time.Time) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(at.Unix(), 10) + "."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Store keeps suppressed addresses
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// Suppress stops email to the event's address. created is false when the
+// address was already suppressed, so its owner's clubs were told before.
+func (s *Store) Suppress(ctx context.Context, event Event) (suppression Suppression, created bool, err error) {
+	reason := TypeBounce
+	if event.Type == TypeComplaint {
+		reason = TypeComplaint
+	}
+
+	query := `
+		INSERT INTO email_suppressions (email, reason, detail, event_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (email) DO NOTHING
+		RETURNING email, reason, COALESCE(detail, ''), suppressed_at`
+
+	err = s.db.QueryRowContext(ctx, query, normalize(event.Email), reason, event.Reason, event.ID).
+		Scan(&suppression.Email, &suppression.Reason, &suppression.Detail, &suppression.SuppressedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		suppression, err = s.Get(ctx, event.Email)
+		return suppression, false, err
+	}
+	if err != nil {
+		return Suppression{}, false, fmt.Errorf("failed to suppress address: %w", err)
+	}
+	return suppression, true, nil
+}
+
+// Get returns the suppression of an address
+func (s *Store) Get(ctx context.Context, email string) (Suppression, error) {
+	var suppression Suppression
+	err := s.db.QueryRowContext(ctx,
+		`SELECT email, reason, COALESCE(detail, ''), suppressed_at FROM email_suppressions WHERE email = $1`,
+		normalize(email),
+	).Scan(&suppression.Email, &suppression.Reason, &suppression.Detail, &suppression.SuppressedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Suppression{}, ErrNotFound
+	}
+	if err != nil {
+		return Suppression{}, fmt.Errorf("failed to get suppression: %w", err)
+	}
+	return suppression, nil
+}
+
+// Lift lets email go to an address again, e.g. once its owner has fixed their mailbox
+func (s *Store) Lift(ctx context.Context, email string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM email_suppressions WHERE email = $1`, normalize(email))
+	if err != nil {
+		return fmt.Errorf("failed to lift suppression: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Recipient returns the user an address belongs to; ok is false when no
+// user has it
+func (s *Store) Recipient(ctx context.Context, email string) (recipient Recipient, ok bool, err error) {
+	query := `
+		SELECT u.id, u.name, COALESCE(array_agg(cm.club_id) FILTER (WHERE cm.club_id IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN club_members cm ON cm.user_id = u.id AND cm.is_active = true
+		WHERE LOWER(u.email) = $1
+		GROUP BY u.id, u.name`
+
+	var clubIDs models.UUIDArray
+	err = s.db.QueryRowContext(ctx, query, normalize(email)).Scan(&recipient.UserID, &recipient.Name, &clubIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Recipient{}, false, nil
+	}
+	if err != nil {
+		return Recipient{}, false, fmt.Errorf("failed to find address owner: %w", err)
+	}
+	recipient.ClubIDs = clubIDs
+	return recipient, true, nil
+}
+
+// Suppressed reports which of userIDs have a suppressed address, for the
+// notification dispatcher's email provider
+func (s *Store) Suppressed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id FROM users u
+		JOIN email_suppressions es ON es.email = LOWER(u.email)
+		WHERE u.id = ANY($1::uuid[])`,
+		models.UUIDArray(userIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suppressed users: %w", err)
+	}
+	defer rows.Close()
+
+	suppressed := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan suppressed user: %w", err)
+		}
+		suppressed[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read suppressed users: %w", err)
+	}
+	return suppressed, nil
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
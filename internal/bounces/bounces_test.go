@@ -0,0 +1,52 @@
+package bounces
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signatureHeader(payload []byte, secret string, at time.Time) string {
+	return "t=" + strconv.FormatInt(at.Unix(), 10) + ",v1=" + hex.EncodeToString(Sign(payload, secret, at))
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"events":[]}`)
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := VerifySignature(payload, signatureHeader(payload, "secret", now), "secret", now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := VerifySignature(payload, signatureHeader(payload, "other", now), "secret", now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for the wrong secret, got %v", err)
+	}
+	if err := VerifySignature([]byte(`{}`), signatureHeader(payload, "secret", now), "secret", now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for a changed body, got %v", err)
+	}
+	if err := VerifySignature(payload, signatureHeader(payload, "secret", now), "secret", now.Add(time.Hour)); err != ErrStaleSignature {
+		t.Errorf("Expected ErrStaleSignature for an old batch, got %v", err)
+	}
+	if err := VerifySignature(payload, "", "secret", now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature without a header, got %v", err)
+	}
+}
+
+func TestEventSuppresses(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  bool
+	}{
+		{Event{Type: TypeBounce, BounceType: BounceHard, Email: "a@example.com"}, true},
+		{Event{Type: TypeBounce, Email: "a@example.com"}, true},
+		{Event{Type: TypeBounce, BounceType: BounceSoft, Email: "a@example.com"}, false},
+		{Event{Type: TypeComplaint, Email: "a@example.com"}, true},
+		{Event{Type: "delivery", Email: "a@example.com"}, false},
+		{Event{Type: TypeComplaint}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.event.Suppresses(); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.event, tt.want, got)
+		}
+	}
+}
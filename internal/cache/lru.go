@@ -0,0 +1,115 @@
+// Package cache keeps the results of hot lookups in process memory.
+//
+// Entries expire after a TTL and the least recently used are evicted once the
+// cache is full. Callers delete entries when the underlying data changes; the
+// TTL bounds how long a change made elsewhere, such as on another instance,
+// can go unseen.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/clock"
+)
+
+// LRU is a size-bounded cache with per-entry expiry. It is safe for
+// concurrent use.
+type LRU[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	clock clock.Clock
+	order *list.List // of *entry[K, V], most recently used first
+	items map[K]*list.Element
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU holds up to size entries for ttl each
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{size: size, ttl: ttl, clock: clock.Real, order: list.New(), items: make(map[K]*list.Element)}
+}
+
+// WithClock replaces the clock entries expire by, for tests
+func (c *LRU[K, V]) WithClock(clk clock.Clock) *LRU[K, V] {
+	c.clock = clk
+	return c
+}
+
+// Get returns the unexpired value stored for key
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[K, V])
+	if !c.clock.Now().Before(e.expiresAt) {
+		c.remove(elem)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set stores value for key, evicting the least recently used entry if the
+// cache is full
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.clock.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete removes key
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// DeleteFunc removes every key match returns true for
+func (c *LRU[K, V]) DeleteFunc(match func(K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.items {
+		if match(key) {
+			c.remove(elem)
+		}
+	}
+}
+
+// Len is the number of entries held, expired ones included until they are
+// looked up or evicted
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
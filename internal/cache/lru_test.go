@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"bookwork-api/internal/clock"
+)
+
+func TestLRUExpires(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC))
+	c := NewLRU[string, int](10, time.Minute).WithClock(clk)
+
+	c.Set("a", 1)
+	clk.Advance(59 * time.Second)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Expected 1 before the TTL, got %d, %v", v, ok)
+	}
+
+	clk.Advance(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected the entry to expire after the TTL")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Expected the expired entry to be dropped, %d left", c.Len())
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2, time.Minute)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Expected %s to be kept", key)
+		}
+	}
+}
+
+func TestLRUSetRefreshesEntry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC))
+	c := NewLRU[string, int](10, time.Minute).WithClock(clk)
+
+	c.Set("a", 1)
+	clk.Advance(45 * time.Second)
+	c.Set("a", 2)
+	clk.Advance(45 * time.Second)
+
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Errorf("Expected the replaced value with a fresh TTL, got %d, %v", v, ok)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Expected one entry, got %d", c.Len())
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := NewLRU[string, int](10, time.Minute)
+	c.Set("club1/alice", 1)
+	c.Set("club1/bob", 2)
+	c.Set("club2/alice", 3)
+
+	c.Delete("club1/alice")
+	if _, ok := c.Get("club1/alice"); ok {
+		t.Error("Expected the deleted entry to be gone")
+	}
+
+	c.DeleteFunc(func(key string) bool { return key[:5] == "club1" })
+	if _, ok := c.Get("club1/bob"); ok {
+		t.Error("Expected matching entries to be deleted")
+	}
+	if _, ok := c.Get("club2/alice"); !ok {
+		t.Error("Expected other entries to be kept")
+	}
+}
@@ -0,0 +1,61 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSiteVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "site-secret" {
+			t.Errorf("Expected the site secret, got %q", r.FormValue("secret"))
+		}
+		switch r.FormValue("response") {
+		case "good":
+			if r.FormValue("remoteip") != "203.0.113.7" {
+				t.Errorf("Expected the client IP, got %q", r.FormValue("remoteip"))
+			}
+			w.Write([]byte(`{"success":true}`))
+		case "down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	verifier := NewSiteVerify(server.URL, "site-secret")
+	ctx := context.Background()
+
+	if err := verifier.Verify(ctx, "good", "203.0.113.7"); err != nil {
+		t.Errorf("Expected passing response, got %v", err)
+	}
+	if err := verifier.Verify(ctx, "forged", ""); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected ErrFailed, got %v", err)
+	}
+	if err := verifier.Verify(ctx, "", ""); err != ErrMissing {
+		t.Errorf("Expected ErrMissing, got %v", err)
+	}
+	if err := verifier.Verify(ctx, "down", ""); err == nil || errors.Is(err, ErrFailed) {
+		t.Errorf("Expected an unavailable provider error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	verifier, err := New(Turnstile, "secret", "")
+	if err != nil || verifier.url != verifyURLs[Turnstile] {
+		t.Errorf("Expected the Turnstile endpoint, got %+v, %v", verifier, err)
+	}
+
+	verifier, err = New("custom", "secret", "https://captcha.example.com/verify")
+	if err != nil || verifier.url != "https://captcha.example.com/verify" {
+		t.Errorf("Expected the configured endpoint, got %+v, %v", verifier, err)
+	}
+
+	if _, err := New("custom", "secret", ""); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
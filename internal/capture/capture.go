@@ -0,0 +1,381 @@
+// Package capture records a sample of request/response pairs for debugging.
+//
+// Capture is opt-in and keeps a bounded number of exchanges in memory, oldest
+// evicted first. Credentials are removed before anything is stored: auth and
+// cookie headers, path segments matched by secret route parameters such as
+// {token}, and query parameters and JSON fields that look secret, are
+// replaced with Redacted. Bodies are cut off at a configured size. Captured
+// requests can be re-issued against another instance with cmd/replay.
+//
+// LogBodies applies the same redaction to write every request's bodies to the
+// log instead, for debugging outside production.
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Redacted replaces secret values in captured requests and responses
+const Redacted = "[redacted]"
+
+// redactedHeaders carry credentials and are never stored
+var redactedHeaders = map[string]bool{
+	"Authorization":   true,
+	"Cookie":          true,
+	"Set-Cookie":      true,
+	"X-Api-Key":       true,
+	"X-Publisher-Key": true,
+}
+
+// Exchange is one captured request and the response it got
+type Exchange struct {
+	ID              uuid.UUID         `json:"id"`
+	RequestID       string            `json:"requestId,omitempty"`
+	Method          string            `json:"method"`
+	Path            string            `json:"path"`
+	Query           string            `json:"query,omitempty"`
+	RequestHeaders  map[string]string `json:"requestHeaders"`
+	RequestBody     string            `json:"requestBody,omitempty"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"responseHeaders"`
+	ResponseBody    string            `json:"responseBody,omitempty"`
+	Truncated       bool              `json:"truncated,omitempty"` // a body was longer than the capture limit
+	DurationMs      int64             `json:"durationMs"`
+	CapturedAt      time.Time         `json:"capturedAt"`
+}
+
+// Recorder samples traffic into a ring buffer. A nil *Recorder captures nothing.
+type Recorder struct {
+	percent  float64
+	maxBody  int
+	sample   func() float64
+	mu       sync.Mutex
+	buffer   []Exchange
+	next     int
+	full     bool
+	captured int64
+}
+
+// NewRecorder keeps up to capacity exchanges, capturing percent (0-100) of
+// requests and at most maxBody bytes of each body
+func NewRecorder(capacity int, percent float64, maxBody int) *Recorder {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Recorder{
+		percent: min(max(percent, 0), 100),
+		maxBody: max(maxBody, 0),
+		sample:  func() float64 { return rand.Float64() * 100 },
+		buffer:  make([]Exchange, capacity),
+	}
+}
+
+// Middleware captures the sampled requests. It must run after RequestID.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rec == nil || rec.percent == 0 || rec.sample() >= rec.percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		requestBody, truncated := readBody(r, rec.maxBody)
+
+		cw := &captureWriter{ResponseWriter: w, limit: rec.maxBody}
+		next.ServeHTTP(cw, r)
+
+		status := cw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		rec.Record(Exchange{
+			ID:              uuid.New(),
+			RequestID:       logging.RequestIDFromContext(r.Context()),
+			Method:          r.Method,
+			Path:            redactPath(r),
+			Query:           redactQuery(r.URL.RawQuery),
+			RequestHeaders:  redactHeaders(r.Header),
+			RequestBody:     redactBody(r.Header.Get("Content-Type"), requestBody),
+			Status:          status,
+			ResponseHeaders: redactHeaders(w.Header()),
+			ResponseBody:    redactBody(w.Header().Get("Content-Type"), cw.body.Bytes()),
+			Truncated:       truncated || cw.truncated,
+			DurationMs:      time.Since(start).Milliseconds(),
+			CapturedAt:      start,
+		})
+	})
+}
+
+// readBody copies up to maxBody bytes of the request body and puts the whole
+// body back for the handler
+func readBody(r *http.Request, maxBody int) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBody)+1))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return nil, false
+	}
+	if len(head) > maxBody {
+		return head[:maxBody], true
+	}
+	return head, false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Record stores an exchange, evicting the oldest one when the buffer is full
+func (rec *Recorder) Record(exchange Exchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.buffer[rec.next] = exchange
+	rec.next = (rec.next + 1) % len(rec.buffer)
+	if rec.next == 0 {
+		rec.full = true
+	}
+	rec.captured++
+}
+
+// Recent returns up to limit captured exchanges, newest first
+func (rec *Recorder) Recent(limit int) []Exchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	count := rec.next
+	if rec.full {
+		count = len(rec.buffer)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+
+	exchanges := make([]Exchange, 0, count)
+	for i := 1; i <= count; i++ {
+		exchanges = append(exchanges, rec.buffer[(rec.next-i+len(rec.buffer))%len(rec.buffer)])
+	}
+	return exchanges
+}
+
+// Get returns the captured exchange with the given ID, if it is still held
+func (rec *Recorder) Get(id uuid.UUID) (Exchange, bool) {
+	for _, exchange := range rec.Recent(0) {
+		if exchange.ID == id {
+			return exchange, true
+		}
+	}
+	return Exchange{}, false
+}
+
+// Clear drops every captured exchange
+func (rec *Recorder) Clear() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.buffer = make([]Exchange, len(rec.buffer))
+	rec.next, rec.full = 0, false
+}
+
+// Stats reports the sampling settings, how many exchanges are held and how
+// many were captured since startup
+type Stats struct {
+	SamplePercent float64 `json:"samplePercent"`
+	Capacity      int     `json:"capacity"`
+	MaxBodyBytes  int     `json:"maxBodyBytes"`
+	Held          int     `json:"held"`
+	Captured      int64   `json:"captured"`
+}
+
+func (rec *Recorder) Stats() Stats {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	held := rec.next
+	if rec.full {
+		held = len(rec.buffer)
+	}
+	return Stats{
+		SamplePercent: rec.percent,
+		Capacity:      len(rec.buffer),
+		MaxBodyBytes:  rec.maxBody,
+		Held:          held,
+		Captured:      rec.captured,
+	}
+}
+
+// captureWriter keeps a copy of the first limit bytes of the response
+type captureWriter struct {
+	http.ResponseWriter
+	status    int
+	limit     int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if room := cw.limit - cw.body.Len(); room > 0 {
+		cw.body.Write(p[:min(room, len(p))])
+		if room < len(p) {
+			cw.truncated = true
+		}
+	} else if len(p) > 0 {
+		cw.truncated = true
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *captureWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection, for streams
+// that extend their write deadline
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// isSecret reports whether a header, parameter or field name looks like it
+// holds a credential
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"password", "token", "secret", "captcha", "apikey", "api_key"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] || isSecret(name) {
+			headers[name] = Redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// redactPath replaces the path segments filled by secret-looking route
+// parameters, such as the token in /api/invites/{token}. The route is only
+// known once the router has matched it, so this runs after the handler.
+func redactPath(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return r.URL.Path
+	}
+	secrets := map[string]bool{}
+	for i, key := range rctx.URLParams.Keys {
+		if isSecret(key) && rctx.URLParams.Values[i] != "" {
+			secrets[rctx.URLParams.Values[i]] = true
+		}
+	}
+	if len(secrets) == 0 {
+		return r.URL.Path
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if secrets[segment] {
+			segments[i] = Redacted
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redacted
+	}
+	return redactValues(values)
+}
+
+func redactValues(values url.Values) string {
+	for name := range values {
+		if isSecret(name) {
+			values[name] = []string{Redacted}
+		}
+	}
+	return values.Encode()
+}
+
+// redactBody redacts secret-looking fields in JSON and form bodies. Other
+// bodies, and ones cut off mid-value, are only kept if no secret-looking
+// name appears in them.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return redactValues(values)
+		}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redacted, err := json.Marshal(redactJSON(value)); err == nil {
+			return string(redacted)
+		}
+	}
+
+	if isSecret(string(body)) {
+		return Redacted
+	}
+	return string(body)
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSecret(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactJSON(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
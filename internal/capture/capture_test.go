@@ -0,0 +1,210 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareCapturesSanitizedExchange(t *testing.T) {
+	rec := NewRecorder(10, 100, 1024)
+
+	var seen string
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"success":true,"data":{"accessToken":"jwt","user":{"email":"ann@example.com"}}}`))
+	}))
+
+	body := `{"email":"ann@example.com","password":"hunter2","profile":{"apiKey":"k"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login?token=abc&page=2", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer jwt")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != body {
+		t.Errorf("Expected the handler to read the full body, got %q", seen)
+	}
+
+	exchanges := rec.Recent(0)
+	if len(exchanges) != 1 {
+		t.Fatalf("Expected one captured exchange, got %d", len(exchanges))
+	}
+	exchange := exchanges[0]
+	if exchange.Status != http.StatusCreated || exchange.Method != http.MethodPost || exchange.Path != "/api/auth/login" {
+		t.Errorf("Unexpected exchange %+v", exchange)
+	}
+	if exchange.RequestHeaders["Authorization"] != Redacted || exchange.ResponseHeaders["Set-Cookie"] != Redacted {
+		t.Errorf("Expected credential headers to be redacted, got %v and %v", exchange.RequestHeaders, exchange.ResponseHeaders)
+	}
+	if exchange.Query != "page=2&token=%5Bredacted%5D" {
+		t.Errorf("Expected the token parameter to be redacted, got %q", exchange.Query)
+	}
+	for _, secret := range []string{"hunter2", `"k"`} {
+		if strings.Contains(exchange.RequestBody, secret) {
+			t.Errorf("Expected %s to be redacted from %s", secret, exchange.RequestBody)
+		}
+	}
+	if !strings.Contains(exchange.RequestBody, "ann@example.com") {
+		t.Errorf("Expected other fields to be kept, got %s", exchange.RequestBody)
+	}
+	if strings.Contains(exchange.ResponseBody, `"jwt"`) {
+		t.Errorf("Expected the access token to be redacted from %s", exchange.ResponseBody)
+	}
+}
+
+func TestMiddlewareSampling(t *testing.T) {
+	rec := NewRecorder(10, 25, 1024)
+	draws := []float64{10, 30, 24.9, 99}
+	rec.sample = func() float64 {
+		d := draws[0]
+		draws = draws[1:]
+		return d
+	}
+
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 4; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/clubs", nil))
+	}
+
+	if stats := rec.Stats(); stats.Captured != 2 {
+		t.Errorf("Expected 2 of 4 requests to be captured, got %d", stats.Captured)
+	}
+}
+
+func TestMiddlewareTruncatesBodies(t *testing.T) {
+	rec := NewRecorder(10, 100, 8)
+
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/echo", strings.NewReader("0123456789abcdef"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Body.String() != "0123456789abcdef" {
+		t.Errorf("Expected the response to be unaffected, got %q", w.Body.String())
+	}
+	exchange := rec.Recent(1)[0]
+	if exchange.RequestBody != "01234567" || exchange.ResponseBody != "01234567" || !exchange.Truncated {
+		t.Errorf("Expected bodies cut off at 8 bytes, got %+v", exchange)
+	}
+}
+
+func TestRecorderRingBuffer(t *testing.T) {
+	rec := NewRecorder(3, 100, 0)
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		rec.Record(Exchange{Path: path})
+	}
+
+	recent := rec.Recent(0)
+	if len(recent) != 3 || recent[0].Path != "/d" || recent[2].Path != "/b" {
+		t.Errorf("Expected the 3 newest exchanges, newest first, got %+v", recent)
+	}
+	if limited := rec.Recent(2); len(limited) != 2 || limited[1].Path != "/c" {
+		t.Errorf("Expected the limit to apply, got %+v", limited)
+	}
+
+	rec.Clear()
+	if recent := rec.Recent(0); len(recent) != 0 {
+		t.Errorf("Expected an empty buffer after Clear, got %d", len(recent))
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"json array", "application/json", `[{"token":"t","id":1}]`, `[{"id":1,"token":"[redacted]"}]`},
+		{"form", "application/x-www-form-urlencoded", "password=x&user=ann", "password=%5Bredacted%5D&user=ann"},
+		{"cut off json with a secret", "application/json", `{"email":"a","password":"hun`, Redacted},
+		{"plain text", "text/plain", "ok", "ok"},
+	}
+
+	for _, tt := range tests {
+		if got := redactBody(tt.contentType, []byte(tt.body)); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestLogBodies(t *testing.T) {
+	var logs bytes.Buffer
+	handler := LogBodies(1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"data":{"refreshToken":"jwt","echo":` + string(body) + `}}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login?token=abc", strings.NewReader(`{"email":"ann@example.com","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(logging.NewContext(req.Context(), logging.New(&logs, "info", "json")))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("Expected the response to be unaffected, got %s", w.Body.String())
+	}
+	logged := logs.String()
+	for _, secret := range []string{"hunter2", "jwt", "abc"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted from the log, got %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "ann@example.com") || !strings.Contains(logged, `"status":200`) {
+		t.Errorf("Expected the sanitized bodies and status in the log, got %s", logged)
+	}
+}
+
+func TestRedactsTokenPathSegments(t *testing.T) {
+	rec := NewRecorder(10, 100, 1024)
+	var logs bytes.Buffer
+	router := chi.NewRouter()
+	router.Use(rec.Middleware)
+	router.Use(LogBodies(1024))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.Route("/api", func(r chi.Router) {
+		r.Get("/invites/{token}", ok)
+		r.Route("/helper/{token}", func(r chi.Router) {
+			r.Get("/events/{eventId}", ok)
+		})
+		r.Get("/clubs/{clubId}", ok)
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/invites/inv-secret", "/api/invites/" + Redacted},
+		{"/api/helper/helper-secret/events/42", "/api/helper/" + Redacted + "/events/42"},
+		{"/api/clubs/42", "/api/clubs/42"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req = req.WithContext(logging.NewContext(req.Context(), logging.New(&logs, "info", "json")))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got := rec.Recent(1)[0].Path; got != tt.expected {
+			t.Errorf("%s: expected captured path %s, got %s", tt.path, tt.expected, got)
+		}
+	}
+
+	for _, secret := range []string{"inv-secret", "helper-secret"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected %q to be redacted from the log, got %s", secret, logs.String())
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package capture
+
+import (
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/logging"
+)
+
+// LogBodies logs every request with its sanitized request and response bodies,
+// each cut off at maxBody bytes. It must run after RequestID and RequestLogger,
+// so the lines carry the request ID.
+func LogBodies(maxBody int) func(http.Handler) http.Handler {
+	maxBody = max(maxBody, 0)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestBody, truncated := readBody(r, maxBody)
+
+			cw := &captureWriter{ResponseWriter: w, limit: maxBody}
+			next.ServeHTTP(cw, r)
+
+			status := cw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			logging.FromContext(r.Context()).Info("request bodies",
+				"method", r.Method,
+				"path", redactPath(r),
+				"query", redactQuery(r.URL.RawQuery),
+				"status", status,
+				"request_body", redactBody(r.Header.Get("Content-Type"), requestBody),
+				"response_body", redactBody(w.Header().Get("Content-Type"), cw.body.Bytes()),
+				"truncated", truncated || cw.truncated,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		})
+	}
+}
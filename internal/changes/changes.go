@@ -0,0 +1,140 @@
+// Package changes keeps a short log of what changed in each club, for clients
+// following a club live instead of polling its resources.
+//
+// Each change only names what changed, such as an item of an event; clients
+// fetch the resource again to see how. Changes are numbered in the order
+// they were recorded, so a client that reconnects with the last number it saw
+// gets what it missed. The log is kept for a retention period; a client
+// further behind than that must reload everything.
+//
+// Recording a change wakes the streams of the club on this instance through a
+// notify.Hub keyed by club. Streams on other instances find it by rechecking.
+package changes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+// Types of change
+const (
+	EventCreated        = "event.created"
+	EventUpdated        = "event.updated"
+	EventDeleted        = "event.deleted"
+	ItemCreated         = "item.created"
+	ItemUpdated         = "item.updated"
+	ItemDeleted         = "item.deleted"
+	ItemsReordered      = "items.reordered" // the entity is the event
+	AvailabilityUpdated = "availability.updated"
+)
+
+// pruneInterval is how often changes past the retention are deleted
+const pruneInterval = time.Hour
+
+// Change is one entry in a club's log
+type Change struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	ClubID    uuid.UUID `json:"clubId"`
+	EventID   uuid.UUID `json:"eventId"`
+	EntityID  uuid.UUID `json:"entityId"` // the item, or the member whose availability changed; the event itself for event changes
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Log records clubs' changes and reads them back in order
+type Log struct {
+	db        *database.DB
+	hub       *notify.Hub
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+// NewLog wakes the streams waiting in hub when a change is recorded and
+// keeps changes for retention
+func NewLog(db *database.DB, hub *notify.Hub, retention time.Duration, logger *slog.Logger) *Log {
+	return &Log{db: db, hub: hub, retention: retention, logger: logger}
+}
+
+// Record adds a change of type typ to entityID of the club's event eventID
+func (l *Log) Record(ctx context.Context, clubID uuid.UUID, typ string, eventID, entityID uuid.UUID) error {
+	_, err := l.db.ExecContext(ctx, `
+		INSERT INTO club_changes (club_id, type, event_id, entity_id) VALUES ($1, $2, $3, $4)`,
+		clubID, typ, eventID, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	l.hub.Publish([]uuid.UUID{clubID})
+	return nil
+}
+
+// Since returns up to limit of the club's changes after the one numbered
+// after, oldest first
+func (l *Log) Since(ctx context.Context, clubID uuid.UUID, after int64, limit int) ([]Change, error) {
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT id, type, club_id, event_id, entity_id, created_at
+		FROM club_changes
+		WHERE club_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`, clubID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []Change{}
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ID, &c.Type, &c.ClubID, &c.EventID, &c.EntityID, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// Bounds returns the numbers of the oldest change still kept and of the
+// latest, both 0 when the log is empty. A client whose last change is
+// before oldest-1 may have missed pruned changes.
+func (l *Log) Bounds(ctx context.Context) (oldest, latest int64, err error) {
+	err = l.db.QueryRowContext(ctx, `SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0) FROM club_changes`).Scan(&oldest, &latest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query change log: %w", err)
+	}
+	return oldest, latest, nil
+}
+
+// Prune deletes changes older than the retention and returns how many were deleted
+func (l *Log) Prune(ctx context.Context) (int64, error) {
+	result, err := l.db.ExecContext(ctx,
+		`DELETE FROM club_changes WHERE created_at < NOW() - $1 * INTERVAL '1 millisecond'`, l.retention.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune change log: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Run prunes the log every hour until ctx is cancelled
+func (l *Log) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if count, err := l.Prune(ctx); err != nil {
+			l.logger.Error("error pruning change log", "error", err)
+		} else if count > 0 {
+			l.logger.Info("pruned change log", "count", count)
+		}
+	}
+}
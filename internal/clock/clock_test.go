@@ -0,0 +1,30 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	if !c.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, c.Now())
+	}
+	c.Advance(90 * time.Minute)
+	if want := start.Add(90 * time.Minute); !c.Now().Equal(want) {
+		t.Errorf("Expected %v after advancing, got %v", want, c.Now())
+	}
+	c.Set(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("Expected %v after setting, got %v", start, c.Now())
+	}
+}
+
+func TestReal(t *testing.T) {
+	before := time.Now()
+	if now := Real.Now(); now.Before(before) || now.Sub(before) > time.Second {
+		t.Errorf("Expected the system time, got %v", now)
+	}
+}
@@ -1,21 +1,55 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
-	Security SecurityConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	CORS          CORSConfig
+	TLS           TLSConfig
+	Security      SecurityConfig
+	Registration  RegistrationConfig
+	Logging       LoggingConfig
+	Sandbox       SandboxConfig
+	Availability  AvailabilityConfig
+	Archive       ArchiveConfig
+	Attachments   AttachmentsConfig
+	Avatars       AvatarsConfig
+	Billing       BillingConfig
+	Email         EmailConfig
+	Notifications NotificationsConfig
+	Publishers    PublishersConfig
+	Shadow        ShadowConfig
+	OAuth         OAuthConfig
+	Recommend     RecommendConfig
+	Analytics     AnalyticsConfig
+	Contact       ContactConfig
+	Captcha       CaptchaConfig
+	Polls         PollsConfig
+	Outbox        OutboxConfig
+	Webhooks      WebhooksConfig
+	Integrations  IntegrationsConfig
+	Streams       StreamsConfig
+	Yearbooks     YearbooksConfig
+	Posters       PostersConfig
+	Exports       ExportsConfig
+	NetworkACL    NetworkACLConfig
+	Capture       CaptureConfig
+	Deployment    DeploymentConfig
+
+	// envProblems are the malformed values Load found, reported by Validate
+	envProblems []string
 }
 
 type ServerConfig struct {
@@ -24,18 +58,290 @@ type ServerConfig struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	AllowedOrigins []string
+
+	// How long shutdown waits for requests, jobs and deliveries to finish
+	ShutdownTimeout time.Duration
+
+	// How long a readiness result is reused before the checks run again
+	ReadinessCacheTTL time.Duration
+}
+
+// TLSConfig lets the API terminate TLS itself, for deployments without a load
+// balancer in front: with a certificate and key, or with autocert domains for
+// certificates from Let's Encrypt. HTTPPort, when set, answers plain HTTP with
+// redirects to HTTPS and the ACME challenges.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+
+	HTTPPort string
+}
+
+// Enabled reports whether the API serves HTTPS itself
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.AutocertDomains) > 0
 }
 
 type SecurityConfig struct {
 	EnableHSTS      bool
 	HSTSMaxAge      int
 	EnableHTTPSOnly bool
+	PublicHost      string // host[:port] plain HTTP is redirected to with EnableHTTPSOnly
+
+	// Failed attempts on tokenized public links before the client IP or token is locked out
+	TokenMaxFailures int
+	TokenLockout     time.Duration
+
+	// Club roles cached for authorization; a zero TTL disables the cache
+	RoleCacheTTL  time.Duration
+	RoleCacheSize int
+
+	// Bearer token that reads the database metrics without an admin login,
+	// for scrapers; empty requires an admin
+	MetricsToken string
+}
+
+type RegistrationConfig struct {
+	MinimumAge   int
+	TermsVersion string
+}
+
+// SandboxConfig controls throwaway sandbox accounts and clubs for integrators
+type SandboxConfig struct {
+	Enabled       bool
+	RetentionDays int
+	PurgeInterval time.Duration
+}
+
+// AvailabilityConfig controls the precomputed availability summaries
+type AvailabilityConfig struct {
+	SummaryRepairInterval time.Duration
+}
+
+// ArchiveConfig controls moving past events into yearly cold-storage partitions
+type ArchiveConfig struct {
+	AfterYears       int // 0 disables archiving
+	DetachAfterYears int // 0 never detaches archive partitions
+	Interval         time.Duration
+}
+
+// AttachmentsConfig controls file uploads on event items and club resources
+type AttachmentsConfig struct {
+	Backend      string // "local" or "s3"
+	LocalDir     string
+	MaxSizeBytes int64
+	AllowedTypes []string
+	URLTTL       time.Duration // lifetime of signed download URLs
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// AvatarsConfig controls profile picture uploads, which are stored in the attachment storage
+type AvatarsConfig struct {
+	MaxSizeBytes  int64
+	PublicBaseURL string // e.g. a CDN in front of /api/avatars; empty serves them from this API
+}
+
+// NotificationsConfig caps the clients long-polling for notifications on one instance
+type NotificationsConfig struct {
+	PollMaxWaiters int
+	PollMaxPerUser int
+}
+
+// BillingConfig connects the Stripe account members pay dues through
+type BillingConfig struct {
+	StripeWebhookSecret string // empty disables the Stripe webhook
+}
+
+// EmailConfig connects the email service provider's bounce and complaint reports
+type EmailConfig struct {
+	WebhookSecret string // empty disables the email webhook
+}
+
+// PublishersConfig controls keys and request signing for sites embedding club widgets
+type PublishersConfig struct {
+	DefaultQuota        int           // signed requests per minute for new publishers
+	DefaultReplayWindow time.Duration // tolerance for signed request timestamps
+	RefreshInterval     time.Duration // how often keys are reloaded and usage is written
+}
+
+// ShadowConfig sets the mode of each shadowed schema refactor: off, dual_write,
+// shadow_read or cutover
+type ShadowConfig struct {
+	EventStartsAt string // event_date and event_time moving to starts_at
+}
+
+// OAuthConfig enables sign-in with external identity providers; a provider
+// without a client ID is disabled
+type OAuthConfig struct {
+	CallbackBaseURL     string // public base URL of the API the providers redirect back to
+	FrontendRedirectURL string // where the callback sends the browser with the tokens; empty responds with JSON
+	StateTTL            time.Duration
+
+	GoogleClientID     string
+	GoogleClientSecret string
+	GitHubClientID     string
+	GitHubClientSecret string
+}
+
+// ContactConfig throttles the public contact form of clubs
+type ContactConfig struct {
+	PerClientLimit int // messages per client IP per hour
+}
+
+// CaptchaConfig requires a CAPTCHA on the listed public endpoints. Checks are
+// off without a secret.
+type CaptchaConfig struct {
+	Provider  string // hcaptcha, turnstile or recaptcha
+	Secret    string
+	VerifyURL string   // overrides the provider's siteverify endpoint
+	Endpoints []string // register, contact
+}
+
+// Requires reports whether endpoint needs a CAPTCHA
+func (c CaptchaConfig) Requires(endpoint string) bool {
+	if c.Secret == "" {
+		return false
+	}
+	for _, e := range c.Endpoints {
+		if strings.TrimSpace(e) == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkACLConfig lists network ranges (CIDRs or addresses) refused on every
+// route, and those the admin routes are limited to; an empty admin list leaves
+// them open. Admins can add ranges at runtime too.
+type NetworkACLConfig struct {
+	Deny            []string
+	AdminAllow      []string
+	TrustProxy      bool // take the client address from the proxy's X-Forwarded-For entry
+	RefreshInterval time.Duration
+}
+
+// CaptureConfig controls opt-in capture of sanitized request/response pairs for
+// debugging. SamplePercent is the share of requests captured, from 0 to 100.
+type CaptureConfig struct {
+	Enabled       bool
+	SamplePercent float64
+	BufferSize    int
+	MaxBodyBytes  int
+}
+
+// DeploymentConfig describes how many instances serve traffic. In clustered
+// mode any instance may serve any request, so startup fails while features keep
+// state only in process memory. Backend URLs record what shared infrastructure
+// is available to move that state to.
+type DeploymentConfig struct {
+	Mode      string // single or clustered
+	RedisURL  string
+	NATSURL   string
+	AuditOnly bool // print the scaling audit and exit
+}
+
+// OutboxConfig controls the poller publishing outbox messages, such as
+// notifications written with the change they report
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Retention    time.Duration // how long published messages are kept
+}
+
+// WebhooksConfig controls the delivery of club events to the webhooks clubs register
+type WebhooksConfig struct {
+	Timeout       time.Duration // how long an endpoint has to answer
+	MaxAttempts   int           // attempts before a delivery is given up
+	RetryBackoff  time.Duration // wait before the first retry, doubled after each
+	AllowInsecure bool          // accept http URLs and private addresses, for local development
+}
+
+// IntegrationsConfig controls announcements in the Slack and Discord webhooks clubs connect
+type IntegrationsConfig struct {
+	Timeout          time.Duration // how long the provider has to answer
+	MaxAttempts      int           // attempts before an announcement is dropped
+	RetryBackoff     time.Duration // wait before the first retry, doubled after each
+	ReminderInterval time.Duration // how often upcoming events are checked for reminders
+}
+
+// StreamsConfig controls the Server-Sent Events streams of club changes
+type StreamsConfig struct {
+	MaxClients      int           // streams open at once on this instance
+	MaxPerClub      int           // streams open at once for one club
+	ChangeRetention time.Duration // how long changes are kept for clients to resume from
+}
+
+// PollsConfig controls the job closing book polls at their deadline
+type PollsConfig struct {
+	CloseInterval time.Duration
+}
+
+// YearbooksConfig controls the job compiling club yearbooks
+type YearbooksConfig struct {
+	PollInterval time.Duration // how often to look for requests queued by other instances
+	LinkTTL      time.Duration // how long the download link in the ready notification works
+}
+
+// PostersConfig controls event posters
+type PostersConfig struct {
+	GuestPageURL string // frontend page the QR codes open, with the guest token appended
+}
+
+// ExportsConfig controls the job compiling users' data exports
+type ExportsConfig struct {
+	PollInterval time.Duration // how often to look for requests queued by other instances
+	Retention    time.Duration // how long a ready archive is kept before it is deleted
 }
 
+// AnalyticsConfig controls the job refreshing the analytics summary views and
+// the counting of requests per endpoint
+type AnalyticsConfig struct {
+	RefreshInterval    time.Duration
+	UsageEnabled       bool
+	UsageFlushInterval time.Duration // how often endpoint usage counters are written
+}
+
+// RecommendConfig controls the job computing similar clubs for public club pages
+type RecommendConfig struct {
+	PerClub  int
+	Interval time.Duration
+}
+
+// LoggingConfig controls the application log. DebugBodies adds sanitized
+// request and response bodies, cut off at DebugBodyMaxBytes, to every request's
+// log; it is for reproducing bugs locally and is refused in production.
+type LoggingConfig struct {
+	Level             string
+	Format            string
+	DebugBodies       bool
+	DebugBodyMaxBytes int
+}
+
+// CORSConfig lists the browser origins allowed to call the API. Origins may
+// use a wildcard subdomain, as in https://*.bookwork.app; without
+// ALLOWED_ORIGINS the list comes from the preset for ENV.
 type CORSConfig struct {
-	AllowedOrigins   []string
-	AllowCredentials bool
-	MaxAge           int
+	AllowedOrigins            []string
+	AllowCredentials          bool
+	OriginsWithoutCredentials []string
+	MaxAge                    int
+}
+
+// corsOriginPresets are the allowed origins for each ENV
+var corsOriginPresets = map[string][]string{
+	"development": {"http://localhost:5173", "http://localhost:3000"},
+	"staging":     {"https://*.staging.bookwork.app"},
+	"production":  {"https://bookwork.app", "https://*.bookwork.app"},
 }
 
 type DatabaseConfig struct {
@@ -50,26 +356,54 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
 	PgBouncerAddr   string
+
+	// Read replicas for list queries, each a libpq connection string or
+	// postgres:// URL; reads fall back to the primary when none is healthy
+	ReadReplicaDSNs      []string
+	ReplicaMaxLag        time.Duration // replicas further behind are not read from
+	ReplicaCheckInterval time.Duration
+
+	// Confirms no instance of an older app version is running, so contract
+	// migrations that drop or rename what it uses may run at startup
+	AllowContractMigrations bool
 }
 
 type JWTConfig struct {
 	SecretKey string
 	Issuer    string
+
+	// Asymmetric signing, so other services can check tokens against the
+	// published keys; without a signing key, tokens are signed with SecretKey
+	SigningKeyFile       string   // PEM private key: RSA for RS256, Ed25519 for EdDSA
+	VerificationKeyFiles []string // PEM public keys of retired signing keys, accepted until their tokens expire
+	AcceptSecret         bool     // also accept tokens signed with SecretKey, e.g. while switching to a signing key
 }
 
+// loadMu serializes Load, which collects malformed values in envProblems
+var (
+	loadMu      sync.Mutex
+	envProblems []string
+)
+
 func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	envProblems = nil
+
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+		slog.Info("no .env file found, using environment variables")
 	}
 
 	config := &Config{
 		Server: ServerConfig{
-			Port:           getEnv("PORT", "8000"),
-			Host:           getEnv("HOST", "localhost"),
-			ReadTimeout:    getEnvAsDuration("READ_TIMEOUT", "30s"),
-			WriteTimeout:   getEnvAsDuration("WRITE_TIMEOUT", "30s"),
-			AllowedOrigins: getEnvAsStringArray("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
+			Port:              getEnv("PORT", "8000"),
+			Host:              getEnv("HOST", "localhost"),
+			ReadTimeout:       getEnvAsDuration("READ_TIMEOUT", "30s"),
+			WriteTimeout:      getEnvAsDuration("WRITE_TIMEOUT", "30s"),
+			ShutdownTimeout:   getEnvAsDuration("SHUTDOWN_TIMEOUT", "30s"),
+			ReadinessCacheTTL: getEnvAsDuration("READINESS_CACHE_TTL", "5s"),
+			AllowedOrigins:    getEnvAsStringArray("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -82,27 +416,221 @@ func Load() (*Config, error) {
 			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
 			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", "5m"),
 			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", "2m"),
-			PgBouncerAddr:   getEnv("PGBOUNCER_ADDR", ""),
+			PgBouncerAddr:   getEnv("DB_PGBOUNCER_ADDR", getEnv("PGBOUNCER_ADDR", "")),
+
+			ReadReplicaDSNs:      getEnvAsStringArray("DB_READ_REPLICA_DSNS", nil),
+			ReplicaMaxLag:        getEnvAsDuration("DB_REPLICA_MAX_LAG", "30s"),
+			ReplicaCheckInterval: getEnvAsDuration("DB_REPLICA_CHECK_INTERVAL", "10s"),
+
+			AllowContractMigrations: getEnvAsBool("ALLOW_CONTRACT_MIGRATIONS", false),
 		},
 		JWT: JWTConfig{
 			SecretKey: getJWTSecret(),
 			Issuer:    getEnv("JWT_ISSUER", "bookwork-api"),
+
+			SigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+			VerificationKeyFiles: getEnvAsStringArray("JWT_VERIFICATION_KEY_FILES", nil),
+			AcceptSecret:         getEnvAsBool("JWT_ACCEPT_SECRET", true),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins:   getEnvAsStringArray("ALLOWED_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
-			AllowCredentials: getEnvAsBool("ALLOW_CREDENTIALS", true),
-			MaxAge:           getEnvAsInt("CORS_MAX_AGE", 300),
+			AllowedOrigins:            getEnvAsStringArray("ALLOWED_ORIGINS", corsOrigins()),
+			AllowCredentials:          getEnvAsBool("ALLOW_CREDENTIALS", true),
+			OriginsWithoutCredentials: getEnvAsStringArray("CORS_NO_CREDENTIALS_ORIGINS", nil),
+			MaxAge:                    getEnvAsInt("CORS_MAX_AGE", 300),
+		},
+		TLS: TLSConfig{
+			CertFile:         getEnv("TLS_CERT_FILE", ""),
+			KeyFile:          getEnv("TLS_KEY_FILE", ""),
+			AutocertDomains:  getEnvAsStringArray("TLS_AUTOCERT_DOMAINS", nil),
+			AutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "./autocert-cache"),
+			AutocertEmail:    getEnv("TLS_AUTOCERT_EMAIL", ""),
+			HTTPPort:         getEnv("TLS_HTTP_PORT", ""),
 		},
 		Security: SecurityConfig{
 			EnableHSTS:      getEnvAsBool("ENABLE_HSTS", true),
 			HSTSMaxAge:      getEnvAsInt("HSTS_MAX_AGE", 31536000),
 			EnableHTTPSOnly: getEnvAsBool("ENABLE_HTTPS_ONLY", false),
+			PublicHost:      getEnv("PUBLIC_HOST", ""),
+
+			TokenMaxFailures: getEnvAsInt("TOKEN_MAX_FAILURES", 10),
+			TokenLockout:     getEnvAsDuration("TOKEN_LOCKOUT", "15m"),
+
+			RoleCacheTTL:  getEnvAsDuration("ROLE_CACHE_TTL", "30s"),
+			RoleCacheSize: getEnvAsInt("ROLE_CACHE_SIZE", 10000),
+
+			MetricsToken: getEnv("METRICS_TOKEN", ""),
+		},
+		Registration: RegistrationConfig{
+			MinimumAge:   getEnvAsInt("MIN_REGISTRATION_AGE", 13),
+			TermsVersion: getEnv("TERMS_VERSION", "2024-01-01"),
+		},
+		Logging: LoggingConfig{
+			Level:             getEnv("LOG_LEVEL", "info"),
+			Format:            getEnv("LOG_FORMAT", "json"),
+			DebugBodies:       getEnvAsBool("LOG_DEBUG_BODIES", false),
+			DebugBodyMaxBytes: getEnvAsInt("LOG_DEBUG_BODY_MAX_BYTES", 4096),
+		},
+		Sandbox: SandboxConfig{
+			Enabled:       getEnvAsBool("SANDBOX_ENABLED", false),
+			RetentionDays: getEnvAsInt("SANDBOX_RETENTION_DAYS", 7),
+			PurgeInterval: getEnvAsDuration("SANDBOX_PURGE_INTERVAL", "1h"),
+		},
+		Availability: AvailabilityConfig{
+			SummaryRepairInterval: getEnvAsDuration("AVAILABILITY_REPAIR_INTERVAL", "1h"),
+		},
+		Archive: ArchiveConfig{
+			AfterYears:       getEnvAsInt("EVENT_ARCHIVE_AFTER_YEARS", 0),
+			DetachAfterYears: getEnvAsInt("EVENT_ARCHIVE_DETACH_AFTER_YEARS", 0),
+			Interval:         getEnvAsDuration("EVENT_ARCHIVE_INTERVAL", "24h"),
+		},
+		Attachments: AttachmentsConfig{
+			Backend:      getEnv("ATTACHMENTS_BACKEND", "local"),
+			LocalDir:     getEnv("ATTACHMENTS_DIR", "./uploads"),
+			MaxSizeBytes: int64(getEnvAsInt("ATTACHMENTS_MAX_SIZE", 10<<20)),
+			AllowedTypes: getEnvAsStringArray("ATTACHMENTS_ALLOWED_TYPES", []string{
+				"application/pdf", "image/png", "image/jpeg", "image/gif", "image/webp", "text/plain",
+			}),
+			URLTTL:      getEnvAsDuration("ATTACHMENTS_URL_TTL", "15m"),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY_ID", ""),
+			S3SecretKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+			S3PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+		},
+		Avatars: AvatarsConfig{
+			MaxSizeBytes:  int64(getEnvAsInt("AVATAR_MAX_SIZE", 5<<20)),
+			PublicBaseURL: getEnv("AVATAR_PUBLIC_BASE_URL", ""),
+		},
+		Billing: BillingConfig{
+			StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		},
+		Email: EmailConfig{
+			WebhookSecret: getEnv("EMAIL_WEBHOOK_SECRET", ""),
+		},
+		Notifications: NotificationsConfig{
+			PollMaxWaiters: getEnvAsInt("NOTIFICATIONS_POLL_MAX_WAITERS", 1000),
+			PollMaxPerUser: getEnvAsInt("NOTIFICATIONS_POLL_MAX_PER_USER", 3),
+		},
+		Publishers: PublishersConfig{
+			DefaultQuota:        getEnvAsInt("PUBLISHER_DEFAULT_QUOTA", 600),
+			DefaultReplayWindow: getEnvAsDuration("PUBLISHER_REPLAY_WINDOW", "5m"),
+			RefreshInterval:     getEnvAsDuration("PUBLISHER_REFRESH_INTERVAL", "1m"),
+		},
+		Shadow: ShadowConfig{
+			EventStartsAt: getEnv("SHADOW_EVENT_STARTS_AT", "dual_write"),
+		},
+		Analytics: AnalyticsConfig{
+			RefreshInterval:    getEnvAsDuration("ANALYTICS_REFRESH_INTERVAL", "15m"),
+			UsageEnabled:       getEnvAsBool("ANALYTICS_USAGE_ENABLED", true),
+			UsageFlushInterval: getEnvAsDuration("ANALYTICS_USAGE_FLUSH_INTERVAL", "1m"),
+		},
+		Recommend: RecommendConfig{
+			PerClub:  getEnvAsInt("CLUB_RECOMMENDATIONS_PER_CLUB", 5),
+			Interval: getEnvAsDuration("CLUB_RECOMMENDATIONS_INTERVAL", "6h"),
 		},
+		Contact: ContactConfig{
+			PerClientLimit: getEnvAsInt("CLUB_CONTACT_HOURLY_LIMIT", 5),
+		},
+		Captcha: CaptchaConfig{
+			Provider:  getEnv("CAPTCHA_PROVIDER", "hcaptcha"),
+			Secret:    getEnv("CAPTCHA_SECRET", ""),
+			VerifyURL: getEnv("CAPTCHA_VERIFY_URL", ""),
+			Endpoints: getEnvAsStringArray("CAPTCHA_ENDPOINTS", []string{"register", "contact"}),
+		},
+		NetworkACL: NetworkACLConfig{
+			Deny:            getEnvAsStringArray("NETWORK_DENYLIST", nil),
+			AdminAllow:      getEnvAsStringArray("ADMIN_NETWORK_ALLOWLIST", nil),
+			TrustProxy:      getEnvAsBool("NETWORK_ACL_TRUST_PROXY", false),
+			RefreshInterval: getEnvAsDuration("NETWORK_RULES_REFRESH_INTERVAL", "1m"),
+		},
+		Capture: CaptureConfig{
+			Enabled:       getEnvAsBool("CAPTURE_ENABLED", false),
+			SamplePercent: getEnvAsFloat("CAPTURE_SAMPLE_PERCENT", 1),
+			BufferSize:    getEnvAsInt("CAPTURE_BUFFER_SIZE", 500),
+			MaxBodyBytes:  getEnvAsInt("CAPTURE_MAX_BODY_BYTES", 16384),
+		},
+		Deployment: DeploymentConfig{
+			Mode:      getEnv("DEPLOYMENT_MODE", "single"),
+			RedisURL:  getEnv("REDIS_URL", ""),
+			NATSURL:   getEnv("NATS_URL", ""),
+			AuditOnly: getEnvAsBool("SCALING_AUDIT_ONLY", false),
+		},
+		Polls: PollsConfig{
+			CloseInterval: getEnvAsDuration("POLL_CLOSE_INTERVAL", "1m"),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", "1s"),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			Retention:    getEnvAsDuration("OUTBOX_RETENTION", "168h"),
+		},
+		Webhooks: WebhooksConfig{
+			Timeout:       getEnvAsDuration("WEBHOOK_TIMEOUT", "10s"),
+			MaxAttempts:   getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			RetryBackoff:  getEnvAsDuration("WEBHOOK_RETRY_BACKOFF", "30s"),
+			AllowInsecure: getEnvAsBool("WEBHOOK_ALLOW_INSECURE", false),
+		},
+		Integrations: IntegrationsConfig{
+			Timeout:          getEnvAsDuration("INTEGRATION_TIMEOUT", "10s"),
+			MaxAttempts:      getEnvAsInt("INTEGRATION_MAX_ATTEMPTS", 5),
+			RetryBackoff:     getEnvAsDuration("INTEGRATION_RETRY_BACKOFF", "30s"),
+			ReminderInterval: getEnvAsDuration("INTEGRATION_REMINDER_INTERVAL", "5m"),
+		},
+		Streams: StreamsConfig{
+			MaxClients:      getEnvAsInt("STREAM_MAX_CLIENTS", 1000),
+			MaxPerClub:      getEnvAsInt("STREAM_MAX_PER_CLUB", 200),
+			ChangeRetention: getEnvAsDuration("CHANGE_LOG_RETENTION", "24h"),
+		},
+		Yearbooks: YearbooksConfig{
+			PollInterval: getEnvAsDuration("YEARBOOK_POLL_INTERVAL", "30s"),
+			LinkTTL:      getEnvAsDuration("YEARBOOK_LINK_TTL", "168h"),
+		},
+		Posters: PostersConfig{
+			GuestPageURL: getEnv("POSTER_GUEST_PAGE_URL", "http://localhost:3000/guest/events"),
+		},
+		Exports: ExportsConfig{
+			PollInterval: getEnvAsDuration("EXPORT_POLL_INTERVAL", "30s"),
+			Retention:    getEnvAsDuration("EXPORT_RETENTION", "168h"),
+		},
+		OAuth: OAuthConfig{
+			CallbackBaseURL:     getEnv("OAUTH_CALLBACK_BASE_URL", "http://localhost:8000"),
+			FrontendRedirectURL: getEnv("OAUTH_FRONTEND_REDIRECT_URL", ""),
+			StateTTL:            getEnvAsDuration("OAUTH_STATE_TTL", "10m"),
+			GoogleClientID:      getEnv("GOOGLE_CLIENT_ID", ""),
+			GoogleClientSecret:  getEnv("GOOGLE_CLIENT_SECRET", ""),
+			GitHubClientID:      getEnv("GITHUB_CLIENT_ID", ""),
+			GitHubClientSecret:  getEnv("GITHUB_CLIENT_SECRET", ""),
+		},
+	}
+
+	config.envProblems = envProblems
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// corsOrigins returns the preset origins for ENV, falling back to the
+// development ones for unknown environments
+func corsOrigins() []string {
+	env := getEnv("ENV", "development")
+	if isProduction() {
+		env = "production"
+	}
+	if origins, ok := corsOriginPresets[env]; ok {
+		return origins
+	}
+	return corsOriginPresets["development"]
+}
+
+// isProduction reports whether ENV names a production deployment
+func isProduction() bool {
+	env := os.Getenv("ENV")
+	return env == "production" || env == "prod"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -115,7 +643,17 @@ func getEnvAsInt(key string, defaultValue int) int {
 		if intVal, err := strconv.Atoi(value); err == nil {
 			return intVal
 		}
-		log.Printf("Warning: Invalid integer value for %s: %s, using default: %d", key, value, defaultValue)
+		envProblems = append(envProblems, fmt.Sprintf("%s must be an integer, got %q", key, value))
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+		envProblems = append(envProblems, fmt.Sprintf("%s must be a number, got %q", key, value))
 	}
 	return defaultValue
 }
@@ -125,7 +663,7 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
 			return boolVal
 		}
-		log.Printf("Warning: Invalid boolean value for %s: %s, using default: %t", key, value, defaultValue)
+		envProblems = append(envProblems, fmt.Sprintf("%s must be true or false, got %q", key, value))
 	}
 	return defaultValue
 }
@@ -143,43 +681,34 @@ func getEnvAsDuration(key string, defaultValue string) time.Duration {
 		return duration
 	}
 	if defaultDuration, err := time.ParseDuration(defaultValue); err == nil {
-		log.Printf("Warning: Invalid duration value for %s: %s, using default: %s", key, value, defaultValue)
+		envProblems = append(envProblems, fmt.Sprintf("%s must be a duration such as 30s or 5m, got %q", key, value))
 		return defaultDuration
 	}
-	log.Printf("Error: Invalid default duration value: %s, using 5m", defaultValue)
+	slog.Error("invalid default duration value, using 5m", "default", defaultValue)
 	return 5 * time.Minute
 }
 
-// getJWTSecret returns the JWT secret key with production validation
+// oldDefaultJWTSecret is the example secret shipped in older .env files
+const oldDefaultJWTSecret = "your-super-secret-jwt-key-change-this-in-production"
+
+// getJWTSecret returns the JWT secret key, with a development default when it
+// is not set outside production. Validate refuses weak secrets in production.
 func getJWTSecret() string {
 	secret := os.Getenv("JWT_SECRET")
 
-	// If no JWT secret is provided
 	if secret == "" {
-		// In production environment, JWT_SECRET is required
-		if env := os.Getenv("ENV"); env == "production" || env == "prod" {
-			log.Fatal("JWT_SECRET environment variable is required in production")
+		if isProduction() {
+			return ""
 		}
-
-		// For development, warn about using default and provide a secure default
-		log.Println("Warning: JWT_SECRET not set, using development default. SET JWT_SECRET for production!")
+		slog.Warn("JWT_SECRET not set, using development default. SET JWT_SECRET for production!")
 		return "dev-jwt-secret-change-this-in-production-environments-use-at-least-32-characters"
 	}
 
-	// Validate secret length (minimum 32 characters for security)
 	if len(secret) < 32 {
-		log.Printf("Warning: JWT_SECRET should be at least 32 characters (current: %d)", len(secret))
-		if env := os.Getenv("ENV"); env == "production" || env == "prod" {
-			log.Fatal("JWT_SECRET must be at least 32 characters in production")
-		}
+		slog.Warn("JWT_SECRET should be at least 32 characters", "length", len(secret))
 	}
-
-	// Check if using the old default value
-	if secret == "your-super-secret-jwt-key-change-this-in-production" {
-		log.Println("Warning: You are using the default JWT_SECRET. Please change it for security!")
-		if env := os.Getenv("ENV"); env == "production" || env == "prod" {
-			log.Fatal("Cannot use default JWT_SECRET in production")
-		}
+	if secret == oldDefaultJWTSecret {
+		slog.Warn("you are using the default JWT_SECRET. Please change it for security!")
 	}
 
 	return secret
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -182,3 +184,219 @@ func TestCORSConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestRegistrationConfig(t *testing.T) {
+	originalAge := os.Getenv("MIN_REGISTRATION_AGE")
+	originalTerms := os.Getenv("TERMS_VERSION")
+	defer func() {
+		os.Setenv("MIN_REGISTRATION_AGE", originalAge)
+		os.Setenv("TERMS_VERSION", originalTerms)
+	}()
+
+	os.Unsetenv("MIN_REGISTRATION_AGE")
+	os.Unsetenv("TERMS_VERSION")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.Registration.MinimumAge != 13 {
+		t.Errorf("Expected default minimum age 13, got %d", config.Registration.MinimumAge)
+	}
+
+	os.Setenv("MIN_REGISTRATION_AGE", "16")
+	os.Setenv("TERMS_VERSION", "2025-06-01")
+
+	config, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.Registration.MinimumAge != 16 {
+		t.Errorf("Expected minimum age 16, got %d", config.Registration.MinimumAge)
+	}
+
+	if config.Registration.TermsVersion != "2025-06-01" {
+		t.Errorf("Expected terms version 2025-06-01, got %s", config.Registration.TermsVersion)
+	}
+}
+
+func TestCaptchaRequires(t *testing.T) {
+	off := CaptchaConfig{Endpoints: []string{"register", "contact"}}
+	if off.Requires("register") {
+		t.Error("Expected no CAPTCHA without a secret")
+	}
+
+	on := CaptchaConfig{Secret: "secret", Endpoints: []string{"register", " contact"}}
+	if !on.Requires("register") || !on.Requires("contact") {
+		t.Error("Expected CAPTCHA on the listed endpoints")
+	}
+	if on.Requires("login") {
+		t.Error("Expected no CAPTCHA on unlisted endpoints")
+	}
+}
+
+func TestDebugBodiesRefusedInProduction(t *testing.T) {
+	t.Setenv("LOG_DEBUG_BODIES", "true")
+	t.Setenv("JWT_SECRET", "a-test-secret-that-is-at-least-32-characters")
+
+	t.Setenv("ENV", "development")
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !config.Logging.DebugBodies || config.Logging.DebugBodyMaxBytes != 4096 {
+		t.Errorf("Expected body logging with the default limit, got %+v", config.Logging)
+	}
+
+	t.Setenv("ENV", "production")
+	if _, err := Load(); err == nil {
+		t.Error("Expected body logging to be refused in production")
+	}
+}
+
+func TestCORSPresets(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "")
+	t.Setenv("JWT_SECRET", "a-test-secret-that-is-at-least-32-characters")
+	t.Setenv("DB_PASSWORD", "secret")
+
+	tests := []struct {
+		env     string
+		origins []string
+	}{
+		{"", []string{"http://localhost:5173", "http://localhost:3000"}},
+		{"staging", []string{"https://*.staging.bookwork.app"}},
+		{"prod", []string{"https://bookwork.app", "https://*.bookwork.app"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENV", tt.env)
+		config, err := Load()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if strings.Join(config.CORS.AllowedOrigins, ",") != strings.Join(tt.origins, ",") {
+			t.Errorf("ENV=%q: expected origins %v, got %v", tt.env, tt.origins, config.CORS.AllowedOrigins)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("READ_TIMEOUT", "0s")
+	t.Setenv("ALLOWED_ORIGINS", "*,bookwork.app,https://api.*.bookwork.app")
+	t.Setenv("ALLOW_CREDENTIALS", "true")
+
+	_, err := Load()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{
+		"READ_TIMEOUT must be positive",
+		"DB_PASSWORD must be set in production",
+		"JWT_SECRET must be set in production",
+		`ALLOWED_ORIGINS must not contain "*"`,
+		`ALLOWED_ORIGINS: "bookwork.app" is not scheme://host[:port]`,
+		`ALLOWED_ORIGINS: "https://api.*.bookwork.app" may only have a wildcard`,
+	}
+	if len(invalid.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %q", len(expected), invalid.Problems)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(invalid.Problems[i], prefix) {
+			t.Errorf("Expected problem %d to start with %q, got %q", i, prefix, invalid.Problems[i])
+		}
+	}
+}
+
+func TestValidateReportsMalformedValues(t *testing.T) {
+	t.Setenv("ENV", "development")
+	t.Setenv("DB_MAX_OPEN_CONNS", "abc")
+	t.Setenv("TOKEN_LOCKOUT", "five")
+
+	_, err := Load()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{
+		`DB_MAX_OPEN_CONNS must be an integer, got "abc"`,
+		`TOKEN_LOCKOUT must be a duration such as 30s or 5m, got "five"`,
+	}
+	if len(invalid.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %q", len(expected), invalid.Problems)
+	}
+	for i, problem := range expected {
+		if invalid.Problems[i] != problem {
+			t.Errorf("Expected problem %d to be %q, got %q", i, problem, invalid.Problems[i])
+		}
+	}
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	t.Setenv("ENV", "development")
+	t.Setenv("ALLOWED_ORIGINS", "https://*.bookwork.app,http://localhost:5173")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Expected the defaults to be valid, got %v", err)
+	}
+
+	config.Outbox.PollInterval = 0
+	config.Attachments.Backend = "s3"
+	err = config.Validate()
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 2 {
+		t.Errorf("Expected two problems, got %v", err)
+	}
+}
+
+func TestValidateHTTPSOnlyNeedsPublicHost(t *testing.T) {
+	t.Setenv("ENV", "development")
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	config.Security.EnableHTTPSOnly = true
+	for host, valid := range map[string]bool{"": false, "api.bookwork.app": true, "api.bookwork.app:8443": true, "api.bookwork.app/path": false} {
+		config.Security.PublicHost = host
+		if err := config.Validate(); (err == nil) != valid {
+			t.Errorf("PUBLIC_HOST %q: expected valid %v, got %v", host, valid, err)
+		}
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		name  string
+		tls   TLSConfig
+		valid bool
+	}{
+		{"off", TLSConfig{}, true},
+		{"certificate files", TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", HTTPPort: "80"}, true},
+		{"autocert", TLSConfig{AutocertDomains: []string{"api.bookwork.app"}, AutocertCacheDir: "cache", HTTPPort: "80"}, true},
+		{"certificate without key", TLSConfig{CertFile: "cert.pem"}, false},
+		{"both sources", TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", AutocertDomains: []string{"api.bookwork.app"}}, false},
+		{"redirect without TLS", TLSConfig{HTTPPort: "80"}, false},
+		{"redirect on the HTTPS port", TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", HTTPPort: "8000"}, false},
+	}
+
+	t.Setenv("ENV", "development")
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	for _, tt := range tests {
+		config.Server.Port = "8000"
+		config.TLS = tt.tls
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in a configuration, so all of them
+// can be fixed in one go rather than one failed start at a time
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration for values that would break the API or
+// leave it insecure, returning a *ValidationError listing all of them. Load
+// calls it; the stricter checks apply when ENV names production. Environment
+// values Load could not parse are listed first.
+func (c *Config) Validate() error {
+	v := &validator{problems: append([]string(nil), c.envProblems...)}
+	production := isProduction()
+
+	// Server
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.addf("PORT must be a port number, got %q", c.Server.Port)
+	}
+	v.positive("READ_TIMEOUT", c.Server.ReadTimeout)
+	v.positive("WRITE_TIMEOUT", c.Server.WriteTimeout)
+	v.positive("SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout)
+	v.notNegative("READINESS_CACHE_TTL", c.Server.ReadinessCacheTTL)
+
+	// Database
+	if c.Database.Host == "" || c.Database.Database == "" || c.Database.User == "" {
+		v.add("DB_HOST, DB_NAME and DB_USER must be set")
+	}
+	if production && c.Database.Password == "" {
+		v.add("DB_PASSWORD must be set in production")
+	}
+	if c.Database.MaxOpenConns < 1 {
+		v.addf("DB_MAX_OPEN_CONNS must be at least 1, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		v.addf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS, got %d", c.Database.MaxIdleConns)
+	}
+	v.notNegative("DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime)
+	v.notNegative("DB_CONN_MAX_IDLE_TIME", c.Database.ConnMaxIdleTime)
+	if len(c.Database.ReadReplicaDSNs) > 0 {
+		v.positive("DB_REPLICA_CHECK_INTERVAL", c.Database.ReplicaCheckInterval)
+	}
+
+	// JWT
+	if production {
+		switch {
+		case c.JWT.SecretKey == "":
+			v.add("JWT_SECRET must be set in production")
+		case len(c.JWT.SecretKey) < 32:
+			v.add("JWT_SECRET must be at least 32 characters in production")
+		case c.JWT.SecretKey == oldDefaultJWTSecret:
+			v.add("JWT_SECRET must not be the example value in production")
+		}
+	}
+
+	// CORS
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			v.add(`ALLOWED_ORIGINS must not contain "*" while ALLOW_CREDENTIALS is true`)
+		} else if problem := checkOrigin(origin); problem != "" {
+			v.addf("ALLOWED_ORIGINS: %s", problem)
+		}
+	}
+	for _, origin := range c.CORS.OriginsWithoutCredentials {
+		if problem := checkOrigin(origin); problem != "" {
+			v.addf("CORS_NO_CREDENTIALS_ORIGINS: %s", problem)
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		v.addf("CORS_MAX_AGE must not be negative, got %d", c.CORS.MaxAge)
+	}
+
+	// TLS
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		v.add("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLS.CertFile != "" && len(c.TLS.AutocertDomains) > 0 {
+		v.add("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS must not both be set")
+	}
+	if len(c.TLS.AutocertDomains) > 0 && c.TLS.AutocertCacheDir == "" {
+		v.add("TLS_AUTOCERT_CACHE_DIR must be set with TLS_AUTOCERT_DOMAINS")
+	}
+	if c.TLS.HTTPPort != "" {
+		if port, err := strconv.Atoi(c.TLS.HTTPPort); err != nil || port < 1 || port > 65535 {
+			v.addf("TLS_HTTP_PORT must be a port number, got %q", c.TLS.HTTPPort)
+		} else if !c.TLS.Enabled() {
+			v.add("TLS_HTTP_PORT needs TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS")
+		} else if c.TLS.HTTPPort == c.Server.Port {
+			v.add("TLS_HTTP_PORT must differ from PORT")
+		}
+	}
+
+	// Security
+	if c.Security.EnableHTTPSOnly {
+		if c.Security.PublicHost == "" {
+			v.add("PUBLIC_HOST must be set with ENABLE_HTTPS_ONLY")
+		} else if u, err := url.Parse("https://" + c.Security.PublicHost); err != nil || u.Host != c.Security.PublicHost {
+			v.addf("PUBLIC_HOST must be a host[:port], got %q", c.Security.PublicHost)
+		}
+	}
+	if c.Security.TokenMaxFailures < 1 {
+		v.addf("TOKEN_MAX_FAILURES must be at least 1, got %d", c.Security.TokenMaxFailures)
+	}
+	v.positive("TOKEN_LOCKOUT", c.Security.TokenLockout)
+	v.notNegative("ROLE_CACHE_TTL", c.Security.RoleCacheTTL)
+
+	// Logging
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.Logging.Level), "debug", "info", "warn", "warning", "error")
+	v.oneOf("LOG_FORMAT", strings.ToLower(c.Logging.Format), "json", "text")
+	if c.Logging.DebugBodies && production {
+		v.add("LOG_DEBUG_BODIES must not be enabled in production")
+	}
+
+	// Storage
+	v.oneOf("ATTACHMENTS_BACKEND", c.Attachments.Backend, "local", "s3")
+	if c.Attachments.Backend == "s3" && c.Attachments.S3Bucket == "" {
+		v.add("S3_BUCKET must be set when ATTACHMENTS_BACKEND is s3")
+	}
+	if c.Attachments.MaxSizeBytes < 1 {
+		v.addf("ATTACHMENTS_MAX_SIZE must be positive, got %d", c.Attachments.MaxSizeBytes)
+	}
+	if c.Avatars.MaxSizeBytes < 1 {
+		v.addf("AVATAR_MAX_SIZE must be positive, got %d", c.Avatars.MaxSizeBytes)
+	}
+
+	// Deployment and debugging
+	v.oneOf("DEPLOYMENT_MODE", c.Deployment.Mode, "single", "clustered")
+	if c.Capture.SamplePercent < 0 || c.Capture.SamplePercent > 100 {
+		v.addf("CAPTURE_SAMPLE_PERCENT must be between 0 and 100, got %g", c.Capture.SamplePercent)
+	}
+
+	// Background jobs run on tickers, which need a positive interval
+	v.positive("SANDBOX_PURGE_INTERVAL", c.Sandbox.PurgeInterval)
+	v.positive("AVAILABILITY_REPAIR_INTERVAL", c.Availability.SummaryRepairInterval)
+	v.positive("EVENT_ARCHIVE_INTERVAL", c.Archive.Interval)
+	v.positive("PUBLISHER_REFRESH_INTERVAL", c.Publishers.RefreshInterval)
+	v.positive("ANALYTICS_REFRESH_INTERVAL", c.Analytics.RefreshInterval)
+	v.positive("ANALYTICS_USAGE_FLUSH_INTERVAL", c.Analytics.UsageFlushInterval)
+	v.positive("CLUB_RECOMMENDATIONS_INTERVAL", c.Recommend.Interval)
+	v.positive("NETWORK_RULES_REFRESH_INTERVAL", c.NetworkACL.RefreshInterval)
+	v.positive("POLL_CLOSE_INTERVAL", c.Polls.CloseInterval)
+	v.positive("OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+	v.positive("INTEGRATION_REMINDER_INTERVAL", c.Integrations.ReminderInterval)
+	v.positive("YEARBOOK_POLL_INTERVAL", c.Yearbooks.PollInterval)
+	v.positive("EXPORT_POLL_INTERVAL", c.Exports.PollInterval)
+
+	// Outbound deliveries
+	v.positive("WEBHOOK_TIMEOUT", c.Webhooks.Timeout)
+	v.positive("INTEGRATION_TIMEOUT", c.Integrations.Timeout)
+	if c.Webhooks.MaxAttempts < 1 {
+		v.addf("WEBHOOK_MAX_ATTEMPTS must be at least 1, got %d", c.Webhooks.MaxAttempts)
+	}
+	if c.Integrations.MaxAttempts < 1 {
+		v.addf("INTEGRATION_MAX_ATTEMPTS must be at least 1, got %d", c.Integrations.MaxAttempts)
+	}
+	if c.Outbox.BatchSize < 1 {
+		v.addf("OUTBOX_BATCH_SIZE must be at least 1, got %d", c.Outbox.BatchSize)
+	}
+	if production && c.Webhooks.AllowInsecure {
+		v.add("WEBHOOK_ALLOW_INSECURE must not be enabled in production")
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects the problems Validate finds
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(problem string) {
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.add(fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	if d <= 0 {
+		v.addf("%s must be positive, got %v", key, d)
+	}
+}
+
+func (v *validator) notNegative(key string, d time.Duration) {
+	if d < 0 {
+		v.addf("%s must not be negative, got %v", key, d)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+}
+
+// checkOrigin describes what is wrong with a CORS origin, or returns "" if it
+// is "*", scheme://host[:port] or scheme://*.domain[:port]
+func checkOrigin(origin string) string {
+	if origin == "*" {
+		return ""
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Sprintf("%q is not scheme://host[:port]", origin)
+	}
+	if host := u.Hostname(); strings.Contains(host, "*") {
+		if rest, ok := strings.CutPrefix(host, "*."); !ok || rest == "" || strings.Contains(rest, "*") {
+			return fmt.Sprintf("%q may only have a wildcard for the leftmost subdomain, as in https://*.example.com", origin)
+		}
+	}
+	return ""
+}
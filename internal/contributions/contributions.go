@@ -0,0 +1,137 @@
+// Package contributions tracks one-off contributions towards an event cost.
+//
+// An event can have a goal: a target amount in the club currency, such as the
+// venue rental, and optionally a Stripe Payment Link members are sent to.
+// Contributions are recorded by treasurers or arrive from Stripe, and only
+// those in the goal currency count towards it. Anonymous contributors are kept
+// for the club's books but hidden from other members.
+package contributions
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoGoal is returned for events that are not raising contributions
+var ErrNoGoal = errors.New("event has no contribution goal")
+
+// Contribution methods
+const (
+	MethodManual = "manual"
+	MethodStripe = "stripe"
+)
+
+// Goal is what an event is raising money for
+type Goal struct {
+	EventID     uuid.UUID   `json:"eventId"`
+	Target      money.Money `json:"target"`
+	Description *string     `json:"description,omitempty"`
+	PaymentLink *string     `json:"paymentLink,omitempty"` // Stripe Payment Link with purpose=contribution and event_id metadata
+	CreatedBy   *uuid.UUID  `json:"createdBy,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// Contribution is one payment towards an event. Contributors who are not
+// members have a Name but no UserID.
+type Contribution struct {
+	ID         uuid.UUID   `json:"id"`
+	EventID    uuid.UUID   `json:"eventId"`
+	UserID     *uuid.UUID  `json:"userId,omitempty"`
+	Name       *string     `json:"name,omitempty"`
+	Amount     money.Money `json:"amount"`
+	Anonymous  bool        `json:"anonymous"`
+	Method     string      `json:"method"`
+	ExternalID *string     `json:"externalId,omitempty"`
+	Notes      *string     `json:"notes,omitempty"`
+	RecordedBy *uuid.UUID  `json:"recordedBy,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+// Redacted returns the contribution as other members see it: anonymous
+// contributors lose their name and ID, and bookkeeping fields are left out
+func (c Contribution) Redacted() Contribution {
+	redacted := Contribution{
+		ID:        c.ID,
+		EventID:   c.EventID,
+		Amount:    c.Amount,
+		Anonymous: c.Anonymous,
+		Method:    c.Method,
+		CreatedAt: c.CreatedAt,
+	}
+	if !c.Anonymous {
+		redacted.UserID, redacted.Name = c.UserID, c.Name
+	}
+	return redacted
+}
+
+// Progress is how much of a goal has been raised
+type Progress struct {
+	Target        money.Money `json:"target"`
+	Raised        money.Money `json:"raised"`
+	Remaining     money.Money `json:"remaining"`
+	Percent       int         `json:"percent"` // rounded down; above 100 once the target is exceeded
+	Contributions int         `json:"contributions"`
+	Reached       bool        `json:"reached"`
+}
+
+// progressOf compares what was raised with the target
+func progressOf(target, raised money.Money, contributions int) Progress {
+	remaining := money.New(0, target.Currency)
+	if raised.Minor < target.Minor {
+		remaining.Minor = target.Minor - raised.Minor
+	}
+	return Progress{
+		Target:        target,
+		Raised:        raised,
+		Remaining:     remaining,
+		Percent:       int(raised.Minor * 100 / target.Minor),
+		Contributions: contributions,
+		Reached:       raised.Minor >= target.Minor,
+	}
+}
+
+// anonymousReference prefixes the client reference of an anonymous contribution
+const anonymousReference = "anon_"
+
+// ValidPaymentLink reports whether link is an absolute https URL
+func ValidPaymentLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// DonationLink returns the payment link with the contributor as its
+// client_reference_id, so the webhook can attribute the payment
+func DonationLink(paymentLink string, userID uuid.UUID, anonymous bool) (string, error) {
+	u, err := url.Parse(paymentLink)
+	if err != nil {
+		return "", err
+	}
+
+	reference := userID.String()
+	if anonymous {
+		reference = anonymousReference + reference
+	}
+
+	query := u.Query()
+	query.Set("client_reference_id", reference)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// contributorOf reads a client reference made by DonationLink. Payments
+// without one come from people who opened the plain payment link.
+func contributorOf(reference string) (userID *uuid.UUID, anonymous bool) {
+	id, anonymous := strings.CutPrefix(reference, anonymousReference)
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, false
+	}
+	return &parsed, anonymous
+}
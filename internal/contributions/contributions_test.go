@@ -0,0 +1,77 @@
+package contributions
+
+import (
+	"net/url"
+	"testing"
+
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+func TestProgressOf(t *testing.T) {
+	target := money.New(50000, "EUR")
+
+	progress := progressOf(target, money.New(12345, "EUR"), 3)
+	if progress.Percent != 24 || progress.Remaining.Minor != 37655 || progress.Reached {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+
+	// Raising more than the target is fine; nothing remains
+	progress = progressOf(target, money.New(60000, "EUR"), 7)
+	if progress.Percent != 120 || progress.Remaining.Minor != 0 || !progress.Reached {
+		t.Errorf("Unexpected progress past the target: %+v", progress)
+	}
+}
+
+func TestDonationLinkReference(t *testing.T) {
+	userID := uuid.New()
+
+	for _, anonymous := range []bool{false, true} {
+		link, err := DonationLink("https://buy.stripe.com/test_abc?locale=de", userID, anonymous)
+		if err != nil {
+			t.Fatalf("DonationLink failed: %v", err)
+		}
+		parsed, _ := url.Parse(link)
+		if parsed.Query().Get("locale") != "de" {
+			t.Errorf("Expected existing query parameters to be kept, got %s", link)
+		}
+
+		gotID, gotAnonymous := contributorOf(parsed.Query().Get("client_reference_id"))
+		if gotID == nil || *gotID != userID || gotAnonymous != anonymous {
+			t.Errorf("Expected %s (anonymous %v), got %v (anonymous %v)", userID, anonymous, gotID, gotAnonymous)
+		}
+	}
+
+	if id, _ := contributorOf(""); id != nil {
+		t.Errorf("Expected no contributor without a reference, got %v", id)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	userID, name, notes := uuid.New(), "Ada", "Cash at the door"
+	contribution := Contribution{UserID: &userID, Name: &name, Amount: money.New(1000, "USD"), Notes: &notes, RecordedBy: &userID}
+
+	if redacted := contribution.Redacted(); redacted.UserID == nil || redacted.Name == nil || redacted.Notes != nil || redacted.RecordedBy != nil {
+		t.Errorf("Expected the contributor without bookkeeping fields, got %+v", redacted)
+	}
+
+	contribution.Anonymous = true
+	if redacted := contribution.Redacted(); redacted.UserID != nil || redacted.Name != nil || redacted.Amount != contribution.Amount {
+		t.Errorf("Expected an anonymous contributor to be hidden, got %+v", redacted)
+	}
+}
+
+func TestValidPaymentLink(t *testing.T) {
+	tests := map[string]bool{
+		"https://buy.stripe.com/test_abc": true,
+		"http://buy.stripe.com/test_abc":  false,
+		"javascript:alert(1)":             false,
+		"buy.stripe.com/test_abc":         false,
+	}
+	for link, expected := range tests {
+		if got := ValidPaymentLink(link); got != expected {
+			t.Errorf("ValidPaymentLink(%q) = %v, expected %v", link, got, expected)
+		}
+	}
+}
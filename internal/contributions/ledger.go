@@ -0,0 +1,183 @@
+package contributions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"bookwork-api/internal/billing"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// Checkout metadata naming the event a Stripe payment contributes to
+const (
+	PurposeContribution = "contribution"
+	MetadataEventID     = "event_id"
+)
+
+// Ledger stores event contribution goals and contributions
+type Ledger struct {
+	db *database.DB
+}
+
+func NewLedger(db *database.DB) *Ledger {
+	return &Ledger{db: db}
+}
+
+// Goal returns the event's goal, or ErrNoGoal
+func (l *Ledger) Goal(ctx context.Context, eventID uuid.UUID) (*Goal, error) {
+	query := `
+		SELECT event_id, target_amount::text, currency, description, payment_link, created_by, created_at, updated_at
+		FROM event_contribution_goals WHERE event_id = $1`
+
+	var goal Goal
+	var target, currency string
+	err := l.db.QueryRowContext(ctx, query, eventID).Scan(&goal.EventID, &target, &currency,
+		&goal.Description, &goal.PaymentLink, &goal.CreatedBy, &goal.CreatedAt, &goal.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoGoal
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.Target, err = money.Parse(target, currency); err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// SetGoal creates or replaces the event's goal, filling in its timestamps
+func (l *Ledger) SetGoal(ctx context.Context, goal *Goal) error {
+	query := `
+		INSERT INTO event_contribution_goals (event_id, target_amount, currency, description, payment_link, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id) DO UPDATE SET
+			target_amount = EXCLUDED.target_amount, currency = EXCLUDED.currency, description = EXCLUDED.description,
+			payment_link = EXCLUDED.payment_link, updated_at = NOW()
+		RETURNING created_by, created_at, updated_at`
+
+	return l.db.QueryRowContext(ctx, query,
+		goal.EventID, goal.Target.String(), goal.Target.Currency, goal.Description, goal.PaymentLink, goal.CreatedBy,
+	).Scan(&goal.CreatedBy, &goal.CreatedAt, &goal.UpdatedAt)
+}
+
+// RemoveGoal stops raising contributions for the event. Contributions already
+// recorded are kept. It returns ErrNoGoal when there was no goal.
+func (l *Ledger) RemoveGoal(ctx context.Context, eventID uuid.UUID) error {
+	result, err := l.db.ExecContext(ctx, `DELETE FROM event_contribution_goals WHERE event_id = $1`, eventID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNoGoal
+	}
+	return nil
+}
+
+// Record stores a contribution, filling in its ID and creation time. A
+// contribution whose ExternalID was already recorded returns billing.ErrAlreadyRecorded.
+func (l *Ledger) Record(ctx context.Context, contribution *Contribution) error {
+	query := `
+		INSERT INTO event_contributions (event_id, user_id, contributor_name, amount, currency, anonymous, method, external_id, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (external_id) DO NOTHING
+		RETURNING id, created_at`
+
+	err := l.db.QueryRowContext(ctx, query,
+		contribution.EventID, contribution.UserID, contribution.Name, contribution.Amount.String(), contribution.Amount.Currency,
+		contribution.Anonymous, contribution.Method, contribution.ExternalID, contribution.Notes, contribution.RecordedBy,
+	).Scan(&contribution.ID, &contribution.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return billing.ErrAlreadyRecorded
+	}
+	return err
+}
+
+// RecordCheckout stores a paid Stripe Checkout session created with
+// contribution metadata. The contributor comes from the client reference of a
+// link made by DonationLink; payments without one are anonymous.
+func (l *Ledger) RecordCheckout(ctx context.Context, session billing.CheckoutSession) error {
+	eventID, err := uuid.Parse(session.Metadata[MetadataEventID])
+	if err != nil {
+		return errors.New("checkout metadata has no valid event_id")
+	}
+	amount, err := session.Amount()
+	if err != nil {
+		return err
+	}
+
+	userID, anonymous := contributorOf(session.ClientReference)
+	externalID := session.ID
+	return l.Record(ctx, &Contribution{
+		EventID:    eventID,
+		UserID:     userID,
+		Amount:     amount,
+		Anonymous:  anonymous || userID == nil,
+		Method:     MethodStripe,
+		ExternalID: &externalID,
+	})
+}
+
+// List returns an event's contributions, newest first, with members' current names
+func (l *Ledger) List(ctx context.Context, eventID uuid.UUID, limit, offset int) ([]Contribution, error) {
+	query := `
+		SELECT c.id, c.event_id, c.user_id, COALESCE(u.name, c.contributor_name), c.amount::text, c.currency,
+		       c.anonymous, c.method, c.external_id, c.notes, c.recorded_by, c.created_at
+		FROM event_contributions c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.event_id = $1
+		ORDER BY c.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := l.db.QueryContext(ctx, query, eventID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contributions := []Contribution{}
+	for rows.Next() {
+		var contribution Contribution
+		var amount, currency string
+		if err := rows.Scan(&contribution.ID, &contribution.EventID, &contribution.UserID, &contribution.Name, &amount, &currency,
+			&contribution.Anonymous, &contribution.Method, &contribution.ExternalID, &contribution.Notes,
+			&contribution.RecordedBy, &contribution.CreatedAt); err != nil {
+			return nil, err
+		}
+		if contribution.Amount, err = money.Parse(amount, currency); err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, contribution)
+	}
+
+	return contributions, rows.Err()
+}
+
+// Progress returns the event's goal and how much of it has been raised, or ErrNoGoal
+func (l *Ledger) Progress(ctx context.Context, eventID uuid.UUID) (*Goal, Progress, error) {
+	goal, err := l.Goal(ctx, eventID)
+	if err != nil {
+		return nil, Progress{}, err
+	}
+
+	query := `
+		SELECT COALESCE(SUM(amount), 0)::text, COUNT(*)
+		FROM event_contributions
+		WHERE event_id = $1 AND currency = $2`
+
+	var raised string
+	var count int
+	if err := l.db.QueryRowContext(ctx, query, eventID, goal.Target.Currency).Scan(&raised, &count); err != nil {
+		return nil, Progress{}, err
+	}
+
+	amount, err := money.Parse(raised, goal.Target.Currency)
+	if err != nil {
+		return nil, Progress{}, err
+	}
+	return goal, progressOf(goal.Target, amount, count), nil
+}
@@ -0,0 +1,312 @@
+// Package corrections lets members ask for changes to records they cannot
+// edit themselves, for moderators to approve or reject.
+//
+// A correction proposes a new value for one record: the member's books read
+// count, or whether they attended an event (whether they are among its
+// attendees). Approving applies the value in the same transaction that
+// records the decision, along with the value it replaced.
+package corrections
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of record a correction can change
+const (
+	KindBooksRead  = "books_read"
+	KindAttendance = "attendance"
+)
+
+// Statuses of a correction
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	// ErrNotFound means the club has no correction with the ID, or none still
+	// pending where only a pending one will do
+	ErrNotFound = errors.New("correction not found")
+	// ErrRecordNotFound means the record to correct does not exist: the
+	// member is not active in the club, or the event is not the club's
+	ErrRecordNotFound = errors.New("record to correct not found")
+	// ErrPending means the member already has an open correction for the record
+	ErrPending = errors.New("a correction for the record is already pending")
+	// ErrNoChange means the record already has the proposed value
+	ErrNoChange = errors.New("record already has the proposed value")
+)
+
+// Correction is a member's request to change one of their records
+type Correction struct {
+	ID           uuid.UUID       `json:"id"`
+	ClubID       uuid.UUID       `json:"clubId"`
+	UserID       uuid.UUID       `json:"userId"`
+	UserName     string          `json:"userName"`
+	Kind         string          `json:"kind"`
+	EventID      *uuid.UUID      `json:"eventId,omitempty"`
+	EventTitle   *string         `json:"eventTitle,omitempty"`
+	Proposed     json.RawMessage `json:"proposed"`           // a count for books_read, true or false for attendance
+	Current      json.RawMessage `json:"current,omitempty"`  // the record's value now, while pending
+	Previous     json.RawMessage `json:"previous,omitempty"` // the value approving replaced
+	Reason       string          `json:"reason"`
+	Status       string          `json:"status"`
+	DecidedBy    *uuid.UUID      `json:"decidedBy,omitempty"`
+	DecidedAt    *time.Time      `json:"decidedAt,omitempty"`
+	DecisionNote *string         `json:"decisionNote,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// Filter selects a club's corrections
+type Filter struct {
+	Status string     // empty for every status
+	UserID *uuid.UUID // only this member's, when set
+}
+
+// Store reads and decides corrections
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// current reads the value of the record c would correct, locking it when
+// lock is set
+func current(ctx context.Context, q rowQuerier, c Correction, lock bool) (json.RawMessage, error) {
+	var query string
+	args := []interface{}{c.ClubID, c.UserID}
+	switch c.Kind {
+	case KindBooksRead:
+		query = `SELECT to_jsonb(COALESCE(books_read, 0)) FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
+	case KindAttendance:
+		query = `SELECT to_jsonb($2 = ANY(COALESCE(attendees, '{}'))) FROM events WHERE club_id = $1 AND id = $3 AND deleted_at IS NULL`
+		args = append(args, c.EventID)
+	default:
+		return nil, fmt.Errorf("unknown correction kind %q", c.Kind)
+	}
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	var value []byte
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to read record to correct: %w", err)
+	}
+	return value, nil
+}
+
+// Create files a pending correction for c.UserID's record
+func (s *Store) Create(ctx context.Context, c Correction) (Correction, error) {
+	value, err := current(ctx, s.db, c, false)
+	if err != nil {
+		return Correction{}, err
+	}
+	if jsonEqual(value, c.Proposed) {
+		return Correction{}, ErrNoChange
+	}
+
+	var id uuid.UUID
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO member_corrections (club_id, user_id, kind, event_id, proposed, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+		RETURNING id`,
+		c.ClubID, c.UserID, c.Kind, c.EventID, []byte(c.Proposed), c.Reason,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return Correction{}, ErrPending
+	}
+	if err != nil {
+		return Correction{}, fmt.Errorf("failed to create correction: %w", err)
+	}
+	return s.Get(ctx, c.ClubID, id)
+}
+
+const selectCorrections = `
+	SELECT mc.id, mc.club_id, mc.user_id, u.name, mc.kind, mc.event_id, e.title, mc.proposed,
+	       CASE WHEN mc.status = 'pending' THEN
+	           CASE mc.kind
+	               WHEN 'books_read' THEN to_jsonb(COALESCE(cm.books_read, 0))
+	               WHEN 'attendance' THEN to_jsonb(mc.user_id = ANY(COALESCE(e.attendees, '{}')))
+	           END
+	       END,
+	       mc.previous, mc.reason, mc.status, mc.decided_by, mc.decided_at, mc.decision_note, mc.created_at
+	FROM member_corrections mc
+	JOIN users u ON u.id = mc.user_id
+	LEFT JOIN club_members cm ON cm.club_id = mc.club_id AND cm.user_id = mc.user_id
+	LEFT JOIN events e ON e.id = mc.event_id`
+
+// List returns a club's corrections, oldest first
+func (s *Store) List(ctx context.Context, clubID uuid.UUID, filter Filter) ([]Correction, error) {
+	query := selectCorrections + ` WHERE mc.club_id = $1 AND ($2 = '' OR mc.status = $2) AND ($3::uuid IS NULL OR mc.user_id = $3)
+		ORDER BY mc.created_at, mc.id`
+	rows, err := s.db.QueryContext(ctx, query, clubID, filter.Status, filter.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query corrections: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Correction{}
+	for rows.Next() {
+		c, err := scanCorrection(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corrections: %w", err)
+	}
+	return list, nil
+}
+
+// Get returns one of a club's corrections
+func (s *Store) Get(ctx context.Context, clubID, id uuid.UUID) (Correction, error) {
+	c, err := scanCorrection(s.db.QueryRowContext(ctx, selectCorrections+` WHERE mc.club_id = $1 AND mc.id = $2`, clubID, id))
+	if err == sql.ErrNoRows {
+		return Correction{}, ErrNotFound
+	}
+	return c, err
+}
+
+// Cancel withdraws a member's own pending correction
+func (s *Store) Cancel(ctx context.Context, clubID, id, userID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE member_corrections SET status = 'cancelled'
+		WHERE id = $1 AND club_id = $2 AND user_id = $3 AND status = 'pending'`, id, clubID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel correction: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Decide approves or rejects a pending correction. Approving applies the
+// proposed value and records the value it replaced.
+func (s *Store) Decide(ctx context.Context, clubID, id, decidedBy uuid.UUID, approve bool, note *string) (Correction, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Correction{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	c := Correction{ID: id, ClubID: clubID}
+	var proposed []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, kind, event_id, proposed FROM member_corrections
+		WHERE id = $1 AND club_id = $2 AND status = 'pending'
+		FOR UPDATE`, id, clubID,
+	).Scan(&c.UserID, &c.Kind, &c.EventID, &proposed)
+	if err == sql.ErrNoRows {
+		return Correction{}, ErrNotFound
+	}
+	if err != nil {
+		return Correction{}, fmt.Errorf("failed to get correction: %w", err)
+	}
+	c.Proposed = proposed
+
+	status := StatusRejected
+	var previous []byte
+	if approve {
+		status = StatusApproved
+		if previous, err = current(ctx, tx, c, true); err != nil {
+			return Correction{}, err
+		}
+		if err := apply(ctx, tx, c); err != nil {
+			return Correction{}, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE member_corrections
+		SET status = $2, previous = $3, decided_by = $4, decided_at = CURRENT_TIMESTAMP, decision_note = $5
+		WHERE id = $1`, id, status, previous, decidedBy, note)
+	if err != nil {
+		return Correction{}, fmt.Errorf("failed to decide correction: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Correction{}, fmt.Errorf("failed to commit correction: %w", err)
+	}
+
+	return s.Get(ctx, clubID, id)
+}
+
+// apply writes the proposed value of c to its record
+func apply(ctx context.Context, tx *sql.Tx, c Correction) error {
+	var err error
+	switch c.Kind {
+	case KindBooksRead:
+		var count int
+		if err := json.Unmarshal(c.Proposed, &count); err != nil {
+			return fmt.Errorf("failed to decode proposed books read: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE club_members SET books_read = $3 WHERE club_id = $1 AND user_id = $2`, c.ClubID, c.UserID, count)
+	case KindAttendance:
+		var attended bool
+		if err := json.Unmarshal(c.Proposed, &attended); err != nil {
+			return fmt.Errorf("failed to decode proposed attendance: %w", err)
+		}
+		query := `UPDATE events SET attendees = array_remove(attendees, $2), updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+		if attended {
+			query = `UPDATE events SET attendees = array_append(array_remove(attendees, $2), $2), updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+		}
+		_, err = tx.ExecContext(ctx, query, c.EventID, c.UserID)
+	default:
+		return fmt.Errorf("unknown correction kind %q", c.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply correction: %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCorrection(row scanner) (Correction, error) {
+	var c Correction
+	var proposed, current, previous []byte
+	err := row.Scan(
+		&c.ID, &c.ClubID, &c.UserID, &c.UserName, &c.Kind, &c.EventID, &c.EventTitle, &proposed,
+		&current, &previous, &c.Reason, &c.Status, &c.DecidedBy, &c.DecidedAt, &c.DecisionNote, &c.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return Correction{}, err
+	}
+	if err != nil {
+		return Correction{}, fmt.Errorf("failed to scan correction: %w", err)
+	}
+	c.Proposed, c.Current, c.Previous = proposed, current, previous
+	return c, nil
+}
+
+// jsonEqual reports whether two JSON scalars hold the same value
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return x == y
+}
@@ -0,0 +1,26 @@
+package corrections
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"3", "3", true},
+		{"3", "3.0", true},
+		{"3", "4", false},
+		{"true", "true", true},
+		{"true", "false", false},
+		{"0", "false", false},
+		{"3", "not json", false},
+	}
+	for _, tt := range tests {
+		if got := jsonEqual(json.RawMessage(tt.a), json.RawMessage(tt.b)); got != tt.expected {
+			t.Errorf("jsonEqual(%s, %s) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
+// DB is the primary database. Queries go through database/sql, backed by a
+// pgx connection pool.
 type DB struct {
 	*sql.DB
+
+	pool     *pgxpool.Pool // nil for the mock
+	replicas *replicaSet   // nil without read replicas
 }
 
 type Config struct {
@@ -37,55 +45,103 @@ func NewMock() *DB {
 }
 
 func New(config Config) (*DB, error) {
-	var dsn string
+	dsn, err := config.dsn()
+	if err != nil {
+		return nil, err
+	}
 
-	// Use PgBouncer if configured, otherwise direct connection
-	if config.PgBouncerAddr != "" {
-		dsn = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			config.Host, config.Port, config.User, config.Password,
-			config.Database, config.SSLMode,
-		)
-	} else {
-		dsn = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-			config.Host, config.Port, config.User, config.Password,
-			config.Database, config.SSLMode,
-		)
-	}
-
-	db, err := sql.Open("postgres", dsn)
+	pool, err := openPool(dsn, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Configure connection pool with enhanced settings
-	db.SetMaxOpenConns(config.MaxOpenConns)
-	db.SetMaxIdleConns(config.MaxIdleConns)
-	db.SetConnMaxLifetime(config.ConnMaxLifetime)
-	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
-
 	// Test the connection with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := db.PingContext(ctx); err != nil {
-		db.Close()
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Printf("Successfully connected to database at %s:%s", config.Host, config.Port)
-	log.Printf("Connection pool: max_open=%d, max_idle=%d, max_lifetime=%v",
-		config.MaxOpenConns, config.MaxIdleConns, config.ConnMaxLifetime)
+	slog.Info("connected to database",
+		"host", config.Host,
+		"port", config.Port,
+		"pgbouncer", config.PgBouncerAddr,
+		"max_open_conns", config.MaxOpenConns,
+		"max_idle_conns", config.MaxIdleConns,
+		"conn_max_lifetime", config.ConnMaxLifetime.String(),
+	)
+
+	return &DB{DB: stdlib.OpenDBFromPool(pool), pool: pool}, nil
+}
+
+// openPool opens a pgx connection pool at dsn with config's pool settings. It
+// keeps MaxIdleConns connections open when idle and closes the rest after
+// ConnMaxIdleTime. database/sql on top of it keeps no connections of its own.
+func openPool(dsn string, config Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(config.MaxOpenConns)
+	}
+	poolConfig.MinConns = int32(min(config.MaxIdleConns, int(poolConfig.MaxConns)))
+	if config.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
+}
+
+// dsn is the connection string for config. With PgBouncerAddr set it connects
+// to PgBouncer instead of the database host. PgBouncer in transaction pooling
+// mode may hand each transaction to a different server connection, so queries
+// are not prepared as named statements (default_query_exec_mode=exec): their
+// parameters go in the same round trip as the query and nothing outlives it.
+func (config Config) dsn() (string, error) {
+	host, port := config.Host, config.Port
+	params := ""
+	if config.PgBouncerAddr != "" {
+		var err error
+		host, port, err = net.SplitHostPort(config.PgBouncerAddr)
+		if err != nil {
+			return "", fmt.Errorf("invalid PgBouncer address %q: %w", config.PgBouncerAddr, err)
+		}
+		params = " default_query_exec_mode=exec"
+	}
 
-	return &DB{db}, nil
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(host), quoteDSN(port), quoteDSN(config.User), quoteDSN(config.Password),
+		quoteDSN(config.Database), quoteDSN(config.SSLMode),
+	) + params, nil
+}
+
+// quoteDSN quotes a connection string value if it is empty or holds spaces,
+// quotes or backslashes
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
 }
 
 func (db *DB) Close() error {
+	if db.replicas != nil {
+		db.replicas.close()
+	}
+	var err error
 	if db.DB != nil {
-		return db.DB.Close()
+		err = db.DB.Close()
 	}
-	return nil // Mock case
+	if db.pool != nil {
+		db.pool.Close()
+	}
+	return err
 }
 
 func (db *DB) Ping() error {
@@ -104,6 +160,22 @@ func (db *DB) BeginTx(ctx context.Context) (*sql.Tx, error) {
 	return &sql.Tx{}, nil // Mock case
 }
 
+// WithTx runs fn in a transaction, committing it if fn returns nil and rolling
+// it back if fn fails or panics, so multi-statement writes land together or
+// not at all
+func (db *DB) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // QueryRowContext with mock support
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	if db.DB != nil {
@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func TestNewDatabase(t *testing.T) {
@@ -194,3 +197,95 @@ func TestDatabaseTransaction(t *testing.T) {
 		t.Errorf("Failed to rollback transaction: %v", err)
 	}
 }
+
+func TestDSNUsesPgBouncer(t *testing.T) {
+	config := Config{
+		Host:     "db.internal",
+		Port:     "5432",
+		User:     "bookwork",
+		Password: "it's a secret",
+		Database: "bookwork",
+		SSLMode:  "disable",
+	}
+
+	direct, err := config.dsn()
+	if err != nil {
+		t.Fatalf("Failed to build DSN: %v", err)
+	}
+	if direct != `host=db.internal port=5432 user=bookwork password='it\'s a secret' dbname=bookwork sslmode=disable` {
+		t.Errorf("Unexpected direct DSN: %s", direct)
+	}
+
+	config.PgBouncerAddr = "pgbouncer.internal:6432"
+	pooled, err := config.dsn()
+	if err != nil {
+		t.Fatalf("Failed to build DSN: %v", err)
+	}
+	if pooled != `host=pgbouncer.internal port=6432 user=bookwork password='it\'s a secret' dbname=bookwork sslmode=disable default_query_exec_mode=exec` {
+		t.Errorf("Unexpected PgBouncer DSN: %s", pooled)
+	}
+	parsed, err := pgxpool.ParseConfig(pooled)
+	if err != nil {
+		t.Fatalf("Expected pgx to accept the PgBouncer DSN: %v", err)
+	}
+	if parsed.ConnConfig.Password != "it's a secret" {
+		t.Errorf("Expected the quoted password to be read back, got %q", parsed.ConnConfig.Password)
+	}
+	if parsed.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeExec {
+		t.Errorf("Expected no named prepared statements through PgBouncer, got %v", parsed.ConnConfig.DefaultQueryExecMode)
+	}
+
+	config.PgBouncerAddr = "pgbouncer.internal"
+	if _, err := config.dsn(); err == nil {
+		t.Error("Expected an address without a port to be rejected")
+	}
+}
+
+// txDriver opens connections that only record how their transactions end
+type txDriver struct {
+	commits, rollbacks int
+}
+
+func (d *txDriver) Open(name string) (driver.Conn, error) { return txConn{d}, nil }
+
+type txConn struct{ d *txDriver }
+
+func (c txConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c txConn) Close() error                              { return nil }
+func (c txConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c txConn) Commit() error                             { c.d.commits++; return nil }
+func (c txConn) Rollback() error                           { c.d.rollbacks++; return nil }
+
+func TestWithTx(t *testing.T) {
+	fake := &txDriver{}
+	sql.Register("withtx-fake", fake)
+	conn, err := sql.Open("withtx-fake", "")
+	if err != nil {
+		t.Fatalf("Failed to open fake database: %v", err)
+	}
+	db := &DB{DB: conn}
+	defer db.Close()
+
+	if err := db.WithTx(context.Background(), func(tx *sql.Tx) error { return nil }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fake.commits != 1 || fake.rollbacks != 0 {
+		t.Errorf("Expected one commit, got %d commits and %d rollbacks", fake.commits, fake.rollbacks)
+	}
+
+	failed := errors.New("second insert failed")
+	if err := db.WithTx(context.Background(), func(tx *sql.Tx) error { return failed }); !errors.Is(err, failed) {
+		t.Fatalf("Expected fn's error, got %v", err)
+	}
+	if fake.commits != 1 || fake.rollbacks != 1 {
+		t.Errorf("Expected the failed transaction rolled back, got %d commits and %d rollbacks", fake.commits, fake.rollbacks)
+	}
+
+	func() {
+		defer func() { recover() }()
+		db.WithTx(context.Background(), func(tx *sql.Tx) error { panic("boom") })
+	}()
+	if fake.commits != 1 || fake.rollbacks != 2 {
+		t.Errorf("Expected the panicking transaction rolled back, got %d commits and %d rollbacks", fake.commits, fake.rollbacks)
+	}
+}
@@ -275,6 +275,14 @@ func (m *MockStore) populateTestData() {
 
 	// Create events for clubs (25+ events)
 	eventTypes := []string{"book_discussion", "author_meetup", "book_swap", "literary_workshop", "social_gathering"}
+	// Each kind of mock event is stored as one of the default event types
+	eventTypeValues := map[string]string{
+		"book_discussion":   "discussion",
+		"author_meetup":     "author_event",
+		"book_swap":         "social",
+		"literary_workshop": "meeting",
+		"social_gathering":  "social",
+	}
 	eventCount := 0
 	for i, club := range clubList {
 		// Each club has 3-4 events
@@ -290,7 +298,7 @@ func (m *MockStore) populateTestData() {
 				Time:         getEventTime(eventCount),
 				Location:     getEventLocation(club.Location, eventCount),
 				Book:         club.CurrentBook,
-				Type:         eventTypes[eventCount%len(eventTypes)],
+				Type:         eventTypeValues[eventTypes[eventCount%len(eventTypes)]],
 				MaxAttendees: intPtr(15 + (eventCount % 10)),
 				IsPublic:     club.IsPublic,
 				CreatedBy:    club.OwnerID,
@@ -321,6 +329,14 @@ func (m *MockStore) populateTestData() {
 		"Technology":        {"Microphone Setup", "Projector", "WiFi Password", "Camera for Photos", "Sound System"},
 		"Cleanup":           {"Trash Collection", "Chair Stacking", "Equipment Return", "Venue Cleanup", "Leftover Management"},
 	}
+	// Each group of mock items is stored under one of the default item categories
+	itemCategoryValues := map[string]string{
+		"Food & Beverages":  "food",
+		"Setup & Logistics": "logistics",
+		"Materials":         "materials",
+		"Technology":        "presentation",
+		"Cleanup":           "logistics",
+	}
 	itemStatuses := []string{"pending", "assigned", "in_progress", "completed"}
 
 	itemCount := 0
@@ -335,7 +351,7 @@ func (m *MockStore) populateTestData() {
 				ID:         uuid.New(),
 				EventID:    event.ID,
 				Name:       names[itemCount%len(names)],
-				Category:   category,
+				Category:   itemCategoryValues[category],
 				AssignedTo: getRandomAssignee(userList, itemCount),
 				Status:     itemStatuses[itemCount%len(itemStatuses)],
 				Notes:      getItemNotes(itemCount),
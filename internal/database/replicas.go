@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// replica is a read-only copy of the primary that list queries may be sent to
+type replica struct {
+	name    string // position in the configured list, for logs; the DSN holds the password
+	db      *sql.DB
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// replicaSet spreads reads over the healthy replicas in turn
+type replicaSet struct {
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+}
+
+// OpenReplicas adds read replicas at dsns, each a libpq connection string or
+// postgres:// URL, with the primary's pool settings. Replicas lagging more
+// than maxLag behind the primary are not read from. Replicas start unchecked
+// and so unused until CheckReplicas or MonitorReplicas finds them healthy.
+func (db *DB) OpenReplicas(dsns []string, config Config, maxLag time.Duration) error {
+	set := &replicaSet{maxLag: maxLag}
+	for i, dsn := range dsns {
+		pool, err := openPool(strings.TrimSpace(dsn), config)
+		if err != nil {
+			set.close()
+			return fmt.Errorf("failed to open read replica %d: %w", i+1, err)
+		}
+		set.replicas = append(set.replicas, &replica{name: fmt.Sprintf("replica %d", i+1), db: stdlib.OpenDBFromPool(pool), pool: pool})
+	}
+	db.replicas = set
+	return nil
+}
+
+// ReadQueryContext runs a read-only query on a healthy replica, or on the
+// primary when there is none or the replica cannot serve it. Replicas trail
+// the primary, so it is for lists where a moment's staleness is acceptable,
+// never for reading back a write.
+func (db *DB) ReadQueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if db.DB == nil || db.replicas == nil {
+		return db.QueryContext(ctx, query, args...)
+	}
+
+	if r := db.replicas.pick(); r != nil {
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err == nil || ctx.Err() != nil || !replicaUnavailable(err) {
+			return rows, err
+		}
+		r.healthy.Store(false)
+		slog.Warn("read replica unavailable, reading from primary", "replica", r.name, "error", err)
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// pick returns the next healthy replica, or nil
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := s.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// replicaUnavailable reports whether err means the replica could not run the
+// query, as opposed to the query itself failing, so the primary should
+// run it instead
+func replicaUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code[:2] {
+		case "08", "57": // connection exception, operator intervention (shutdown, recovery)
+			return true
+		}
+		// Canceled by replay of a conflicting change from the primary
+		return pgErr.Code == "40001"
+	}
+	return false
+}
+
+// CheckReplicas marks each replica healthy if it answers and is no more than
+// the maximum lag behind the primary
+func (db *DB) CheckReplicas(ctx context.Context) {
+	if db.replicas == nil {
+		return
+	}
+	for _, r := range db.replicas.replicas {
+		lag, err := r.lag(ctx)
+		healthy := err == nil && lag <= db.replicas.maxLag
+		if was := r.healthy.Swap(healthy); was != healthy {
+			if healthy {
+				slog.Info("read replica healthy", "replica", r.name, "lag", lag.String())
+			} else {
+				slog.Warn("read replica unhealthy", "replica", r.name, "lag", lag.String(), "error", err)
+			}
+		}
+	}
+}
+
+// lag is how far the replica's replay trails the primary. A replica with
+// nothing to replay since it last caught up reports no lag.
+func (r *replica) lag(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var seconds float64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// MonitorReplicas checks the replicas immediately and then every interval
+// until ctx is cancelled
+func (db *DB) MonitorReplicas(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		db.CheckReplicas(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *replicaSet) close() error {
+	var firstErr error
+	for _, r := range s.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if r.pool != nil {
+			r.pool.Close()
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// serverDriver opens connections to named fake servers that count the
+// queries they answer, failing them with the server's err if it has one
+type serverDriver struct {
+	servers map[string]*fakeServer
+}
+
+type fakeServer struct {
+	queries int
+	err     error
+}
+
+func (d *serverDriver) Open(name string) (driver.Conn, error) {
+	return serverConn{d.servers[name]}, nil
+}
+
+type serverConn struct{ s *fakeServer }
+
+func (c serverConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c serverConn) Close() error              { return nil }
+func (c serverConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (c serverConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.s.queries++
+	if c.s.err != nil {
+		return nil, c.s.err
+	}
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func openFakeServers(t *testing.T, servers map[string]*fakeServer) *DB {
+	t.Helper()
+	open := func(name string) *sql.DB {
+		return sql.OpenDB(driverConnector{&serverDriver{servers}, name})
+	}
+	db := &DB{DB: open("primary"), replicas: &replicaSet{maxLag: time.Minute}}
+	for _, name := range []string{"replica 1", "replica 2"} {
+		r := &replica{name: name, db: open(name)}
+		r.healthy.Store(true)
+		db.replicas.replicas = append(db.replicas.replicas, r)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type driverConnector struct {
+	d    *serverDriver
+	name string
+}
+
+func (c driverConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.name) }
+func (c driverConnector) Driver() driver.Driver                        { return c.d }
+
+func readOnce(t *testing.T, db *DB) error {
+	t.Helper()
+	rows, err := db.ReadQueryContext(context.Background(), "SELECT 1")
+	if err == nil {
+		rows.Close()
+	}
+	return err
+}
+
+func TestReadQueryContextSpreadsReadsOverReplicas(t *testing.T) {
+	servers := map[string]*fakeServer{"primary": {}, "replica 1": {}, "replica 2": {}}
+	db := openFakeServers(t, servers)
+
+	for i := 0; i < 4; i++ {
+		if err := readOnce(t, db); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if servers["primary"].queries != 0 || servers["replica 1"].queries != 2 || servers["replica 2"].queries != 2 {
+		t.Errorf("Expected reads split between the replicas, got primary %d, replicas %d and %d",
+			servers["primary"].queries, servers["replica 1"].queries, servers["replica 2"].queries)
+	}
+}
+
+func TestReadQueryContextFailsOverToPrimary(t *testing.T) {
+	servers := map[string]*fakeServer{
+		"primary":   {},
+		"replica 1": {err: &pgconn.PgError{Code: "57P03"}}, // cannot connect now
+		"replica 2": {err: &pgconn.PgError{Code: "57P01"}}, // admin shutdown
+	}
+	db := openFakeServers(t, servers)
+
+	for i := 0; i < 3; i++ {
+		if err := readOnce(t, db); err != nil {
+			t.Fatalf("Expected the primary to answer, got %v", err)
+		}
+	}
+	if servers["primary"].queries != 3 {
+		t.Errorf("Expected every read on the primary, got %d", servers["primary"].queries)
+	}
+	if servers["replica 1"].queries != 1 || servers["replica 2"].queries != 1 {
+		t.Errorf("Expected each failed replica tried once and then skipped, got %d and %d",
+			servers["replica 1"].queries, servers["replica 2"].queries)
+	}
+}
+
+func TestReadQueryContextKeepsQueryErrors(t *testing.T) {
+	syntax := &pgconn.PgError{Code: "42601"}
+	servers := map[string]*fakeServer{"primary": {}, "replica 1": {err: syntax}, "replica 2": {err: syntax}}
+	db := openFakeServers(t, servers)
+
+	if err := readOnce(t, db); !errors.Is(err, syntax) {
+		t.Errorf("Expected the query's own error, got %v", err)
+	}
+	if servers["primary"].queries != 0 {
+		t.Error("Expected a failing query not to be retried on the primary")
+	}
+}
@@ -0,0 +1,529 @@
+// Package dataexport compiles archives of everything the API stores about a
+// user, for users exercising their right of access.
+//
+// Archives are compiled in the background. A request queues a row in
+// user_exports; a Compiler on any instance claims it, reads each section of
+// the user's data, and writes a ZIP to attachment storage holding a manifest
+// and one JSON file per section. The user is notified with a signed download
+// link. Archives hold personal data, so a ready one expires after the
+// retention period and the Compiler deletes its file.
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound means the user has not asked for an export
+var ErrNotFound = errors.New("export not found")
+
+// Statuses of an export
+const (
+	StatusPending   = "pending"
+	StatusCompiling = "compiling"
+	StatusReady     = "ready"
+	StatusFailed    = "failed"
+	StatusExpired   = "expired"
+)
+
+// StaleAfter is how long a compile may run before it is assumed lost with its
+// instance and another one takes it over
+const StaleAfter = 15 * time.Minute
+
+// Section is one part of a user's data. Its query selects the user's ($1)
+// rows; a single section is one row, written as an object rather than a list.
+type Section struct {
+	Name   string
+	Single bool
+	Query  string
+}
+
+// Sections lists what an export holds. Secrets such as password hashes and
+// refresh tokens are left out.
+var Sections = []Section{
+	{Name: "profile", Single: true, Query: `
+		SELECT id, name, email, phone, avatar, role, timezone, date_of_birth, guardian_email, is_active,
+		       last_login_at, created_at, updated_at
+		FROM users WHERE id = $1`},
+	{Name: "identities", Query: `
+		SELECT provider, subject, email, created_at, last_used_at
+		FROM user_identities WHERE user_id = $1 ORDER BY created_at`},
+	{Name: "terms_acceptances", Query: `
+		SELECT terms_version, ip_address, user_agent, accepted_at
+		FROM terms_acceptances WHERE user_id = $1 ORDER BY accepted_at`},
+	{Name: "memberships", Query: `
+		SELECT cm.club_id, c.name AS club_name, cm.role, cm.joined_date, cm.books_read, cm.is_active
+		FROM club_members cm JOIN clubs c ON c.id = cm.club_id
+		WHERE cm.user_id = $1 ORDER BY cm.joined_date`},
+	{Name: "join_requests", Query: `
+		SELECT jr.club_id, c.name AS club_name, jr.status, jr.message, jr.decided_at, jr.created_at
+		FROM club_join_requests jr JOIN clubs c ON c.id = jr.club_id
+		WHERE jr.user_id = $1 ORDER BY jr.created_at`},
+	{Name: "availability", Query: `
+		SELECT a.event_id, e.club_id, e.title AS event_title, e.event_date, a.status, a.notes, a.updated_at
+		FROM availability a JOIN events e ON e.id = a.event_id
+		WHERE a.user_id = $1
+		UNION ALL
+		SELECT a.event_id, e.club_id, e.title, a.event_date, a.status, a.notes, a.updated_at
+		FROM availability_archive a LEFT JOIN events_archive e ON e.id = a.event_id AND e.event_date = a.event_date
+		WHERE a.user_id = $1
+		ORDER BY event_date, event_id`},
+	{Name: "event_items", Query: `
+		SELECT ei.id, ei.event_id, e.title AS event_title, ei.name, ei.category, ei.status, ei.notes,
+		       ei.assigned_to = $1 AS assigned, ei.created_by = $1 AS created, ei.created_at
+		FROM event_items ei JOIN events e ON e.id = ei.event_id
+		WHERE ei.assigned_to = $1 OR ei.created_by = $1 ORDER BY ei.created_at`},
+	{Name: "poll_votes", Query: `
+		SELECT v.poll_id, p.title AS poll_title, o.title AS choice, v.rank, v.created_at
+		FROM poll_votes v JOIN polls p ON p.id = v.poll_id JOIN poll_options o ON o.id = v.option_id
+		WHERE v.user_id = $1 ORDER BY v.created_at, v.poll_id, v.rank`},
+	{Name: "corrections", Query: `
+		SELECT club_id, kind, event_id, proposed, previous, reason, status, decision_note, decided_at, created_at
+		FROM member_corrections WHERE user_id = $1 ORDER BY created_at`},
+	{Name: "dues_payments", Query: `
+		SELECT club_id, amount, currency, period_start, method, notes, paid_at
+		FROM dues_payments WHERE user_id = $1 ORDER BY paid_at`},
+	{Name: "contributions", Query: `
+		SELECT event_id, amount, currency, anonymous, method, notes, created_at
+		FROM event_contributions WHERE user_id = $1 ORDER BY created_at`},
+	{Name: "partnership_messages", Query: `
+		SELECT partnership_id, club_id, body, created_at
+		FROM partnership_messages WHERE user_id = $1 ORDER BY created_at`},
+	{Name: "contact_messages", Query: `
+		SELECT club_id, sender_name, sender_email, message, created_at
+		FROM club_contact_messages
+		WHERE LOWER(sender_email) = (SELECT LOWER(email) FROM users WHERE id = $1) ORDER BY created_at`},
+	{Name: "attachments", Query: `
+		SELECT id, club_id, file_name, content_type, size_bytes, created_at
+		FROM attachments WHERE uploaded_by = $1 ORDER BY created_at`},
+	{Name: "notifications", Query: `
+		SELECT type, title, body, club_id, event_id, created_at, read_at
+		FROM notifications WHERE user_id = $1 ORDER BY created_at`},
+	{Name: "audit_entries", Query: `
+		SELECT method, route, status, entity_type, entity_id, changes, ip_address, request_id, created_at
+		FROM audit_log WHERE actor_id = $1 ORDER BY created_at, id`},
+}
+
+// SectionData is one section of a user's data, as JSON
+type SectionData struct {
+	Name   string
+	Single bool
+	Data   json.RawMessage
+}
+
+// Manifest describes an archive
+type Manifest struct {
+	UserID      uuid.UUID       `json:"userId"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Sections    []ManifestEntry `json:"sections"`
+}
+
+// ManifestEntry is one file of an archive
+type ManifestEntry struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Records int    `json:"records"`
+}
+
+// WriteArchive writes a ZIP of the sections, one JSON file each, with a
+// manifest listing them
+func WriteArchive(w io.Writer, userID uuid.UUID, generatedAt time.Time, sections []SectionData) error {
+	archive := zip.NewWriter(w)
+	manifest := Manifest{UserID: userID, GeneratedAt: generatedAt, Sections: []ManifestEntry{}}
+
+	for _, section := range sections {
+		entry := ManifestEntry{Name: section.Name, File: section.Name + ".json"}
+		if section.Single {
+			if len(section.Data) > 0 && string(section.Data) != "null" {
+				entry.Records = 1
+			}
+		} else {
+			var rows []json.RawMessage
+			if err := json.Unmarshal(section.Data, &rows); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", section.Name, err)
+			}
+			entry.Records = len(rows)
+		}
+		if err := writeJSON(archive, entry.File, section.Data); err != nil {
+			return err
+		}
+		manifest.Sections = append(manifest.Sections, entry)
+	}
+
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeJSON(archive, "manifest.json", encoded); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// writeJSON adds a file holding data, indented for reading
+func writeJSON(archive *zip.Writer, name string, data json.RawMessage) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return fmt.Errorf("failed to format %s: %w", name, err)
+	}
+	f, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	_, err = f.Write(indented.Bytes())
+	return err
+}
+
+// Job is a user's request for an export
+type Job struct {
+	ID                   uuid.UUID  `json:"id"`
+	UserID               uuid.UUID  `json:"userId"`
+	Status               string     `json:"status"`
+	RequestedAt          time.Time  `json:"requestedAt"`
+	CompletedAt          *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt            *time.Time `json:"expiresAt,omitempty"` // when a ready archive is deleted
+	SizeBytes            *int64     `json:"sizeBytes,omitempty"`
+	Error                *string    `json:"error,omitempty"`
+	DownloadURL          string     `json:"downloadUrl,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"downloadUrlExpiresAt,omitempty"`
+	StorageKey           string     `json:"-"`
+}
+
+// Links signs and checks export download links. A link works until the
+// archive expires.
+type Links struct {
+	signer *signedurl.Signer
+}
+
+func NewLinks(signer *signedurl.Signer) Links {
+	return Links{signer: signer}
+}
+
+// Sign gives a ready job a download URL valid until the archive expires
+func (l Links) Sign(job *Job) {
+	if job.ExpiresAt == nil {
+		return
+	}
+	expiresAt := *job.ExpiresAt
+	job.DownloadURL = "/api/exports/" + l.signer.Sign(job.ID.String(), expiresAt)
+	job.DownloadURLExpiresAt = &expiresAt
+}
+
+// Verify returns the ID of the export a download token was issued for
+func (l Links) Verify(token string, now time.Time) (uuid.UUID, error) {
+	subject, _, err := l.signer.Verify(token, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, signedurl.ErrMalformed
+	}
+	return id, nil
+}
+
+// Store reads users' data and tracks export jobs
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// Load reads every section of a user's data
+func (s *Store) Load(ctx context.Context, userID uuid.UUID) ([]SectionData, error) {
+	sections := make([]SectionData, 0, len(Sections))
+	for _, section := range Sections {
+		query := `SELECT COALESCE(jsonb_agg(to_jsonb(t)), '[]'::jsonb) FROM (` + section.Query + `) t`
+		if section.Single {
+			query = `SELECT to_jsonb(t) FROM (` + section.Query + `) t`
+		}
+		var data []byte
+		err := s.db.QueryRowContext(ctx, query, userID).Scan(&data)
+		if err == sql.ErrNoRows {
+			data = []byte("null")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", section.Name, err)
+		}
+		sections = append(sections, SectionData{Name: section.Name, Single: section.Single, Data: data})
+	}
+	return sections, nil
+}
+
+// Request queues an export of the user's data, or returns the one already
+// queued. A ready, failed or expired export is compiled again.
+func (s *Store) Request(ctx context.Context, userID uuid.UUID) (Job, error) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_exports (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE
+		SET status = 'pending', requested_at = CURRENT_TIMESTAMP, started_at = NULL, error = NULL
+		WHERE user_exports.status IN ('ready', 'failed', 'expired')`, userID)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to queue export: %w", err)
+	}
+	return s.Get(ctx, userID)
+}
+
+const selectJobs = `
+	SELECT id, user_id, status, requested_at, completed_at, expires_at, size_bytes, error, COALESCE(storage_key, '')
+	FROM user_exports`
+
+// Get returns the user's export
+func (s *Store) Get(ctx context.Context, userID uuid.UUID) (Job, error) {
+	return s.get(ctx, selectJobs+` WHERE user_id = $1`, userID)
+}
+
+// GetByID returns an export by its ID
+func (s *Store) GetByID(ctx context.Context, id uuid.UUID) (Job, error) {
+	return s.get(ctx, selectJobs+` WHERE id = $1`, id)
+}
+
+func (s *Store) get(ctx context.Context, query string, args ...interface{}) (Job, error) {
+	var job Job
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&job.ID, &job.UserID, &job.Status, &job.RequestedAt, &job.CompletedAt, &job.ExpiresAt,
+		&job.SizeBytes, &job.Error, &job.StorageKey,
+	)
+	if err == sql.ErrNoRows {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to get export: %w", err)
+	}
+	return job, nil
+}
+
+// claim marks the oldest queued export, or one whose compile went stale, as
+// compiling and returns it; ok is false when there is none
+func (s *Store) claim(ctx context.Context, now time.Time) (job Job, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE user_exports SET status = 'compiling', started_at = $1
+		WHERE id = (
+			SELECT id FROM user_exports
+			WHERE status = 'pending' OR (status = 'compiling' AND started_at < $2)
+			ORDER BY requested_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, requested_at`, now, now.Add(-StaleAfter),
+	).Scan(&job.ID, &job.UserID, &job.Status, &job.RequestedAt)
+	if err == sql.ErrNoRows {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("failed to claim export: %w", err)
+	}
+	return job, true, nil
+}
+
+func (s *Store) complete(ctx context.Context, id uuid.UUID, storageKey string, size int64, completedAt, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE user_exports
+		SET status = 'ready', storage_key = $2, size_bytes = $3, error = NULL, completed_at = $4, expires_at = $5
+		WHERE id = $1`, id, storageKey, size, completedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) fail(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE user_exports SET status = 'failed', error = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, message)
+	if err != nil {
+		return fmt.Errorf("failed to mark export failed: %w", err)
+	}
+	return nil
+}
+
+// expire marks ready exports past their expiry as expired and returns the
+// storage keys of their archives
+func (s *Store) expire(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT id, storage_key FROM user_exports
+			WHERE status = 'ready' AND expires_at <= $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE user_exports u SET status = 'expired', storage_key = NULL
+		FROM due WHERE u.id = due.id
+		RETURNING COALESCE(due.storage_key, '')`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire exports: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan expired export: %w", err)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// notifier is the part of notify.Notifier that tells users their export is done
+type notifier interface {
+	NotifyUser(ctx context.Context, userID uuid.UUID, notification models.Notification) (int, error)
+}
+
+// Compiler compiles queued exports one at a time and deletes expired
+// archives. It checks for work every interval, and at once when woken by a
+// request on this instance.
+type Compiler struct {
+	store     *Store
+	storage   attachments.Storage
+	links     Links
+	retention time.Duration
+	notifier  notifier
+	interval  time.Duration
+	logger    *slog.Logger
+	wake      chan struct{}
+}
+
+func NewCompiler(store *Store, storage attachments.Storage, links Links, retention, interval time.Duration, logger *slog.Logger) *Compiler {
+	return &Compiler{
+		store:     store,
+		storage:   storage,
+		links:     links,
+		retention: retention,
+		interval:  interval,
+		logger:    logger,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// WithNotifier notifies users when their export is ready or has failed
+func (c *Compiler) WithNotifier(n notifier) *Compiler {
+	c.notifier = n
+	return c
+}
+
+// Wake makes Run look for queued exports now rather than at the next interval
+func (c *Compiler) Wake() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CompileNext compiles one queued export and reports whether there was one
+func (c *Compiler) CompileNext(ctx context.Context, now time.Time) (bool, error) {
+	job, ok, err := c.store.claim(ctx, now)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	key := "exports/" + job.UserID.String() + ".zip"
+	var archive bytes.Buffer
+	sections, err := c.store.Load(ctx, job.UserID)
+	if err == nil {
+		if err = WriteArchive(&archive, job.UserID, now, sections); err == nil {
+			if err = c.storage.Put(ctx, key, archive.Bytes(), "application/zip"); err == nil {
+				job.ExpiresAt = timePtr(now.Add(c.retention))
+				err = c.store.complete(ctx, job.ID, key, int64(archive.Len()), now, *job.ExpiresAt)
+			}
+		}
+	}
+	if err != nil {
+		if ferr := c.store.fail(ctx, job.ID, "The export could not be compiled. Please ask for it again."); ferr != nil {
+			c.logger.Error("error marking export failed", "export_id", job.ID, "error", ferr)
+		}
+		c.notify(ctx, job, false)
+		return true, fmt.Errorf("failed to compile export %s: %w", job.ID, err)
+	}
+
+	c.notify(ctx, job, true)
+	return true, nil
+}
+
+// ExpireDue deletes the archives of exports past their expiry
+func (c *Compiler) ExpireDue(ctx context.Context, now time.Time) error {
+	keys, err := c.store.expire(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := c.storage.Delete(ctx, key); err != nil && !errors.Is(err, attachments.ErrNotFound) {
+			c.logger.Error("error deleting expired export", "storage_key", key, "error", err)
+		}
+	}
+	return nil
+}
+
+// notify tells the user how their export went
+func (c *Compiler) notify(ctx context.Context, job Job, ready bool) {
+	if c.notifier == nil {
+		return
+	}
+
+	notification := models.Notification{Type: notify.TypeDataExport}
+	if ready {
+		c.links.Sign(&job)
+		notification.Title = "Your data export is ready"
+		notification.Body = fmt.Sprintf("Your data export is ready. Download it before %s, when it is deleted: %s",
+			job.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), job.DownloadURL)
+	} else {
+		notification.Title = "Your data export could not be compiled"
+		notification.Body = "Something went wrong compiling your data export. Please ask for it again."
+	}
+
+	if _, err := c.notifier.NotifyUser(ctx, job.UserID, notification); err != nil {
+		c.logger.Error("error notifying export requester", "export_id", job.ID, "error", err)
+	}
+}
+
+// Run compiles queued exports until none are left and deletes expired ones,
+// then waits for the next interval or a wake, until ctx is cancelled
+func (c *Compiler) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			compiled, err := c.CompileNext(ctx, time.Now())
+			if err != nil {
+				c.logger.Error("error compiling export", "error", err)
+			}
+			if !compiled || ctx.Err() != nil {
+				break
+			}
+		}
+		if err := c.ExpireDue(ctx, time.Now()); err != nil {
+			c.logger.Error("error expiring exports", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.wake:
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
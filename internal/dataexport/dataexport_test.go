@@ -0,0 +1,112 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/signedurl"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteArchive(t *testing.T) {
+	userID := uuid.New()
+	generatedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sections := []SectionData{
+		{Name: "profile", Single: true, Data: json.RawMessage(`{"name":"Ada","email":"ada@example.com"}`)},
+		{Name: "memberships", Data: json.RawMessage(`[{"club_name":"Readers"},{"club_name":"Poets"}]`)},
+		{Name: "notifications", Data: json.RawMessage(`[]`)},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, userID, generatedAt, sections); err != nil {
+		t.Fatalf("WriteArchive: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expected a valid ZIP: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(body)
+	}
+
+	for _, name := range []string{"manifest.json", "profile.json", "memberships.json", "notifications.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the archive, got %v", name, files)
+		}
+	}
+	if !strings.Contains(files["profile.json"], `"email": "ada@example.com"`) {
+		t.Errorf("Expected the profile to be written indented, got %s", files["profile.json"])
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if manifest.UserID != userID || !manifest.GeneratedAt.Equal(generatedAt) {
+		t.Errorf("Expected the manifest to name the user and time, got %+v", manifest)
+	}
+	want := []ManifestEntry{
+		{Name: "profile", File: "profile.json", Records: 1},
+		{Name: "memberships", File: "memberships.json", Records: 2},
+		{Name: "notifications", File: "notifications.json", Records: 0},
+	}
+	if len(manifest.Sections) != len(want) {
+		t.Fatalf("Expected %d sections, got %+v", len(want), manifest.Sections)
+	}
+	for i, entry := range want {
+		if manifest.Sections[i] != entry {
+			t.Errorf("Section %d: expected %+v, got %+v", i, entry, manifest.Sections[i])
+		}
+	}
+}
+
+func TestWriteArchiveMissingProfile(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteArchive(&buf, uuid.New(), time.Now(), []SectionData{{Name: "profile", Single: true, Data: json.RawMessage(`null`)}})
+	if err != nil {
+		t.Fatalf("WriteArchive: %v", err)
+	}
+}
+
+func TestSectionsAreUnique(t *testing.T) {
+	seen := map[string]bool{"manifest": true}
+	for _, section := range Sections {
+		if seen[section.Name] {
+			t.Errorf("Section %s is listed twice or clashes with the manifest", section.Name)
+		}
+		seen[section.Name] = true
+		if strings.Contains(section.Query, "password_hash") || strings.Contains(section.Query, "refresh_tokens") {
+			t.Errorf("Section %s must not export secrets", section.Name)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	now := time.Now()
+	links := NewLinks(signedurl.NewSigner("test-secret", "data-export-download"))
+	expiresAt := now.Add(time.Hour)
+	job := Job{ID: uuid.New(), ExpiresAt: &expiresAt}
+	links.Sign(&job)
+
+	token := job.DownloadURL[len("/api/exports/"):]
+	if id, err := links.Verify(token, now); err != nil || id != job.ID {
+		t.Errorf("Expected the link to verify as %s, got %s, %v", job.ID, id, err)
+	}
+	if _, err := links.Verify(token, now.Add(2*time.Hour)); err != signedurl.ErrExpired {
+		t.Errorf("Expected the link to expire with the archive, got %v", err)
+	}
+}
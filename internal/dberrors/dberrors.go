@@ -0,0 +1,78 @@
+// Package dberrors maps database constraint violations to client errors.
+//
+// A write that breaks a unique, foreign key, not-null or check constraint is
+// the client's doing, not the server's: it gets a 409 or 422 with a stable
+// error code instead of a 500. Constraint names stay in the logs and out of
+// responses.
+package dberrors
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the violations mapped here
+const (
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	notNullViolation     = "23502"
+	checkViolation       = "23514"
+	exclusionViolation   = "23P01"
+	stringTooLong        = "22001"
+	invalidTextValue     = "22P02"
+	numericOutOfRange    = "22003"
+	invalidDatetimeValue = "22007"
+)
+
+// Violation is how a constraint violation is reported to the client
+type Violation struct {
+	Status     int
+	Code       apierror.Code
+	Message    string
+	Details    map[string]interface{}
+	Constraint string // for logs; never sent to clients
+}
+
+// Classify reports whether err, or an error it wraps, is a constraint
+// violation the client caused, and how to answer it
+func Classify(err error) (Violation, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return Violation{}, false
+	}
+
+	v := Violation{Constraint: pgErr.ConstraintName}
+	switch pgErr.Code {
+	case uniqueViolation:
+		v.Status, v.Code, v.Message = http.StatusConflict, apierror.CodeAlreadyExists, "A record with these details already exists"
+	case exclusionViolation:
+		v.Status, v.Code, v.Message = http.StatusConflict, apierror.CodeOverlapsExisting, "The record overlaps an existing one"
+	case foreignKeyViolation:
+		// Deleting a row still referenced reports the referencing table's constraint
+		if strings.HasPrefix(pgErr.Message, "update or delete on table") {
+			v.Status, v.Code, v.Message = http.StatusConflict, apierror.CodeStillReferenced, "The record is still used by other records"
+		} else {
+			v.Status, v.Code, v.Message = http.StatusUnprocessableEntity, apierror.CodeReferenceNotFound, "A referenced record does not exist"
+		}
+	case notNullViolation:
+		v.Status, v.Code, v.Message = http.StatusUnprocessableEntity, apierror.CodeMissingValue, "A required value is missing"
+		if pgErr.ColumnName != "" {
+			v.Details = models.InvalidField(pgErr.ColumnName, "required", "is required")
+		}
+	case checkViolation:
+		v.Status, v.Code, v.Message = http.StatusUnprocessableEntity, apierror.CodeInvalidValue, "A value is not allowed"
+	case stringTooLong:
+		v.Status, v.Code, v.Message = http.StatusUnprocessableEntity, apierror.CodeValueTooLong, "A value is longer than allowed"
+	case invalidTextValue, numericOutOfRange, invalidDatetimeValue:
+		v.Status, v.Code, v.Message = http.StatusUnprocessableEntity, apierror.CodeInvalidValue, "A value is not valid"
+	default:
+		return Violation{}, false
+	}
+	return v, true
+}
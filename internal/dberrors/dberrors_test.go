@@ -0,0 +1,53 @@
+package dberrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"bookwork-api/internal/apierror"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   apierror.Code
+	}{
+		{"unique", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, http.StatusConflict, "ALREADY_EXISTS"},
+		{"wrapped unique", fmt.Errorf("failed to create tag: %w", &pgconn.PgError{Code: "23505"}), http.StatusConflict, "ALREADY_EXISTS"},
+		{"missing reference", &pgconn.PgError{Code: "23503", Message: `insert or update on table "events" violates foreign key constraint "events_club_id_fkey"`}, http.StatusUnprocessableEntity, "REFERENCE_NOT_FOUND"},
+		{"still referenced", &pgconn.PgError{Code: "23503", Message: `update or delete on table "clubs" violates foreign key constraint "events_club_id_fkey" on table "events"`}, http.StatusConflict, "STILL_REFERENCED"},
+		{"not null", &pgconn.PgError{Code: "23502", ColumnName: "title"}, http.StatusUnprocessableEntity, "MISSING_VALUE"},
+		{"check", &pgconn.PgError{Code: "23514"}, http.StatusUnprocessableEntity, "INVALID_VALUE"},
+		{"too long", &pgconn.PgError{Code: "22001"}, http.StatusUnprocessableEntity, "VALUE_TOO_LONG"},
+	}
+
+	for _, tt := range tests {
+		v, ok := Classify(tt.err)
+		if !ok || v.Status != tt.status || v.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %+v (%v)", tt.name, tt.status, tt.code, v, ok)
+		}
+	}
+
+	if v, _ := Classify(&pgconn.PgError{Code: "23502", ColumnName: "title"}); v.Details == nil {
+		t.Error("Expected the missing column in the details")
+	}
+}
+
+func TestClassifyLeavesServerErrors(t *testing.T) {
+	for _, err := range []error{
+		errors.New("connection refused"),
+		&pgconn.PgError{Code: "40001"}, // serialization failure
+		&pgconn.PgError{Code: "42P01"}, // undefined table
+		nil,
+	} {
+		if v, ok := Classify(err); ok {
+			t.Errorf("%v: expected no client error, got %+v", err, v)
+		}
+	}
+}
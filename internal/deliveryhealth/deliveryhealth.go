@@ -0,0 +1,220 @@
+// Package deliveryhealth keeps what became of each notification at each
+// external delivery provider and reports it per club, so club owners can see
+// why members say they never got a reminder.
+//
+// The notification dispatcher records an outcome per notification and
+// provider: sent, failed, bounced, dropped when the provider's queue was
+// full, or suppressed when the recipient must not be reached through it. Reports aggregate the outcomes of a club's notifications created
+// within a window, and those of its webhook deliveries from the webhook
+// delivery log.
+package deliveryhealth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+// recentFailuresLimit is how many undelivered notifications a report lists
+const recentFailuresLimit = 20
+
+// Provider is the delivery record of one provider for a club's notifications
+type Provider struct {
+	Provider    string  `json:"provider"`
+	Total       int     `json:"total"`
+	Sent        int     `json:"sent"`
+	Failed      int     `json:"failed"`
+	Bounced     int     `json:"bounced"`
+	Dropped     int     `json:"dropped"`
+	Suppressed  int     `json:"suppressed"`
+	SuccessRate float64 `json:"successRate"`
+	BounceRate  float64 `json:"bounceRate"`
+}
+
+// Failure is a notification a provider did not deliver
+type Failure struct {
+	NotificationID uuid.UUID `json:"notificationId"`
+	UserID         uuid.UUID `json:"userId"`
+	UserName       string    `json:"userName"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Provider       string    `json:"provider"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	RecordedAt     time.Time `json:"recordedAt"`
+}
+
+// Report is the delivery health of a club's notifications since a time
+type Report struct {
+	Since          time.Time  `json:"since"`
+	Notifications  int        `json:"notifications"`
+	Providers      []Provider `json:"providers"`
+	RecentFailures []Failure  `json:"recentFailures"`
+	Webhooks       *Provider  `json:"webhooks"` // nil when the club's webhooks had nothing to deliver
+}
+
+// Store records delivery outcomes and reports on them
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// RecordOutcomes keeps the outcomes of deliveries at provider, replacing any
+// earlier outcome of the same notification there, such as a sent delivery
+// that later bounced
+func (s *Store) RecordOutcomes(ctx context.Context, provider string, outcomes []notify.Outcome) error {
+	ids := make([]string, len(outcomes))
+	statuses := make([]string, len(outcomes))
+	errs := make([]string, len(outcomes))
+	for i, outcome := range outcomes {
+		ids[i] = outcome.NotificationID.String()
+		statuses[i] = outcome.Status
+		errs[i] = outcome.Error
+	}
+
+	query := `
+		INSERT INTO notification_deliveries (notification_id, provider, status, error)
+		SELECT o.id, $1, o.status, NULLIF(o.error, '')
+		FROM unnest($2::uuid[], $3::text[], $4::text[]) AS o(id, status, error)
+		JOIN notifications n ON n.id = o.id
+		ON CONFLICT (notification_id, provider) DO UPDATE
+		SET status = EXCLUDED.status, error = EXCLUDED.error, recorded_at = CURRENT_TIMESTAMP`
+
+	if _, err := s.db.ExecContext(ctx, query, provider, models.StringArray(ids), models.StringArray(statuses), models.StringArray(errs)); err != nil {
+		return fmt.Errorf("failed to record delivery outcomes: %w", err)
+	}
+	return nil
+}
+
+// Club reports the delivery health of the notifications created for clubID's
+// members since since
+func (s *Store) Club(ctx context.Context, clubID uuid.UUID, since time.Time) (Report, error) {
+	report := Report{Since: since, Providers: []Provider{}, RecentFailures: []Failure{}}
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE club_id = $1 AND created_at >= $2`,
+		clubID, since,
+	).Scan(&report.Notifications)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.provider, COUNT(*),
+		       COUNT(*) FILTER (WHERE d.status = 'sent'),
+		       COUNT(*) FILTER (WHERE d.status = 'failed'),
+		       COUNT(*) FILTER (WHERE d.status = 'bounced'),
+		       COUNT(*) FILTER (WHERE d.status = 'dropped'),
+		       COUNT(*) FILTER (WHERE d.status = 'suppressed')
+		FROM notification_deliveries d
+		JOIN notifications n ON n.id = d.notification_id
+		WHERE n.club_id = $1 AND n.created_at >= $2
+		GROUP BY d.provider
+		ORDER BY d.provider`,
+		clubID, since,
+	)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to query delivery outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Provider
+		if err := rows.Scan(&p.Provider, &p.Total, &p.Sent, &p.Failed, &p.Bounced, &p.Dropped, &p.Suppressed); err != nil {
+			return Report{}, fmt.Errorf("failed to scan delivery outcomes: %w", err)
+		}
+		report.Providers = append(report.Providers, withRates(p))
+	}
+	if err := rows.Err(); err != nil {
+		return Report{}, fmt.Errorf("failed to read delivery outcomes: %w", err)
+	}
+
+	failures, err := s.recentFailures(ctx, clubID, since)
+	if err != nil {
+		return Report{}, err
+	}
+	report.RecentFailures = failures
+
+	webhooks, err := s.webhooks(ctx, clubID, since)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Webhooks = webhooks
+	return report, nil
+}
+
+// webhooks reports the club's webhook deliveries since since. A delivery is
+// sent once an attempt succeeded and failed once the last attempt failed; one
+// still being retried counts towards the total only.
+func (s *Store) webhooks(ctx context.Context, clubID uuid.UUID, since time.Time) (*Provider, error) {
+	p := Provider{Provider: "webhooks"}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT d.delivery_id),
+		       COUNT(*) FILTER (WHERE d.status = 'succeeded'),
+		       COUNT(*) FILTER (WHERE d.status = 'failed')
+		FROM webhook_deliveries d
+		JOIN club_webhooks w ON w.id = d.webhook_id
+		WHERE w.club_id = $1 AND d.created_at >= $2`,
+		clubID, since,
+	).Scan(&p.Total, &p.Sent, &p.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	if p.Total == 0 {
+		return nil, nil
+	}
+	p = withRates(p)
+	return &p, nil
+}
+
+// recentFailures lists the club's latest undelivered notifications, newest first
+func (s *Store) recentFailures(ctx context.Context, clubID uuid.UUID, since time.Time) ([]Failure, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT n.id, n.user_id, u.name, n.type, n.title, d.provider, d.status, COALESCE(d.error, ''), d.recorded_at
+		FROM notification_deliveries d
+		JOIN notifications n ON n.id = d.notification_id
+		JOIN users u ON u.id = n.user_id
+		WHERE n.club_id = $1 AND n.created_at >= $2 AND d.status <> 'sent'
+		ORDER BY d.recorded_at DESC
+		LIMIT $3`,
+		clubID, since, recentFailuresLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delivery failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []Failure{}
+	for rows.Next() {
+		var f Failure
+		if err := rows.Scan(&f.NotificationID, &f.UserID, &f.UserName, &f.Type, &f.Title, &f.Provider, &f.Status, &f.Error, &f.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read delivery failures: %w", err)
+	}
+	return failures, nil
+}
+
+// withRates fills in a provider's rates from its counts. The bounce rate is
+// of deliveries the provider handed on, i.e. those that were sent or bounced.
+func withRates(p Provider) Provider {
+	if p.Total > 0 {
+		p.SuccessRate = float64(p.Sent) / float64(p.Total)
+	}
+	if handed := p.Sent + p.Bounced; handed > 0 {
+		p.BounceRate = float64(p.Bounced) / float64(handed)
+	}
+	return p
+}
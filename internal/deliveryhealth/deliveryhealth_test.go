@@ -0,0 +1,20 @@
+package deliveryhealth
+
+import "testing"
+
+func TestWithRates(t *testing.T) {
+	p := withRates(Provider{Provider: "email", Total: 10, Sent: 6, Failed: 1, Bounced: 2, Dropped: 1})
+	if p.SuccessRate != 0.6 {
+		t.Errorf("Expected a success rate of 0.6, got %v", p.SuccessRate)
+	}
+	if p.BounceRate != 0.25 {
+		t.Errorf("Expected a bounce rate of 0.25, got %v", p.BounceRate)
+	}
+}
+
+func TestWithRatesNothingHandedOn(t *testing.T) {
+	p := withRates(Provider{Provider: "push", Total: 3, Dropped: 3})
+	if p.SuccessRate != 0 || p.BounceRate != 0 {
+		t.Errorf("Expected zero rates, got %v and %v", p.SuccessRate, p.BounceRate)
+	}
+}
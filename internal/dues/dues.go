@@ -0,0 +1,124 @@
+// Package dues tracks optional club membership dues.
+//
+// A club charges a fixed amount in its currency every period (monthly,
+// quarterly or yearly). Each payment covers one period, named by its first
+// day, and a member has paid for a period once their payments for it add up
+// to the amount. Payments in another currency are kept but not counted.
+package dues
+
+import (
+	"errors"
+	"time"
+
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotConfigured is returned for member statuses of a club that charges no dues
+var ErrNotConfigured = errors.New("club does not charge dues")
+
+// Period is how often dues are charged
+type Period string
+
+const (
+	Monthly   Period = "monthly"
+	Quarterly Period = "quarterly"
+	Yearly    Period = "yearly"
+)
+
+// Valid reports whether p is a known period
+func (p Period) Valid() bool {
+	return p == Monthly || p == Quarterly || p == Yearly
+}
+
+// Start returns the first day of the period containing t, in UTC
+func (p Period) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch p {
+	case Yearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	case Quarterly:
+		month := time.Month((int(t.Month())-1)/3*3 + 1)
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// End returns the first day of the period after the one starting at start
+func (p Period) End(start time.Time) time.Time {
+	switch p {
+	case Yearly:
+		return start.AddDate(1, 0, 0)
+	case Quarterly:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Settings is a club's dues policy. A nil Amount means the club charges no dues.
+type Settings struct {
+	Amount          *money.Money `json:"amount"`
+	Period          Period       `json:"period,omitempty"`
+	RequiredForRSVP bool         `json:"requiredForRsvp"`
+	Currency        string       `json:"currency"` // the club currency dues are charged in
+}
+
+// Enabled reports whether the club charges dues
+func (s Settings) Enabled() bool {
+	return s.Amount != nil && s.Period.Valid()
+}
+
+// Payment methods
+const (
+	MethodManual = "manual"
+	MethodStripe = "stripe"
+)
+
+// Payment is a dues payment covering one period
+type Payment struct {
+	ID          uuid.UUID   `json:"id"`
+	ClubID      uuid.UUID   `json:"clubId"`
+	UserID      uuid.UUID   `json:"userId"`
+	Amount      money.Money `json:"amount"`
+	PeriodStart string      `json:"periodStart"` // YYYY-MM-DD
+	Method      string      `json:"method"`
+	ExternalID  *string     `json:"externalId,omitempty"`
+	Notes       *string     `json:"notes,omitempty"`
+	RecordedBy  *uuid.UUID  `json:"recordedBy,omitempty"`
+	PaidAt      time.Time   `json:"paidAt"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+// Payment statuses for a period
+const (
+	StatusPaid    = "paid"
+	StatusPartial = "partial"
+	StatusUnpaid  = "unpaid"
+)
+
+// MemberStatus is whether a member has paid the dues for one period
+type MemberStatus struct {
+	UserID      uuid.UUID   `json:"userId"`
+	Name        string      `json:"name"`
+	PeriodStart string      `json:"periodStart"`
+	PeriodEnd   string      `json:"periodEnd"` // first day of the next period
+	Due         money.Money `json:"due"`
+	Paid        money.Money `json:"paid"`
+	Status      string      `json:"status"`
+	LastPaidAt  *time.Time  `json:"lastPaidAt,omitempty"`
+}
+
+// statusOf compares what was paid for a period with what is due
+func statusOf(paid, due money.Money) string {
+	switch {
+	case paid.Minor >= due.Minor:
+		return StatusPaid
+	case paid.Minor > 0:
+		return StatusPartial
+	default:
+		return StatusUnpaid
+	}
+}
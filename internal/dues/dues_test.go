@@ -0,0 +1,58 @@
+package dues
+
+import (
+	"testing"
+	"time"
+
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/timeutil"
+)
+
+func TestPeriodStartAndEnd(t *testing.T) {
+	at := time.Date(2024, time.August, 17, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period Period
+		start  string
+		end    string
+	}{
+		{Monthly, "2024-08-01", "2024-09-01"},
+		{Quarterly, "2024-07-01", "2024-10-01"},
+		{Yearly, "2024-01-01", "2025-01-01"},
+	}
+	for _, tt := range tests {
+		start := tt.period.Start(at)
+		if got := timeutil.FormatDate(start); got != tt.start {
+			t.Errorf("%s start = %s, expected %s", tt.period, got, tt.start)
+		}
+		if got := timeutil.FormatDate(tt.period.End(start)); got != tt.end {
+			t.Errorf("%s end = %s, expected %s", tt.period, got, tt.end)
+		}
+	}
+
+	// Periods are calendar periods in UTC
+	late := time.Date(2024, time.March, 31, 23, 0, 0, 0, time.FixedZone("UTC-5", -5*3600))
+	if got := timeutil.FormatDate(Quarterly.Start(late)); got != "2024-04-01" {
+		t.Errorf("Expected the UTC quarter, got %s", got)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	due := money.New(2500, "EUR")
+	tests := map[int64]string{0: StatusUnpaid, 1000: StatusPartial, 2500: StatusPaid, 3000: StatusPaid}
+	for paid, expected := range tests {
+		if got := statusOf(money.New(paid, "EUR"), due); got != expected {
+			t.Errorf("statusOf(%d) = %s, expected %s", paid, got, expected)
+		}
+	}
+}
+
+func TestSettingsEnabled(t *testing.T) {
+	amount := money.New(1000, "USD")
+	if (Settings{}).Enabled() {
+		t.Error("Expected settings without an amount to be disabled")
+	}
+	if !(Settings{Amount: &amount, Period: Monthly}).Enabled() {
+		t.Error("Expected settings with an amount and period to be enabled")
+	}
+}
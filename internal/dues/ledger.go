@@ -0,0 +1,251 @@
+package dues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"bookwork-api/internal/billing"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// Checkout metadata naming the club, member and (optionally) period a Stripe payment is for
+const (
+	PurposeDues         = "dues"
+	MetadataClubID      = "club_id"
+	MetadataUserID      = "user_id"
+	MetadataPeriodStart = "period_start"
+)
+
+// Ledger stores club dues settings and payments
+type Ledger struct {
+	db *database.DB
+}
+
+func NewLedger(db *database.DB) *Ledger {
+	return &Ledger{db: db}
+}
+
+// Settings returns the club's dues policy, or sql.ErrNoRows for an unknown club
+func (l *Ledger) Settings(ctx context.Context, clubID uuid.UUID) (Settings, error) {
+	query := `SELECT dues_amount::text, dues_period, dues_required_for_rsvp, currency FROM clubs WHERE id = $1`
+
+	var amount, period sql.NullString
+	var settings Settings
+	if err := l.db.QueryRowContext(ctx, query, clubID).Scan(&amount, &period, &settings.RequiredForRSVP, &settings.Currency); err != nil {
+		return Settings{}, err
+	}
+
+	settings.Period = Period(period.String)
+	if amount.Valid {
+		parsed, err := money.Parse(amount.String, settings.Currency)
+		if err != nil {
+			return Settings{}, err
+		}
+		settings.Amount = &parsed
+	}
+	return settings, nil
+}
+
+// Configure replaces the club's dues policy; a nil Amount stops charging dues
+func (l *Ledger) Configure(ctx context.Context, clubID uuid.UUID, settings Settings) error {
+	var amount, period interface{}
+	if settings.Amount != nil {
+		amount, period = settings.Amount.String(), string(settings.Period)
+	}
+
+	query := `UPDATE clubs SET dues_amount = $2, dues_period = $3, dues_required_for_rsvp = $4 WHERE id = $1`
+	result, err := l.db.ExecContext(ctx, query, clubID, amount, period, settings.RequiredForRSVP)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Record stores a payment, filling in its ID and timestamps. A payment whose
+// ExternalID was already recorded returns billing.ErrAlreadyRecorded.
+func (l *Ledger) Record(ctx context.Context, payment *Payment) error {
+	query := `
+		INSERT INTO dues_payments (club_id, user_id, amount, currency, period_start, method, external_id, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (external_id) DO NOTHING
+		RETURNING id, paid_at, created_at`
+
+	err := l.db.QueryRowContext(ctx, query,
+		payment.ClubID, payment.UserID, payment.Amount.String(), payment.Amount.Currency, payment.PeriodStart,
+		payment.Method, payment.ExternalID, payment.Notes, payment.RecordedBy,
+	).Scan(&payment.ID, &payment.PaidAt, &payment.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return billing.ErrAlreadyRecorded
+	}
+	return err
+}
+
+// RecordCheckout stores a paid Stripe Checkout session created with dues
+// metadata. Without a period_start the payment covers the current period.
+func (l *Ledger) RecordCheckout(ctx context.Context, session billing.CheckoutSession) error {
+	clubID, err := uuid.Parse(session.Metadata[MetadataClubID])
+	if err != nil {
+		return errors.New("checkout metadata has no valid club_id")
+	}
+	userID, err := uuid.Parse(session.Metadata[MetadataUserID])
+	if err != nil {
+		return errors.New("checkout metadata has no valid user_id")
+	}
+	amount, err := session.Amount()
+	if err != nil {
+		return err
+	}
+
+	settings, err := l.Settings(ctx, clubID)
+	if err != nil {
+		return err
+	}
+	period := settings.Period
+	if !period.Valid() {
+		period = Monthly
+	}
+	periodStart := period.Start(time.Now())
+	if requested, err := timeutil.ParseDate(session.Metadata[MetadataPeriodStart]); err == nil {
+		periodStart = period.Start(requested)
+	}
+
+	externalID := session.ID
+	return l.Record(ctx, &Payment{
+		ClubID:      clubID,
+		UserID:      userID,
+		Amount:      amount,
+		PeriodStart: timeutil.FormatDate(periodStart),
+		Method:      MethodStripe,
+		ExternalID:  &externalID,
+	})
+}
+
+// Payments lists a club's payments, newest first, optionally for one member
+func (l *Ledger) Payments(ctx context.Context, clubID uuid.UUID, userID *uuid.UUID, limit, offset int) ([]Payment, error) {
+	query := `
+		SELECT id, club_id, user_id, amount::text, currency, period_start, method, external_id, notes, recorded_by, paid_at, created_at
+		FROM dues_payments
+		WHERE club_id = $1 AND ($2::uuid IS NULL OR user_id = $2)
+		ORDER BY paid_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := l.db.QueryContext(ctx, query, clubID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []Payment{}
+	for rows.Next() {
+		var payment Payment
+		var amount, currency string
+		var periodStart time.Time
+		if err := rows.Scan(&payment.ID, &payment.ClubID, &payment.UserID, &amount, &currency, &periodStart,
+			&payment.Method, &payment.ExternalID, &payment.Notes, &payment.RecordedBy, &payment.PaidAt, &payment.CreatedAt); err != nil {
+			return nil, err
+		}
+		if payment.Amount, err = money.Parse(amount, currency); err != nil {
+			return nil, err
+		}
+		payment.PeriodStart = timeutil.FormatDate(periodStart)
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// Statuses returns every active member's payment status for the period containing at,
+// ordered by name. It returns ErrNotConfigured when the club charges no dues.
+func (l *Ledger) Statuses(ctx context.Context, clubID uuid.UUID, at time.Time) ([]MemberStatus, error) {
+	settings, err := l.Settings(ctx, clubID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled() {
+		return nil, ErrNotConfigured
+	}
+	start := settings.Period.Start(at)
+
+	query := `
+		SELECT u.id, u.name,
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.currency = $3), 0)::text,
+		       MAX(p.paid_at)
+		FROM club_members cm
+		JOIN users u ON u.id = cm.user_id
+		LEFT JOIN dues_payments p ON p.club_id = cm.club_id AND p.user_id = cm.user_id AND p.period_start = $2
+		WHERE cm.club_id = $1 AND cm.is_active = true
+		GROUP BY u.id, u.name
+		ORDER BY u.name`
+
+	rows, err := l.db.QueryContext(ctx, query, clubID, start, settings.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []MemberStatus{}
+	for rows.Next() {
+		var status MemberStatus
+		var paid string
+		if err := rows.Scan(&status.UserID, &status.Name, &paid, &status.LastPaidAt); err != nil {
+			return nil, err
+		}
+		if err := status.fill(settings, start, paid); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// Status returns a member's status for the period containing at along with the
+// club's settings. The status is nil when the club charges no dues.
+func (l *Ledger) Status(ctx context.Context, clubID, userID uuid.UUID, at time.Time) (*MemberStatus, Settings, error) {
+	settings, err := l.Settings(ctx, clubID)
+	if err != nil {
+		return nil, Settings{}, err
+	}
+	if !settings.Enabled() {
+		return nil, settings, nil
+	}
+	start := settings.Period.Start(at)
+
+	query := `
+		SELECT COALESCE(SUM(amount), 0)::text, MAX(paid_at)
+		FROM dues_payments
+		WHERE club_id = $1 AND user_id = $2 AND period_start = $3 AND currency = $4`
+
+	status := MemberStatus{UserID: userID}
+	var paid string
+	if err := l.db.QueryRowContext(ctx, query, clubID, userID, start, settings.Currency).Scan(&paid, &status.LastPaidAt); err != nil {
+		return nil, Settings{}, err
+	}
+	if err := status.fill(settings, start, paid); err != nil {
+		return nil, Settings{}, err
+	}
+	return &status, settings, nil
+}
+
+// fill sets the period, amounts and status from the sum paid for the period
+func (s *MemberStatus) fill(settings Settings, start time.Time, paid string) error {
+	amount, err := money.Parse(paid, settings.Currency)
+	if err != nil {
+		return err
+	}
+	s.PeriodStart = timeutil.FormatDate(start)
+	s.PeriodEnd = timeutil.FormatDate(settings.Period.End(start))
+	s.Due = *settings.Amount
+	s.Paid = amount
+	s.Status = statusOf(amount, s.Due)
+	return nil
+}
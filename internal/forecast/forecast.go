@@ -0,0 +1,74 @@
+// Package forecast projects how many people will turn up to an event.
+//
+// Clubs record the headcount of past events next to the RSVPs they had. The
+// club's conversion rate is the people who came per RSVP over its recent
+// events, and the expected headcount is the current RSVPs at that rate. The
+// low and high figures apply the worst and best rates seen, so organizers can
+// cater and book a venue for a range rather than a single number.
+package forecast
+
+import (
+	"math"
+
+	"bookwork-api/internal/models"
+)
+
+// MinEvents is the number of past events with RSVPs needed before history is
+// used; with fewer, the forecast is the RSVP count itself
+const MinEvents = 3
+
+// HistoryEvents is the number of a club's most recent recorded events the
+// conversion rate is taken from, so it follows changes in the club
+const HistoryEvents = 10
+
+// Outcome is a past event's RSVPs and recorded headcount
+type Outcome struct {
+	RSVPs    int
+	Attended int
+}
+
+// Project forecasts the headcount for rsvps current RSVPs from the club's past
+// outcomes. capacity, when set, caps the figures.
+func Project(history []Outcome, rsvps, maybes int, capacity *int) models.AttendanceForecast {
+	forecast := models.AttendanceForecast{
+		RSVPs:    rsvps,
+		Maybes:   maybes,
+		Expected: rsvps,
+		Low:      rsvps,
+		High:     rsvps,
+		Basis:    models.ForecastBasisRSVPs,
+	}
+
+	var totalRSVPs, totalAttended int
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, o := range history {
+		// Without RSVPs an event says nothing about conversion
+		if o.RSVPs <= 0 || o.Attended < 0 {
+			continue
+		}
+		forecast.SampleEvents++
+		totalRSVPs += o.RSVPs
+		totalAttended += o.Attended
+
+		rate := float64(o.Attended) / float64(o.RSVPs)
+		low = math.Min(low, rate)
+		high = math.Max(high, rate)
+	}
+
+	if forecast.SampleEvents >= MinEvents {
+		rate := float64(totalAttended) / float64(totalRSVPs)
+		rounded := math.Round(rate*100) / 100
+		forecast.ConversionRate = &rounded
+		forecast.Basis = models.ForecastBasisHistory
+		forecast.Expected = int(math.Round(float64(rsvps) * rate))
+		forecast.Low = int(math.Floor(float64(rsvps) * low))
+		forecast.High = int(math.Ceil(float64(rsvps) * high))
+	}
+
+	if capacity != nil {
+		forecast.Expected = min(forecast.Expected, *capacity)
+		forecast.Low = min(forecast.Low, *capacity)
+		forecast.High = min(forecast.High, *capacity)
+	}
+	return forecast
+}
@@ -0,0 +1,53 @@
+package forecast
+
+import (
+	"testing"
+
+	"bookwork-api/internal/models"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestProjectFromHistory(t *testing.T) {
+	history := []Outcome{
+		{RSVPs: 10, Attended: 8},
+		{RSVPs: 20, Attended: 18},
+		{RSVPs: 10, Attended: 6},
+		{RSVPs: 0, Attended: 4}, // no RSVPs: ignored
+	}
+
+	got := Project(history, 15, 3, nil)
+
+	if got.Basis != models.ForecastBasisHistory || got.SampleEvents != 3 {
+		t.Fatalf("Expected a history forecast from 3 events, got %+v", got)
+	}
+	if got.ConversionRate == nil || *got.ConversionRate != 0.8 {
+		t.Errorf("Expected a conversion rate of 0.8, got %v", got.ConversionRate)
+	}
+	// 15 RSVPs at 0.8, 0.6 and 0.9
+	if got.RSVPs != 15 || got.Maybes != 3 || got.Expected != 12 || got.Low != 9 || got.High != 14 {
+		t.Errorf("Unexpected forecast: %+v", got)
+	}
+}
+
+func TestProjectWithoutEnoughHistory(t *testing.T) {
+	got := Project([]Outcome{{RSVPs: 10, Attended: 5}, {RSVPs: 10, Attended: 5}}, 12, 0, nil)
+
+	if got.Basis != models.ForecastBasisRSVPs || got.ConversionRate != nil {
+		t.Errorf("Expected an RSVP-only forecast, got %+v", got)
+	}
+	if got.Expected != 12 || got.Low != 12 || got.High != 12 || got.SampleEvents != 2 {
+		t.Errorf("Expected the RSVP count, got %+v", got)
+	}
+}
+
+func TestProjectCapsAtCapacity(t *testing.T) {
+	history := []Outcome{{RSVPs: 10, Attended: 12}, {RSVPs: 10, Attended: 11}, {RSVPs: 10, Attended: 10}}
+
+	got := Project(history, 20, 0, ptr(21))
+
+	// Walk-ins push the rate above one, but the venue holds 21
+	if got.Expected != 21 || got.Low != 20 || got.High != 21 {
+		t.Errorf("Expected figures capped at 21, got %+v", got)
+	}
+}
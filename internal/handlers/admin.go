@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/shadow"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// overviewWindow is how far back request rates are measured
+const overviewWindow = time.Minute
+
+// webhookFailureWindow is how far back given-up webhook deliveries are counted;
+// they are rare enough that a minute would nearly always read zero
+const webhookFailureWindow = time.Hour
+
+// AdminHandler serves the operational overview behind the internal ops dashboard
+// and the audit log
+type AdminHandler struct {
+	clocked
+
+	db         *sql.DB
+	requests   *middleware.RequestRecorder
+	dispatcher *notify.Dispatcher
+	auditLog   auditLister
+	shadows    *shadow.Registry
+}
+
+// auditLister reads the audit log; *audit.Log implements it
+type auditLister interface {
+	List(ctx context.Context, filter audit.Filter) ([]audit.Entry, error)
+}
+
+// AdminOverview is one screen of operational numbers. Metrics the server has no
+// source for are null rather than zero, so the dashboard can tell them apart.
+type AdminOverview struct {
+	RequestsPerMinute    int        `json:"requestsPerMinute"`
+	ErrorRate            float64    `json:"errorRate"`
+	PendingJobs          int        `json:"pendingJobs"`
+	WebhookFailures      *int       `json:"webhookFailures"` // deliveries given up on in the last hour
+	DBPool               DBPoolStat `json:"dbPool"`
+	WebSocketConnections *int       `json:"webSocketConnections"` // no WebSocket endpoints yet
+	GeneratedAt          time.Time  `json:"generatedAt"`
+	RequestWindowSeconds int        `json:"requestWindowSeconds"`
+}
+
+// DBPoolStat summarizes database connection pool usage
+type DBPoolStat struct {
+	Status         string   `json:"status"`
+	InUse          int      `json:"inUse"`
+	Idle           int      `json:"idle"`
+	MaxOpen        int      `json:"maxOpen"`
+	Saturation     *float64 `json:"saturation"`
+	WaitCount      int64    `json:"waitCount"`
+	WaitDurationMs int64    `json:"waitDurationMs"`
+}
+
+// NewAdminHandler creates the handler; db may be nil in mock mode
+func NewAdminHandler(db *sql.DB, requests *middleware.RequestRecorder, dispatcher *notify.Dispatcher) *AdminHandler {
+	return &AdminHandler{db: db, requests: requests, dispatcher: dispatcher}
+}
+
+// WithAuditLog enables GET /admin/audit
+func (h *AdminHandler) WithAuditLog(auditLog auditLister) *AdminHandler {
+	h.auditLog = auditLog
+	return h
+}
+
+// WithShadows enables GET /admin/shadow
+func (h *AdminHandler) WithShadows(shadows *shadow.Registry) *AdminHandler {
+	h.shadows = shadows
+	return h
+}
+
+// GetOverview aggregates request rate, error rate, queued jobs, webhook
+// failures and pool usage
+func (h *AdminHandler) GetOverview(w http.ResponseWriter, r *http.Request) {
+	now := h.now()
+	total, serverErrors := h.requests.Throughput(now.Add(-overviewWindow))
+
+	overview := AdminOverview{
+		RequestsPerMinute:    total,
+		PendingJobs:          h.dispatcher.Pending(),
+		WebhookFailures:      h.webhookFailures(r.Context(), now.Add(-webhookFailureWindow)),
+		DBPool:               h.poolStats(),
+		GeneratedAt:          now.UTC(),
+		RequestWindowSeconds: int(overviewWindow.Seconds()),
+	}
+	if total > 0 {
+		overview.ErrorRate = float64(serverErrors) / float64(total)
+	}
+
+	h.writeSuccessResponse(w, overview, "Overview retrieved successfully")
+}
+
+// GetShadowReports reports each shadowed schema refactor's mode, dual writes,
+// compared reads and mismatches since startup, so it can be cut over once clean
+func (h *AdminHandler) GetShadowReports(w http.ResponseWriter, r *http.Request) {
+	if h.shadows == nil {
+		h.writeError(w, apierror.NotFound("Shadow reporting is not enabled"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"refactors": h.shadows.Stats(),
+	}
+
+	h.writeSuccessResponse(w, response, "Shadow reports retrieved successfully")
+}
+
+// GetAuditLog lists audit entries, newest first. Filters: actorId, entityType,
+// entityId, method, from and to (RFC 3339), page and limit.
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if h.auditLog == nil {
+		h.writeError(w, apierror.NotFound("Audit log is not enabled"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := audit.Filter{
+		EntityType: query.Get("entityType"),
+		EntityID:   query.Get("entityId"),
+		Method:     query.Get("method"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	}
+
+	if actor := query.Get("actorId"); actor != "" {
+		actorID, err := uuid.Parse(actor)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid actorId", models.InvalidID("actorId")))
+			return
+		}
+		filter.ActorID = &actorID
+	}
+
+	if from := query.Get("from"); from != "" {
+		parsed, err := timeutil.ParseTimestamp(from)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid from. Use RFC 3339, e.g. 2024-01-31T00:00:00Z", models.InvalidField("from", "datetime", "must be an RFC 3339 time")))
+			return
+		}
+		filter.From = &parsed
+	}
+
+	if to := query.Get("to"); to != "" {
+		parsed, err := timeutil.ParseTimestamp(to)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid to. Use RFC 3339, e.g. 2024-01-31T00:00:00Z", models.InvalidField("to", "datetime", "must be an RFC 3339 time")))
+			return
+		}
+		filter.To = &parsed
+	}
+
+	entries, err := h.auditLog.List(r.Context(), filter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying audit log", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get audit log"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"entries": entries,
+		"page":    page,
+		"limit":   limit,
+	}
+
+	h.writeSuccessResponse(w, response, "Audit log retrieved successfully")
+}
+
+// webhookFailures counts the webhook deliveries whose last attempt since since
+// failed for good. It is nil without a database or when the log can't be read.
+func (h *AdminHandler) webhookFailures(ctx context.Context, since time.Time) *int {
+	if h.db == nil {
+		return nil
+	}
+
+	var failures int
+	err := h.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhook_deliveries WHERE status = 'failed' AND created_at >= $1`,
+		since,
+	).Scan(&failures)
+	if err != nil {
+		logging.FromContext(ctx).Error("error counting webhook failures", "error", err)
+		return nil
+	}
+	return &failures
+}
+
+func (h *AdminHandler) poolStats() DBPoolStat {
+	if h.db == nil {
+		return DBPoolStat{Status: "mock"}
+	}
+
+	stats := h.db.Stats()
+	pool := DBPoolStat{
+		Status:         "healthy",
+		InUse:          stats.InUse,
+		Idle:           stats.Idle,
+		MaxOpen:        stats.MaxOpenConnections,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: stats.WaitDuration.Milliseconds(),
+	}
+	// An unlimited pool (MaxOpenConnections 0) cannot saturate
+	if stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		pool.Saturation = &saturation
+	}
+	return pool
+}
+
+func (h *AdminHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
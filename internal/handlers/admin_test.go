@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/middleware"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/shadow"
+
+	"github.com/google/uuid"
+)
+
+func TestAdminOverview(t *testing.T) {
+	recorder := middleware.NewRequestRecorder(10)
+	now := time.Now()
+	recorder.Record(middleware.RequestRecord{Status: http.StatusOK, StartedAt: now})
+	recorder.Record(middleware.RequestRecord{Status: http.StatusOK, StartedAt: now})
+	recorder.Record(middleware.RequestRecord{Status: http.StatusOK, StartedAt: now})
+	recorder.Record(middleware.RequestRecord{Status: http.StatusBadGateway, StartedAt: now})
+
+	dispatcher := notify.NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	handler := NewAdminHandler(nil, recorder, dispatcher)
+
+	w := httptest.NewRecorder()
+	handler.GetOverview(w, httptest.NewRequest("GET", "/api/admin/overview", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Data["requestsPerMinute"] != float64(4) || response.Data["errorRate"] != 0.25 {
+		t.Errorf("Unexpected request numbers: %v", response.Data)
+	}
+	if response.Data["webhookFailures"] != nil || response.Data["webSocketConnections"] != nil {
+		t.Errorf("Expected unavailable metrics to be null, got %v", response.Data)
+	}
+}
+
+type fakeAuditLog struct {
+	filter audit.Filter
+}
+
+func (f *fakeAuditLog) List(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	f.filter = filter
+	return []audit.Entry{{ID: 1, Method: "PUT", Route: "/api/club/{clubId}/settings"}}, nil
+}
+
+func TestAdminAuditLogFilters(t *testing.T) {
+	auditLog := &fakeAuditLog{}
+	handler := NewAdminHandler(nil, middleware.NewRequestRecorder(1), nil).WithAuditLog(auditLog)
+	actorID := uuid.New()
+
+	w := httptest.NewRecorder()
+	handler.GetAuditLog(w, httptest.NewRequest("GET",
+		"/api/admin/audit?actorId="+actorID.String()+"&entityType=club&method=put&from=2024-01-01T00:00:00Z&page=3&limit=10", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	filter := auditLog.filter
+	if filter.ActorID == nil || *filter.ActorID != actorID || filter.EntityType != "club" || filter.Method != "put" {
+		t.Errorf("Unexpected filter: %+v", filter)
+	}
+	if filter.From == nil || filter.From.Year() != 2024 || filter.To != nil {
+		t.Errorf("Expected only a from bound, got %v and %v", filter.From, filter.To)
+	}
+	if filter.Limit != 10 || filter.Offset != 20 {
+		t.Errorf("Expected limit 10 offset 20, got %d and %d", filter.Limit, filter.Offset)
+	}
+
+	for _, query := range []string{"actorId=nope", "from=yesterday", "to=2024-01-01"} {
+		w := httptest.NewRecorder()
+		handler.GetAuditLog(w, httptest.NewRequest("GET", "/api/admin/audit?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestShadowReports(t *testing.T) {
+	dispatcher := notify.NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+
+	// Not configured
+	w := httptest.NewRecorder()
+	NewAdminHandler(nil, middleware.NewRequestRecorder(10), dispatcher).GetShadowReports(w, httptest.NewRequest("GET", "/api/admin/shadow", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without shadows, got %d", w.Code)
+	}
+
+	shadows := shadow.NewRegistry()
+	startsAt := shadows.Register("events.starts_at", shadow.ShadowRead)
+	handler := NewEventHandler(nil).WithStartsAtShadow(startsAt)
+
+	// A clean read and a row that was never dual-written
+	legacy := time.Date(2030, 1, 15, 19, 30, 0, 0, time.UTC)
+	event := &models.Event{ID: uuid.New()}
+	handler.shadowStartsAt(context.Background(), event, legacy, &legacy)
+	handler.shadowStartsAt(context.Background(), event, legacy, nil)
+
+	w = httptest.NewRecorder()
+	NewAdminHandler(nil, middleware.NewRequestRecorder(10), dispatcher).WithShadows(shadows).
+		GetShadowReports(w, httptest.NewRequest("GET", "/api/admin/shadow", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data struct {
+			Refactors []shadow.Stats `json:"refactors"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	refactors := response.Data.Refactors
+	if len(refactors) != 1 || refactors[0].Reads != 2 || refactors[0].Missing != 1 || refactors[0].Mismatches != 0 {
+		t.Errorf("Unexpected reports: %+v", refactors)
+	}
+}
+
+func TestShadowStartsAtCutover(t *testing.T) {
+	shadows := shadow.NewRegistry()
+	handler := NewEventHandler(nil).WithStartsAtShadow(shadows.Register("events.starts_at", shadow.Cutover))
+
+	legacy := time.Date(2030, 1, 15, 19, 30, 0, 0, time.UTC)
+	startsAt := time.Date(2030, 1, 15, 20, 0, 0, 0, time.UTC)
+	event := &models.Event{ID: uuid.New(), Date: "2030-01-15T00:00:00Z", Time: "0000-01-01T19:30:00Z"}
+	handler.shadowStartsAt(context.Background(), event, legacy, &startsAt)
+
+	if event.Date != "2030-01-15T00:00:00Z" || event.Time != "0000-01-01T20:00:00Z" {
+		t.Errorf("Expected the event to be served from starts_at, got %s %s", event.Date, event.Time)
+	}
+	if stats := shadows.Stats()[0]; stats.Mismatches != 1 {
+		t.Errorf("Expected one mismatch, got %d", stats.Mismatches)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/analytics"
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxAnalyticsDays bounds the date range of an analytics report
+const maxAnalyticsDays = 366
+
+// analyticsReader is the part of analytics.Store the handler uses
+type analyticsReader interface {
+	Report(ctx context.Context, clubID *uuid.UUID, from, to time.Time) (*analytics.Report, error)
+}
+
+// usageReader is the part of analytics.Store reading endpoint usage
+type usageReader interface {
+	EndpointUsage(ctx context.Context, from, to time.Time, routes []analytics.Route) ([]analytics.EndpointUsage, error)
+}
+
+// AnalyticsHandler serves activity summaries read from the analytics views:
+// events and RSVPs per day and active members per week
+type AnalyticsHandler struct {
+	clocked
+
+	analytics analyticsReader
+	usage     usageReader       // nil leaves endpoint usage out of platform reports
+	routes    []analytics.Route // listed in endpoint usage even when unused
+}
+
+func NewAnalyticsHandler(analytics analyticsReader) *AnalyticsHandler {
+	return &AnalyticsHandler{analytics: analytics}
+}
+
+// WithUsage adds the requests per endpoint to platform reports, listing every
+// one of routes whether or not it was used
+func (h *AnalyticsHandler) WithUsage(usage usageReader, routes []analytics.Route) *AnalyticsHandler {
+	h.usage = usage
+	h.routes = routes
+	return h
+}
+
+// GetPlatformAnalytics summarizes activity across all clubs, or one club with
+// clubId, between from and to (YYYY-MM-DD, inclusive), defaulting to the last
+// 30 days
+func (h *AnalyticsHandler) GetPlatformAnalytics(w http.ResponseWriter, r *http.Request) {
+	var clubID *uuid.UUID
+	if value := r.URL.Query().Get("clubId"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+			return
+		}
+		clubID = &id
+	}
+
+	h.writeReport(w, r, clubID)
+}
+
+// GetClubAnalytics summarizes a club's activity between from and to
+// (YYYY-MM-DD, inclusive), defaulting to the last 30 days
+func (h *AnalyticsHandler) GetClubAnalytics(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.writeReport(w, r, &clubID)
+}
+
+func (h *AnalyticsHandler) writeReport(w http.ResponseWriter, r *http.Request, clubID *uuid.UUID) {
+	to := h.now().UTC()
+	from := to.AddDate(0, 0, -29)
+	var err error
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = timeutil.ParseDate(value); err != nil {
+			h.writeError(w, apierror.Validation("from must be a date (YYYY-MM-DD)", models.InvalidField("from", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = timeutil.ParseDate(value); err != nil {
+			h.writeError(w, apierror.Validation("to must be a date (YYYY-MM-DD)", models.InvalidField("to", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxAnalyticsDays*24*time.Hour {
+		h.writeError(w, apierror.Validation("from must be before to and at most 366 days earlier", models.InvalidField("from", "range", "must be before to and at most 366 days earlier")))
+		return
+	}
+
+	report, err := h.analytics.Report(r.Context(), clubID, from, to)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying analytics", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get analytics"))
+		return
+	}
+	if clubID == nil && h.usage != nil {
+		if report.EndpointUsage, err = h.usage.EndpointUsage(r.Context(), from, to, h.routes); err != nil {
+			logging.FromContext(r.Context()).Error("error querying endpoint usage", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get analytics"))
+			return
+		}
+	}
+
+	h.writeSuccessResponse(w, report, "Analytics retrieved successfully")
+}
+
+func (h *AnalyticsHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AnalyticsHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/analytics"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeAnalytics records the last report asked for
+type fakeAnalytics struct {
+	clubID   *uuid.UUID
+	from, to time.Time
+}
+
+func (f *fakeAnalytics) Report(ctx context.Context, clubID *uuid.UUID, from, to time.Time) (*analytics.Report, error) {
+	f.clubID, f.from, f.to = clubID, from, to
+	return &analytics.Report{From: timeutil.FormatDate(from), To: timeutil.FormatDate(to)}, nil
+}
+
+func (f *fakeAnalytics) EndpointUsage(ctx context.Context, from, to time.Time, routes []analytics.Route) ([]analytics.EndpointUsage, error) {
+	usage := []analytics.EndpointUsage{}
+	for _, route := range routes {
+		usage = append(usage, analytics.EndpointUsage{Method: route.Method, Route: route.Route})
+	}
+	return usage, nil
+}
+
+func setupAnalyticsTest() (*fakeAnalytics, chi.Router) {
+	reader := &fakeAnalytics{}
+	handler := NewAnalyticsHandler(reader)
+	handler.clock = clock.NewFake(time.Date(2030, time.March, 15, 23, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Get("/admin/analytics", handler.GetPlatformAnalytics)
+	router.Get("/club/{clubId}/analytics", handler.GetClubAnalytics)
+	return reader, router
+}
+
+func TestGetPlatformAnalytics(t *testing.T) {
+	reader, router := setupAnalyticsTest()
+	clubID := uuid.New()
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"?clubId=nope", http.StatusBadRequest},
+		{"?from=2030-13-01", http.StatusBadRequest},
+		{"?from=2030-03-10&to=2030-03-01", http.StatusBadRequest},
+		{"?from=2028-01-01&to=2030-01-01", http.StatusBadRequest},
+		{"?from=2030-01-01&to=2030-01-31", http.StatusOK},
+		{"?clubId=" + clubID.String(), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/analytics"+tt.query, nil))
+		if w.Code != tt.expected {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.expected, w.Code)
+		}
+	}
+
+	if reader.clubID == nil || *reader.clubID != clubID {
+		t.Errorf("Expected the report to be limited to club %s, got %v", clubID, reader.clubID)
+	}
+	if timeutil.FormatDate(reader.from) != "2030-02-14" || timeutil.FormatDate(reader.to) != "2030-03-15" {
+		t.Errorf("Expected the last 30 days by default, got %s to %s", reader.from, reader.to)
+	}
+}
+
+func TestGetClubAnalytics(t *testing.T) {
+	reader, router := setupAnalyticsTest()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/club/nope/analytics", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid club ID to be rejected, got %d", w.Code)
+	}
+
+	clubID := uuid.New()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/club/"+clubID.String()+"/analytics?from=2030-03-01&to=2030-03-07", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if reader.clubID == nil || *reader.clubID != clubID {
+		t.Errorf("Expected the report for club %s, got %v", clubID, reader.clubID)
+	}
+
+	var response struct {
+		Data analytics.Report `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.From != "2030-03-01" || response.Data.To != "2030-03-07" {
+		t.Errorf("Unexpected range %s to %s", response.Data.From, response.Data.To)
+	}
+}
+
+func TestPlatformAnalyticsIncludesEndpointUsage(t *testing.T) {
+	reader := &fakeAnalytics{}
+	handler := NewAnalyticsHandler(reader).WithUsage(reader, []analytics.Route{{Method: "GET", Route: "/api/clubs"}})
+	router := chi.NewRouter()
+	router.Get("/admin/analytics", handler.GetPlatformAnalytics)
+	router.Get("/club/{clubId}/analytics", handler.GetClubAnalytics)
+
+	tests := []struct {
+		target   string
+		expected int
+	}{
+		{"/admin/analytics", 1},
+		{"/admin/analytics?clubId=" + uuid.New().String(), 0},
+		{"/club/" + uuid.New().String() + "/analytics", 0},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		var response struct {
+			Data analytics.Report `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.target, err)
+		}
+		if len(response.Data.EndpointUsage) != tt.expected {
+			t.Errorf("%s: expected %d endpoints, got %+v", tt.target, tt.expected, response.Data.EndpointUsage)
+		}
+	}
+}
@@ -0,0 +1,572 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	announcementSeverities = []string{"info", "warning", "critical"}
+	announcementAudiences  = []string{"all", "owners"}
+)
+
+// announcementColumns is the column list scanned by scanAnnouncement
+const announcementColumns = `a.id, a.title, a.body, a.severity, a.audience, a.show_banner,
+		a.starts_at, a.ends_at, a.created_by, a.created_at, a.updated_at`
+
+// announcementVisibleTo restricts announcements to those live now and meant for user $1.
+// The owners audience covers anyone who owns at least one club.
+const announcementVisibleTo = `a.starts_at <= NOW() AND (a.ends_at IS NULL OR a.ends_at > NOW())
+		AND (a.audience = 'all' OR EXISTS (SELECT 1 FROM clubs c WHERE c.owner_id = $1 AND c.deleted_at IS NULL))`
+
+// AnnouncementHandler serves platform-wide announcements: admins publish and schedule
+// them, users receive them through the notification feed and the banner endpoint
+type AnnouncementHandler struct {
+	clocked
+
+	db *database.DB
+}
+
+func NewAnnouncementHandler(db *database.DB) *AnnouncementHandler {
+	return &AnnouncementHandler{db: db}
+}
+
+// CreateAnnouncement publishes or schedules an announcement (admin only)
+func (h *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateAnnouncementRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	now := h.now()
+	announcement := &models.Announcement{
+		ID:         uuid.New(),
+		Title:      strings.TrimSpace(req.Title),
+		Body:       strings.TrimSpace(req.Body),
+		Severity:   req.Severity,
+		Audience:   req.Audience,
+		ShowBanner: req.ShowBanner,
+		StartsAt:   now,
+		EndsAt:     req.EndsAt,
+		CreatedBy:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if announcement.Severity == "" {
+		announcement.Severity = "info"
+	}
+	if announcement.Audience == "" {
+		announcement.Audience = "all"
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = *req.StartsAt
+	}
+
+	if err := validateAnnouncement(announcement); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	query := `
+		INSERT INTO announcements (id, title, body, severity, audience, show_banner, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = h.db.ExecContext(r.Context(), query,
+		announcement.ID, announcement.Title, announcement.Body, announcement.Severity, announcement.Audience,
+		announcement.ShowBanner, announcement.StartsAt, announcement.EndsAt, announcement.CreatedBy,
+	)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating announcement", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create announcement"))
+		return
+	}
+
+	announcement.Status = announcement.StatusAt(now)
+
+	response := map[string]interface{}{
+		"announcement": announcement,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Announcement created successfully")
+}
+
+// ListAnnouncements lists every announcement, including scheduled and expired ones (admin only)
+func (h *AnnouncementHandler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && !containsString([]string{"scheduled", "active", "expired"}, status) {
+		h.writeError(w, apierror.Validation("Invalid status. Must be 'scheduled', 'active', or 'expired'", models.InvalidField("status", "oneof", "must be one of: scheduled, active, expired")))
+		return
+	}
+
+	query := `SELECT ` + announcementColumns + ` FROM announcements a ORDER BY a.starts_at DESC`
+
+	rows, err := h.db.QueryContext(r.Context(), query)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying announcements", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get announcements"))
+		return
+	}
+	defer rows.Close()
+
+	now := h.now()
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		if err := scanAnnouncement(rows, &announcement); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning announcement", "error", err)
+			continue
+		}
+
+		announcement.Status = announcement.StatusAt(now)
+		if status != "" && announcement.Status != status {
+			continue
+		}
+
+		announcements = append(announcements, announcement)
+	}
+
+	response := map[string]interface{}{
+		"announcements": announcements,
+	}
+
+	h.writeSuccessResponse(w, response, "Announcements retrieved successfully")
+}
+
+// UpdateAnnouncement edits an announcement, e.g. to extend a maintenance window (admin only)
+func (h *AnnouncementHandler) UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := uuid.Parse(chi.URLParam(r, "announcementId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid announcement ID", models.InvalidID("announcementId")))
+		return
+	}
+
+	var req models.UpdateAnnouncementRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var announcement models.Announcement
+	query := `SELECT ` + announcementColumns + ` FROM announcements a WHERE a.id = $1`
+	if err := scanAnnouncement(h.db.QueryRowContext(r.Context(), query, announcementID), &announcement); err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Announcement not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting announcement", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update announcement"))
+		return
+	}
+
+	// Apply the changes to the stored announcement so the result is validated as a whole
+	setParts := []string{}
+	args := []interface{}{}
+	argIndex := 1
+
+	set := func(column string, value interface{}) {
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	if req.Title != nil {
+		announcement.Title = strings.TrimSpace(*req.Title)
+		set("title", announcement.Title)
+	}
+	if req.Body != nil {
+		announcement.Body = strings.TrimSpace(*req.Body)
+		set("body", announcement.Body)
+	}
+	if req.Severity != nil {
+		announcement.Severity = *req.Severity
+		set("severity", announcement.Severity)
+	}
+	if req.Audience != nil {
+		announcement.Audience = *req.Audience
+		set("audience", announcement.Audience)
+	}
+	if req.ShowBanner != nil {
+		announcement.ShowBanner = *req.ShowBanner
+		set("show_banner", announcement.ShowBanner)
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = *req.StartsAt
+		set("starts_at", announcement.StartsAt)
+	}
+	if req.EndsAt != nil {
+		announcement.EndsAt = req.EndsAt
+		set("ends_at", *announcement.EndsAt)
+	}
+
+	if len(setParts) == 0 {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	if err := validateAnnouncement(&announcement); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	announcement.UpdatedAt = h.now()
+	set("updated_at", announcement.UpdatedAt)
+
+	args = append(args, announcementID)
+	updateQuery := fmt.Sprintf("UPDATE announcements SET %s WHERE id = $%d", strings.Join(setParts, ", "), argIndex)
+
+	if _, err := h.db.ExecContext(r.Context(), updateQuery, args...); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating announcement", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update announcement"))
+		return
+	}
+
+	announcement.Status = announcement.StatusAt(announcement.UpdatedAt)
+
+	response := map[string]interface{}{
+		"announcement": announcement,
+	}
+
+	h.writeSuccessResponse(w, response, "Announcement updated successfully")
+}
+
+// DeleteAnnouncement removes an announcement from every feed (admin only)
+func (h *AnnouncementHandler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := uuid.Parse(chi.URLParam(r, "announcementId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid announcement ID", models.InvalidID("announcementId")))
+		return
+	}
+
+	result, err := h.db.ExecContext(r.Context(), `DELETE FROM announcements WHERE id = $1`, announcementID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting announcement", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete announcement"))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("Announcement not found"))
+		return
+	}
+
+	response := map[string]string{
+		"message": "Announcement deleted successfully",
+	}
+
+	h.writeSuccessResponse(w, response, "Announcement deleted successfully")
+}
+
+// GetNotifications returns the caller's notification feed: live announcements for their audience
+func (h *AnnouncementHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	query := `
+		SELECT ` + announcementColumns + `, ar.user_id IS NOT NULL
+		FROM announcements a
+		LEFT JOIN announcement_reads ar ON ar.announcement_id = a.id AND ar.user_id = $1
+		WHERE ` + announcementVisibleTo + `
+		ORDER BY a.starts_at DESC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying notifications", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get notifications"))
+		return
+	}
+	defer rows.Close()
+
+	now := h.now()
+	unread := 0
+	notifications := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		var read bool
+		if err := scanAnnouncement(rows, &announcement, &read); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning notification", "error", err)
+			continue
+		}
+
+		announcement.Status = announcement.StatusAt(now)
+		announcement.Read = &read
+		if !read {
+			unread++
+		}
+
+		notifications = append(notifications, announcement)
+	}
+
+	clubNotifications, clubUnread, err := h.listClubNotifications(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying club notifications", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get notifications"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"notifications":     notifications,
+		"clubNotifications": clubNotifications,
+		"unreadCount":       unread + clubUnread,
+	}
+
+	h.writeSuccessResponse(w, response, "Notifications retrieved successfully")
+}
+
+// listClubNotifications returns the caller's most recent club activity notifications
+// and how many of them are unread
+func (h *AnnouncementHandler) listClubNotifications(ctx context.Context, userID uuid.UUID) ([]models.Notification, int, error) {
+	query := `
+		SELECT id, user_id, type, title, body, club_id, event_id, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 50`
+
+	rows, err := h.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	unread := 0
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.ClubID, &n.EventID, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, 0, err
+		}
+		if n.ReadAt == nil {
+			unread++
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, unread, rows.Err()
+}
+
+// MarkNotificationRead marks one of the caller's club notifications read
+func (h *AnnouncementHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := uuid.Parse(chi.URLParam(r, "notificationId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid notification ID", models.InvalidID("notificationId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := h.db.ExecContext(r.Context(), query, notificationID, userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error marking notification read", "error", err)
+		h.writeError(w, apierror.Internal("Failed to mark notification as read"))
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		h.writeError(w, apierror.NotFound("Notification not found"))
+		return
+	}
+
+	response := map[string]string{
+		"notificationId": notificationID.String(),
+	}
+
+	h.writeSuccessResponse(w, response, "Notification marked as read")
+}
+
+// GetBanner returns the live banner announcements the caller has not dismissed,
+// most severe first, so clients can show the top one
+func (h *AnnouncementHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	query := `
+		SELECT ` + announcementColumns + `
+		FROM announcements a
+		WHERE a.show_banner = true AND ` + announcementVisibleTo + `
+		  AND NOT EXISTS (
+		      SELECT 1 FROM announcement_reads ar
+		      WHERE ar.announcement_id = a.id AND ar.user_id = $1
+		  )
+		ORDER BY CASE a.severity WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END, a.starts_at DESC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying banner announcements", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get banner"))
+		return
+	}
+	defer rows.Close()
+
+	now := h.now()
+	banners := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		if err := scanAnnouncement(rows, &announcement); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning banner announcement", "error", err)
+			continue
+		}
+
+		announcement.Status = announcement.StatusAt(now)
+		banners = append(banners, announcement)
+	}
+
+	response := map[string]interface{}{
+		"banners": banners,
+	}
+
+	h.writeSuccessResponse(w, response, "Banner retrieved successfully")
+}
+
+// MarkRead marks an announcement as read for the caller, which also dismisses its banner
+func (h *AnnouncementHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := uuid.Parse(chi.URLParam(r, "announcementId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid announcement ID", models.InvalidID("announcementId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// Only announcements the caller can currently see can be marked read
+	query := `
+		INSERT INTO announcement_reads (announcement_id, user_id)
+		SELECT a.id, $1 FROM announcements a
+		WHERE a.id = $2 AND ` + announcementVisibleTo + `
+		ON CONFLICT (announcement_id, user_id) DO NOTHING`
+
+	if _, err := h.db.ExecContext(r.Context(), query, userID, announcementID); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error marking announcement read", "error", err)
+		h.writeError(w, apierror.Internal("Failed to mark announcement as read"))
+		return
+	}
+
+	var read bool
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT EXISTS (SELECT 1 FROM announcement_reads WHERE announcement_id = $1 AND user_id = $2)`,
+		announcementID, userID).Scan(&read)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error checking announcement read", "error", err)
+		h.writeError(w, apierror.Internal("Failed to mark announcement as read"))
+		return
+	}
+	if !read {
+		h.writeError(w, apierror.NotFound("Announcement not found"))
+		return
+	}
+
+	response := map[string]string{
+		"message": "Announcement marked as read",
+	}
+
+	h.writeSuccessResponse(w, response, "Announcement marked as read")
+}
+
+// validateAnnouncement reports the first invalid field, or nil
+func validateAnnouncement(a *models.Announcement) *apierror.Error {
+	switch {
+	case a.Title == "":
+		return invalidField("title", "required", "is required", "Title is required")
+	case len(a.Title) > 255:
+		return invalidField("title", "max", "must be at most 255 characters", "Title must be at most 255 characters")
+	case !containsString(announcementSeverities, a.Severity):
+		return invalidField("severity", "oneof", "must be one of: info, warning, critical",
+			"Invalid severity. Must be 'info', 'warning', or 'critical'")
+	case !containsString(announcementAudiences, a.Audience):
+		return invalidField("audience", "oneof", "must be one of: all, owners", "Invalid audience. Must be 'all' or 'owners'")
+	case a.EndsAt != nil && !a.EndsAt.After(a.StartsAt):
+		return invalidField("endsAt", "gtfield", "must be after startsAt", "End time must be after start time")
+	}
+	return nil
+}
+
+// scanAnnouncement scans announcementColumns into a, followed by any extra destinations
+func scanAnnouncement(row interface{ Scan(...interface{}) error }, a *models.Announcement, extra ...interface{}) error {
+	var createdBy *uuid.UUID
+	dest := []interface{}{
+		&a.ID, &a.Title, &a.Body, &a.Severity, &a.Audience, &a.ShowBanner,
+		&a.StartsAt, &a.EndsAt, &createdBy, &a.CreatedAt, &a.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if createdBy != nil {
+		a.CreatedBy = *createdBy
+	}
+	return nil
+}
+
+func (h *AnnouncementHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *AnnouncementHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AnnouncementHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the room allowed for multipart boundaries and headers beyond the file itself
+const multipartOverhead = 64 << 10
+
+// AttachmentHandler uploads, lists and serves files attached to event items and clubs.
+// Downloads go through short-lived signed URLs, so links can be handed to a browser
+// or an <img> tag without the caller's access token.
+type AttachmentHandler struct {
+	clocked
+
+	db           *database.DB
+	storage      attachments.Storage
+	signer       *signedurl.Signer
+	maxSize      int64
+	allowedTypes []string
+	urlTTL       time.Duration
+}
+
+func NewAttachmentHandler(db *database.DB, storage attachments.Storage, signer *signedurl.Signer, maxSize int64, allowedTypes []string, urlTTL time.Duration) *AttachmentHandler {
+	if len(allowedTypes) == 0 {
+		allowedTypes = attachments.DefaultAllowedTypes
+	}
+	return &AttachmentHandler{
+		db:           db,
+		storage:      storage,
+		signer:       signer,
+		maxSize:      maxSize,
+		allowedTypes: allowedTypes,
+		urlTTL:       urlTTL,
+	}
+}
+
+// UploadItemAttachment attaches a file to an event item (club managers and the event creator)
+func (h *AttachmentHandler) UploadItemAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	event, itemID, ok := h.resolveItem(w, r)
+	if !ok {
+		return
+	}
+
+	h.upload(w, r, event.ClubID, &itemID, userID)
+}
+
+// GetItemAttachments lists an event item's attachments with signed download URLs
+func (h *AttachmentHandler) GetItemAttachments(w http.ResponseWriter, r *http.Request) {
+	_, itemID, ok := h.resolveItem(w, r)
+	if !ok {
+		return
+	}
+
+	h.list(w, r, `WHERE event_item_id = $1`, itemID)
+}
+
+// DeleteItemAttachment removes an event item's attachment and its stored file
+func (h *AttachmentHandler) DeleteItemAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	_, itemID, ok := h.resolveItem(w, r)
+	if !ok {
+		return
+	}
+
+	h.delete(w, r, `event_item_id = $2`, itemID)
+}
+
+// UploadClubResource adds a club resource such as a venue map (club managers)
+func (h *AttachmentHandler) UploadClubResource(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	h.upload(w, r, clubID, nil, userID)
+}
+
+// GetClubResources lists the club's resources with signed download URLs
+func (h *AttachmentHandler) GetClubResources(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.list(w, r, `WHERE club_id = $1 AND event_item_id IS NULL`, clubID)
+}
+
+// DeleteClubResource removes a club resource and its stored file
+func (h *AttachmentHandler) DeleteClubResource(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.delete(w, r, `club_id = $2 AND event_item_id IS NULL`, clubID)
+}
+
+// Download serves an attachment to anyone holding a valid signed URL (no login)
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	subject, _, err := h.signer.Verify(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		if err == signedurl.ErrExpired {
+			h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This download link has expired", nil))
+			return
+		}
+		h.writeError(w, apierror.NotFound("Attachment not found"))
+		return
+	}
+
+	attachmentID, err := uuid.Parse(subject)
+	if err != nil {
+		h.writeError(w, apierror.NotFound("Attachment not found"))
+		return
+	}
+
+	var attachment models.Attachment
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT file_name, content_type, size_bytes, storage_key FROM attachments WHERE id = $1`, attachmentID,
+	).Scan(&attachment.FileName, &attachment.ContentType, &attachment.SizeBytes, &attachment.StorageKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Attachment not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting attachment", "error", err)
+		h.writeError(w, apierror.Internal("Failed to download attachment"))
+		return
+	}
+
+	body, err := h.storage.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if err == attachments.ErrNotFound {
+			h.writeError(w, apierror.NotFound("Attachment not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reading attachment from storage", "error", err)
+		h.writeError(w, apierror.Internal("Failed to download attachment"))
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, body); err != nil {
+		logging.FromContext(r.Context()).Error("error streaming attachment", "error", err)
+	}
+}
+
+// resolveItem checks that the item in the URL belongs to the event authorized by
+// authz.RequireEventRole. It writes the error response itself and reports whether
+// the caller may continue.
+func (h *AttachmentHandler) resolveItem(w http.ResponseWriter, r *http.Request) (*models.Event, uuid.UUID, bool) {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.NotFound("Event not found"))
+		return nil, uuid.Nil, false
+	}
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
+		return nil, uuid.Nil, false
+	}
+
+	var exists int
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT 1 FROM event_items WHERE id = $1 AND event_id = $2`, itemID, event.ID,
+	).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Item not found"))
+			return nil, uuid.Nil, false
+		}
+		logging.FromContext(r.Context()).Error("error getting event item", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get item"))
+		return nil, uuid.Nil, false
+	}
+
+	return event, itemID, true
+}
+
+// upload reads the "file" part of a multipart request, validates it and stores it
+func (h *AttachmentHandler) upload(w http.ResponseWriter, r *http.Request, clubID uuid.UUID, itemID *uuid.UUID, userID uuid.UUID) {
+	fileName, content, uploadErr := h.readUpload(w, r)
+	if uploadErr != nil {
+		h.writeError(w, uploadErr)
+		return
+	}
+
+	contentType, err := attachments.DetectType(content, h.allowedTypes)
+	if err != nil {
+		h.writeError(w, apierror.New(apierror.CodeUnsupportedMediaType, "This file type is not allowed", map[string]interface{}{
+			"detectedType": contentType,
+			"allowedTypes": h.allowedTypes,
+		}))
+		return
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New(),
+		ClubID:      clubID,
+		EventItemID: itemID,
+		FileName:    fileName,
+		ContentType: contentType,
+		SizeBytes:   int64(len(content)),
+		UploadedBy:  &userID,
+		CreatedAt:   h.now(),
+	}
+	attachment.StorageKey = "clubs/" + clubID.String() + "/" + attachment.ID.String()
+
+	if err := h.storage.Put(r.Context(), attachment.StorageKey, content, contentType); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error storing attachment", "error", err)
+		h.writeError(w, apierror.Internal("Failed to upload attachment"))
+		return
+	}
+
+	query := `
+		INSERT INTO attachments (id, club_id, event_item_id, file_name, content_type, size_bytes, storage_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = h.db.ExecContext(r.Context(), query,
+		attachment.ID, attachment.ClubID, attachment.EventItemID, attachment.FileName,
+		attachment.ContentType, attachment.SizeBytes, attachment.StorageKey, attachment.UploadedBy,
+	)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error creating attachment", "error", err)
+		// Do not leave an unreferenced file behind
+		if err := h.storage.Delete(context.WithoutCancel(r.Context()), attachment.StorageKey); err != nil {
+			logging.FromContext(r.Context()).Error("error removing orphaned attachment", "error", err)
+		}
+		h.writeError(w, apierror.Internal("Failed to upload attachment"))
+		return
+	}
+
+	h.sign(attachment)
+
+	response := map[string]interface{}{
+		"attachment": attachment,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Attachment uploaded successfully")
+}
+
+// readUpload returns the name and contents of the "file" part, enforcing the size limit
+func (h *AttachmentHandler) readUpload(w http.ResponseWriter, r *http.Request) (string, []byte, *apierror.Error) {
+	return readMultipartFile(w, r, h.maxSize)
+}
+
+// readMultipartFile returns the name and contents of the "file" part of a
+// multipart upload, which must not be empty or larger than maxSize
+func readMultipartFile(w http.ResponseWriter, r *http.Request, maxSize int64) (string, []byte, *apierror.Error) {
+	tooLarge := apierror.New(apierror.CodeRequestTooLarge, "File is too large", map[string]interface{}{"maxBytes": maxSize})
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", nil, invalidField("", "multipart", "Request must be a multipart/form-data upload", "Expected a multipart/form-data upload")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", nil, invalidField("file", "required", "is required", "A file field is required")
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return "", nil, tooLarge
+			}
+			return "", nil, invalidField("", "multipart", "Request is not a valid multipart upload", "Malformed multipart upload")
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		var content bytes.Buffer
+		if _, err := io.Copy(&content, io.LimitReader(part, maxSize+1)); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return "", nil, tooLarge
+			}
+			return "", nil, invalidField("", "multipart", "Request is not a valid multipart upload", "Malformed multipart upload")
+		}
+		if int64(content.Len()) > maxSize {
+			return "", nil, tooLarge
+		}
+		if content.Len() == 0 {
+			return "", nil, invalidField("file", "min", "must not be empty", "File is empty")
+		}
+
+		return cleanFileName(part.FileName()), content.Bytes(), nil
+	}
+}
+
+// list writes the attachments matching where (with one argument) and signs their download URLs
+func (h *AttachmentHandler) list(w http.ResponseWriter, r *http.Request, where string, arg interface{}) {
+	query := `
+		SELECT id, club_id, event_item_id, file_name, content_type, size_bytes, uploaded_by, created_at
+		FROM attachments ` + where + `
+		ORDER BY created_at ASC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, arg)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying attachments", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get attachments"))
+		return
+	}
+	defer rows.Close()
+
+	list := []*models.Attachment{}
+	for rows.Next() {
+		var attachment models.Attachment
+		err := rows.Scan(
+			&attachment.ID, &attachment.ClubID, &attachment.EventItemID, &attachment.FileName,
+			&attachment.ContentType, &attachment.SizeBytes, &attachment.UploadedBy, &attachment.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning attachment", "error", err)
+			continue
+		}
+		h.sign(&attachment)
+		list = append(list, &attachment)
+	}
+
+	response := map[string]interface{}{
+		"attachments": list,
+	}
+
+	h.writeSuccessResponse(w, response, "Attachments retrieved successfully")
+}
+
+// delete removes the attachment in the URL if it also matches scope (with $2 bound to arg)
+func (h *AttachmentHandler) delete(w http.ResponseWriter, r *http.Request, scope string, arg interface{}) {
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid attachment ID", models.InvalidID("attachmentId")))
+		return
+	}
+
+	var storageKey string
+	err = h.db.QueryRowContext(r.Context(),
+		`DELETE FROM attachments WHERE id = $1 AND `+scope+` RETURNING storage_key`, attachmentID, arg,
+	).Scan(&storageKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Attachment not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting attachment", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete attachment"))
+		return
+	}
+
+	// The row is gone, so a failure here only leaves an unreachable file behind
+	if err := h.storage.Delete(r.Context(), storageKey); err != nil {
+		logging.FromContext(r.Context()).Error("error deleting attachment from storage", "error", err, "storage_key", storageKey)
+	}
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Attachment deleted successfully"}, "Attachment deleted successfully")
+}
+
+// sign sets a short-lived download URL on the attachment
+func (h *AttachmentHandler) sign(attachment *models.Attachment) {
+	expiresAt := h.now().Add(h.urlTTL)
+	attachment.DownloadURL = "/api/attachments/" + h.signer.Sign(attachment.ID.String(), expiresAt)
+	attachment.DownloadURLExpiresAt = &expiresAt
+}
+
+// cleanFileName keeps the base name of an uploaded file without control characters
+func cleanFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+		for !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return name
+}
+
+func (h *AttachmentHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AttachmentHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AttachmentHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func setupAttachmentTest(t *testing.T, maxSize int64) (*AttachmentHandler, chi.Router, *signedurl.Signer) {
+	storage, err := attachments.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+
+	signer := signedurl.NewSigner("test-secret", "attachment-download")
+	handler := NewAttachmentHandler(database.NewMock(), storage, signer, maxSize, []string{"image/png", "text/plain"}, time.Minute)
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/resources", handler.UploadClubResource)
+	router.Get("/attachments/{token}", handler.Download)
+	return handler, router, signer
+}
+
+func multipartUpload(t *testing.T, field, fileName string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, fileName)
+	if err != nil {
+		t.Fatalf("CreateFormFile failed: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/club/"+uuid.New().String()+"/resources", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+}
+
+func TestUploadRejectsInvalidFiles(t *testing.T) {
+	_, router, _ := setupAttachmentTest(t, 16)
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{
+		{"too large", multipartUpload(t, "file", "notes.txt", []byte(strings.Repeat("a", 17))), http.StatusRequestEntityTooLarge},
+		{"disallowed type", multipartUpload(t, "file", "page.html", []byte("<html></html>")), http.StatusUnsupportedMediaType},
+		{"empty file", multipartUpload(t, "file", "notes.txt", nil), http.StatusBadRequest},
+		{"missing file field", multipartUpload(t, "document", "notes.txt", []byte("hello")), http.StatusBadRequest},
+	}
+
+	plain := httptest.NewRequest(http.MethodPost, "/club/"+uuid.New().String()+"/resources", strings.NewReader(`{}`))
+	plain.Header.Set("Content-Type", "application/json")
+	plain = plain.WithContext(auth.NewContext(plain.Context(), auth.Principal{UserID: uuid.New()}))
+	tests = append(tests, struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{"not multipart", plain, http.StatusBadRequest})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDownloadRejectsBadTokens(t *testing.T) {
+	_, router, signer := setupAttachmentTest(t, 1024)
+
+	expired := signer.Sign(uuid.New().String(), time.Now().Add(-time.Minute))
+	forged := signedurl.NewSigner("other-secret", "attachment-download").Sign(uuid.New().String(), time.Now().Add(time.Minute))
+	wrongSubject := signer.Sign("not-a-uuid", time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"expired", expired, http.StatusGone},
+		{"forged", forged, http.StatusNotFound},
+		{"malformed", "garbage", http.StatusNotFound},
+		{"invalid subject", wrongSubject, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/attachments/"+tt.token, nil))
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestCleanFileName(t *testing.T) {
+	tests := map[string]string{
+		"map.png":              "map.png",
+		"../../etc/passwd":     "passwd",
+		`C:\Users\me\menu.pdf`: "menu.pdf",
+		"bad\x00name\n.txt":    "badname.txt",
+		"":                     "attachment",
+		"   ":                  "attachment",
+		"dir/":                 "dir",
+	}
+	for input, expected := range tests {
+		if got := cleanFileName(input); got != expected {
+			t.Errorf("cleanFileName(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	long := strings.Repeat("é", 200)
+	if got := cleanFileName(long); len(got) > 255 || !strings.HasPrefix(long, got) {
+		t.Errorf("Expected a valid prefix of at most 255 bytes, got %d bytes", len(got))
+	}
+}
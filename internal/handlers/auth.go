@@ -6,95 +6,273 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
 	"bookwork-api/internal/auth"
+	"bookwork-api/internal/captcha"
 	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/timeutil"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db   *database.DB
-	auth *auth.Service
+	clocked
+
+	db           *database.DB
+	users        store.UserStore
+	auth         *auth.Service
+	minimumAge   int
+	termsVersion string
+
+	// Sandbox accounts are only offered when enabled, and purged after sandboxRetention
+	sandboxEnabled   bool
+	sandboxRetention time.Duration
+
+	// Sign-in with external identity providers; nil when not configured
+	oauth *oauthSettings
+
+	// Checks registrations for bots; nil when registration needs no CAPTCHA
+	captcha captcha.Verifier
 }
 
-func NewAuthHandler(db *database.DB, authService *auth.Service) *AuthHandler {
+func NewAuthHandler(db *database.DB, users store.UserStore, authService *auth.Service) *AuthHandler {
 	return &AuthHandler{
-		db:   db,
-		auth: authService,
+		db:           db,
+		users:        users,
+		auth:         authService,
+		minimumAge:   13,
+		termsVersion: "2024-01-01",
 	}
 }
 
-func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
-	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+// WithRegistrationPolicy sets the minimum age and current terms version enforced at registration
+func (h *AuthHandler) WithRegistrationPolicy(minimumAge int, termsVersion string) *AuthHandler {
+	h.minimumAge = minimumAge
+	h.termsVersion = termsVersion
+	return h
+}
+
+// WithSandbox allows registering throwaway sandbox accounts that expire after retention
+func (h *AuthHandler) WithSandbox(enabled bool, retention time.Duration) *AuthHandler {
+	h.sandboxEnabled = enabled
+	h.sandboxRetention = retention
+	return h
+}
+
+// WithCaptcha requires a passing CAPTCHA response to register
+func (h *AuthHandler) WithCaptcha(verifier captcha.Verifier) *AuthHandler {
+	h.captcha = verifier
+	return h
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Bots are turned away before anything else is checked
+	if err := checkCaptcha(r, h.captcha, req.CaptchaToken); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	// Validate required fields
-	if req.Email == "" || req.Password == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Email and password are required", nil)
+	// Emails are normalised before validation so surrounding spaces are not an error
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if req.Sandbox && !h.sandboxEnabled {
+		h.writeError(w, apierror.New(apierror.CodeSandboxDisabled, "Sandbox accounts are not available on this server", nil))
+		return
+	}
+
+	// Terms of service must be accepted, and for the current version
+	if !req.AcceptTerms {
+		h.writeError(w, apierror.New(apierror.CodeTermsNotAccepted, "You must accept the terms of service", map[string]interface{}{
+			"termsVersion": h.termsVersion,
+		}))
+		return
+	}
+
+	if req.TermsVersion != "" && req.TermsVersion != h.termsVersion {
+		h.writeError(w, apierror.New(apierror.CodeTermsVersionMismatch, "The accepted terms of service are out of date", map[string]interface{}{
+			"termsVersion": h.termsVersion,
+		}))
+		return
+	}
+
+	// Enforce the deployment's minimum age (the format was validated above)
+	dateOfBirth, _ := timeutil.ParseDate(req.DateOfBirth)
+
+	now := h.now().UTC()
+	if dateOfBirth.After(now) {
+		h.writeError(w, apierror.Validation("Date of birth cannot be in the future", models.InvalidField("dateOfBirth", "past", "cannot be in the future")))
+		return
+	}
+
+	if ageOn(dateOfBirth, now) < h.minimumAge {
+		h.writeError(w, apierror.New(apierror.CodeAgeRequirementNotMet, "You do not meet the minimum age requirement", map[string]interface{}{
+			"minimumAge": h.minimumAge,
+		}))
+		return
+	}
+
+	// Reject duplicate accounts
+	var exists int
+	err := h.db.QueryRowContext(r.Context(), `SELECT 1 FROM users WHERE email = $1`, req.Email).Scan(&exists)
+	if err == nil {
+		h.writeError(w, apierror.Conflict("An account with this email already exists"))
+		return
+	}
+	if err != sql.ErrNoRows {
+		logging.FromContext(r.Context()).Error("error checking existing user", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
+		return
+	}
+
+	passwordHash, err := h.auth.HashPassword(req.Password)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error hashing password", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create account"))
+		return
+	}
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: passwordHash,
+		Role:         "member",
+		IsActive:     true,
+		DateOfBirth:  &req.DateOfBirth,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if req.Sandbox {
+		expiresAt := now.Add(h.sandboxRetention)
+		user.IsSandbox = true
+		user.SandboxExpiresAt = &expiresAt
+	}
+
+	if err := h.createUserWithTerms(r.Context(), user, middleware.ClientIP(r), r.UserAgent()); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating user", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create account"))
+		return
+	}
+
+	// Sign the new user in
+	tokens, err := h.auth.GenerateTokens(user)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error generating tokens", "error", err)
+		h.writeError(w, apierror.Internal("Failed to generate tokens"))
+		return
+	}
+
+	if err := h.storeRefreshToken(r.Context(), h.db, user.ID, uuid.New(), tokens); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error storing refresh token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to store refresh token"))
+		return
+	}
+
+	expiresAt := timeutil.FormatTimestamp(h.now().Add(30 * time.Minute))
+
+	response := &models.FrontendLoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         user.PublicUser(),
+		ExpiresAt:    expiresAt,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Registration successful")
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
 	// Get user from database
-	user, err := h.getUserByEmail(r.Context(), req.Email)
+	user, err := h.users.GetByEmail(r.Context(), req.Email)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials", nil)
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Unauthorized("Invalid credentials"))
 			return
 		}
-		log.Printf("Error getting user: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+		logging.FromContext(r.Context()).Error("error getting user", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
 		return
 	}
 
 	// Verify password
 	if !h.auth.VerifyPassword(user.PasswordHash, req.Password) {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid credentials", nil)
+		h.writeError(w, apierror.Unauthorized("Invalid credentials"))
 		return
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Account is deactivated", nil)
+		h.writeError(w, apierror.Unauthorized("Account is deactivated"))
 		return
 	}
 
 	// Generate tokens
 	tokens, err := h.auth.GenerateTokens(user)
 	if err != nil {
-		log.Printf("Error generating tokens: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate tokens", nil)
+		logging.FromContext(r.Context()).Error("error generating tokens", "error", err)
+		h.writeError(w, apierror.Internal("Failed to generate tokens"))
 		return
 	}
 
 	// Store refresh token in database
-	if err := h.storeRefreshToken(r.Context(), user.ID, tokens.RefreshToken); err != nil {
-		log.Printf("Error storing refresh token: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store refresh token", nil)
+	if err := h.storeRefreshToken(r.Context(), h.db, user.ID, uuid.New(), tokens); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error storing refresh token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to store refresh token"))
 		return
 	}
 
 	// Update last login
 	if err := h.updateLastLogin(r.Context(), user.ID); err != nil {
-		log.Printf("Error updating last login: %v", err)
+		logging.FromContext(r.Context()).Error("error updating last login", "error", err)
 		// Don't fail the request for this
 	}
 
 	// Calculate expiration time (30 minutes from now)
-	expiresAt := time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339)
+	expiresAt := timeutil.FormatTimestamp(h.now().Add(30 * time.Minute))
 
 	response := &models.FrontendLoginResponse{
-		Token:     tokens.AccessToken,
-		User:      user.PublicUser(),
-		ExpiresAt: expiresAt,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         user.PublicUser(),
+		ExpiresAt:    expiresAt,
 	}
 
 	h.writeSuccessResponse(w, response, "Login successful")
@@ -103,18 +281,18 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Validate(w http.ResponseWriter, r *http.Request) {
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
-	user, err := h.getUserByID(r.Context(), userID)
+	user, err := h.users.GetByID(r.Context(), userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found", nil)
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Unauthorized("User not found"))
 			return
 		}
-		log.Printf("Error getting user: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+		logging.FromContext(r.Context()).Error("error getting user", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
 		return
 	}
 
@@ -128,95 +306,140 @@ func (h *AuthHandler) Validate(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req models.RefreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
-		return
-	}
-
-	if req.RefreshToken == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Refresh token is required", nil)
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
 	// Validate refresh token
 	claims, err := h.auth.ValidateToken(req.RefreshToken)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid refresh token", nil)
+		h.writeError(w, apierror.Unauthorized("Invalid refresh token"))
 		return
 	}
 
 	if claims.Type != "refresh" {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token type", nil)
+		h.writeError(w, apierror.Unauthorized("Invalid token type"))
+		return
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("Refresh token not found or revoked"))
 		return
 	}
 
 	// Check if refresh token exists and is not revoked
-	exists, err := h.isRefreshTokenValid(r.Context(), claims.UserID, req.RefreshToken)
+	stored, err := h.getRefreshToken(r.Context(), tokenID, claims.UserID, req.RefreshToken)
 	if err != nil {
-		log.Printf("Error checking refresh token: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Unauthorized("Refresh token not found or revoked"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error checking refresh token", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
 		return
 	}
 
-	if !exists {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Refresh token not found or revoked", nil)
+	if stored.revoked {
+		h.rejectReusedRefreshToken(w, r, stored)
 		return
 	}
 
 	// Get user
-	user, err := h.getUserByID(r.Context(), claims.UserID)
+	user, err := h.users.GetByID(r.Context(), claims.UserID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found", nil)
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Unauthorized("User not found"))
 			return
 		}
-		log.Printf("Error getting user: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+		logging.FromContext(r.Context()).Error("error getting user", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
+		return
+	}
+
+	// Rotate: issue a new token pair and replace the presented refresh token with the new one
+	tokens, err := h.auth.GenerateTokens(user)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error generating new access token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to generate new token"))
 		return
 	}
 
-	// Generate new access token
-	newAccessToken, err := h.auth.GenerateTokens(user)
+	rotated, err := h.rotateRefreshToken(r.Context(), stored, tokens)
 	if err != nil {
-		log.Printf("Error generating new access token: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate new token", nil)
+		logging.FromContext(r.Context()).Error("error rotating refresh token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to rotate refresh token"))
+		return
+	}
+
+	if !rotated {
+		// Another request rotated the token first, so it has been presented twice
+		h.rejectReusedRefreshToken(w, r, stored)
 		return
 	}
 
 	// Calculate expiration time (30 minutes from now)
-	expiresAt := time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339)
+	expiresAt := timeutil.FormatTimestamp(h.now().Add(30 * time.Minute))
 
 	response := &models.FrontendRefreshResponse{
-		Token:     newAccessToken.AccessToken,
-		ExpiresAt: expiresAt,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    expiresAt,
 	}
 
 	h.writeSuccessResponse(w, response, "Token refreshed successfully")
 }
 
-func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	var req models.LogoutRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+// rejectReusedRefreshToken revokes the family of a refresh token presented after it was
+// rotated or revoked: either the client or an attacker holds a stolen copy, so every
+// session descended from the same login is ended
+func (h *AuthHandler) rejectReusedRefreshToken(w http.ResponseWriter, r *http.Request, stored *refreshToken) {
+	logger := logging.FromContext(r.Context())
+	logger.Warn("refresh token reuse detected", "user_id", stored.userID.String(), "family_id", stored.familyID.String())
+
+	if err := h.revokeRefreshTokenFamily(r.Context(), stored.familyID); err != nil {
+		logger.Error("error revoking refresh token family", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
 		return
 	}
 
-	if req.RefreshToken == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Refresh token is required", nil)
+	h.writeError(w, apierror.New(apierror.CodeTokenReused, "Refresh token has already been used; please log in again", nil))
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.LogoutRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
 	// Validate and revoke refresh token
 	claims, err := h.auth.ValidateToken(req.RefreshToken)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid refresh token", nil)
+		h.writeError(w, apierror.Unauthorized("Invalid refresh token"))
 		return
 	}
 
-	// Revoke the refresh token
-	if err := h.revokeRefreshToken(r.Context(), claims.UserID, req.RefreshToken); err != nil {
-		log.Printf("Error revoking refresh token: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke token", nil)
+	if claims.Type != "refresh" {
+		h.writeError(w, apierror.Unauthorized("Invalid token type"))
+		return
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("Invalid refresh token"))
+		return
+	}
+
+	// Revoke only the presented refresh token; other sessions stay signed in
+	if err := h.revokeRefreshToken(r.Context(), claims.UserID, tokenID); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error revoking refresh token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to revoke token"))
 		return
 	}
 
@@ -227,99 +450,225 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Logout successful")
 }
 
-// Database helper methods
-func (h *AuthHandler) getUserByEmail(ctx context.Context, email string) (*models.User, error) {
+// ChangePassword replaces the caller's password once they confirm the current
+// one. Every session is signed out, and the caller gets a fresh token pair.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), userID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Unauthorized("User not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting user", "error", err)
+		h.writeError(w, apierror.Internal("Internal server error"))
+		return
+	}
+
+	if !h.auth.VerifyPassword(user.PasswordHash, req.CurrentPassword) {
+		h.writeError(w, apierror.Validation("Current password is incorrect", models.InvalidField("currentPassword", "password", "is incorrect")))
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		h.writeError(w, apierror.Validation("New password must differ from the current one", models.InvalidField("newPassword", "nefield", "must differ from the current password")))
+		return
+	}
+
+	passwordHash, err := h.auth.HashPassword(req.NewPassword)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error hashing password", "error", err)
+		h.writeError(w, apierror.Internal("Failed to change password"))
+		return
+	}
+
+	// The password and the sign-out of every session change together
 	query := `
-		SELECT id, name, email, password_hash, phone, avatar, role, is_active, 
-		       last_login_at, created_at, updated_at 
-		FROM users 
-		WHERE email = $1 AND is_active = true`
-
-	var user models.User
-	err := h.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Name, &user.Email, &user.PasswordHash,
-		&user.Phone, &user.Avatar, &user.Role, &user.IsActive,
-		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
-	)
+		WITH revoked AS (
+			UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW()
+			WHERE user_id = $2 AND is_revoked = false
+		)
+		UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := h.db.ExecContext(r.Context(), query, passwordHash, userID); err != nil {
+		logging.FromContext(r.Context()).Error("error changing password", "error", err)
+		h.writeError(w, apierror.Internal("Failed to change password"))
+		return
+	}
+	audit.Describe(r.Context(), "user", userID.String(), nil)
 
-	return &user, err
+	tokens, err := h.auth.GenerateTokens(user)
+	if err == nil {
+		err = h.storeRefreshToken(r.Context(), h.db, user.ID, uuid.New(), tokens)
+	}
+	if err != nil {
+		// The password has changed; the caller signs in again with it
+		logging.FromContext(r.Context()).Error("error issuing tokens after password change", "error", err)
+		h.writeSuccessResponse(w, map[string]interface{}{}, "Password changed; sign in again")
+		return
+	}
+
+	response := &models.FrontendRefreshResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timeutil.FormatTimestamp(h.now().Add(30 * time.Minute)),
+	}
+
+	h.writeSuccessResponse(w, response, "Password changed successfully; other sessions were signed out")
 }
 
-func (h *AuthHandler) getUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
-	query := `
-		SELECT id, name, email, password_hash, phone, avatar, role, is_active, 
-		       last_login_at, created_at, updated_at 
-		FROM users 
-		WHERE id = $1 AND is_active = true`
-
-	var user models.User
-	err := h.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.ID, &user.Name, &user.Email, &user.PasswordHash,
-		&user.Phone, &user.Avatar, &user.Role, &user.IsActive,
-		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+// Database helper methods
+// createUserWithTerms inserts the user and records the terms acceptance atomically
+func (h *AuthHandler) createUserWithTerms(ctx context.Context, user *models.User, ipAddress, userAgent string) error {
+	tx, err := h.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userQuery := `
+		INSERT INTO users (id, name, email, password_hash, role, is_active, date_of_birth, is_sandbox, sandbox_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = tx.ExecContext(ctx, userQuery,
+		user.ID, user.Name, user.Email, user.PasswordHash, user.Role, user.IsActive, user.DateOfBirth,
+		user.IsSandbox, user.SandboxExpiresAt,
 	)
+	if err != nil {
+		return err
+	}
 
-	return &user, err
+	termsQuery := `
+		INSERT INTO terms_acceptances (user_id, terms_version, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err := tx.ExecContext(ctx, termsQuery, user.ID, h.termsVersion, ipAddress, userAgent); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// refreshToken is a stored refresh token
+type refreshToken struct {
+	tokenID  uuid.UUID
+	userID   uuid.UUID
+	familyID uuid.UUID
+	revoked  bool
 }
 
-func (h *AuthHandler) storeRefreshToken(ctx context.Context, userID uuid.UUID, token string) error {
-	// Hash the token using SHA-256 first to avoid bcrypt 72-byte limit
+// execer is satisfied by both *database.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// hashRefreshToken hashes a token for storage; SHA-256 first avoids the bcrypt 72-byte limit
+func hashRefreshToken(token string) string {
 	sha := sha256.Sum256([]byte(token))
-	tokenHash := hex.EncodeToString(sha[:])
+	return hex.EncodeToString(sha[:])
+}
 
+func (h *AuthHandler) storeRefreshToken(ctx context.Context, db execer, userID, familyID uuid.UUID, tokens *models.TokenResponse) error {
 	// Then bcrypt the SHA-256 hash for secure storage
-	hashedToken, err := bcrypt.GenerateFromPassword([]byte(tokenHash), bcrypt.DefaultCost)
+	hashedToken, err := bcrypt.GenerateFromPassword([]byte(hashRefreshToken(tokens.RefreshToken)), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
 	query := `
-		INSERT INTO refresh_tokens (user_id, token_hash, expires_at) 
-		VALUES ($1, $2, $3)`
+		INSERT INTO refresh_tokens (user_id, token_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`
 
-	_, err = h.db.ExecContext(ctx, query, userID, string(hashedToken), time.Now().Add(7*24*time.Hour))
+	_, err = db.ExecContext(ctx, query, userID, tokens.RefreshTokenID, familyID, string(hashedToken), h.now().Add(auth.RefreshTokenTTL))
 	return err
 }
 
-func (h *AuthHandler) isRefreshTokenValid(ctx context.Context, userID uuid.UUID, token string) (bool, error) {
-	// Hash the token using SHA-256 first to match storage format
-	sha := sha256.Sum256([]byte(token))
-	tokenHash := hex.EncodeToString(sha[:])
-
+// getRefreshToken loads an unexpired refresh token by its token ID, including revoked ones
+// so reuse can be detected. It returns store.ErrNotFound when the token is unknown or
+// does not match the stored hash.
+func (h *AuthHandler) getRefreshToken(ctx context.Context, tokenID, userID uuid.UUID, token string) (*refreshToken, error) {
 	query := `
-		SELECT token_hash 
-		FROM refresh_tokens 
-		WHERE user_id = $1 AND expires_at > NOW() AND is_revoked = false`
+		SELECT token_id, user_id, family_id, token_hash, COALESCE(is_revoked, false)
+		FROM refresh_tokens
+		WHERE token_id = $1 AND user_id = $2 AND expires_at > NOW()`
+
+	var stored refreshToken
+	var hashedToken string
+	err := h.db.QueryRowContext(ctx, query, tokenID, userID).Scan(
+		&stored.tokenID, &stored.userID, &stored.familyID, &hashedToken, &stored.revoked,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	// Compare the SHA-256 hash with the stored bcrypt hash
+	if bcrypt.CompareHashAndPassword([]byte(hashedToken), []byte(hashRefreshToken(token))) != nil {
+		return nil, store.ErrNotFound
+	}
 
-	rows, err := h.db.QueryContext(ctx, query, userID)
+	return &stored, nil
+}
+
+// rotateRefreshToken revokes the presented token and stores its replacement in the same family.
+// It reports false when the token was already revoked by a concurrent request.
+func (h *AuthHandler) rotateRefreshToken(ctx context.Context, stored *refreshToken, tokens *models.TokenResponse) (bool, error) {
+	tx, err := h.db.BeginTx(ctx)
 	if err != nil {
 		return false, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	for rows.Next() {
-		var hashedToken string
-		if err := rows.Scan(&hashedToken); err != nil {
-			continue
-		}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET is_revoked = true, revoked_at = NOW(), replaced_by = $2
+		WHERE token_id = $1 AND is_revoked = false`,
+		stored.tokenID, tokens.RefreshTokenID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
 
-		// Compare the SHA-256 hash with the stored bcrypt hash
-		if bcrypt.CompareHashAndPassword([]byte(hashedToken), []byte(tokenHash)) == nil {
-			return true, nil
-		}
+	if err := h.storeRefreshToken(ctx, tx, stored.userID, stored.familyID, tokens); err != nil {
+		return false, err
 	}
 
-	return false, nil
+	return true, tx.Commit()
 }
 
-func (h *AuthHandler) revokeRefreshToken(ctx context.Context, userID uuid.UUID, token string) error {
-	// First, find the token
+// revokeRefreshToken revokes a single refresh token
+func (h *AuthHandler) revokeRefreshToken(ctx context.Context, userID, tokenID uuid.UUID) error {
 	query := `
-		UPDATE refresh_tokens 
-		SET is_revoked = true 
-		WHERE user_id = $1 AND expires_at > NOW() AND is_revoked = false`
+		UPDATE refresh_tokens
+		SET is_revoked = true, revoked_at = NOW()
+		WHERE token_id = $1 AND user_id = $2 AND is_revoked = false`
 
-	_, err := h.db.ExecContext(ctx, query, userID)
+	_, err := h.db.ExecContext(ctx, query, tokenID, userID)
+	return err
+}
+
+// revokeRefreshTokenFamily revokes every token descended from the same login
+func (h *AuthHandler) revokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET is_revoked = true, revoked_at = NOW()
+		WHERE family_id = $1 AND is_revoked = false`
+
+	_, err := h.db.ExecContext(ctx, query, familyID)
 	return err
 }
 
@@ -330,18 +679,36 @@ func (h *AuthHandler) updateLastLogin(ctx context.Context, userID uuid.UUID) err
 }
 
 // Response helper methods
-func (h *AuthHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+// GetJWKS publishes the public keys access tokens are signed with as a JWK
+// Set, so other services can check tokens without the shared secret. The
+// document is the bare set that JWT libraries expect.
+func (h *AuthHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.auth.KeyRing().JWKS())
+}
 
-	response := models.NewAPIResponse(true, data, message)
-	json.NewEncoder(w).Encode(response)
+func (h *AuthHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
 }
 
-func (h *AuthHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
+func (h *AuthHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
-	response := models.NewErrorResponse(code, message, details)
+	response := models.NewAPIResponse(true, data, message)
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *AuthHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
+
+// ageOn returns the age in whole years of someone born on dateOfBirth at the given time
+func ageOn(dateOfBirth, at time.Time) int {
+	age := at.Year() - dateOfBirth.Year()
+	if at.Month() < dateOfBirth.Month() || (at.Month() == dateOfBirth.Month() && at.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
@@ -3,15 +3,22 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
 	"database/sql"
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"bookwork-api/internal/auth"
 	"bookwork-api/internal/database"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
 
 	"github.com/google/uuid"
 )
@@ -55,8 +62,12 @@ func setupAuthTest(t *testing.T) (*AuthHandler, *auth.Service) {
 	// Create auth service
 	authService := auth.NewService("test-secret-key-that-is-at-least-32-chars", "test-issuer")
 
-	// Create handler
-	handler := NewAuthHandler(db, authService)
+	// Create handler, falling back to in-memory users without a database
+	users := store.NewMemory().Stores().Users
+	if db != nil {
+		users = store.NewPostgres(db).Users
+	}
+	handler := NewAuthHandler(db, users, authService)
 
 	return handler, authService
 }
@@ -261,3 +272,218 @@ func TestRefreshHandlerInvalidToken(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", w.Code)
 	}
 }
+
+func TestRegisterHandlerUnderAge(t *testing.T) {
+	handler, _ := setupAuthTest(t)
+	handler.WithRegistrationPolicy(16, "2024-01-01")
+
+	registerReq := models.RegisterRequest{
+		Name:        "Young Reader",
+		Email:       "young@example.com",
+		Password:    "password123",
+		DateOfBirth: time.Now().AddDate(-15, 0, 0).Format("2006-01-02"),
+		AcceptTerms: true,
+	}
+
+	reqBody, _ := json.Marshal(registerReq)
+	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	handler.Register(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+
+	var response models.FrontendErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Code != "AGE_REQUIREMENT_NOT_MET" {
+		t.Errorf("Expected code AGE_REQUIREMENT_NOT_MET, got %s", response.Code)
+	}
+}
+
+func TestRegisterHandlerTermsNotAccepted(t *testing.T) {
+	handler, _ := setupAuthTest(t)
+
+	registerReq := models.RegisterRequest{
+		Name:        "Reader",
+		Email:       "reader@example.com",
+		Password:    "password123",
+		DateOfBirth: "1990-05-01",
+	}
+
+	reqBody, _ := json.Marshal(registerReq)
+	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	handler.Register(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestRegisterHandlerSandboxDisabled(t *testing.T) {
+	handler, _ := setupAuthTest(t)
+
+	registerReq := models.RegisterRequest{
+		Name:        "Integrator",
+		Email:       "integrator@example.com",
+		Password:    "password123",
+		DateOfBirth: "1990-05-01",
+		AcceptTerms: true,
+		Sandbox:     true,
+	}
+
+	reqBody, _ := json.Marshal(registerReq)
+	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	handler.Register(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestRegisterHandlerCaptchaRejected(t *testing.T) {
+	handler, _ := setupAuthTest(t)
+	handler.WithCaptcha(rejectingCaptcha{})
+
+	registerReq := models.RegisterRequest{
+		Name:         "Bot",
+		Email:        "bot@example.com",
+		Password:     "password123",
+		DateOfBirth:  "1990-05-01",
+		AcceptTerms:  true,
+		CaptchaToken: "forged",
+	}
+
+	reqBody, _ := json.Marshal(registerReq)
+	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	handler.Register(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "CAPTCHA_FAILED") {
+		t.Errorf("Expected CAPTCHA_FAILED, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	dob := time.Date(2010, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at       time.Time
+		expected int
+	}{
+		{time.Date(2023, time.June, 14, 0, 0, 0, 0, time.UTC), 12},
+		{time.Date(2023, time.June, 15, 0, 0, 0, 0, time.UTC), 13},
+		{time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 13},
+	}
+
+	for _, tt := range tests {
+		if age := ageOn(dob, tt.at); age != tt.expected {
+			t.Errorf("Expected age %d on %s, got %d", tt.expected, tt.at.Format("2006-01-02"), age)
+		}
+	}
+}
+
+func setupChangePasswordTest(t *testing.T) (*AuthHandler, uuid.UUID) {
+	authService := auth.NewService("test-secret-key-that-is-at-least-32-chars", "test-issuer")
+	hash, err := authService.HashPassword("old-password")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+
+	mem := store.NewMemory()
+	userID := uuid.New()
+	mem.PutUser(models.User{ID: userID, Name: "Ada", Email: "ada@example.com", PasswordHash: hash, Role: "member", IsActive: true})
+	return NewAuthHandler(database.NewMock(), mem.Stores().Users, authService), userID
+}
+
+func changePasswordRequest(userID uuid.UUID, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/users/me/password", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+}
+
+func TestChangePassword(t *testing.T) {
+	handler, userID := setupChangePasswordTest(t)
+
+	w := httptest.NewRecorder()
+	handler.ChangePassword(w, changePasswordRequest(userID, `{"currentPassword": "old-password", "newPassword": "new-password"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Data models.FrontendRefreshResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.Token == "" || body.Data.RefreshToken == "" {
+		t.Errorf("Expected a fresh token pair, got %+v", body.Data)
+	}
+}
+
+func TestChangePasswordRejectsWrongCurrentPassword(t *testing.T) {
+	handler, userID := setupChangePasswordTest(t)
+
+	w := httptest.NewRecorder()
+	handler.ChangePassword(w, changePasswordRequest(userID, `{"currentPassword": "guess", "newPassword": "new-password"}`))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "currentPassword") {
+		t.Errorf("Expected 400 on currentPassword, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChangePasswordValidation(t *testing.T) {
+	handler, userID := setupChangePasswordTest(t)
+
+	for _, body := range []string{
+		`{"currentPassword": "old-password", "newPassword": "short"}`,
+		`{"currentPassword": "old-password", "newPassword": "old-password"}`,
+		`{"newPassword": "new-password"}`,
+	} {
+		w := httptest.NewRecorder()
+		handler.ChangePassword(w, changePasswordRequest(userID, body))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(private)
+	key, err := auth.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKey failed: %v", err)
+	}
+	ring, _ := auth.NewKeyRing(key, auth.SecretKey("test-secret-key-that-is-at-least-32-chars"))
+	authService := auth.NewService("test-secret-key-that-is-at-least-32-chars", "test-issuer").WithKeyRing(ring)
+	handler := NewAuthHandler(nil, store.NewMemory().Stores().Users, authService)
+
+	rr := httptest.NewRecorder()
+	handler.GetJWKS(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	var set auth.JWKSet
+	if err := json.Unmarshal(rr.Body.Bytes(), &set); err != nil {
+		t.Fatalf("failed to decode JWK Set: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].KeyID != key.ID || set.Keys[0].Algorithm != "EdDSA" {
+		t.Fatalf("expected only the signing key, got %s", rr.Body.String())
+	}
+}
@@ -1,127 +1,127 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"bookwork-api/internal/apierror"
 	"bookwork-api/internal/auth"
-	"bookwork-api/internal/database"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/changes"
+	"bookwork-api/internal/dues"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/webhooks"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type AvailabilityHandler struct {
-	db *database.DB
+	clocked
+
+	stores   *store.Stores
+	dues     duesStanding
+	webhooks webhookQueue
+	changes  changeRecorder // the club's change log, for its streams
 }
 
-func NewAvailabilityHandler(db *database.DB) *AvailabilityHandler {
-	return &AvailabilityHandler{db: db}
+// duesStanding reports a member's dues status; *dues.Ledger implements it
+type duesStanding interface {
+	Status(ctx context.Context, clubID, userID uuid.UUID, at time.Time) (*dues.MemberStatus, dues.Settings, error)
 }
 
-func (h *AvailabilityHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
-	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
-		return
-	}
+func NewAvailabilityHandler(stores *store.Stores) *AvailabilityHandler {
+	return &AvailabilityHandler{stores: stores}
+}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
-		return
-	}
+// WithDues rejects RSVPs from members with unpaid dues in clubs that require them
+func (h *AvailabilityHandler) WithDues(ledger duesStanding) *AvailabilityHandler {
+	h.dues = ledger
+	return h
+}
 
-	// Check if user can access this event
-	if !h.canAccessEvent(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Access denied", nil)
-		return
-	}
+// WithWebhooks sends availability changes to the club's webhooks
+func (h *AvailabilityHandler) WithWebhooks(webhooks webhookQueue) *AvailabilityHandler {
+	h.webhooks = webhooks
+	return h
+}
 
-	query := `
-		SELECT user_id, status, notes, updated_at
-		FROM availability
-		WHERE event_id = $1
-		ORDER BY updated_at DESC`
+// WithChanges records availability changes in the club's change log
+func (h *AvailabilityHandler) WithChanges(changes changeRecorder) *AvailabilityHandler {
+	h.changes = changes
+	return h
+}
 
-	rows, err := h.db.QueryContext(r.Context(), query, eventID)
+func (h *AvailabilityHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		log.Printf("Error querying availability: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get availability", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
-	defer rows.Close()
-
-	availability := make(map[string]*models.Availability)
-	summary := &models.AvailabilitySummary{}
-
-	for rows.Next() {
-		var avail models.Availability
-		avail.EventID = eventID
-
-		err := rows.Scan(&avail.UserID, &avail.Status, &avail.Notes, &avail.UpdatedAt)
-		if err != nil {
-			log.Printf("Error scanning availability: %v", err)
-			continue
-		}
 
-		availability[avail.UserID.String()] = &avail
-
-		// Update summary
-		switch avail.Status {
-		case "available":
-			summary.Available++
-		case "maybe":
-			summary.Maybe++
-		case "unavailable":
-			summary.Unavailable++
-		}
-		summary.Total++
+	responses, err := h.stores.Availability.ListByEvent(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying availability", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get availability"))
+		return
 	}
 
 	// Transform availability to frontend format
 	frontendAvailability := make(map[string]*models.FrontendAvailability)
-	for userID, avail := range availability {
-		frontendAvailability[userID] = avail.ToFrontendFormat()
+	updated := make([]time.Time, 0, len(responses))
+	for i := range responses {
+		frontendAvailability[responses[i].UserID.String()] = responses[i].ToFrontendFormat()
+		updated = append(updated, responses[i].UpdatedAt)
 	}
+	middleware.SetLastModified(w, updated...)
 
 	// Return the availability map directly as expected by frontend
 	h.writeSuccessResponse(w, frontendAvailability, "Availability retrieved successfully")
 }
 
-func (h *AvailabilityHandler) UpdateAvailability(w http.ResponseWriter, r *http.Request) {
+// GetAvailabilitySummary returns the event's response counts per status without loading every response
+func (h *AvailabilityHandler) GetAvailabilitySummary(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	summary, err := h.stores.Availability.Summary(r.Context(), eventID)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		logging.FromContext(r.Context()).Error("error querying availability summary", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get availability summary"))
 		return
 	}
 
-	// Check if user can access this event
-	if !h.canAccessEvent(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Access denied", nil)
+	h.writeSuccessResponse(w, summary, "Availability summary retrieved successfully")
+}
+
+func (h *AvailabilityHandler) UpdateAvailability(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
-	var req models.AvailabilityRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
-	// Validate status
-	validStatuses := []string{"available", "maybe", "unavailable"}
-	if !h.contains(validStatuses, req.Status) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid status. Must be 'available', 'maybe', or 'unavailable'", nil)
+	var req models.AvailabilityRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
@@ -133,33 +133,39 @@ func (h *AvailabilityHandler) UpdateAvailability(w http.ResponseWriter, r *http.
 
 	// Check if user can update availability for the specified user
 	if requestUserID != userID {
-		// Only owners and moderators can update availability for other users
-		if !h.canManageEvent(r.Context(), eventID, userID) {
-			h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Cannot update availability for other users", nil)
+		// Only members who manage events can update availability for other users
+		if !authz.Can(r.Context(), authz.ManageEvents) {
+			h.writeError(w, apierror.Forbidden("Cannot update availability for other users"))
 			return
 		}
 	}
 
-	// Upsert availability
-	query := `
-		INSERT INTO availability (id, event_id, user_id, status, notes, updated_at)
-		VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW())
-		ON CONFLICT (event_id, user_id)
-		DO UPDATE SET status = $3, notes = $4, updated_at = NOW()`
-
-	_, err = h.db.ExecContext(r.Context(), query, eventID, requestUserID, req.Status, req.Notes)
-	if err != nil {
-		log.Printf("Error updating availability: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update availability", nil)
+	// Clubs may require paid dues to say yes or maybe; declining is always allowed
+	if req.Status != "unavailable" && !h.duesPaid(w, r, requestUserID) {
 		return
 	}
 
+	// Upsert availability
 	availability := &models.Availability{
 		EventID:   eventID,
 		UserID:    requestUserID,
 		Status:    req.Status,
 		Notes:     req.Notes,
-		UpdatedAt: time.Now(),
+		UpdatedAt: h.now(),
+	}
+
+	if err := h.stores.Availability.Upsert(r.Context(), availability); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating availability", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update availability"))
+		return
+	}
+	h.publishAvailability(r.Context(), availability)
+	if event, ok := authz.EventFromContext(r.Context()); ok {
+		recordChange(r.Context(), h.changes, event.ClubID, changes.AvailabilityUpdated, eventID, requestUserID)
 	}
 
 	response := map[string]interface{}{
@@ -169,40 +175,106 @@ func (h *AvailabilityHandler) UpdateAvailability(w http.ResponseWriter, r *http.
 	h.writeSuccessResponse(w, response, "Availability updated successfully")
 }
 
-// Helper methods
-func (h *AvailabilityHandler) canAccessEvent(ctx context.Context, eventID, userID uuid.UUID) bool {
-	query := `
-		SELECT 1 FROM events e
-		JOIN club_members cm ON e.club_id = cm.club_id
-		WHERE e.id = $1 AND cm.user_id = $2 AND cm.is_active = true`
-
-	var exists int
-	err := h.db.QueryRowContext(ctx, query, eventID, userID).Scan(&exists)
-	return err == nil
+// ExportPDF renders the event's availability as a printable roster for in-person sign-in
+func (h *AvailabilityHandler) ExportPDF(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	// Only organizers print sign-in sheets
+	if !authz.Can(r.Context(), authz.ManageEvents) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	event, err := h.stores.Events.GetByID(r.Context(), eventID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to export availability"))
+		return
+	}
+
+	sheet := reports.AvailabilitySheet{
+		EventTitle:  event.Title,
+		EventDate:   event.Date,
+		EventTime:   event.Time,
+		Location:    event.Location,
+		GeneratedAt: h.now(),
+	}
+
+	// Every active member appears on the sheet, with or without a response
+	roster, err := h.stores.Availability.Roster(r.Context(), eventID, event.ClubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying availability roster", "error", err)
+		h.writeError(w, apierror.Internal("Failed to export availability"))
+		return
+	}
+
+	for _, entry := range roster {
+		sheet.Rows = append(sheet.Rows, reports.AvailabilityRow{
+			Name:   entry.Name,
+			Status: entry.Status,
+			Notes:  entry.Notes,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := reports.WriteAvailabilityPDF(&buf, sheet); err != nil {
+		logging.FromContext(r.Context()).Error("error rendering availability PDF", "error", err)
+		h.writeError(w, apierror.Internal("Failed to export availability"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="availability-%s.pdf"`, eventID))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
 }
 
-func (h *AvailabilityHandler) canManageEvent(ctx context.Context, eventID, userID uuid.UUID) bool {
-	query := `
-		SELECT cm.role FROM events e
-		JOIN club_members cm ON e.club_id = cm.club_id
-		WHERE e.id = $1 AND cm.user_id = $2 AND cm.is_active = true`
+// Helper methods
+// duesPaid reports whether the member may RSVP under the event's club dues
+// policy, writing the error response when they may not
+func (h *AvailabilityHandler) duesPaid(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok || h.dues == nil {
+		return true
+	}
 
-	var role string
-	err := h.db.QueryRowContext(ctx, query, eventID, userID).Scan(&role)
+	status, settings, err := h.dues.Status(r.Context(), event.ClubID, userID, h.now())
 	if err != nil {
+		logging.FromContext(r.Context()).Error("error checking dues status", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update availability"))
 		return false
 	}
+	if status == nil || !settings.RequiredForRSVP || status.Status == dues.StatusPaid {
+		return true
+	}
 
-	return role == "owner" || role == "moderator"
+	h.writeError(w, apierror.New(apierror.CodeDuesUnpaid, "Membership dues for the current period must be paid to RSVP", map[string]interface{}{
+		"periodStart": status.PeriodStart,
+		"due":         status.Due,
+		"paid":        status.Paid,
+	}))
+	return false
 }
 
-func (h *AvailabilityHandler) contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+// publishAvailability sends a saved availability to the club's webhooks. The
+// availability is already saved, so a failure is logged rather than returned.
+func (h *AvailabilityHandler) publishAvailability(ctx context.Context, availability *models.Availability) {
+	event, ok := authz.EventFromContext(ctx)
+	if !ok || h.webhooks == nil {
+		return
+	}
+	if err := h.webhooks.Publish(ctx, event.ClubID, webhooks.AvailabilityUpdated, availability); err != nil {
+		logging.FromContext(ctx).Error("error queueing availability webhooks", "error", err)
 	}
-	return false
 }
 
 func (h *AvailabilityHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
@@ -212,17 +284,6 @@ func (h *AvailabilityHandler) writeSuccessResponse(w http.ResponseWriter, data i
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *AvailabilityHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := &models.FrontendErrorResponse{
-		Error:      code,
-		Message:    message,
-		StatusCode: statusCode,
-		Details:    details,
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-	}
-
-	json.NewEncoder(w).Encode(response)
+func (h *AvailabilityHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
 }
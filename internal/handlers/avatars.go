@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/avatars"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// avatarStore is the part of avatars.Store the avatar handler uses
+type avatarStore interface {
+	Set(ctx context.Context, userID uuid.UUID, version, url string) (string, error)
+	Clear(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+// avatarCacheControl lets browsers and CDNs keep avatars forever; a new
+// upload gets a new URL
+const avatarCacheControl = "public, max-age=31536000, immutable"
+
+// AvatarHandler takes profile picture uploads and serves the resized versions
+type AvatarHandler struct {
+	clocked
+
+	storage attachments.Storage
+	avatars avatarStore
+	baseURL string // where avatar paths are served from, e.g. a CDN
+	maxSize int64
+}
+
+// NewAvatarHandler serves avatars below baseURL, which defaults to this API's
+// /api prefix
+func NewAvatarHandler(storage attachments.Storage, avatarStore avatarStore, baseURL string, maxSize int64) *AvatarHandler {
+	if baseURL == "" {
+		baseURL = "/api"
+	}
+	return &AvatarHandler{storage: storage, avatars: avatarStore, baseURL: strings.TrimSuffix(baseURL, "/"), maxSize: maxSize}
+}
+
+// UploadAvatar makes the uploaded image the current user's avatar. It is
+// stored as a square JPEG at each of avatars.Sizes, without its metadata.
+func (h *AvatarHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	_, content, uploadErr := readMultipartFile(w, r, h.maxSize)
+	if uploadErr != nil {
+		h.writeError(w, uploadErr)
+		return
+	}
+
+	contentType, err := attachments.DetectType(content, avatars.AllowedTypes)
+	if err != nil {
+		h.writeError(w, apierror.New(apierror.CodeUnsupportedMediaType, "This file type is not allowed", map[string]interface{}{
+			"detectedType": contentType,
+			"allowedTypes": avatars.AllowedTypes,
+		}))
+		return
+	}
+
+	images, err := avatars.Process(content)
+	if err != nil {
+		switch {
+		case errors.Is(err, avatars.ErrUndecodable):
+			h.writeError(w, apierror.Validation("Image cannot be read", models.InvalidField("file", "image", "must be a valid JPEG, PNG or GIF image")))
+		case errors.Is(err, avatars.ErrTooLarge):
+			h.writeError(w, apierror.Validation("Image dimensions are too large", models.InvalidField("file", "dimensions", "must be at most 16 megapixels")))
+		default:
+			logging.FromContext(r.Context()).Error("error processing avatar", "error", err)
+			h.writeError(w, apierror.Internal("Failed to upload avatar"))
+		}
+		return
+	}
+
+	version := avatars.Version(content)
+	for _, size := range avatars.Sizes {
+		if err := h.storage.Put(r.Context(), avatars.Key(userID, version, size), images[size], "image/jpeg"); err != nil {
+			logging.FromContext(r.Context()).Error("error storing avatar", "error", err)
+			// Do not leave unreferenced files behind
+			h.removeVersion(context.WithoutCancel(r.Context()), userID, version)
+			h.writeError(w, apierror.Internal("Failed to upload avatar"))
+			return
+		}
+	}
+
+	url := h.url(userID, version, avatars.DefaultSize)
+	previous, err := h.avatars.Set(r.Context(), userID, version, url)
+	if err != nil {
+		h.removeVersion(context.WithoutCancel(r.Context()), userID, version)
+		if errors.Is(err, avatars.ErrUserNotFound) {
+			h.writeError(w, apierror.NotFound("User not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error setting avatar", "error", err)
+		h.writeError(w, apierror.Internal("Failed to upload avatar"))
+		return
+	}
+	// Uploading the same image again keeps its files
+	if previous != "" && previous != version {
+		h.removeVersion(context.WithoutCancel(r.Context()), userID, previous)
+	}
+	audit.Describe(r.Context(), "user", userID.String(), audit.Changes{"avatar": {To: url}})
+
+	h.writeResponse(w, http.StatusCreated, h.response(userID, version), "Avatar uploaded successfully")
+}
+
+// DeleteAvatar removes the current user's avatar, uploaded or linked
+func (h *AvatarHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	previous, err := h.avatars.Clear(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, avatars.ErrUserNotFound) {
+			h.writeError(w, apierror.NotFound("User not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error clearing avatar", "error", err)
+		h.writeError(w, apierror.Internal("Failed to remove avatar"))
+		return
+	}
+	if previous != "" {
+		h.removeVersion(context.WithoutCancel(r.Context()), userID, previous)
+	}
+	audit.Describe(r.Context(), "user", userID.String(), nil)
+
+	h.writeSuccessResponse(w, nil, "Avatar removed successfully")
+}
+
+// ServeAvatar serves one size of an avatar version. Paths are public like
+// any profile picture link, and never change, so they are cached forever.
+func (h *AvatarHandler) ServeAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	version := chi.URLParam(r, "version")
+	sizeText, isJPEG := strings.CutSuffix(chi.URLParam(r, "file"), ".jpg")
+	size, sizeErr := strconv.Atoi(sizeText)
+	if err != nil || !avatars.ValidVersion(version) || !isJPEG || sizeErr != nil || !avatars.ValidSize(size) {
+		h.writeError(w, apierror.NotFound("Avatar not found"))
+		return
+	}
+
+	body, err := h.storage.Get(r.Context(), avatars.Key(userID, version, size))
+	if err != nil {
+		if errors.Is(err, attachments.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Avatar not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reading avatar from storage", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get avatar"))
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", avatarCacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, body); err != nil {
+		logging.FromContext(r.Context()).Error("error streaming avatar", "error", err)
+	}
+}
+
+// removeVersion deletes the files of an avatar version; failures only leave
+// unreferenced files behind, so they are logged
+func (h *AvatarHandler) removeVersion(ctx context.Context, userID uuid.UUID, version string) {
+	for _, size := range avatars.Sizes {
+		err := h.storage.Delete(ctx, avatars.Key(userID, version, size))
+		if err != nil && !errors.Is(err, attachments.ErrNotFound) {
+			logging.FromContext(ctx).Error("error removing avatar", "error", err, "version", version)
+		}
+	}
+}
+
+func (h *AvatarHandler) url(userID uuid.UUID, version string, size int) string {
+	return h.baseURL + avatars.Path(userID, version, size)
+}
+
+// response lists the URL of an avatar version and of each of its sizes
+func (h *AvatarHandler) response(userID uuid.UUID, version string) map[string]interface{} {
+	sizes := make(map[string]string, len(avatars.Sizes))
+	for _, size := range avatars.Sizes {
+		sizes[strconv.Itoa(size)] = h.url(userID, version, size)
+	}
+	return map[string]interface{}{
+		"avatar": h.url(userID, version, avatars.DefaultSize),
+		"sizes":  sizes,
+	}
+}
+
+func (h *AvatarHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AvatarHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AvatarHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
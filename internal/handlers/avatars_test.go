@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/avatars"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeAvatarStore keeps each user's avatar version in memory
+type fakeAvatarStore struct {
+	versions map[uuid.UUID]string
+	urls     map[uuid.UUID]string
+}
+
+func (s *fakeAvatarStore) Set(ctx context.Context, userID uuid.UUID, version, url string) (string, error) {
+	previous := s.versions[userID]
+	s.versions[userID], s.urls[userID] = version, url
+	return previous, nil
+}
+
+func (s *fakeAvatarStore) Clear(ctx context.Context, userID uuid.UUID) (string, error) {
+	previous := s.versions[userID]
+	delete(s.versions, userID)
+	delete(s.urls, userID)
+	return previous, nil
+}
+
+func setupAvatarTest(t *testing.T, baseURL string) (attachments.Storage, *fakeAvatarStore, chi.Router) {
+	storage, err := attachments.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	store := &fakeAvatarStore{versions: map[uuid.UUID]string{}, urls: map[uuid.UUID]string{}}
+	handler := NewAvatarHandler(storage, store, baseURL, 1<<20)
+
+	router := chi.NewRouter()
+	router.Post("/users/me/avatar", handler.UploadAvatar)
+	router.Delete("/users/me/avatar", handler.DeleteAvatar)
+	router.Get("/avatars/{userId}/{version}/{file}", handler.ServeAvatar)
+	return storage, store, router
+}
+
+func avatarUpload(t *testing.T, userID uuid.UUID, content []byte) *http.Request {
+	req := multipartUpload(t, "file", "me.png", content)
+	req.URL.Path = "/users/me/avatar"
+	return req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadAvatarStoresEverySize(t *testing.T) {
+	storage, store, router := setupAvatarTest(t, "https://cdn.example.com/")
+	userID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, avatarUpload(t, userID, testPNG(t, 40, 30)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			Avatar string            `json:"avatar"`
+			Sizes  map[string]string `json:"sizes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	version := store.versions[userID]
+	want := "https://cdn.example.com" + avatars.Path(userID, version, avatars.DefaultSize)
+	if response.Data.Avatar != want || store.urls[userID] != want {
+		t.Errorf("Expected avatar %q, got %q (stored %q)", want, response.Data.Avatar, store.urls[userID])
+	}
+	if len(response.Data.Sizes) != len(avatars.Sizes) {
+		t.Errorf("Expected %d sizes, got %v", len(avatars.Sizes), response.Data.Sizes)
+	}
+	for _, size := range avatars.Sizes {
+		body, err := storage.Get(context.Background(), avatars.Key(userID, version, size))
+		if err != nil {
+			t.Fatalf("size %d not stored: %v", size, err)
+		}
+		body.Close()
+	}
+
+	// A new upload replaces the old version's files
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, avatarUpload(t, userID, testPNG(t, 20, 20)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := storage.Get(context.Background(), avatars.Key(userID, version, 64)); err != attachments.ErrNotFound {
+		t.Errorf("Expected the replaced version to be removed, got %v", err)
+	}
+}
+
+func TestUploadAvatarRejectsInvalidImages(t *testing.T) {
+	_, _, router := setupAvatarTest(t, "")
+
+	tests := []struct {
+		name    string
+		content []byte
+		status  int
+	}{
+		{"not an image", []byte("just some text"), http.StatusUnsupportedMediaType},
+		{"truncated image", testPNG(t, 10, 10)[:40], http.StatusBadRequest},
+		{"empty", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, avatarUpload(t, uuid.New(), tt.content))
+			if w.Code != tt.status {
+				t.Errorf("Expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeAvatar(t *testing.T) {
+	_, store, router := setupAvatarTest(t, "")
+	userID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, avatarUpload(t, userID, testPNG(t, 8, 8)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if url := store.urls[userID]; url != "/api"+avatars.Path(userID, store.versions[userID], avatars.DefaultSize) {
+		t.Errorf("Expected the avatar to be served by the API, got %q", url)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, avatars.Path(userID, store.versions[userID], 128), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "image/jpeg" || w.Header().Get("Cache-Control") != avatarCacheControl {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+	if img, err := jpeg.Decode(w.Body); err != nil || img.Bounds().Dx() != 128 {
+		t.Errorf("Expected a 128px JPEG, got %v", err)
+	}
+
+	for _, path := range []string{
+		avatars.Path(userID, store.versions[userID], 100),
+		avatars.Path(uuid.New(), store.versions[userID], 128),
+		"/avatars/" + userID.String() + "/not-a-version/128.jpg",
+		"/avatars/" + userID.String() + "/" + store.versions[userID] + "/128.png",
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestDeleteAvatarRemovesFiles(t *testing.T) {
+	storage, store, router := setupAvatarTest(t, "")
+	userID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, avatarUpload(t, userID, testPNG(t, 8, 8)))
+	version := store.versions[userID]
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/me/avatar", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := store.versions[userID]; ok {
+		t.Error("Expected the avatar to be cleared")
+	}
+	if _, err := storage.Get(context.Background(), avatars.Key(userID, version, avatars.DefaultSize)); err != attachments.ErrNotFound {
+		t.Errorf("Expected the files to be removed, got %v", err)
+	}
+}
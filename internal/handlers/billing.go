@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/billing"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+)
+
+// BillingHandler receives Stripe webhooks and hands each paid checkout to the
+// recorder registered for the purpose named in its metadata
+type BillingHandler struct {
+	clocked
+
+	webhookSecret string
+	recorders     map[string]checkoutRecorder
+}
+
+// checkoutRecorder records a paid Stripe Checkout session; *dues.Ledger implements it
+type checkoutRecorder interface {
+	RecordCheckout(ctx context.Context, session billing.CheckoutSession) error
+}
+
+// maxWebhookBytes bounds webhook bodies; Stripe events are a few kilobytes
+const maxWebhookBytes = 64 << 10
+
+func NewBillingHandler(webhookSecret string) *BillingHandler {
+	return &BillingHandler{webhookSecret: webhookSecret, recorders: map[string]checkoutRecorder{}}
+}
+
+// WithRecorder records paid checkouts whose metadata purpose is purpose
+func (h *BillingHandler) WithRecorder(purpose string, recorder checkoutRecorder) *BillingHandler {
+	h.recorders[purpose] = recorder
+	return h
+}
+
+// StripeWebhook verifies and records a Stripe event. Events the API does not
+// use are acknowledged so Stripe stops retrying them.
+func (h *BillingHandler) StripeWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.webhookSecret == "" {
+		h.writeError(w, apierror.NotFound("Stripe is not configured"))
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		h.writeError(w, apierror.New(apierror.CodeRequestTooLarge, "Webhook body is too large", nil))
+		return
+	}
+
+	if err := billing.VerifySignature(payload, r.Header.Get("Stripe-Signature"), h.webhookSecret, h.now()); err != nil {
+		logging.FromContext(r.Context()).Warn("rejected Stripe webhook", "error", err)
+		h.writeError(w, apierror.New(apierror.CodeInvalidSignature, "Invalid Stripe signature", nil))
+		return
+	}
+
+	var event billing.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		h.writeError(w, apierror.Validation("Malformed event", models.InvalidField("", "json", "Event must be valid JSON")))
+		return
+	}
+
+	session, paid, err := billing.PaidCheckout(event)
+	if err != nil {
+		h.writeError(w, apierror.Validation("Malformed checkout session", models.InvalidField("", "checkout_session", "Event does not contain a valid checkout session")))
+		return
+	}
+	if !paid {
+		h.writeSuccessResponse(w, map[string]interface{}{"received": true}, "Event ignored")
+		return
+	}
+
+	recorder, ok := h.recorders[session.Metadata[billing.Purpose]]
+	if !ok {
+		h.writeSuccessResponse(w, map[string]interface{}{"received": true}, "Event ignored")
+		return
+	}
+
+	// A redelivered event is already recorded. Other failures, including bad
+	// metadata, return 500 so they are retried and show as failed deliveries in Stripe.
+	if err := recorder.RecordCheckout(r.Context(), session); err != nil && !errors.Is(err, billing.ErrAlreadyRecorded) {
+		logging.FromContext(r.Context()).Error("error recording Stripe checkout", "error", err, "event", event.ID, "session", session.ID)
+		h.writeError(w, apierror.Internal("Failed to record payment"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"received": true}, "Payment recorded")
+}
+
+func (h *BillingHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BillingHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/captcha"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
+	"bookwork-api/internal/models"
+)
+
+// checkCaptcha verifies the CAPTCHA response sent with a public form. It passes
+// when verifier is nil, i.e. the endpoint does not require a CAPTCHA.
+func checkCaptcha(r *http.Request, verifier captcha.Verifier, response string) *apierror.Error {
+	if verifier == nil {
+		return nil
+	}
+
+	err := verifier.Verify(r.Context(), response, middleware.ClientIP(r))
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, captcha.ErrMissing) || errors.Is(err, captcha.ErrFailed) {
+		return apierror.New(apierror.CodeCaptchaFailed, "CAPTCHA verification failed", models.InvalidField("captchaToken", "captcha", "must be a valid CAPTCHA response"))
+	}
+
+	logging.FromContext(r.Context()).Error("error verifying CAPTCHA", "error", err)
+	return apierror.New(apierror.CodeCaptchaUnavailable, "CAPTCHA verification is unavailable; please try again later", nil)
+}
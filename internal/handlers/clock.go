@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"time"
+
+	"bookwork-api/internal/clock"
+)
+
+// clocked gives a handler a clock that tests can stop. The zero value uses
+// the system clock.
+type clocked struct {
+	clock clock.Clock
+}
+
+func (c clocked) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
@@ -2,44 +2,276 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
 	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/captcha"
 	"bookwork-api/internal/database"
+	"bookwork-api/internal/holidays"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/pagination"
+	"bookwork-api/internal/policy"
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/tags"
+	"bookwork-api/internal/timeutil"
+	"bookwork-api/internal/webhooks"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+// maxClubTags bounds how many tags a club can have
+const maxClubTags = 10
+
 type ClubHandler struct {
-	db *database.DB
+	clocked
+
+	db       *database.DB
+	notifier *notify.Notifier
+	captcha  captcha.Verifier // checks the public contact form; nil skips the check
+	removals store.RemovalStore
+	roles    roleCache // nil when member roles are not cached
+	webhooks webhookQueue
+}
+
+// roleCache is a cache of member roles that must forget the roles it holds
+// once they change
+type roleCache interface {
+	Forget(clubID, userID uuid.UUID)
+	ForgetClub(clubID uuid.UUID)
 }
 
 func NewClubHandler(db *database.DB) *ClubHandler {
 	return &ClubHandler{db: db}
 }
 
+// WithNotifier prompts club owners to raise the member limit when their club fills up
+func (h *ClubHandler) WithNotifier(notifier *notify.Notifier) *ClubHandler {
+	h.notifier = notifier
+	return h
+}
+
+// WithRemovals removes members through the store, which can preview removals
+func (h *ClubHandler) WithRemovals(removals store.RemovalStore) *ClubHandler {
+	h.removals = removals
+	return h
+}
+
+// WithRoleCache keeps roles cached for authorization in step with the
+// membership changes made here
+func (h *ClubHandler) WithRoleCache(roles roleCache) *ClubHandler {
+	h.roles = roles
+	return h
+}
+
+// WithWebhooks sends new members to the club's webhooks
+func (h *ClubHandler) WithWebhooks(webhooks webhookQueue) *ClubHandler {
+	h.webhooks = webhooks
+	return h
+}
+
+// clubSortColumns maps the accepted sort options to their ORDER BY clauses
+var clubSortColumns = map[string]string{
+	"name":    "c.name ASC",
+	"newest":  "c.created_at DESC",
+	"oldest":  "c.created_at ASC",
+	"members": "member_count DESC, c.name ASC",
+}
+
+// ListClubs searches clubs the caller can discover: all public clubs plus private clubs they belong to
+func (h *ClubHandler) ListClubs(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	withHTML, derr := htmlFormatParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	// Parse query parameters
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "name"
+	}
+	orderBy, ok := clubSortColumns[sort]
+	if !ok {
+		h.writeError(w, apierror.Validation("Invalid sort option. Use name, newest, oldest, or members", models.InvalidField("sort", "oneof", "must be one of: name, newest, oldest, members")))
+		return
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	location := strings.TrimSpace(r.URL.Query().Get("location"))
+	tagsParam := r.URL.Query().Get("tags")
+	publicParam := r.URL.Query().Get("is_public")
+	verifiedParam := r.URL.Query().Get("verified")
+
+	offset := (page - 1) * limit
+
+	// Build filter clause shared by the list and count queries
+	// Sandbox accounts only see sandbox clubs, and real accounts only real clubs
+	where := ` WHERE (c.is_public = true OR EXISTS (
+			SELECT 1 FROM club_members m WHERE m.club_id = c.id AND m.user_id = $1 AND m.is_active = true))
+		AND COALESCE(c.is_sandbox, false) = $2 AND c.deleted_at IS NULL`
+	args := []interface{}{userID, auth.IsSandboxFromContext(r.Context())}
+	argCount := 2
+
+	if search != "" {
+		argCount++
+		where += ` AND (c.name ILIKE $` + strconv.Itoa(argCount) + ` OR c.description ILIKE $` + strconv.Itoa(argCount) + `)`
+		args = append(args, "%"+escapeLike(search)+"%")
+	}
+
+	if location != "" {
+		argCount++
+		where += ` AND c.location ILIKE $` + strconv.Itoa(argCount)
+		args = append(args, "%"+escapeLike(location)+"%")
+	}
+
+	if tagsParam != "" {
+		// Aliases find the clubs tagged with their canonical tag
+		if filter := tags.NormalizeAll(strings.Split(tagsParam, ",")); len(filter) > 0 {
+			argCount++
+			where += ` AND c.tags && canonical_tags($` + strconv.Itoa(argCount) + `)`
+			args = append(args, models.StringArray(filter))
+		}
+	}
+
+	if publicParam != "" {
+		isPublic, err := strconv.ParseBool(publicParam)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid is_public value", models.InvalidField("is_public", "boolean", "must be true or false")))
+			return
+		}
+		argCount++
+		where += ` AND c.is_public = $` + strconv.Itoa(argCount)
+		args = append(args, isPublic)
+	}
+
+	if verifiedParam != "" {
+		verified, err := strconv.ParseBool(verifiedParam)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid verified value", models.InvalidField("verified", "boolean", "must be true or false")))
+			return
+		}
+		if verified {
+			where += ` AND c.verification_type IS NOT NULL`
+		} else {
+			where += ` AND c.verification_type IS NULL`
+		}
+	}
+
+	query := `
+		SELECT c.id, c.name, COALESCE(c.description, ''), c.owner_id, c.is_public, c.max_members,
+		       c.meeting_frequency, c.current_book, c.tags, c.location, c.created_at, c.updated_at,
+		       COALESCE(c.is_sandbox, false), c.sandbox_expires_at, c.verification_type,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true) AS member_count
+		FROM clubs c` + where +
+		` ORDER BY ` + orderBy + ` LIMIT $` + strconv.Itoa(argCount+1) + ` OFFSET $` + strconv.Itoa(argCount+2)
+
+	rows, err := h.db.ReadQueryContext(r.Context(), query, append(args, limit, offset)...)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying clubs", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get clubs"))
+		return
+	}
+	defer rows.Close()
+
+	clubs := []models.Club{}
+	for rows.Next() {
+		var club models.Club
+		var ownerID *uuid.UUID
+
+		err := rows.Scan(
+			&club.ID, &club.Name, &club.Description, &ownerID, &club.IsPublic, &club.MaxMembers,
+			&club.MeetingFrequency, &club.CurrentBook, &club.Tags, &club.Location,
+			&club.CreatedAt, &club.UpdatedAt, &club.IsSandbox, &club.SandboxExpiresAt, &club.Verification, &club.MemberCount,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning club", "error", err)
+			continue
+		}
+		if ownerID != nil {
+			club.OwnerID = *ownerID
+		}
+		if withHTML {
+			club.DescriptionHTML = renderDescription(&club.Description)
+		}
+
+		clubs = append(clubs, club)
+	}
+
+	// Get total count
+	var total int
+	h.db.QueryRowContext(r.Context(), `SELECT COUNT(*) FROM clubs c`+where, args...).Scan(&total)
+
+	totalPages := (total + limit - 1) / limit
+
+	response := map[string]interface{}{
+		"clubs": clubs,
+		"pagination": models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.writeSuccessResponse(w, response, "Clubs retrieved successfully")
+}
+
 func (h *ClubHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
 	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	includeStats, ok := parseMemberIncludes(r.URL.Query().Get("include"))
+	if !ok {
+		h.writeError(w, apierror.Validation("include must be stats", models.InvalidField("include", "oneof", "must be stats")))
+		return
+	}
+
+	// Youth clubs restrict the member directory to members who manage members
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get members"))
 		return
 	}
 
-	// Check if user is a member of the club
-	if !h.isClubMember(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "You are not a member of this club", nil)
+	if !clubPolicy.CanViewMemberDirectory(authz.Can(r.Context(), authz.ManageMembers)) {
+		h.writeError(w, apierror.Forbidden("The member directory is not available in this club"))
 		return
 	}
 
@@ -61,10 +293,12 @@ func (h *ClubHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
 
 	// Build query
 	query := `
-		SELECT cm.id, cm.club_id, cm.user_id, cm.role, cm.joined_date, cm.books_read, cm.is_active,
-		       u.id, u.name, u.email, u.phone, u.avatar
+		SELECT cm.id, cm.club_id, cm.user_id, cm.role, cm.joined_date, cm.books_read, cm.is_active, cm.version,
+		       u.id, u.name, u.email, u.phone, u.avatar, es.reason, es.suppressed_at,
+		       GREATEST(cm.joined_date, u.updated_at, es.suppressed_at)
 		FROM club_members cm
 		JOIN users u ON cm.user_id = u.id
+		LEFT JOIN email_suppressions es ON es.email = LOWER(u.email)
 		WHERE cm.club_id = $1`
 
 	args := []interface{}{clubID}
@@ -83,64 +317,136 @@ func (h *ClubHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
 		args = append(args, active)
 	}
 
-	query += ` ORDER BY cm.joined_date DESC LIMIT $` + strconv.Itoa(argCount+1) + ` OFFSET $` + strconv.Itoa(argCount+2)
-	args = append(args, limit, offset)
+	// With ?cursor= pages continue after the last member of the previous page instead of skipping rows
+	useCursor := pagination.CursorRequested(r)
+	if cursor := r.URL.Query().Get("cursor"); useCursor && cursor != "" {
+		joinedDate, memberID, err := parseMemberCursor(cursor)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid cursor", models.InvalidField("cursor", "cursor", "must be a nextCursor from a previous page")))
+			return
+		}
+		query += ` AND (cm.joined_date, cm.id) < ($` + strconv.Itoa(argCount+1) + `, $` + strconv.Itoa(argCount+2) + `)`
+		args = append(args, joinedDate, memberID)
+		argCount += 2
+	}
+
+	query += ` ORDER BY cm.joined_date DESC, cm.id DESC LIMIT $` + strconv.Itoa(argCount+1)
+	if useCursor {
+		// One extra row tells whether there is a next page
+		args = append(args, limit+1)
+	} else {
+		query += ` OFFSET $` + strconv.Itoa(argCount+2)
+		args = append(args, limit, offset)
+	}
 
-	rows, err := h.db.QueryContext(r.Context(), query, args...)
+	rows, err := h.db.ReadQueryContext(r.Context(), query, args...)
 	if err != nil {
-		log.Printf("Error querying members: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get members", nil)
+		logging.FromContext(r.Context()).Error("error querying members", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get members"))
 		return
 	}
 	defer rows.Close()
 
 	var members []models.ClubMember
+	var updated []time.Time
 	for rows.Next() {
 		var member models.ClubMember
 		var user models.User
+		var updatedAt time.Time
+		var suppressedReason *string
+		var suppressedAt *time.Time
 
 		err := rows.Scan(
 			&member.ID, &member.ClubID, &member.UserID, &member.Role,
-			&member.JoinedDate, &member.BooksRead, &member.IsActive,
+			&member.JoinedDate, &member.BooksRead, &member.IsActive, &member.Version,
 			&user.ID, &user.Name, &user.Email, &user.Phone, &user.Avatar,
+			&suppressedReason, &suppressedAt, &updatedAt,
 		)
 		if err != nil {
-			log.Printf("Error scanning member: %v", err)
+			logging.FromContext(r.Context()).Error("error scanning member", "error", err)
 			continue
 		}
+		if suppressedReason != nil && suppressedAt != nil {
+			user.EmailUndeliverable = &models.EmailUndeliverable{Reason: *suppressedReason, Since: timeutil.FormatTimestamp(*suppressedAt)}
+		}
 
 		member.User = &user
 		members = append(members, member)
+		updated = append(updated, updatedAt)
 	}
 
-	// Get total count
-	countQuery := `SELECT COUNT(*) FROM club_members WHERE club_id = $1`
-	countArgs := []interface{}{clubID}
+	var pageInfo interface{}
+	if useCursor {
+		var next *string
+		if len(members) > limit {
+			members = members[:limit]
+			updated = updated[:limit]
+			last := members[limit-1]
+			cursor := pagination.EncodeCursor(last.JoinedDate.UTC().Format(time.RFC3339Nano), last.ID.String())
+			next = &cursor
+		}
+		pageInfo = models.CursorPagination{Limit: limit, NextCursor: next, HasMore: next != nil}
+	} else {
+		// Get total count
+		countQuery := `SELECT COUNT(*) FROM club_members WHERE club_id = $1`
+		countArgs := []interface{}{clubID}
+
+		if role != "" {
+			countQuery += ` AND role = $2`
+			countArgs = append(countArgs, role)
+		}
 
-	if role != "" {
-		countQuery += ` AND role = $2`
-		countArgs = append(countArgs, role)
-	}
+		var total int
+		h.db.QueryRowContext(r.Context(), countQuery, countArgs...).Scan(&total)
 
-	var total int
-	h.db.QueryRowContext(r.Context(), countQuery, countArgs...).Scan(&total)
+		pageInfo = models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: (total + limit - 1) / limit,
+		}
+	}
 
-	totalPages := (total + limit - 1) / limit
+	// Statistics for the whole page come from one query rather than one per member
+	var stats map[uuid.UUID]*models.MemberStats
+	if includeStats {
+		userIDs := make([]uuid.UUID, len(members))
+		for i, member := range members {
+			userIDs[i] = member.UserID
+		}
+		if stats, err = h.memberStats(r.Context(), clubID, userIDs); err != nil {
+			logging.FromContext(r.Context()).Error("error querying member stats", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get members"))
+			return
+		}
+	}
 
-	// Transform members to frontend format
+	// Transform members to frontend format; whether a member's email bounces
+	// is for the managers who follow up with them
+	isManager := authz.Can(r.Context(), authz.ManageMembers)
 	var frontendMembers []*models.FrontendClubMember
 	for _, member := range members {
-		frontendMembers = append(frontendMembers, member.ToFrontendFormat())
+		frontendMember := member.ToFrontendFormat()
+		if !isManager {
+			frontendMember.EmailUndeliverable = nil
+		}
+		if includeStats {
+			frontendMember.Stats = stats[member.UserID]
+			if frontendMember.Stats == nil {
+				frontendMember.Stats = &models.MemberStats{BooksRead: member.BooksRead, BooksTrend: booksTrend(0, 0)}
+			}
+		}
+		frontendMembers = append(frontendMembers, frontendMember)
 	}
 
 	response := map[string]interface{}{
-		"members": frontendMembers,
-		"pagination": models.Pagination{
-			Page:       page,
-			Limit:      limit,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+		"members":    frontendMembers,
+		"pagination": pageInfo,
+	}
+	// Statistics change without the members changing, so the member times
+	// would understate when the response last changed
+	if !includeStats {
+		middleware.SetLastModified(w, updated...)
 	}
 
 	h.writeSuccessResponse(w, response, "Members retrieved successfully")
@@ -149,59 +455,64 @@ func (h *ClubHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
 func (h *ClubHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+	var req models.AddMemberRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	// Check if user has permission to add members (owner or moderator)
-	if !h.canManageMembers(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	// Check if user already is a member
+	if h.isClubMember(r.Context(), clubID, req.UserID) {
+		h.writeError(w, apierror.Conflict("User is already a member"))
 		return
 	}
 
-	var req models.AddMemberRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	if !h.sameSandbox(r.Context(), clubID, req.UserID) {
+		h.writeError(w, apierror.New(apierror.CodeSandboxMismatch, "Sandbox accounts and clubs cannot be mixed with real ones", nil))
 		return
 	}
 
-	// Check if user already is a member
-	if h.isClubMember(r.Context(), clubID, req.UserID) {
-		h.writeErrorResponse(w, http.StatusConflict, "CONFLICT", "User is already a member", nil)
+	// Enforce the club's safety policy on the new member
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to add member"))
 		return
 	}
 
-	// Add member
-	memberID := uuid.New()
-	query := `
-		INSERT INTO club_members (id, club_id, user_id, role) 
-		VALUES ($1, $2, $3, $4)`
+	if err := clubPolicy.CheckNewMember(h.getGuardianEmail(r.Context(), req.UserID)); err != nil {
+		h.writeError(w, apierror.Validation("A guardian email must be on file to join a youth club", models.InvalidField("", "guardian_email", "A guardian email must be on file to join a youth club")))
+		return
+	}
 
-	_, err = h.db.ExecContext(r.Context(), query, memberID, clubID, req.UserID, req.Role)
-	if err != nil {
-		log.Printf("Error adding member: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to add member", nil)
+	if err := h.checkMemberRole(r.Context(), clubID, req.Role); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	member := &models.ClubMember{
-		ID:         memberID,
-		ClubID:     clubID,
-		UserID:     req.UserID,
-		Role:       req.Role,
-		JoinedDate: time.Now(),
-		BooksRead:  0,
-		IsActive:   true,
+	// Add member
+	member, capacity, err := h.addMemberWithinCapacity(r.Context(), clubID, req.UserID, req.Role)
+	if err != nil {
+		if err == errClubFull {
+			h.writeClubFull(w, capacity, false)
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error adding member", "error", err)
+		h.writeError(w, apierror.Internal("Failed to add member"))
+		return
 	}
 
 	response := map[string]interface{}{
-		"member": member,
+		"member":   member,
+		"capacity": capacity,
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -211,31 +522,101 @@ func (h *ClubHandler) AddMember(w http.ResponseWriter, r *http.Request) {
 func (h *ClubHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
 	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid member ID", nil)
+		h.writeError(w, apierror.Validation("Invalid member ID", models.InvalidID("memberId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	ifVersion, derr := ifMatchVersion(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	var req models.UpdateMemberRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.updateMember(w, r, clubID, memberID, req, ifVersion)
+}
+
+// memberPatch holds the fields of a membership that PatchMember can change,
+// as a merge patch document
+type memberPatch struct {
+	Role     string `json:"role" validate:"required,max=20"`
+	IsActive *bool  `json:"isActive" validate:"required"`
+}
+
+// PatchMember changes a membership with a JSON merge patch. Unlike
+// UpdateMember it refuses unknown fields up front.
+func (h *ClubHandler) PatchMember(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Validation("Invalid member ID", models.InvalidID("memberId")))
+		return
+	}
+
+	ifVersion, derr := ifMatchVersion(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	member, err := h.getMember(r.Context(), clubID, memberID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Member not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting member", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update member"))
+		return
+	}
+	if versionMismatch(ifVersion, member.Version) {
+		h.writeVersionConflict(w, member)
 		return
 	}
 
-	// Check permissions
-	if !h.canManageMembers(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	patch := memberPatch{Role: member.Role, IsActive: &member.IsActive}
+	fields, derr := decodeMergePatch(w, r, &patch)
+	if derr == nil {
+		derr = validateRequest(&patch)
+	}
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
 	var req models.UpdateMemberRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	for _, field := range fields {
+		switch field {
+		case "role":
+			req.Role = &patch.Role
+		case "isActive":
+			req.IsActive = patch.IsActive
+		}
+	}
+	h.updateMember(w, r, clubID, memberID, req, ifVersion)
+}
+
+// updateMember writes the fields set in req and answers with the changes.
+// With ifVersion, the member must still be at that version.
+func (h *ClubHandler) updateMember(w http.ResponseWriter, r *http.Request, clubID, memberID uuid.UUID, req models.UpdateMemberRequest, ifVersion *int) {
+	if err := h.checkMemberChange(r.Context(), clubID, memberID, req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
@@ -257,28 +638,54 @@ func (h *ClubHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(setParts) == 0 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "No fields to update", nil)
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
 		return
 	}
 
-	argCount++
-	args = append(args, memberID)
-
-	query := `UPDATE club_members SET ` + join(setParts, ", ") + ` WHERE id = $` + strconv.Itoa(argCount)
+	// Scope the update to the club the caller was authorized for
+	args = append(args, memberID, clubID)
 
-	_, err = h.db.ExecContext(r.Context(), query, args...)
+	query := `UPDATE club_members SET ` + join(append(setParts, "version = version + 1"), ", ") +
+		` WHERE id = $` + strconv.Itoa(argCount+1) + ` AND club_id = $` + strconv.Itoa(argCount+2)
+	if ifVersion != nil {
+		args = append(args, *ifVersion)
+		query += ` AND version = $` + strconv.Itoa(argCount+3)
+	}
+	query += ` RETURNING version`
+
+	var version int
+	err := h.db.QueryRowContext(r.Context(), query, args...).Scan(&version)
+	if err == sql.ErrNoRows {
+		// Missing, or changed by someone else since the client read it
+		current, err := h.getMember(r.Context(), clubID, memberID)
+		if err != nil || ifVersion == nil {
+			h.writeError(w, apierror.NotFound("Member not found"))
+			return
+		}
+		h.writeVersionConflict(w, current)
+		return
+	}
 	if err != nil {
-		log.Printf("Error updating member: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update member", nil)
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating member", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update member"))
 		return
 	}
 
+	h.forgetClubRoles(clubID)
+	audit.Describe(r.Context(), "club_member", memberID.String(), audit.Diff(nil, req))
+
 	response := map[string]interface{}{
 		"member": map[string]interface{}{
 			"id":        memberID,
-			"updatedAt": time.Now(),
+			"version":   version,
+			"updatedAt": h.now(),
 		},
 	}
+	w.Header().Set("ETag", versionETag(version))
 
 	if req.Role != nil {
 		response["member"].(map[string]interface{})["role"] = *req.Role
@@ -290,44 +697,67 @@ func (h *ClubHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Member updated successfully")
 }
 
-func (h *ClubHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
-	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+// getMember loads one membership of the club, without its user
+func (h *ClubHandler) getMember(ctx context.Context, clubID, memberID uuid.UUID) (*models.ClubMember, error) {
+	var member models.ClubMember
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, club_id, user_id, role, joined_date, books_read, is_active, version
+		FROM club_members WHERE id = $1 AND club_id = $2`, memberID, clubID,
+	).Scan(&member.ID, &member.ClubID, &member.UserID, &member.Role,
+		&member.JoinedDate, &member.BooksRead, &member.IsActive, &member.Version)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
-		return
+		return nil, err
 	}
+	return &member, nil
+}
 
-	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
+// writeVersionConflict refuses an edit of a membership that changed since the
+// client read it, answering with the membership as it is now
+func (h *ClubHandler) writeVersionConflict(w http.ResponseWriter, current *models.ClubMember) {
+	derr := versionConflict(current, current.Version)
+	w.Header().Set("ETag", versionETag(current.Version))
+	h.writeError(w, derr)
+}
+
+func (h *ClubHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid member ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	memberID, err := uuid.Parse(chi.URLParam(r, "memberId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Validation("Invalid member ID", models.InvalidID("memberId")))
 		return
 	}
 
-	// Check permissions
-	if !h.canManageMembers(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	dryRun, derr := dryRunParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	query := `DELETE FROM club_members WHERE id = $1 AND club_id = $2`
-	result, err := h.db.ExecContext(r.Context(), query, memberID, clubID)
+	removal, err := h.removals.RemoveMember(r.Context(), clubID, memberID, dryRun)
 	if err != nil {
-		log.Printf("Error removing member: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to remove member", nil)
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Member not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error removing member", "error", err)
+		h.writeError(w, apierror.Internal("Failed to remove member"))
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Member not found", nil)
+	if dryRun {
+		h.writeSuccessResponse(w, removal, "Member would be removed")
 		return
 	}
+	h.forgetClubRoles(clubID)
+	audit.Describe(r.Context(), "club_member", memberID.String(), nil)
 
 	response := map[string]string{
 		"message": "Member removed successfully",
@@ -336,44 +766,1151 @@ func (h *ClubHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Member removed successfully")
 }
 
-// Helper methods
-func (h *ClubHandler) isClubMember(ctx context.Context, clubID, userID uuid.UUID) bool {
-	query := `SELECT 1 FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
-	var exists int
-	err := h.db.QueryRowContext(ctx, query, clubID, userID).Scan(&exists)
-	return err == nil
-}
+// JoinClub lets a user join a public club directly, or request to join a private club
+func (h *ClubHandler) JoinClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
 
-func (h *ClubHandler) canManageMembers(ctx context.Context, clubID, userID uuid.UUID) bool {
-	query := `SELECT role FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
-	var role string
-	err := h.db.QueryRowContext(ctx, query, clubID, userID).Scan(&role)
+	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		return false
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
 	}
-	return role == "owner" || role == "moderator"
-}
 
-func (h *ClubHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
-	w.Header().Set("Content-Type", "application/json")
+	// The request body is optional
+	var req models.JoinClubRequest
+	if err := decodeOptionalJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
 
-	response := models.NewAPIResponse(true, data, message)
-	json.NewEncoder(w).Encode(response)
-}
+	var isPublic, isSandbox bool
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT COALESCE(is_public, false), COALESCE(is_sandbox, false) FROM clubs WHERE id = $1 AND deleted_at IS NULL`, clubID,
+	).Scan(&isPublic, &isSandbox)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to join club"))
+		return
+	}
 
-func (h *ClubHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	if isSandbox != auth.IsSandboxFromContext(r.Context()) {
+		h.writeError(w, apierror.New(apierror.CodeSandboxMismatch, "Sandbox accounts and clubs cannot be mixed with real ones", nil))
+		return
+	}
+
+	// Existing memberships, including ones deactivated by moderators
+	var isActive bool
+	err = h.db.QueryRowContext(r.Context(), `SELECT is_active FROM club_members WHERE club_id = $1 AND user_id = $2`, clubID, userID).Scan(&isActive)
+	if err == nil {
+		if isActive {
+			h.writeError(w, apierror.Conflict("You are already a member of this club"))
+		} else {
+			h.writeError(w, apierror.Forbidden("Your membership in this club has been deactivated"))
+		}
+		return
+	}
+	if err != sql.ErrNoRows {
+		logging.FromContext(r.Context()).Error("error checking membership", "error", err)
+		h.writeError(w, apierror.Internal("Failed to join club"))
+		return
+	}
 
-	response := &models.FrontendErrorResponse{
-		Error:      code,
-		Message:    message,
-		StatusCode: statusCode,
-		Details:    details,
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+	// Enforce the club's safety policy on the new member
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to join club"))
+		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	if err := clubPolicy.CheckNewMember(h.getGuardianEmail(r.Context(), userID)); err != nil {
+		h.writeError(w, apierror.Validation("A guardian email must be on file to join a youth club", models.InvalidField("", "guardian_email", "A guardian email must be on file to join a youth club")))
+		return
+	}
+
+	// Pending requests and waitlist entries are both open requests
+	var openStatus string
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT status FROM club_join_requests WHERE club_id = $1 AND user_id = $2 AND status IN ('pending', 'waitlisted')`,
+		clubID, userID).Scan(&openStatus)
+	if err == nil {
+		if openStatus == "waitlisted" {
+			h.writeError(w, apierror.Conflict("You are already on the waitlist for this club"))
+		} else {
+			h.writeError(w, apierror.Conflict("A join request is already pending"))
+		}
+		return
+	}
+
+	// Private clubs go through the approval queue
+	if !isPublic {
+		joinRequest, err := h.createJoinRequest(r.Context(), clubID, userID, "pending", req.Message)
+		if err != nil {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error creating join request", "error", err)
+			h.writeError(w, apierror.Internal("Failed to request membership"))
+			return
+		}
+
+		response := map[string]interface{}{
+			"joinRequest": joinRequest,
+		}
+
+		h.writeResponse(w, http.StatusAccepted, response, "Join request submitted for approval")
+		return
+	}
+
+	member, capacity, err := h.addMemberWithinCapacity(r.Context(), clubID, userID, "member")
+	if err != nil {
+		if err != errClubFull {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error joining club", "error", err)
+			h.writeError(w, apierror.Internal("Failed to join club"))
+			return
+		}
+		if !req.Waitlist {
+			h.writeClubFull(w, capacity, true)
+			return
+		}
+
+		// Full club: queue the caller until a manager approves them once there is room
+		joinRequest, err := h.createJoinRequest(r.Context(), clubID, userID, "waitlisted", req.Message)
+		if err != nil {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error joining waitlist", "error", err)
+			h.writeError(w, apierror.Internal("Failed to join waitlist"))
+			return
+		}
+
+		response := map[string]interface{}{
+			"joinRequest": joinRequest,
+			"capacity":    capacity,
+		}
+
+		h.writeResponse(w, http.StatusAccepted, response, "Club is full; added to the waitlist")
+		return
+	}
+
+	response := map[string]interface{}{
+		"member":   member,
+		"capacity": capacity,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Joined club successfully")
+}
+
+// LeaveClub removes the caller's membership, or cancels their pending join request
+func (h *ClubHandler) LeaveClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// The owner has to transfer the club before leaving it
+	var ownerID *uuid.UUID
+	err = h.db.QueryRowContext(r.Context(), `SELECT owner_id FROM clubs WHERE id = $1`, clubID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to leave club"))
+		return
+	}
+
+	if ownerID != nil && *ownerID == userID {
+		h.writeError(w, apierror.Conflict("The club owner cannot leave the club"))
+		return
+	}
+
+	result, err := h.db.ExecContext(r.Context(), `DELETE FROM club_members WHERE club_id = $1 AND user_id = $2`, clubID, userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error leaving club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to leave club"))
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		h.forgetRole(clubID, userID)
+		h.writeSuccessResponse(w, map[string]string{"message": "Left club successfully"}, "Left club successfully")
+		return
+	}
+
+	// Not a member: withdraw a pending join request or waitlist entry instead
+	query := `
+		UPDATE club_join_requests SET status = 'cancelled', decided_at = NOW()
+		WHERE club_id = $1 AND user_id = $2 AND status IN ('pending', 'waitlisted')`
+
+	result, err = h.db.ExecContext(r.Context(), query, clubID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error cancelling join request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to leave club"))
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("You are not a member of this club"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Join request cancelled"}, "Join request cancelled")
+}
+
+// DeleteClub soft-deletes a club. Members, events and dues are kept so a global
+// admin can restore it; until then the club is hidden everywhere. Only the owner
+// (or a global admin) may delete a club.
+func (h *ClubHandler) DeleteClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var ownerID *uuid.UUID
+	err = h.db.QueryRowContext(r.Context(), `SELECT owner_id FROM clubs WHERE id = $1 AND deleted_at IS NULL`, clubID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete club"))
+		return
+	}
+
+	if !authz.IsAdmin(r.Context()) && (ownerID == nil || *ownerID != userID) {
+		h.writeError(w, apierror.Forbidden("Only the club owner can delete the club"))
+		return
+	}
+
+	query := `UPDATE clubs SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`
+	result, err := h.db.ExecContext(r.Context(), query, clubID, userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete club"))
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("Club not found"))
+		return
+	}
+	h.forgetClubRoles(clubID)
+	audit.Describe(r.Context(), "club", clubID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Club deleted successfully"}, "Club deleted successfully")
+}
+
+// GetPublicClub returns the public page of a club for anonymous visitors.
+// Private and sandbox clubs are reported as not found.
+func (h *ClubHandler) GetPublicClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	withHTML, derr := htmlFormatParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	query := `
+		SELECT c.id, c.name, COALESCE(c.description, ''), c.meeting_frequency, c.current_book,
+		       c.tags, c.location, c.verification_type, c.created_at,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true)
+		FROM clubs c
+		WHERE c.id = $1 AND c.is_public = true AND COALESCE(c.is_sandbox, false) = false AND c.deleted_at IS NULL`
+
+	var club models.PublicClub
+	err = h.db.QueryRowContext(r.Context(), query, clubID).Scan(
+		&club.ID, &club.Name, &club.Description, &club.MeetingFrequency, &club.CurrentBook,
+		&club.Tags, &club.Location, &club.Verification, &club.CreatedAt, &club.MemberCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting public club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get club"))
+		return
+	}
+
+	if withHTML {
+		club.DescriptionHTML = renderDescription(&club.Description)
+	}
+
+	similar, err := h.similarClubs(r.Context(), clubID)
+	if err != nil {
+		// Recommendations are extra; the page still works without them
+		logging.FromContext(r.Context()).Error("error getting similar clubs", "error", err)
+		similar = []models.SimilarClub{}
+	}
+
+	response := map[string]interface{}{
+		"club":         club,
+		"similarClubs": similar,
+	}
+
+	h.writeSuccessResponse(w, response, "Club retrieved successfully")
+}
+
+// similarClubs returns the precomputed recommendations for a club that are
+// still public, best first
+func (h *ClubHandler) similarClubs(ctx context.Context, clubID uuid.UUID) ([]models.SimilarClub, error) {
+	query := `
+		SELECT c.id, c.name, COALESCE(c.description, ''), c.meeting_frequency, c.current_book,
+		       c.tags, c.location, c.verification_type, c.created_at,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true),
+		       r.score, r.tag_score, r.book_score, r.location_score
+		FROM club_recommendations r
+		JOIN clubs c ON c.id = r.similar_club_id
+		WHERE r.club_id = $1 AND c.is_public = true AND COALESCE(c.is_sandbox, false) = false AND c.deleted_at IS NULL
+		ORDER BY r.rank`
+
+	rows, err := h.db.QueryContext(ctx, query, clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	similar := []models.SimilarClub{}
+	for rows.Next() {
+		var club models.SimilarClub
+		err := rows.Scan(
+			&club.ID, &club.Name, &club.Description, &club.MeetingFrequency, &club.CurrentBook,
+			&club.Tags, &club.Location, &club.Verification, &club.CreatedAt, &club.MemberCount,
+			&club.Score, &club.TagScore, &club.BookScore, &club.LocationScore,
+		)
+		if err != nil {
+			return nil, err
+		}
+		similar = append(similar, club)
+	}
+	return similar, rows.Err()
+}
+
+// CreateSandboxClub creates a throwaway club for a sandbox account. The club expires
+// together with the account, and the caller is its owner and a moderator.
+func (h *ClubHandler) CreateSandboxClub(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !auth.IsSandboxFromContext(r.Context()) {
+		h.writeError(w, apierror.Forbidden("Only sandbox accounts can create sandbox clubs"))
+		return
+	}
+
+	var req models.CreateSandboxClubRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var expiresAt *time.Time
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT sandbox_expires_at FROM users WHERE id = $1 AND is_sandbox = true`, userID,
+	).Scan(&expiresAt)
+	if err != nil || expiresAt == nil {
+		if err != nil && err != sql.ErrNoRows {
+			logging.FromContext(r.Context()).Error("error getting sandbox account", "error", err)
+		}
+		h.writeError(w, apierror.Forbidden("Only sandbox accounts can create sandbox clubs"))
+		return
+	}
+
+	now := h.now()
+	club := &models.Club{
+		ID:               uuid.New(),
+		Name:             req.Name,
+		Description:      strings.TrimSpace(req.Description),
+		OwnerID:          userID,
+		MemberCount:      1,
+		IsPublic:         req.IsPublic,
+		Tags:             models.StringArray{},
+		IsSandbox:        true,
+		SandboxExpiresAt: expiresAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	// The club and its owner's membership are written together or not at all
+	err = h.db.WithTx(r.Context(), func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(r.Context(), `
+			INSERT INTO clubs (id, name, description, owner_id, is_public, is_sandbox, sandbox_expires_at)
+			VALUES ($1, $2, $3, $4, $5, true, $6)`,
+			club.ID, club.Name, club.Description, club.OwnerID, club.IsPublic, club.SandboxExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert club: %w", err)
+		}
+
+		// Club member roles do not include owner; ownership is clubs.owner_id
+		_, err = tx.ExecContext(r.Context(),
+			`INSERT INTO club_members (club_id, user_id, role) VALUES ($1, $2, 'moderator')`,
+			club.ID, userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add owner: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating sandbox club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create sandbox club"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"club": club,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Sandbox club created successfully")
+}
+
+// GetJoinRequests lists the club's join requests for owners and moderators
+func (h *ClubHandler) GetJoinRequests(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	query := `
+		SELECT jr.id, jr.club_id, jr.user_id, jr.status, jr.message, jr.decided_by, jr.decided_at, jr.created_at,
+		       u.id, u.name, u.email, u.avatar
+		FROM club_join_requests jr
+		JOIN users u ON jr.user_id = u.id
+		WHERE jr.club_id = $1 AND jr.status = $2
+		ORDER BY jr.created_at ASC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, clubID, status)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying join requests", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get join requests"))
+		return
+	}
+	defer rows.Close()
+
+	joinRequests := []models.ClubJoinRequest{}
+	for rows.Next() {
+		var jr models.ClubJoinRequest
+		var user models.User
+
+		err := rows.Scan(
+			&jr.ID, &jr.ClubID, &jr.UserID, &jr.Status, &jr.Message, &jr.DecidedBy, &jr.DecidedAt, &jr.CreatedAt,
+			&user.ID, &user.Name, &user.Email, &user.Avatar,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning join request", "error", err)
+			continue
+		}
+
+		jr.User = &user
+		joinRequests = append(joinRequests, jr)
+	}
+
+	response := map[string]interface{}{
+		"joinRequests": joinRequests,
+	}
+
+	h.writeSuccessResponse(w, response, "Join requests retrieved successfully")
+}
+
+func (h *ClubHandler) ApproveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideJoinRequest(w, r, true)
+}
+
+func (h *ClubHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideJoinRequest(w, r, false)
+}
+
+func (h *ClubHandler) decideJoinRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid request ID", models.InvalidID("requestId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var requesterID uuid.UUID
+	query := `SELECT user_id FROM club_join_requests WHERE id = $1 AND club_id = $2 AND status IN ('pending', 'waitlisted')`
+	if err := h.db.QueryRowContext(r.Context(), query, requestID, clubID).Scan(&requesterID); err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Pending join request not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting join request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to process join request"))
+		return
+	}
+
+	status := "rejected"
+	var member *models.ClubMember
+	if approve {
+		status = "approved"
+		var capacity models.ClubCapacity
+		member, capacity, err = h.addMemberWithinCapacity(r.Context(), clubID, requesterID, "member")
+		if err != nil {
+			if err == errClubFull {
+				h.writeClubFull(w, capacity, false)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error approving join request", "error", err)
+			h.writeError(w, apierror.Internal("Failed to process join request"))
+			return
+		}
+	}
+
+	updateQuery := `
+		UPDATE club_join_requests SET status = $1, decided_by = $2, decided_at = NOW()
+		WHERE id = $3 AND status IN ('pending', 'waitlisted')`
+
+	if _, err := h.db.ExecContext(r.Context(), updateQuery, status, userID, requestID); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating join request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to process join request"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"joinRequest": map[string]interface{}{
+			"id":     requestID,
+			"status": status,
+		},
+	}
+	if member != nil {
+		response["member"] = member
+	}
+
+	h.writeSuccessResponse(w, response, "Join request "+status)
+}
+
+func (h *ClubHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get club settings"))
+		return
+	}
+
+	capacity, err := h.getClubCapacity(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting club capacity", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get club settings"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"settings": clubPolicy,
+		"theme":    h.getClubTheme(r.Context(), clubID),
+		"region":   h.getClubRegion(r.Context(), clubID),
+		"tags":     h.getClubTags(r.Context(), clubID),
+		"capacity": capacity,
+	}
+
+	h.writeSuccessResponse(w, response, "Club settings retrieved successfully")
+}
+
+func (h *ClubHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	var req models.UpdateClubSettingsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Build update query
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 0
+
+	if req.YouthMode != nil {
+		argCount++
+		setParts = append(setParts, "youth_mode = $"+strconv.Itoa(argCount))
+		args = append(args, *req.YouthMode)
+	}
+
+	if req.BrandColor != nil {
+		if !reports.IsHexColor(*req.BrandColor) {
+			h.writeError(w, apierror.Validation("Invalid brand color. Use #RRGGBB", models.InvalidField("brandColor", "hexcolor", "must be in the format #RRGGBB")))
+			return
+		}
+		argCount++
+		setParts = append(setParts, "brand_color = $"+strconv.Itoa(argCount))
+		args = append(args, *req.BrandColor)
+	}
+
+	if req.Country != nil {
+		// An empty country clears the setting
+		var country interface{}
+		if *req.Country != "" {
+			code := strings.ToUpper(*req.Country)
+			if !isCountryCode(code) {
+				h.writeError(w, apierror.Validation("Invalid country. Use an ISO 3166-1 alpha-2 code such as US", models.InvalidField("country", "iso3166_1_alpha2", "must be an ISO 3166-1 alpha-2 code such as US")))
+				return
+			}
+			country = code
+		}
+		argCount++
+		setParts = append(setParts, "country = $"+strconv.Itoa(argCount))
+		args = append(args, country)
+	}
+
+	if req.MaxMembers != nil {
+		// Zero removes the limit. A limit below the current member count keeps
+		// existing members but admits nobody new until the club drops below it.
+		if *req.MaxMembers < 0 {
+			h.writeError(w, apierror.Validation("maxMembers must be zero (no limit) or positive", models.InvalidField("maxMembers", "min", "must be at least 0")))
+			return
+		}
+		var maxMembers interface{}
+		if *req.MaxMembers > 0 {
+			maxMembers = *req.MaxMembers
+		}
+		argCount++
+		setParts = append(setParts, "max_members = $"+strconv.Itoa(argCount))
+		args = append(args, maxMembers)
+	}
+
+	if req.Currency != nil {
+		currency, ok := money.LookupCurrency(*req.Currency)
+		if !ok {
+			h.writeError(w, apierror.Validation("Unsupported currency. Use an ISO 4217 code such as USD", models.InvalidField("currency", "currency", "must be a supported ISO 4217 code")))
+			return
+		}
+		// Item costs snapshot their rate to the club currency, and dues and
+		// contribution goals are charged in it, so it is fixed once any exists
+		var inUse bool
+		query := `
+			SELECT c.currency <> $2 AND (
+				c.dues_amount IS NOT NULL
+				OR EXISTS (SELECT 1 FROM dues_payments p WHERE p.club_id = c.id)
+				OR EXISTS (
+					SELECT 1 FROM event_items ei
+					JOIN events e ON e.id = ei.event_id
+					WHERE e.club_id = c.id AND ei.cost IS NOT NULL
+				)
+				OR EXISTS (
+					SELECT 1 FROM event_contribution_goals g
+					JOIN events e ON e.id = g.event_id
+					WHERE e.club_id = c.id
+				)
+			)
+			FROM clubs c WHERE c.id = $1`
+		if err := h.db.QueryRowContext(r.Context(), query, clubID, currency.Code).Scan(&inUse); err != nil && err != sql.ErrNoRows {
+			logging.FromContext(r.Context()).Error("error checking item costs", "error", err)
+			h.writeError(w, apierror.Internal("Failed to update club settings"))
+			return
+		}
+		if inUse {
+			h.writeError(w, apierror.New(apierror.CodeCurrencyInUse, "The currency cannot change once event items have costs, events raise contributions or the club charges dues", nil))
+			return
+		}
+		argCount++
+		setParts = append(setParts, "currency = $"+strconv.Itoa(argCount))
+		args = append(args, currency.Code)
+	}
+
+	if req.Tags != nil {
+		clubTags := tags.NormalizeAll(*req.Tags)
+		if len(clubTags) > maxClubTags {
+			h.writeError(w, apierror.Validation("A club can have at most 10 tags", models.InvalidField("tags", "max", "must have at most 10 tags")))
+			return
+		}
+		for _, tag := range clubTags {
+			if utf8.RuneCountInString(tag) > tags.MaxLength {
+				h.writeError(w, apierror.Validation("Tags must be at most 50 characters", models.InvalidField("tags", "max", "must be at most 50 characters each")))
+				return
+			}
+		}
+		argCount++
+		setParts = append(setParts, "tags = canonical_tags($"+strconv.Itoa(argCount)+")")
+		args = append(args, models.StringArray(clubTags))
+	}
+	if len(setParts) == 0 {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	argCount++
+	args = append(args, clubID)
+
+	query := `UPDATE clubs SET ` + strings.Join(setParts, ", ") + ` WHERE id = $` + strconv.Itoa(argCount)
+	result, err := h.db.ExecContext(r.Context(), query, args...)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating club settings", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update club settings"))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("Club not found"))
+		return
+	}
+	audit.Describe(r.Context(), "club", clubID.String(), audit.Diff(nil, req))
+
+	// New tags join the canonical set, so they are suggested to other clubs
+	if req.Tags != nil {
+		query := `INSERT INTO tags (name) SELECT UNNEST(tags) FROM clubs WHERE id = $1 ON CONFLICT (name) DO NOTHING`
+		if _, err := h.db.ExecContext(r.Context(), query, clubID); err != nil {
+			logging.FromContext(r.Context()).Error("error registering club tags", "error", err)
+		}
+	}
+
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get club settings"))
+		return
+	}
+
+	capacity, err := h.getClubCapacity(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting club capacity", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get club settings"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"settings": clubPolicy,
+		"theme":    h.getClubTheme(r.Context(), clubID),
+		"region":   h.getClubRegion(r.Context(), clubID),
+		"tags":     h.getClubTags(r.Context(), clubID),
+		"capacity": capacity,
+	}
+
+	h.writeSuccessResponse(w, response, "Club settings updated successfully")
+}
+
+// parseMemberCursor reads the joined date and membership ID of a GetMembers cursor
+func parseMemberCursor(cursor string) (time.Time, uuid.UUID, error) {
+	key, err := pagination.DecodeCursor(cursor, 2)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	joinedDate, err := time.Parse(time.RFC3339Nano, key[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, pagination.ErrInvalidCursor
+	}
+	memberID, err := uuid.Parse(key[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, pagination.ErrInvalidCursor
+	}
+	return joinedDate, memberID, nil
+}
+
+// Helper methods
+func (h *ClubHandler) isClubMember(ctx context.Context, clubID, userID uuid.UUID) bool {
+	query := `SELECT 1 FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
+	var exists int
+	err := h.db.QueryRowContext(ctx, query, clubID, userID).Scan(&exists)
+	return err == nil
+}
+
+// checkMemberRole refuses to give members a role the club does not have
+// (every built-in role but owner, and the club's custom roles) or one granting
+// capabilities the caller does not hold
+func (h *ClubHandler) checkMemberRole(ctx context.Context, clubID uuid.UUID, role string) *apierror.Error {
+	granted, exists, err := h.roleGrants(ctx, clubID, role)
+	if err != nil {
+		logging.FromContext(ctx).Error("error checking club role", "error", err)
+		return apierror.Internal("Failed to check role")
+	}
+	if !exists || role == authz.RoleOwner {
+		return invalidField("role", "oneof", "must be a built-in role other than owner, or one of the club's roles", "Invalid role")
+	}
+	return checkGrant(ctx, granted)
+}
+
+// checkMemberChange refuses changes to a membership that the caller may not
+// make, before the new role itself is checked
+func (h *ClubHandler) checkMemberChange(ctx context.Context, clubID, memberID uuid.UUID, req models.UpdateMemberRequest) *apierror.Error {
+	member, err := h.getMember(ctx, clubID, memberID)
+	if err == sql.ErrNoRows {
+		return apierror.NotFound("Member not found")
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("error getting member", "error", err)
+		return apierror.Internal("Failed to update member")
+	}
+	current, _, err := h.roleGrants(ctx, clubID, member.Role)
+	if err != nil {
+		logging.FromContext(ctx).Error("error checking club role", "error", err)
+		return apierror.Internal("Failed to check role")
+	}
+
+	if err := checkRoleChange(ctx, member, current, req.Role != nil); err != nil {
+		return err
+	}
+	if req.Role != nil {
+		return h.checkMemberRole(ctx, clubID, *req.Role)
+	}
+	return nil
+}
+
+// checkRoleChange refuses what a member manager may not do to a membership:
+// change their own role, or change anything about a member whose current
+// role grants capabilities they do not hold
+func checkRoleChange(ctx context.Context, member *models.ClubMember, current []authz.Capability, changesRole bool) *apierror.Error {
+	if userID, err := auth.GetUserIDFromContext(ctx); err == nil && changesRole && member.UserID == userID {
+		return apierror.Forbidden("You cannot change your own role")
+	}
+	membership, _ := authz.FromContext(ctx)
+	if missing := membership.Missing(current); len(missing) > 0 {
+		return apierror.New(apierror.CodeForbidden, "This member's role grants capabilities you do not hold", map[string]interface{}{"capabilities": missing})
+	}
+	return nil
+}
+
+// roleGrants returns the capabilities a role grants in the club, and whether
+// the club has the role at all
+func (h *ClubHandler) roleGrants(ctx context.Context, clubID uuid.UUID, role string) ([]authz.Capability, bool, error) {
+	if granted, builtIn := authz.BuiltInRoles[role]; builtIn {
+		return granted, true, nil
+	}
+
+	var granted models.StringArray
+	query := `SELECT capabilities FROM club_roles WHERE club_id = $1 AND name = $2`
+	if err := h.db.QueryRowContext(ctx, query, clubID, role).Scan(&granted); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return toCapabilities(granted), true, nil
+}
+
+// sameSandbox reports whether the user and club are both sandbox data or both real data
+func (h *ClubHandler) sameSandbox(ctx context.Context, clubID, userID uuid.UUID) bool {
+	query := `
+		SELECT COALESCE(u.is_sandbox, false) = COALESCE(c.is_sandbox, false)
+		FROM users u, clubs c
+		WHERE u.id = $1 AND c.id = $2`
+
+	var same bool
+	if err := h.db.QueryRowContext(ctx, query, userID, clubID).Scan(&same); err != nil {
+		return false
+	}
+	return same
+}
+
+func (h *ClubHandler) getClubPolicy(ctx context.Context, clubID uuid.UUID) (policy.ClubPolicy, error) {
+	query := `SELECT COALESCE(youth_mode, false) FROM clubs WHERE id = $1`
+	var youthMode bool
+	if err := h.db.QueryRowContext(ctx, query, clubID).Scan(&youthMode); err != nil {
+		return policy.ClubPolicy{}, err
+	}
+	return policy.ForClub(youthMode), nil
+}
+
+// errClubFull is returned when a club has no room left under max_members
+var errClubFull = errors.New("club is full")
+
+// addMemberWithinCapacity adds a member only if the club is below max_members.
+// The club row is locked while members are counted so concurrent joins cannot
+// overfill the club. The returned capacity is the club's capacity after the add,
+// or at the time the add was refused.
+func (h *ClubHandler) addMemberWithinCapacity(ctx context.Context, clubID, userID uuid.UUID, role string) (*models.ClubMember, models.ClubCapacity, error) {
+	var maxMembers *int
+	var activeMembers int
+	var capacity models.ClubCapacity
+	member := &models.ClubMember{
+		ID:         uuid.New(),
+		ClubID:     clubID,
+		UserID:     userID,
+		Role:       role,
+		JoinedDate: h.now(),
+		BooksRead:  0,
+		IsActive:   true,
+	}
+
+	err := h.db.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `SELECT max_members FROM clubs WHERE id = $1 FOR UPDATE`, clubID).Scan(&maxMembers); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM club_members WHERE club_id = $1 AND is_active = true`, clubID,
+		).Scan(&activeMembers)
+		if err != nil {
+			return err
+		}
+
+		capacity = models.NewClubCapacity(maxMembers, activeMembers)
+		if capacity.IsFull() {
+			return errClubFull
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO club_members (id, club_id, user_id, role) VALUES ($1, $2, $3, $4)`,
+			member.ID, clubID, userID, role,
+		)
+		if err != nil || h.webhooks == nil {
+			return err
+		}
+		return h.webhooks.Queue(ctx, tx, clubID, webhooks.MemberJoined, member)
+	})
+	if err != nil {
+		return nil, capacity, err
+	}
+	h.forgetRole(clubID, userID)
+
+	capacity = models.NewClubCapacity(maxMembers, activeMembers+1)
+	if capacity.IsFull() {
+		h.notifyAtCapacity(ctx, clubID, capacity)
+	}
+
+	return member, capacity, nil
+}
+
+// forgetRole drops the cached role of a user whose membership changed
+func (h *ClubHandler) forgetRole(clubID, userID uuid.UUID) {
+	if h.roles != nil {
+		h.roles.Forget(clubID, userID)
+	}
+}
+
+// forgetClubRoles drops the cached roles in a club after a change by member ID
+// or to the whole club
+func (h *ClubHandler) forgetClubRoles(clubID uuid.UUID) {
+	if h.roles != nil {
+		h.roles.ForgetClub(clubID)
+	}
+}
+
+// notifyAtCapacity prompts the owner to raise the member limit of a club that just filled up.
+// Failures are logged; the member was already added.
+func (h *ClubHandler) notifyAtCapacity(ctx context.Context, clubID uuid.UUID, capacity models.ClubCapacity) {
+	logging.FromContext(ctx).Info("club reached capacity", "club_id", clubID, "max_members", *capacity.MaxMembers)
+	if h.notifier == nil {
+		return
+	}
+
+	notification := models.Notification{
+		Type:  notify.TypeClubAtCapacity,
+		Title: "Your club is full",
+		Body:  fmt.Sprintf("All %d member spots are taken. Raise the member limit in club settings to let more people join.", *capacity.MaxMembers),
+	}
+	if _, err := h.notifier.NotifyClubOwner(ctx, clubID, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying club owner of capacity", "error", err)
+	}
+}
+
+// getClubCapacity returns the club's member limit and current active member count
+func (h *ClubHandler) getClubCapacity(ctx context.Context, clubID uuid.UUID) (models.ClubCapacity, error) {
+	query := `
+		SELECT c.max_members,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true)
+		FROM clubs c
+		WHERE c.id = $1`
+
+	var maxMembers *int
+	var activeMembers int
+	if err := h.db.QueryRowContext(ctx, query, clubID).Scan(&maxMembers, &activeMembers); err != nil {
+		return models.ClubCapacity{}, err
+	}
+	return models.NewClubCapacity(maxMembers, activeMembers), nil
+}
+
+// createJoinRequest records an open join request: "pending" approval or "waitlisted" for a full club
+func (h *ClubHandler) createJoinRequest(ctx context.Context, clubID, userID uuid.UUID, status string, message *string) (*models.ClubJoinRequest, error) {
+	joinRequest := &models.ClubJoinRequest{
+		ID:        uuid.New(),
+		ClubID:    clubID,
+		UserID:    userID,
+		Status:    status,
+		Message:   message,
+		CreatedAt: h.now(),
+	}
+
+	query := `
+		INSERT INTO club_join_requests (id, club_id, user_id, status, message)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := h.db.ExecContext(ctx, query, joinRequest.ID, clubID, userID, status, message); err != nil {
+		return nil, err
+	}
+	return joinRequest, nil
+}
+
+// writeClubFull writes a 409 CLUB_FULL carrying the club's capacity, and whether
+// the caller can retry with "waitlist": true
+func (h *ClubHandler) writeClubFull(w http.ResponseWriter, capacity models.ClubCapacity, waitlist bool) {
+	details := capacity.Details()
+	details["waitlistAvailable"] = waitlist
+	h.writeError(w, apierror.New(apierror.CodeClubFull, "This club has reached its maximum number of members", details))
+}
+
+func (h *ClubHandler) getClubTheme(ctx context.Context, clubID uuid.UUID) models.ClubTheme {
+	query := `SELECT COALESCE(brand_color, '') FROM clubs WHERE id = $1`
+	var brandColor string
+	h.db.QueryRowContext(ctx, query, clubID).Scan(&brandColor)
+	if brandColor == "" {
+		brandColor = reports.DefaultBrandColor
+	}
+	return models.ClubTheme{BrandColor: brandColor}
+}
+
+// getClubRegion reports the club's country and whether holiday warnings are available for it
+func (h *ClubHandler) getClubRegion(ctx context.Context, clubID uuid.UUID) map[string]interface{} {
+	country, currency := "", money.DefaultCurrency
+	h.db.QueryRowContext(ctx, `SELECT COALESCE(country, ''), currency FROM clubs WHERE id = $1`, clubID).Scan(&country, &currency)
+
+	region := map[string]interface{}{
+		"country":           nil,
+		"holidaysSupported": false,
+		"currency":          currency,
+	}
+	if country != "" {
+		region["country"] = country
+		region["holidaysSupported"] = holidays.Supported(country)
+	}
+	return region
+}
+
+func (h *ClubHandler) getClubTags(ctx context.Context, clubID uuid.UUID) models.StringArray {
+	clubTags := models.StringArray{}
+	h.db.QueryRowContext(ctx, `SELECT COALESCE(tags, '{}') FROM clubs WHERE id = $1`, clubID).Scan(&clubTags)
+	return clubTags
+}
+
+func (h *ClubHandler) getGuardianEmail(ctx context.Context, userID uuid.UUID) *string {
+	query := `SELECT guardian_email FROM users WHERE id = $1`
+	var guardianEmail *string
+	if err := h.db.QueryRowContext(ctx, query, userID).Scan(&guardianEmail); err != nil {
+		return nil
+	}
+	return guardianEmail
+}
+
+func (h *ClubHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ClubHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ClubHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
+
+// escapeLike escapes the LIKE wildcard characters in user input
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // String join helper
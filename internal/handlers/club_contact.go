@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/captcha"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Contact form limits per day, on top of the per-client rate limit of the route
+const (
+	maxContactPerClubPerDay   = 20
+	maxContactPerSenderPerDay = 3
+)
+
+// WithCaptcha requires a passing CAPTCHA response on the public contact form
+func (h *ClubHandler) WithCaptcha(verifier captcha.Verifier) *ClubHandler {
+	h.captcha = verifier
+	return h
+}
+
+// ContactClub relays a prospective member's message to the owner of a public
+// club as a notification. The owner's address is never shown; they reply to
+// the sender's.
+func (h *ClubHandler) ContactClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	var req models.ContactClubRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Bots get the same answer as people, so they have nothing to learn from
+	if req.Website != "" {
+		logging.FromContext(r.Context()).Info("dropped contact form spam", "club_id", clubID.String())
+		h.writeResponse(w, http.StatusAccepted, map[string]string{"message": "Message sent"}, "Message sent successfully")
+		return
+	}
+
+	if err := checkCaptcha(r, h.captcha, req.CaptchaToken); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Only public clubs with an owner take messages; the rest are not found
+	query := `
+		SELECT c.name,
+		       (SELECT COUNT(*) FROM club_contact_messages m WHERE m.club_id = c.id AND m.created_at > $2),
+		       (SELECT COUNT(*) FROM club_contact_messages m WHERE m.club_id = c.id AND m.created_at > $2 AND LOWER(m.sender_email) = LOWER($3))
+		FROM clubs c
+		WHERE c.id = $1 AND c.is_public = true AND COALESCE(c.is_sandbox, false) = false
+		  AND c.deleted_at IS NULL AND c.owner_id IS NOT NULL`
+
+	var clubName string
+	var clubMessages, senderMessages int
+	since := h.now().Add(-24 * time.Hour)
+	err = h.db.QueryRowContext(r.Context(), query, clubID, since, req.Email).Scan(&clubName, &clubMessages, &senderMessages)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club for contact", "error", err)
+		h.writeError(w, apierror.Internal("Failed to send message"))
+		return
+	}
+
+	if clubMessages >= maxContactPerClubPerDay || senderMessages >= maxContactPerSenderPerDay {
+		h.writeError(w, apierror.New(apierror.CodeTooManyMessages, "This club has received too many messages; please try again tomorrow", nil))
+		return
+	}
+
+	_, err = h.db.ExecContext(r.Context(), `
+		INSERT INTO club_contact_messages (club_id, sender_name, sender_email, message, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		clubID, req.Name, req.Email, req.Message, h.now())
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error storing contact message", "error", err)
+		h.writeError(w, apierror.Internal("Failed to send message"))
+		return
+	}
+
+	if h.notifier != nil {
+		notification := models.Notification{
+			Type:  notify.TypeClubContact,
+			Title: fmt.Sprintf("Message about %s from %s", clubName, req.Name),
+			Body:  fmt.Sprintf("%s\n\nReply to %s <%s>.", req.Message, req.Name, req.Email),
+		}
+		if _, err := h.notifier.NotifyClubOwner(r.Context(), clubID, notification); err != nil {
+			logging.FromContext(r.Context()).Error("error notifying club owner of contact message", "error", err)
+			h.writeError(w, apierror.Internal("Failed to send message"))
+			return
+		}
+	}
+
+	h.writeResponse(w, http.StatusAccepted, map[string]string{"message": "Message sent"}, "Message sent successfully")
+}
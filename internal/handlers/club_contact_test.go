@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/captcha"
+	"bookwork-api/internal/database"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// rejectingCaptcha fails every response
+type rejectingCaptcha struct{}
+
+func (rejectingCaptcha) Verify(ctx context.Context, response, remoteIP string) error {
+	return captcha.ErrFailed
+}
+
+func TestContactClubValidation(t *testing.T) {
+	plain := NewClubHandler(database.NewMock())
+	protected := NewClubHandler(database.NewMock()).WithCaptcha(rejectingCaptcha{})
+
+	router := chi.NewRouter()
+	router.Post("/public/clubs/{clubId}/contact", plain.ContactClub)
+	router.Post("/protected/clubs/{clubId}/contact", protected.ContactClub)
+
+	clubID := uuid.New().String()
+	valid := `{"name":"Ada","email":"ada@example.com","message":"Do you meet on weekends?"}`
+	tests := []struct {
+		path     string
+		body     string
+		expected int
+	}{
+		{"/public/clubs/not-a-uuid/contact", valid, http.StatusBadRequest},
+		{"/public/clubs/" + clubID + "/contact", `{"name":"Ada","email":"not-an-email","message":"Do you meet on weekends?"}`, http.StatusBadRequest},
+		{"/public/clubs/" + clubID + "/contact", `{"name":"Ada","email":"ada@example.com","message":"Hi"}`, http.StatusBadRequest},
+		// The honeypot is answered as if the message was sent
+		{"/public/clubs/" + clubID + "/contact", `{"name":"Bot","email":"bot@example.com","message":"Cheap watches here","website":"http://spam.example"}`, http.StatusAccepted},
+		{"/protected/clubs/" + clubID + "/contact", valid, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.path, tt.body, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
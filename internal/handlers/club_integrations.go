@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/integrations"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// defaultRemindBeforeHours is how long before an event a new integration posts its reminder
+const defaultRemindBeforeHours = 24
+
+// clubIntegrations is the part of integrations.Store the integration handler uses
+type clubIntegrations interface {
+	List(ctx context.Context, clubID uuid.UUID) ([]integrations.Integration, error)
+	Create(ctx context.Context, clubID uuid.UUID, n integrations.New) (integrations.Integration, error)
+	Update(ctx context.Context, clubID, integrationID uuid.UUID, update integrations.Update) (integrations.Integration, error)
+	Delete(ctx context.Context, clubID, integrationID uuid.UUID) error
+}
+
+// eventAnnouncer is the part of integrations.Store that announces events
+// written in tx in the club's chat integrations
+type eventAnnouncer interface {
+	Queue(ctx context.Context, tx *sql.Tx, clubID uuid.UUID, a integrations.Announcement) error
+}
+
+// ClubIntegrationHandler lets club managers connect the Slack and Discord
+// webhooks their events are announced in
+type ClubIntegrationHandler struct {
+	clocked
+
+	integrations clubIntegrations
+}
+
+func NewClubIntegrationHandler(integrations clubIntegrations) *ClubIntegrationHandler {
+	return &ClubIntegrationHandler{integrations: integrations}
+}
+
+// ListIntegrations returns the club's integrations, without their webhook URLs
+func (h *ClubIntegrationHandler) ListIntegrations(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.integrations.List(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying integrations", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get integrations"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"integrations": list}, "Integrations retrieved successfully")
+}
+
+// CreateIntegration connects a Slack or Discord incoming webhook. Its URL
+// carries the provider's token, so it is never returned.
+func (h *ClubIntegrationHandler) CreateIntegration(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateIntegrationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	req.WebhookURL = strings.TrimSpace(req.WebhookURL)
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := integrations.ValidateURL(req.Provider, req.WebhookURL); err != nil {
+		h.writeError(w, apierror.Validation("webhookUrl "+err.Error(), models.InvalidField("webhookUrl", "url", err.Error())))
+		return
+	}
+
+	remindBefore := defaultRemindBeforeHours
+	if req.RemindBeforeHours != nil {
+		remindBefore = *req.RemindBeforeHours
+	}
+
+	created, err := h.integrations.Create(r.Context(), clubID, integrations.New{
+		Provider:          req.Provider,
+		WebhookURL:        req.WebhookURL,
+		Name:              req.Name,
+		RemindBeforeHours: remindBefore,
+		CreatedBy:         userID,
+	})
+	if err != nil {
+		h.writeIntegrationError(w, r, err, "Failed to create integration")
+		return
+	}
+	audit.Describe(r.Context(), "chat_integration", created.ID.String(), audit.Diff(nil, created))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"integration": created}, "Integration created successfully")
+}
+
+// UpdateIntegration changes an integration's webhook, name or reminders, or
+// pauses and resumes it
+func (h *ClubIntegrationHandler) UpdateIntegration(w http.ResponseWriter, r *http.Request) {
+	clubID, integrationID, ok := h.clubIntegration(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateIntegrationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.WebhookURL != nil {
+		trimmed := strings.TrimSpace(*req.WebhookURL)
+		req.WebhookURL = &trimmed
+	}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		req.Name = &trimmed
+	}
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.WebhookURL == nil && req.Name == nil && req.RemindBeforeHours == nil && req.IsActive == nil {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	if req.WebhookURL != nil {
+		// A new URL must belong to the provider the integration was connected to
+		existing, ok := h.find(w, r, clubID, integrationID)
+		if !ok {
+			return
+		}
+		if err := integrations.ValidateURL(existing.Provider, *req.WebhookURL); err != nil {
+			h.writeError(w, apierror.Validation("webhookUrl "+err.Error(), models.InvalidField("webhookUrl", "url", err.Error())))
+			return
+		}
+	}
+
+	updated, err := h.integrations.Update(r.Context(), clubID, integrationID, integrations.Update{
+		WebhookURL:        req.WebhookURL,
+		Name:              req.Name,
+		RemindBeforeHours: req.RemindBeforeHours,
+		IsActive:          req.IsActive,
+	})
+	if err != nil {
+		h.writeIntegrationError(w, r, err, "Failed to update integration")
+		return
+	}
+	audit.Describe(r.Context(), "chat_integration", integrationID.String(), audit.Diff(nil, updated))
+
+	h.writeSuccessResponse(w, map[string]interface{}{"integration": updated}, "Integration updated successfully")
+}
+
+// DeleteIntegration disconnects an integration
+func (h *ClubIntegrationHandler) DeleteIntegration(w http.ResponseWriter, r *http.Request) {
+	clubID, integrationID, ok := h.clubIntegration(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.integrations.Delete(r.Context(), clubID, integrationID); err != nil {
+		h.writeIntegrationError(w, r, err, "Failed to delete integration")
+		return
+	}
+	audit.Describe(r.Context(), "chat_integration", integrationID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Integration deleted successfully"}, "Integration deleted successfully")
+}
+
+// find returns an integration of the club, answering 404 when there is none
+func (h *ClubIntegrationHandler) find(w http.ResponseWriter, r *http.Request, clubID, integrationID uuid.UUID) (integrations.Integration, bool) {
+	list, err := h.integrations.List(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying integrations", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update integration"))
+		return integrations.Integration{}, false
+	}
+	for _, i := range list {
+		if i.ID == integrationID {
+			return i, true
+		}
+	}
+	h.writeError(w, apierror.NotFound("Integration not found"))
+	return integrations.Integration{}, false
+}
+
+func (h *ClubIntegrationHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *ClubIntegrationHandler) clubIntegration(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	integrationID, err := uuid.Parse(chi.URLParam(r, "integrationId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid integration ID", models.InvalidID("integrationId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, integrationID, true
+}
+
+func (h *ClubIntegrationHandler) writeIntegrationError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, integrations.ErrNotFound) {
+		h.writeError(w, apierror.NotFound("Integration not found"))
+		return
+	}
+	if verr := violationError(r.Context(), err); verr != nil {
+		h.writeError(w, verr)
+		return
+	}
+	logging.FromContext(r.Context()).Error("error changing integration", "error", err)
+	h.writeError(w, apierror.Internal(message))
+}
+
+func (h *ClubIntegrationHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *ClubIntegrationHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ClubIntegrationHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
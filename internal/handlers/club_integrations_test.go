@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/integrations"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeClubIntegrations keeps integrations in memory
+type fakeClubIntegrations struct {
+	integrations []integrations.Integration
+}
+
+func (f *fakeClubIntegrations) List(ctx context.Context, clubID uuid.UUID) ([]integrations.Integration, error) {
+	list := []integrations.Integration{}
+	for _, i := range f.integrations {
+		if i.ClubID == clubID {
+			list = append(list, i)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClubIntegrations) Create(ctx context.Context, clubID uuid.UUID, n integrations.New) (integrations.Integration, error) {
+	i := integrations.Integration{ID: uuid.New(), ClubID: clubID, Provider: n.Provider, WebhookURL: n.WebhookURL,
+		Name: n.Name, RemindBeforeHours: n.RemindBeforeHours, IsActive: true, CreatedBy: &n.CreatedBy}
+	f.integrations = append(f.integrations, i)
+	return i, nil
+}
+
+func (f *fakeClubIntegrations) Update(ctx context.Context, clubID, integrationID uuid.UUID, update integrations.Update) (integrations.Integration, error) {
+	for n, i := range f.integrations {
+		if i.ClubID == clubID && i.ID == integrationID {
+			if update.WebhookURL != nil {
+				f.integrations[n].WebhookURL = *update.WebhookURL
+			}
+			if update.RemindBeforeHours != nil {
+				f.integrations[n].RemindBeforeHours = *update.RemindBeforeHours
+			}
+			if update.IsActive != nil {
+				f.integrations[n].IsActive = *update.IsActive
+			}
+			return f.integrations[n], nil
+		}
+	}
+	return integrations.Integration{}, integrations.ErrNotFound
+}
+
+func (f *fakeClubIntegrations) Delete(ctx context.Context, clubID, integrationID uuid.UUID) error {
+	for n, i := range f.integrations {
+		if i.ClubID == clubID && i.ID == integrationID {
+			f.integrations = append(f.integrations[:n], f.integrations[n+1:]...)
+			return nil
+		}
+	}
+	return integrations.ErrNotFound
+}
+
+func setupClubIntegrationTest() (*fakeClubIntegrations, chi.Router) {
+	store := &fakeClubIntegrations{}
+	handler := NewClubIntegrationHandler(store)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), auth.Principal{UserID: uuid.New()})))
+		})
+	})
+	router.Get("/club/{clubId}/integrations", handler.ListIntegrations)
+	router.Post("/club/{clubId}/integrations", handler.CreateIntegration)
+	router.Put("/club/{clubId}/integrations/{integrationId}", handler.UpdateIntegration)
+	router.Delete("/club/{clubId}/integrations/{integrationId}", handler.DeleteIntegration)
+	return store, router
+}
+
+func TestCreateIntegration(t *testing.T) {
+	store, router := setupClubIntegrationTest()
+	clubID := uuid.New()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"provider": "teams", "webhookUrl": "https://hooks.slack.com/services/T0/B0/X"}`, http.StatusBadRequest},
+		{`{"provider": "slack", "webhookUrl": "https://discord.com/api/webhooks/1/abc"}`, http.StatusBadRequest},
+		{`{"provider": "slack", "webhookUrl": "https://example.com/services/T0/B0/X"}`, http.StatusBadRequest},
+		{`{"provider": "discord", "webhookUrl": "https://discord.com/api/webhooks/1/abc", "remindBeforeHours": 200}`, http.StatusBadRequest},
+		{`{"provider": "discord", "webhookUrl": " https://discord.com/api/webhooks/1/abc ", "name": "#meetups"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/club/"+clubID.String()+"/integrations", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("POST %s: expected status %d, got %d: %s", tt.body, tt.expected, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusCreated && bytes.Contains(w.Body.Bytes(), []byte("api/webhooks")) {
+			t.Errorf("Expected the webhook URL not to be returned, got %s", w.Body.String())
+		}
+	}
+
+	if len(store.integrations) != 1 {
+		t.Fatalf("Expected one integration to be created, got %d", len(store.integrations))
+	}
+	if got := store.integrations[0]; got.WebhookURL != "https://discord.com/api/webhooks/1/abc" || got.RemindBeforeHours != defaultRemindBeforeHours {
+		t.Errorf("Expected a trimmed URL and the default reminder, got %q %d", got.WebhookURL, got.RemindBeforeHours)
+	}
+}
+
+func TestUpdateIntegration(t *testing.T) {
+	store, router := setupClubIntegrationTest()
+	clubID := uuid.New()
+	created, _ := store.Create(context.Background(), clubID, integrations.New{Provider: integrations.ProviderSlack,
+		WebhookURL: "https://hooks.slack.com/services/T0/B0/X", RemindBeforeHours: 24})
+	path := "/club/" + clubID.String() + "/integrations/" + created.ID.String()
+
+	tests := []struct {
+		path     string
+		body     string
+		expected int
+	}{
+		{path, `{}`, http.StatusBadRequest},
+		{path, `{"webhookUrl": "https://discord.com/api/webhooks/1/abc"}`, http.StatusBadRequest},
+		{"/club/" + uuid.New().String() + "/integrations/" + created.ID.String(), `{"remindBeforeHours": 2}`, http.StatusNotFound},
+		{path, `{"remindBeforeHours": 0}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("PUT %s: expected status %d, got %d: %s", tt.body, tt.expected, w.Code, w.Body.String())
+		}
+	}
+	if store.integrations[0].RemindBeforeHours != 0 {
+		t.Error("Expected reminders to be turned off")
+	}
+}
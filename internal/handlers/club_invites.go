@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// defaultInviteTTL is how long an invite link works when no expiry is given
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+// CreateInvite issues a shareable link to join the club. The token is only
+// returned here; the link stops working when it expires, runs out of uses or
+// is revoked.
+func (h *ClubHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// The request body is optional
+	var req models.CreateClubInviteRequest
+	if err := decodeOptionalJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error generating invite token", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create invite"))
+		return
+	}
+
+	ttl := defaultInviteTTL
+	if req.ExpiresInHours != nil {
+		ttl = time.Duration(*req.ExpiresInHours) * time.Hour
+	}
+
+	now := h.now()
+	invite := models.ClubInvite{
+		ID:        uuid.New(),
+		ClubID:    clubID,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: now.Add(ttl),
+		CreatedBy: &userID,
+		CreatedAt: now,
+	}
+
+	query := `
+		INSERT INTO club_invites (id, club_id, token_hash, max_uses, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = h.db.ExecContext(r.Context(), query, invite.ID, clubID, hashInviteToken(token),
+		invite.MaxUses, invite.ExpiresAt, userID, invite.CreatedAt)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating invite", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create invite"))
+		return
+	}
+	audit.Describe(r.Context(), "club_invite", invite.ID.String(), audit.Diff(nil, req))
+
+	response := map[string]interface{}{
+		"invite": invite,
+		"token":  token,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Invite created successfully")
+}
+
+// GetInvites lists the club's invites, newest first, including used up, expired and revoked ones
+func (h *ClubHandler) GetInvites(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	query := `
+		SELECT id, club_id, max_uses, use_count, expires_at, created_by, created_at, revoked_at
+		FROM club_invites
+		WHERE club_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying invites", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get invites"))
+		return
+	}
+	defer rows.Close()
+
+	now := h.now()
+	invites := []map[string]interface{}{}
+	for rows.Next() {
+		var invite models.ClubInvite
+		err := rows.Scan(&invite.ID, &invite.ClubID, &invite.MaxUses, &invite.UseCount,
+			&invite.ExpiresAt, &invite.CreatedBy, &invite.CreatedAt, &invite.RevokedAt)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning invite", "error", err)
+			continue
+		}
+		invites = append(invites, map[string]interface{}{
+			"invite": invite,
+			"usable": invite.Usable(now),
+		})
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"invites": invites}, "Invites retrieved successfully")
+}
+
+// RevokeInvite stops an invite link from working
+func (h *ClubHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	inviteID, err := uuid.Parse(chi.URLParam(r, "inviteId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid invite ID", models.InvalidID("inviteId")))
+		return
+	}
+
+	result, err := h.db.ExecContext(r.Context(),
+		`UPDATE club_invites SET revoked_at = NOW() WHERE id = $1 AND club_id = $2 AND revoked_at IS NULL`,
+		inviteID, clubID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error revoking invite", "error", err)
+		h.writeError(w, apierror.Internal("Failed to revoke invite"))
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		h.writeError(w, apierror.NotFound("Active invite not found"))
+		return
+	}
+	audit.Describe(r.Context(), "club_invite", inviteID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Invite revoked successfully"}, "Invite revoked successfully")
+}
+
+// PreviewInvite shows the club an invite link is for, without signing in.
+// Private clubs are shown too: holding the link is the permission.
+func (h *ClubHandler) PreviewInvite(w http.ResponseWriter, r *http.Request) {
+	invite, err := h.inviteByToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		h.writeInviteLookupError(w, r, err)
+		return
+	}
+
+	query := `
+		SELECT c.id, c.name, COALESCE(c.description, ''), c.meeting_frequency, c.current_book,
+		       c.tags, c.location, c.verification_type, c.created_at,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true)
+		FROM clubs c
+		WHERE c.id = $1`
+
+	preview := models.ClubInvitePreview{
+		ExpiresAt:     invite.ExpiresAt,
+		RemainingUses: invite.RemainingUses(),
+	}
+	club := &preview.Club
+	err = h.db.QueryRowContext(r.Context(), query, invite.ClubID).Scan(
+		&club.ID, &club.Name, &club.Description, &club.MeetingFrequency, &club.CurrentBook,
+		&club.Tags, &club.Location, &club.Verification, &club.CreatedAt, &club.MemberCount,
+	)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting invited club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get invite"))
+		return
+	}
+
+	h.writeSuccessResponse(w, preview, "Invite retrieved successfully")
+}
+
+// AcceptInvite joins the caller to the invite's club. The invite stands in for
+// approval of private clubs, but the club's safety policy and member limit
+// still apply.
+func (h *ClubHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	invite, err := h.inviteByToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		h.writeInviteLookupError(w, r, err)
+		return
+	}
+	clubID := invite.ClubID
+
+	var isSandbox bool
+	err = h.db.QueryRowContext(r.Context(), `SELECT COALESCE(is_sandbox, false) FROM clubs WHERE id = $1`, clubID).Scan(&isSandbox)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to accept invite"))
+		return
+	}
+	if isSandbox != auth.IsSandboxFromContext(r.Context()) {
+		h.writeError(w, apierror.New(apierror.CodeSandboxMismatch, "Sandbox accounts and clubs cannot be mixed with real ones", nil))
+		return
+	}
+
+	// Existing memberships, including ones deactivated by moderators
+	var isActive bool
+	err = h.db.QueryRowContext(r.Context(), `SELECT is_active FROM club_members WHERE club_id = $1 AND user_id = $2`, clubID, userID).Scan(&isActive)
+	if err == nil {
+		if isActive {
+			h.writeError(w, apierror.Conflict("You are already a member of this club"))
+		} else {
+			h.writeError(w, apierror.Forbidden("Your membership in this club has been deactivated"))
+		}
+		return
+	}
+	if err != sql.ErrNoRows {
+		logging.FromContext(r.Context()).Error("error checking membership", "error", err)
+		h.writeError(w, apierror.Internal("Failed to accept invite"))
+		return
+	}
+
+	// Enforce the club's safety policy on the new member
+	clubPolicy, err := h.getClubPolicy(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error loading club policy", "error", err)
+		h.writeError(w, apierror.Internal("Failed to accept invite"))
+		return
+	}
+
+	if err := clubPolicy.CheckNewMember(h.getGuardianEmail(r.Context(), userID)); err != nil {
+		h.writeError(w, apierror.Validation("A guardian email must be on file to join a youth club", models.InvalidField("", "guardian_email", "A guardian email must be on file to join a youth club")))
+		return
+	}
+
+	// Claim a use first so concurrent accepts cannot exceed max_uses
+	claimed, err := h.claimInviteUse(r.Context(), invite.ID, 1)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error claiming invite", "error", err)
+		h.writeError(w, apierror.Internal("Failed to accept invite"))
+		return
+	}
+	if !claimed {
+		h.writeError(w, apierror.NotFound("Invite not found or no longer valid"))
+		return
+	}
+
+	member, capacity, err := h.addMemberWithinCapacity(r.Context(), clubID, userID, "member")
+	if err != nil {
+		// The use was not spent; give it back
+		if _, rerr := h.claimInviteUse(r.Context(), invite.ID, -1); rerr != nil {
+			logging.FromContext(r.Context()).Error("error releasing invite use", "error", rerr)
+		}
+		if err == errClubFull {
+			h.writeClubFull(w, capacity, false)
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error joining club by invite", "error", err)
+		h.writeError(w, apierror.Internal("Failed to accept invite"))
+		return
+	}
+
+	// Requests to join are settled by the invite
+	_, err = h.db.ExecContext(r.Context(), `
+		UPDATE club_join_requests SET status = 'approved', decided_at = NOW()
+		WHERE club_id = $1 AND user_id = $2 AND status IN ('pending', 'waitlisted')`,
+		clubID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error closing join requests", "error", err)
+	}
+	audit.Describe(r.Context(), "club_member", member.ID.String(), audit.Changes{"inviteId": {To: invite.ID}})
+
+	response := map[string]interface{}{
+		"member":   member,
+		"capacity": capacity,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Joined club successfully")
+}
+
+// errInviteUnusable is returned for an invite that is revoked, expired or used up
+var errInviteUnusable = sql.ErrNoRows
+
+// inviteByToken returns the usable invite for a token of a club that is not deleted
+func (h *ClubHandler) inviteByToken(ctx context.Context, token string) (*models.ClubInvite, error) {
+	query := `
+		SELECT i.id, i.club_id, i.max_uses, i.use_count, i.expires_at, i.created_by, i.created_at, i.revoked_at
+		FROM club_invites i
+		JOIN clubs c ON c.id = i.club_id
+		WHERE i.token_hash = $1 AND c.deleted_at IS NULL`
+
+	var invite models.ClubInvite
+	err := h.db.QueryRowContext(ctx, query, hashInviteToken(token)).Scan(
+		&invite.ID, &invite.ClubID, &invite.MaxUses, &invite.UseCount,
+		&invite.ExpiresAt, &invite.CreatedBy, &invite.CreatedAt, &invite.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !invite.Usable(h.now()) {
+		return nil, errInviteUnusable
+	}
+	return &invite, nil
+}
+
+// claimInviteUse adds delta to the invite's use count, refusing to go past
+// max_uses or to use a revoked or expired invite
+func (h *ClubHandler) claimInviteUse(ctx context.Context, inviteID uuid.UUID, delta int) (bool, error) {
+	query := `
+		UPDATE club_invites SET use_count = use_count + $2
+		WHERE id = $1 AND use_count + $2 >= 0
+		  AND ($2 < 0 OR (revoked_at IS NULL AND expires_at > NOW() AND (max_uses IS NULL OR use_count < max_uses)))`
+
+	result, err := h.db.ExecContext(ctx, query, inviteID, delta)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// writeInviteLookupError reports unknown and unusable invites alike, as a 404
+// the token guard counts
+func (h *ClubHandler) writeInviteLookupError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == sql.ErrNoRows {
+		h.writeError(w, apierror.NotFound("Invite not found or no longer valid"))
+		return
+	}
+	logging.FromContext(r.Context()).Error("error getting invite", "error", err)
+	h.writeError(w, apierror.Internal("Failed to get invite"))
+}
+
+// newInviteToken returns a random token for an invite link
+func newInviteToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "inv_" + hex.EncodeToString(bytes), nil
+}
+
+// hashInviteToken is how invite tokens are stored and looked up
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// booksTrendDays is the length of the periods a member's books trend compares
+const booksTrendDays = 90
+
+// parseMemberIncludes reads ?include= on the member list, a comma-separated
+// list of extras; stats is the only one
+func parseMemberIncludes(value string) (stats bool, ok bool) {
+	if value == "" {
+		return false, true
+	}
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) != "stats" {
+			return false, false
+		}
+		stats = true
+	}
+	return stats, true
+}
+
+// memberStatsQuery aggregates the statistics of several members in one pass.
+// A member attended an event held by today if they are among its attendees
+// or said they were available; archived events count too. Event items of
+// archived events are gone with them, so completed items only count live
+// events. The books trend counts the distinct books of attended events in
+// the last period ($4 to $3) and the one before it ($5 to $4).
+const memberStatsQuery = `
+	WITH club_events AS (
+		SELECT id, event_date, book, attendees FROM events
+		WHERE club_id = $1 AND deleted_at IS NULL AND event_date <= $3
+		UNION ALL
+		SELECT id, event_date, book, attendees FROM events_archive
+		WHERE club_id = $1
+	),
+	attended AS (
+		SELECT ce.id AS event_id, ce.event_date, ce.book, a.user_id
+		FROM club_events ce, unnest(ce.attendees) AS a(user_id)
+		WHERE a.user_id = ANY($2::uuid[])
+		UNION
+		SELECT ce.id, ce.event_date, ce.book, av.user_id FROM availability av
+		JOIN club_events ce ON ce.id = av.event_id
+		WHERE av.status = 'available' AND av.user_id = ANY($2::uuid[])
+		UNION
+		SELECT ce.id, ce.event_date, ce.book, av.user_id FROM availability_archive av
+		JOIN club_events ce ON ce.id = av.event_id AND ce.event_date = av.event_date
+		WHERE av.status = 'available' AND av.user_id = ANY($2::uuid[])
+	),
+	attendance AS (
+		SELECT user_id, COUNT(DISTINCT event_id) AS events,
+		       COUNT(DISTINCT book) FILTER (WHERE event_date > $4) AS recent_books,
+		       COUNT(DISTINCT book) FILTER (WHERE event_date <= $4 AND event_date > $5) AS previous_books
+		FROM attended
+		GROUP BY user_id
+	),
+	items AS (
+		SELECT ei.assigned_to AS user_id, COUNT(*) AS completed
+		FROM event_items ei
+		JOIN events e ON e.id = ei.event_id
+		WHERE e.club_id = $1 AND e.deleted_at IS NULL AND ei.status = 'completed' AND ei.assigned_to = ANY($2::uuid[])
+		GROUP BY ei.assigned_to
+	)
+	SELECT cm.user_id, COALESCE(cm.books_read, 0), COALESCE(a.events, 0), COALESCE(i.completed, 0),
+	       COALESCE(a.recent_books, 0), COALESCE(a.previous_books, 0)
+	FROM club_members cm
+	LEFT JOIN attendance a ON a.user_id = cm.user_id
+	LEFT JOIN items i ON i.user_id = cm.user_id
+	WHERE cm.club_id = $1 AND cm.user_id = ANY($2::uuid[])`
+
+// memberStats returns the statistics of a club's members by user ID, in a
+// single query however many members there are
+func (h *ClubHandler) memberStats(ctx context.Context, clubID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]*models.MemberStats, error) {
+	stats := make(map[uuid.UUID]*models.MemberStats, len(userIDs))
+	if len(userIDs) == 0 {
+		return stats, nil
+	}
+
+	now := h.now().UTC()
+	today := now.Format("2006-01-02")
+	recentFrom := now.AddDate(0, 0, -booksTrendDays).Format("2006-01-02")
+	previousFrom := now.AddDate(0, 0, -2*booksTrendDays).Format("2006-01-02")
+
+	rows, err := h.db.QueryContext(ctx, memberStatsQuery, clubID, models.UUIDArray(userIDs), today, recentFrom, previousFrom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uuid.UUID
+		var s models.MemberStats
+		if err := rows.Scan(&userID, &s.BooksRead, &s.EventsAttended, &s.ItemsCompleted, &s.BooksTrend.Recent, &s.BooksTrend.Previous); err != nil {
+			return nil, fmt.Errorf("failed to scan member stats: %w", err)
+		}
+		s.BooksTrend = booksTrend(s.BooksTrend.Recent, s.BooksTrend.Previous)
+		stats[userID] = &s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read member stats: %w", err)
+	}
+	return stats, nil
+}
+
+// booksTrend compares the books of the last period with the period before
+func booksTrend(recent, previous int) models.BooksTrend {
+	direction := "steady"
+	if recent > previous {
+		direction = "up"
+	} else if recent < previous {
+		direction = "down"
+	}
+	return models.BooksTrend{PeriodDays: booksTrendDays, Recent: recent, Previous: previous, Direction: direction}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/database"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestParseMemberIncludes(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStats bool
+		wantOK    bool
+	}{
+		{"", false, true},
+		{"stats", true, true},
+		{" stats ", true, true},
+		{"stats,stats", true, true},
+		{"events", false, false},
+		{"stats,events", false, false},
+		{",", false, false},
+	}
+
+	for _, tt := range tests {
+		stats, ok := parseMemberIncludes(tt.value)
+		if stats != tt.wantStats || ok != tt.wantOK {
+			t.Errorf("parseMemberIncludes(%q) = %v, %v; want %v, %v", tt.value, stats, ok, tt.wantStats, tt.wantOK)
+		}
+	}
+}
+
+func TestBooksTrend(t *testing.T) {
+	tests := []struct {
+		recent, previous int
+		want             string
+	}{
+		{3, 1, "up"},
+		{1, 3, "down"},
+		{2, 2, "steady"},
+		{0, 0, "steady"},
+	}
+
+	for _, tt := range tests {
+		trend := booksTrend(tt.recent, tt.previous)
+		if trend.Direction != tt.want || trend.Recent != tt.recent || trend.Previous != tt.previous || trend.PeriodDays != booksTrendDays {
+			t.Errorf("booksTrend(%d, %d) = %+v; want direction %s", tt.recent, tt.previous, trend, tt.want)
+		}
+	}
+}
+
+func TestGetMembersRejectsUnknownInclude(t *testing.T) {
+	handler := NewClubHandler(database.NewMock())
+
+	clubID := uuid.New().String()
+	req := httptest.NewRequest("GET", "/club/"+clubID+"/members?include=everything", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clubId", clubID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	handler.GetMembers(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// clubRoleName is the form of custom role names: lowercase, as members are
+// given roles by name
+var clubRoleName = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// builtInRole is a role every club has, as listed by ListRoles
+type builtInRole struct {
+	Name         string             `json:"name"`
+	Capabilities []authz.Capability `json:"capabilities"`
+}
+
+// ClubRoleHandler lets clubs define roles beyond the built-in ones, granting
+// any set of capabilities
+type ClubRoleHandler struct {
+	clocked
+
+	roles store.ClubRoleStore
+}
+
+func NewClubRoleHandler(roles store.ClubRoleStore) *ClubRoleHandler {
+	return &ClubRoleHandler{roles: roles}
+}
+
+// ListRoles returns the built-in roles and the club's own, with what each
+// grants, and every capability a role can grant
+func (h *ClubRoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roles.List(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying club roles", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get roles"))
+		return
+	}
+
+	builtIn := []builtInRole{}
+	for _, name := range []string{authz.RoleOwner, authz.RoleModerator, authz.RoleTreasurer, "member", "guest", "admin"} {
+		capabilities := authz.BuiltInRoles[name]
+		if capabilities == nil {
+			capabilities = []authz.Capability{}
+		}
+		builtIn = append(builtIn, builtInRole{Name: name, Capabilities: capabilities})
+	}
+
+	response := map[string]interface{}{
+		"roles":        roles,
+		"builtInRoles": builtIn,
+		"capabilities": authz.Capabilities,
+	}
+
+	h.writeSuccessResponse(w, response, "Roles retrieved successfully")
+}
+
+// CreateRole defines a custom role, which members can then be given by name
+func (h *ClubRoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateClubRoleRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if !clubRoleName.MatchString(req.Name) {
+		h.writeError(w, invalidField("name", "role_name", "must start with a lowercase letter followed by lowercase letters, digits, _ or -", "Invalid role name"))
+		return
+	}
+	if _, builtIn := authz.BuiltInRoles[req.Name]; builtIn {
+		h.writeError(w, apierror.New(apierror.CodeAlreadyExists, "A built-in role has this name", nil))
+		return
+	}
+	if err := checkGrant(r.Context(), toCapabilities(req.Capabilities)); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if _, err := h.roles.Get(r.Context(), clubID, req.Name); err != store.ErrNotFound {
+		if err == nil {
+			h.writeError(w, apierror.New(apierror.CodeAlreadyExists, "The club already has a role with this name", nil))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club role", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create role"))
+		return
+	}
+
+	now := h.now()
+	role := &models.ClubRole{
+		ID:           uuid.New(),
+		ClubID:       clubID,
+		Name:         req.Name,
+		Capabilities: dedupe(req.Capabilities),
+		CreatedBy:    &userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := h.roles.Create(r.Context(), role); err != nil {
+		h.writeRoleError(w, r, err, "Failed to create role")
+		return
+	}
+	audit.Describe(r.Context(), "club_role", role.ID.String(), audit.Diff(nil, req))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"role": role}, "Role created successfully")
+}
+
+// UpdateRole replaces what a custom role grants. Members holding it gain and
+// lose capabilities right away, or once the role cache expires on other
+// instances.
+func (h *ClubRoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	clubID, roleID, ok := h.clubRole(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateClubRoleRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if !h.checkHeldRole(w, r, clubID, roleID) {
+		return
+	}
+	if err := checkGrant(r.Context(), toCapabilities(req.Capabilities)); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	role, err := h.roles.Update(r.Context(), clubID, roleID, dedupe(req.Capabilities))
+	if err != nil {
+		h.writeRoleError(w, r, err, "Failed to update role")
+		return
+	}
+	audit.Describe(r.Context(), "club_role", roleID.String(), audit.Diff(nil, req))
+
+	h.writeSuccessResponse(w, map[string]interface{}{"role": role}, "Role updated successfully")
+}
+
+// DeleteRole removes a custom role no active member holds
+func (h *ClubRoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	clubID, roleID, ok := h.clubRole(w, r)
+	if !ok {
+		return
+	}
+	if !h.checkHeldRole(w, r, clubID, roleID) {
+		return
+	}
+
+	if err := h.roles.Delete(r.Context(), clubID, roleID); err != nil {
+		h.writeRoleError(w, r, err, "Failed to delete role")
+		return
+	}
+	audit.Describe(r.Context(), "club_role", roleID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Role deleted successfully"}, "Role deleted successfully")
+}
+
+func (h *ClubRoleHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *ClubRoleHandler) clubRole(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid role ID", models.InvalidID("roleId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, roleID, true
+}
+
+// checkHeldRole refuses changes to a role granting capabilities the caller
+// does not hold, which only those holding them may make
+func (h *ClubRoleHandler) checkHeldRole(w http.ResponseWriter, r *http.Request, clubID, roleID uuid.UUID) bool {
+	roles, err := h.roles.List(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying club roles", "error", err)
+		h.writeError(w, apierror.Internal("Failed to change role"))
+		return false
+	}
+	for _, role := range roles {
+		if role.ID != roleID {
+			continue
+		}
+		membership, _ := authz.FromContext(r.Context())
+		if missing := membership.Missing(toCapabilities(role.Capabilities)); len(missing) > 0 {
+			h.writeError(w, apierror.New(apierror.CodeForbidden, "This role grants capabilities you do not hold", map[string]interface{}{"capabilities": missing}))
+			return false
+		}
+		return true
+	}
+	h.writeError(w, apierror.NotFound("Role not found"))
+	return false
+}
+
+// checkGrant refuses to let the caller hand out capabilities they do not
+// hold, so that managing members cannot be turned into running the club
+func checkGrant(ctx context.Context, granted []authz.Capability) *apierror.Error {
+	membership, _ := authz.FromContext(ctx)
+	if missing := membership.Missing(granted); len(missing) > 0 {
+		return apierror.New(apierror.CodeForbidden, "You cannot grant capabilities you do not hold", map[string]interface{}{"capabilities": missing})
+	}
+	return nil
+}
+
+func toCapabilities(names []string) []authz.Capability {
+	capabilities := make([]authz.Capability, len(names))
+	for i, name := range names {
+		capabilities[i] = authz.Capability(name)
+	}
+	return capabilities
+}
+
+func (h *ClubRoleHandler) writeRoleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch err {
+	case store.ErrNotFound:
+		h.writeError(w, apierror.NotFound("Role not found"))
+		return
+	case store.ErrRoleInUse:
+		h.writeError(w, apierror.New(apierror.CodeStillReferenced, "Members still hold this role; give them another first", nil))
+		return
+	}
+	if verr := violationError(r.Context(), err); verr != nil {
+		h.writeError(w, verr)
+		return
+	}
+	logging.FromContext(r.Context()).Error("error changing club role", "error", err)
+	h.writeError(w, apierror.Internal(message))
+}
+
+func (h *ClubRoleHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *ClubRoleHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ClubRoleHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
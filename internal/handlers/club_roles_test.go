@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestClubRoles(t *testing.T) {
+	mem := store.NewMemory()
+	handler := NewClubRoleHandler(mem.Stores().ClubRoles)
+	router := chi.NewRouter()
+	router.Get("/club/{clubId}/roles", handler.ListRoles)
+	router.Post("/club/{clubId}/roles", handler.CreateRole)
+	router.Put("/club/{clubId}/roles/{roleId}", handler.UpdateRole)
+	router.Delete("/club/{clubId}/roles/{roleId}", handler.DeleteRole)
+
+	clubID, userID := uuid.New(), uuid.New()
+	path := "/club/" + clubID.String() + "/roles"
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := auth.NewContext(req.Context(), auth.Principal{UserID: userID})
+		ctx = authz.NewContext(ctx, authz.Membership{ClubID: clubID, Role: authz.RoleOwner})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req.WithContext(ctx))
+		return w
+	}
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"name": "host", "capabilities": ["manage_events", "manage_items", "manage_events"]}`, http.StatusCreated},
+		{`{"name": "host", "capabilities": ["manage_items"]}`, http.StatusConflict},
+		{`{"name": "moderator", "capabilities": ["manage_items"]}`, http.StatusConflict},
+		{`{"name": "Event Host", "capabilities": ["manage_items"]}`, http.StatusBadRequest},
+		{`{"name": "herald", "capabilities": ["post_everywhere"]}`, http.StatusBadRequest},
+		{`{"name": "herald", "capabilities": []}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := serve(http.MethodPost, path, tt.body); w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.body, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	var list struct {
+		Data struct {
+			Roles        []models.ClubRole `json:"roles"`
+			BuiltInRoles []builtInRole     `json:"builtInRoles"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(serve(http.MethodGet, path, "").Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Data.Roles) != 1 || len(list.Data.Roles[0].Capabilities) != 2 || len(list.Data.BuiltInRoles) != 6 {
+		t.Fatalf("Unexpected roles: %+v", list.Data)
+	}
+	host := list.Data.Roles[0]
+
+	if w := serve(http.MethodPut, path+"/"+host.ID.String(), `{"capabilities": ["post_announcements"]}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 updating the role, got %d: %s", w.Code, w.Body.String())
+	}
+	if role, _ := mem.Stores().ClubRoles.Get(context.Background(), clubID, "host"); role == nil || len(role.Capabilities) != 1 || role.Capabilities[0] != "post_announcements" {
+		t.Errorf("Expected the capabilities replaced, got %+v", role)
+	}
+	if w := serve(http.MethodPut, path+"/"+uuid.New().String(), `{"capabilities": ["manage_items"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 updating an unknown role, got %d", w.Code)
+	}
+
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: uuid.New(), Role: "host", IsActive: true})
+	if w := serve(http.MethodDelete, path+"/"+host.ID.String(), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 deleting a role members hold, got %d", w.Code)
+	}
+}
+
+func TestClubRolesRefuseEscalation(t *testing.T) {
+	mem := store.NewMemory()
+	handler := NewClubRoleHandler(mem.Stores().ClubRoles)
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/roles", handler.CreateRole)
+	router.Put("/club/{clubId}/roles/{roleId}", handler.UpdateRole)
+	router.Delete("/club/{clubId}/roles/{roleId}", handler.DeleteRole)
+
+	clubID := uuid.New()
+	path := "/club/" + clubID.String() + "/roles"
+	bursar := &models.ClubRole{ID: uuid.New(), ClubID: clubID, Name: "bursar", Capabilities: models.StringArray{"manage_dues"}}
+	greeter := &models.ClubRole{ID: uuid.New(), ClubID: clubID, Name: "greeter", Capabilities: models.StringArray{"manage_members"}}
+	for _, role := range []*models.ClubRole{bursar, greeter} {
+		if err := mem.Stores().ClubRoles.Create(context.Background(), role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moderator := authz.Membership{ClubID: clubID, Role: authz.RoleModerator}
+	greeterMember := authz.Membership{ClubID: clubID, Role: "greeter", Capabilities: []authz.Capability{authz.ManageMembers}}
+
+	tests := []struct {
+		name       string
+		membership authz.Membership
+		method     string
+		path       string
+		body       string
+		expected   int
+	}{
+		{"moderator grants dues", moderator, http.MethodPost, path, `{"name": "collector", "capabilities": ["manage_dues"]}`, http.StatusForbidden},
+		{"member manager grants club", greeterMember, http.MethodPost, path, `{"name": "deputy", "capabilities": ["manage_members", "manage_club"]}`, http.StatusForbidden},
+		{"member manager extends a role", greeterMember, http.MethodPut, path + "/" + greeter.ID.String(), `{"capabilities": ["manage_members", "manage_dues"]}`, http.StatusForbidden},
+		{"moderator changes a dues role", moderator, http.MethodPut, path + "/" + bursar.ID.String(), `{"capabilities": ["manage_events"]}`, http.StatusForbidden},
+		{"moderator deletes a dues role", moderator, http.MethodDelete, path + "/" + bursar.ID.String(), ``, http.StatusForbidden},
+		{"member manager grants what they hold", greeterMember, http.MethodPost, path, `{"name": "doorkeeper", "capabilities": ["manage_members"]}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req.WithContext(authz.NewContext(ctx, tt.membership)))
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	if role, _ := mem.Stores().ClubRoles.Get(context.Background(), clubID, "bursar"); role == nil || role.Capabilities[0] != "manage_dues" {
+		t.Errorf("Expected the dues role unchanged, got %+v", role)
+	}
+}
+
+func TestCheckRoleChange(t *testing.T) {
+	clubID, callerID := uuid.New(), uuid.New()
+	moderator := authz.Membership{ClubID: clubID, Role: authz.RoleModerator}
+	greeter := authz.Membership{ClubID: clubID, Role: "greeter", Capabilities: []authz.Capability{authz.ManageMembers}}
+	self := &models.ClubMember{ClubID: clubID, UserID: callerID, Role: authz.RoleModerator}
+	other := &models.ClubMember{ClubID: clubID, UserID: uuid.New(), Role: "member"}
+	moderatorMember := &models.ClubMember{ClubID: clubID, UserID: uuid.New(), Role: authz.RoleModerator}
+
+	tests := []struct {
+		name        string
+		membership  authz.Membership
+		member      *models.ClubMember
+		changesRole bool
+		allowed     bool
+	}{
+		{"moderator promotes themselves", moderator, self, true, false},
+		{"moderator deactivates themselves", moderator, self, false, true},
+		{"moderator changes a member", moderator, other, true, true},
+		{"member manager demotes a moderator", greeter, moderatorMember, true, false},
+		{"member manager deactivates a moderator", greeter, moderatorMember, false, false},
+		{"member manager changes a member", greeter, other, true, true},
+	}
+	for _, tt := range tests {
+		ctx := auth.NewContext(context.Background(), auth.Principal{UserID: callerID})
+		ctx = authz.NewContext(ctx, tt.membership)
+		err := checkRoleChange(ctx, tt.member, authz.BuiltInRoles[tt.member.Role], tt.changesRole)
+		if (err == nil) != tt.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", tt.name, tt.allowed, err)
+		}
+	}
+
+	// Giving a role goes through the same grant check as defining one
+	ctx := authz.NewContext(context.Background(), greeter)
+	if err := checkGrant(ctx, authz.BuiltInRoles[authz.RoleTreasurer]); err == nil || err.Status != http.StatusForbidden {
+		t.Errorf("Expected a member manager to be refused making a treasurer, got %v", err)
+	}
+	if err := checkGrant(ctx, authz.BuiltInRoles["member"]); err != nil {
+		t.Errorf("Expected a member manager to make members, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/changes"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// changeLog is the part of changes.Log the stream handler uses
+type changeLog interface {
+	Since(ctx context.Context, clubID uuid.UUID, after int64, limit int) ([]changes.Change, error)
+	Bounds(ctx context.Context) (oldest, latest int64, err error)
+}
+
+// changeRecorder is the part of changes.Log handlers record changes with
+type changeRecorder interface {
+	Record(ctx context.Context, clubID uuid.UUID, typ string, eventID, entityID uuid.UUID) error
+}
+
+const (
+	// streamDuration is how long one stream stays open; well below the
+	// router's request timeout. Clients reconnect with Last-Event-ID.
+	streamDuration = 50 * time.Second
+	// streamHeartbeat is how often an idle stream sends a comment, so proxies
+	// do not close it
+	streamHeartbeat = 15 * time.Second
+	// streamRecheck is how often a stream reads the log anyway, to find
+	// changes recorded by other instances, whose hubs it does not hear
+	streamRecheck = 5 * time.Second
+	// streamBatch bounds how many changes one read of the log returns
+	streamBatch = 100
+	// streamRetry is the reconnection delay, in milliseconds, sent to clients
+	streamRetry = 1000
+)
+
+// recordChange adds a change to the club's log for its streams. The change
+// itself is already saved, so a failure is logged rather than returned.
+func recordChange(ctx context.Context, recorder changeRecorder, clubID uuid.UUID, typ string, eventID, entityID uuid.UUID) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Record(ctx, clubID, typ, eventID, entityID); err != nil {
+		logging.FromContext(ctx).Error("error recording change", "type", typ, "error", err)
+	}
+}
+
+// ClubStreamHandler streams a club's changes as Server-Sent Events, for
+// clients that cannot hold a WebSocket open through their proxy
+type ClubStreamHandler struct {
+	clocked
+
+	log       changeLog
+	hub       *notify.Hub
+	duration  time.Duration
+	heartbeat time.Duration
+	recheck   time.Duration
+}
+
+func NewClubStreamHandler(log changeLog, hub *notify.Hub) *ClubStreamHandler {
+	return &ClubStreamHandler{log: log, hub: hub, duration: streamDuration, heartbeat: streamHeartbeat, recheck: streamRecheck}
+}
+
+// StreamChanges sends the club's changes as they are recorded: one SSE
+// event per change, named by its type, with the change number as its id.
+// A client reconnecting with Last-Event-ID (or ?lastEventId=) first gets
+// what it missed, or a reset event when the log no longer goes back that
+// far. Without one, the stream starts from now.
+func (h *ClubStreamHandler) StreamChanges(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var cursor int64
+	if lastEventID != "" {
+		cursor, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || cursor < 0 {
+			h.writeError(w, apierror.Validation("Invalid Last-Event-ID", models.InvalidField("lastEventId", "integer", "must be the id of an event from this stream")))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, apierror.New(apierror.CodeStreamingUnsupported, "Streaming is not supported on this connection", nil))
+		return
+	}
+
+	// Subscribe before the first read, so nothing recorded in between is missed
+	wake, unsubscribe, err := h.hub.Subscribe(clubID)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(pollRetryAfter))
+		if errors.Is(err, notify.ErrUserWaiting) {
+			h.writeError(w, apierror.New(apierror.CodeTooManyStreams, "Too many streams are open for this club", nil))
+			return
+		}
+		h.writeError(w, apierror.New(apierror.CodeStreamingUnavailable, "Too many clients are streaming; please try again shortly", nil))
+		return
+	}
+	defer unsubscribe()
+
+	oldest, latest, err := h.log.Bounds(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error reading change log", "error", err)
+		h.writeError(w, apierror.Internal("Failed to open stream"))
+		return
+	}
+	reset := false
+	switch {
+	case lastEventID == "":
+		cursor = latest
+	case oldest > 0 && cursor < oldest-1:
+		// Changes the client has not seen were pruned
+		reset, cursor = true, latest
+	}
+
+	// The stream outlives the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(h.now().Add(h.duration + 10*time.Second))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if reset {
+		fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", cursor)
+	}
+	flusher.Flush()
+
+	end := time.NewTimer(h.duration)
+	defer end.Stop()
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	recheck := time.NewTicker(h.recheck)
+	defer recheck.Stop()
+
+	for {
+		batch, err := h.log.Since(r.Context(), clubID, cursor, streamBatch)
+		if err != nil {
+			if r.Context().Err() == nil {
+				logging.FromContext(r.Context()).Error("error reading change log", "error", err)
+			}
+			return
+		}
+		for _, change := range batch {
+			data, _ := json.Marshal(change)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+			cursor = change.ID
+		}
+		if len(batch) > 0 {
+			flusher.Flush()
+		}
+		if len(batch) == streamBatch {
+			continue
+		}
+
+		select {
+		case <-wake:
+		case <-recheck.C:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-end.C:
+			return
+		case <-h.hub.Done():
+			// Shutting down; the client reconnects elsewhere
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *ClubStreamHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
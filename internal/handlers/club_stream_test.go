@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/changes"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeChangeLog keeps changes in memory, numbered from oldest
+type fakeChangeLog struct {
+	mu      sync.Mutex
+	oldest  int64
+	changes []changes.Change
+	hub     *notify.Hub
+}
+
+func (f *fakeChangeLog) Record(ctx context.Context, clubID uuid.UUID, typ string, eventID, entityID uuid.UUID) error {
+	f.mu.Lock()
+	id := f.oldest + int64(len(f.changes))
+	f.changes = append(f.changes, changes.Change{ID: id, Type: typ, ClubID: clubID, EventID: eventID, EntityID: entityID})
+	f.mu.Unlock()
+	f.hub.Publish([]uuid.UUID{clubID})
+	return nil
+}
+
+func (f *fakeChangeLog) Since(ctx context.Context, clubID uuid.UUID, after int64, limit int) ([]changes.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := []changes.Change{}
+	for _, c := range f.changes {
+		if c.ClubID == clubID && c.ID > after && len(list) < limit {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeChangeLog) Bounds(ctx context.Context) (int64, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.changes) == 0 {
+		return 0, 0, nil
+	}
+	return f.oldest, f.oldest + int64(len(f.changes)) - 1, nil
+}
+
+func setupClubStreamTest(oldest int64) (*fakeChangeLog, chi.Router) {
+	hub := notify.NewHub(10, 2)
+	log := &fakeChangeLog{oldest: oldest, hub: hub}
+	handler := NewClubStreamHandler(log, hub)
+	handler.duration = 200 * time.Millisecond
+
+	router := chi.NewRouter()
+	router.Get("/club/{clubId}/stream", handler.StreamChanges)
+	return log, router
+}
+
+func TestStreamChangesResumes(t *testing.T) {
+	log, router := setupClubStreamTest(1)
+	clubID, eventID := uuid.New(), uuid.New()
+	log.Record(context.Background(), clubID, changes.EventCreated, eventID, eventID)
+	log.Record(context.Background(), uuid.New(), changes.EventCreated, eventID, eventID)
+	log.Record(context.Background(), clubID, changes.ItemCreated, eventID, uuid.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	w := httptest.NewRecorder()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		log.Record(context.Background(), clubID, changes.AvailabilityUpdated, eventID, uuid.New())
+	}()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "id: 1\n") || strings.Contains(body, "id: 2\n") {
+		t.Errorf("Expected only changes to this club after the last one seen, got %s", body)
+	}
+	if !strings.Contains(body, "id: 3\nevent: item.created\n") {
+		t.Errorf("Expected the missed item change, got %s", body)
+	}
+	if !strings.Contains(body, "id: 4\nevent: availability.updated\n") {
+		t.Errorf("Expected the change recorded while streaming, got %s", body)
+	}
+}
+
+func TestStreamChangesReset(t *testing.T) {
+	log, router := setupClubStreamTest(10)
+	clubID, eventID := uuid.New(), uuid.New()
+	log.Record(context.Background(), clubID, changes.EventUpdated, eventID, eventID)
+
+	tests := []struct {
+		lastEventID string
+		expected    int
+		reset       bool
+	}{
+		{"abc", http.StatusBadRequest, false},
+		{"-1", http.StatusBadRequest, false},
+		{"3", http.StatusOK, true},
+		{"9", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/stream?lastEventId="+tt.lastEventID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("Last-Event-ID %s: expected status %d, got %d", tt.lastEventID, tt.expected, w.Code)
+		}
+		if got := strings.Contains(w.Body.String(), "event: reset\n"); got != tt.reset {
+			t.Errorf("Last-Event-ID %s: expected reset %v, got %s", tt.lastEventID, tt.reset, w.Body.String())
+		}
+	}
+}
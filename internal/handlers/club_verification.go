@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// verificationRequestColumns are scanned by scanVerificationRequest
+const verificationRequestColumns = `
+	v.id, v.club_id, c.name, v.requested_by, v.verification_type, v.organization, v.website, v.details,
+	v.status, v.review_note, v.reviewed_by, v.reviewed_at, v.created_at`
+
+// verificationStatuses are the accepted status filters of the review queue
+var verificationStatuses = map[string]bool{"pending": true, "approved": true, "rejected": true}
+
+// ApplyForVerification asks platform admins to verify the club as a library,
+// bookstore or official partner. A club has one application under review at a time.
+func (h *ClubHandler) ApplyForVerification(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.ApplyForVerificationRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var verification *string
+	var isSandbox bool
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT verification_type, COALESCE(is_sandbox, false) FROM clubs WHERE id = $1 AND deleted_at IS NULL`,
+		clubID).Scan(&verification, &isSandbox)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club", "error", err)
+		h.writeError(w, apierror.Internal("Failed to apply for verification"))
+		return
+	}
+	if isSandbox {
+		h.writeError(w, apierror.Forbidden("Sandbox clubs cannot be verified"))
+		return
+	}
+	if verification != nil {
+		h.writeError(w, apierror.New(apierror.CodeAlreadyVerified, "This club is already verified", nil))
+		return
+	}
+
+	application := models.ClubVerificationRequest{
+		ID:           uuid.New(),
+		ClubID:       clubID,
+		RequestedBy:  &userID,
+		Type:         req.Type,
+		Organization: req.Organization,
+		Website:      req.Website,
+		Details:      req.Details,
+		Status:       "pending",
+		CreatedAt:    h.now(),
+	}
+
+	query := `
+		INSERT INTO club_verification_requests
+			(id, club_id, requested_by, verification_type, organization, website, details, status, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, 'pending', $8
+		WHERE NOT EXISTS (SELECT 1 FROM club_verification_requests WHERE club_id = $2 AND status = 'pending')`
+
+	result, err := h.db.ExecContext(r.Context(), query, application.ID, clubID, userID, application.Type,
+		application.Organization, application.Website, application.Details, application.CreatedAt)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating verification request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to apply for verification"))
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		h.writeError(w, apierror.Conflict("This club already has a verification request under review"))
+		return
+	}
+	audit.Describe(r.Context(), "club_verification_request", application.ID.String(), audit.Diff(nil, req))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"request": application}, "Verification requested successfully")
+}
+
+// GetVerification returns the club's badge and its most recent application
+func (h *ClubHandler) GetVerification(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	var verification *string
+	err = h.db.QueryRowContext(r.Context(), `SELECT verification_type FROM clubs WHERE id = $1`, clubID).Scan(&verification)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club verification", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get verification"))
+		return
+	}
+
+	query := `SELECT` + verificationRequestColumns + `
+		FROM club_verification_requests v
+		JOIN clubs c ON c.id = v.club_id
+		WHERE v.club_id = $1
+		ORDER BY v.created_at DESC
+		LIMIT 1`
+
+	latest, err := scanVerificationRequest(h.db.QueryRowContext(r.Context(), query, clubID))
+	if err != nil && err != sql.ErrNoRows {
+		logging.FromContext(r.Context()).Error("error getting verification request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get verification"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"verification":  verification,
+		"latestRequest": latest,
+	}
+
+	h.writeSuccessResponse(w, response, "Verification retrieved successfully")
+}
+
+// ListVerificationRequests is the admin review queue, oldest first. It shows
+// pending applications unless another status is asked for.
+func (h *ClubHandler) ListVerificationRequests(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if !verificationStatuses[status] {
+		h.writeError(w, apierror.Validation("Invalid status. Use pending, approved, or rejected", models.InvalidField("status", "oneof", "must be one of: pending, approved, rejected")))
+		return
+	}
+
+	query := `SELECT` + verificationRequestColumns + `
+		FROM club_verification_requests v
+		JOIN clubs c ON c.id = v.club_id
+		WHERE v.status = $1 AND c.deleted_at IS NULL
+		ORDER BY v.created_at ASC
+		LIMIT 200`
+
+	rows, err := h.db.QueryContext(r.Context(), query, status)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying verification requests", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get verification requests"))
+		return
+	}
+	defer rows.Close()
+
+	requests := []models.ClubVerificationRequest{}
+	for rows.Next() {
+		application, err := scanVerificationRequest(rows)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning verification request", "error", err)
+			continue
+		}
+		requests = append(requests, *application)
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"requests": requests}, "Verification requests retrieved successfully")
+}
+
+func (h *ClubHandler) ApproveVerificationRequest(w http.ResponseWriter, r *http.Request) {
+	h.reviewVerificationRequest(w, r, true)
+}
+
+func (h *ClubHandler) RejectVerificationRequest(w http.ResponseWriter, r *http.Request) {
+	h.reviewVerificationRequest(w, r, false)
+}
+
+// reviewVerificationRequest decides a pending application. Approving gives the
+// club the badge of the type applied for.
+func (h *ClubHandler) reviewVerificationRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid request ID", models.InvalidID("requestId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// The note is optional
+	var req models.ReviewVerificationRequest
+	if err := decodeOptionalJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	status := "rejected"
+	if approve {
+		status = "approved"
+	}
+
+	tx, err := h.db.BeginTx(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error beginning transaction", "error", err)
+		h.writeError(w, apierror.Internal("Failed to review verification request"))
+		return
+	}
+	defer tx.Rollback()
+
+	var clubID uuid.UUID
+	var verificationType string
+	err = tx.QueryRowContext(r.Context(), `
+		UPDATE club_verification_requests
+		SET status = $1, review_note = $2, reviewed_by = $3, reviewed_at = $4
+		WHERE id = $5 AND status = 'pending'
+		RETURNING club_id, verification_type`,
+		status, req.Note, userID, h.now(), requestID,
+	).Scan(&clubID, &verificationType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Pending verification request not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reviewing verification request", "error", err)
+		h.writeError(w, apierror.Internal("Failed to review verification request"))
+		return
+	}
+
+	if approve {
+		if err := setClubVerification(r.Context(), tx, clubID, &verificationType, h.now()); err != nil {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error verifying club", "error", err)
+			h.writeError(w, apierror.Internal("Failed to review verification request"))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.FromContext(r.Context()).Error("error committing verification review", "error", err)
+		h.writeError(w, apierror.Internal("Failed to review verification request"))
+		return
+	}
+	audit.Describe(r.Context(), "club_verification_request", requestID.String(), audit.Changes{"status": {From: "pending", To: status}})
+
+	h.notifyVerification(r.Context(), clubID, approve, verificationType, req.Note)
+
+	response := map[string]interface{}{
+		"request": map[string]interface{}{
+			"id":     requestID,
+			"clubId": clubID,
+			"status": status,
+		},
+	}
+
+	h.writeSuccessResponse(w, response, "Verification request "+status)
+}
+
+// SetVerification verifies a club directly, for partners onboarded outside the
+// application flow, or changes the kind of badge it has
+func (h *ClubHandler) SetVerification(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	var req models.SetClubVerificationRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.updateVerification(w, r, clubID, &req.Type)
+}
+
+// RemoveVerification takes a club's badge away
+func (h *ClubHandler) RemoveVerification(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.updateVerification(w, r, clubID, nil)
+}
+
+func (h *ClubHandler) updateVerification(w http.ResponseWriter, r *http.Request, clubID uuid.UUID, verificationType *string) {
+	var before *string
+	err := h.db.QueryRowContext(r.Context(),
+		`SELECT verification_type FROM clubs WHERE id = $1 AND deleted_at IS NULL AND COALESCE(is_sandbox, false) = false`,
+		clubID).Scan(&before)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting club verification", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update verification"))
+		return
+	}
+
+	if err := setClubVerification(r.Context(), h.db, clubID, verificationType, h.now()); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating club verification", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update verification"))
+		return
+	}
+	audit.Describe(r.Context(), "club", clubID.String(), audit.Changes{"verification": {From: before, To: verificationType}})
+
+	response := map[string]interface{}{
+		"clubId":       clubID,
+		"verification": verificationType,
+	}
+
+	h.writeSuccessResponse(w, response, "Verification updated successfully")
+}
+
+// setClubVerification sets the club's badge, or removes it when verificationType is nil
+func setClubVerification(ctx context.Context, db execer, clubID uuid.UUID, verificationType *string, now time.Time) error {
+	var verifiedAt *time.Time
+	if verificationType != nil {
+		verifiedAt = &now
+	}
+	_, err := db.ExecContext(ctx,
+		`UPDATE clubs SET verification_type = $1, verified_at = $2, updated_at = NOW() WHERE id = $3`,
+		verificationType, verifiedAt, clubID)
+	return err
+}
+
+// notifyVerification tells the club owner how their application was decided.
+// Failures are logged; the decision is already recorded.
+func (h *ClubHandler) notifyVerification(ctx context.Context, clubID uuid.UUID, approved bool, verificationType string, note *string) {
+	if h.notifier == nil {
+		return
+	}
+
+	notification := models.Notification{
+		Type:  notify.TypeClubVerification,
+		Title: "Your club is now verified",
+		Body:  fmt.Sprintf("Your club now shows the verified %s badge.", verificationType),
+	}
+	if !approved {
+		notification.Title = "Your verification request was declined"
+		notification.Body = "Your club's verification request was not approved."
+	}
+	if note != nil && *note != "" {
+		notification.Body += " " + *note
+	}
+	notification.ClubID = &clubID
+
+	if _, err := h.notifier.NotifyClubOwner(ctx, clubID, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying club owner of verification", "error", err)
+	}
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVerificationRequest(row rowScanner) (*models.ClubVerificationRequest, error) {
+	var v models.ClubVerificationRequest
+	err := row.Scan(
+		&v.ID, &v.ClubID, &v.ClubName, &v.RequestedBy, &v.Type, &v.Organization, &v.Website, &v.Details,
+		&v.Status, &v.ReviewNote, &v.ReviewedBy, &v.ReviewedAt, &v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestVerificationValidation(t *testing.T) {
+	handler := NewClubHandler(database.NewMock())
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/verification", handler.ApplyForVerification)
+	router.Get("/admin/verification-requests", handler.ListVerificationRequests)
+	router.Post("/admin/verification-requests/{requestId}/approve", handler.ApproveVerificationRequest)
+	router.Put("/admin/clubs/{clubId}/verification", handler.SetVerification)
+
+	clubPath := "/club/" + uuid.New().String() + "/verification"
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"POST", "/club/not-a-uuid/verification", `{"type":"library","organization":"City Library"}`, http.StatusBadRequest},
+		{"POST", clubPath, `{"type":"cafe","organization":"Corner Cafe"}`, http.StatusBadRequest},
+		{"POST", clubPath, `{"type":"bookstore"}`, http.StatusBadRequest},
+		{"POST", clubPath, `{"type":"library","organization":"City Library","website":"not a url"}`, http.StatusBadRequest},
+		{"GET", "/admin/verification-requests?status=waiting", "", http.StatusBadRequest},
+		{"POST", "/admin/verification-requests/not-a-uuid/approve", "", http.StatusBadRequest},
+		{"PUT", "/admin/clubs/" + uuid.New().String() + "/verification", `{"type":"sponsor"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s %s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.body, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/webhooks"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxWebhookDeliveries bounds how many delivery attempts one read of the log returns
+const maxWebhookDeliveries = 100
+
+// clubWebhooks is the part of webhooks.Store the webhook handler uses
+type clubWebhooks interface {
+	List(ctx context.Context, clubID uuid.UUID) ([]webhooks.Webhook, error)
+	Create(ctx context.Context, clubID uuid.UUID, url string, events []string, createdBy uuid.UUID) (webhooks.Webhook, error)
+	Update(ctx context.Context, clubID, webhookID uuid.UUID, update webhooks.Update) (webhooks.Webhook, error)
+	Delete(ctx context.Context, clubID, webhookID uuid.UUID) error
+	Deliveries(ctx context.Context, clubID, webhookID uuid.UUID, limit int) ([]webhooks.Delivery, error)
+}
+
+// webhookQueue is the part of webhooks.Store that sends club events to
+// webhooks: with a change written in tx, or after one already committed
+type webhookQueue interface {
+	Queue(ctx context.Context, tx *sql.Tx, clubID uuid.UUID, eventType string, data interface{}) error
+	Publish(ctx context.Context, clubID uuid.UUID, eventType string, data interface{}) error
+}
+
+// ClubWebhookHandler lets club managers register endpoints that receive the
+// club's events and read how deliveries to them went
+type ClubWebhookHandler struct {
+	clocked
+
+	webhooks clubWebhooks
+	insecure bool // accept http URLs, for local development
+}
+
+func NewClubWebhookHandler(webhooks clubWebhooks, insecure bool) *ClubWebhookHandler {
+	return &ClubWebhookHandler{webhooks: webhooks, insecure: insecure}
+}
+
+// ListWebhooks returns the club's webhooks, without their secrets
+func (h *ClubWebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.webhooks.List(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying webhooks", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get webhooks"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"webhooks": list, "eventTypes": webhooks.EventTypes}, "Webhooks retrieved successfully")
+}
+
+// CreateWebhook registers an endpoint. Its signing secret is only ever
+// returned here; a lost secret means registering the endpoint again.
+func (h *ClubWebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := h.checkURL(req.URL); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	created, err := h.webhooks.Create(r.Context(), clubID, req.URL, dedupe(req.Events), userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating webhook", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create webhook"))
+		return
+	}
+	audit.Describe(r.Context(), "club_webhook", created.ID.String(), audit.Diff(nil, req))
+
+	response := map[string]interface{}{
+		"webhook": created,
+		"secret":  created.Secret,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Webhook created successfully")
+}
+
+// UpdateWebhook changes a webhook's URL or events, or pauses and resumes it
+func (h *ClubWebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID, webhookID, ok := h.clubWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.URL != nil {
+		trimmed := strings.TrimSpace(*req.URL)
+		req.URL = &trimmed
+	}
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.URL == nil && req.Events == nil && req.IsActive == nil {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+	if req.URL != nil {
+		if err := h.checkURL(*req.URL); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	updated, err := h.webhooks.Update(r.Context(), clubID, webhookID, webhooks.Update{
+		URL:      req.URL,
+		Events:   dedupe(req.Events),
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		h.writeWebhookError(w, r, err, "Failed to update webhook")
+		return
+	}
+	audit.Describe(r.Context(), "club_webhook", webhookID.String(), audit.Diff(nil, req))
+
+	h.writeSuccessResponse(w, map[string]interface{}{"webhook": updated}, "Webhook updated successfully")
+}
+
+// DeleteWebhook removes a webhook with its delivery log
+func (h *ClubWebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID, webhookID, ok := h.clubWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.Delete(r.Context(), clubID, webhookID); err != nil {
+		h.writeWebhookError(w, r, err, "Failed to delete webhook")
+		return
+	}
+	audit.Describe(r.Context(), "club_webhook", webhookID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Webhook deleted successfully"}, "Webhook deleted successfully")
+}
+
+// GetDeliveries returns the latest delivery attempts to a webhook, newest
+// first: ?limit= of them, 50 by default and at most 100
+func (h *ClubWebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	clubID, webhookID, ok := h.clubWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit > maxWebhookDeliveries {
+		limit = 50
+	}
+
+	deliveries, err := h.webhooks.Deliveries(r.Context(), clubID, webhookID, limit)
+	if err != nil {
+		h.writeWebhookError(w, r, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"deliveries": deliveries}, "Webhook deliveries retrieved successfully")
+}
+
+// checkURL rejects URLs deliveries could not or must not be sent to
+func (h *ClubWebhookHandler) checkURL(url string) *apierror.Error {
+	if err := webhooks.ValidateURL(url, h.insecure); err != nil {
+		return invalidField("url", "url", err.Error(), "url "+err.Error())
+	}
+	return nil
+}
+
+// dedupe drops repeated event types, keeping the first of each
+func dedupe(events []string) []string {
+	if events == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return unique
+}
+
+func (h *ClubWebhookHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *ClubWebhookHandler) clubWebhook(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid webhook ID", models.InvalidID("webhookId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, webhookID, true
+}
+
+func (h *ClubWebhookHandler) writeWebhookError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, webhooks.ErrNotFound) {
+		h.writeError(w, apierror.NotFound("Webhook not found"))
+		return
+	}
+	if verr := violationError(r.Context(), err); verr != nil {
+		h.writeError(w, verr)
+		return
+	}
+	logging.FromContext(r.Context()).Error("error changing webhook", "error", err)
+	h.writeError(w, apierror.Internal(message))
+}
+
+func (h *ClubWebhookHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *ClubWebhookHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ClubWebhookHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/contributions"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// ContributionHandler serves one-off contributions towards an event cost: the
+// goal, progress towards it and the contributor list. It runs behind
+// authz.RequireEventRole, which loads the event.
+type ContributionHandler struct {
+	clocked
+
+	ledger contributionLedger
+	clubs  store.ClubStore
+}
+
+// contributionLedger stores goals and contributions; *contributions.Ledger implements it
+type contributionLedger interface {
+	Goal(ctx context.Context, eventID uuid.UUID) (*contributions.Goal, error)
+	SetGoal(ctx context.Context, goal *contributions.Goal) error
+	RemoveGoal(ctx context.Context, eventID uuid.UUID) error
+	Record(ctx context.Context, contribution *contributions.Contribution) error
+	List(ctx context.Context, eventID uuid.UUID, limit, offset int) ([]contributions.Contribution, error)
+	Progress(ctx context.Context, eventID uuid.UUID) (*contributions.Goal, contributions.Progress, error)
+}
+
+func NewContributionHandler(ledger contributionLedger, clubs store.ClubStore) *ContributionHandler {
+	return &ContributionHandler{ledger: ledger, clubs: clubs}
+}
+
+// GetProgress returns the event's goal, how much has been raised and, when the
+// goal has a payment link, the caller's donation links
+func (h *ContributionHandler) GetProgress(w http.ResponseWriter, r *http.Request) {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.NotFound("Event not found"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	goal, progress, err := h.ledger.Progress(r.Context(), event.ID)
+	if err != nil {
+		if err == contributions.ErrNoGoal {
+			h.writeError(w, apierror.NotFound("The event is not raising contributions"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting contribution progress", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get contribution progress"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"goal":     goal,
+		"progress": progress,
+	}
+
+	// Links carry the caller as the client reference so Stripe payments are attributed
+	if goal.PaymentLink != nil {
+		named, err := contributions.DonationLink(*goal.PaymentLink, userID, false)
+		if err == nil {
+			anonymous, _ := contributions.DonationLink(*goal.PaymentLink, userID, true)
+			response["donationLink"] = named
+			response["anonymousDonationLink"] = anonymous
+		}
+	}
+
+	h.writeSuccessResponse(w, response, "Contribution progress retrieved successfully")
+}
+
+// GetContributions lists the event's contributions, newest first. Fundraisers
+// see every contributor; other members see anonymous contributions without one.
+func (h *ContributionHandler) GetContributions(w http.ResponseWriter, r *http.Request) {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.NotFound("Event not found"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	list, err := h.ledger.List(r.Context(), event.ID, limit, (page-1)*limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying contributions", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get contributions"))
+		return
+	}
+
+	if !authz.Can(r.Context(), authz.ManageContributions) {
+		for i := range list {
+			list[i] = list[i].Redacted()
+		}
+	}
+
+	response := map[string]interface{}{
+		"contributions": list,
+		"page":          page,
+		"limit":         limit,
+	}
+
+	h.writeSuccessResponse(w, response, "Contributions retrieved successfully")
+}
+
+// UpdateGoal sets the event's target in the club currency; a null target removes the goal
+func (h *ContributionHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.NotFound("Event not found"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !authz.Can(r.Context(), authz.ManageContributions) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.UpdateContributionGoalRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if req.Target == nil {
+		if err := h.ledger.RemoveGoal(r.Context(), event.ID); err != nil && err != contributions.ErrNoGoal {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error removing contribution goal", "error", err)
+			h.writeError(w, apierror.Internal("Failed to update contribution goal"))
+			return
+		}
+		audit.Describe(r.Context(), "event", event.ID.String(), nil)
+		h.writeSuccessResponse(w, map[string]interface{}{"goal": nil}, "Contribution goal removed successfully")
+		return
+	}
+
+	currency, err := h.clubs.Currency(r.Context(), event.ClubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting club currency", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update contribution goal"))
+		return
+	}
+
+	target, derr := parsePaymentAmount("target", *req.Target, currency)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	if req.PaymentLink != nil {
+		link := strings.TrimSpace(*req.PaymentLink)
+		if !contributions.ValidPaymentLink(link) {
+			h.writeError(w, apierror.Validation("Payment link must be an https URL", models.InvalidField("paymentLink", "https_url", "must be an https URL")))
+			return
+		}
+		req.PaymentLink = &link
+	}
+
+	before, err := h.ledger.Goal(r.Context(), event.ID)
+	if err != nil && err != contributions.ErrNoGoal {
+		logging.FromContext(r.Context()).Error("error getting contribution goal", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update contribution goal"))
+		return
+	}
+
+	goal := &contributions.Goal{
+		EventID:     event.ID,
+		Target:      target,
+		Description: req.Description,
+		PaymentLink: req.PaymentLink,
+		CreatedBy:   &userID,
+	}
+	if err := h.ledger.SetGoal(r.Context(), goal); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error setting contribution goal", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update contribution goal"))
+		return
+	}
+	audit.Describe(r.Context(), "event", event.ID.String(), audit.Diff(before, goal))
+
+	response := map[string]interface{}{
+		"goal": goal,
+	}
+
+	h.writeSuccessResponse(w, response, "Contribution goal updated successfully")
+}
+
+// RecordContribution records a contribution taken outside the API, such as cash
+// at the door, in the goal currency
+func (h *ContributionHandler) RecordContribution(w http.ResponseWriter, r *http.Request) {
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.NotFound("Event not found"))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !authz.Can(r.Context(), authz.ManageContributions) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.RecordContributionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+		if name == "" {
+			req.Name = nil
+		}
+	}
+	if (req.UserID == nil) == (req.Name == nil) {
+		h.writeError(w, apierror.Validation("Give either the contributing member's userId or a contributor name", models.FieldErrorDetails(
+			models.FieldError{Field: "userId", Code: "required_without", Message: "give either userId or name"},
+			models.FieldError{Field: "name", Code: "required_without", Message: "give either userId or name"},
+		)))
+		return
+	}
+
+	goal, err := h.ledger.Goal(r.Context(), event.ID)
+	if err != nil {
+		if err == contributions.ErrNoGoal {
+			h.writeError(w, apierror.New(apierror.CodeNoContributionGoal, "The event is not raising contributions", nil))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting contribution goal", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record contribution"))
+		return
+	}
+
+	amount, derr := parsePaymentAmount("amount", req.Amount, goal.Target.Currency)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	if req.UserID != nil {
+		if _, err := h.clubs.MemberRole(r.Context(), event.ClubID, *req.UserID); err != nil {
+			if err == store.ErrNotFound {
+				h.writeError(w, apierror.Validation("The contributor must be a member of the club; give a name for anyone else", models.InvalidField("userId", "member", "must be a member of the club")))
+				return
+			}
+			logging.FromContext(r.Context()).Error("error checking club membership", "error", err)
+			h.writeError(w, apierror.Internal("Failed to record contribution"))
+			return
+		}
+	}
+
+	contribution := &contributions.Contribution{
+		EventID:    event.ID,
+		UserID:     req.UserID,
+		Name:       req.Name,
+		Amount:     amount,
+		Anonymous:  req.Anonymous,
+		Method:     contributions.MethodManual,
+		Notes:      req.Notes,
+		RecordedBy: &userID,
+	}
+	if err := h.ledger.Record(r.Context(), contribution); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error recording contribution", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record contribution"))
+		return
+	}
+	audit.Describe(r.Context(), "contribution", contribution.ID.String(), nil)
+
+	response := map[string]interface{}{
+		"contribution": contribution,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	h.writeSuccessResponse(w, response, "Contribution recorded successfully")
+}
+
+func (h *ContributionHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ContributionHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
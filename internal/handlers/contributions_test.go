@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/contributions"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeContributionLedger keeps one event's goal and contributions in memory
+type fakeContributionLedger struct {
+	goal          *contributions.Goal
+	contributions []contributions.Contribution
+}
+
+func (l *fakeContributionLedger) Goal(ctx context.Context, eventID uuid.UUID) (*contributions.Goal, error) {
+	if l.goal == nil {
+		return nil, contributions.ErrNoGoal
+	}
+	return l.goal, nil
+}
+
+func (l *fakeContributionLedger) SetGoal(ctx context.Context, goal *contributions.Goal) error {
+	l.goal = goal
+	return nil
+}
+
+func (l *fakeContributionLedger) RemoveGoal(ctx context.Context, eventID uuid.UUID) error {
+	l.goal = nil
+	return nil
+}
+
+func (l *fakeContributionLedger) Record(ctx context.Context, contribution *contributions.Contribution) error {
+	contribution.ID = uuid.New()
+	l.contributions = append(l.contributions, *contribution)
+	return nil
+}
+
+func (l *fakeContributionLedger) List(ctx context.Context, eventID uuid.UUID, limit, offset int) ([]contributions.Contribution, error) {
+	return append([]contributions.Contribution(nil), l.contributions...), nil
+}
+
+func (l *fakeContributionLedger) Progress(ctx context.Context, eventID uuid.UUID) (*contributions.Goal, contributions.Progress, error) {
+	if l.goal == nil {
+		return nil, contributions.Progress{}, contributions.ErrNoGoal
+	}
+	return l.goal, contributions.Progress{Target: l.goal.Target}, nil
+}
+
+type contributionTest struct {
+	ledger    *fakeContributionLedger
+	router    chi.Router
+	eventID   uuid.UUID
+	treasurer uuid.UUID
+	member    uuid.UUID
+}
+
+func setupContributionTest() *contributionTest {
+	mem := store.NewMemory()
+	tt := &contributionTest{ledger: &fakeContributionLedger{}, eventID: uuid.New(), treasurer: uuid.New(), member: uuid.New()}
+	clubID := uuid.New()
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: tt.treasurer, Role: authz.RoleTreasurer, IsActive: true})
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: tt.member, Role: "member", IsActive: true})
+	mem.PutEvent(models.Event{ID: tt.eventID, ClubID: clubID, Title: "Book Night"})
+	mem.PutClubCurrency(clubID, "EUR")
+	stores := mem.Stores()
+
+	handler := NewContributionHandler(tt.ledger, stores.Clubs)
+	tt.router = chi.NewRouter()
+	tt.router.Route("/events/{eventId}/contributions", func(r chi.Router) {
+		r.Use(authz.New(stores).RequireEventRole())
+		r.Get("/", handler.GetContributions)
+		r.Post("/", handler.RecordContribution)
+		r.Get("/progress", handler.GetProgress)
+		r.Put("/goal", handler.UpdateGoal)
+	})
+	return tt
+}
+
+func (tt *contributionTest) serve(method, path, body string, userID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/events/"+tt.eventID.String()+"/contributions"+path, bytes.NewBufferString(body))
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+	w := httptest.NewRecorder()
+	tt.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateContributionGoal(t *testing.T) {
+	tt := setupContributionTest()
+
+	tests := []struct {
+		body     string
+		userID   uuid.UUID
+		expected int
+	}{
+		{`{"target": "500"}`, tt.member, http.StatusForbidden},
+		{`{"target": "-5"}`, tt.treasurer, http.StatusBadRequest},
+		{`{"target": "500", "paymentLink": "http://buy.stripe.com/x"}`, tt.treasurer, http.StatusBadRequest},
+		{`{"target": "500", "description": "Venue rental", "paymentLink": "https://buy.stripe.com/x"}`, tt.treasurer, http.StatusOK},
+	}
+	for _, test := range tests {
+		if w := tt.serve("PUT", "/goal", test.body, test.userID); w.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.body, test.expected, w.Code)
+		}
+	}
+
+	if tt.ledger.goal == nil || tt.ledger.goal.Target != money.New(50000, "EUR") {
+		t.Fatalf("Expected a goal of 500 EUR in the club currency, got %+v", tt.ledger.goal)
+	}
+
+	w := tt.serve("GET", "/progress", "", tt.member)
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Data["donationLink"] != "https://buy.stripe.com/x?client_reference_id="+tt.member.String() {
+		t.Errorf("Expected a donation link for the caller, got %d %v", w.Code, response.Data)
+	}
+
+	// A null target stops raising contributions
+	if w := tt.serve("PUT", "/goal", `{"target": null}`, tt.treasurer); w.Code != http.StatusOK || tt.ledger.goal != nil {
+		t.Errorf("Expected the goal to be removed, got %d", w.Code)
+	}
+	if w := tt.serve("GET", "/progress", "", tt.member); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a goal, got %d", w.Code)
+	}
+}
+
+func TestRecordContribution(t *testing.T) {
+	tt := setupContributionTest()
+
+	if w := tt.serve("POST", "/", `{"name": "Grandma", "amount": "20"}`, tt.treasurer); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 without a goal, got %d", w.Code)
+	}
+	tt.ledger.goal = &contributions.Goal{EventID: tt.eventID, Target: money.New(50000, "EUR")}
+
+	tests := []struct {
+		body     string
+		userID   uuid.UUID
+		expected int
+	}{
+		{`{"name": "Grandma", "amount": "20"}`, tt.member, http.StatusForbidden},
+		{`{"amount": "20"}`, tt.treasurer, http.StatusBadRequest},
+		{`{"userId": "` + uuid.New().String() + `", "amount": "20"}`, tt.treasurer, http.StatusBadRequest},
+		{`{"name": "Grandma", "amount": "20.001"}`, tt.treasurer, http.StatusBadRequest},
+		{`{"name": "Grandma", "amount": "20"}`, tt.treasurer, http.StatusCreated},
+		{`{"userId": "` + tt.member.String() + `", "amount": "35.50", "anonymous": true}`, tt.treasurer, http.StatusCreated},
+	}
+	for _, test := range tests {
+		if w := tt.serve("POST", "/", test.body, test.userID); w.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.body, test.expected, w.Code)
+		}
+	}
+
+	if len(tt.ledger.contributions) != 2 || tt.ledger.contributions[1].Amount != money.New(3550, "EUR") {
+		t.Fatalf("Unexpected contributions: %+v", tt.ledger.contributions)
+	}
+
+	// Members do not see who contributed anonymously; treasurers do
+	list := func(userID uuid.UUID) []contributions.Contribution {
+		var response struct {
+			Data struct {
+				Contributions []contributions.Contribution `json:"contributions"`
+			} `json:"data"`
+		}
+		json.NewDecoder(tt.serve("GET", "/", "", userID).Body).Decode(&response)
+		return response.Data.Contributions
+	}
+	if got := list(tt.member); len(got) != 2 || got[1].UserID != nil || got[0].Name == nil {
+		t.Errorf("Expected the anonymous contributor to be hidden from members, got %+v", got)
+	}
+	if got := list(tt.treasurer); len(got) != 2 || got[1].UserID == nil || *got[1].UserID != tt.member {
+		t.Errorf("Expected treasurers to see every contributor, got %+v", got)
+	}
+}
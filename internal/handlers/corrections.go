@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/corrections"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// correctionStore is the part of corrections.Store the correction handler uses
+type correctionStore interface {
+	Create(ctx context.Context, c corrections.Correction) (corrections.Correction, error)
+	List(ctx context.Context, clubID uuid.UUID, filter corrections.Filter) ([]corrections.Correction, error)
+	Get(ctx context.Context, clubID, id uuid.UUID) (corrections.Correction, error)
+	Cancel(ctx context.Context, clubID, id, userID uuid.UUID) error
+	Decide(ctx context.Context, clubID, id, decidedBy uuid.UUID, approve bool, note *string) (corrections.Correction, error)
+}
+
+// userNotifier is the part of notify.Notifier that notifies a single member
+type userNotifier interface {
+	NotifyUser(ctx context.Context, userID uuid.UUID, notification models.Notification) (int, error)
+}
+
+// correctionFields names each correction kind's field in a decision's audit entry
+var correctionFields = map[string]string{
+	corrections.KindBooksRead:  "booksRead",
+	corrections.KindAttendance: "attended",
+}
+
+// CorrectionHandler lets members ask for corrections to their books read
+// count and attendance, and moderators approve or reject them
+type CorrectionHandler struct {
+	clocked
+
+	corrections correctionStore
+	notifier    userNotifier
+}
+
+func NewCorrectionHandler(store correctionStore) *CorrectionHandler {
+	return &CorrectionHandler{corrections: store}
+}
+
+// WithNotifier tells members when their corrections are decided
+func (h *CorrectionHandler) WithNotifier(notifier userNotifier) *CorrectionHandler {
+	h.notifier = notifier
+	return h
+}
+
+// CreateCorrection files a correction to one of the caller's own records
+func (h *CorrectionHandler) CreateCorrection(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CorrectionRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	correction := corrections.Correction{ClubID: clubID, UserID: userID, Kind: req.Kind, Reason: req.Reason}
+	if req.Kind == corrections.KindAttendance {
+		correction.EventID = req.EventID
+		correction.Proposed, _ = json.Marshal(*req.Attended)
+	} else {
+		correction.Proposed, _ = json.Marshal(*req.BooksRead)
+	}
+
+	created, err := h.corrections.Create(r.Context(), correction)
+	if err != nil {
+		switch {
+		case errors.Is(err, corrections.ErrRecordNotFound):
+			h.writeError(w, apierror.NotFound("The record to correct was not found"))
+		case errors.Is(err, corrections.ErrPending):
+			h.writeError(w, apierror.New(apierror.CodeCorrectionPending, "A correction for this record is already pending", nil))
+		case errors.Is(err, corrections.ErrNoChange):
+			h.writeError(w, apierror.New(apierror.CodeNoChange, "The record already has this value", nil))
+		default:
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error creating correction", "error", err)
+			h.writeError(w, apierror.Internal("Failed to request correction"))
+		}
+		return
+	}
+	audit.Describe(r.Context(), "member_correction", created.ID.String(), audit.Diff(nil, req))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"correction": created}, "Correction requested successfully")
+}
+
+// GetCorrections lists the club's corrections for moderators, pending ones by
+// default, and the caller's own corrections for other members
+func (h *CorrectionHandler) GetCorrections(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	filter := corrections.Filter{Status: r.URL.Query().Get("status")}
+	switch filter.Status {
+	case "", corrections.StatusPending, corrections.StatusApproved, corrections.StatusRejected, corrections.StatusCancelled, "all":
+	default:
+		h.writeError(w, apierror.Validation("Invalid status. Use pending, approved, rejected, cancelled or all", models.InvalidField("status", "oneof", "must be one of: pending, approved, rejected, cancelled, all")))
+		return
+	}
+
+	if authz.Can(r.Context(), authz.ManageMembers) && r.URL.Query().Get("mine") != "true" {
+		if filter.Status == "" {
+			filter.Status = corrections.StatusPending
+		}
+	} else {
+		filter.UserID = &userID
+	}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	list, err := h.corrections.List(r.Context(), clubID, filter)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying corrections", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get corrections"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"corrections": list}, "Corrections retrieved successfully")
+}
+
+// CancelCorrection withdraws one of the caller's pending corrections
+func (h *CorrectionHandler) CancelCorrection(w http.ResponseWriter, r *http.Request) {
+	clubID, correctionID, ok := h.clubCorrection(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if err := h.corrections.Cancel(r.Context(), clubID, correctionID, userID); err != nil {
+		if errors.Is(err, corrections.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Pending correction not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error cancelling correction", "error", err)
+		h.writeError(w, apierror.Internal("Failed to cancel correction"))
+		return
+	}
+	audit.Describe(r.Context(), "member_correction", correctionID.String(),
+		audit.Changes{"status": {From: corrections.StatusPending, To: corrections.StatusCancelled}})
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Correction cancelled successfully"}, "Correction cancelled successfully")
+}
+
+// ApproveCorrection applies a pending correction (moderators)
+func (h *CorrectionHandler) ApproveCorrection(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, true)
+}
+
+// RejectCorrection turns down a pending correction (moderators)
+func (h *CorrectionHandler) RejectCorrection(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, false)
+}
+
+func (h *CorrectionHandler) decide(w http.ResponseWriter, r *http.Request, approve bool) {
+	clubID, correctionID, ok := h.clubCorrection(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CorrectionDecisionRequest
+	if r.ContentLength != 0 {
+		if err := decodeAndValidate(w, r, &req); err != nil {
+			h.writeError(w, err)
+			return
+		}
+	}
+
+	// Moderators cannot decide their own corrections
+	correction, err := h.corrections.Get(r.Context(), clubID, correctionID)
+	if err == nil && correction.Status != corrections.StatusPending {
+		err = corrections.ErrNotFound
+	}
+	if err != nil {
+		if errors.Is(err, corrections.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Pending correction not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting correction", "error", err)
+		h.writeError(w, apierror.Internal("Failed to decide correction"))
+		return
+	}
+	if correction.UserID == userID {
+		h.writeError(w, apierror.Forbidden("Another moderator must decide your own correction"))
+		return
+	}
+
+	decided, err := h.corrections.Decide(r.Context(), clubID, correctionID, userID, approve, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, corrections.ErrNotFound):
+			h.writeError(w, apierror.NotFound("Pending correction not found"))
+		case errors.Is(err, corrections.ErrRecordNotFound):
+			h.writeError(w, apierror.New(apierror.CodeRecordGone, "The record to correct no longer exists; reject the correction instead", nil))
+		default:
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error deciding correction", "error", err)
+			h.writeError(w, apierror.Internal("Failed to decide correction"))
+		}
+		return
+	}
+
+	changes := audit.Changes{"status": {From: corrections.StatusPending, To: decided.Status}}
+	if approve {
+		changes[correctionFields[decided.Kind]] = audit.FieldChange{From: decided.Previous, To: decided.Proposed}
+	}
+	audit.Describe(r.Context(), "member_correction", correctionID.String(), changes)
+	h.notifyDecided(r.Context(), decided)
+
+	h.writeSuccessResponse(w, map[string]interface{}{"correction": decided}, "Correction "+decided.Status)
+}
+
+// notifyDecided tells the member how their correction was decided; failures
+// are logged, the decision stands
+func (h *CorrectionHandler) notifyDecided(ctx context.Context, c corrections.Correction) {
+	if h.notifier == nil {
+		return
+	}
+
+	what := "books read count"
+	if c.Kind == corrections.KindAttendance {
+		what = "attendance"
+		if c.EventTitle != nil {
+			what += " at " + *c.EventTitle
+		}
+	}
+	notification := models.Notification{
+		Type:    notify.TypeCorrectionDecided,
+		Title:   "Your correction was " + c.Status,
+		Body:    "Your correction to your " + what + " was " + c.Status + ".",
+		ClubID:  &c.ClubID,
+		EventID: c.EventID,
+	}
+	if c.DecisionNote != nil {
+		notification.Body += " " + *c.DecisionNote
+	}
+
+	if _, err := h.notifier.NotifyUser(ctx, c.UserID, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying correction decision", "error", err)
+	}
+}
+
+func (h *CorrectionHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *CorrectionHandler) clubCorrection(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	correctionID, err := uuid.Parse(chi.URLParam(r, "correctionId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid correction ID", models.InvalidID("correctionId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, correctionID, true
+}
+
+func (h *CorrectionHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *CorrectionHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CorrectionHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/corrections"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakeCorrections struct {
+	byID    map[uuid.UUID]corrections.Correction
+	filters []corrections.Filter
+	created []corrections.Correction
+	decided []bool
+}
+
+func (f *fakeCorrections) Create(ctx context.Context, c corrections.Correction) (corrections.Correction, error) {
+	c.ID, c.Status = uuid.New(), corrections.StatusPending
+	f.created = append(f.created, c)
+	return c, nil
+}
+
+func (f *fakeCorrections) List(ctx context.Context, clubID uuid.UUID, filter corrections.Filter) ([]corrections.Correction, error) {
+	f.filters = append(f.filters, filter)
+	return []corrections.Correction{}, nil
+}
+
+func (f *fakeCorrections) Get(ctx context.Context, clubID, id uuid.UUID) (corrections.Correction, error) {
+	if c, ok := f.byID[id]; ok {
+		return c, nil
+	}
+	return corrections.Correction{}, corrections.ErrNotFound
+}
+
+func (f *fakeCorrections) Cancel(ctx context.Context, clubID, id, userID uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeCorrections) Decide(ctx context.Context, clubID, id, decidedBy uuid.UUID, approve bool, note *string) (corrections.Correction, error) {
+	f.decided = append(f.decided, approve)
+	c := f.byID[id]
+	c.Status, c.DecisionNote = corrections.StatusRejected, note
+	if approve {
+		c.Status, c.Previous = corrections.StatusApproved, json.RawMessage("2")
+	}
+	return c, nil
+}
+
+type recordingNotifier struct {
+	notified []models.Notification
+	users    []uuid.UUID
+}
+
+func (n *recordingNotifier) NotifyUser(ctx context.Context, userID uuid.UUID, notification models.Notification) (int, error) {
+	n.notified = append(n.notified, notification)
+	n.users = append(n.users, userID)
+	return 1, nil
+}
+
+func correctionRequest(method, path, body string, userID uuid.UUID, role string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := auth.NewContext(req.Context(), auth.Principal{UserID: userID})
+	return req.WithContext(authz.NewContext(ctx, authz.Membership{Role: role}))
+}
+
+func setupCorrectionTest() (*fakeCorrections, *recordingNotifier, chi.Router) {
+	store := &fakeCorrections{byID: map[uuid.UUID]corrections.Correction{}}
+	notifier := &recordingNotifier{}
+	handler := NewCorrectionHandler(store).WithNotifier(notifier)
+
+	router := chi.NewRouter()
+	router.Get("/club/{clubId}/corrections", handler.GetCorrections)
+	router.Post("/club/{clubId}/corrections", handler.CreateCorrection)
+	router.Post("/club/{clubId}/corrections/{correctionId}/approve", handler.ApproveCorrection)
+	router.Post("/club/{clubId}/corrections/{correctionId}/reject", handler.RejectCorrection)
+	return store, notifier, router
+}
+
+func TestCreateCorrection(t *testing.T) {
+	store, _, router := setupCorrectionTest()
+	path := "/club/" + uuid.New().String() + "/corrections"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path, `{"kind":"books_read","reason":"I finished two"}`, uuid.New(), "member"))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "booksRead is required when kind is books_read") {
+		t.Errorf("Expected a missing count to be rejected, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path, `{"kind":"books_read","booksRead":5,"reason":"I finished two"}`, uuid.New(), "member"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d %s", w.Code, w.Body.String())
+	}
+	if len(store.created) != 1 || string(store.created[0].Proposed) != "5" || store.created[0].EventID != nil {
+		t.Errorf("Expected a books read correction to 5, got %+v", store.created)
+	}
+}
+
+func TestGetCorrectionsScopesMembersToTheirOwn(t *testing.T) {
+	store, _, router := setupCorrectionTest()
+	path := "/club/" + uuid.New().String() + "/corrections"
+	member := uuid.New()
+
+	for _, req := range []*http.Request{
+		correctionRequest(http.MethodGet, path, "", uuid.New(), authz.RoleModerator),
+		correctionRequest(http.MethodGet, path+"?status=all", "", member, "member"),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	if f := store.filters[0]; f.Status != corrections.StatusPending || f.UserID != nil {
+		t.Errorf("Expected moderators to see every pending correction, got %+v", f)
+	}
+	if f := store.filters[1]; f.Status != "" || f.UserID == nil || *f.UserID != member {
+		t.Errorf("Expected members to see all of their own corrections, got %+v", f)
+	}
+}
+
+func TestDecideCorrection(t *testing.T) {
+	store, notifier, router := setupCorrectionTest()
+	clubID, moderator := uuid.New(), uuid.New()
+	mine := corrections.Correction{ID: uuid.New(), ClubID: clubID, UserID: moderator, Kind: corrections.KindBooksRead, Status: corrections.StatusPending}
+	theirs := corrections.Correction{ID: uuid.New(), ClubID: clubID, UserID: uuid.New(), Kind: corrections.KindBooksRead, Status: corrections.StatusPending, Proposed: json.RawMessage("5")}
+	store.byID[mine.ID], store.byID[theirs.ID] = mine, theirs
+	path := "/club/" + clubID.String() + "/corrections/"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path+mine.ID.String()+"/approve", "", moderator, authz.RoleModerator))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a moderator's own correction to be refused, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path+uuid.New().String()+"/approve", "", moderator, authz.RoleModerator))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown correction, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path+theirs.ID.String()+"/reject", `{"note":"Attendance was taken on the night."}`, moderator, authz.RoleModerator))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if len(store.decided) != 1 || store.decided[0] {
+		t.Errorf("Expected one rejection, got %v", store.decided)
+	}
+	if len(notifier.notified) != 1 || !strings.Contains(notifier.notified[0].Body, "was rejected. Attendance was taken on the night.") {
+		t.Errorf("Expected the member to be told with the note, got %+v", notifier.notified)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/dataexport"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// dataExportStore is the part of dataexport.Store the data export handler uses
+type dataExportStore interface {
+	Request(ctx context.Context, userID uuid.UUID) (dataexport.Job, error)
+	Get(ctx context.Context, userID uuid.UUID) (dataexport.Job, error)
+	GetByID(ctx context.Context, id uuid.UUID) (dataexport.Job, error)
+}
+
+// DataExportHandler queues archives of everything the API stores about the
+// caller and serves them once compiled, through a signed link that is also
+// sent to the user when the archive is ready
+type DataExportHandler struct {
+	clocked
+
+	exports  dataExportStore
+	storage  attachments.Storage
+	links    dataexport.Links
+	compiler interface{ Wake() } // nil when no compiler runs on this instance
+}
+
+func NewDataExportHandler(exports dataExportStore, storage attachments.Storage, links dataexport.Links) *DataExportHandler {
+	return &DataExportHandler{exports: exports, storage: storage, links: links}
+}
+
+// WithCompiler starts compiling a requested export at once instead of at the
+// compiler's next check
+func (h *DataExportHandler) WithCompiler(compiler interface{ Wake() }) *DataExportHandler {
+	h.compiler = compiler
+	return h
+}
+
+// RequestExport queues an export of the caller's data, compiling it afresh
+// if it was compiled before
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	job, err := h.exports.Request(r.Context(), userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error queuing data export", "error", err)
+		h.writeError(w, apierror.Internal("Failed to request export"))
+		return
+	}
+	audit.Describe(r.Context(), "user_export", job.ID.String(), nil)
+	if h.compiler != nil {
+		h.compiler.Wake()
+	}
+
+	h.writeResponse(w, http.StatusAccepted, map[string]interface{}{"export": job}, "Export requested; you will be notified when it is ready")
+}
+
+// GetExport returns the status of the caller's export and, once it is ready,
+// a download link for the archive
+func (h *DataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	job, err := h.exports.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, dataexport.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("No export has been requested"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting data export", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get export"))
+		return
+	}
+	if h.available(job) {
+		h.links.Sign(&job)
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"export": job}, "Export retrieved successfully")
+}
+
+// Download serves an export archive to anyone holding a valid signed link
+func (h *DataExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id, err := h.links.Verify(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		if err == signedurl.ErrExpired {
+			h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This download link has expired", nil))
+			return
+		}
+		h.writeError(w, apierror.NotFound("Export not found"))
+		return
+	}
+
+	job, err := h.exports.GetByID(r.Context(), id)
+	if err == nil && !h.available(job) {
+		err = dataexport.ErrNotFound
+	}
+	var body io.ReadCloser
+	if err == nil {
+		body, err = h.storage.Get(r.Context(), job.StorageKey)
+	}
+	if err != nil {
+		if errors.Is(err, dataexport.ErrNotFound) || errors.Is(err, attachments.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Export not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reading data export", "error", err)
+		h.writeError(w, apierror.Internal("Failed to download export"))
+		return
+	}
+	defer body.Close()
+
+	filename := "bookwork-export-" + job.CompletedAt.UTC().Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// available reports whether a job's archive can be downloaded now
+func (h *DataExportHandler) available(job dataexport.Job) bool {
+	return job.Status == dataexport.StatusReady && job.StorageKey != "" && job.CompletedAt != nil &&
+		job.ExpiresAt != nil && h.now().Before(*job.ExpiresAt)
+}
+
+func (h *DataExportHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *DataExportHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *DataExportHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/dataexport"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakeDataExports struct {
+	jobs      map[uuid.UUID]dataexport.Job
+	requested []uuid.UUID
+}
+
+func (f *fakeDataExports) Request(ctx context.Context, userID uuid.UUID) (dataexport.Job, error) {
+	f.requested = append(f.requested, userID)
+	job := dataexport.Job{ID: uuid.New(), UserID: userID, Status: dataexport.StatusPending}
+	f.jobs[job.ID] = job
+	return job, nil
+}
+
+func (f *fakeDataExports) Get(ctx context.Context, userID uuid.UUID) (dataexport.Job, error) {
+	for _, job := range f.jobs {
+		if job.UserID == userID {
+			return job, nil
+		}
+	}
+	return dataexport.Job{}, dataexport.ErrNotFound
+}
+
+func (f *fakeDataExports) GetByID(ctx context.Context, id uuid.UUID) (dataexport.Job, error) {
+	if job, ok := f.jobs[id]; ok {
+		return job, nil
+	}
+	return dataexport.Job{}, dataexport.ErrNotFound
+}
+
+var exportTestNow = time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+func setupDataExportTest(t *testing.T) (*DataExportHandler, *fakeDataExports, attachments.Storage, chi.Router) {
+	storage, err := attachments.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	store := &fakeDataExports{jobs: map[uuid.UUID]dataexport.Job{}}
+	handler := NewDataExportHandler(store, storage, dataexport.NewLinks(signedurl.NewSigner("test-secret", "data-export-download")))
+	handler.clock = clock.NewFake(exportTestNow)
+
+	router := chi.NewRouter()
+	router.Post("/users/me/export", handler.RequestExport)
+	router.Get("/users/me/export", handler.GetExport)
+	router.Get("/exports/{token}", handler.Download)
+	return handler, store, storage, router
+}
+
+func exportRequest(method, path string, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	return req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+}
+
+func TestRequestExport(t *testing.T) {
+	handler, store, _, router := setupDataExportTest(t)
+	var wakes wakeCounter
+	handler.WithCompiler(&wakes)
+	userID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, exportRequest(http.MethodGet, "/users/me/export", userID))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before an export is requested, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, exportRequest(http.MethodPost, "/users/me/export", userID))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(store.requested) != 1 || store.requested[0] != userID || wakes != 1 {
+		t.Errorf("Expected the caller's export to be queued and the compiler woken, got %v and %d wakes", store.requested, wakes)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, exportRequest(http.MethodGet, "/users/me/export", userID))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"pending"`) || strings.Contains(w.Body.String(), "downloadUrl") {
+		t.Errorf("Expected a pending export without a link, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetAndDownloadExport(t *testing.T) {
+	_, store, storage, router := setupDataExportTest(t)
+	userID := uuid.New()
+	completedAt, expiresAt := exportTestNow.Add(-time.Hour), exportTestNow.Add(time.Hour)
+	ready := dataexport.Job{
+		ID: uuid.New(), UserID: userID, Status: dataexport.StatusReady, StorageKey: "exports/user.zip",
+		CompletedAt: &completedAt, ExpiresAt: &expiresAt,
+	}
+	store.jobs[ready.ID] = ready
+	storage.Put(context.Background(), ready.StorageKey, []byte("PK archive"), "application/zip")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, exportRequest(http.MethodGet, "/users/me/export", userID))
+	var response struct {
+		Data struct {
+			Export dataexport.Job `json:"export"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Data.Export.DownloadURL == "" {
+		t.Fatalf("Expected a download link once ready, got %s", w.Body.String())
+	}
+	if !response.Data.Export.DownloadURLExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected the link to expire with the archive at %s, got %s", expiresAt, response.Data.Export.DownloadURLExpiresAt)
+	}
+
+	url := strings.TrimPrefix(response.Data.Export.DownloadURL, "/api")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" || w.Body.String() != "PK archive" {
+		t.Errorf("Expected the archive, got %d %s", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, "bookwork-export-2026-01-05.zip") {
+		t.Errorf("Expected the archive to be named for its date, got %q", disposition)
+	}
+
+	// Once the export has expired its link finds nothing
+	ready.Status, ready.StorageKey = dataexport.StatusExpired, ""
+	store.jobs[ready.ID] = ready
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired export, got %d", w.Code)
+	}
+}
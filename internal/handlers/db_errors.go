@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"context"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/dberrors"
+	"bookwork-api/internal/logging"
+)
+
+// violationError returns the client error for a write that broke a database
+// constraint, such as a duplicate or a reference to a missing record, or nil
+// if err is a server error
+func violationError(ctx context.Context, err error) *apierror.Error {
+	v, ok := dberrors.Classify(err)
+	if !ok {
+		return nil
+	}
+	logging.FromContext(ctx).Warn("write rejected by database constraint", "code", v.Code, "constraint", v.Constraint, "error", err)
+	return apierror.New(v.Code, v.Message, v.Details)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/mergepatch"
+	"bookwork-api/internal/models"
+)
+
+// Limits for client-supplied JSON bodies. Real requests are small and shallow;
+// anything beyond these is a buggy or adversarial client.
+const (
+	maxJSONBodyBytes   = 1 << 20 // 1 MiB
+	maxJSONDepth       = 32
+	maxJSONArrayLength = 1000
+)
+
+var errEmptyBody = invalidField("", "required", "Request body is required", "Invalid JSON format")
+
+var errInvalidJSON = invalidField("", "json", "Request body must be valid JSON", "Invalid JSON format")
+
+// decodeJSON reads a JSON request body into v, rejecting bodies that are too large,
+// too deeply nested or contain overly long arrays before they are decoded. Free-text
+// fields are sanitized as their sanitize tags say.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) *apierror.Error {
+	data, derr := readJSONBody(w, r)
+	if derr != nil {
+		return derr
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errEmptyBody
+	}
+	return unmarshalJSON(data, v)
+}
+
+// decodeOptionalJSON is decodeJSON for endpoints where the body may be omitted
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, v interface{}) *apierror.Error {
+	data, derr := readJSONBody(w, r)
+	if derr != nil {
+		return derr
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return unmarshalJSON(data, v)
+}
+
+// decodeMergePatch applies a JSON Merge Patch body to target, which holds the
+// current values of the resource's patchable fields, and returns the fields the
+// patch names. Plain application/json bodies are accepted as merge patches too.
+func decodeMergePatch(w http.ResponseWriter, r *http.Request, target interface{}) ([]string, *apierror.Error) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergepatch.ContentType && mediaType != "application/json") {
+			return nil, apierror.New(apierror.CodeUnsupportedMediaType, "Request body must be a JSON merge patch", map[string]interface{}{"allowedTypes": []string{mergepatch.ContentType, "application/json"}})
+		}
+	}
+
+	data, derr := readJSONBody(w, r)
+	if derr != nil {
+		return nil, derr
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errEmptyBody
+	}
+	if derr := checkJSONLimits(data); derr != nil {
+		return nil, derr
+	}
+
+	fields, err := mergepatch.Apply(target, data)
+	if err != nil {
+		var unknown *mergepatch.UnknownFieldsError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &unknown):
+			errs := make([]models.FieldError, 0, len(unknown.Fields))
+			for _, field := range unknown.Fields {
+				errs = append(errs, models.FieldError{Field: field, Code: "unknown", Message: "is not a field of this resource"})
+			}
+			return nil, apierror.New(apierror.CodeUnknownFields, "Patch contains unknown fields", models.FieldErrorDetails(errs...))
+		case errors.Is(err, mergepatch.ErrNotObject):
+			return nil, invalidField("", "object", "Merge patch must be a JSON object", "Invalid JSON format")
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return nil, invalidField(typeErr.Field, "type", "must be "+jsonTypeName(typeErr.Type), "Invalid JSON format")
+		}
+		return nil, errInvalidJSON
+	}
+	models.Sanitize(target)
+	return fields, nil
+}
+
+func readJSONBody(w http.ResponseWriter, r *http.Request) ([]byte, *apierror.Error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, apierror.New(apierror.CodeRequestTooLarge, "Request body is too large", map[string]interface{}{"maxBytes": maxJSONBodyBytes})
+		}
+		return nil, invalidField("", "body", "Request body could not be read", "Failed to read request body")
+	}
+	return data, nil
+}
+
+func unmarshalJSON(data []byte, v interface{}) *apierror.Error {
+	if derr := checkJSONLimits(data); derr != nil {
+		return derr
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return invalidField(typeErr.Field, "type", "must be "+jsonTypeName(typeErr.Type), "Invalid JSON format")
+		}
+		return errInvalidJSON
+	}
+	models.Sanitize(v)
+	return nil
+}
+
+// checkJSONLimits streams through the tokens of data, without building values,
+// and enforces the nesting depth and array length limits
+func checkJSONLimits(data []byte) *apierror.Error {
+	type container struct {
+		array  bool
+		length int
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []container
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errInvalidJSON
+		}
+
+		delim, isDelim := tok.(json.Delim)
+		if isDelim && (delim == ']' || delim == '}') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// Every other token starts a value (or is an object key, which is never inside an array)
+		if n := len(stack); n > 0 && stack[n-1].array {
+			stack[n-1].length++
+			if stack[n-1].length > maxJSONArrayLength {
+				return apierror.New(apierror.CodeJSONArrayTooLong, "Request contains an array with too many elements", map[string]interface{}{"maxArrayLength": maxJSONArrayLength})
+			}
+		}
+
+		if isDelim {
+			stack = append(stack, container{array: delim == '['})
+			if len(stack) > maxJSONDepth {
+				return apierror.New(apierror.CodeJSONTooDeep, "Request JSON is nested too deeply", map[string]interface{}{"maxDepth": maxJSONDepth})
+			}
+		}
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// jsonTypeName describes the JSON value expected for a Go type
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// IDs, decimals and the like are written as strings
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return "a string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/models"
+)
+
+func TestDecodeJSONLimits(t *testing.T) {
+	type payload struct {
+		Status string        `json:"status"`
+		Items  []interface{} `json:"items"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		code     apierror.Code
+		optional bool
+	}{
+		{"valid", `{"status":"available","items":[1,2,3]}`, 0, "", false},
+		{"syntax error", `{"status":`, http.StatusBadRequest, "VALIDATION_ERROR", false},
+		{"empty body", ``, http.StatusBadRequest, "VALIDATION_ERROR", false},
+		{"empty optional body", ``, 0, "", true},
+		{"wrong type", `{"status":42}`, http.StatusBadRequest, "VALIDATION_ERROR", false},
+		{"too deep", `{"items":` + strings.Repeat("[", maxJSONDepth) + strings.Repeat("]", maxJSONDepth) + `}`, http.StatusBadRequest, "JSON_TOO_DEEP", false},
+		{"at depth limit", `{"items":` + strings.Repeat("[", maxJSONDepth-1) + strings.Repeat("]", maxJSONDepth-1) + `}`, 0, "", false},
+		{"array too long", `{"items":[` + strings.Repeat("0,", maxJSONArrayLength) + `0]}`, http.StatusBadRequest, "JSON_ARRAY_TOO_LONG", false},
+		{"object keys are not array elements", `{"items":[{` + strings.Repeat(`"k":0,`, maxJSONArrayLength) + `"k":0}]}`, 0, "", false},
+		{"too large", `{"status":"` + strings.Repeat("a", maxJSONBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var v payload
+			decode := decodeJSON
+			if tt.optional {
+				decode = decodeOptionalJSON
+			}
+
+			err := decode(w, req, &v)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %+v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected %s error, got none", tt.code)
+			}
+			if err.Status != tt.status || err.Code != tt.code {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.code, err.Status, err.Code)
+			}
+		})
+	}
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	type payload struct {
+		Status string  `json:"status"`
+		Notes  *string `json:"notes"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        apierror.Code
+	}{
+		{"merge patch", "application/merge-patch+json", `{"notes":null}`, 0, ""},
+		{"plain JSON", "application/json; charset=utf-8", `{"status":"done"}`, 0, ""},
+		{"unsupported type", "text/plain", `{"status":"done"}`, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"unknown field", "", `{"status":"done","colour":"red"}`, http.StatusUnprocessableEntity, "UNKNOWN_FIELDS"},
+		{"empty body", "", ``, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"trailing data", "", `{"status":"done"} {"status":"pending"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"too deep", "", `{"status":` + strings.Repeat("[", maxJSONDepth+1) + strings.Repeat("]", maxJSONDepth+1) + `}`, http.StatusBadRequest, "JSON_TOO_DEEP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			notes := "Bring snacks"
+			v := payload{Status: "pending", Notes: &notes}
+			_, err := decodeMergePatch(w, req, &v)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %+v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected %s error, got none", tt.code)
+			}
+			if err.Status != tt.status || err.Code != tt.code {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.code, err.Status, err.Code)
+			}
+		})
+	}
+}
+
+func TestDecodeSanitizesFreeText(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"title":"<b>Dune</b> night","description":"Bring <script>alert(1)</script>[snacks](javascript:alert(1))","agenda":[{"title":"<i>Welcome</i>"}],"location":"Library"}`))
+	var event models.CreateEventRequest
+	if err := decodeJSON(httptest.NewRecorder(), req, &event); err != nil {
+		t.Fatalf("Expected no error, got %+v", err)
+	}
+	if event.Title != "Dune night" || *event.Description != "Bring snacks)" || event.Agenda[0].Title != "Welcome" || event.Location != "Library" {
+		t.Errorf("Expected sanitized fields, got %q, %q, %q", event.Title, *event.Description, event.Agenda[0].Title)
+	}
+
+	req = httptest.NewRequest("PATCH", "/", strings.NewReader(`{"notes":"<img src=x onerror=alert(1)>Two loaves"}`))
+	patch := itemPatch{Status: "pending"}
+	if _, err := decodeMergePatch(httptest.NewRecorder(), req, &patch); err != nil {
+		t.Fatalf("Expected no error, got %+v", err)
+	}
+	if *patch.Notes != "Two loaves" {
+		t.Errorf("Expected sanitized patch notes, got %q", *patch.Notes)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/deliveryhealth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Days of notifications a delivery health report covers by default and at most
+const (
+	defaultDeliveryHealthDays = 30
+	maxDeliveryHealthDays     = 90
+)
+
+// deliveryHealthStore is the part of deliveryhealth.Store the handler uses
+type deliveryHealthStore interface {
+	Club(ctx context.Context, clubID uuid.UUID, since time.Time) (deliveryhealth.Report, error)
+}
+
+// DeliveryHealthHandler shows club owners how their club's notifications
+// fared at each delivery provider
+type DeliveryHealthHandler struct {
+	clocked
+
+	health deliveryHealthStore
+}
+
+func NewDeliveryHealthHandler(health deliveryHealthStore) *DeliveryHealthHandler {
+	return &DeliveryHealthHandler{health: health}
+}
+
+// GetDeliveryHealth reports delivery outcomes per provider for the club's
+// notifications of the last ?days= days, with the latest that were not delivered
+func (h *DeliveryHealthHandler) GetDeliveryHealth(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	days := defaultDeliveryHealthDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > maxDeliveryHealthDays {
+			h.writeError(w, apierror.Validation("Invalid days", models.InvalidField("days", "range", "must be between 1 and 90")))
+			return
+		}
+	}
+
+	report, err := h.health.Club(r.Context(), clubID, h.now().AddDate(0, 0, -days))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting delivery health", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get delivery health"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"health": report, "days": days}, "Delivery health retrieved successfully")
+}
+
+func (h *DeliveryHealthHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *DeliveryHealthHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/deliveryhealth"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakeDeliveryHealth struct {
+	clubID uuid.UUID
+	since  time.Time
+}
+
+func (f *fakeDeliveryHealth) Club(ctx context.Context, clubID uuid.UUID, since time.Time) (deliveryhealth.Report, error) {
+	f.clubID, f.since = clubID, since
+	return deliveryhealth.Report{
+		Since:          since,
+		Notifications:  4,
+		Providers:      []deliveryhealth.Provider{{Provider: "email", Total: 4, Sent: 3, Bounced: 1, SuccessRate: 0.75, BounceRate: 0.25}},
+		RecentFailures: []deliveryhealth.Failure{},
+	}, nil
+}
+
+var deliveryHealthTestNow = time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC)
+
+func setupDeliveryHealthTest() (*fakeDeliveryHealth, chi.Router) {
+	store := &fakeDeliveryHealth{}
+	handler := NewDeliveryHealthHandler(store)
+	handler.clock = clock.NewFake(deliveryHealthTestNow)
+
+	router := chi.NewRouter()
+	router.Get("/club/{clubId}/delivery-health", handler.GetDeliveryHealth)
+	return store, router
+}
+
+func TestGetDeliveryHealth(t *testing.T) {
+	store, router := setupDeliveryHealthTest()
+	clubID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/delivery-health?days=7", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if store.clubID != clubID || !store.since.Equal(deliveryHealthTestNow.AddDate(0, 0, -7)) {
+		t.Errorf("Expected the club's last 7 days, got %s since %s", store.clubID, store.since)
+	}
+
+	var body struct {
+		Data struct {
+			Days   int `json:"days"`
+			Health struct {
+				Providers []deliveryhealth.Provider `json:"providers"`
+				Webhooks  *deliveryhealth.Provider  `json:"webhooks"`
+			} `json:"health"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Data.Days != 7 || len(body.Data.Health.Providers) != 1 || body.Data.Health.Providers[0].BounceRate != 0.25 {
+		t.Errorf("Unexpected report: %s", w.Body.String())
+	}
+	if body.Data.Health.Webhooks != nil {
+		t.Errorf("Expected no webhook stats, got %+v", body.Data.Health.Webhooks)
+	}
+}
+
+// TestDeliveryHealthRoute guards the report as the API does. A club's owner
+// is stored as a moderator in club_members, as CreateClub adds them.
+func TestDeliveryHealthRoute(t *testing.T) {
+	clubID, ownerID, memberID := uuid.New(), uuid.New(), uuid.New()
+	mem := store.NewMemory()
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: ownerID, Role: authz.RoleModerator, IsActive: true})
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: memberID, Role: "member", IsActive: true})
+
+	authorizer := authz.New(mem.Stores())
+	router := chi.NewRouter()
+	router.With(authorizer.RequireClubCapability(authz.ManageClub)).
+		Get("/club/{clubId}/delivery-health", NewDeliveryHealthHandler(&fakeDeliveryHealth{}).GetDeliveryHealth)
+
+	for userID, expected := range map[uuid.UUID]int{ownerID: http.StatusOK, memberID: http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/delivery-health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID})))
+		if w.Code != expected {
+			t.Errorf("Expected %d, got %d: %s", expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetDeliveryHealthDefaultsToThirtyDays(t *testing.T) {
+	store, router := setupDeliveryHealthTest()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+uuid.New().String()+"/delivery-health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !store.since.Equal(deliveryHealthTestNow.AddDate(0, 0, -30)) {
+		t.Errorf("Expected the last 30 days, got since %s", store.since)
+	}
+}
+
+func TestGetDeliveryHealthRejectsInvalidDays(t *testing.T) {
+	_, router := setupDeliveryHealthTest()
+
+	for _, days := range []string{"0", "91", "week"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+uuid.New().String()+"/delivery-health?days="+days, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: expected 400, got %d", days, w.Code)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"bookwork-api/internal/apierror"
+
+	"net/http"
+	"strconv"
+)
+
+// dryRunParam reads ?dryRun=, which makes a destructive operation report what
+// it would remove without committing anything
+func dryRunParam(r *http.Request) (bool, *apierror.Error) {
+	value := r.URL.Query().Get("dryRun")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidField("dryRun", "boolean", "must be true or false", "Invalid dryRun value")
+	}
+	return dryRun, nil
+}
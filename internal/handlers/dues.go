@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/dues"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// DuesHandler serves club membership dues: the club's policy, payments recorded
+// by treasurers and which members have paid for the current period
+type DuesHandler struct {
+	clocked
+
+	ledger duesLedger
+	clubs  store.ClubStore
+}
+
+// duesLedger stores dues settings and payments; *dues.Ledger implements it
+type duesLedger interface {
+	Settings(ctx context.Context, clubID uuid.UUID) (dues.Settings, error)
+	Configure(ctx context.Context, clubID uuid.UUID, settings dues.Settings) error
+	Record(ctx context.Context, payment *dues.Payment) error
+	Payments(ctx context.Context, clubID uuid.UUID, userID *uuid.UUID, limit, offset int) ([]dues.Payment, error)
+	Statuses(ctx context.Context, clubID uuid.UUID, at time.Time) ([]dues.MemberStatus, error)
+	Status(ctx context.Context, clubID, userID uuid.UUID, at time.Time) (*dues.MemberStatus, dues.Settings, error)
+}
+
+func NewDuesHandler(ledger duesLedger, clubs store.ClubStore) *DuesHandler {
+	return &DuesHandler{ledger: ledger, clubs: clubs}
+}
+
+// GetDues returns the club's dues policy and the caller's status for the current period
+func (h *DuesHandler) GetDues(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	status, settings, err := h.ledger.Status(r.Context(), clubID, userID, h.now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting dues status", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get dues"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"dues":   settings,
+		"status": status,
+	}
+
+	h.writeSuccessResponse(w, response, "Dues retrieved successfully")
+}
+
+// UpdateDues replaces the club's dues policy
+func (h *DuesHandler) UpdateDues(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	var req models.UpdateDuesRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	settings, err := h.ledger.Settings(r.Context(), clubID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting dues settings", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update dues"))
+		return
+	}
+	before := settings
+
+	settings.Amount, settings.Period, settings.RequiredForRSVP = nil, "", false
+	if req.Amount != nil {
+		amount, err := parsePaymentAmount("amount", *req.Amount, settings.Currency)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		period := dues.Period(req.Period)
+		if !period.Valid() {
+			h.writeError(w, apierror.Validation("Invalid period. Must be 'monthly', 'quarterly', or 'yearly'", models.InvalidField("period", "oneof", "must be one of: monthly, quarterly, yearly")))
+			return
+		}
+		settings.Amount, settings.Period, settings.RequiredForRSVP = &amount, period, req.RequiredForRSVP
+	} else if req.RequiredForRSVP {
+		h.writeError(w, apierror.Validation("RSVPs can only require dues when the club charges them", models.InvalidField("requiredForRsvp", "required_with", "requires an amount")))
+		return
+	}
+
+	if err := h.ledger.Configure(r.Context(), clubID, settings); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating dues settings", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update dues"))
+		return
+	}
+	audit.Describe(r.Context(), "club", clubID.String(), audit.Diff(before, settings))
+
+	response := map[string]interface{}{
+		"dues": settings,
+	}
+
+	h.writeSuccessResponse(w, response, "Dues updated successfully")
+}
+
+// GetMemberStatuses lists every active member's payment status for the current
+// period, or for the period containing ?date=YYYY-MM-DD
+func (h *DuesHandler) GetMemberStatuses(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	at := h.now()
+	if date := r.URL.Query().Get("date"); date != "" {
+		parsed, err := timeutil.ParseDate(date)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid date. Use YYYY-MM-DD", models.InvalidField("date", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+		at = parsed
+	}
+
+	statuses, err := h.ledger.Statuses(r.Context(), clubID, at)
+	if err != nil {
+		switch err {
+		case dues.ErrNotConfigured:
+			h.writeError(w, apierror.New(apierror.CodeDuesNotConfigured, "The club does not charge dues", nil))
+		case sql.ErrNoRows:
+			h.writeError(w, apierror.NotFound("Club not found"))
+		default:
+			logging.FromContext(r.Context()).Error("error getting dues statuses", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get dues statuses"))
+		}
+		return
+	}
+
+	counts := map[string]int{dues.StatusPaid: 0, dues.StatusPartial: 0, dues.StatusUnpaid: 0}
+	for _, status := range statuses {
+		counts[status.Status]++
+	}
+
+	response := map[string]interface{}{
+		"members": statuses,
+		"counts":  counts,
+	}
+
+	h.writeSuccessResponse(w, response, "Dues statuses retrieved successfully")
+}
+
+// GetPayments lists the club's payments, newest first. Filters: userId, page and limit.
+func (h *DuesHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	var userID *uuid.UUID
+	if user := query.Get("userId"); user != "" {
+		parsed, err := uuid.Parse(user)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid userId", models.InvalidID("userId")))
+			return
+		}
+		userID = &parsed
+	}
+
+	payments, err := h.ledger.Payments(r.Context(), clubID, userID, limit, (page-1)*limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying dues payments", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get payments"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"payments": payments,
+		"page":     page,
+		"limit":    limit,
+	}
+
+	h.writeSuccessResponse(w, response, "Payments retrieved successfully")
+}
+
+// RecordPayment records a payment taken outside the API, such as cash or a bank transfer
+func (h *DuesHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.RecordDuesPaymentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	settings, err := h.ledger.Settings(r.Context(), clubID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Club not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting dues settings", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record payment"))
+		return
+	}
+	if !settings.Enabled() {
+		h.writeError(w, apierror.New(apierror.CodeDuesNotConfigured, "The club does not charge dues", nil))
+		return
+	}
+
+	amount, derr := parsePaymentAmount("amount", req.Amount, settings.Currency)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	at := h.now()
+	if req.PeriodStart != "" {
+		parsed, err := timeutil.ParseDate(req.PeriodStart)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid periodStart. Use YYYY-MM-DD", models.InvalidField("periodStart", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+		at = parsed
+	}
+
+	if _, err := h.clubs.MemberRole(r.Context(), clubID, req.UserID); err != nil {
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.Validation("The payer must be a member of the club", models.InvalidField("userId", "member", "must be a member of the club")))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error checking club membership", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record payment"))
+		return
+	}
+
+	payment := &dues.Payment{
+		ClubID:      clubID,
+		UserID:      req.UserID,
+		Amount:      amount,
+		PeriodStart: timeutil.FormatDate(settings.Period.Start(at)),
+		Method:      dues.MethodManual,
+		Notes:       req.Notes,
+		RecordedBy:  &userID,
+	}
+	if err := h.ledger.Record(r.Context(), payment); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error recording dues payment", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record payment"))
+		return
+	}
+	audit.Describe(r.Context(), "dues_payment", payment.ID.String(), nil)
+
+	response := map[string]interface{}{
+		"payment": payment,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	h.writeSuccessResponse(w, response, "Payment recorded successfully")
+}
+
+// maxPaymentAmount is the exclusive upper bound of dues and contribution amounts, in major units
+const maxPaymentAmount = 1e8
+
+// parsePaymentAmount parses a positive amount in currency, reporting errors against field
+func parsePaymentAmount(field string, amount money.Decimal, currency string) (money.Money, *apierror.Error) {
+	parsed, err := money.Parse(string(amount), currency)
+	if err == money.ErrTooPrecise {
+		return money.Money{}, invalidField(field, "precision", "has more decimal places than the currency allows",
+			"Amount has more decimal places than the currency allows")
+	}
+	if err != nil {
+		return money.Money{}, invalidField(field, "decimal", "must be a decimal amount", "Invalid amount")
+	}
+	c, _ := money.LookupCurrency(currency)
+	if parsed.Minor <= 0 || parsed.Minor >= maxPaymentAmount*int64(math.Pow10(c.Digits)) {
+		return money.Money{}, invalidField(field, "range", "must be positive and less than 100000000",
+			"Amount must be positive and less than 100000000")
+	}
+	return parsed, nil
+}
+
+func (h *DuesHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *DuesHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/billing"
+	"bookwork-api/internal/dues"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeLedger keeps dues settings and payments in memory
+type fakeLedger struct {
+	settings dues.Settings
+	payments []dues.Payment
+	checkout []billing.CheckoutSession
+}
+
+func (l *fakeLedger) Settings(ctx context.Context, clubID uuid.UUID) (dues.Settings, error) {
+	return l.settings, nil
+}
+
+func (l *fakeLedger) Configure(ctx context.Context, clubID uuid.UUID, settings dues.Settings) error {
+	l.settings = settings
+	return nil
+}
+
+func (l *fakeLedger) Record(ctx context.Context, payment *dues.Payment) error {
+	payment.ID = uuid.New()
+	l.payments = append(l.payments, *payment)
+	return nil
+}
+
+func (l *fakeLedger) Payments(ctx context.Context, clubID uuid.UUID, userID *uuid.UUID, limit, offset int) ([]dues.Payment, error) {
+	return l.payments, nil
+}
+
+func (l *fakeLedger) Statuses(ctx context.Context, clubID uuid.UUID, at time.Time) ([]dues.MemberStatus, error) {
+	return nil, dues.ErrNotConfigured
+}
+
+func (l *fakeLedger) Status(ctx context.Context, clubID, userID uuid.UUID, at time.Time) (*dues.MemberStatus, dues.Settings, error) {
+	if !l.settings.Enabled() {
+		return nil, l.settings, nil
+	}
+	paid := money.New(0, l.settings.Currency)
+	for _, payment := range l.payments {
+		if payment.UserID == userID {
+			paid.Minor += payment.Amount.Minor
+		}
+	}
+	status := dues.StatusUnpaid
+	if paid.Minor >= l.settings.Amount.Minor {
+		status = dues.StatusPaid
+	}
+	return &dues.MemberStatus{UserID: userID, Due: *l.settings.Amount, Paid: paid, Status: status}, l.settings, nil
+}
+
+func (l *fakeLedger) RecordCheckout(ctx context.Context, session billing.CheckoutSession) error {
+	for _, recorded := range l.checkout {
+		if recorded.ID == session.ID {
+			return billing.ErrAlreadyRecorded
+		}
+	}
+	l.checkout = append(l.checkout, session)
+	return nil
+}
+
+func serveDues(handler http.HandlerFunc, method, body string, clubID, userID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clubId", clubID.String())
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(auth.NewContext(ctx, auth.Principal{UserID: userID}))
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestUpdateDues(t *testing.T) {
+	ledger := &fakeLedger{settings: dues.Settings{Currency: "EUR"}}
+	handler := NewDuesHandler(ledger, store.NewMemory().Stores().Clubs)
+	clubID, userID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"amount": "25.00", "period": "weekly"}`, http.StatusBadRequest},
+		{`{"amount": "25.001", "period": "monthly"}`, http.StatusBadRequest},
+		{`{"amount": 0, "period": "monthly"}`, http.StatusBadRequest},
+		{`{"amount": null, "requiredForRsvp": true}`, http.StatusBadRequest},
+		{`{"amount": "25.00", "period": "quarterly", "requiredForRsvp": true}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serveDues(handler.UpdateDues, "PUT", tt.body, clubID, userID); w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.expected, w.Code)
+		}
+	}
+
+	if !ledger.settings.Enabled() || ledger.settings.Amount.Minor != 2500 || ledger.settings.Period != dues.Quarterly || !ledger.settings.RequiredForRSVP {
+		t.Errorf("Unexpected settings: %+v", ledger.settings)
+	}
+
+	// A null amount stops charging dues
+	if w := serveDues(handler.UpdateDues, "PUT", `{"amount": null}`, clubID, userID); w.Code != http.StatusOK || ledger.settings.Enabled() {
+		t.Errorf("Expected dues to be disabled, got %d %+v", w.Code, ledger.settings)
+	}
+}
+
+func TestRecordDuesPayment(t *testing.T) {
+	amount := money.New(2500, "EUR")
+	ledger := &fakeLedger{settings: dues.Settings{Amount: &amount, Period: dues.Quarterly, Currency: "EUR"}}
+	mem := store.NewMemory()
+	clubID, treasurerID, memberID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: memberID, Role: "member", IsActive: true})
+	handler := NewDuesHandler(ledger, mem.Stores().Clubs)
+
+	body := `{"userId": "` + uuid.New().String() + `", "amount": "25"}`
+	if w := serveDues(handler.RecordPayment, "POST", body, clubID, treasurerID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-member, got %d", w.Code)
+	}
+
+	body = `{"userId": "` + memberID.String() + `", "amount": "25", "periodStart": "2024-05-20", "notes": "Cash"}`
+	if w := serveDues(handler.RecordPayment, "POST", body, clubID, treasurerID); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	payment := ledger.payments[0]
+	if payment.PeriodStart != "2024-04-01" || payment.Method != dues.MethodManual || *payment.RecordedBy != treasurerID || payment.Amount != amount {
+		t.Errorf("Unexpected payment: %+v", payment)
+	}
+}
+
+func TestRSVPRequiresPaidDues(t *testing.T) {
+	mem := store.NewMemory()
+	clubID, eventID, memberID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: memberID, Role: "member", IsActive: true})
+	mem.PutEvent(models.Event{ID: eventID, ClubID: clubID, Title: "Book Night", CreatedBy: memberID})
+	stores := mem.Stores()
+
+	amount := money.New(1000, "USD")
+	ledger := &fakeLedger{settings: dues.Settings{Amount: &amount, Period: dues.Monthly, Currency: "USD", RequiredForRSVP: true}}
+	handler := NewAvailabilityHandler(stores).WithDues(ledger)
+
+	router := chi.NewRouter()
+	router.With(authz.New(stores).RequireEventRole()).Post("/events/{eventId}/availability", handler.UpdateAvailability)
+
+	rsvp := func(status string) int {
+		req := httptest.NewRequest("POST", "/events/"+eventID.String()+"/availability", bytes.NewBufferString(`{"status": "`+status+`"}`))
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: memberID}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := rsvp("available"); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 with unpaid dues, got %d", code)
+	}
+	if code := rsvp("unavailable"); code != http.StatusOK {
+		t.Errorf("Expected declining to be allowed, got %d", code)
+	}
+
+	ledger.payments = append(ledger.payments, dues.Payment{UserID: memberID, Amount: amount})
+	if code := rsvp("available"); code != http.StatusOK {
+		t.Errorf("Expected status 200 with paid dues, got %d", code)
+	}
+}
+
+func TestStripeWebhook(t *testing.T) {
+	ledger := &fakeLedger{}
+	handler := NewBillingHandler("whsec_test").WithRecorder(dues.PurposeDues, ledger)
+
+	send := func(payload, secret string) int {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + payload))
+
+		req := httptest.NewRequest("POST", "/api/webhooks/stripe", bytes.NewBufferString(payload))
+		req.Header.Set("Stripe-Signature", "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+		w := httptest.NewRecorder()
+		handler.StripeWebhook(w, req)
+		return w.Code
+	}
+
+	paid := `{"id": "evt_1", "type": "checkout.session.completed", "data": {"object": {"id": "cs_1", "amount_total": 1000, "currency": "usd", "payment_status": "paid", "metadata": {"purpose": "dues"}}}}`
+	if code := send(paid, "whsec_other"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad signature, got %d", code)
+	}
+	if code := send(paid, "whsec_test"); code != http.StatusOK || len(ledger.checkout) != 1 {
+		t.Errorf("Expected the checkout to be recorded, got %d %+v", code, ledger.checkout)
+	}
+	// Stripe redelivers events; the payment is only recorded once
+	if code := send(paid, "whsec_test"); code != http.StatusOK || len(ledger.checkout) != 1 {
+		t.Errorf("Expected a redelivery to be acknowledged once, got %d %+v", code, ledger.checkout)
+	}
+
+	other := `{"id": "evt_2", "type": "checkout.session.completed", "data": {"object": {"id": "cs_2", "payment_status": "paid", "metadata": {"purpose": "merch"}}}}`
+	if code := send(other, "whsec_test"); code != http.StatusOK || len(ledger.checkout) != 1 {
+		t.Errorf("Expected other purposes to be ignored, got %d", code)
+	}
+}
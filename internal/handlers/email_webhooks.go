@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/bounces"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// suppressionStore is the part of bounces.Store the email webhook handler uses
+type suppressionStore interface {
+	Suppress(ctx context.Context, event bounces.Event) (bounces.Suppression, bool, error)
+	Recipient(ctx context.Context, email string) (bounces.Recipient, bool, error)
+	Lift(ctx context.Context, email string) error
+}
+
+// clubManagerNotifier notifies a club's owners and moderators; *notify.Notifier implements it
+type clubManagerNotifier interface {
+	NotifyClubManagers(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error)
+}
+
+// maxEmailWebhookBytes bounds email webhook bodies, batches of up to
+// bounces.MaxBatchEvents events
+const maxEmailWebhookBytes = 256 << 10
+
+// EmailWebhookHandler receives bounce and complaint reports from the email
+// service provider, stops email to the addresses concerned and tells the
+// managers of their clubs to follow up another way
+type EmailWebhookHandler struct {
+	clocked
+
+	secret       string
+	suppressions suppressionStore
+	notifier     clubManagerNotifier // nil when nobody is notified
+}
+
+func NewEmailWebhookHandler(secret string, suppressions suppressionStore) *EmailWebhookHandler {
+	return &EmailWebhookHandler{secret: secret, suppressions: suppressions}
+}
+
+// WithNotifier notifies club managers of members whose address was suppressed
+func (h *EmailWebhookHandler) WithNotifier(notifier clubManagerNotifier) *EmailWebhookHandler {
+	h.notifier = notifier
+	return h
+}
+
+// EmailWebhook verifies a batch of provider events and suppresses the
+// addresses that hard-bounced or complained. Other events are acknowledged
+// and ignored so the provider stops retrying them.
+func (h *EmailWebhookHandler) EmailWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.secret == "" {
+		h.writeError(w, apierror.NotFound("Email webhooks are not configured"))
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEmailWebhookBytes))
+	if err != nil {
+		h.writeError(w, apierror.New(apierror.CodeRequestTooLarge, "Webhook body is too large", nil))
+		return
+	}
+
+	if err := bounces.VerifySignature(payload, r.Header.Get(bounces.SignatureHeader), h.secret, h.now()); err != nil {
+		logging.FromContext(r.Context()).Warn("rejected email webhook", "error", err)
+		h.writeError(w, apierror.New(apierror.CodeInvalidSignature, "Invalid webhook signature", nil))
+		return
+	}
+
+	var batch bounces.Batch
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		h.writeError(w, apierror.Validation("Malformed events", models.InvalidField("", "json", "Body must be valid JSON")))
+		return
+	}
+	if len(batch.Events) > bounces.MaxBatchEvents {
+		h.writeError(w, apierror.Validation("Too many events", models.InvalidField("events", "max", "must hold at most 500 events")))
+		return
+	}
+
+	// Suppressing is idempotent, so a failure returns 500 and the provider
+	// retries the whole batch
+	suppressed := 0
+	for _, event := range batch.Events {
+		if !event.Suppresses() {
+			continue
+		}
+		suppression, created, err := h.suppressions.Suppress(r.Context(), event)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error suppressing address", "error", err, "event", event.ID)
+			h.writeError(w, apierror.Internal("Failed to record events"))
+			return
+		}
+		suppressed++
+		if created {
+			h.notifyManagers(r.Context(), suppression)
+		}
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"received": len(batch.Events), "suppressed": suppressed}, "Events recorded")
+}
+
+// LiftSuppression lets email go to an address again once its owner has fixed it
+func (h *EmailWebhookHandler) LiftSuppression(w http.ResponseWriter, r *http.Request) {
+	email := chi.URLParam(r, "email")
+	if err := h.suppressions.Lift(r.Context(), email); err != nil {
+		if errors.Is(err, bounces.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Address is not suppressed"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error lifting suppression", "error", err)
+		h.writeError(w, apierror.Internal("Failed to lift suppression"))
+		return
+	}
+	audit.Describe(r.Context(), "email_suppression", email, nil)
+
+	h.writeSuccessResponse(w, nil, "Suppression lifted successfully")
+}
+
+// notifyManagers tells the managers of every club the address's owner is a
+// member of; the address is suppressed either way
+func (h *EmailWebhookHandler) notifyManagers(ctx context.Context, suppression bounces.Suppression) {
+	if h.notifier == nil {
+		return
+	}
+	recipient, ok, err := h.suppressions.Recipient(ctx, suppression.Email)
+	if err != nil {
+		logging.FromContext(ctx).Error("error finding suppressed address owner", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	notification := models.Notification{
+		Type:  notify.TypeEmailUndeliverable,
+		Title: "Email to " + recipient.Name + " is bouncing",
+		Body:  "Their address no longer accepts email, so they will miss email reminders. Please check their address with them another way.",
+	}
+	if suppression.Reason == bounces.TypeComplaint {
+		notification.Title = recipient.Name + " marked club email as spam"
+		notification.Body = "They will not be sent email any more. Please check with them another way whether they still want reminders."
+	}
+
+	for _, clubID := range recipient.ClubIDs {
+		if _, err := h.notifier.NotifyClubManagers(ctx, clubID, notification); err != nil {
+			logging.FromContext(ctx).Error("error notifying club managers", "error", err, "club", clubID)
+		}
+	}
+}
+
+func (h *EmailWebhookHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EmailWebhookHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/bounces"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakeSuppressions struct {
+	suppressed map[string]bounces.Suppression
+	recipients map[string]bounces.Recipient
+}
+
+func (f *fakeSuppressions) Suppress(ctx context.Context, event bounces.Event) (bounces.Suppression, bool, error) {
+	email := strings.ToLower(event.Email)
+	if existing, ok := f.suppressed[email]; ok {
+		return existing, false, nil
+	}
+	s := bounces.Suppression{Email: email, Reason: event.Type, Detail: event.Reason, SuppressedAt: emailWebhookTestNow}
+	f.suppressed[email] = s
+	return s, true, nil
+}
+
+func (f *fakeSuppressions) Recipient(ctx context.Context, email string) (bounces.Recipient, bool, error) {
+	r, ok := f.recipients[email]
+	return r, ok, nil
+}
+
+func (f *fakeSuppressions) Lift(ctx context.Context, email string) error {
+	if _, ok := f.suppressed[email]; !ok {
+		return bounces.ErrNotFound
+	}
+	delete(f.suppressed, email)
+	return nil
+}
+
+type recordingManagerNotifier struct {
+	notified []ownerNotification
+}
+
+func (n *recordingManagerNotifier) NotifyClubManagers(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error) {
+	n.notified = append(n.notified, ownerNotification{clubID, notification})
+	return 2, nil
+}
+
+var emailWebhookTestNow = time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+const emailWebhookTestSecret = "email-secret"
+
+func setupEmailWebhookTest() (*fakeSuppressions, *recordingManagerNotifier, chi.Router) {
+	store := &fakeSuppressions{suppressed: map[string]bounces.Suppression{}, recipients: map[string]bounces.Recipient{}}
+	notifier := &recordingManagerNotifier{}
+	handler := NewEmailWebhookHandler(emailWebhookTestSecret, store).WithNotifier(notifier)
+	handler.clock = clock.NewFake(emailWebhookTestNow)
+
+	router := chi.NewRouter()
+	router.Post("/webhooks/email", handler.EmailWebhook)
+	router.Delete("/admin/email-suppressions/{email}", handler.LiftSuppression)
+	return store, notifier, router
+}
+
+func signedEmailWebhook(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/email", strings.NewReader(body))
+	signature := hex.EncodeToString(bounces.Sign([]byte(body), emailWebhookTestSecret, emailWebhookTestNow))
+	req.Header.Set(bounces.SignatureHeader, "t="+strconv.FormatInt(emailWebhookTestNow.Unix(), 10)+",v1="+signature)
+	return req
+}
+
+func TestEmailWebhookSuppressesAndNotifies(t *testing.T) {
+	store, notifier, router := setupEmailWebhookTest()
+	clubA, clubB := uuid.New(), uuid.New()
+	store.recipients["ada@example.com"] = bounces.Recipient{UserID: uuid.New(), Name: "Ada", ClubIDs: []uuid.UUID{clubA, clubB}}
+
+	body := `{"events": [
+		{"id": "e1", "type": "bounce", "bounceType": "hard", "email": "Ada@example.com", "reason": "mailbox does not exist"},
+		{"id": "e2", "type": "bounce", "bounceType": "soft", "email": "bob@example.com"},
+		{"id": "e3", "type": "delivery", "email": "cy@example.com"}
+	]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedEmailWebhook(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"suppressed":1`) {
+		t.Errorf("Expected one suppressed address, got %s", w.Body.String())
+	}
+	if _, ok := store.suppressed["ada@example.com"]; !ok || len(store.suppressed) != 1 {
+		t.Errorf("Expected only the hard bounce to be suppressed, got %v", store.suppressed)
+	}
+	if len(notifier.notified) != 2 || notifier.notified[0].clubID != clubA || notifier.notified[1].clubID != clubB {
+		t.Fatalf("Expected the managers of both clubs to be notified, got %+v", notifier.notified)
+	}
+	if notifier.notified[0].notification.Title != "Email to Ada is bouncing" {
+		t.Errorf("Unexpected title %q", notifier.notified[0].notification.Title)
+	}
+
+	// A repeated report does not notify again
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, signedEmailWebhook(body))
+	if w.Code != http.StatusOK || len(notifier.notified) != 2 {
+		t.Errorf("Expected a repeated bounce to be acknowledged without notifying, got %d and %d notifications", w.Code, len(notifier.notified))
+	}
+}
+
+func TestEmailWebhookComplaint(t *testing.T) {
+	store, notifier, router := setupEmailWebhookTest()
+	store.recipients["ada@example.com"] = bounces.Recipient{UserID: uuid.New(), Name: "Ada", ClubIDs: []uuid.UUID{uuid.New()}}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedEmailWebhook(`{"events": [{"type": "complaint", "email": "ada@example.com"}]}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(notifier.notified) != 1 || notifier.notified[0].notification.Title != "Ada marked club email as spam" {
+		t.Errorf("Expected a complaint notification, got %+v", notifier.notified)
+	}
+}
+
+func TestEmailWebhookRejectsBadSignature(t *testing.T) {
+	store, _, router := setupEmailWebhookTest()
+
+	req := signedEmailWebhook(`{"events": [{"type": "complaint", "email": "ada@example.com"}]}`)
+	req.Header.Set(bounces.SignatureHeader, "t=1,v1=00")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+	if len(store.suppressed) != 0 {
+		t.Errorf("Expected nothing to be suppressed, got %v", store.suppressed)
+	}
+}
+
+func TestEmailWebhookNotConfigured(t *testing.T) {
+	handler := NewEmailWebhookHandler("", &fakeSuppressions{})
+
+	w := httptest.NewRecorder()
+	handler.EmailWebhook(w, signedEmailWebhook(`{"events": []}`))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+}
+
+func TestLiftSuppression(t *testing.T) {
+	store, _, router := setupEmailWebhookTest()
+	store.suppressed["ada@example.com"] = bounces.Suppression{Email: "ada@example.com", Reason: bounces.TypeBounce}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/email-suppressions/ada@example.com", nil))
+	if w.Code != http.StatusOK || len(store.suppressed) != 0 {
+		t.Errorf("Expected the suppression to be lifted, got %d and %v", w.Code, store.suppressed)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/email-suppressions/ada@example.com", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an address that is not suppressed, got %d", w.Code)
+	}
+}
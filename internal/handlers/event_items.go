@@ -3,76 +3,81 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"math"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
 	"bookwork-api/internal/auth"
-	"bookwork-api/internal/database"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/changes"
+	"bookwork-api/internal/logging"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/shopping"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/timeutil"
+	"bookwork-api/internal/vocab"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type EventItemHandler struct {
-	db *database.DB
+	clocked
+
+	stores     *store.Stores
+	vocabulary vocabularyReader // the club's item categories
+	changes    changeRecorder   // the club's change log, for its streams
+	notifier   userNotifier     // tells members about items assigned to or claimed from them
 }
 
-func NewEventItemHandler(db *database.DB) *EventItemHandler {
-	return &EventItemHandler{db: db}
+func NewEventItemHandler(stores *store.Stores) *EventItemHandler {
+	return &EventItemHandler{stores: stores}
 }
 
-func (h *EventItemHandler) GetItems(w http.ResponseWriter, r *http.Request) {
-	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
-		return
-	}
+// WithVocabulary validates item categories against each club's own list
+// instead of the defaults
+func (h *EventItemHandler) WithVocabulary(vocabulary vocabularyReader) *EventItemHandler {
+	h.vocabulary = vocabulary
+	return h
+}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
-		return
-	}
+// WithChanges records item changes in the club's change log
+func (h *EventItemHandler) WithChanges(changes changeRecorder) *EventItemHandler {
+	h.changes = changes
+	return h
+}
 
-	// Check if user can access this event
-	if !h.canAccessEvent(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Access denied", nil)
-		return
-	}
+// WithNotifier tells assignees when items are assigned to them or taken from
+// them, and organizers when members claim items
+func (h *EventItemHandler) WithNotifier(notifier userNotifier) *EventItemHandler {
+	h.notifier = notifier
+	return h
+}
 
-	query := `
-		SELECT id, event_id, name, category, assigned_to, status, notes, created_by, created_at, updated_at
-		FROM event_items
-		WHERE event_id = $1
-		ORDER BY created_at ASC`
+// recordChange records a change to an item of the event loaded for the route
+func (h *EventItemHandler) recordChange(ctx context.Context, typ string, eventID, itemID uuid.UUID) {
+	if event, ok := authz.EventFromContext(ctx); ok {
+		recordChange(ctx, h.changes, event.ClubID, typ, eventID, itemID)
+	}
+}
 
-	rows, err := h.db.QueryContext(r.Context(), query, eventID)
+func (h *EventItemHandler) GetItems(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		log.Printf("Error querying event items: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get items", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
-	defer rows.Close()
 
-	var items []models.EventItem
-	for rows.Next() {
-		var item models.EventItem
-
-		err := rows.Scan(
-			&item.ID, &item.EventID, &item.Name, &item.Category,
-			&item.AssignedTo, &item.Status, &item.Notes, &item.CreatedBy,
-			&item.CreatedAt, &item.UpdatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning item: %v", err)
-			continue
-		}
-
-		items = append(items, item)
+	items, err := h.stores.EventItems.ListByEvent(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying event items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get items"))
+		return
 	}
 
 	// Transform items to frontend format
@@ -91,68 +96,47 @@ func (h *EventItemHandler) GetItems(w http.ResponseWriter, r *http.Request) {
 func (h *EventItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
 	// Check if user can manage items for this event
-	if !h.canManageEventItems(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
 		return
 	}
 
 	var req models.CreateEventItemRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	// Validate required fields
-	if req.Item.Name == "" || req.Item.Category == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Name and category are required", nil)
+	event, _ := authz.EventFromContext(r.Context())
+	item, derr := h.newItem(r.Context(), event, "item.", req.Item, userID)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	// Validate category
-	validCategories := []string{"food", "materials", "logistics", "discussion", "presentation", "other"}
-	if !h.contains(validCategories, req.Item.Category) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid category", nil)
+	if err := h.stores.EventItems.Create(r.Context(), item); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating event item", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create item"))
 		return
 	}
 
-	// Create item
-	itemID := uuid.New()
-	query := `
-		INSERT INTO event_items (id, event_id, name, category, assigned_to, status, notes, created_by) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-
-	_, err = h.db.ExecContext(r.Context(), query,
-		itemID, eventID, req.Item.Name, req.Item.Category,
-		req.Item.AssignedTo, "pending", req.Item.Notes, userID,
-	)
-	if err != nil {
-		log.Printf("Error creating event item: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create item", nil)
-		return
-	}
-
-	item := &models.EventItem{
-		ID:         itemID,
-		EventID:    eventID,
-		Name:       req.Item.Name,
-		Category:   req.Item.Category,
-		AssignedTo: req.Item.AssignedTo,
-		Status:     "pending",
-		Notes:      req.Item.Notes,
-		CreatedBy:  userID,
-		CreatedAt:  time.Now(),
-	}
+	h.recordChange(r.Context(), changes.ItemCreated, eventID, item.ID)
+	h.notifyAssignment(r.Context(), event, item, nil, userID)
 
 	response := map[string]interface{}{
 		"item": item,
@@ -165,83 +149,100 @@ func (h *EventItemHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
 func (h *EventItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
 	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid item ID", nil)
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
 	// Check permissions
-	if !h.canManageEventItems(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
 		return
 	}
 
-	var req models.UpdateEventItemRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	ifVersion, derr := ifMatchVersion(r)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	// Build update query
-	setParts := []string{}
-	args := []interface{}{}
-	argCount := 0
-
-	if req.Status != "" {
-		validStatuses := []string{"pending", "assigned", "confirmed", "completed"}
-		if h.contains(validStatuses, req.Status) {
-			argCount++
-			setParts = append(setParts, "status = $"+strconv.Itoa(argCount))
-			args = append(args, req.Status)
-		}
+	var req models.UpdateEventItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
 	}
 
-	if req.Notes != nil {
-		argCount++
-		setParts = append(setParts, "notes = $"+strconv.Itoa(argCount))
-		args = append(args, *req.Notes)
+	event, _ := authz.EventFromContext(r.Context())
+	update, derr := h.itemUpdate(r.Context(), event, "", req)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
 	}
+	fieldsChanged := update != (store.EventItemUpdate{})
 
-	if len(setParts) == 0 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "No fields to update", nil)
+	if !fieldsChanged && req.AssignedTo == nil {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
 		return
 	}
 
-	argCount++
-	args = append(args, itemID)
-	argCount++
-	args = append(args, eventID)
-
-	query := `UPDATE event_items SET ` + strings.Join(setParts, ", ") + `, updated_at = NOW() WHERE id = $` + strconv.Itoa(argCount-1) + ` AND event_id = $` + strconv.Itoa(argCount)
-
-	result, err := h.db.ExecContext(r.Context(), query, args...)
-	if err != nil {
-		log.Printf("Error updating event item: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update item", nil)
+	if derr, err := h.checkAssignee(r.Context(), event.ClubID, "assignedTo", req.AssignedTo); err != nil {
+		logging.FromContext(r.Context()).Error("error checking item assignee", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update item"))
+		return
+	} else if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Item not found", nil)
-		return
+	// Reassign first, so a missing item is found before anything is written.
+	// The first write checks the version the client edited.
+	var assigned *models.EventItem
+	var previous *uuid.UUID
+	if req.AssignedTo != nil {
+		assigned, previous, err = h.stores.EventItems.Assign(r.Context(), eventID, itemID, req.AssignedTo, ifVersion)
+		if err != nil {
+			h.writeUpdateError(w, r, eventID, itemID, err)
+			return
+		}
+	} else {
+		update.IfVersion = ifVersion
+	}
+	if fieldsChanged {
+		if err := h.stores.EventItems.Update(r.Context(), eventID, itemID, update); err != nil {
+			h.writeUpdateError(w, r, eventID, itemID, err)
+			return
+		}
+	}
+	// Invalid statuses are ignored rather than rejected, so log what was applied
+	applied := models.UpdateEventItemRequest{Notes: update.Notes, Quantity: update.Quantity, AssignedTo: req.AssignedTo, DueDate: req.DueDate}
+	if update.Status != nil {
+		applied.Status = *update.Status
+	}
+	if update.Cost != nil {
+		amount := money.Decimal(update.Cost.String())
+		applied.Cost, applied.CostCurrency = &amount, &update.Cost.Currency
+	}
+	audit.Describe(r.Context(), "event_item", itemID.String(), audit.Diff(nil, applied))
+	h.recordChange(r.Context(), changes.ItemUpdated, eventID, itemID)
+	if assigned != nil {
+		h.notifyAssignment(r.Context(), event, assigned, previous, userID)
 	}
 
 	response := map[string]interface{}{
 		"item": map[string]interface{}{
 			"id":        itemID,
-			"updatedAt": time.Now(),
+			"updatedAt": h.now(),
 		},
 	}
 
@@ -251,49 +252,572 @@ func (h *EventItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	if req.Notes != nil {
 		response["item"].(map[string]interface{})["notes"] = *req.Notes
 	}
+	if update.DueDate != nil {
+		response["item"].(map[string]interface{})["dueDate"] = *update.DueDate
+	} else if update.ClearDueDate {
+		response["item"].(map[string]interface{})["dueDate"] = nil
+	}
+	if assigned != nil {
+		response["item"].(map[string]interface{})["assignedTo"] = assigned.AssignedTo
+		if update.Status == nil {
+			response["item"].(map[string]interface{})["status"] = assigned.Status
+		}
+	}
+	if req.Quantity != nil {
+		response["item"].(map[string]interface{})["quantity"] = *req.Quantity
+	}
+	if update.Cost != nil {
+		response["item"].(map[string]interface{})["cost"] = *update.Cost
+		response["item"].(map[string]interface{})["exchangeRate"] = *update.ExchangeRate
+	}
+	if current, err := h.stores.EventItems.Get(r.Context(), eventID, itemID); err == nil {
+		response["item"].(map[string]interface{})["version"] = current.Version
+		w.Header().Set("ETag", versionETag(current.Version))
+	}
 
 	h.writeSuccessResponse(w, response, "Item updated successfully")
 }
 
-func (h *EventItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+// itemPatch holds the fields of an item that PatchItem can change, as a merge
+// patch document. Notes, quantity, cost, due date and assignee may be cleared
+// with null.
+type itemPatch struct {
+	Status       string         `json:"status" validate:"required,oneof=pending assigned confirmed completed"`
+	AssignedTo   *uuid.UUID     `json:"assignedTo"`
+	Notes        *string        `json:"notes" sanitize:"markdown"`
+	Quantity     *float64       `json:"quantity"`
+	Cost         *money.Decimal `json:"cost"`
+	CostCurrency *string        `json:"costCurrency"`
+	DueDate      *string        `json:"dueDate"`
+}
+
+func newItemPatch(item *models.EventItem, event *models.Event) itemPatch {
+	patch := itemPatch{
+		Status:     item.Status,
+		AssignedTo: item.AssignedTo,
+		Notes:      item.Notes,
+		Quantity:   item.Quantity,
+	}
+	if item.Cost != nil {
+		amount := money.Decimal(item.Cost.String())
+		patch.Cost, patch.CostCurrency = &amount, &item.Cost.Currency
+	}
+	if item.DueDate != nil {
+		due := timeutil.FormatTimestampIn(*item.DueDate, event.TimeLocation())
+		patch.DueDate = &due
+	}
+	return patch
+}
+
+// PatchItem changes an item with a JSON merge patch. Unlike UpdateItem it
+// refuses unknown fields and invalid statuses, and null clears a field.
+func (h *EventItemHandler) PatchItem(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
 	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid item ID", nil)
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
-	// Check permissions
-	if !h.canManageEventItems(r.Context(), eventID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	ifVersion, derr := ifMatchVersion(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	item, err := h.stores.EventItems.Get(r.Context(), eventID, itemID)
+	if err != nil {
+		h.writeUpdateError(w, r, eventID, itemID, err)
+		return
+	}
+	if versionMismatch(ifVersion, item.Version) {
+		h.writeVersionConflict(w, item)
+		return
+	}
+
+	event, _ := authz.EventFromContext(r.Context())
+	patch := newItemPatch(item, event)
+	fields, derr := decodeMergePatch(w, r, &patch)
+	if derr == nil {
+		derr = validateRequest(&patch)
+	}
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	update, reassign, derr := h.patchUpdate(r.Context(), event, patch, fields)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	if reassign {
+		if derr, err := h.checkAssignee(r.Context(), event.ClubID, "assignedTo", patch.AssignedTo); err != nil {
+			logging.FromContext(r.Context()).Error("error checking item assignee", "error", err)
+			h.writeError(w, apierror.Internal("Failed to update item"))
+			return
+		} else if derr != nil {
+			h.writeError(w, derr)
+			return
+		}
+	}
+
+	// Reassigning moves pending and assigned statuses along, so it goes
+	// first and an explicit status in the patch wins. The first write checks
+	// the version again, in case the item changed since it was read.
+	var assigned *models.EventItem
+	var previous *uuid.UUID
+	if reassign {
+		assigned, previous, err = h.stores.EventItems.Assign(r.Context(), eventID, itemID, patch.AssignedTo, ifVersion)
+		if err != nil {
+			h.writeUpdateError(w, r, eventID, itemID, err)
+			return
+		}
+	} else {
+		update.IfVersion = ifVersion
+	}
+	if update != (store.EventItemUpdate{IfVersion: update.IfVersion}) {
+		if err := h.stores.EventItems.Update(r.Context(), eventID, itemID, update); err != nil {
+			h.writeUpdateError(w, r, eventID, itemID, err)
+			return
+		}
+	}
+
+	updated, err := h.stores.EventItems.Get(r.Context(), eventID, itemID)
+	if err != nil {
+		h.writeUpdateError(w, r, eventID, itemID, err)
 		return
 	}
+	audit.Describe(r.Context(), "event_item", itemID.String(), audit.Diff(item, updated))
+	h.recordChange(r.Context(), changes.ItemUpdated, eventID, itemID)
+	if assigned != nil {
+		h.notifyAssignment(r.Context(), event, updated, previous, userID)
+	}
+
+	w.Header().Set("ETag", versionETag(updated.Version))
+	h.writeSuccessResponse(w, map[string]interface{}{"item": updated}, "Item updated successfully")
+}
+
+// patchUpdate turns the patched fields of an item into a store update, and
+// reports whether the assignee is among them
+func (h *EventItemHandler) patchUpdate(ctx context.Context, event *models.Event, patch itemPatch, fields []string) (store.EventItemUpdate, bool, *apierror.Error) {
+	var update store.EventItemUpdate
+	var reassign, repriced bool
+
+	for _, field := range fields {
+		switch field {
+		case "status":
+			update.Status = &patch.Status
+		case "assignedTo":
+			reassign = true
+		case "notes":
+			update.Notes, update.ClearNotes = patch.Notes, patch.Notes == nil
+		case "quantity":
+			if derr := validateItemQuantity("quantity", patch.Quantity); derr != nil {
+				return update, false, derr
+			}
+			update.Quantity, update.ClearQuantity = patch.Quantity, patch.Quantity == nil
+		case "cost", "costCurrency":
+			repriced = true
+		case "dueDate":
+			if patch.DueDate == nil {
+				update.ClearDueDate = true
+				continue
+			}
+			due, derr := itemDueDate("dueDate", *patch.DueDate, event)
+			if derr != nil {
+				return update, false, derr
+			}
+			update.DueDate = &due
+		}
+	}
+
+	if repriced {
+		// Clearing the cost clears its currency too
+		if patch.Cost == nil && !h.contains(fields, "costCurrency") {
+			patch.CostCurrency = nil
+		}
+		cost, rate, derr := h.resolveCost(ctx, "", patch.Cost, patch.CostCurrency)
+		if derr != nil {
+			return update, false, derr
+		}
+		update.Cost, update.ExchangeRate, update.ClearCost = cost, rate, cost == nil
+	}
+	return update, reassign, nil
+}
+
+// writeUpdateError answers a failed item update
+func (h *EventItemHandler) writeUpdateError(w http.ResponseWriter, r *http.Request, eventID, itemID uuid.UUID, err error) {
+	if err == store.ErrNotFound {
+		h.writeError(w, apierror.NotFound("Item not found"))
+		return
+	}
+	if err == store.ErrVersionConflict {
+		if current, err := h.stores.EventItems.Get(r.Context(), eventID, itemID); err == nil {
+			h.writeVersionConflict(w, current)
+			return
+		}
+	}
+	if verr := violationError(r.Context(), err); verr != nil {
+		h.writeError(w, verr)
+		return
+	}
+	logging.FromContext(r.Context()).Error("error updating event item", "error", err)
+	h.writeError(w, apierror.Internal("Failed to update item"))
+}
+
+// writeVersionConflict refuses an edit of an item that changed since the
+// client read it, answering with the item as it is now
+func (h *EventItemHandler) writeVersionConflict(w http.ResponseWriter, current *models.EventItem) {
+	derr := versionConflict(current, current.Version)
+	w.Header().Set("ETag", versionETag(current.Version))
+	h.writeError(w, derr)
+}
+
+// bulkItemResult is what became of one operation of a bulk request
+type bulkItemResult struct {
+	Op      string                 `json:"op"`
+	ItemID  *uuid.UUID             `json:"itemId,omitempty"`
+	Status  int                    `json:"status"`
+	Item    *models.EventItem      `json:"item,omitempty"` // for created items
+	Error   apierror.Code          `json:"error,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// fail records why the operation was refused
+func (res *bulkItemResult) fail(derr *apierror.Error) {
+	res.Status, res.Error, res.Message, res.Details = derr.Status, derr.Code, derr.Message, derr.Details
+}
 
-	query := `DELETE FROM event_items WHERE id = $1 AND event_id = $2`
-	result, err := h.db.ExecContext(r.Context(), query, itemID, eventID)
+// BulkItems creates, updates and deletes many of the event's items in one
+// transaction, for checklist editors saving several changes at once. Either
+// every operation is made or none is: the response lists each operation's
+// result, and operations that were not made because another failed have
+// status 424.
+func (h *EventItemHandler) BulkItems(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		log.Printf("Error deleting event item: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete item", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Item not found", nil)
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
 		return
 	}
 
+	var req models.BulkEventItemsRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	event, _ := authz.EventFromContext(r.Context())
+	ops := make([]store.EventItemOp, len(req.Operations))
+	results := make([]bulkItemResult, len(req.Operations))
+	var invalid *apierror.Error
+	for i, operation := range req.Operations {
+		prefix := fmt.Sprintf("operations[%d].", i)
+		results[i] = bulkItemResult{Op: operation.Op, ItemID: operation.ItemID}
+
+		op, derr := h.bulkOp(r.Context(), event, prefix, operation, userID)
+		if derr != nil {
+			if derr.Status == http.StatusInternalServerError {
+				h.writeError(w, apierror.New(derr.Code, derr.Message, nil))
+				return
+			}
+			results[i].fail(derr)
+			if invalid == nil {
+				invalid = derr
+			}
+			continue
+		}
+		ops[i] = op
+	}
+	if invalid != nil {
+		h.writeBulkFailure(w, results, invalid)
+		return
+	}
+
+	failed, err := h.stores.EventItems.Apply(r.Context(), eventID, ops)
+	if err != nil {
+		if failed < 0 {
+			logging.FromContext(r.Context()).Error("error applying event item operations", "error", err)
+			h.writeError(w, apierror.Internal("Failed to change items"))
+			return
+		}
+		derr := apierror.NotFound("Item not found")
+		if err != store.ErrNotFound {
+			if derr = violationError(r.Context(), err); derr == nil {
+				logging.FromContext(r.Context()).Error("error applying event item operations", "index", failed, "error", err)
+				h.writeError(w, apierror.Internal("Failed to change items"))
+				return
+			}
+		}
+		results[failed].fail(derr)
+		h.writeBulkFailure(w, results, derr)
+		return
+	}
+
+	audit.Describe(r.Context(), "event", eventID.String(), audit.Diff(nil, req))
+	for i, op := range ops {
+		switch {
+		case op.Create != nil:
+			results[i].Status, results[i].ItemID, results[i].Item = http.StatusCreated, &op.Create.ID, op.Create
+			h.recordChange(r.Context(), changes.ItemCreated, eventID, op.Create.ID)
+			h.notifyAssignment(r.Context(), event, op.Create, nil, userID)
+		case op.Delete:
+			results[i].Status = http.StatusOK
+			h.recordChange(r.Context(), changes.ItemDeleted, eventID, op.ItemID)
+		default:
+			results[i].Status = http.StatusOK
+			h.recordChange(r.Context(), changes.ItemUpdated, eventID, op.ItemID)
+		}
+	}
+
+	response := map[string]interface{}{
+		"results": results,
+	}
+
+	h.writeSuccessResponse(w, response, "Items changed successfully")
+}
+
+// bulkOp checks one operation of a bulk request as its own endpoint would
+func (h *EventItemHandler) bulkOp(ctx context.Context, event *models.Event, prefix string, operation models.BulkEventItemOperation, userID uuid.UUID) (store.EventItemOp, *apierror.Error) {
+	if operation.Op == "create" {
+		if operation.Item == nil {
+			return store.EventItemOp{}, invalidField(prefix+"item", "required", "is required to create an item", "Item is required")
+		}
+		item, derr := h.newItem(ctx, event, prefix+"item.", *operation.Item, userID)
+		if derr != nil {
+			return store.EventItemOp{}, derr
+		}
+		return store.EventItemOp{Create: item}, nil
+	}
+
+	if operation.ItemID == nil {
+		return store.EventItemOp{}, invalidField(prefix+"itemId", "required", "is required to "+operation.Op+" an item", "Item ID is required")
+	}
+	if operation.Op == "delete" {
+		return store.EventItemOp{ItemID: *operation.ItemID, Delete: true}, nil
+	}
+
+	if operation.Update == nil {
+		return store.EventItemOp{}, invalidField(prefix+"update", "required", "is required to update an item", "Update is required")
+	}
+	if operation.Update.AssignedTo != nil {
+		// Reassignments notify who had the item, so they go through PUT one at a time
+		return store.EventItemOp{}, invalidField(prefix+"update.assignedTo", "unsupported", "cannot be changed in bulk", "Items are reassigned one at a time")
+	}
+	update, derr := h.itemUpdate(ctx, event, prefix+"update.", *operation.Update)
+	if derr != nil {
+		return store.EventItemOp{}, derr
+	}
+	if update == (store.EventItemUpdate{}) {
+		return store.EventItemOp{}, invalidField(prefix+"update", "required", "Give at least one field to update", "No fields to update")
+	}
+	return store.EventItemOp{ItemID: *operation.ItemID, Update: &update}, nil
+}
+
+// writeBulkFailure answers a bulk request none of whose operations were made,
+// with the status of the first that failed
+func (h *EventItemHandler) writeBulkFailure(w http.ResponseWriter, results []bulkItemResult, first *apierror.Error) {
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i].Status, results[i].Error = apierror.Status(apierror.CodeNotApplied), apierror.CodeNotApplied
+		}
+	}
+	h.writeError(w, apierror.New(first.Code, "No items were changed: "+first.Message, map[string]interface{}{"results": results}))
+}
+
+// ReorderItems puts the event's checklist in a new order, as after a
+// drag-and-drop. The request lists every item of the event once; a list that
+// no longer matches, because an item was added or removed meanwhile, is refused
+// so the client can reload and try again.
+func (h *EventItemHandler) ReorderItems(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.ReorderEventItemsRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if err := h.stores.EventItems.Reorder(r.Context(), eventID, req.ItemIDs); err != nil {
+		if err == store.ErrItemsChanged {
+			h.writeError(w, apierror.New(apierror.CodeItemsChanged, "The items listed are not the event's current items", nil))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reordering event items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to reorder items"))
+		return
+	}
+	audit.Describe(r.Context(), "event", eventID.String(), audit.Diff(nil, req))
+	h.recordChange(r.Context(), changes.ItemsReordered, eventID, eventID)
+
+	items, err := h.stores.EventItems.ListByEvent(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting event items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get items"))
+		return
+	}
+	frontendItems := []*models.FrontendEventItem{}
+	for _, item := range items {
+		frontendItems = append(frontendItems, item.ToFrontendFormat())
+	}
+
+	response := map[string]interface{}{
+		"items": frontendItems,
+	}
+
+	h.writeSuccessResponse(w, response, "Items reordered successfully")
+}
+
+// ClaimItem lets any member of the club volunteer for an unassigned item. The
+// event's organizer is told who took it.
+func (h *EventItemHandler) ClaimItem(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// Any role in the club will do; authz.RequireEventRole has checked there is one
+	event, ok := authz.EventFromContext(r.Context())
+	if !ok {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	item, err := h.stores.EventItems.Claim(r.Context(), eventID, itemID, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.writeError(w, apierror.NotFound("Item not found"))
+		case store.ErrAlreadyAssigned:
+			h.writeError(w, apierror.New(apierror.CodeAlreadyAssigned, "Someone is already bringing this item", nil))
+		default:
+			logging.FromContext(r.Context()).Error("error claiming event item", "error", err)
+			h.writeError(w, apierror.Internal("Failed to claim item"))
+		}
+		return
+	}
+	audit.Describe(r.Context(), "event_item", itemID.String(), audit.Diff(nil, models.UpdateEventItemRequest{AssignedTo: &userID}))
+	h.recordChange(r.Context(), changes.ItemUpdated, eventID, itemID)
+
+	if event.CreatedBy != userID {
+		name := "A member"
+		if user, err := h.stores.Users.GetByID(r.Context(), userID); err == nil {
+			name = user.Name
+		}
+		h.notify(r.Context(), event.CreatedBy, models.Notification{
+			Type:    notify.TypeItemClaimed,
+			Title:   "Item claimed",
+			Body:    name + " volunteered for " + item.Name + " for " + event.Title + ".",
+			ClubID:  &event.ClubID,
+			EventID: &event.ID,
+		})
+	}
+
+	response := map[string]interface{}{
+		"item": item,
+	}
+
+	h.writeSuccessResponse(w, response, "Item claimed successfully")
+}
+
+func (h *EventItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	// Check permissions
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	if err := h.stores.EventItems.Delete(r.Context(), eventID, itemID); err != nil {
+		if err == store.ErrNotFound {
+			h.writeError(w, apierror.NotFound("Item not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting event item", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete item"))
+		return
+	}
+	audit.Describe(r.Context(), "event_item", itemID.String(), nil)
+	h.recordChange(r.Context(), changes.ItemDeleted, eventID, itemID)
+
 	response := map[string]string{
 		"message": "Item deleted successfully",
 	}
@@ -301,32 +825,367 @@ func (h *EventItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Item deleted successfully")
 }
 
+// GetShoppingList consolidates the event's food items into one shopping list,
+// broken down per assignee, with the cost split evenly among the attendees
+func (h *EventItemHandler) GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	items, err := h.stores.EventItems.ListByEvent(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying event items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get shopping list"))
+		return
+	}
+
+	assignedTo, err := h.stores.EventItems.ShoppingListAssignee(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting shopping list assignee", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get shopping list"))
+		return
+	}
+
+	// Members who said they will attend share the cost
+	responses, err := h.stores.Availability.ListByEvent(r.Context(), eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying availability", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get shopping list"))
+		return
+	}
+	var attendees []uuid.UUID
+	for _, response := range responses {
+		if response.Status == "available" {
+			attendees = append(attendees, response.UserID)
+		}
+	}
+
+	// Costs are totalled in the club currency, formatted for the caller's locale
+	event, _ := authz.EventFromContext(r.Context())
+	currency, err := h.stores.Clubs.Currency(r.Context(), event.ClubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting club currency", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get shopping list"))
+		return
+	}
+	list, err := shopping.Build(eventID, items, assignedTo, attendees, currency)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error building shopping list", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get shopping list"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"shoppingList": list,
+		"moneyFormat":  money.FormatFor(currency, money.NegotiateLocale(r.Header.Get("Accept-Language"))),
+	}
+
+	h.writeSuccessResponse(w, response, "Shopping list retrieved successfully")
+}
+
+// AssignShoppingList sets the club member who shops for the event's whole list
+func (h *EventItemHandler) AssignShoppingList(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.AssignShoppingListRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// The shopper has to be an active member of the event's club
+	if req.UserID != nil {
+		event, _ := authz.EventFromContext(r.Context())
+		if _, err := h.stores.Clubs.MemberRole(r.Context(), event.ClubID, *req.UserID); err != nil {
+			if err == store.ErrNotFound {
+				h.writeError(w, apierror.Validation("The shopper must be a member of the club", models.InvalidField("userId", "member", "must be a member of the club")))
+				return
+			}
+			logging.FromContext(r.Context()).Error("error checking club membership", "error", err)
+			h.writeError(w, apierror.Internal("Failed to assign shopping list"))
+			return
+		}
+	}
+
+	if err := h.stores.EventItems.AssignShoppingList(r.Context(), eventID, req.UserID); err != nil {
+		logging.FromContext(r.Context()).Error("error assigning shopping list", "error", err)
+		h.writeError(w, apierror.Internal("Failed to assign shopping list"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"shoppingList": map[string]interface{}{
+			"eventId":    eventID,
+			"assignedTo": req.UserID,
+		},
+	}
+
+	h.writeSuccessResponse(w, response, "Shopping list assigned successfully")
+}
+
 // Helper methods
-func (h *EventItemHandler) canAccessEvent(ctx context.Context, eventID, userID uuid.UUID) bool {
-	query := `
-		SELECT 1 FROM events e
-		JOIN club_members cm ON e.club_id = cm.club_id
-		WHERE e.id = $1 AND cm.user_id = $2 AND cm.is_active = true`
 
-	var exists int
-	err := h.db.QueryRowContext(ctx, query, eventID, userID).Scan(&exists)
-	return err == nil
+// validateItemQuantity checks an item's quantity, reporting errors against field
+func validateItemQuantity(field string, quantity *float64) *apierror.Error {
+	if quantity != nil && (*quantity <= 0 || *quantity >= maxItemAmount) {
+		return invalidField(field, "range", "must be positive and less than 100000000", "Quantity must be positive and less than 100000000")
+	}
+	return nil
 }
 
-func (h *EventItemHandler) canManageEventItems(ctx context.Context, eventID, userID uuid.UUID) bool {
-	query := `
-		SELECT cm.role, e.created_by FROM events e
-		JOIN club_members cm ON e.club_id = cm.club_id
-		WHERE e.id = $1 AND cm.user_id = $2 AND cm.is_active = true`
+// maxItemAmount is the exclusive upper bound of item quantities and costs, in major units
+const maxItemAmount = 1e8
 
-	var role string
-	var createdBy uuid.UUID
-	err := h.db.QueryRowContext(ctx, query, eventID, userID).Scan(&role, &createdBy)
+// resolveCost parses a request cost in currency, or the club currency when none
+// is given, and snapshots the exchange rate from it to the club currency so later
+// rate changes do not rewrite past costs. A nil amount yields no cost. Errors
+// name the cost fields with prefix, the path of the item in the request.
+func (h *EventItemHandler) resolveCost(ctx context.Context, prefix string, amount *money.Decimal, currency *string) (*money.Money, *string, *apierror.Error) {
+	if amount == nil {
+		if currency != nil {
+			return nil, nil, invalidField(prefix+"costCurrency", "required_with", "requires a cost", "costCurrency requires a cost")
+		}
+		return nil, nil, nil
+	}
+
+	event, _ := authz.EventFromContext(ctx)
+	clubCurrency, err := h.stores.Clubs.Currency(ctx, event.ClubID)
+	if err != nil {
+		logging.FromContext(ctx).Error("error getting club currency", "error", err)
+		return nil, nil, apierror.Internal("Failed to resolve item cost")
+	}
+	code := clubCurrency
+	if currency != nil {
+		code = *currency
+	}
+
+	cost, err := money.Parse(string(*amount), code)
+	switch err {
+	case nil:
+	case money.ErrUnknownCurrency:
+		return nil, nil, invalidField(prefix+"costCurrency", "currency", "must be a supported ISO 4217 code", "Unsupported cost currency")
+	case money.ErrTooPrecise:
+		return nil, nil, invalidField(prefix+"cost", "precision", "has more decimal places than the currency allows",
+			"Cost has more decimal places than the currency allows")
+	default:
+		return nil, nil, invalidField(prefix+"cost", "decimal", "must be a decimal amount", "Invalid cost")
+	}
+	c, _ := money.LookupCurrency(cost.Currency)
+	if cost.Minor < 0 || cost.Minor >= maxItemAmount*int64(math.Pow10(c.Digits)) {
+		return nil, nil, invalidField(prefix+"cost", "range", "cannot be negative and must be less than 100000000",
+			"Cost cannot be negative and must be less than 100000000")
+	}
+
+	rate, err := exchangeRate(ctx, h.stores.ExchangeRates, cost.Currency, clubCurrency)
+	if err == store.ErrNotFound {
+		return nil, nil, apierror.New(apierror.CodeExchangeRateUnavailable, "No exchange rate from the cost currency to the club currency", map[string]interface{}{"from": cost.Currency, "to": clubCurrency})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("error getting exchange rate", "error", err)
+		return nil, nil, apierror.Internal("Failed to resolve item cost")
+	}
+	return &cost, &rate, nil
+}
+
+// exchangeRate returns how many units of to one unit of from is worth, falling
+// back to the inverse of the stored opposite rate
+func exchangeRate(ctx context.Context, rates store.ExchangeRateStore, from, to string) (string, error) {
+	if from == to {
+		return "1", nil
+	}
+	rate, err := rates.Get(ctx, from, to)
+	if err != store.ErrNotFound {
+		return rate, err
+	}
+	opposite, err := rates.Get(ctx, to, from)
 	if err != nil {
+		return "", err
+	}
+	inverse, ok := money.InvertRate(opposite)
+	if !ok {
+		return "", money.ErrInvalidRate
+	}
+	return inverse, nil
+}
+
+// canManageEventItems reports whether the caller may manage the items of the event
+// authorized by authz.RequireEventRole: members who manage items and the event's creator
+func canManageEventItems(ctx context.Context, userID uuid.UUID) bool {
+	event, ok := authz.EventFromContext(ctx)
+	if !ok {
 		return false
 	}
 
-	return role == "owner" || role == "moderator" || createdBy == userID
+	return authz.Can(ctx, authz.ManageItems) || event.CreatedBy == userID
+}
+
+// newItem builds an item from a create request, checking its fields against
+// the event and club. prefix names the request's item in error details.
+func (h *EventItemHandler) newItem(ctx context.Context, event *models.Event, prefix string, req models.EventItemRequest, userID uuid.UUID) (*models.EventItem, *apierror.Error) {
+	if derr, err := checkTerm(ctx, h.vocabulary, event.ClubID, vocab.ItemCategory, prefix+"category", req.Category); err != nil {
+		logging.FromContext(ctx).Error("error loading item categories", "error", err)
+		return nil, apierror.Internal("Failed to create item")
+	} else if derr != nil {
+		return nil, derr
+	}
+
+	if derr := validateItemQuantity(prefix+"quantity", req.Quantity); derr != nil {
+		return nil, derr
+	}
+
+	cost, rate, derr := h.resolveCost(ctx, prefix, req.Cost, req.CostCurrency)
+	if derr != nil {
+		return nil, derr
+	}
+
+	if derr, err := h.checkAssignee(ctx, event.ClubID, prefix+"assignedTo", req.AssignedTo); err != nil {
+		logging.FromContext(ctx).Error("error checking item assignee", "error", err)
+		return nil, apierror.Internal("Failed to create item")
+	} else if derr != nil {
+		return nil, derr
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != nil {
+		due, derr := itemDueDate(prefix+"dueDate", *req.DueDate, event)
+		if derr != nil {
+			return nil, derr
+		}
+		dueDate = &due
+	}
+
+	return &models.EventItem{
+		ID:           uuid.New(),
+		EventID:      event.ID,
+		Name:         req.Name,
+		Category:     req.Category,
+		AssignedTo:   req.AssignedTo,
+		Status:       "pending",
+		Notes:        req.Notes,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+		Cost:         cost,
+		ExchangeRate: rate,
+		DueDate:      dueDate,
+		CreatedBy:    userID,
+		CreatedAt:    h.now(),
+	}, nil
+}
+
+// itemUpdate collects the fields of an update request other than the
+// assignee. Unknown statuses are ignored rather than rejected.
+func (h *EventItemHandler) itemUpdate(ctx context.Context, event *models.Event, prefix string, req models.UpdateEventItemRequest) (store.EventItemUpdate, *apierror.Error) {
+	var update store.EventItemUpdate
+
+	if req.Status != "" {
+		validStatuses := []string{"pending", "assigned", "confirmed", "completed"}
+		if h.contains(validStatuses, req.Status) {
+			update.Status = &req.Status
+		}
+	}
+
+	update.Notes = req.Notes
+
+	if derr := validateItemQuantity(prefix+"quantity", req.Quantity); derr != nil {
+		return update, derr
+	}
+	update.Quantity = req.Quantity
+
+	cost, rate, derr := h.resolveCost(ctx, prefix, req.Cost, req.CostCurrency)
+	if derr != nil {
+		return update, derr
+	}
+	update.Cost, update.ExchangeRate = cost, rate
+
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			update.ClearDueDate = true
+		} else {
+			due, derr := itemDueDate(prefix+"dueDate", *req.DueDate, event)
+			if derr != nil {
+				return update, derr
+			}
+			update.DueDate = &due
+		}
+	}
+	return update, nil
+}
+
+// itemDueDate parses an item's due date, read in the event's timezone when it
+// has no offset, and requires it to be before the event starts
+func itemDueDate(field, raw string, event *models.Event) (time.Time, *apierror.Error) {
+	due, err := timeutil.ParseTimestampIn(raw, event.TimeLocation())
+	if err != nil {
+		return time.Time{}, invalidField(field, "datetime", "must be an ISO 8601 date and time", "Invalid due date")
+	}
+	if !due.Before(event.StartTime()) {
+		return time.Time{}, invalidField(field, "before_event", "must be before the event starts", "Due date must be before the event starts")
+	}
+	return due.UTC(), nil
+}
+
+// checkAssignee rejects an assignee who is not an active member of the club
+func (h *EventItemHandler) checkAssignee(ctx context.Context, clubID uuid.UUID, field string, assignee *uuid.UUID) (*apierror.Error, error) {
+	if assignee == nil {
+		return nil, nil
+	}
+	if _, err := h.stores.Clubs.MemberRole(ctx, clubID, *assignee); err == store.ErrNotFound {
+		return invalidField(field, "member", "must be an active member of the club", "Invalid assignee"), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// notifyAssignment tells a new assignee the item is theirs, and the previous
+// one that it no longer is, unless they made the change themselves
+func (h *EventItemHandler) notifyAssignment(ctx context.Context, event *models.Event, item *models.EventItem, previous *uuid.UUID, actorID uuid.UUID) {
+	if item.AssignedTo != nil && *item.AssignedTo != actorID && (previous == nil || *previous != *item.AssignedTo) {
+		h.notify(ctx, *item.AssignedTo, models.Notification{
+			Type:    notify.TypeItemAssigned,
+			Title:   "Item assigned to you",
+			Body:    "You are bringing " + item.Name + " for " + event.Title + ".",
+			ClubID:  &event.ClubID,
+			EventID: &event.ID,
+		})
+	}
+	if previous != nil && *previous != actorID && (item.AssignedTo == nil || *previous != *item.AssignedTo) {
+		h.notify(ctx, *previous, models.Notification{
+			Type:    notify.TypeItemReassigned,
+			Title:   "Item reassigned",
+			Body:    "You are no longer bringing " + item.Name + " for " + event.Title + ".",
+			ClubID:  &event.ClubID,
+			EventID: &event.ID,
+		})
+	}
+}
+
+// notify sends a notification to one member, logging failures; the change it
+// is about is already saved
+func (h *EventItemHandler) notify(ctx context.Context, userID uuid.UUID, notification models.Notification) {
+	if h.notifier == nil {
+		return
+	}
+	if _, err := h.notifier.NotifyUser(ctx, userID, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying item assignment", "type", notification.Type, "error", err)
+	}
 }
 
 func (h *EventItemHandler) contains(slice []string, item string) bool {
@@ -345,17 +1204,6 @@ func (h *EventItemHandler) writeSuccessResponse(w http.ResponseWriter, data inte
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *EventItemHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := &models.FrontendErrorResponse{
-		Error:      code,
-		Message:    message,
-		StatusCode: statusCode,
-		Details:    details,
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-	}
-
-	json.NewEncoder(w).Encode(response)
+func (h *EventItemHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
 }
@@ -0,0 +1,628 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type eventItemFixture struct {
+	handler  *EventItemHandler
+	router   chi.Router
+	mem      *store.Memory
+	notifier *recordingNotifier
+	clubID   uuid.UUID
+	eventID  uuid.UUID
+	ownerID  uuid.UUID
+	memberID uuid.UUID
+	otherID  uuid.UUID
+}
+
+func setupEventItemTest() *eventItemFixture {
+	mem := store.NewMemory()
+
+	clubID := uuid.New()
+	f := &eventItemFixture{
+		mem:      mem,
+		clubID:   clubID,
+		eventID:  uuid.New(),
+		ownerID:  uuid.New(),
+		memberID: uuid.New(),
+		otherID:  uuid.New(),
+	}
+
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: f.ownerID, Role: "owner", IsActive: true})
+	mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: f.memberID, Role: "member", IsActive: true})
+	mem.PutEvent(models.Event{ID: f.eventID, ClubID: clubID, Title: "Book Night", CreatedBy: f.ownerID,
+		Date: "2030-03-20", Time: "19:00", Timezone: "Europe/Berlin"})
+
+	stores := mem.Stores()
+	f.notifier = &recordingNotifier{}
+	f.handler = NewEventItemHandler(stores).WithNotifier(f.notifier)
+
+	// Mirror the API routes so requests go through club role authorization
+	f.router = chi.NewRouter()
+	f.router.Route("/events/{eventId}/items", func(r chi.Router) {
+		r.Use(authz.New(stores).RequireEventRole())
+		r.Get("/", f.handler.GetItems)
+		r.Post("/", f.handler.CreateItem)
+		r.Put("/{itemId}", f.handler.UpdateItem)
+		r.Patch("/{itemId}", f.handler.PatchItem)
+		r.Delete("/{itemId}", f.handler.DeleteItem)
+		r.Post("/{itemId}/claim", f.handler.ClaimItem)
+		r.Patch("/order", f.handler.ReorderItems)
+		r.Post("/bulk", f.handler.BulkItems)
+	})
+	f.router.Route("/events/{eventId}/shopping-list", func(r chi.Router) {
+		r.Use(authz.New(stores).RequireEventRole())
+		r.Get("/", f.handler.GetShoppingList)
+		r.Put("/assignee", f.handler.AssignShoppingList)
+	})
+	return f
+}
+
+// serve sends a request through the router as the auth middleware would
+func (f *eventItemFixture) serve(method, path string, body interface{}, userID uuid.UUID) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func (f *eventItemFixture) itemsPath(itemID ...string) string {
+	path := "/events/" + f.eventID.String() + "/items/"
+	if len(itemID) > 0 {
+		path += itemID[0]
+	}
+	return path
+}
+
+func TestEventItemLifecycle(t *testing.T) {
+	f := setupEventItemTest()
+
+	// Create
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Snacks", Category: "food"}}
+	w := f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	itemID := items[0].ID.String()
+
+	// Update
+	notes := "Bringing crackers"
+	updateReq := models.UpdateEventItemRequest{Status: "confirmed", Notes: &notes}
+	w = f.serve("PUT", f.itemsPath(itemID), updateReq, f.ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	items, _ = f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if items[0].Status != "confirmed" || items[0].Notes == nil || *items[0].Notes != notes {
+		t.Errorf("Expected item to be updated, got %+v", items[0])
+	}
+
+	// List
+	w = f.serve("GET", f.itemsPath(), nil, f.ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	// Delete
+	w = f.serve("DELETE", f.itemsPath(itemID), nil, f.ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	// Deleting again reports not found
+	w = f.serve("DELETE", f.itemsPath(itemID), nil, f.ownerID)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestEventItemsForbiddenForNonMembers(t *testing.T) {
+	f := setupEventItemTest()
+
+	w := f.serve("GET", f.itemsPath(), nil, f.otherID)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Snacks", Category: "food"}}
+	w = f.serve("POST", f.itemsPath(), createReq, f.otherID)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
+
+func TestUpdateItemRequiresFields(t *testing.T) {
+	f := setupEventItemTest()
+
+	w := f.serve("PUT", f.itemsPath(uuid.New().String()), models.UpdateEventItemRequest{Status: "bogus"}, f.ownerID)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestClaimItem(t *testing.T) {
+	f := setupEventItemTest()
+	f.mem.PutUser(models.User{ID: f.memberID, Name: "Robin", IsActive: true})
+	item := models.EventItem{ID: uuid.New(), EventID: f.eventID, Name: "Snacks", Category: "food", Status: "pending"}
+	f.handler.stores.EventItems.Create(context.Background(), &item)
+	claimPath := f.itemsPath(item.ID.String()) + "/claim"
+
+	if w := f.serve("POST", claimPath, nil, f.otherID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected non-members not to claim items, got %d", w.Code)
+	}
+	if w := f.serve("POST", f.itemsPath(uuid.New().String())+"/claim", nil, f.memberID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing item, got %d", w.Code)
+	}
+
+	w := f.serve("POST", claimPath, nil, f.memberID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if items[0].AssignedTo == nil || *items[0].AssignedTo != f.memberID || items[0].Status != "assigned" {
+		t.Errorf("Expected the item to be assigned to the member, got %+v", items[0])
+	}
+	if len(f.notifier.users) != 1 || f.notifier.users[0] != f.ownerID || f.notifier.notified[0].Body != "Robin volunteered for Snacks for Book Night." {
+		t.Errorf("Expected the organizer to be notified, got %+v", f.notifier.notified)
+	}
+
+	// Claiming again is harmless; claiming someone else's item is not
+	if w := f.serve("POST", claimPath, nil, f.memberID); w.Code != http.StatusOK {
+		t.Errorf("Expected claiming one's own item to succeed, got %d", w.Code)
+	}
+	if w := f.serve("POST", claimPath, nil, f.ownerID); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for an assigned item, got %d", w.Code)
+	}
+}
+
+func TestReassignItemNotifies(t *testing.T) {
+	f := setupEventItemTest()
+	secondID := uuid.New()
+	f.mem.PutMember(models.ClubMember{ID: uuid.New(), ClubID: f.clubID, UserID: secondID, Role: "member", IsActive: true})
+
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Wine", Category: "food", AssignedTo: &f.otherID}}
+	if w := f.serve("POST", f.itemsPath(), createReq, f.ownerID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a non-member assignee to be rejected, got %d", w.Code)
+	}
+	createReq.Item.AssignedTo = &f.memberID
+	if w := f.serve("POST", f.itemsPath(), createReq, f.ownerID); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(f.notifier.users) != 1 || f.notifier.users[0] != f.memberID {
+		t.Fatalf("Expected the assignee to be notified, got %v", f.notifier.users)
+	}
+
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	itemPath := f.itemsPath(items[0].ID.String())
+	if w := f.serve("PUT", itemPath, models.UpdateEventItemRequest{AssignedTo: &secondID}, f.memberID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected members not to reassign items, got %d", w.Code)
+	}
+	if w := f.serve("PUT", itemPath, models.UpdateEventItemRequest{AssignedTo: &secondID}, f.ownerID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	types := map[uuid.UUID]string{}
+	for i, userID := range f.notifier.users[1:] {
+		types[userID] = f.notifier.notified[i+1].Type
+	}
+	if len(types) != 2 || types[secondID] != "item_assigned" || types[f.memberID] != "item_reassigned" {
+		t.Errorf("Expected the new and previous assignees to be notified, got %v", types)
+	}
+}
+
+func TestItemDueDates(t *testing.T) {
+	f := setupEventItemTest()
+
+	tests := []struct {
+		dueDate  string
+		expected int
+	}{
+		{"next week", http.StatusBadRequest},
+		{"2030-03-20T19:00", http.StatusBadRequest},     // when the event starts
+		{"2030-03-20T18:30:00Z", http.StatusBadRequest}, // 19:30 in Berlin
+		{"2030-03-19T12:00", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		dueDate := tt.dueDate
+		createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Chairs", Category: "logistics", DueDate: &dueDate}}
+		if w := f.serve("POST", f.itemsPath(), createReq, f.ownerID); w.Code != tt.expected {
+			t.Errorf("dueDate %s: expected status %d, got %d: %s", tt.dueDate, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if got := items[0].ToFrontendFormat().DueDate; got == nil || *got != "2030-03-19T11:00:00Z" {
+		t.Errorf("Expected the due date in UTC, got %v", got)
+	}
+
+	clear := ""
+	if w := f.serve("PUT", f.itemsPath(items[0].ID.String()), models.UpdateEventItemRequest{DueDate: &clear}, f.ownerID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	items, _ = f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if items[0].DueDate != nil {
+		t.Errorf("Expected the due date to be removed, got %v", items[0].DueDate)
+	}
+}
+
+func TestPatchItem(t *testing.T) {
+	f := setupEventItemTest()
+	notes, quantity, dueDate := "Two bottles", 2.0, "2030-03-19T12:00"
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Wine", Category: "food",
+		Notes: &notes, Quantity: &quantity, DueDate: &dueDate}}
+	if w := f.serve("POST", f.itemsPath(), createReq, f.ownerID); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	itemPath := f.itemsPath(items[0].ID.String())
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected int
+		code     string
+	}{
+		{"unknown fields", `{"notes": "Red", "colour": "red", "name": "Port"}`, http.StatusUnprocessableEntity, "UNKNOWN_FIELDS"},
+		{"invalid status", `{"status": "bogus"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"status cleared", `{"status": null}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"wrong type", `{"quantity": "two"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"not an object", `["notes"]`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"non-member assignee", `{"assignedTo": "` + f.otherID.String() + `"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		w := f.serve("PATCH", itemPath, json.RawMessage(tt.patch), f.ownerID)
+		if w.Code != tt.expected || !bytes.Contains(w.Body.Bytes(), []byte(`"`+tt.code+`"`)) {
+			t.Errorf("%s: expected %d %s, got %d: %s", tt.name, tt.expected, tt.code, w.Code, w.Body.String())
+		}
+	}
+	if item, _ := f.handler.stores.EventItems.Get(context.Background(), f.eventID, items[0].ID); *item.Notes != notes {
+		t.Errorf("Expected rejected patches to change nothing, got notes %q", *item.Notes)
+	}
+
+	patch := `{"notes": null, "quantity": 3, "dueDate": null, "assignedTo": "` + f.memberID.String() + `"}`
+	if w := f.serve("PATCH", itemPath, json.RawMessage(patch), f.ownerID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	item, _ := f.handler.stores.EventItems.Get(context.Background(), f.eventID, items[0].ID)
+	if item.Notes != nil || item.DueDate != nil || *item.Quantity != 3 {
+		t.Errorf("Expected notes and due date cleared and quantity set, got %+v", item)
+	}
+	if item.AssignedTo == nil || *item.AssignedTo != f.memberID || item.Status != "assigned" {
+		t.Errorf("Expected the item assigned to the member, got %v %s", item.AssignedTo, item.Status)
+	}
+	if len(f.notifier.users) != 1 || f.notifier.users[0] != f.memberID {
+		t.Errorf("Expected the assignee to be notified, got %v", f.notifier.users)
+	}
+
+	if w := f.serve("PATCH", itemPath, json.RawMessage(`{"assignedTo": null}`), f.ownerID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	item, _ = f.handler.stores.EventItems.Get(context.Background(), f.eventID, items[0].ID)
+	if item.AssignedTo != nil || item.Status != "pending" || *item.Quantity != 3 {
+		t.Errorf("Expected only the assignee cleared, got %+v", item)
+	}
+
+	if w := f.serve("PATCH", f.itemsPath(uuid.New().String()), json.RawMessage(`{"notes": "x"}`), f.ownerID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing item, got %d", w.Code)
+	}
+}
+
+func TestItemVersionConflicts(t *testing.T) {
+	f := setupEventItemTest()
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Wine", Category: "food"}}
+	if w := f.serve("POST", f.itemsPath(), createReq, f.ownerID); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	itemPath := f.itemsPath(items[0].ID.String())
+
+	edit := func(method, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, itemPath, bytes.NewBufferString(body))
+		req.Header.Set("If-Match", ifMatch)
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: f.ownerID}))
+		w := httptest.NewRecorder()
+		f.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Two editors both read version 1
+	if w := edit("PATCH", `"1"`, `{"notes": "Red"}`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("Expected the first edit to make version 2, got %d %s: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	for _, method := range []string{"PATCH", "PUT"} {
+		w := edit(method, `"1"`, `{"notes": "White"}`)
+		if w.Code != http.StatusConflict || w.Header().Get("ETag") != `"2"` {
+			t.Fatalf("%s: expected 409 with the current ETag, got %d %s", method, w.Code, w.Header().Get("ETag"))
+		}
+		var resp struct {
+			Details struct {
+				Current models.EventItem `json:"current"`
+			} `json:"details"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Details.Current.Notes == nil || *resp.Details.Current.Notes != "Red" {
+			t.Errorf("%s: expected the current item in the conflict, got %s", method, w.Body.String())
+		}
+	}
+
+	if w := edit("PUT", `"2"`, `{"assignedTo": "`+f.memberID.String()+`"}`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Errorf("Expected an edit of the current version to succeed, got %d %s: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if w := edit("PATCH", `W/"3"`, `{"notes": "Rosé"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a weak ETag to be refused, got %d", w.Code)
+	}
+	if w := edit("PATCH", `*`, `{"notes": "Rosé"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected If-Match * to edit any version, got %d", w.Code)
+	}
+}
+
+func TestReorderItems(t *testing.T) {
+	f := setupEventItemTest()
+	for _, name := range []string{"Chairs", "Tea", "Books"} {
+		createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: name, Category: "logistics"}}
+		f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	}
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	chairs, tea, books := items[0].ID, items[1].ID, items[2].ID
+
+	tests := []struct {
+		itemIDs  []uuid.UUID
+		userID   uuid.UUID
+		expected int
+	}{
+		{[]uuid.UUID{books, tea, chairs}, f.memberID, http.StatusForbidden},
+		{[]uuid.UUID{}, f.ownerID, http.StatusBadRequest},
+		{[]uuid.UUID{books, tea}, f.ownerID, http.StatusConflict},
+		{[]uuid.UUID{books, tea, tea}, f.ownerID, http.StatusConflict},
+		{[]uuid.UUID{books, tea, uuid.New()}, f.ownerID, http.StatusConflict},
+		{[]uuid.UUID{books, chairs, tea}, f.ownerID, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := f.serve("PATCH", f.itemsPath("order"), models.ReorderEventItemsRequest{ItemIDs: tt.itemIDs}, tt.userID)
+		if w.Code != tt.expected {
+			t.Errorf("Reorder %v: expected status %d, got %d: %s", tt.itemIDs, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	items, _ = f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if items[0].Name != "Books" || items[1].Name != "Chairs" || items[2].Name != "Tea" || items[2].SortOrder != 3 {
+		t.Errorf("Expected the new order, got %s, %s, %s", items[0].Name, items[1].Name, items[2].Name)
+	}
+
+	// New items go to the end of the list
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Cake", Category: "food"}}
+	f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	items, _ = f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if items[3].Name != "Cake" || items[3].SortOrder != 4 {
+		t.Errorf("Expected a new item last, got %+v", items[3])
+	}
+}
+
+func TestBulkItems(t *testing.T) {
+	f := setupEventItemTest()
+	for _, name := range []string{"Chairs", "Tea"} {
+		createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: name, Category: "logistics"}}
+		f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	}
+	items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	chairs, tea := items[0].ID, items[1].ID
+	notes := "Folding ones"
+	missing := uuid.New()
+
+	// One failing operation leaves everything as it was
+	w := f.serve("POST", f.itemsPath("bulk"), models.BulkEventItemsRequest{Operations: []models.BulkEventItemOperation{
+		{Op: "create", Item: &models.EventItemRequest{Name: "Cake", Category: "food"}},
+		{Op: "delete", ItemID: &tea},
+		{Op: "update", ItemID: &missing, Update: &models.UpdateEventItemRequest{Notes: &notes}},
+	}}, f.ownerID)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+	var failure struct {
+		Details struct {
+			Results []bulkItemResult `json:"results"`
+		} `json:"details"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &failure)
+	if got := failure.Details.Results; len(got) != 3 || got[0].Status != http.StatusFailedDependency || got[2].Status != http.StatusNotFound {
+		t.Errorf("Expected per-operation statuses, got %+v", got)
+	}
+	if items, _ := f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID); len(items) != 2 {
+		t.Errorf("Expected no change to be made, got %d items", len(items))
+	}
+
+	// Invalid operations are all reported before anything is tried
+	w = f.serve("POST", f.itemsPath("bulk"), models.BulkEventItemsRequest{Operations: []models.BulkEventItemOperation{
+		{Op: "create", Item: &models.EventItemRequest{Name: "Cake", Category: "cutlery"}},
+		{Op: "update", ItemID: &chairs, Update: &models.UpdateEventItemRequest{AssignedTo: &f.memberID}},
+		{Op: "delete"},
+	}}, f.ownerID)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &failure)
+	for i, result := range failure.Details.Results {
+		if result.Status != http.StatusBadRequest {
+			t.Errorf("Expected operation %d to be invalid, got %+v", i, result)
+		}
+	}
+
+	w = f.serve("POST", f.itemsPath("bulk"), models.BulkEventItemsRequest{Operations: []models.BulkEventItemOperation{
+		{Op: "create", Item: &models.EventItemRequest{Name: "Cake", Category: "food", AssignedTo: &f.memberID}},
+		{Op: "delete", ItemID: &tea},
+		{Op: "update", ItemID: &chairs, Update: &models.UpdateEventItemRequest{Notes: &notes}},
+	}}, f.ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	items, _ = f.handler.stores.EventItems.ListByEvent(context.Background(), f.eventID)
+	if len(items) != 2 || items[0].Notes == nil || *items[0].Notes != notes || items[1].Name != "Cake" {
+		t.Errorf("Expected every operation to be made, got %+v", items)
+	}
+	if len(f.notifier.users) != 1 || f.notifier.users[0] != f.memberID {
+		t.Errorf("Expected the new item's assignee to be notified, got %v", f.notifier.users)
+	}
+
+	if w := f.serve("POST", f.itemsPath("bulk"), models.BulkEventItemsRequest{Operations: []models.BulkEventItemOperation{{Op: "delete", ItemID: &chairs}}}, f.memberID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected members not to change items in bulk, got %d", w.Code)
+	}
+}
+
+func TestShoppingList(t *testing.T) {
+	f := setupEventItemTest()
+
+	quantity, cost, unit := 2.0, money.Decimal("12"), "bottles"
+	for _, item := range []models.EventItemRequest{
+		{Name: "Lemonade", Category: "food", Quantity: &quantity, Unit: &unit, Cost: &cost},
+		{Name: "lemonade", Category: "food", Quantity: &quantity, Unit: &unit},
+		{Name: "Projector", Category: "logistics"},
+	} {
+		w := f.serve("POST", f.itemsPath(), models.CreateEventItemRequest{Item: item}, f.ownerID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
+	}
+
+	listPath := "/events/" + f.eventID.String() + "/shopping-list/"
+
+	// Only club managers and the event creator assign the list, and only to members
+	w := f.serve("PUT", listPath+"assignee", models.AssignShoppingListRequest{UserID: &f.memberID}, f.memberID)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+	w = f.serve("PUT", listPath+"assignee", models.AssignShoppingListRequest{UserID: &f.otherID}, f.ownerID)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	w = f.serve("PUT", listPath+"assignee", models.AssignShoppingListRequest{UserID: &f.memberID}, f.ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	w = f.serve("GET", listPath, nil, f.memberID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data struct {
+			ShoppingList models.ShoppingList `json:"shoppingList"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	list := response.Data.ShoppingList
+	if len(list.Lines) != 1 || list.Lines[0].Quantity != 4 || list.TotalCost != money.New(1200, "USD") {
+		t.Errorf("Expected one merged lemonade line costing 12, got %+v", list)
+	}
+	if list.AssignedTo == nil || *list.AssignedTo != f.memberID || len(list.Assignees) != 1 || *list.Assignees[0].UserID != f.memberID {
+		t.Errorf("Expected the whole list to be assigned to the member, got %+v", list)
+	}
+}
+
+func TestCreateItemRejectsNegativeCost(t *testing.T) {
+	f := setupEventItemTest()
+
+	cost := money.Decimal("-1")
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Snacks", Category: "food", Cost: &cost}}
+	w := f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestItemCostsInOtherCurrencies(t *testing.T) {
+	f := setupEventItemTest()
+	f.mem.PutClubCurrency(f.clubID, "EUR")
+
+	// Without a rate to the club currency the cost is rejected rather than guessed
+	cost, usd := money.Decimal("10.00"), "USD"
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Wine", Category: "food", Cost: &cost, CostCurrency: &usd}}
+	w := f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", w.Code)
+	}
+
+	// Only the opposite rate is configured, so its inverse is used: 10 USD = 8.00 EUR
+	f.mem.Stores().ExchangeRates.Set(context.Background(), models.ExchangeRate{Base: "EUR", Quote: "USD", Rate: "1.25"})
+	w = f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	bread := money.Decimal("2.5")
+	createReq = models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Bread", Category: "food", Cost: &bread}}
+	w = f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// A later rate change does not rewrite the snapshotted cost
+	f.mem.Stores().ExchangeRates.Set(context.Background(), models.ExchangeRate{Base: "USD", Quote: "EUR", Rate: "2"})
+
+	req := httptest.NewRequest("GET", "/events/"+f.eventID.String()+"/shopping-list/", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: f.ownerID}))
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var response struct {
+		Data struct {
+			ShoppingList models.ShoppingList `json:"shoppingList"`
+			MoneyFormat  money.Format        `json:"moneyFormat"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.ShoppingList.TotalCost != money.New(1050, "EUR") {
+		t.Errorf("Expected 10.50 EUR, got %+v", response.Data.ShoppingList.TotalCost)
+	}
+	if response.Data.MoneyFormat.Locale != "de-DE" || response.Data.MoneyFormat.DecimalSeparator != "," {
+		t.Errorf("Expected German formatting, got %+v", response.Data.MoneyFormat)
+	}
+}
+
+func TestCreateItemRejectsTooPreciseCost(t *testing.T) {
+	f := setupEventItemTest()
+	f.mem.PutClubCurrency(f.clubID, "JPY")
+
+	cost := money.Decimal("100.5")
+	createReq := models.CreateEventItemRequest{Item: models.EventItemRequest{Name: "Tea", Category: "food", Cost: &cost}}
+	w := f.serve("POST", f.itemsPath(), createReq, f.ownerID)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/templates"
+	"bookwork-api/internal/vocab"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// templateReader is the part of templates.Store that applies templates to new events
+type templateReader interface {
+	Get(ctx context.Context, id uuid.UUID) (templates.Template, error)
+	RecordUse(ctx context.Context, id uuid.UUID) error
+}
+
+// templateLibrary is the part of templates.Store the template handler uses
+type templateLibrary interface {
+	templateReader
+	ListByClub(ctx context.Context, clubID uuid.UUID) ([]templates.Template, error)
+	Library(ctx context.Context, q templates.LibraryQuery) ([]templates.Template, int, error)
+	Create(ctx context.Context, t templates.Template) (templates.Template, error)
+	Update(ctx context.Context, t templates.Template) (templates.Template, error)
+	Delete(ctx context.Context, clubID, id uuid.UUID) error
+}
+
+// EventTemplateHandler lets clubs save event templates and browse the ones
+// other clubs share. Templates are applied by creating an event with templateId.
+type EventTemplateHandler struct {
+	clocked
+
+	templates  templateLibrary
+	vocabulary vocabularyReader // the club's event types and item categories
+}
+
+func NewEventTemplateHandler(library templateLibrary) *EventTemplateHandler {
+	return &EventTemplateHandler{templates: library}
+}
+
+// WithVocabulary validates template types and item categories against each
+// club's own lists instead of the defaults
+func (h *EventTemplateHandler) WithVocabulary(vocabulary vocabularyReader) *EventTemplateHandler {
+	h.vocabulary = vocabulary
+	return h
+}
+
+// GetClubTemplates returns the club's own templates
+func (h *EventTemplateHandler) GetClubTemplates(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.templates.ListByClub(r.Context(), clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying event templates", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event templates"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"templates": list}, "Event templates retrieved successfully")
+}
+
+// GetTemplateLibrary returns a page of the templates clubs share, the most
+// used first or, with sort=newest, the most recently shared
+func (h *EventTemplateHandler) GetTemplateLibrary(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && sort != "popular" && sort != "newest" {
+		h.writeError(w, apierror.Validation("Invalid sort option. Use popular or newest", models.InvalidField("sort", "oneof", "must be one of: popular, newest")))
+		return
+	}
+
+	list, total, err := h.templates.Library(r.Context(), templates.LibraryQuery{
+		Search: r.URL.Query().Get("q"),
+		Type:   r.URL.Query().Get("type"),
+		Sort:   sort,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying template library", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get template library"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"templates": list,
+		"pagination": models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: (total + limit - 1) / limit,
+		},
+	}
+
+	h.writeSuccessResponse(w, response, "Template library retrieved successfully")
+}
+
+// GetTemplate returns one of the club's templates or a shared one
+func (h *EventTemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	clubID, templateID, ok := h.clubTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := h.templates.Get(r.Context(), templateID)
+	if err == nil && !template.VisibleTo(clubID) {
+		err = templates.ErrNotFound
+	}
+	if err != nil {
+		h.writeTemplateError(w, r, err, "Failed to get event template")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"template": template}, "Event template retrieved successfully")
+}
+
+// CreateTemplate saves a template for the club, shared in the library if asked
+func (h *EventTemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	template, ok := h.decodeTemplate(w, r, clubID)
+	if !ok {
+		return
+	}
+	template.CreatedBy = &userID
+
+	created, err := h.templates.Create(r.Context(), template)
+	if err != nil {
+		h.writeTemplateError(w, r, err, "Failed to create event template")
+		return
+	}
+	audit.Describe(r.Context(), "event_template", created.ID.String(), audit.Diff(nil, created))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"template": created}, "Event template created successfully")
+}
+
+// UpdateTemplate replaces one of the club's templates
+func (h *EventTemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	clubID, templateID, ok := h.clubTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	before, err := h.templates.Get(r.Context(), templateID)
+	if err == nil && before.ClubID != clubID {
+		err = templates.ErrNotFound
+	}
+	if err != nil {
+		h.writeTemplateError(w, r, err, "Failed to update event template")
+		return
+	}
+
+	template, ok := h.decodeTemplate(w, r, clubID)
+	if !ok {
+		return
+	}
+	template.ID = templateID
+
+	updated, err := h.templates.Update(r.Context(), template)
+	if err != nil {
+		h.writeTemplateError(w, r, err, "Failed to update event template")
+		return
+	}
+	audit.Describe(r.Context(), "event_template", templateID.String(), audit.Diff(before, updated))
+
+	h.writeSuccessResponse(w, map[string]interface{}{"template": updated}, "Event template updated successfully")
+}
+
+// DeleteTemplate removes one of the club's templates, and with it its library listing
+func (h *EventTemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	clubID, templateID, ok := h.clubTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.templates.Delete(r.Context(), clubID, templateID); err != nil {
+		h.writeTemplateError(w, r, err, "Failed to delete event template")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Event template deleted successfully"}, "Event template deleted successfully")
+}
+
+// decodeTemplate reads an EventTemplateRequest, checking its type and item
+// categories against the club's vocabularies
+func (h *EventTemplateHandler) decodeTemplate(w http.ResponseWriter, r *http.Request, clubID uuid.UUID) (templates.Template, bool) {
+	var req models.EventTemplateRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return templates.Template{}, false
+	}
+
+	// check rejects a value outside the club's vocabulary of kind
+	check := func(kind vocab.Kind, field, value string) bool {
+		derr, err := checkTerm(r.Context(), h.vocabulary, clubID, kind, field, value)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error loading club vocabulary", "error", err)
+			h.writeError(w, apierror.Internal("Failed to save event template"))
+			return false
+		}
+		if derr != nil {
+			h.writeError(w, derr)
+			return false
+		}
+		return true
+	}
+	if !check(vocab.EventType, "type", req.Type) {
+		return templates.Template{}, false
+	}
+	for i, item := range req.Items {
+		if !check(vocab.ItemCategory, "items["+strconv.Itoa(i)+"].category", item.Category) {
+			return templates.Template{}, false
+		}
+	}
+
+	template := templates.Template{
+		ClubID:          clubID,
+		Name:            req.Name,
+		Description:     req.Description,
+		Type:            req.Type,
+		DurationMinutes: req.DurationMinutes,
+		Agenda:          req.Agenda,
+		Items:           req.Items,
+		Shared:          req.Shared,
+	}
+	return template, true
+}
+
+func (h *EventTemplateHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *EventTemplateHandler) clubTemplate(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	templateID, err := uuid.Parse(chi.URLParam(r, "templateId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid template ID", models.InvalidID("templateId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, templateID, true
+}
+
+func (h *EventTemplateHandler) writeTemplateError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, templates.ErrNotFound) {
+		h.writeError(w, apierror.NotFound("Event template not found"))
+		return
+	}
+	logging.FromContext(r.Context()).Error("error with event template", "error", err)
+	h.writeError(w, apierror.Internal(message))
+}
+
+func (h *EventTemplateHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *EventTemplateHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *EventTemplateHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
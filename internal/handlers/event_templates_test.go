@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/templates"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeTemplates keeps templates by ID and records the last library query
+type fakeTemplates struct {
+	templates map[uuid.UUID]templates.Template
+	query     templates.LibraryQuery
+}
+
+func (f *fakeTemplates) Get(ctx context.Context, id uuid.UUID) (templates.Template, error) {
+	t, ok := f.templates[id]
+	if !ok {
+		return templates.Template{}, templates.ErrNotFound
+	}
+	return t, nil
+}
+
+func (f *fakeTemplates) RecordUse(ctx context.Context, id uuid.UUID) error {
+	t := f.templates[id]
+	t.Uses++
+	f.templates[id] = t
+	return nil
+}
+
+func (f *fakeTemplates) ListByClub(ctx context.Context, clubID uuid.UUID) ([]templates.Template, error) {
+	list := []templates.Template{}
+	for _, t := range f.templates {
+		if t.ClubID == clubID {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeTemplates) Library(ctx context.Context, q templates.LibraryQuery) ([]templates.Template, int, error) {
+	f.query = q
+	return []templates.Template{}, 0, nil
+}
+
+func (f *fakeTemplates) Create(ctx context.Context, t templates.Template) (templates.Template, error) {
+	t.ID = uuid.New()
+	f.templates[t.ID] = t
+	return t, nil
+}
+
+func (f *fakeTemplates) Update(ctx context.Context, t templates.Template) (templates.Template, error) {
+	f.templates[t.ID] = t
+	return t, nil
+}
+
+func (f *fakeTemplates) Delete(ctx context.Context, clubID, id uuid.UUID) error {
+	if t, ok := f.templates[id]; !ok || t.ClubID != clubID {
+		return templates.ErrNotFound
+	}
+	delete(f.templates, id)
+	return nil
+}
+
+func setupTemplateTest() (*fakeTemplates, chi.Router) {
+	library := &fakeTemplates{templates: map[uuid.UUID]templates.Template{}}
+	handler := NewEventTemplateHandler(library)
+
+	router := chi.NewRouter()
+	router.Get("/event-templates/library", handler.GetTemplateLibrary)
+	router.Post("/club/{clubId}/event-templates", handler.CreateTemplate)
+	router.Get("/club/{clubId}/event-templates/{templateId}", handler.GetTemplate)
+	router.Put("/club/{clubId}/event-templates/{templateId}", handler.UpdateTemplate)
+	router.Delete("/club/{clubId}/event-templates/{templateId}", handler.DeleteTemplate)
+	return library, router
+}
+
+func serveTemplates(router chi.Router, method, path, body string) int {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestCreateTemplateChecksVocabulary(t *testing.T) {
+	library, router := setupTemplateTest()
+	path := "/club/" + uuid.NewString() + "/event-templates"
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"name": "Book night", "type": "party"}`, http.StatusBadRequest},
+		{`{"name": "Book night", "type": "discussion", "items": [{"name": "Raffle", "category": "raffle"}]}`, http.StatusBadRequest},
+		{`{"name": "Book night", "type": "discussion", "durationMinutes": 0}`, http.StatusBadRequest},
+		{`{"name": "Book night", "type": "discussion", "agenda": [{"title": ""}]}`, http.StatusBadRequest},
+		{`{"name": "Book night", "type": "discussion", "durationMinutes": 120, "agenda": [{"title": "Welcome", "minutes": 10}], "items": [{"name": "Tea", "category": "food"}], "shared": true}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if code := serveTemplates(router, "POST", path, tt.body); code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.expected, code)
+		}
+	}
+
+	if len(library.templates) != 1 {
+		t.Fatalf("Expected one template, got %d", len(library.templates))
+	}
+	for _, template := range library.templates {
+		if template.CreatedBy == nil || !template.Shared || len(template.Agenda) != 1 || len(template.Items) != 1 {
+			t.Errorf("Expected the template as sent with its author, got %+v", template)
+		}
+	}
+}
+
+func TestTemplateVisibility(t *testing.T) {
+	library, router := setupTemplateTest()
+	owner, other := uuid.New(), uuid.New()
+	private, _ := library.Create(context.Background(), templates.Template{ClubID: owner, Type: "social"})
+	shared, _ := library.Create(context.Background(), templates.Template{ClubID: owner, Type: "social", Shared: true})
+
+	tests := []struct {
+		method   string
+		club     uuid.UUID
+		template uuid.UUID
+		expected int
+	}{
+		{"GET", owner, private.ID, http.StatusOK},
+		{"GET", other, private.ID, http.StatusNotFound},
+		{"GET", other, shared.ID, http.StatusOK},
+		{"DELETE", other, shared.ID, http.StatusNotFound},
+		{"DELETE", owner, shared.ID, http.StatusOK},
+	}
+	for _, tt := range tests {
+		path := "/club/" + tt.club.String() + "/event-templates/" + tt.template.String()
+		if code := serveTemplates(router, tt.method, path, ""); code != tt.expected {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, path, tt.expected, code)
+		}
+	}
+
+	// Another club cannot overwrite a shared template
+	path := "/club/" + other.String() + "/event-templates/" + private.ID.String()
+	if code := serveTemplates(router, "PUT", path, `{"name": "Mine now", "type": "social"}`); code != http.StatusNotFound {
+		t.Errorf("Expected another club's template not to be updated, got %d", code)
+	}
+}
+
+func TestTemplateLibraryQuery(t *testing.T) {
+	library, router := setupTemplateTest()
+
+	if code := serveTemplates(router, "GET", "/event-templates/library?sort=random", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown sort to be rejected, got %d", code)
+	}
+	if code := serveTemplates(router, "GET", "/event-templates/library?q=mystery&type=social&sort=newest&page=3&limit=10", ""); code != http.StatusOK {
+		t.Errorf("Expected the library, got %d", code)
+	}
+	expected := templates.LibraryQuery{Search: "mystery", Type: "social", Sort: "newest", Limit: 10, Offset: 20}
+	if library.query != expected {
+		t.Errorf("Expected query %+v, got %+v", expected, library.query)
+	}
+}
+
+func TestCreateEventFromUnknownTemplate(t *testing.T) {
+	library := &fakeTemplates{templates: map[uuid.UUID]templates.Template{}}
+	private, _ := library.Create(context.Background(), templates.Template{ClubID: uuid.New(), Type: "social"})
+	handler := NewEventHandler(database.NewMock()).WithTemplates(library)
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/events", handler.CreateEvent)
+
+	for _, templateID := range []string{uuid.NewString(), private.ID.String()} {
+		body := `{"title":"Book Night","date":"2030-01-15","time":"19:30","location":"Library","templateId":"` + templateID + `"}`
+		if code := serveTemplates(router, "POST", "/club/"+uuid.NewString()+"/events", body); code != http.StatusBadRequest {
+			t.Errorf("Expected a template the club cannot use to be rejected, got %d", code)
+		}
+	}
+	if library.templates[private.ID].Uses != 0 {
+		t.Error("Expected no use to be recorded")
+	}
+}
@@ -1,47 +1,131 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"bookwork-api/internal/analytics"
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
 	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/changes"
 	"bookwork-api/internal/database"
+	"bookwork-api/internal/forecast"
+	"bookwork-api/internal/holidays"
+	"bookwork-api/internal/integrations"
+	"bookwork-api/internal/localtime"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
 	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/pagination"
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/shadow"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/templates"
+	"bookwork-api/internal/timeutil"
+	"bookwork-api/internal/vocab"
+	"bookwork-api/internal/webhooks"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type EventHandler struct {
-	db *database.DB
+	clocked
+
+	db       *database.DB
+	notifier *notify.Notifier
+	webhooks webhookQueue
+	startsAt *shadow.Refactor // event_date and event_time moving to starts_at
+	stores   *store.Stores
+
+	integrations eventAnnouncer   // chat integrations new and changed events are announced in
+	changes      changeRecorder   // the club's change log, for its streams
+	vocabulary   vocabularyReader // the club's event types
+	templates    templateReader   // templates events can be created from
 }
 
 func NewEventHandler(db *database.DB) *EventHandler {
 	return &EventHandler{db: db}
 }
 
+// WithNotifier notifies club members of new events
+func (h *EventHandler) WithNotifier(notifier *notify.Notifier) *EventHandler {
+	h.notifier = notifier
+	return h
+}
+
+// WithWebhooks sends new events to the club's webhooks
+func (h *EventHandler) WithWebhooks(webhooks webhookQueue) *EventHandler {
+	h.webhooks = webhooks
+	return h
+}
+
+// WithIntegrations announces new and changed events in the club's chat integrations
+func (h *EventHandler) WithIntegrations(integrations eventAnnouncer) *EventHandler {
+	h.integrations = integrations
+	return h
+}
+
+// WithChanges records created, updated and deleted events in the club's change log
+func (h *EventHandler) WithChanges(changes changeRecorder) *EventHandler {
+	h.changes = changes
+	return h
+}
+
+// WithStartsAtShadow writes and compares the starts_at column according to the
+// refactor's mode; without it only event_date and event_time are used
+func (h *EventHandler) WithStartsAtShadow(startsAt *shadow.Refactor) *EventHandler {
+	h.startsAt = startsAt
+	return h
+}
+
+// WithVocabulary validates event types against each club's own list instead
+// of the defaults
+func (h *EventHandler) WithVocabulary(vocabulary vocabularyReader) *EventHandler {
+	h.vocabulary = vocabulary
+	return h
+}
+
+// WithTemplates lets CreateEvent start from a saved or shared template
+func (h *EventHandler) WithTemplates(templates templateReader) *EventHandler {
+	h.templates = templates
+	return h
+}
+
+// WithStores adds the item and availability summaries to GetEvent; events are
+// deleted through it
+func (h *EventHandler) WithStores(stores *store.Stores) *EventHandler {
+	h.stores = stores
+	return h
+}
+
 func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
-	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+	withHTML, derr := htmlFormatParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	// Check if user is a member of the club
-	if !h.isClubMember(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "You are not a member of this club", nil)
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
@@ -65,9 +149,10 @@ func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	// Build query
 	query := `
 		SELECT id, club_id, title, description, event_date, event_time, location, 
-		       book, type, max_attendees, is_public, created_by, attendees, created_at, updated_at
+		       book, type, max_attendees, is_public, created_by, attendees, created_at, updated_at,
+		       timezone, ends_at
 		FROM events
-		WHERE club_id = $1`
+		WHERE club_id = $1 AND deleted_at IS NULL`
 
 	args := []interface{}{clubID}
 	argCount := 1
@@ -90,13 +175,33 @@ func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		args = append(args, eventType)
 	}
 
-	query += ` ORDER BY event_date DESC, event_time DESC LIMIT $` + strconv.Itoa(argCount+1) + ` OFFSET $` + strconv.Itoa(argCount+2)
-	args = append(args, limit, offset)
+	// With ?cursor= pages continue after the last event of the previous page instead of skipping rows
+	useCursor := pagination.CursorRequested(r)
+	if cursor := r.URL.Query().Get("cursor"); useCursor && cursor != "" {
+		date, clock, eventID, err := parseEventCursor(cursor)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid cursor", models.InvalidField("cursor", "cursor", "must be a nextCursor from a previous page")))
+			return
+		}
+		query += ` AND (event_date, event_time, id) < ($` + strconv.Itoa(argCount+1) + `::date, $` +
+			strconv.Itoa(argCount+2) + `::time, $` + strconv.Itoa(argCount+3) + `)`
+		args = append(args, date, clock, eventID)
+		argCount += 3
+	}
 
-	rows, err := h.db.QueryContext(r.Context(), query, args...)
+	query += ` ORDER BY event_date DESC, event_time DESC, id DESC LIMIT $` + strconv.Itoa(argCount+1)
+	if useCursor {
+		// One extra row tells whether there is a next page
+		args = append(args, limit+1)
+	} else {
+		query += ` OFFSET $` + strconv.Itoa(argCount+2)
+		args = append(args, limit, offset)
+	}
+
+	rows, err := h.db.ReadQueryContext(r.Context(), query, args...)
 	if err != nil {
-		log.Printf("Error querying events: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get events", nil)
+		logging.FromContext(r.Context()).Error("error querying events", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get events"))
 		return
 	}
 	defer rows.Close()
@@ -111,9 +216,10 @@ func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 			&event.Date, &event.Time, &event.Location, &event.Book,
 			&event.Type, &event.MaxAttendees, &event.IsPublic, &event.CreatedBy,
 			&attendees, &event.CreatedAt, &event.UpdatedAt,
+			&event.Timezone, &event.EndsAt,
 		)
 		if err != nil {
-			log.Printf("Error scanning event: %v", err)
+			logging.FromContext(r.Context()).Error("error scanning event", "error", err)
 			continue
 		}
 
@@ -121,133 +227,351 @@ func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		events = append(events, event)
 	}
 
-	// Get total count
-	countQuery := `SELECT COUNT(*) FROM events WHERE club_id = $1`
-	countArgs := []interface{}{clubID}
+	var pageInfo interface{}
+	if useCursor {
+		var next *string
+		if len(events) > limit {
+			events = events[:limit]
+			cursor := eventCursor(events[limit-1])
+			next = &cursor
+		}
+		pageInfo = models.CursorPagination{Limit: limit, NextCursor: next, HasMore: next != nil}
+	} else {
+		// Get total count
+		countQuery := `SELECT COUNT(*) FROM events WHERE club_id = $1 AND deleted_at IS NULL`
+		countArgs := []interface{}{clubID}
+
+		if from != "" {
+			countQuery += ` AND event_date >= $2`
+			countArgs = append(countArgs, from)
+		}
 
-	if from != "" {
-		countQuery += ` AND event_date >= $2`
-		countArgs = append(countArgs, from)
-	}
+		var total int
+		h.db.QueryRowContext(r.Context(), countQuery, countArgs...).Scan(&total)
 
-	var total int
-	h.db.QueryRowContext(r.Context(), countQuery, countArgs...).Scan(&total)
+		pageInfo = models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: (total + limit - 1) / limit,
+		}
+	}
 
-	totalPages := (total + limit - 1) / limit
+	// Transform events to frontend format, localized to the caller's timezone
+	loc := userLocation(r.Context(), h.db, userID)
+	now := h.now()
 
-	// Transform events to frontend format
 	var frontendEvents []*models.FrontendEvent
 	for _, event := range events {
-		frontendEvents = append(frontendEvents, event.ToFrontendFormat())
+		if withHTML {
+			event.DescriptionHTML = renderDescription(event.Description)
+		}
+		frontendEvents = append(frontendEvents, event.ToLocalizedFrontendFormat(loc, now))
 	}
 
 	response := map[string]interface{}{
-		"events": frontendEvents,
-		"pagination": models.Pagination{
-			Page:       page,
-			Limit:      limit,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+		"events":     frontendEvents,
+		"pagination": pageInfo,
 	}
 
+	updated := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		updated = append(updated, event.UpdatedAt)
+	}
+	middleware.SetLastModified(w, updated...)
+
 	h.writeSuccessResponse(w, response, "Events retrieved successfully")
 }
 
-func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+// eventCursorTime is the layout of event times in GetEvents cursors
+const eventCursorTime = "15:04:05.999999"
+
+// eventCursor returns the GetEvents cursor after event. The driver may scan
+// dates and times as RFC 3339 timestamps, so they are normalized first.
+func eventCursor(event models.Event) string {
+	date, clock := event.Date, event.Time
+	if t, err := timeutil.ParseDate(date); err == nil {
+		date = timeutil.FormatDate(t)
+	}
+	if t, err := timeutil.ParseTime(clock); err == nil {
+		clock = t.Format(eventCursorTime)
+	}
+	return pagination.EncodeCursor(date, clock, event.ID.String())
+}
+
+// parseEventCursor reads the date, time and ID of a GetEvents cursor
+func parseEventCursor(cursor string) (date, clock string, eventID uuid.UUID, err error) {
+	key, err := pagination.DecodeCursor(cursor, 3)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+	if _, err := time.Parse(timeutil.DateLayout, key[0]); err != nil {
+		return "", "", uuid.Nil, pagination.ErrInvalidCursor
+	}
+	if _, err := time.Parse(eventCursorTime, key[1]); err != nil {
+		return "", "", uuid.Nil, pagination.ErrInvalidCursor
+	}
+	if eventID, err = uuid.Parse(key[2]); err != nil {
+		return "", "", uuid.Nil, pagination.ErrInvalidCursor
+	}
+	return key[0], key[1], eventID, nil
+}
+
+// GetArchivedEvents lists a club's events for one year from cold storage (?year=YYYY, default last year)
+func (h *EventHandler) GetArchivedEvents(w http.ResponseWriter, r *http.Request) {
 	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid club ID", nil)
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	userID, err := auth.GetUserIDFromContext(r.Context())
+	year := h.now().Year() - 1
+	if value := r.URL.Query().Get("year"); value != "" {
+		year, err = strconv.Atoi(value)
+		if err != nil || year < 1900 || year > 9999 {
+			h.writeError(w, apierror.Validation("Invalid year", models.InvalidField("year", "range", "must be a year between 1900 and 9999")))
+			return
+		}
+	}
+
+	// Bounding event_date lets Postgres scan only that year's partition. There
+	// is no single archived event to fetch, so the list carries the agenda.
+	query := `
+		SELECT id, club_id, title, description, event_date, event_time, location,
+		       book, type, max_attendees, is_public, created_by, attendees, created_at, updated_at,
+		       timezone, ends_at, agenda
+		FROM events_archive
+		WHERE club_id = $1 AND event_date >= make_date($2, 1, 1) AND event_date < make_date($2 + 1, 1, 1)
+		ORDER BY event_date DESC, event_time DESC`
+
+	rows, err := h.db.ReadQueryContext(r.Context(), query, clubID, year)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		logging.FromContext(r.Context()).Error("error querying archived events", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get archived events"))
 		return
 	}
+	defer rows.Close()
 
-	// Check if user can create events in this club
-	if !h.canManageEvents(r.Context(), clubID, userID) {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
-		return
+	events := []models.Event{}
+	for rows.Next() {
+		var event models.Event
+		var createdBy uuid.NullUUID
+		var attendees models.UUIDArray
+
+		err := rows.Scan(
+			&event.ID, &event.ClubID, &event.Title, &event.Description,
+			&event.Date, &event.Time, &event.Location, &event.Book,
+			&event.Type, &event.MaxAttendees, &event.IsPublic, &createdBy,
+			&attendees, &event.CreatedAt, &event.UpdatedAt,
+			&event.Timezone, &event.EndsAt, &event.Agenda,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning archived event", "error", err)
+			continue
+		}
+
+		event.CreatedBy = createdBy.UUID
+		event.Attendees = attendees
+		events = append(events, event)
 	}
 
-	var req models.CreateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
-		return
+	response := map[string]interface{}{
+		"year":   year,
+		"events": events,
 	}
 
-	// Validate required fields
-	if req.Title == "" || req.Date == "" || req.Time == "" || req.Location == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Title, date, time, and location are required", nil)
+	h.writeSuccessResponse(w, response, "Archived events retrieved successfully")
+}
+
+func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
 		return
 	}
 
-	// Validate date format and ensure it's in the future
-	eventDate, err := time.Parse("2006-01-02", req.Date)
+	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid date format. Use YYYY-MM-DD", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateEventRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
 		return
 	}
 
-	if eventDate.Before(time.Now().Truncate(24 * time.Hour)) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Event date must be in the future", nil)
+	// A template fills in what the request leaves out
+	var template *templates.Template
+	if req.TemplateID != nil {
+		t, err := h.loadTemplate(r.Context(), clubID, *req.TemplateID)
+		if errors.Is(err, templates.ErrNotFound) {
+			h.writeError(w, apierror.Validation("Event template not found", models.InvalidField("templateId", "exists", "must be a template of the club or from the library")))
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error getting event template", "error", err)
+			h.writeError(w, apierror.Internal("Failed to create event"))
+			return
+		}
+		template = &t
+		if req.Type == "" {
+			req.Type = t.Type
+		}
+		if req.Description == nil {
+			req.Description = t.Description
+		}
+		if req.Agenda == nil {
+			req.Agenda = t.Agenda
+		}
+	}
+
+	if derr, err := checkTerm(r.Context(), h.vocabulary, clubID, vocab.EventType, "type", req.Type); err != nil {
+		logging.FromContext(r.Context()).Error("error loading event types", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create event"))
 		return
+	} else if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	// The event is scheduled in its own timezone, by default the creator's
+	var loc *time.Location
+	if req.Timezone != nil {
+		if loc, err = localtime.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			h.writeError(w, apierror.Validation("Timezone must be an IANA name such as Europe/London", models.InvalidField("timezone", "timezone", "must be an IANA name such as Europe/London")))
+			return
+		}
+	} else {
+		loc = userLocation(r.Context(), h.db, userID)
 	}
 
-	// Validate time format
-	if !h.isValidTimeFormat(req.Time) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid time format. Use HH:MM", nil)
+	start, endsAt, derr := resolveEventTimes(req, loc)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
+	if endsAt == nil && template != nil && template.Duration() > 0 {
+		end := start.Add(template.Duration())
+		endsAt = &end
+	}
 
-	// Validate event type
-	validTypes := []string{"discussion", "meeting", "social", "author_event"}
-	if !h.contains(validTypes, req.Type) {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event type", nil)
+	// date and time stay the wall-clock start in the event's timezone
+	date, clock := req.Date, req.Time
+	if req.StartsAt != nil {
+		local := start.In(loc)
+		date, clock = timeutil.FormatDate(local), local.Format(timeutil.TimeLayout)
+	}
+
+	// It must not be in the past, by the calendar where the event happens
+	eventDate, _ := timeutil.ParseDate(date)
+	if date < timeutil.FormatDate(h.now().In(loc)) {
+		field := "date"
+		if req.StartsAt != nil {
+			field = "startsAt"
+		}
+		h.writeError(w, apierror.Validation("Event date must be in the future", models.InvalidField(field, "future", "must not be in the past")))
 		return
 	}
 
+	// starts_at is only written once the refactor is past off
+	var startsAt *time.Time
+	if h.startsAt.Writes() {
+		startsAt = &start
+	}
+
 	// Create event
 	eventID := uuid.New()
 	query := `
 		INSERT INTO events (id, club_id, title, description, event_date, event_time, location, 
-		                   book, type, max_attendees, is_public, created_by, attendees) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
-
-	attendees := models.UUIDArray{}
-	_, err = h.db.ExecContext(r.Context(), query,
-		eventID, clubID, req.Title, req.Description, req.Date, req.Time,
-		req.Location, req.Book, req.Type, req.MaxAttendees, req.IsPublic,
-		userID, attendees,
-	)
-	if err != nil {
-		log.Printf("Error creating event: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create event", nil)
-		return
+		                   book, type, max_attendees, is_public, created_by, attendees, starts_at,
+		                   timezone, ends_at, agenda) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	// Events without an agenda store NULL rather than []
+	var agenda interface{}
+	if len(req.Agenda) > 0 {
+		agenda = req.Agenda
 	}
 
+	attendees := models.UUIDArray{}
 	event := &models.Event{
 		ID:          eventID,
 		ClubID:      clubID,
 		Title:       req.Title,
 		Description: req.Description,
-		Date:        req.Date,
-		Time:        req.Time,
+		Date:        date,
+		Time:        clock,
+		Timezone:    loc.String(),
+		EndsAt:      endsAt,
 		Location:    req.Location,
 		Book:        req.Book,
 		Type:        req.Type,
 		Attendees:   attendees,
+		Agenda:      req.Agenda,
 		CreatedBy:   userID,
-		CreatedAt:   time.Now(),
+		CreatedAt:   h.now(),
 	}
 
+	err = h.db.WithTx(r.Context(), func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(r.Context(), query,
+			eventID, clubID, req.Title, req.Description, date, clock,
+			req.Location, req.Book, req.Type, req.MaxAttendees, req.IsPublic,
+			userID, attendees, startsAt, loc.String(), endsAt, agenda,
+		)
+		if err != nil {
+			return err
+		}
+		if h.webhooks != nil {
+			if err := h.webhooks.Queue(r.Context(), tx, clubID, webhooks.EventCreated, event); err != nil {
+				return err
+			}
+		}
+		if h.integrations != nil {
+			if err := h.integrations.Queue(r.Context(), tx, clubID, integrations.NewAnnouncement(integrations.KindCreated, event)); err != nil {
+				return err
+			}
+		}
+		if h.notifier == nil {
+			return nil
+		}
+
+		// Members are notified through the outbox, so only of events that were created
+		notification := models.Notification{
+			Type:    notify.TypeEventCreated,
+			Title:   "New event: " + req.Title,
+			Body:    date + " " + clock + " (" + loc.String() + ") at " + req.Location,
+			EventID: &eventID,
+		}
+		return h.notifier.QueueClubMembers(r.Context(), tx, clubID, userID, notification)
+	})
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create event"))
+		return
+	}
+	if startsAt != nil {
+		h.startsAt.RecordWrite()
+	}
+	recordChange(r.Context(), h.changes, clubID, changes.EventCreated, eventID, eventID)
+
 	response := map[string]interface{}{
 		"event": event,
 	}
 
+	if template != nil {
+		response["items"] = h.applyTemplateItems(r.Context(), event, *template)
+	}
+
+	if warnings := h.holidayWarnings(r.Context(), clubID, eventDate); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	h.writeSuccessResponse(w, response, "Event created successfully")
 }
@@ -255,13 +579,13 @@ func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
@@ -269,45 +593,148 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	event, err := h.getEventByID(r.Context(), eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Event not found", nil)
+			h.writeError(w, apierror.NotFound("Event not found"))
 			return
 		}
-		log.Printf("Error getting event: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get event", nil)
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
 		return
 	}
 
 	// Check if user can update this event
-	if !h.canManageEvents(r.Context(), event.ClubID, userID) && event.CreatedBy != userID {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	if !authz.Can(r.Context(), authz.ManageEvents) && event.CreatedBy != userID {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	// Refuse edits of a version someone else has replaced
+	ifVersion, derr := ifMatchVersion(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+	if versionMismatch(ifVersion, event.Version) {
+		h.writeVersionConflict(w, event)
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid JSON format", nil)
+	// The body is a JSON merge patch of the event's fields
+	patch := newEventPatch(event)
+	fields, derr := decodeMergePatch(w, r, &patch)
+	if derr == nil {
+		derr = validateRequest(&patch)
+	}
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
+	updates := patch.updates(fields)
 
-	// Build update query dynamically
+	// Build update query dynamically, tracking the result for the audit log
 	setParts := []string{}
 	args := []interface{}{}
 	argCount := 0
+	updated := *event
+
+	// Expressions for the new date, time and timezone, for keeping starts_at in step
+	dateExpr, timeExpr, tzExpr := "event_date", "event_time", "timezone"
+
+	// A new timezone keeps the wall-clock date and time, moving the start
+	loc := event.TimeLocation()
+	if value, ok := updates["timezone"]; ok {
+		name, _ := value.(string)
+		newLoc, err := localtime.LoadLocation(name)
+		if err != nil || name == "" {
+			h.writeError(w, apierror.Validation("Timezone must be an IANA name such as Europe/London", models.InvalidField("timezone", "timezone", "must be an IANA name such as Europe/London")))
+			return
+		}
+		loc = newLoc
+		argCount++
+		setParts = append(setParts, "timezone = $"+strconv.Itoa(argCount))
+		args = append(args, loc.String())
+		updated.Timezone = loc.String()
+		tzExpr = "$" + strconv.Itoa(argCount) + "::text"
+	}
+
+	// startsAt replaces the date and time with its wall-clock time in the timezone
+	if value, ok := updates["startsAt"]; ok {
+		str, _ := value.(string)
+		start, err := timeutil.ParseTimestampIn(str, loc)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid start time", models.InvalidField("startsAt", "datetime", "must be an ISO 8601 date and time")))
+			return
+		}
+		local := start.In(loc)
+		updates["date"], updates["time"] = timeutil.FormatDate(local), local.Format(timeutil.TimeLayout)
+		delete(updates, "startsAt")
+	}
+
+	// endsAt may be cleared with null
+	if value, ok := updates["endsAt"]; ok {
+		var endsAt *time.Time
+		if value != nil {
+			str, _ := value.(string)
+			end, err := timeutil.ParseTimestampIn(str, loc)
+			if err != nil {
+				h.writeError(w, apierror.Validation("Invalid end time", models.InvalidField("endsAt", "datetime", "must be an ISO 8601 date and time")))
+				return
+			}
+			endsAt = &end
+		}
+		argCount++
+		setParts = append(setParts, "ends_at = $"+strconv.Itoa(argCount))
+		args = append(args, endsAt)
+		updated.EndsAt = endsAt
+	}
 
 	for key, value := range updates {
 		switch key {
-		case "title", "description", "location", "book":
-			if str, ok := value.(string); ok && str != "" {
-				argCount++
-				setParts = append(setParts, key+" = $"+strconv.Itoa(argCount))
-				args = append(args, str)
+		case "title", "location":
+			str, _ := value.(string)
+			argCount++
+			setParts = append(setParts, key+" = $"+strconv.Itoa(argCount))
+			args = append(args, str)
+			if key == "title" {
+				updated.Title = str
+			} else {
+				updated.Location = str
+			}
+		case "description", "book":
+			// null or an empty string clears them
+			var text *string
+			if str, _ := value.(string); str != "" {
+				text = &str
+			}
+			argCount++
+			setParts = append(setParts, key+" = $"+strconv.Itoa(argCount))
+			args = append(args, text)
+			if key == "description" {
+				updated.Description = text
+			} else {
+				updated.Book = text
+			}
+		case "type":
+			str, _ := value.(string)
+			if derr, err := checkTerm(r.Context(), h.vocabulary, event.ClubID, vocab.EventType, "type", str); err != nil {
+				logging.FromContext(r.Context()).Error("error loading event types", "error", err)
+				h.writeError(w, apierror.Internal("Failed to update event"))
+				return
+			} else if derr != nil {
+				h.writeError(w, derr)
+				return
 			}
+			argCount++
+			setParts = append(setParts, "type = $"+strconv.Itoa(argCount))
+			args = append(args, str)
+			updated.Type = str
 		case "date":
 			if str, ok := value.(string); ok {
-				if _, err := time.Parse("2006-01-02", str); err == nil {
+				if _, err := time.Parse(timeutil.DateLayout, str); err == nil {
 					argCount++
 					setParts = append(setParts, "event_date = $"+strconv.Itoa(argCount))
 					args = append(args, str)
+					updated.Date = str
+					dateExpr = "$" + strconv.Itoa(argCount) + "::date"
 				}
 			}
 		case "time":
@@ -315,33 +742,86 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 				argCount++
 				setParts = append(setParts, "event_time = $"+strconv.Itoa(argCount))
 				args = append(args, str)
+				updated.Time = str
+				timeExpr = "$" + strconv.Itoa(argCount) + "::time"
 			}
 		}
 	}
 
 	if len(setParts) == 0 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "No valid fields to update", nil)
+		h.writeError(w, apierror.Validation("No valid fields to update", models.InvalidField("", "required", "Give at least one field to update")))
 		return
 	}
 
+	if updated.EndsAt != nil && !updated.EndsAt.After(updated.StartTime()) {
+		h.writeError(w, apierror.Validation("Event must end after it starts", models.InvalidField("endsAt", "after_start", "must be after the start")))
+		return
+	}
+
+	startsAtWritten := h.startsAt.Writes() && (dateExpr != "event_date" || timeExpr != "event_time" || tzExpr != "timezone")
+	if startsAtWritten {
+		setParts = append(setParts, "starts_at = ("+dateExpr+" + "+timeExpr+") AT TIME ZONE "+tzExpr)
+	}
+
 	argCount++
 	args = append(args, eventID)
 
-	query := `UPDATE events SET ` + strings.Join(setParts, ", ") + `, updated_at = NOW() WHERE id = $` + strconv.Itoa(argCount)
+	query := `UPDATE events SET ` + strings.Join(setParts, ", ") + `, version = version + 1, updated_at = NOW() WHERE id = $` + strconv.Itoa(argCount) + ` AND deleted_at IS NULL`
+	if ifVersion != nil {
+		argCount++
+		args = append(args, *ifVersion)
+		query += ` AND version = $` + strconv.Itoa(argCount)
+	}
+	query += ` RETURNING version`
 
-	_, err = h.db.ExecContext(r.Context(), query, args...)
+	err = h.db.WithTx(r.Context(), func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(r.Context(), query, args...).Scan(&updated.Version); err != nil || h.integrations == nil {
+			return err
+		}
+		return h.integrations.Queue(r.Context(), tx, event.ClubID, integrations.NewAnnouncement(integrations.KindUpdated, &updated))
+	})
+	if err == sql.ErrNoRows {
+		// Deleted, or changed by someone else, since it was read
+		current, err := h.getEventByID(r.Context(), eventID)
+		if err != nil || ifVersion == nil {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		h.writeVersionConflict(w, current)
+		return
+	}
 	if err != nil {
-		log.Printf("Error updating event: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update event", nil)
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update event"))
 		return
 	}
+	if startsAtWritten {
+		h.startsAt.RecordWrite()
+	}
+	audit.Describe(r.Context(), "event", eventID.String(), audit.Diff(event, &updated))
+	recordChange(r.Context(), h.changes, event.ClubID, changes.EventUpdated, eventID, eventID)
 
 	response := map[string]interface{}{
 		"event": map[string]interface{}{
 			"id":        eventID,
-			"updatedAt": time.Now(),
+			"version":   updated.Version,
+			"updatedAt": h.now(),
 		},
 	}
+	w.Header().Set("ETag", versionETag(updated.Version))
+
+	// Warn when the event moved onto a public holiday
+	if str, ok := updates["date"].(string); ok {
+		if newDate, err := time.Parse(timeutil.DateLayout, str); err == nil {
+			if warnings := h.holidayWarnings(r.Context(), event.ClubID, newDate); len(warnings) > 0 {
+				response["warnings"] = warnings
+			}
+		}
+	}
 
 	// Add updated fields to response
 	for key, value := range updates {
@@ -357,16 +837,91 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Event updated successfully")
 }
 
+// eventPatch holds the fields of an event that UpdateEvent can change, as a
+// merge patch document. Times are wall-clock times in the event's timezone.
+type eventPatch struct {
+	Title       string  `json:"title" validate:"required,min=1,max=100" sanitize:"text"`
+	Description *string `json:"description" sanitize:"markdown"`
+	Date        string  `json:"date" validate:"required,datetime=2006-01-02"`
+	Time        string  `json:"time" validate:"required,datetime=15:04"`
+	StartsAt    string  `json:"startsAt" validate:"required"`
+	EndsAt      *string `json:"endsAt"`
+	Timezone    string  `json:"timezone" validate:"required"`
+	Location    string  `json:"location" validate:"required,min=1,max=200" sanitize:"text"`
+	Book        *string `json:"book" sanitize:"text"`
+	Type        string  `json:"type" validate:"required,max=50"`
+}
+
+func newEventPatch(event *models.Event) eventPatch {
+	loc := event.TimeLocation()
+	start := event.StartTime().In(loc)
+	patch := eventPatch{
+		Title:       event.Title,
+		Description: event.Description,
+		Date:        timeutil.FormatDate(start),
+		Time:        start.Format(timeutil.TimeLayout),
+		StartsAt:    start.Format("2006-01-02T15:04"),
+		Timezone:    loc.String(),
+		Location:    event.Location,
+		Book:        event.Book,
+		Type:        event.Type,
+	}
+	if event.EndsAt != nil {
+		end := timeutil.FormatTimestampIn(*event.EndsAt, loc)
+		patch.EndsAt = &end
+	}
+	return patch
+}
+
+// updates returns the patched values of the named fields, keyed by their
+// JSON names. Cleared fields are nil.
+func (p eventPatch) updates(fields []string) map[string]interface{} {
+	values := map[string]interface{}{
+		"title":       p.Title,
+		"description": p.Description,
+		"date":        p.Date,
+		"time":        p.Time,
+		"startsAt":    p.StartsAt,
+		"endsAt":      p.EndsAt,
+		"timezone":    p.Timezone,
+		"location":    p.Location,
+		"book":        p.Book,
+		"type":        p.Type,
+	}
+
+	updates := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		value := values[field]
+		if str, ok := value.(*string); ok {
+			if str == nil {
+				value = nil
+			} else {
+				value = *str
+			}
+		}
+		updates[field] = value
+	}
+	return updates
+}
+
+// writeVersionConflict refuses an edit of an event that changed since the
+// client read it, answering with the event as it is now
+func (h *EventHandler) writeVersionConflict(w http.ResponseWriter, current *models.Event) {
+	derr := versionConflict(current, current.Version)
+	w.Header().Set("ETag", versionETag(current.Version))
+	h.writeError(w, derr)
+}
+
 func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid event ID", nil)
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
 		return
 	}
 
 	userID, err := auth.GetUserIDFromContext(r.Context())
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context", nil)
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
 		return
 	}
 
@@ -374,33 +929,47 @@ func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	event, err := h.getEventByID(r.Context(), eventID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Event not found", nil)
+			h.writeError(w, apierror.NotFound("Event not found"))
 			return
 		}
-		log.Printf("Error getting event: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get event", nil)
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
 		return
 	}
 
 	// Check permissions
-	if !h.canManageEvents(r.Context(), event.ClubID, userID) && event.CreatedBy != userID {
-		h.writeErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions", nil)
+	if !authz.Can(r.Context(), authz.ManageEvents) && event.CreatedBy != userID {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
 		return
 	}
 
-	query := `DELETE FROM events WHERE id = $1`
-	result, err := h.db.ExecContext(r.Context(), query, eventID)
-	if err != nil {
-		log.Printf("Error deleting event: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete event", nil)
+	dryRun, derr := dryRunParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Event not found", nil)
+	// Soft delete: items and availability stay so a global admin can restore the event
+	removal, err := h.stores.Removals.DeleteEvent(r.Context(), eventID, userID, dryRun)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete event"))
+		return
+	}
+	if dryRun {
+		h.writeSuccessResponse(w, map[string]interface{}{"dryRun": true, "event": event, "effects": removal.Effects}, "Event would be deleted")
 		return
 	}
+	audit.Describe(r.Context(), "event", eventID.String(), audit.Diff(event, nil))
+	recordChange(r.Context(), h.changes, event.ClubID, changes.EventDeleted, eventID, eventID)
 
 	response := map[string]string{
 		"message": "Event deleted successfully",
@@ -409,38 +978,452 @@ func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccessResponse(w, response, "Event deleted successfully")
 }
 
+// GetEvent returns an event. Organizers (members who manage events and its creator)
+// also get the headcount recorded for it or, until one is, the forecast
+// attendance from the club's history.
+func (h *EventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	withHTML, derr := htmlFormatParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	event, err := h.getEventByID(r.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
+		return
+	}
+
+	attendeeCount, err := h.attendeeCount(r.Context(), event)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error counting attendees", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
+		return
+	}
+	if withHTML {
+		event.DescriptionHTML = renderDescription(event.Description)
+	}
+
+	response := map[string]interface{}{
+		"event":         event,
+		"attendeeCount": attendeeCount,
+	}
+
+	// Everything the event page shows, so it needs no other request
+	if h.stores != nil {
+		items, err := h.stores.EventItems.ListByEvent(r.Context(), eventID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error querying event items", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get event"))
+			return
+		}
+		availability, err := h.stores.Availability.Summary(r.Context(), eventID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error querying availability summary", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get event"))
+			return
+		}
+		response["itemsSummary"] = models.SummarizeEventItems(items)
+		response["availabilitySummary"] = availability
+	}
+
+	if authz.Can(r.Context(), authz.ManageEvents) || event.CreatedBy == userID {
+		attendance, err := h.recordedAttendance(r.Context(), eventID)
+		switch {
+		case err == nil:
+			response["attendance"] = attendance
+		case err == sql.ErrNoRows:
+			projected, err := h.forecastAttendance(r.Context(), event)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("error forecasting attendance", "error", err)
+				h.writeError(w, apierror.Internal("Failed to get event"))
+				return
+			}
+			response["forecast"] = projected
+		default:
+			logging.FromContext(r.Context()).Error("error getting event attendance", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get event"))
+			return
+		}
+	}
+
+	// The ETag names the version edits are based on, for If-Match
+	w.Header().Set("ETag", versionETag(event.Version))
+	h.writeSuccessResponse(w, response, "Event retrieved successfully")
+}
+
+// RecordAttendance records how many people came to an event once it has
+// started, with its RSVPs at the time, for forecasting the club's later events
+func (h *EventHandler) RecordAttendance(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	event, err := h.getEventByID(r.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
+		return
+	}
+
+	if !authz.Can(r.Context(), authz.ManageEvents) && event.CreatedBy != userID {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.RecordAttendanceRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	now := h.now()
+	if event.StartTime().After(now) {
+		h.writeError(w, apierror.Validation("Attendance can only be recorded once the event has started", models.InvalidField("attended", "not_started", "can only be recorded once the event has started")))
+		return
+	}
+
+	rsvps, _, err := h.rsvpCounts(r.Context(), event)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error counting RSVPs", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record attendance"))
+		return
+	}
+
+	attendance := models.EventAttendance{
+		EventID:    eventID,
+		RSVPs:      rsvps,
+		Attended:   *req.Attended,
+		RecordedBy: &userID,
+		RecordedAt: now,
+	}
+
+	query := `
+		INSERT INTO event_attendance (event_id, club_id, event_date, rsvps, attended, recorded_by, recorded_at)
+		VALUES ($1, $2, $3::date, $4, $5, $6, $7)
+		ON CONFLICT (event_id) DO UPDATE SET
+			rsvps = EXCLUDED.rsvps,
+			attended = EXCLUDED.attended,
+			recorded_by = EXCLUDED.recorded_by,
+			recorded_at = EXCLUDED.recorded_at`
+
+	_, err = h.db.ExecContext(r.Context(), query, eventID, event.ClubID, timeutil.FormatDate(event.StartTime()),
+		attendance.RSVPs, attendance.Attended, userID, attendance.RecordedAt)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error recording attendance", "error", err)
+		h.writeError(w, apierror.Internal("Failed to record attendance"))
+		return
+	}
+	audit.Describe(r.Context(), "event", eventID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]interface{}{"attendance": attendance}, "Attendance recorded successfully")
+}
+
+// PrintAttendees renders name tags or a sign-in sheet for the event's RSVP'd attendees
+func (h *EventHandler) PrintAttendees(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "nametags"
+	}
+	if format != "nametags" && format != "signin" {
+		h.writeError(w, apierror.Validation("Invalid format. Use 'nametags' or 'signin'", models.InvalidField("format", "oneof", "must be one of: nametags, signin")))
+		return
+	}
+
+	event, err := h.getEventByID(r.Context(), eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
+		return
+	}
+
+	if !authz.Can(r.Context(), authz.ManageEvents) && event.CreatedBy != userID {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	sheet := reports.AttendeeSheet{
+		EventTitle:  event.Title,
+		EventDate:   event.Date,
+		EventTime:   event.Time,
+		GeneratedAt: h.now(),
+	}
+
+	// Club branding from the theme settings
+	brandQuery := `SELECT name, COALESCE(brand_color, '') FROM clubs WHERE id = $1`
+	if err := h.db.QueryRowContext(r.Context(), brandQuery, event.ClubID).Scan(&sheet.ClubName, &sheet.BrandColor); err != nil {
+		logging.FromContext(r.Context()).Error("error getting club branding", "error", err)
+	}
+
+	// RSVP'd attendees: listed on the event or marked available
+	attendeeQuery := `
+		SELECT u.name FROM users u
+		WHERE u.id = ANY($1::uuid[])
+		   OR u.id IN (SELECT user_id FROM availability WHERE event_id = $2 AND status = 'available')
+		ORDER BY u.name ASC`
+
+	rows, err := h.db.QueryContext(r.Context(), attendeeQuery, event.Attendees, eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying attendees", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get attendees"))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning attendee", "error", err)
+			continue
+		}
+		sheet.Attendees = append(sheet.Attendees, name)
+	}
+
+	var buf bytes.Buffer
+	if format == "signin" {
+		err = reports.WriteSignInSheetPDF(&buf, sheet)
+	} else {
+		err = reports.WriteNameTagsPDF(&buf, sheet)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error rendering attendee PDF", "error", err)
+		h.writeError(w, apierror.Internal("Failed to generate PDF"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.pdf"`, format, eventID))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
 // Helper methods
-func (h *EventHandler) isClubMember(ctx context.Context, clubID, userID uuid.UUID) bool {
-	query := `SELECT 1 FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
-	var exists int
-	err := h.db.QueryRowContext(ctx, query, clubID, userID).Scan(&exists)
-	return err == nil
+
+// holidayWarnings returns a warning when the date is a public holiday in the club's country
+func (h *EventHandler) holidayWarnings(ctx context.Context, clubID uuid.UUID, date time.Time) []models.Warning {
+	var country string
+	h.db.QueryRowContext(ctx, `SELECT COALESCE(country, '') FROM clubs WHERE id = $1`, clubID).Scan(&country)
+	if country == "" {
+		return nil
+	}
+
+	holiday, ok := holidays.Lookup(country, date)
+	if !ok {
+		return nil
+	}
+
+	return []models.Warning{{
+		Code:    "PUBLIC_HOLIDAY",
+		Message: fmt.Sprintf("%s is %s in %s; attendance may be lower", holiday.Date, holiday.Name, holiday.Country),
+		Details: map[string]interface{}{
+			"holiday": holiday,
+		},
+	}}
+}
+
+// rsvpCounts returns the event's RSVPs (listed on the event or marked
+// available) and the members who answered maybe
+// loadTemplate returns a template the club may apply: its own or a shared one
+func (h *EventHandler) loadTemplate(ctx context.Context, clubID, templateID uuid.UUID) (templates.Template, error) {
+	if h.templates == nil {
+		return templates.Template{}, templates.ErrNotFound
+	}
+	t, err := h.templates.Get(ctx, templateID)
+	if err != nil {
+		return templates.Template{}, err
+	}
+	if !t.VisibleTo(clubID) {
+		return templates.Template{}, templates.ErrNotFound
+	}
+	return t, nil
+}
+
+// applyTemplateItems adds a template's items to a new event and counts the use.
+// The event exists either way, so failures are logged rather than returned.
+func (h *EventHandler) applyTemplateItems(ctx context.Context, event *models.Event, template templates.Template) []*models.EventItem {
+	created := []*models.EventItem{}
+	if err := h.templates.RecordUse(ctx, template.ID); err != nil {
+		logging.FromContext(ctx).Error("error recording event template use", "error", err)
+	}
+	if h.stores == nil || len(template.Items) == 0 {
+		return created
+	}
+
+	categories := vocab.Defaults(vocab.ItemCategory)
+	if h.vocabulary != nil {
+		v, err := h.vocabulary.List(ctx, event.ClubID, vocab.ItemCategory)
+		if err != nil {
+			logging.FromContext(ctx).Error("error loading item categories", "error", err)
+			return created
+		}
+		categories = v
+	}
+
+	for _, item := range template.ItemsFor(categories) {
+		eventItem := &models.EventItem{
+			ID:        uuid.New(),
+			EventID:   event.ID,
+			Name:      item.Name,
+			Category:  item.Category,
+			Status:    "pending",
+			Notes:     item.Notes,
+			Quantity:  item.Quantity,
+			Unit:      item.Unit,
+			CreatedBy: event.CreatedBy,
+			CreatedAt: h.now(),
+		}
+		if err := h.stores.EventItems.Create(ctx, eventItem); err != nil {
+			logging.FromContext(ctx).Error("error creating event item from template", "error", err)
+			continue
+		}
+		created = append(created, eventItem)
+	}
+	return created
+}
+
+func (h *EventHandler) rsvpCounts(ctx context.Context, event *models.Event) (rsvps, maybes int, err error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users u
+			 WHERE u.id = ANY($1::uuid[])
+			    OR u.id IN (SELECT user_id FROM availability WHERE event_id = $2 AND status = 'available')),
+			(SELECT COUNT(*) FROM availability
+			 WHERE event_id = $2 AND status = 'maybe' AND NOT (user_id = ANY($1::uuid[])))`
+
+	err = h.db.QueryRowContext(ctx, query, event.Attendees, event.ID).Scan(&rsvps, &maybes)
+	return rsvps, maybes, err
+}
+
+// attendeeCount counts the event's RSVP'd attendees: listed on the event or
+// marked available, as on the printed attendee list
+func (h *EventHandler) attendeeCount(ctx context.Context, event *models.Event) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM users u
+		WHERE u.id = ANY($1::uuid[])
+		   OR u.id IN (SELECT user_id FROM availability WHERE event_id = $2 AND status = 'available')`
+
+	var count int
+	err := h.db.QueryRowContext(ctx, query, event.Attendees, event.ID).Scan(&count)
+	return count, err
+}
+
+// recordedAttendance returns the headcount recorded for an event, or sql.ErrNoRows
+func (h *EventHandler) recordedAttendance(ctx context.Context, eventID uuid.UUID) (*models.EventAttendance, error) {
+	query := `SELECT rsvps, attended, recorded_by, recorded_at FROM event_attendance WHERE event_id = $1`
+
+	attendance := models.EventAttendance{EventID: eventID}
+	err := h.db.QueryRowContext(ctx, query, eventID).Scan(
+		&attendance.RSVPs, &attendance.Attended, &attendance.RecordedBy, &attendance.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attendance, nil
 }
 
-func (h *EventHandler) canManageEvents(ctx context.Context, clubID, userID uuid.UUID) bool {
-	query := `SELECT role FROM club_members WHERE club_id = $1 AND user_id = $2 AND is_active = true`
-	var role string
-	err := h.db.QueryRowContext(ctx, query, clubID, userID).Scan(&role)
+// forecastAttendance projects the event's headcount from its RSVPs and the
+// club's most recent recorded events
+func (h *EventHandler) forecastAttendance(ctx context.Context, event *models.Event) (models.AttendanceForecast, error) {
+	rsvps, maybes, err := h.rsvpCounts(ctx, event)
 	if err != nil {
-		return false
+		return models.AttendanceForecast{}, err
 	}
-	return role == "owner" || role == "moderator"
+
+	query := `
+		SELECT rsvps, attended FROM event_attendance
+		WHERE club_id = $1 AND event_id <> $2
+		ORDER BY event_date DESC
+		LIMIT $3`
+
+	rows, err := h.db.QueryContext(ctx, query, event.ClubID, event.ID, forecast.HistoryEvents)
+	if err != nil {
+		return models.AttendanceForecast{}, err
+	}
+	defer rows.Close()
+
+	history := []forecast.Outcome{}
+	for rows.Next() {
+		var o forecast.Outcome
+		if err := rows.Scan(&o.RSVPs, &o.Attended); err != nil {
+			return models.AttendanceForecast{}, err
+		}
+		history = append(history, o)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AttendanceForecast{}, err
+	}
+
+	return forecast.Project(history, rsvps, maybes, event.MaxAttendees), nil
 }
 
 func (h *EventHandler) getEventByID(ctx context.Context, eventID uuid.UUID) (*models.Event, error) {
 	query := `
 		SELECT id, club_id, title, description, event_date, event_time, location, 
-		       book, type, max_attendees, is_public, created_by, attendees, created_at, updated_at
-		FROM events WHERE id = $1`
+		       book, type, max_attendees, is_public, created_by, attendees, created_at, updated_at,
+		       timezone, ends_at, (event_date + event_time) AT TIME ZONE timezone, starts_at, agenda, version
+		FROM events WHERE id = $1 AND deleted_at IS NULL`
 
 	var event models.Event
 	var attendees models.UUIDArray
+	var legacyStartsAt time.Time
+	var startsAt *time.Time
 
 	err := h.db.QueryRowContext(ctx, query, eventID).Scan(
 		&event.ID, &event.ClubID, &event.Title, &event.Description,
 		&event.Date, &event.Time, &event.Location, &event.Book,
 		&event.Type, &event.MaxAttendees, &event.IsPublic, &event.CreatedBy,
 		&attendees, &event.CreatedAt, &event.UpdatedAt,
+		&event.Timezone, &event.EndsAt, &legacyStartsAt, &startsAt, &event.Agenda, &event.Version,
 	)
 
 	if err != nil {
@@ -448,21 +1431,69 @@ func (h *EventHandler) getEventByID(ctx context.Context, eventID uuid.UUID) (*mo
 	}
 
 	event.Attendees = attendees
+	h.shadowStartsAt(ctx, &event, legacyStartsAt, startsAt)
 	return &event, nil
 }
 
-func (h *EventHandler) isValidTimeFormat(timeStr string) bool {
-	_, err := time.Parse("15:04", timeStr)
-	return err == nil
+// shadowStartsAt compares starts_at with event_date and event_time and, in
+// cutover mode, serves the event's date and time from starts_at
+func (h *EventHandler) shadowStartsAt(ctx context.Context, event *models.Event, legacy time.Time, startsAt *time.Time) {
+	if h.startsAt != nil {
+		analytics.TagVariant(ctx, h.startsAt.Name(), string(h.startsAt.Mode()))
+	}
+	if !h.startsAt.Compares() {
+		return
+	}
+	if startsAt == nil {
+		h.startsAt.RecordMissing(ctx, event.ID.String(), legacy)
+		return
+	}
+
+	shadow.Compare(ctx, h.startsAt, event.ID.String(), legacy, *startsAt, time.Time.Equal)
+	if h.startsAt.ReadsNew() {
+		event.Date, event.Time = splitStartsAt(startsAt.In(event.TimeLocation()))
+	}
+}
+
+// splitStartsAt formats a starts_at timestamp, in the event's timezone, the
+// way the event_date and event_time columns scan into strings
+func splitStartsAt(t time.Time) (date, clock string) {
+	date = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano)
+	clock = time.Date(0, 1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC).Format(time.RFC3339Nano)
+	return date, clock
 }
 
-func (h *EventHandler) contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
+// resolveEventTimes reads when a new event starts, from startsAt or else the
+// legacy date and time, and when it ends, on the wall clock of loc
+func resolveEventTimes(req models.CreateEventRequest, loc *time.Location) (time.Time, *time.Time, *apierror.Error) {
+	var start time.Time
+	if req.StartsAt != nil {
+		t, err := timeutil.ParseTimestampIn(*req.StartsAt, loc)
+		if err != nil {
+			return time.Time{}, nil, invalidField("startsAt", "datetime", "must be an ISO 8601 date and time", "Invalid start time")
 		}
+		start = t
+	} else {
+		// The formats were validated with the request
+		start, _ = timeutil.CombineDateTimeIn(req.Date, req.Time, loc)
+	}
+
+	if req.EndsAt == nil {
+		return start, nil, nil
+	}
+	end, err := timeutil.ParseTimestampIn(*req.EndsAt, loc)
+	if err != nil {
+		return time.Time{}, nil, invalidField("endsAt", "datetime", "must be an ISO 8601 date and time", "Invalid end time")
+	}
+	if !end.After(start) {
+		return time.Time{}, nil, invalidField("endsAt", "after_start", "must be after the start", "Event must end after it starts")
 	}
-	return false
+	return start, &end, nil
+}
+
+func (h *EventHandler) isValidTimeFormat(timeStr string) bool {
+	_, err := time.Parse(timeutil.TimeLayout, timeStr)
+	return err == nil
 }
 
 func (h *EventHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
@@ -472,17 +1503,6 @@ func (h *EventHandler) writeSuccessResponse(w http.ResponseWriter, data interfac
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *EventHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, code, message string, details map[string]interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := &models.FrontendErrorResponse{
-		Error:      code,
-		Message:    message,
-		StatusCode: statusCode,
-		Details:    details,
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-	}
-
-	json.NewEncoder(w).Encode(response)
+func (h *EventHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
 }
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestResolveEventTimes(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		req   models.CreateEventRequest
+		start time.Time
+		end   *time.Time
+		field string
+	}{
+		{
+			name:  "legacy date and time in the event's timezone",
+			req:   models.CreateEventRequest{Date: "2030-07-15", Time: "19:30"},
+			start: time.Date(2030, time.July, 15, 17, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "startsAt without an offset",
+			req:   models.CreateEventRequest{StartsAt: str("2030-01-15T19:30"), EndsAt: str("2030-01-15T21:00")},
+			start: time.Date(2030, time.January, 15, 18, 30, 0, 0, time.UTC),
+			end:   timePtr(time.Date(2030, time.January, 15, 20, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:  "startsAt with an offset wins over date and time",
+			req:   models.CreateEventRequest{Date: "2030-01-01", Time: "10:00", StartsAt: str("2030-01-15T19:30:00Z")},
+			start: time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "unparseable start",
+			req:   models.CreateEventRequest{StartsAt: str("next tuesday")},
+			field: "startsAt",
+		},
+		{
+			name:  "ends before it starts",
+			req:   models.CreateEventRequest{StartsAt: str("2030-01-15T19:30"), EndsAt: str("2030-01-15T19:30")},
+			field: "endsAt",
+		},
+	}
+
+	for _, tt := range tests {
+		start, end, derr := resolveEventTimes(tt.req, berlin)
+		if tt.field != "" {
+			if derr == nil {
+				t.Errorf("%s: expected an error for %s", tt.name, tt.field)
+				continue
+			}
+			if errs, _ := derr.Details["errors"].([]models.FieldError); len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("%s: expected an error for %s, got %+v", tt.name, tt.field, derr)
+			}
+			continue
+		}
+		if derr != nil {
+			t.Errorf("%s: unexpected error %+v", tt.name, derr)
+			continue
+		}
+		if !start.Equal(tt.start) {
+			t.Errorf("%s: expected start %v, got %v", tt.name, tt.start, start)
+		}
+		if (end == nil) != (tt.end == nil) || (end != nil && !end.Equal(*tt.end)) {
+			t.Errorf("%s: expected end %v, got %v", tt.name, tt.end, end)
+		}
+	}
+}
+
+func TestCreateEventTimeValidation(t *testing.T) {
+	handler := NewEventHandler(database.NewMock())
+	handler.clock = clock.NewFake(time.Date(2030, time.March, 15, 12, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/events", handler.CreateEvent)
+	path := "/club/" + uuid.New().String() + "/events"
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown timezone", `{"title":"Book Night","startsAt":"2030-03-20T19:00","timezone":"Mars/Olympus","location":"Library","type":"discussion"}`},
+		{"start in the past", `{"title":"Book Night","startsAt":"2030-03-14T19:00","timezone":"Europe/Berlin","location":"Library","type":"discussion"}`},
+		{"end before start", `{"title":"Book Night","startsAt":"2030-03-20T19:00","endsAt":"2030-03-20T18:00","timezone":"Europe/Berlin","location":"Library","type":"discussion"}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestEventPatchUpdates(t *testing.T) {
+	description := "Chapters 1-5"
+	end := time.Date(2030, time.March, 20, 20, 0, 0, 0, time.UTC)
+	event := &models.Event{Title: "Book Night", Description: &description, Date: "2030-03-20", Time: "19:00",
+		Timezone: "Europe/Berlin", EndsAt: &end, Location: "Library", Type: "discussion"}
+
+	patch := newEventPatch(event)
+	if patch.StartsAt != "2030-03-20T19:00" || *patch.EndsAt != "2030-03-20T21:00:00+01:00" {
+		t.Errorf("Expected wall-clock times in the event's timezone, got %s and %s", patch.StartsAt, *patch.EndsAt)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"title":"Poetry Night","description":null,"endsAt":null}`))
+	fields, derr := decodeMergePatch(httptest.NewRecorder(), req, &patch)
+	if derr != nil {
+		t.Fatalf("Unexpected error %+v", derr)
+	}
+
+	updates := patch.updates(fields)
+	if len(updates) != 3 || updates["title"] != "Poetry Night" || updates["description"] != nil || updates["endsAt"] != nil {
+		t.Errorf("Expected only the patched fields, with cleared ones nil, got %v", updates)
+	}
+	if _, ok := updates["description"]; !ok {
+		t.Errorf("Expected the cleared description among the updates, got %v", updates)
+	}
+}
+
+func TestHTMLFormatParam(t *testing.T) {
+	for query, expected := range map[string]bool{"": false, "?format=markdown": false, "?format=html": true} {
+		withHTML, err := htmlFormatParam(httptest.NewRequest(http.MethodGet, "/api/events/1"+query, nil))
+		if err != nil || withHTML != expected {
+			t.Errorf("%q: got %v, %v", query, withHTML, err)
+		}
+	}
+	if _, err := htmlFormatParam(httptest.NewRequest(http.MethodGet, "/api/events/1?format=pdf", nil)); err == nil {
+		t.Error("Expected an error for format=pdf")
+	}
+
+	if renderDescription(nil) != nil || renderDescription(new(string)) != nil {
+		t.Error("Expected no HTML for an empty description")
+	}
+	description := "Bring *snacks*<script>"
+	if html := renderDescription(&description); html == nil || *html != "<p>Bring <em>snacks</em>&lt;script&gt;</p>" {
+		t.Errorf("Unexpected HTML %v", html)
+	}
+}
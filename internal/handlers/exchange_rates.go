@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/store"
+)
+
+// ExchangeRateHandler lets global admins maintain the rates used to convert
+// item costs into club currencies. Rates are snapshotted onto items when a
+// cost is set, so changing a rate never rewrites past costs.
+type ExchangeRateHandler struct {
+	clocked
+
+	stores *store.Stores
+}
+
+func NewExchangeRateHandler(stores *store.Stores) *ExchangeRateHandler {
+	return &ExchangeRateHandler{stores: stores}
+}
+
+// ListRates returns every configured rate
+func (h *ExchangeRateHandler) ListRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.stores.ExchangeRates.List(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying exchange rates", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get exchange rates"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"rates": rates,
+	}
+
+	h.writeSuccessResponse(w, response, "Exchange rates retrieved successfully")
+}
+
+// SetRate creates or replaces the rate from base to quote
+func (h *ExchangeRateHandler) SetRate(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.SetExchangeRateRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	base, ok := money.LookupCurrency(req.Base)
+	if !ok {
+		h.writeError(w, apierror.Validation("Unsupported base currency", models.InvalidField("base", "currency", "must be a supported ISO 4217 code")))
+		return
+	}
+	quote, ok := money.LookupCurrency(req.Quote)
+	if !ok {
+		h.writeError(w, apierror.Validation("Unsupported quote currency", models.InvalidField("quote", "currency", "must be a supported ISO 4217 code")))
+		return
+	}
+	if base.Code == quote.Code {
+		h.writeError(w, apierror.Validation("Base and quote currencies must differ", models.InvalidField("quote", "nefield", "must differ from base")))
+		return
+	}
+	if err := validateRate(string(req.Rate)); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	rate := models.ExchangeRate{
+		Base:      base.Code,
+		Quote:     quote.Code,
+		Rate:      string(req.Rate),
+		UpdatedBy: &userID,
+		UpdatedAt: h.now(),
+	}
+	if err := h.stores.ExchangeRates.Set(r.Context(), rate); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error setting exchange rate", "error", err)
+		h.writeError(w, apierror.Internal("Failed to set exchange rate"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"rate": rate,
+	}
+
+	h.writeSuccessResponse(w, response, "Exchange rate updated successfully")
+}
+
+// validateRate checks a rate fits the NUMERIC(20, 10) column
+func validateRate(rate string) *apierror.Error {
+	if _, ok := money.ParseRate(rate); !ok {
+		return invalidField("rate", "decimal", "must be a positive decimal", "Rate must be a positive decimal")
+	}
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(rate), ".")
+	if len(strings.TrimLeft(whole, "0")) > 10 || len(strings.TrimRight(fraction, "0")) > 10 {
+		return invalidField("rate", "max", "must be less than 10000000000 with at most 10 decimal places",
+			"Rate must be less than 10000000000 with at most 10 decimal places")
+	}
+	return nil
+}
+
+func (h *ExchangeRateHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ExchangeRateHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSetExchangeRate(t *testing.T) {
+	stores := store.NewMemory().Stores()
+	handler := NewExchangeRateHandler(stores)
+	adminID := uuid.New()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"base": "eur", "quote": "USD", "rate": "1.0842"}`, http.StatusOK},
+		{`{"base": "EUR", "quote": "USD", "rate": 1.09}`, http.StatusOK},
+		{`{"base": "EUR", "quote": "EUR", "rate": "1"}`, http.StatusBadRequest},
+		{`{"base": "XXX", "quote": "USD", "rate": "1"}`, http.StatusBadRequest},
+		{`{"base": "EUR", "quote": "USD", "rate": "0"}`, http.StatusBadRequest},
+		{`{"base": "EUR", "quote": "USD", "rate": "1.00000000001"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("PUT", "/api/admin/exchange-rates", bytes.NewBufferString(tt.body))
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: adminID}))
+		w := httptest.NewRecorder()
+		handler.SetRate(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.expected, w.Code)
+		}
+	}
+
+	// The second valid request replaced the first
+	w := httptest.NewRecorder()
+	handler.ListRates(w, httptest.NewRequest("GET", "/api/admin/exchange-rates", nil))
+
+	var response struct {
+		Data struct {
+			Rates []models.ExchangeRate `json:"rates"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	rates := response.Data.Rates
+	if len(rates) != 1 || rates[0].Base != "EUR" || rates[0].Rate != "1.09" || rates[0].UpdatedBy == nil || *rates[0].UpdatedBy != adminID {
+		t.Errorf("Unexpected rates: %+v", rates)
+	}
+}
+
+// violatingRates fails every write with a foreign key violation, as when the
+// admin's user row is gone
+type violatingRates struct {
+	store.ExchangeRateStore
+}
+
+func (violatingRates) Set(ctx context.Context, rate models.ExchangeRate) error {
+	return &pgconn.PgError{Code: "23503", Message: `insert or update on table "exchange_rates" violates foreign key constraint "exchange_rates_updated_by_fkey"`}
+}
+
+func TestSetExchangeRateConstraintViolation(t *testing.T) {
+	stores := store.NewMemory().Stores()
+	stores.ExchangeRates = violatingRates{stores.ExchangeRates}
+	handler := NewExchangeRateHandler(stores)
+
+	req := httptest.NewRequest("PUT", "/api/admin/exchange-rates", bytes.NewBufferString(`{"base": "EUR", "quote": "USD", "rate": "1.09"}`))
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+	w := httptest.NewRecorder()
+	handler.SetRate(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.FrontendErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Error != "REFERENCE_NOT_FOUND" {
+		t.Errorf("Expected REFERENCE_NOT_FOUND, got %s", response.Error)
+	}
+}
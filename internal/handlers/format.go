@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/markdown"
+)
+
+// htmlFormatParam reads ?format= on endpoints returning club and event
+// descriptions: markdown, the default, returns the stored Markdown only; html
+// adds the rendered descriptionHtml
+func htmlFormatParam(r *http.Request) (bool, *apierror.Error) {
+	switch r.URL.Query().Get("format") {
+	case "", "markdown":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, invalidField("format", "oneof", "must be one of: markdown, html", "Invalid format value")
+}
+
+// renderDescription returns the HTML of a Markdown description, or nil when
+// there is none
+func renderDescription(description *string) *string {
+	if description == nil || *description == "" {
+		return nil
+	}
+	rendered := markdown.Render(*description)
+	return &rendered
+}
@@ -522,14 +522,18 @@ func (h *HealthHandler) getHealthSummary() HealthSummary {
 	return summary
 }
 
-// RegisterHealthRoutes registers health monitoring routes
-func (h *HealthHandler) RegisterRoutes() chi.Router {
+// RegisterRoutes registers health monitoring routes. The metrics expose query
+// text and who is connected, so they are only served through protect.
+func (h *HealthHandler) RegisterRoutes(protect func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 
 	r.Get("/health", h.HealthCheck)
-	r.Get("/metrics", h.DatabaseMetrics)
-	r.Get("/metrics/tables", h.TableStats)
-	r.Get("/metrics/slow-queries", h.SlowQueries)
+	r.Group(func(r chi.Router) {
+		r.Use(protect)
+		r.Get("/metrics", h.DatabaseMetrics)
+		r.Get("/metrics/tables", h.TableStats)
+		r.Get("/metrics/slow-queries", h.SlowQueries)
+	})
 
 	return r
 }
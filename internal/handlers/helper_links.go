@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/signedurl"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// HelperLinkHandler manages signed links that let non-members (e.g. a venue contact)
+// view and update selected coordination items until the event is over
+type HelperLinkHandler struct {
+	clocked
+
+	db     *database.DB
+	signer *signedurl.Signer
+}
+
+func NewHelperLinkHandler(db *database.DB, signer *signedurl.Signer) *HelperLinkHandler {
+	return &HelperLinkHandler{db: db, signer: signer}
+}
+
+// CreateLink issues a signed helper link for a set of event items
+func (h *HelperLinkHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	var req models.CreateHelperLinkRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Labels are trimmed first so a blank label counts as missing
+	req.Label = strings.TrimSpace(req.Label)
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// Links never outlive the event: they expire at the end of the event day
+	// where the event happens
+	var eventEnd time.Time
+	err = h.db.QueryRowContext(r.Context(), `SELECT (event_date + INTERVAL '1 day') AT TIME ZONE timezone FROM events WHERE id = $1 AND deleted_at IS NULL`, eventID).Scan(&eventEnd)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create helper link"))
+		return
+	}
+
+	expiresAt := eventEnd
+	if req.ExpiresAt != nil {
+		if req.ExpiresAt.After(eventEnd) {
+			h.writeError(w, apierror.Validation("Helper links cannot expire after the event", models.InvalidField("expiresAt", "max", "cannot be after the event ends")))
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	if !expiresAt.After(h.now()) {
+		h.writeError(w, apierror.Validation("Helper link expiry must be in the future", models.InvalidField("expiresAt", "future", "must be in the future")))
+		return
+	}
+
+	// Every item has to belong to this event
+	itemIDs := models.UUIDArray(uniqueUUIDs(req.ItemIDs))
+	var matched int
+	err = h.db.QueryRowContext(r.Context(),
+		`SELECT COUNT(*) FROM event_items WHERE event_id = $1 AND id = ANY($2)`,
+		eventID, itemIDs).Scan(&matched)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error checking event items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create helper link"))
+		return
+	}
+	if matched != len(itemIDs) {
+		h.writeError(w, apierror.Validation("All items must belong to this event", models.InvalidField("itemIds", "event_items", "must all belong to this event")))
+		return
+	}
+
+	link := &models.EventHelperLink{
+		ID:        uuid.New(),
+		EventID:   eventID,
+		Label:     req.Label,
+		ItemIDs:   itemIDs,
+		CanUpdate: req.CanUpdate,
+		CreatedBy: userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: h.now(),
+	}
+
+	query := `
+		INSERT INTO event_helper_links (id, event_id, label, item_ids, can_update, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = h.db.ExecContext(r.Context(), query,
+		link.ID, link.EventID, link.Label, link.ItemIDs, link.CanUpdate, link.CreatedBy, link.ExpiresAt,
+	)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating helper link", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create helper link"))
+		return
+	}
+
+	// The token is only shown once; revoking the link invalidates it
+	token := h.signer.Sign(link.ID.String(), link.ExpiresAt)
+
+	response := map[string]interface{}{
+		"link":  link,
+		"token": token,
+		"path":  "/api/helper/" + token,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Helper link created successfully")
+}
+
+// GetLinks lists the helper links issued for an event
+func (h *HelperLinkHandler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	query := `
+		SELECT id, event_id, label, item_ids, can_update, created_by, expires_at, revoked_at, last_used_at, created_at
+		FROM event_helper_links
+		WHERE event_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := h.db.QueryContext(r.Context(), query, eventID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying helper links", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get helper links"))
+		return
+	}
+	defer rows.Close()
+
+	links := []models.EventHelperLink{}
+	for rows.Next() {
+		var link models.EventHelperLink
+
+		err := rows.Scan(
+			&link.ID, &link.EventID, &link.Label, &link.ItemIDs, &link.CanUpdate, &link.CreatedBy,
+			&link.ExpiresAt, &link.RevokedAt, &link.LastUsedAt, &link.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning helper link", "error", err)
+			continue
+		}
+
+		links = append(links, link)
+	}
+
+	response := map[string]interface{}{
+		"links": links,
+	}
+
+	h.writeSuccessResponse(w, response, "Helper links retrieved successfully")
+}
+
+// RevokeLink disables a helper link before it expires
+func (h *HelperLinkHandler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	linkID, err := uuid.Parse(chi.URLParam(r, "linkId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid link ID", models.InvalidID("linkId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	if !canManageEventItems(r.Context(), userID) {
+		h.writeError(w, apierror.Forbidden("Insufficient permissions"))
+		return
+	}
+
+	query := `UPDATE event_helper_links SET revoked_at = NOW() WHERE id = $1 AND event_id = $2 AND revoked_at IS NULL`
+	result, err := h.db.ExecContext(r.Context(), query, linkID, eventID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error revoking helper link", "error", err)
+		h.writeError(w, apierror.Internal("Failed to revoke helper link"))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("Helper link not found"))
+		return
+	}
+
+	response := map[string]string{
+		"message": "Helper link revoked successfully",
+	}
+
+	h.writeSuccessResponse(w, response, "Helper link revoked successfully")
+}
+
+// GetHelperView returns the event summary and the items a helper link grants access to
+func (h *HelperLinkHandler) GetHelperView(w http.ResponseWriter, r *http.Request) {
+	link, ok := h.resolveLink(w, r)
+	if !ok {
+		return
+	}
+
+	var event models.Event
+	query := `SELECT id, title, event_date, event_time, timezone, location FROM events WHERE id = $1 AND deleted_at IS NULL`
+	err := h.db.QueryRowContext(r.Context(), query, link.EventID).Scan(
+		&event.ID, &event.Title, &event.Date, &event.Time, &event.Timezone, &event.Location,
+	)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting event for helper link", "error", err)
+		h.writeError(w, apierror.Internal("Failed to load event"))
+		return
+	}
+
+	itemsQuery := `
+		SELECT id, event_id, name, category, assigned_to, status, notes, due_date, sort_order, version, created_by, created_at, updated_at
+		FROM event_items
+		WHERE event_id = $1 AND id = ANY($2)
+		ORDER BY sort_order, created_at ASC`
+
+	rows, err := h.db.QueryContext(r.Context(), itemsQuery, link.EventID, link.ItemIDs)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying helper link items", "error", err)
+		h.writeError(w, apierror.Internal("Failed to load items"))
+		return
+	}
+	defer rows.Close()
+
+	frontendItems := []*models.FrontendEventItem{}
+	for rows.Next() {
+		var item models.EventItem
+
+		err := rows.Scan(
+			&item.ID, &item.EventID, &item.Name, &item.Category,
+			&item.AssignedTo, &item.Status, &item.Notes, &item.DueDate, &item.SortOrder, &item.Version,
+			&item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning item", "error", err)
+			continue
+		}
+
+		frontendItems = append(frontendItems, item.ToFrontendFormat())
+	}
+
+	response := map[string]interface{}{
+		"link": map[string]interface{}{
+			"label":     link.Label,
+			"canUpdate": link.CanUpdate,
+			"expiresAt": link.ExpiresAt,
+		},
+		"event": map[string]interface{}{
+			"id":       event.ID,
+			"title":    event.Title,
+			"date":     event.Date,
+			"time":     event.Time,
+			"timezone": event.Timezone,
+			"startsAt": timeutil.FormatTimestamp(event.StartTime()),
+			"location": event.Location,
+		},
+		"items": frontendItems,
+	}
+
+	h.writeSuccessResponse(w, response, "Helper view retrieved successfully")
+}
+
+// UpdateHelperItem lets a helper update the status or notes of an item in scope
+func (h *HelperLinkHandler) UpdateHelperItem(w http.ResponseWriter, r *http.Request) {
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid item ID", models.InvalidID("itemId")))
+		return
+	}
+
+	link, ok := h.resolveLink(w, r)
+	if !ok {
+		return
+	}
+
+	if !link.CanUpdate {
+		h.writeError(w, apierror.Forbidden("This link is view-only"))
+		return
+	}
+
+	inScope := false
+	for _, id := range link.ItemIDs {
+		if id == itemID {
+			inScope = true
+			break
+		}
+	}
+	if !inScope {
+		h.writeError(w, apierror.Forbidden("This link does not grant access to the item"))
+		return
+	}
+
+	var req models.UpdateEventItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 0
+
+	if req.Status != "" {
+		validStatuses := []string{"pending", "in_progress", "completed"}
+		if !containsString(validStatuses, req.Status) {
+			h.writeError(w, apierror.Validation("Invalid status", models.InvalidField("status", "oneof", "must be one of: pending, in_progress, completed")))
+			return
+		}
+		argCount++
+		setParts = append(setParts, "status = $"+strconv.Itoa(argCount))
+		args = append(args, req.Status)
+	}
+
+	if req.Notes != nil {
+		argCount++
+		setParts = append(setParts, "notes = $"+strconv.Itoa(argCount))
+		args = append(args, *req.Notes)
+	}
+
+	if len(setParts) == 0 {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	args = append(args, itemID, link.EventID)
+	query := `UPDATE event_items SET ` + strings.Join(setParts, ", ") + `, version = version + 1, updated_at = NOW() WHERE id = $` + strconv.Itoa(argCount+1) + ` AND event_id = $` + strconv.Itoa(argCount+2)
+
+	result, err := h.db.ExecContext(r.Context(), query, args...)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating item via helper link", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update item"))
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound("Item not found"))
+		return
+	}
+
+	item := map[string]interface{}{
+		"id":        itemID,
+		"updatedAt": h.now(),
+	}
+	if req.Status != "" {
+		item["status"] = req.Status
+	}
+	if req.Notes != nil {
+		item["notes"] = *req.Notes
+	}
+
+	response := map[string]interface{}{
+		"item": item,
+	}
+
+	h.writeSuccessResponse(w, response, "Item updated successfully")
+}
+
+// resolveLink verifies the token in the URL and loads the link it refers to.
+// It writes the error response itself and reports whether the caller may continue.
+func (h *HelperLinkHandler) resolveLink(w http.ResponseWriter, r *http.Request) (*models.EventHelperLink, bool) {
+	subject, _, err := h.signer.Verify(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		if err == signedurl.ErrExpired {
+			h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This link has expired", nil))
+			return nil, false
+		}
+		h.writeError(w, apierror.NotFound("Link not found"))
+		return nil, false
+	}
+
+	linkID, err := uuid.Parse(subject)
+	if err != nil {
+		h.writeError(w, apierror.NotFound("Link not found"))
+		return nil, false
+	}
+
+	// Links to soft-deleted events or clubs stop working until they are restored
+	var link models.EventHelperLink
+	query := `
+		SELECT l.id, l.event_id, l.label, l.item_ids, l.can_update, l.created_by, l.expires_at, l.revoked_at, l.created_at
+		FROM event_helper_links l
+		JOIN events e ON e.id = l.event_id AND e.deleted_at IS NULL
+		JOIN clubs c ON c.id = e.club_id AND c.deleted_at IS NULL
+		WHERE l.id = $1`
+
+	err = h.db.QueryRowContext(r.Context(), query, linkID).Scan(
+		&link.ID, &link.EventID, &link.Label, &link.ItemIDs, &link.CanUpdate,
+		&link.CreatedBy, &link.ExpiresAt, &link.RevokedAt, &link.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Link not found"))
+			return nil, false
+		}
+		logging.FromContext(r.Context()).Error("error getting helper link", "error", err)
+		h.writeError(w, apierror.Internal("Failed to load link"))
+		return nil, false
+	}
+
+	if link.RevokedAt != nil || !h.now().Before(link.ExpiresAt) {
+		h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This link is no longer active", nil))
+		return nil, false
+	}
+
+	if _, err := h.db.ExecContext(r.Context(), `UPDATE event_helper_links SET last_used_at = NOW() WHERE id = $1`, link.ID); err != nil {
+		logging.FromContext(r.Context()).Error("error recording helper link use", "error", err)
+	}
+
+	return &link, true
+}
+
+func (h *HelperLinkHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *HelperLinkHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *HelperLinkHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
+
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
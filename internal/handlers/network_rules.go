@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/netacl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// networkACL is the part of netacl.ACL the handler uses
+type networkACL interface {
+	Rules() []netacl.Rule
+	Add(ctx context.Context, nr netacl.NewRule) (netacl.Rule, error)
+	Remove(ctx context.Context, id uuid.UUID) (netacl.Rule, error)
+	ClientAddr(r *http.Request) (netip.Addr, bool)
+	AdminRestricted() bool
+}
+
+// NetworkRuleHandler lets global admins deny networks and restrict the admin
+// routes to trusted ones at runtime
+type NetworkRuleHandler struct {
+	clocked
+
+	acl networkACL
+}
+
+func NewNetworkRuleHandler(acl networkACL) *NetworkRuleHandler {
+	return &NetworkRuleHandler{acl: acl}
+}
+
+// ListRules returns the rules in force, including those from the configuration
+func (h *NetworkRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	h.writeSuccessResponse(w, map[string]interface{}{"rules": h.acl.Rules()}, "Network rules retrieved successfully")
+}
+
+// CreateRule adds a range to a list. The first admin allow range must include
+// the caller, so admins cannot lock themselves out.
+func (h *NetworkRuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreateNetworkRuleRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	prefix, err := netacl.ParsePrefix(req.CIDR)
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid network range", models.InvalidField("cidr", "cidr", "must be a CIDR range or an IP address")))
+		return
+	}
+
+	if req.List == netacl.ListAdminAllow && !h.acl.AdminRestricted() {
+		if caller, ok := h.acl.ClientAddr(r); !ok || !prefix.Contains(caller.Unmap()) {
+			h.writeError(w, apierror.New(apierror.CodeAdminLockout, "The first admin range must include your own address", map[string]interface{}{
+				"clientIp": caller.String(),
+			}))
+			return
+		}
+	}
+
+	nr := netacl.NewRule{
+		List:      req.List,
+		CIDR:      prefix.String(),
+		Note:      req.Note,
+		CreatedBy: &userID,
+	}
+	if req.ExpiresInHours != nil {
+		expiresAt := h.now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		nr.ExpiresAt = &expiresAt
+	}
+
+	rule, err := h.acl.Add(r.Context(), nr)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating network rule", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create network rule"))
+		return
+	}
+	audit.Describe(r.Context(), "network_rule", rule.ID.String(), audit.Diff(nil, req))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"rule": rule}, "Network rule created successfully")
+}
+
+// DeleteRule removes a rule added at runtime; ranges from the configuration stay
+func (h *NetworkRuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid rule ID", models.InvalidID("ruleId")))
+		return
+	}
+
+	rule, err := h.acl.Remove(r.Context(), ruleID)
+	if err != nil {
+		if errors.Is(err, netacl.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Network rule not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error deleting network rule", "error", err)
+		h.writeError(w, apierror.Internal("Failed to delete network rule"))
+		return
+	}
+	audit.Describe(r.Context(), "network_rule", ruleID.String(), audit.Diff(rule, nil))
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Network rule deleted successfully"}, "Network rule deleted successfully")
+}
+
+func (h *NetworkRuleHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *NetworkRuleHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *NetworkRuleHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/netacl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestCreateNetworkRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminAllow []string
+		body       string
+		expected   int
+	}{
+		{"deny range", nil, `{"list":"deny","cidr":"198.51.100.0/24","note":"scraper"}`, http.StatusCreated},
+		{"single address", nil, `{"list":"deny","cidr":"198.51.100.7"}`, http.StatusCreated},
+		{"unknown list", nil, `{"list":"allow","cidr":"198.51.100.0/24"}`, http.StatusBadRequest},
+		{"invalid range", nil, `{"list":"deny","cidr":"198.51.100.0/33"}`, http.StatusBadRequest},
+		{"first admin range includes caller", nil, `{"list":"admin_allow","cidr":"203.0.113.0/24"}`, http.StatusCreated},
+		{"first admin range excludes caller", nil, `{"list":"admin_allow","cidr":"192.0.2.0/24"}`, http.StatusConflict},
+		{"further admin range", []string{"203.0.113.0/24"}, `{"list":"admin_allow","cidr":"192.0.2.0/24"}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		acl, err := netacl.New(database.NewMock(), nil, tt.adminAllow, false, time.Minute, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		handler := NewNetworkRuleHandler(acl)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/network-rules", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "203.0.113.9:52100"
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+
+		w := httptest.NewRecorder()
+		handler.CreateRule(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDeleteNetworkRuleInvalidID(t *testing.T) {
+	acl, err := netacl.New(database.NewMock(), nil, nil, false, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := chi.NewRouter()
+	router.Delete("/admin/network-rules/{ruleId}", NewNetworkRuleHandler(acl).DeleteRule)
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/network-rules/not-a-uuid", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// notificationFeed is the part of notify.Feed the poll handler uses
+type notificationFeed interface {
+	Since(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Notification, error)
+}
+
+const (
+	// pollWait is how long a poll waits for new notifications; well below
+	// the router's request timeout and the idle timeouts of common proxies
+	pollWait = 25 * time.Second
+	// pollRecheck is how often a waiting poll reads the feed anyway, to find
+	// notifications written by other instances, whose hubs it does not hear
+	pollRecheck = 5 * time.Second
+	// pollRetryAfter is the Retry-After, in seconds, of a refused poll
+	pollRetryAfter = 5
+)
+
+// NotificationPollHandler long-polls for new notifications, for clients that
+// cannot hold a WebSocket or an event stream open
+type NotificationPollHandler struct {
+	clocked
+
+	feed    notificationFeed
+	hub     *notify.Hub
+	wait    time.Duration
+	recheck time.Duration
+}
+
+func NewNotificationPollHandler(feed notificationFeed, hub *notify.Hub) *NotificationPollHandler {
+	return &NotificationPollHandler{feed: feed, hub: hub, wait: pollWait, recheck: pollRecheck}
+}
+
+// PollNotifications returns the caller's notifications created after since,
+// waiting up to 25 seconds for some when there are none yet. The response's
+// since is the cursor of the next poll; without one, polls start from now.
+func (h *NotificationPollHandler) PollNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	since := h.now()
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err = timeutil.ParseTimestamp(value)
+		if err != nil {
+			h.writeError(w, apierror.Validation("Invalid since. Use RFC 3339, e.g. 2024-01-31T00:00:00Z", models.InvalidField("since", "datetime", "must be an RFC 3339 time")))
+			return
+		}
+	}
+
+	// Subscribe before the first read, so nothing written in between is missed
+	wake, unsubscribe, err := h.hub.Subscribe(userID)
+	if err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(pollRetryAfter))
+		if errors.Is(err, notify.ErrUserWaiting) {
+			h.writeError(w, apierror.New(apierror.CodeTooManyPolls, "Too many polls are waiting for your notifications", nil))
+			return
+		}
+		h.writeError(w, apierror.New(apierror.CodePollingUnavailable, "Too many clients are waiting for notifications; please try again shortly", nil))
+		return
+	}
+	defer unsubscribe()
+
+	timeout := time.NewTimer(h.wait)
+	defer timeout.Stop()
+	recheck := time.NewTicker(h.recheck)
+	defer recheck.Stop()
+
+	for {
+		notifications, err := h.feed.Since(r.Context(), userID, since)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			logging.FromContext(r.Context()).Error("error polling notifications", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get notifications"))
+			return
+		}
+		if len(notifications) > 0 {
+			since = notifications[len(notifications)-1].CreatedAt
+			h.writeSuccessResponse(w, pollResponse(notifications, since), "Notifications retrieved successfully")
+			return
+		}
+
+		select {
+		case <-wake:
+		case <-recheck.C:
+		case <-timeout.C:
+			h.writeSuccessResponse(w, pollResponse(notifications, since), "No new notifications")
+			return
+		case <-h.hub.Done():
+			// Shutting down; the client polls again elsewhere
+			h.writeSuccessResponse(w, pollResponse(notifications, since), "No new notifications")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func pollResponse(notifications []models.Notification, since time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"notifications": notifications,
+		"since":         since.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (h *NotificationPollHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *NotificationPollHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
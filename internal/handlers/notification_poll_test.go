@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+// fakeNotificationFeed holds notifications in memory
+type fakeNotificationFeed struct {
+	mu            sync.Mutex
+	notifications []models.Notification
+	reads         int
+}
+
+func (f *fakeNotificationFeed) Since(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	found := []models.Notification{}
+	for _, n := range f.notifications {
+		if n.UserID == userID && n.CreatedAt.After(since) {
+			found = append(found, n)
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeNotificationFeed) add(n models.Notification) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = append(f.notifications, n)
+}
+
+func pollRequest(userID uuid.UUID, since string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/notifications/poll?since="+since, nil)
+	return req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+}
+
+type pollBody struct {
+	Data struct {
+		Notifications []models.Notification `json:"notifications"`
+		Since         string                `json:"since"`
+	} `json:"data"`
+}
+
+func TestPollNotificationsAnswersAtOnce(t *testing.T) {
+	userID := uuid.New()
+	created := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	feed := &fakeNotificationFeed{notifications: []models.Notification{{ID: uuid.New(), UserID: userID, Title: "New event", CreatedAt: created}}}
+	handler := NewNotificationPollHandler(feed, notify.NewHub(10, 10))
+
+	rr := httptest.NewRecorder()
+	handler.PollNotifications(rr, pollRequest(userID, "2024-03-01T00:00:00Z"))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body pollBody
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data.Notifications) != 1 || body.Data.Since != "2024-03-01T12:00:00.0000005Z" {
+		t.Fatalf("unexpected response: %+v", body.Data)
+	}
+}
+
+func TestPollNotificationsWakesOnPublish(t *testing.T) {
+	userID := uuid.New()
+	feed := &fakeNotificationFeed{}
+	hub := notify.NewHub(10, 10)
+	handler := NewNotificationPollHandler(feed, hub)
+	handler.recheck = time.Hour
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.PollNotifications(rr, pollRequest(userID, "2024-03-01T00:00:00Z"))
+		done <- rr
+	}()
+
+	for hub.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	feed.add(models.Notification{ID: uuid.New(), UserID: userID, CreatedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)})
+	hub.Publish([]uuid.UUID{userID})
+
+	select {
+	case rr := <-done:
+		var body pollBody
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		if len(body.Data.Notifications) != 1 {
+			t.Fatalf("expected the new notification, got %s", rr.Body.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll was not woken")
+	}
+	if hub.Waiting() != 0 {
+		t.Fatalf("expected the poll to leave the hub, %d waiting", hub.Waiting())
+	}
+}
+
+func TestPollNotificationsTimesOut(t *testing.T) {
+	feed := &fakeNotificationFeed{}
+	handler := NewNotificationPollHandler(feed, notify.NewHub(10, 10))
+	handler.wait = 30 * time.Millisecond
+	handler.recheck = 10 * time.Millisecond
+
+	rr := httptest.NewRecorder()
+	handler.PollNotifications(rr, pollRequest(uuid.New(), "2024-03-01T00:00:00Z"))
+
+	var body pollBody
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if rr.Code != http.StatusOK || len(body.Data.Notifications) != 0 || body.Data.Since != "2024-03-01T00:00:00Z" {
+		t.Fatalf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+	if feed.reads < 2 {
+		t.Fatalf("expected the feed to be rechecked, read %d times", feed.reads)
+	}
+}
+
+func TestPollNotificationsCaps(t *testing.T) {
+	userID := uuid.New()
+	hub := notify.NewHub(10, 1)
+	_, unsubscribe, _ := hub.Subscribe(userID)
+	defer unsubscribe()
+	handler := NewNotificationPollHandler(&fakeNotificationFeed{}, hub)
+
+	rr := httptest.NewRecorder()
+	handler.PollNotifications(rr, pollRequest(userID, ""))
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d", rr.Code)
+	}
+
+	full := NewNotificationPollHandler(&fakeNotificationFeed{}, notify.NewHub(0, 1))
+	rr = httptest.NewRecorder()
+	full.PollNotifications(rr, pollRequest(uuid.New(), ""))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+}
+
+func TestPollNotificationsInvalidSince(t *testing.T) {
+	handler := NewNotificationPollHandler(&fakeNotificationFeed{}, notify.NewHub(10, 10))
+
+	rr := httptest.NewRecorder()
+	handler.PollNotifications(rr, pollRequest(uuid.New(), "yesterday"))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
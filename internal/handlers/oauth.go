@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/oauth"
+	"bookwork-api/internal/signedurl"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// oauthStateCookie binds the sign-in state to the browser that started it, so
+// a callback link cannot sign someone else in
+const oauthStateCookie = "oauth_state"
+
+// oauthSettings configures sign-in with external identity providers
+type oauthSettings struct {
+	providers    *oauth.Registry
+	state        *signedurl.Signer
+	stateTTL     time.Duration
+	callbackBase string // public base URL of the API
+	frontendURL  string // where the callback sends the browser; empty responds with JSON
+}
+
+// WithOAuth enables sign-in with the registry's providers. State is signed
+// with signer and valid for stateTTL.
+func (h *AuthHandler) WithOAuth(providers *oauth.Registry, signer *signedurl.Signer, stateTTL time.Duration, callbackBase, frontendURL string) *AuthHandler {
+	h.oauth = &oauthSettings{
+		providers:    providers,
+		state:        signer,
+		stateTTL:     stateTTL,
+		callbackBase: strings.TrimRight(callbackBase, "/"),
+		frontendURL:  frontendURL,
+	}
+	return h
+}
+
+// OAuthProviders lists the providers members can sign in with
+func (h *AuthHandler) OAuthProviders(w http.ResponseWriter, r *http.Request) {
+	providers := []string{}
+	if h.oauth != nil {
+		providers = h.oauth.providers.Names()
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"providers": providers}, "Sign-in providers retrieved successfully")
+}
+
+// OAuthStart sends the browser to the provider to sign in
+func (h *AuthHandler) OAuthStart(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+
+	authURL, ok := h.startOAuth(w, r, provider, uuid.Nil)
+	if !ok {
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OAuthLink starts linking a provider to the signed-in member's account. The
+// request carries the access token, so rather than redirecting it answers with
+// the provider URL to send the browser to; the callback links the identity
+// signed in with there. Signing in first is what proves the account is the
+// member's: an email address matching the provider's is not enough.
+func (h *AuthHandler) OAuthLink(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	authURL, ok := h.startOAuth(w, r, provider, userID)
+	if !ok {
+		return
+	}
+	h.writeSuccessResponse(w, map[string]string{"url": authURL}, "Continue at the provider to link it")
+}
+
+// startOAuth binds a new sign-in to the browser with the state cookie and
+// returns the provider URL it continues at. linkTo, unless nil, is the member
+// the identity is linked to on the way back.
+func (h *AuthHandler) startOAuth(w http.ResponseWriter, r *http.Request, provider *oauth.Provider, linkTo uuid.UUID) (string, bool) {
+	nonce, err := randomNonce()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error generating OAuth state", "error", err)
+		h.writeError(w, apierror.Internal("Failed to start sign-in"))
+		return "", false
+	}
+
+	subject := provider.Name + ":" + nonce
+	if linkTo != uuid.Nil {
+		subject += ":" + linkTo.String()
+	}
+	expiresAt := h.now().Add(h.oauth.stateTTL)
+	state := h.oauth.state.Sign(subject, expiresAt)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    nonce,
+		Path:     "/api/auth/oauth",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode, // sent on the provider's top-level redirect back
+	})
+
+	return provider.AuthCodeURL(state, h.oauthCallbackURL(provider)), true
+}
+
+// OAuthCallback completes sign-in with the code the provider sent back. The
+// identity signs in the account it is linked to. It is linked only when a
+// signed-in member started the sign-in with OAuthLink, never by matching email
+// addresses. Providers cannot create accounts: the age and terms checks of
+// registration need the member.
+func (h *AuthHandler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProvider(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		h.oauthFailure(w, r, apierror.New(apierror.CodeOAuthDenied, "Sign-in was cancelled at the provider", nil))
+		return
+	}
+
+	// The state must be ours, unexpired, for this provider and from this browser
+	cookie, err := r.Cookie(oauthStateCookie)
+	subject, _, verr := h.oauth.state.Verify(query.Get("state"), h.now())
+	var linkTo uuid.UUID
+	if err == nil && verr == nil {
+		linkTo, ok = parseOAuthState(subject, provider.Name, cookie.Value)
+	}
+	if err != nil || verr != nil || !ok {
+		h.oauthFailure(w, r, apierror.New(apierror.CodeInvalidOAuthState, "Sign-in expired or was started in another browser; please try again", nil))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: "/api/auth/oauth", MaxAge: -1, HttpOnly: true})
+
+	identity, err := h.oauth.providers.Exchange(r.Context(), provider, query.Get("code"), h.oauthCallbackURL(provider))
+	if err != nil {
+		if errors.Is(err, oauth.ErrNoEmail) {
+			h.oauthFailure(w, r, apierror.New(apierror.CodeOAuthNoEmail, "The provider did not share an email address", nil))
+			return
+		}
+		logging.FromContext(r.Context()).Warn("error exchanging OAuth code", "provider", provider.Name, "error", err)
+		h.oauthFailure(w, r, apierror.New(apierror.CodeOAuthProviderError, "Sign-in with the provider failed", nil))
+		return
+	}
+
+	user, err := h.userForIdentity(r.Context(), identity, linkTo)
+	if err != nil {
+		switch err {
+		case errLinkRequired:
+			h.oauthFailure(w, r, apierror.New(apierror.CodeOAuthLinkRequired, "An account uses this email address; sign in with your password, then link the provider from your account", nil))
+		case errNoAccount:
+			h.oauthFailure(w, r, apierror.New(apierror.CodeRegistrationRequired, "No account uses this email address; register first, then sign in with the provider", nil))
+		case errIdentityTaken:
+			h.oauthFailure(w, r, apierror.New(apierror.CodeOAuthIdentityTaken, "This provider account is already linked to another member", nil))
+		default:
+			logging.FromContext(r.Context()).Error("error linking OAuth identity", "error", err)
+			h.oauthFailure(w, r, apierror.Internal("Internal server error"))
+		}
+		return
+	}
+
+	if !user.IsActive {
+		h.oauthFailure(w, r, apierror.Unauthorized("Account is deactivated"))
+		return
+	}
+
+	tokens, err := h.auth.GenerateTokens(user)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error generating tokens", "error", err)
+		h.oauthFailure(w, r, apierror.Internal("Failed to generate tokens"))
+		return
+	}
+
+	if err := h.storeRefreshToken(r.Context(), h.db, user.ID, uuid.New(), tokens); err != nil {
+		logging.FromContext(r.Context()).Error("error storing refresh token", "error", err)
+		h.oauthFailure(w, r, apierror.Internal("Failed to store refresh token"))
+		return
+	}
+
+	if err := h.updateLastLogin(r.Context(), user.ID); err != nil {
+		logging.FromContext(r.Context()).Error("error updating last login", "error", err)
+	}
+
+	response := &models.FrontendLoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         user.PublicUser(),
+		ExpiresAt:    timeutil.FormatTimestamp(h.now().Add(30 * time.Minute)),
+	}
+
+	if h.oauth.frontendURL == "" {
+		h.writeSuccessResponse(w, response, "Login successful")
+		return
+	}
+
+	// Fragments are not sent to servers, so the tokens stay out of access logs
+	fragment := url.Values{
+		"token":        {response.Token},
+		"refreshToken": {response.RefreshToken},
+		"expiresAt":    {response.ExpiresAt},
+	}
+	http.Redirect(w, r, h.oauth.frontendURL+"#"+fragment.Encode(), http.StatusFound)
+}
+
+var (
+	errLinkRequired  = errors.New("OAuth identity must be linked by a signed-in member")
+	errNoAccount     = errors.New("no account with the OAuth email")
+	errIdentityTaken = errors.New("OAuth identity is linked to another account")
+)
+
+// parseOAuthState checks that the signed state subject is for provider and
+// the browser holding nonce, and returns the member it links to, if any
+func parseOAuthState(subject, provider, nonce string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(subject, provider+":"+nonce)
+	if !ok || nonce == "" {
+		return uuid.Nil, false
+	}
+	if rest == "" {
+		return uuid.Nil, true
+	}
+	linkTo, err := uuid.Parse(strings.TrimPrefix(rest, ":"))
+	if err != nil || !strings.HasPrefix(rest, ":") {
+		return uuid.Nil, false
+	}
+	return linkTo, true
+}
+
+// userForIdentity returns the account linked to the identity. An identity not
+// linked yet is linked to linkTo, the signed-in member who asked for it; with
+// no such member it signs nobody in. Local accounts do not verify their email
+// addresses, so one matching the identity's proves nothing about who holds it.
+func (h *AuthHandler) userForIdentity(ctx context.Context, identity oauth.Identity, linkTo uuid.UUID) (*models.User, error) {
+	var userID uuid.UUID
+	err := h.db.QueryRowContext(ctx, `
+		UPDATE user_identities SET last_used_at = NOW()
+		WHERE provider = $1 AND subject = $2
+		RETURNING user_id`,
+		identity.Provider, identity.Subject,
+	).Scan(&userID)
+	if err == nil {
+		if linkTo != uuid.Nil && linkTo != userID {
+			return nil, errIdentityTaken
+		}
+		return h.users.GetByID(ctx, userID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if linkTo == uuid.Nil {
+		if _, err := h.users.GetByEmail(ctx, identity.Email); err != nil {
+			if err == store.ErrNotFound {
+				return nil, errNoAccount
+			}
+			return nil, err
+		}
+		return nil, errLinkRequired
+	}
+
+	user, err := h.users.GetByID(ctx, linkTo)
+	if err != nil {
+		return nil, err
+	}
+	result, err := h.db.ExecContext(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO NOTHING`,
+		user.ID, identity.Provider, identity.Subject, identity.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Linked to someone else since it was looked up
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, errIdentityTaken
+	}
+	logging.FromContext(ctx).Info("linked OAuth identity", "user_id", user.ID.String(), "provider", identity.Provider)
+
+	return user, nil
+}
+
+// oauthProvider returns the provider named in the path, or writes a 404
+func (h *AuthHandler) oauthProvider(w http.ResponseWriter, r *http.Request) (*oauth.Provider, bool) {
+	if h.oauth == nil {
+		h.writeError(w, apierror.NotFound("Sign-in provider not found"))
+		return nil, false
+	}
+
+	provider, err := h.oauth.providers.Provider(chi.URLParam(r, "provider"))
+	if err != nil {
+		h.writeError(w, apierror.NotFound("Sign-in provider not found"))
+		return nil, false
+	}
+	return provider, true
+}
+
+func (h *AuthHandler) oauthCallbackURL(provider *oauth.Provider) string {
+	return h.oauth.callbackBase + "/api/auth/oauth/" + provider.Name + "/callback"
+}
+
+// oauthFailure reports a failed callback to the frontend when one is
+// configured, since the browser arrived from the provider rather than the app
+func (h *AuthHandler) oauthFailure(w http.ResponseWriter, r *http.Request, err *apierror.Error) {
+	if h.oauth.frontendURL == "" {
+		h.writeError(w, err)
+		return
+	}
+
+	fragment := url.Values{"error": {string(err.Code)}, "message": {err.Message}}
+	http.Redirect(w, r, h.oauth.frontendURL+"#"+fragment.Encode(), http.StatusFound)
+}
+
+func randomNonce() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/oauth"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func setupOAuthTest(t *testing.T, frontendURL string) (*AuthHandler, http.Handler) {
+	handler, _ := setupAuthTest(t)
+	handler.WithOAuth(
+		oauth.NewRegistry(oauth.NewGitHub("client", "secret")),
+		signedurl.NewSigner("test-secret", "oauth-state"),
+		10*time.Minute, "https://api.example/", frontendURL,
+	)
+	handler.clock = clock.NewFake(time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Get("/api/auth/oauth/providers", handler.OAuthProviders)
+	router.Get("/api/auth/oauth/{provider}/start", handler.OAuthStart)
+	router.Get("/api/auth/oauth/{provider}/callback", handler.OAuthCallback)
+	router.Post("/api/auth/oauth/{provider}/link", handler.OAuthLink)
+	return handler, router
+}
+
+func TestOAuthStart(t *testing.T) {
+	_, router := setupOAuthTest(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oauth/github/start", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, w.Code)
+	}
+	location, _ := url.Parse(w.Header().Get("Location"))
+	if location.Host != "github.com" || location.Query().Get("redirect_uri") != "https://api.example/api/auth/oauth/github/callback" {
+		t.Errorf("Unexpected redirect: %s", location)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oauthStateCookie || !cookies[0].HttpOnly || cookies[0].Value == "" {
+		t.Fatalf("Expected an HttpOnly state cookie, got %+v", cookies)
+	}
+	if state := location.Query().Get("state"); state == "" || strings.Contains(state, cookies[0].Value) {
+		t.Errorf("Expected an opaque signed state, got %q", state)
+	}
+}
+
+func TestOAuthUnknownProvider(t *testing.T) {
+	_, router := setupOAuthTest(t, "")
+
+	for _, path := range []string{"/api/auth/oauth/google/start", "/api/auth/oauth/myspace/callback"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestOAuthCallbackRejectsState(t *testing.T) {
+	handler, router := setupOAuthTest(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oauth/github/start", nil))
+	location, _ := url.Parse(w.Header().Get("Location"))
+	state := location.Query().Get("state")
+	cookie := w.Result().Cookies()[0]
+
+	tests := []struct {
+		name   string
+		state  string
+		cookie string
+		after  time.Duration
+	}{
+		{"no cookie", state, "", 0},
+		{"another browser", state, "someone-else", 0},
+		{"forged state", "github:" + cookie.Value, cookie.Value, 0},
+		{"expired", state, cookie.Value, 11 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		handler.clock = clock.NewFake(time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC).Add(tt.after))
+
+		req := httptest.NewRequest("GET", "/api/auth/oauth/github/callback?code=abc&state="+url.QueryEscape(tt.state), nil)
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "INVALID_OAUTH_STATE") {
+			t.Errorf("%s: expected INVALID_OAUTH_STATE, got %d %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestOAuthCallbackRedirectsFailuresToFrontend(t *testing.T) {
+	_, router := setupOAuthTest(t, "https://app.example/oauth")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oauth/github/callback?error=access_denied", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusFound, w.Code)
+	}
+	location, _ := url.Parse(w.Header().Get("Location"))
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if location.Host != "app.example" || fragment.Get("error") != "OAUTH_DENIED" {
+		t.Errorf("Unexpected redirect: %s", w.Header().Get("Location"))
+	}
+}
+
+func TestOAuthProviders(t *testing.T) {
+	_, router := setupOAuthTest(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oauth/providers", nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"providers":["github"]`) {
+		t.Errorf("Expected github as the only provider, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOAuthLink(t *testing.T) {
+	handler, router := setupOAuthTest(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/auth/oauth/github/link", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected linking without signing in to be refused, got %d", w.Code)
+	}
+
+	userID := uuid.New()
+	req := httptest.NewRequest("POST", "/api/auth/oauth/github/link", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: userID}))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	location, _ := url.Parse(response.Data.URL)
+	cookies := w.Result().Cookies()
+	if location.Host != "github.com" || len(cookies) != 1 || cookies[0].Name != oauthStateCookie {
+		t.Fatalf("Expected the provider URL and a state cookie, got %q and %+v", response.Data.URL, cookies)
+	}
+
+	// The state names the member, so the callback links to nobody else
+	subject, _, err := handler.oauth.state.Verify(location.Query().Get("state"), handler.now())
+	if err != nil {
+		t.Fatalf("Expected a valid state: %v", err)
+	}
+	if linkTo, ok := parseOAuthState(subject, "github", cookies[0].Value); !ok || linkTo != userID {
+		t.Errorf("Expected the state to link to %s, got %s", userID, linkTo)
+	}
+}
+
+func TestParseOAuthState(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		subject string
+		nonce   string
+		linkTo  uuid.UUID
+		ok      bool
+	}{
+		{"sign-in", "github:abc", "abc", uuid.Nil, true},
+		{"link", "github:abc:" + userID.String(), "abc", userID, true},
+		{"another provider", "google:abc", "abc", uuid.Nil, false},
+		{"another browser", "github:abc", "abd", uuid.Nil, false},
+		{"longer nonce", "github:abcd", "abc", uuid.Nil, false},
+		{"no nonce", "github:", "", uuid.Nil, false},
+		{"bad member", "github:abc:someone", "abc", uuid.Nil, false},
+	}
+
+	for _, tt := range tests {
+		linkTo, ok := parseOAuthState(tt.subject, "github", tt.nonce)
+		if ok != tt.ok || linkTo != tt.linkTo {
+			t.Errorf("%s: expected %s, %v, got %s, %v", tt.name, tt.linkTo, tt.ok, linkTo, ok)
+		}
+	}
+}
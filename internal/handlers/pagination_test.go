@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/pagination"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestEventCursor(t *testing.T) {
+	eventID := uuid.New()
+
+	// Dates and times scanned as timestamps are normalized to what Postgres casts back
+	for _, event := range []models.Event{
+		{ID: eventID, Date: "2024-05-01", Time: "19:30:00"},
+		{ID: eventID, Date: "2024-05-01T00:00:00Z", Time: "0000-01-01T19:30:00Z"},
+	} {
+		date, clock, id, err := parseEventCursor(eventCursor(event))
+		if err != nil || date != "2024-05-01" || clock != "19:30:00" || id != eventID {
+			t.Errorf("%s %s: got %s %s %s, %v", event.Date, event.Time, date, clock, id, err)
+		}
+	}
+
+	for _, bad := range []string{"garbage", pagination.EncodeCursor("2024-05-01", "19:30:00"), pagination.EncodeCursor("May 1", "19:30:00", eventID.String())} {
+		if _, _, _, err := parseEventCursor(bad); err != pagination.ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestMemberCursor(t *testing.T) {
+	joined := time.Date(2024, time.March, 3, 10, 15, 0, 123456000, time.UTC)
+	memberID := uuid.New()
+
+	gotJoined, gotID, err := parseMemberCursor(pagination.EncodeCursor(joined.Format(time.RFC3339Nano), memberID.String()))
+	if err != nil || !gotJoined.Equal(joined) || gotID != memberID {
+		t.Errorf("Expected %s %s back, got %s %s, %v", joined, memberID, gotJoined, gotID, err)
+	}
+
+	if _, _, err := parseMemberCursor(pagination.EncodeCursor("yesterday", memberID.String())); err != pagination.ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor for a bad date, got %v", err)
+	}
+}
+
+func TestGetEventsRejectsInvalidCursor(t *testing.T) {
+	handler := NewEventHandler(database.NewMock())
+
+	req := httptest.NewRequest("GET", "/club/"+uuid.New().String()+"/events?cursor=garbage", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("clubId", uuid.New().String())
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(auth.NewContext(ctx, auth.Principal{UserID: uuid.New()}))
+
+	w := httptest.NewRecorder()
+	handler.GetEvents(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
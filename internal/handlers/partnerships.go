@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/partnerships"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// partnershipStore is the part of partnerships.Store the partnership handler uses
+type partnershipStore interface {
+	Propose(ctx context.Context, p partnerships.Partnership) (partnerships.Partnership, error)
+	List(ctx context.Context, clubID uuid.UUID, status string) ([]partnerships.Partnership, error)
+	Get(ctx context.Context, clubID, id uuid.UUID) (partnerships.Partnership, error)
+	Change(ctx context.Context, clubID, id, userID uuid.UUID, action string) (partnerships.Partnership, string, error)
+	SetBook(ctx context.Context, clubID, id uuid.UUID, book string, setCurrentBook bool) (partnerships.Partnership, string, error)
+	ShareEvent(ctx context.Context, clubID, id, eventID, userID uuid.UUID) error
+	UnshareEvent(ctx context.Context, clubID, id, eventID uuid.UUID) error
+	Events(ctx context.Context, clubID, id uuid.UUID) ([]partnerships.SharedEvent, error)
+	Messages(ctx context.Context, clubID, id uuid.UUID, before time.Time, limit int) ([]partnerships.Message, error)
+	PostMessage(ctx context.Context, clubID, id, userID uuid.UUID, body string) (partnerships.Message, error)
+	DeleteMessage(ctx context.Context, clubID, id, messageID, userID uuid.UUID, moderate bool) error
+}
+
+// clubOwnerNotifier is the part of notify.Notifier that notifies a club's owner
+type clubOwnerNotifier interface {
+	NotifyClubOwner(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error)
+}
+
+// partnershipVerbs describes each status a club moves a partnership to, for
+// the other club's notification
+var partnershipVerbs = map[string]string{
+	partnerships.StatusActive:    "accepted",
+	partnerships.StatusDeclined:  "declined",
+	partnerships.StatusCancelled: "withdrew",
+	partnerships.StatusEnded:     "ended",
+}
+
+// PartnershipHandler lets two clubs read a book together: moderators propose,
+// answer and end partnerships and share events with them, and members of both
+// clubs see the shared events and discuss the book in one thread. Routes are
+// under the caller's club, which must be one side of the partnership.
+type PartnershipHandler struct {
+	clocked
+
+	partnerships partnershipStore
+	notifier     clubOwnerNotifier
+}
+
+func NewPartnershipHandler(store partnershipStore) *PartnershipHandler {
+	return &PartnershipHandler{partnerships: store}
+}
+
+// WithNotifier tells the other club's owner when a partnership is proposed,
+// answered or ended
+func (h *PartnershipHandler) WithNotifier(notifier clubOwnerNotifier) *PartnershipHandler {
+	h.notifier = notifier
+	return h
+}
+
+// GetPartnerships lists the club's partnerships, proposed by it or to it
+func (h *PartnershipHandler) GetPartnerships(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", partnerships.StatusPending, partnerships.StatusActive, partnerships.StatusDeclined, partnerships.StatusCancelled, partnerships.StatusEnded:
+	default:
+		h.writeError(w, apierror.Validation("Invalid status. Use pending, active, declined, cancelled or ended", models.InvalidField("status", "oneof", "must be one of: pending, active, declined, cancelled, ended")))
+		return
+	}
+
+	list, err := h.partnerships.List(r.Context(), clubID, status)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to get partnerships")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"partnerships": list}, "Partnerships retrieved successfully")
+}
+
+// ProposePartnership proposes a joint read to another club (moderators)
+func (h *PartnershipHandler) ProposePartnership(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.PartnershipRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.PartnerClubID == clubID {
+		h.writeError(w, apierror.Validation("partnerClubId must be another club", models.InvalidField("partnerClubId", "ne", "must be another club")))
+		return
+	}
+
+	proposed, err := h.partnerships.Propose(r.Context(), partnerships.Partnership{
+		ClubID:        clubID,
+		PartnerClubID: req.PartnerClubID,
+		Book:          req.Book,
+		Message:       req.Message,
+		ProposedBy:    &userID,
+	})
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to propose partnership")
+		return
+	}
+	audit.Describe(r.Context(), "club_partnership", proposed.ID.String(), audit.Diff(nil, req))
+
+	body := proposed.ClubName + " would like to read " + proposed.Book + " together with your club."
+	if proposed.Message != nil {
+		body += " " + *proposed.Message
+	}
+	h.notify(r.Context(), proposed.PartnerClubID, models.Notification{
+		Type:   notify.TypeClubPartnership,
+		Title:  "Joint read proposed by " + proposed.ClubName,
+		Body:   body,
+		ClubID: &proposed.PartnerClubID,
+	})
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"partnership": proposed}, "Partnership proposed successfully")
+}
+
+// GetPartnership returns one of the club's partnerships
+func (h *PartnershipHandler) GetPartnership(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := h.partnerships.Get(r.Context(), clubID, partnershipID)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to get partnership")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"partnership": p}, "Partnership retrieved successfully")
+}
+
+// UpdatePartnershipBook changes the book the clubs read together (moderators
+// of either club)
+func (h *PartnershipHandler) UpdatePartnershipBook(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PartnershipBookRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	p, previous, err := h.partnerships.SetBook(r.Context(), clubID, partnershipID, req.Book, req.SetCurrentBook)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to update partnership")
+		return
+	}
+	audit.Describe(r.Context(), "club_partnership", partnershipID.String(), audit.Changes{"book": {From: previous, To: p.Book}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"partnership": p}, "Partnership updated successfully")
+}
+
+// AcceptPartnership starts a partnership proposed to the club (moderators)
+func (h *PartnershipHandler) AcceptPartnership(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, partnerships.ActionAccept)
+}
+
+// DeclinePartnership turns down a partnership proposed to the club (moderators)
+func (h *PartnershipHandler) DeclinePartnership(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, partnerships.ActionDecline)
+}
+
+// EndPartnership ends an active partnership, or withdraws the club's own
+// proposal (moderators)
+func (h *PartnershipHandler) EndPartnership(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, partnerships.ActionEnd)
+}
+
+func (h *PartnershipHandler) change(w http.ResponseWriter, r *http.Request, action string) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	p, previous, err := h.partnerships.Change(r.Context(), clubID, partnershipID, userID, action)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to "+action+" partnership")
+		return
+	}
+	audit.Describe(r.Context(), "club_partnership", partnershipID.String(), audit.Changes{"status": {From: previous, To: p.Status}})
+
+	actor, other := p.ClubName, p.Other(clubID)
+	if clubID == p.PartnerClubID {
+		actor = p.PartnerClubName
+	}
+	h.notify(r.Context(), other, models.Notification{
+		Type:   notify.TypeClubPartnership,
+		Title:  actor + " " + partnershipVerbs[p.Status] + " the joint read",
+		Body:   actor + " " + partnershipVerbs[p.Status] + " the joint read of " + p.Book + ".",
+		ClubID: &other,
+	})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"partnership": p}, "Partnership "+p.Status)
+}
+
+// GetPartnershipEvents lists the events both clubs shared with a partnership
+func (h *PartnershipHandler) GetPartnershipEvents(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.partnerships.Events(r.Context(), clubID, partnershipID)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to get shared events")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"events": events}, "Shared events retrieved successfully")
+}
+
+// SharePartnershipEvent shares one of the club's events with the partner
+// club's members (moderators)
+func (h *PartnershipHandler) SharePartnershipEvent(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.ShareEventRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if err := h.partnerships.ShareEvent(r.Context(), clubID, partnershipID, req.EventID, userID); err != nil {
+		h.writeStoreError(w, r, err, "Failed to share event")
+		return
+	}
+	audit.Describe(r.Context(), "club_partnership", partnershipID.String(), audit.Changes{"sharedEvent": {To: req.EventID}})
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Event shared successfully"}, "Event shared successfully")
+}
+
+// UnsharePartnershipEvent stops sharing one of the club's events (moderators)
+func (h *PartnershipHandler) UnsharePartnershipEvent(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	if err := h.partnerships.UnshareEvent(r.Context(), clubID, partnershipID, eventID); err != nil {
+		h.writeStoreError(w, r, err, "Failed to unshare event")
+		return
+	}
+	audit.Describe(r.Context(), "club_partnership", partnershipID.String(), audit.Changes{"sharedEvent": {From: eventID}})
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Event unshared successfully"}, "Event unshared successfully")
+}
+
+// GetPartnershipMessages returns the latest messages of a partnership's
+// thread, oldest first; ?before= pages back through older ones
+func (h *PartnershipHandler) GetPartnershipMessages(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	var before time.Time
+	if value := r.URL.Query().Get("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			h.writeError(w, apierror.Validation("before must be an ISO 8601 time", models.InvalidField("before", "datetime", "must be an ISO 8601 time")))
+			return
+		}
+		before = parsed
+	}
+
+	messages, err := h.partnerships.Messages(r.Context(), clubID, partnershipID, before, limit)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to get messages")
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"messages": messages}, "Messages retrieved successfully")
+}
+
+// PostPartnershipMessage adds to an active partnership's thread
+func (h *PartnershipHandler) PostPartnershipMessage(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.PartnershipMessageRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	message, err := h.partnerships.PostMessage(r.Context(), clubID, partnershipID, userID, req.Body)
+	if err != nil {
+		h.writeStoreError(w, r, err, "Failed to post message")
+		return
+	}
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"message": message}, "Message posted successfully")
+}
+
+// DeletePartnershipMessage removes the caller's own message; moderators of
+// either club can remove any message
+func (h *PartnershipHandler) DeletePartnershipMessage(w http.ResponseWriter, r *http.Request) {
+	clubID, partnershipID, ok := h.clubPartnership(w, r)
+	if !ok {
+		return
+	}
+
+	messageID, err := uuid.Parse(chi.URLParam(r, "messageId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid message ID", models.InvalidID("messageId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	moderate := authz.Can(r.Context(), authz.ManageEvents)
+	if err := h.partnerships.DeleteMessage(r.Context(), clubID, partnershipID, messageID, userID, moderate); err != nil {
+		h.writeStoreError(w, r, err, "Failed to delete message")
+		return
+	}
+	if moderate {
+		audit.Describe(r.Context(), "partnership_message", messageID.String(), nil)
+	}
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Message deleted successfully"}, "Message deleted successfully")
+}
+
+// notify tells a club's owner about a partnership; failures are logged, the
+// change stands
+func (h *PartnershipHandler) notify(ctx context.Context, clubID uuid.UUID, notification models.Notification) {
+	if h.notifier == nil {
+		return
+	}
+	if _, err := h.notifier.NotifyClubOwner(ctx, clubID, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying partnership", "error", err)
+	}
+}
+
+// writeStoreError answers with the response for an error from the store
+func (h *PartnershipHandler) writeStoreError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, partnerships.ErrNotFound):
+		h.writeError(w, apierror.NotFound("Partnership not found"))
+	case errors.Is(err, partnerships.ErrClubNotFound):
+		h.writeError(w, apierror.NotFound("Partner club not found"))
+	case errors.Is(err, partnerships.ErrEventNotFound):
+		h.writeError(w, apierror.NotFound("Event not found in this club"))
+	case errors.Is(err, partnerships.ErrMessageNotFound):
+		h.writeError(w, apierror.NotFound("Message not found"))
+	case errors.Is(err, partnerships.ErrOpen):
+		h.writeError(w, apierror.New(apierror.CodePartnershipOpen, "The clubs already have a pending or active partnership", nil))
+	case errors.Is(err, partnerships.ErrTransition):
+		h.writeError(w, apierror.New(apierror.CodeInvalidStatus, "The partnership cannot be changed this way in its current status", nil))
+	case errors.Is(err, partnerships.ErrNotActive):
+		h.writeError(w, apierror.New(apierror.CodePartnershipNotActive, "The partnership is not active", nil))
+	default:
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error in partnership", "error", err)
+		h.writeError(w, apierror.Internal(message))
+	}
+}
+
+func (h *PartnershipHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *PartnershipHandler) clubPartnership(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	partnershipID, err := uuid.Parse(chi.URLParam(r, "partnershipId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid partnership ID", models.InvalidID("partnershipId")))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return clubID, partnershipID, true
+}
+
+func (h *PartnershipHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *PartnershipHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PartnershipHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
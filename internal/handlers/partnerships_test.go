@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/authz"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/partnerships"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakePartnerships struct {
+	byID      map[uuid.UUID]partnerships.Partnership
+	proposed  []partnerships.Partnership
+	moderated []bool
+}
+
+func (f *fakePartnerships) Propose(ctx context.Context, p partnerships.Partnership) (partnerships.Partnership, error) {
+	p.ID, p.Status, p.ClubName = uuid.New(), partnerships.StatusPending, "Proposers"
+	f.proposed = append(f.proposed, p)
+	return p, nil
+}
+
+func (f *fakePartnerships) List(ctx context.Context, clubID uuid.UUID, status string) ([]partnerships.Partnership, error) {
+	return []partnerships.Partnership{}, nil
+}
+
+func (f *fakePartnerships) Get(ctx context.Context, clubID, id uuid.UUID) (partnerships.Partnership, error) {
+	if p, ok := f.byID[id]; ok && (p.ClubID == clubID || p.PartnerClubID == clubID) {
+		return p, nil
+	}
+	return partnerships.Partnership{}, partnerships.ErrNotFound
+}
+
+func (f *fakePartnerships) Change(ctx context.Context, clubID, id, userID uuid.UUID, action string) (partnerships.Partnership, string, error) {
+	p, err := f.Get(ctx, clubID, id)
+	if err != nil {
+		return partnerships.Partnership{}, "", err
+	}
+	status, err := partnerships.Next(p, clubID, action)
+	if err != nil {
+		return partnerships.Partnership{}, "", err
+	}
+	previous := p.Status
+	p.Status = status
+	f.byID[id] = p
+	return p, previous, nil
+}
+
+func (f *fakePartnerships) SetBook(ctx context.Context, clubID, id uuid.UUID, book string, setCurrentBook bool) (partnerships.Partnership, string, error) {
+	return partnerships.Partnership{}, "", nil
+}
+
+func (f *fakePartnerships) ShareEvent(ctx context.Context, clubID, id, eventID, userID uuid.UUID) error {
+	return nil
+}
+
+func (f *fakePartnerships) UnshareEvent(ctx context.Context, clubID, id, eventID uuid.UUID) error {
+	return nil
+}
+
+func (f *fakePartnerships) Events(ctx context.Context, clubID, id uuid.UUID) ([]partnerships.SharedEvent, error) {
+	return []partnerships.SharedEvent{}, nil
+}
+
+func (f *fakePartnerships) Messages(ctx context.Context, clubID, id uuid.UUID, before time.Time, limit int) ([]partnerships.Message, error) {
+	return []partnerships.Message{}, nil
+}
+
+func (f *fakePartnerships) PostMessage(ctx context.Context, clubID, id, userID uuid.UUID, body string) (partnerships.Message, error) {
+	return partnerships.Message{ID: uuid.New(), ClubID: clubID, UserID: &userID, Body: body}, nil
+}
+
+func (f *fakePartnerships) DeleteMessage(ctx context.Context, clubID, id, messageID, userID uuid.UUID, moderate bool) error {
+	f.moderated = append(f.moderated, moderate)
+	return nil
+}
+
+type ownerNotification struct {
+	clubID       uuid.UUID
+	notification models.Notification
+}
+
+type recordingOwnerNotifier struct {
+	notified []ownerNotification
+}
+
+func (n *recordingOwnerNotifier) NotifyClubOwner(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error) {
+	n.notified = append(n.notified, ownerNotification{clubID, notification})
+	return 1, nil
+}
+
+func setupPartnershipTest() (*fakePartnerships, *recordingOwnerNotifier, chi.Router) {
+	store := &fakePartnerships{byID: map[uuid.UUID]partnerships.Partnership{}}
+	notifier := &recordingOwnerNotifier{}
+	handler := NewPartnershipHandler(store).WithNotifier(notifier)
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/partnerships", handler.ProposePartnership)
+	router.Post("/club/{clubId}/partnerships/{partnershipId}/accept", handler.AcceptPartnership)
+	router.Post("/club/{clubId}/partnerships/{partnershipId}/end", handler.EndPartnership)
+	router.Get("/club/{clubId}/partnerships/{partnershipId}/messages", handler.GetPartnershipMessages)
+	router.Delete("/club/{clubId}/partnerships/{partnershipId}/messages/{messageId}", handler.DeletePartnershipMessage)
+	return store, notifier, router
+}
+
+func TestProposePartnership(t *testing.T) {
+	store, notifier, router := setupPartnershipTest()
+	clubID, partnerID := uuid.New(), uuid.New()
+	path := "/club/" + clubID.String() + "/partnerships"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path, `{"partnerClubId":"`+clubID.String()+`","book":"Middlemarch"}`, uuid.New(), authz.RoleModerator))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a partnership with the club itself to be rejected, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path, `{"partnerClubId":"`+partnerID.String()+`","book":"Middlemarch"}`, uuid.New(), authz.RoleModerator))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d %s", w.Code, w.Body.String())
+	}
+	if len(store.proposed) != 1 || store.proposed[0].ClubID != clubID || store.proposed[0].PartnerClubID != partnerID {
+		t.Errorf("Expected a proposal from %s to %s, got %+v", clubID, partnerID, store.proposed)
+	}
+	if len(notifier.notified) != 1 || notifier.notified[0].clubID != partnerID {
+		t.Errorf("Expected the partner club's owner to be notified, got %+v", notifier.notified)
+	}
+}
+
+func TestPartnershipLifecycle(t *testing.T) {
+	store, notifier, router := setupPartnershipTest()
+	proposer, partner := uuid.New(), uuid.New()
+	id := uuid.New()
+	store.byID[id] = partnerships.Partnership{
+		ID: id, ClubID: proposer, ClubName: "Proposers", PartnerClubID: partner, PartnerClubName: "Partners",
+		Book: "Middlemarch", Status: partnerships.StatusPending,
+	}
+	path := func(club uuid.UUID, action string) string {
+		return "/club/" + club.String() + "/partnerships/" + id.String() + "/" + action
+	}
+
+	// Only the partner club answers a proposal
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path(proposer, "accept"), "", uuid.New(), authz.RoleModerator))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected the proposer accepting to be a conflict, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path(partner, "accept"), "", uuid.New(), authz.RoleModerator))
+	if w.Code != http.StatusOK || store.byID[id].Status != partnerships.StatusActive {
+		t.Fatalf("Expected the partnership to be active, got %d %s", w.Code, w.Body.String())
+	}
+	if n := notifier.notified; len(n) != 1 || n[0].clubID != proposer || n[0].notification.Title != "Partners accepted the joint read" {
+		t.Errorf("Expected the proposer's owner to be told of the acceptance, got %+v", n)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path(proposer, "end"), "", uuid.New(), authz.RoleOwner))
+	if w.Code != http.StatusOK || store.byID[id].Status != partnerships.StatusEnded {
+		t.Fatalf("Expected the partnership to be ended, got %d %s", w.Code, w.Body.String())
+	}
+	if n := notifier.notified; len(n) != 2 || n[1].clubID != partner {
+		t.Errorf("Expected the partner's owner to be told of the end, got %+v", n)
+	}
+
+	// Clubs outside the partnership do not see it
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodPost, path(uuid.New(), "end"), "", uuid.New(), authz.RoleModerator))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another club, got %d", w.Code)
+	}
+}
+
+func TestDeletePartnershipMessageModeration(t *testing.T) {
+	store, _, router := setupPartnershipTest()
+	path := "/club/" + uuid.New().String() + "/partnerships/" + uuid.New().String() + "/messages/" + uuid.New().String()
+
+	for _, role := range []string{"member", authz.RoleModerator} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, correctionRequest(http.MethodDelete, path, "", uuid.New(), role))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+	}
+	if len(store.moderated) != 2 || store.moderated[0] || !store.moderated[1] {
+		t.Errorf("Expected only the moderator to delete any message, got %v", store.moderated)
+	}
+}
+
+func TestGetPartnershipMessagesRejectsInvalidBefore(t *testing.T) {
+	_, _, router := setupPartnershipTest()
+	path := "/club/" + uuid.New().String() + "/partnerships/" + uuid.New().String() + "/messages?before=yesterday"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, correctionRequest(http.MethodGet, path, "", uuid.New(), "member"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
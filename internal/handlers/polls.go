@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/polls"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// PollHandler serves book polls, in which members vote for the club's next read
+type PollHandler struct {
+	clocked
+
+	db       *database.DB
+	notifier *notify.Notifier
+}
+
+func NewPollHandler(db *database.DB) *PollHandler {
+	return &PollHandler{db: db}
+}
+
+// WithNotifier tells members about new polls and their results
+func (h *PollHandler) WithNotifier(notifier *notify.Notifier) *PollHandler {
+	h.notifier = notifier
+	return h
+}
+
+// GetPolls lists the club's polls, newest first
+func (h *PollHandler) GetPolls(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	query := `
+		SELECT p.id, p.club_id, p.title, p.kind, p.closes_at, p.set_current_book, p.created_by,
+		       p.created_at, p.closed_at, p.winner_option_id,
+		       (SELECT COUNT(DISTINCT v.user_id) FROM poll_votes v WHERE v.poll_id = p.id)
+		FROM polls p
+		WHERE p.club_id = $1
+		ORDER BY p.created_at DESC
+		LIMIT 100`
+
+	rows, err := h.db.QueryContext(r.Context(), query, clubID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying polls", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get polls"))
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Poll{}
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error scanning poll", "error", err)
+			continue
+		}
+		list = append(list, *poll)
+	}
+
+	for i := range list {
+		if list[i].Options, err = h.pollOptions(r.Context(), list[i].ID); err != nil {
+			logging.FromContext(r.Context()).Error("error getting poll options", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get polls"))
+			return
+		}
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"polls": list}, "Polls retrieved successfully")
+}
+
+// CreatePoll opens a poll among candidate books until its deadline
+func (h *PollHandler) CreatePoll(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreatePollRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = polls.KindSingle
+	}
+
+	now := h.now()
+	if !req.ClosesAt.After(now) {
+		h.writeError(w, apierror.Validation("The poll must close in the future", models.InvalidField("closesAt", "future", "must be in the future")))
+		return
+	}
+
+	poll := models.Poll{
+		ID:             uuid.New(),
+		ClubID:         clubID,
+		Title:          req.Title,
+		Kind:           req.Kind,
+		ClosesAt:       req.ClosesAt,
+		SetCurrentBook: req.SetCurrentBook,
+		CreatedBy:      &userID,
+		CreatedAt:      now,
+		Options:        make([]models.PollOption, len(req.Options)),
+	}
+
+	tx, err := h.db.BeginTx(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error beginning transaction", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create poll"))
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(r.Context(), `
+		INSERT INTO polls (id, club_id, title, kind, closes_at, set_current_book, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		poll.ID, clubID, poll.Title, poll.Kind, poll.ClosesAt, poll.SetCurrentBook, userID, now)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating poll", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create poll"))
+		return
+	}
+
+	for i, option := range req.Options {
+		poll.Options[i] = models.PollOption{ID: uuid.New(), Title: option.Title, Author: option.Author, Position: i + 1}
+		_, err := tx.ExecContext(r.Context(), `
+			INSERT INTO poll_options (id, poll_id, title, author, position)
+			VALUES ($1, $2, $3, $4, $5)`,
+			poll.Options[i].ID, poll.ID, option.Title, option.Author, i+1)
+		if err != nil {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error creating poll option", "error", err)
+			h.writeError(w, apierror.Internal("Failed to create poll"))
+			return
+		}
+	}
+
+	if h.notifier != nil {
+		notification := models.Notification{
+			Type:  notify.TypePollCreated,
+			Title: "New poll: " + poll.Title,
+			Body:  fmt.Sprintf("Vote for the next book before %s.", timeutil.FormatTimestamp(poll.ClosesAt)),
+		}
+		// Members are notified through the outbox, so only of polls that were created
+		if err := h.notifier.QueueClubMembers(r.Context(), tx, clubID, userID, notification); err != nil {
+			logging.FromContext(r.Context()).Error("error queueing poll notification", "error", err)
+			h.writeError(w, apierror.Internal("Failed to create poll"))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.FromContext(r.Context()).Error("error committing poll", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create poll"))
+		return
+	}
+	audit.Describe(r.Context(), "poll", poll.ID.String(), audit.Diff(nil, req))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"poll": poll}, "Poll created successfully")
+}
+
+// GetPoll returns a poll with the caller's ballot. Counts are only shown once
+// the poll has closed, so early votes do not sway later ones.
+func (h *PollHandler) GetPoll(w http.ResponseWriter, r *http.Request) {
+	poll, ok := h.loadPoll(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	ballot, err := h.ballot(r.Context(), poll.ID, userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting ballot", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get poll"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"poll":     poll,
+		"isOpen":   poll.IsOpen(h.now()),
+		"myBallot": ballot,
+	}
+
+	if poll.ClosedAt != nil {
+		ballots, err := polls.Ballots(r.Context(), h.db, poll.ID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error getting poll results", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get poll"))
+			return
+		}
+		candidates := make([]uuid.UUID, len(poll.Options))
+		for i, option := range poll.Options {
+			candidates[i] = option.ID
+		}
+		response["results"] = polls.Count(poll.Kind, candidates, ballots)
+	}
+
+	h.writeSuccessResponse(w, response, "Poll retrieved successfully")
+}
+
+// Vote casts or replaces the caller's ballot while the poll is open
+func (h *PollHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	poll, ok := h.loadPoll(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.PollVoteRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if !poll.IsOpen(h.now()) {
+		h.writeError(w, apierror.New(apierror.CodePollClosed, "This poll is closed", nil))
+		return
+	}
+
+	if derr := validateBallot(poll, req.OptionIDs); derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error beginning transaction", "error", err)
+		h.writeError(w, apierror.Internal("Failed to vote"))
+		return
+	}
+	defer tx.Rollback()
+
+	// Closing locks the poll, so a vote cannot land after the tally
+	var open bool
+	err = tx.QueryRowContext(r.Context(), `SELECT closed_at IS NULL FROM polls WHERE id = $1 FOR SHARE`, poll.ID).Scan(&open)
+	if err != nil || !open {
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error locking poll", "error", err)
+		}
+		h.writeError(w, apierror.New(apierror.CodePollClosed, "This poll is closed", nil))
+		return
+	}
+
+	if _, err := tx.ExecContext(r.Context(), `DELETE FROM poll_votes WHERE poll_id = $1 AND user_id = $2`, poll.ID, userID); err != nil {
+		logging.FromContext(r.Context()).Error("error clearing ballot", "error", err)
+		h.writeError(w, apierror.Internal("Failed to vote"))
+		return
+	}
+	for i, optionID := range req.OptionIDs {
+		_, err := tx.ExecContext(r.Context(), `
+			INSERT INTO poll_votes (poll_id, user_id, option_id, rank, created_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			poll.ID, userID, optionID, i+1, h.now())
+		if err != nil {
+			if verr := violationError(r.Context(), err); verr != nil {
+				h.writeError(w, verr)
+				return
+			}
+			logging.FromContext(r.Context()).Error("error recording vote", "error", err)
+			h.writeError(w, apierror.Internal("Failed to vote"))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logging.FromContext(r.Context()).Error("error committing vote", "error", err)
+		h.writeError(w, apierror.Internal("Failed to vote"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"myBallot": req.OptionIDs}, "Vote recorded successfully")
+}
+
+// ClosePoll closes a poll before its deadline and announces the result
+func (h *PollHandler) ClosePoll(w http.ResponseWriter, r *http.Request) {
+	poll, ok := h.loadPoll(w, r)
+	if !ok {
+		return
+	}
+
+	closed, err := polls.Close(r.Context(), h.db, poll.ID, h.now())
+	if err != nil {
+		if err == polls.ErrNotOpen {
+			h.writeError(w, apierror.New(apierror.CodePollClosed, "This poll is already closed", nil))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error closing poll", "error", err)
+		h.writeError(w, apierror.Internal("Failed to close poll"))
+		return
+	}
+	audit.Describe(r.Context(), "poll", poll.ID.String(), nil)
+
+	h.NotifyClosed(r.Context(), closed)
+
+	response := map[string]interface{}{
+		"pollId":         closed.PollID,
+		"results":        closed.Tally,
+		"winner":         closed.WinnerTitle,
+		"currentBookSet": closed.SetAsCurrent,
+	}
+
+	h.writeSuccessResponse(w, response, "Poll closed successfully")
+}
+
+// NotifyClosed tells the club's members the result of a poll. It is also
+// called by the closer for polls closed at their deadline.
+func (h *PollHandler) NotifyClosed(ctx context.Context, closed *polls.Closed) {
+	if h.notifier == nil {
+		return
+	}
+
+	notification := models.Notification{
+		Type:  notify.TypePollClosed,
+		Title: "Poll closed",
+		Body:  "Nobody voted, so there is no winner.",
+	}
+	if closed.WinnerTitle != nil {
+		notification.Body = fmt.Sprintf("%s won the vote.", *closed.WinnerTitle)
+		if closed.SetAsCurrent {
+			notification.Body += " It is now the club's current book."
+		}
+	}
+	if _, err := h.notifier.NotifyClubMembers(ctx, closed.ClubID, uuid.Nil, notification); err != nil {
+		logging.FromContext(ctx).Error("error notifying club members of poll result", "error", err)
+	}
+}
+
+// validateBallot checks the ballot only names the poll's options, once each,
+// and names a single one in a single-choice poll
+func validateBallot(poll *models.Poll, optionIDs []uuid.UUID) *apierror.Error {
+	if poll.Kind != polls.KindRanked && len(optionIDs) != 1 {
+		return invalidField("optionIds", "len", "must name exactly one option in a single-choice poll", "Invalid ballot")
+	}
+
+	valid := make(map[uuid.UUID]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !valid[id] {
+			return invalidField("optionIds", "option", "must only name options of this poll", "Invalid ballot")
+		}
+		if seen[id] {
+			return invalidField("optionIds", "unique", "must not name an option twice", "Invalid ballot")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// loadPoll returns the poll in the path with its options, or writes an error
+func (h *PollHandler) loadPoll(w http.ResponseWriter, r *http.Request) (*models.Poll, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return nil, false
+	}
+
+	pollID, err := uuid.Parse(chi.URLParam(r, "pollId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid poll ID", models.InvalidID("pollId")))
+		return nil, false
+	}
+
+	query := `
+		SELECT p.id, p.club_id, p.title, p.kind, p.closes_at, p.set_current_book, p.created_by,
+		       p.created_at, p.closed_at, p.winner_option_id,
+		       (SELECT COUNT(DISTINCT v.user_id) FROM poll_votes v WHERE v.poll_id = p.id)
+		FROM polls p
+		WHERE p.id = $1 AND p.club_id = $2`
+
+	poll, err := scanPoll(h.db.QueryRowContext(r.Context(), query, pollID, clubID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Poll not found"))
+			return nil, false
+		}
+		logging.FromContext(r.Context()).Error("error getting poll", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get poll"))
+		return nil, false
+	}
+
+	if poll.Options, err = h.pollOptions(r.Context(), poll.ID); err != nil {
+		logging.FromContext(r.Context()).Error("error getting poll options", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get poll"))
+		return nil, false
+	}
+	return poll, true
+}
+
+func (h *PollHandler) pollOptions(ctx context.Context, pollID uuid.UUID) ([]models.PollOption, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT id, title, author, position FROM poll_options WHERE poll_id = $1 ORDER BY position`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := []models.PollOption{}
+	for rows.Next() {
+		var option models.PollOption
+		if err := rows.Scan(&option.ID, &option.Title, &option.Author, &option.Position); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+	return options, rows.Err()
+}
+
+// ballot returns the member's choices in order of preference, empty if they have not voted
+func (h *PollHandler) ballot(ctx context.Context, pollID, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT option_id FROM poll_votes WHERE poll_id = $1 AND user_id = $2 ORDER BY rank`, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ballot := []uuid.UUID{}
+	for rows.Next() {
+		var optionID uuid.UUID
+		if err := rows.Scan(&optionID); err != nil {
+			return nil, err
+		}
+		ballot = append(ballot, optionID)
+	}
+	return ballot, rows.Err()
+}
+
+func scanPoll(row rowScanner) (*models.Poll, error) {
+	var poll models.Poll
+	err := row.Scan(
+		&poll.ID, &poll.ClubID, &poll.Title, &poll.Kind, &poll.ClosesAt, &poll.SetCurrentBook, &poll.CreatedBy,
+		&poll.CreatedAt, &poll.ClosedAt, &poll.WinnerOptionID, &poll.Voters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+func (h *PollHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PollHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PollHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/polls"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestCreatePollValidation(t *testing.T) {
+	handler := NewPollHandler(database.NewMock())
+	handler.clock = clock.NewFake(time.Date(2030, time.March, 15, 12, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/polls", handler.CreatePoll)
+
+	path := "/club/" + uuid.New().String() + "/polls"
+	options := `"options":[{"title":"Middlemarch"},{"title":"Dune","author":"Frank Herbert"}]`
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected int
+	}{
+		{"invalid club", "/club/not-a-uuid/polls", `{"title":"Next read","closesAt":"2030-03-20T18:00:00Z",` + options + `}`, http.StatusBadRequest},
+		{"one option", path, `{"title":"Next read","closesAt":"2030-03-20T18:00:00Z","options":[{"title":"Dune"}]}`, http.StatusBadRequest},
+		{"unknown kind", path, `{"title":"Next read","kind":"approval","closesAt":"2030-03-20T18:00:00Z",` + options + `}`, http.StatusBadRequest},
+		{"closes in the past", path, `{"title":"Next read","closesAt":"2030-03-01T18:00:00Z",` + options + `}`, http.StatusBadRequest},
+		{"no deadline", path, `{"title":"Next read",` + options + `}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidateBallot(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	single := &models.Poll{Kind: polls.KindSingle, Options: []models.PollOption{{ID: a}, {ID: b}}}
+	ranked := &models.Poll{Kind: polls.KindRanked, Options: single.Options}
+
+	tests := []struct {
+		name  string
+		poll  *models.Poll
+		votes []uuid.UUID
+		valid bool
+	}{
+		{"single vote", single, []uuid.UUID{a}, true},
+		{"two votes in a single-choice poll", single, []uuid.UUID{a, b}, false},
+		{"ranked ballot", ranked, []uuid.UUID{b, a}, true},
+		{"partial ranking", ranked, []uuid.UUID{b}, true},
+		{"duplicate ranking", ranked, []uuid.UUID{a, a}, false},
+		{"foreign option", ranked, []uuid.UUID{a, uuid.New()}, false},
+	}
+
+	for _, tt := range tests {
+		if err := validateBallot(tt.poll, tt.votes); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
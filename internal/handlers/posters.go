@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/posters"
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// posterEventStore is the part of posters.Store the poster handler uses
+type posterEventStore interface {
+	Event(ctx context.Context, eventID uuid.UUID) (posters.Event, error)
+}
+
+// PosterHandler renders shareable posters of public events and serves the
+// event details behind their QR codes to guests
+type PosterHandler struct {
+	clocked
+
+	events posterEventStore
+	links  posters.Links
+}
+
+func NewPosterHandler(events posterEventStore, links posters.Links) *PosterHandler {
+	return &PosterHandler{events: events, links: links}
+}
+
+// GetEventPoster renders a poster of a public event as a PNG (the default) or
+// an SVG, with a QR code linking to the event's guest RSVP page
+func (h *PosterHandler) GetEventPoster(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		h.writeError(w, apierror.Validation("Invalid format. Use 'png' or 'svg'", models.InvalidField("format", "oneof", "must be one of: png, svg")))
+		return
+	}
+
+	event, err := h.events.Event(r.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, posters.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get event"))
+		return
+	}
+	if !event.IsPublic {
+		h.writeError(w, apierror.New(apierror.CodeEventNotPublic, "Only public events have posters", nil))
+		return
+	}
+	if !h.now().Before(event.EndsAt) {
+		h.writeError(w, apierror.New(apierror.CodeEventOver, "This event is over", nil))
+		return
+	}
+
+	poster := reports.EventPoster{
+		ClubName:   event.ClubName,
+		BrandColor: event.BrandColor,
+		EventTitle: event.Title,
+		EventDate:  event.Date,
+		EventTime:  event.Time,
+		Location:   event.Location,
+		Link:       h.links.Link(event),
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+		err = reports.WritePosterSVG(&buf, poster)
+	} else {
+		err = reports.WritePosterPNG(&buf, poster)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error rendering event poster", "error", err)
+		h.writeError(w, apierror.Internal("Failed to generate poster"))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="poster-%s.%s"`, eventID, format))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// GetGuestEvent returns the public details of the event a poster links to, for
+// the guest RSVP page (no login; the token is the credential)
+func (h *PosterHandler) GetGuestEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := h.links.Verify(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		if errors.Is(err, signedurl.ErrExpired) {
+			h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This link has expired", nil))
+			return
+		}
+		h.writeError(w, apierror.NotFound("Link not found"))
+		return
+	}
+
+	// Links stop working when their event is deleted or made private
+	event, err := h.events.Event(r.Context(), eventID)
+	if errors.Is(err, posters.ErrNotFound) || (err == nil && !event.IsPublic) {
+		h.writeError(w, apierror.NotFound("Link not found"))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to load link"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"event":     event,
+		"expiresAt": event.EndsAt,
+	}
+	h.writeSuccessResponse(w, response, "Event retrieved successfully")
+}
+
+func (h *PosterHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PosterHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/posters"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakePosterEvents holds events in memory
+type fakePosterEvents map[uuid.UUID]posters.Event
+
+func (f fakePosterEvents) Event(ctx context.Context, eventID uuid.UUID) (posters.Event, error) {
+	event, ok := f[eventID]
+	if !ok {
+		return posters.Event{}, posters.ErrNotFound
+	}
+	return event, nil
+}
+
+func setupPosterTest() (fakePosterEvents, posters.Links, chi.Router) {
+	events := fakePosterEvents{}
+	links := posters.NewLinks(signedurl.NewSigner("secret", "event-guest-link"), "https://bookwork.example/guest/events/")
+	handler := NewPosterHandler(events, links)
+
+	router := chi.NewRouter()
+	router.Get("/events/{eventId}/poster", handler.GetEventPoster)
+	router.Get("/guest/events/{token}", handler.GetGuestEvent)
+	return events, links, router
+}
+
+func publicEvent() posters.Event {
+	return posters.Event{
+		ID:       uuid.New(),
+		Title:    "Spring Reading",
+		Date:     "2099-04-01",
+		Time:     "19:00",
+		Location: "Library",
+		ClubName: "Readers",
+		IsPublic: true,
+		EndsAt:   time.Date(2099, 4, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestGetEventPoster(t *testing.T) {
+	events, _, router := setupPosterTest()
+	event := publicEvent()
+	events[event.ID] = event
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events/"+event.ID.String()+"/poster", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected a PNG, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(rr.Body.Bytes(), []byte("\x89PNG")) {
+		t.Fatal("expected a PNG body")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events/"+event.ID.String()+"/poster?format=svg", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "Spring Reading") {
+		t.Fatalf("expected an SVG with the title, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events/"+event.ID.String()+"/poster?format=gif", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+}
+
+func TestGetEventPosterRefusesPrivateAndPastEvents(t *testing.T) {
+	events, _, router := setupPosterTest()
+	private := publicEvent()
+	private.IsPublic = false
+	past := publicEvent()
+	past.EndsAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	events[private.ID], events[past.ID] = private, past
+
+	for _, tc := range []struct {
+		id     uuid.UUID
+		status int
+	}{{private.ID, http.StatusConflict}, {past.ID, http.StatusConflict}, {uuid.New(), http.StatusNotFound}} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events/"+tc.id.String()+"/poster", nil))
+		if rr.Code != tc.status {
+			t.Errorf("expected %d, got %d: %s", tc.status, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestGetGuestEvent(t *testing.T) {
+	events, links, router := setupPosterTest()
+	event := publicEvent()
+	events[event.ID] = event
+
+	link := links.Link(event)
+	token := strings.TrimPrefix(link, "https://bookwork.example/guest/events/")
+	if token == link || strings.Contains(token, "/") {
+		t.Fatalf("unexpected link %q", link)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/guest/events/"+token, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Data struct {
+			Event posters.Event `json:"event"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if body.Data.Event.Title != "Spring Reading" || body.Data.Event.ClubName != "Readers" {
+		t.Fatalf("unexpected event %+v", body.Data.Event)
+	}
+
+	// Making the event private stops the link
+	event.IsPublic = false
+	events[event.ID] = event
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/guest/events/"+token, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a private event, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/guest/events/forged.token", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a forged token, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/publisher"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxPublisherUsageDays bounds the date range of a usage report
+const maxPublisherUsageDays = 366
+
+// publisherRegistry is the part of publisher.Registry the handler uses
+type publisherRegistry interface {
+	List(ctx context.Context) ([]publisher.Publisher, error)
+	Create(ctx context.Context, np publisher.NewPublisher) (publisher.Publisher, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	Usage(ctx context.Context, id uuid.UUID, from, to time.Time) ([]publisher.Usage, error)
+}
+
+// PublisherHandler lets global admins issue and revoke keys for sites
+// embedding club widgets and read their daily usage
+type PublisherHandler struct {
+	clocked
+
+	registry publisherRegistry
+}
+
+func NewPublisherHandler(registry publisherRegistry) *PublisherHandler {
+	return &PublisherHandler{registry: registry}
+}
+
+// ListPublishers returns every publisher, including revoked ones
+func (h *PublisherHandler) ListPublishers(w http.ResponseWriter, r *http.Request) {
+	publishers, err := h.registry.List(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying publishers", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get publishers"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"publishers": publishers}, "Publishers retrieved successfully")
+}
+
+// CreatePublisher issues a new key and secret. The secret is only ever
+// returned here; a lost secret means issuing a new publisher.
+func (h *PublisherHandler) CreatePublisher(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.CreatePublisherRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateRequest(&req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	np := publisher.NewPublisher{
+		Name:             req.Name,
+		RequireSignature: req.RequireSignature,
+		CreatedBy:        &userID,
+	}
+	if req.QuotaPerMinute != nil {
+		np.QuotaPerMinute = *req.QuotaPerMinute
+	}
+	if req.ReplayWindowSeconds != nil {
+		np.ReplayWindowSeconds = *req.ReplayWindowSeconds
+	}
+
+	created, err := h.registry.Create(r.Context(), np)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error creating publisher", "error", err)
+		h.writeError(w, apierror.Internal("Failed to create publisher"))
+		return
+	}
+	audit.Describe(r.Context(), "publisher", created.ID.String(), nil)
+
+	response := map[string]interface{}{
+		"publisher": created,
+		"secret":    created.Secret,
+	}
+
+	h.writeResponse(w, http.StatusCreated, response, "Publisher created successfully")
+}
+
+// RevokePublisher disables a publisher key immediately
+func (h *PublisherHandler) RevokePublisher(w http.ResponseWriter, r *http.Request) {
+	publisherID, err := uuid.Parse(chi.URLParam(r, "publisherId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid publisher ID", models.InvalidID("publisherId")))
+		return
+	}
+
+	if err := h.registry.Revoke(r.Context(), publisherID); err != nil {
+		if errors.Is(err, publisher.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Active publisher not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error revoking publisher", "error", err)
+		h.writeError(w, apierror.Internal("Failed to revoke publisher"))
+		return
+	}
+	audit.Describe(r.Context(), "publisher", publisherID.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Publisher revoked successfully"}, "Publisher revoked successfully")
+}
+
+// GetUsage returns a publisher's daily usage per route between from and to
+// (YYYY-MM-DD, inclusive), defaulting to the last 30 days
+func (h *PublisherHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	publisherID, err := uuid.Parse(chi.URLParam(r, "publisherId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid publisher ID", models.InvalidID("publisherId")))
+		return
+	}
+
+	to := h.now().UTC()
+	from := to.AddDate(0, 0, -29)
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = timeutil.ParseDate(value); err != nil {
+			h.writeError(w, apierror.Validation("from must be a date (YYYY-MM-DD)", models.InvalidField("from", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = timeutil.ParseDate(value); err != nil {
+			h.writeError(w, apierror.Validation("to must be a date (YYYY-MM-DD)", models.InvalidField("to", "datetime", "must be in the format YYYY-MM-DD")))
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > maxPublisherUsageDays*24*time.Hour {
+		h.writeError(w, apierror.Validation("from must be before to and at most 366 days earlier", models.InvalidField("from", "range", "must be before to and at most 366 days earlier")))
+		return
+	}
+
+	usage, err := h.registry.Usage(r.Context(), publisherID, from, to)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying publisher usage", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get publisher usage"))
+		return
+	}
+
+	totals := map[string]int64{"requests": 0, "signed": 0, "throttled": 0, "rejected": 0}
+	for _, u := range usage {
+		totals["requests"] += u.Requests
+		totals["signed"] += u.Signed
+		totals["throttled"] += u.Throttled
+		totals["rejected"] += u.Rejected
+	}
+
+	response := map[string]interface{}{
+		"from":   timeutil.FormatDate(from),
+		"to":     timeutil.FormatDate(to),
+		"usage":  usage,
+		"totals": totals,
+	}
+
+	h.writeSuccessResponse(w, response, "Publisher usage retrieved successfully")
+}
+
+func (h *PublisherHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *PublisherHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *PublisherHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/publisher"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakePublisherRegistry keeps publishers in memory
+type fakePublisherRegistry struct {
+	publishers []publisher.Publisher
+}
+
+func (f *fakePublisherRegistry) List(ctx context.Context) ([]publisher.Publisher, error) {
+	return f.publishers, nil
+}
+
+func (f *fakePublisherRegistry) Create(ctx context.Context, np publisher.NewPublisher) (publisher.Publisher, error) {
+	p := publisher.Publisher{ID: uuid.New(), Name: np.Name, Key: "pk_new", Secret: "sk_new",
+		QuotaPerMinute: np.QuotaPerMinute, ReplayWindowSeconds: np.ReplayWindowSeconds, CreatedBy: np.CreatedBy}
+	f.publishers = append(f.publishers, p)
+	return p, nil
+}
+
+func (f *fakePublisherRegistry) Revoke(ctx context.Context, id uuid.UUID) error {
+	for i, p := range f.publishers {
+		if p.ID == id && p.RevokedAt == nil {
+			now := time.Now()
+			f.publishers[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return publisher.ErrNotFound
+}
+
+func (f *fakePublisherRegistry) Usage(ctx context.Context, id uuid.UUID, from, to time.Time) ([]publisher.Usage, error) {
+	return []publisher.Usage{
+		{Day: from.Format("2006-01-02"), Route: "/api/public/clubs/{clubId}", Requests: 7, Signed: 5},
+		{Day: from.Format("2006-01-02"), Route: "*", Throttled: 2, Rejected: 1},
+	}, nil
+}
+
+func setupPublisherTest() (*fakePublisherRegistry, chi.Router) {
+	registry := &fakePublisherRegistry{}
+	handler := NewPublisherHandler(registry)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), auth.Principal{UserID: uuid.New()})))
+		})
+	})
+	router.Get("/admin/publishers", handler.ListPublishers)
+	router.Post("/admin/publishers", handler.CreatePublisher)
+	router.Delete("/admin/publishers/{publisherId}", handler.RevokePublisher)
+	router.Get("/admin/publishers/{publisherId}/usage", handler.GetUsage)
+	return registry, router
+}
+
+func TestCreatePublisher(t *testing.T) {
+	registry, router := setupPublisherTest()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"name": "  "}`, http.StatusBadRequest},
+		{`{"name": "Book Blog", "quotaPerMinute": 0}`, http.StatusBadRequest},
+		{`{"name": "Book Blog", "replayWindowSeconds": 7200}`, http.StatusBadRequest},
+		{`{"name": " Book Blog ", "quotaPerMinute": 120, "requireSignature": true}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/admin/publishers", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.expected, w.Code)
+		}
+		if w.Code != http.StatusCreated {
+			continue
+		}
+
+		// The secret is returned once, outside the publisher itself
+		var response struct {
+			Data struct {
+				Publisher map[string]interface{} `json:"publisher"`
+				Secret    string                 `json:"secret"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Data.Secret != "sk_new" || response.Data.Publisher["secret"] != nil {
+			t.Errorf("Unexpected create response: %+v", response.Data)
+		}
+	}
+
+	if len(registry.publishers) != 1 || registry.publishers[0].Name != "Book Blog" || registry.publishers[0].QuotaPerMinute != 120 {
+		t.Errorf("Unexpected publishers: %+v", registry.publishers)
+	}
+}
+
+func TestRevokePublisher(t *testing.T) {
+	registry, router := setupPublisherTest()
+	p, _ := registry.Create(context.Background(), publisher.NewPublisher{Name: "Book Blog"})
+
+	for _, expected := range []int{http.StatusOK, http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/publishers/"+p.ID.String(), nil))
+		if w.Code != expected {
+			t.Errorf("Expected status %d, got %d", expected, w.Code)
+		}
+	}
+}
+
+func TestGetPublisherUsage(t *testing.T) {
+	_, router := setupPublisherTest()
+	id := uuid.New().String()
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"", http.StatusOK},
+		{"?from=2026-01-01&to=2026-01-31", http.StatusOK},
+		{"?from=2026-02-01&to=2026-01-01", http.StatusBadRequest},
+		{"?from=2024-01-01&to=2026-01-01", http.StatusBadRequest},
+		{"?from=yesterday", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/publishers/"+id+"/usage"+tt.query, nil))
+		if w.Code != tt.expected {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.expected, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/publishers/"+id+"/usage", nil))
+	var response struct {
+		Data struct {
+			Totals map[string]int64 `json:"totals"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	totals := response.Data.Totals
+	if totals["requests"] != 7 || totals["signed"] != 5 || totals["throttled"] != 2 || totals["rejected"] != 1 {
+		t.Errorf("Unexpected totals: %+v", totals)
+	}
+}
+
+func TestUsageDefaultsToLast30Days(t *testing.T) {
+	handler := NewPublisherHandler(&fakePublisherRegistry{})
+	handler.clock = clock.NewFake(time.Date(2030, time.March, 15, 23, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Get("/admin/publishers/{publisherId}/usage", handler.GetUsage)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/publishers/"+uuid.NewString()+"/usage", nil))
+
+	var response struct {
+		Data struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Data.From != "2030-02-14" || response.Data.To != "2030-03-15" {
+		t.Errorf("Expected 2030-02-14 to 2030-03-15, got %s to %s", response.Data.From, response.Data.To)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/capture"
+	"bookwork-api/internal/middleware"
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// SupportHandler serves support tooling, such as matching the X-Request-ID from
+// a frontend bug report to the request the server handled, and captured traffic
+type SupportHandler struct {
+	clocked
+
+	requests *middleware.RequestRecorder
+	captures *capture.Recorder
+}
+
+func NewSupportHandler(requests *middleware.RequestRecorder) *SupportHandler {
+	return &SupportHandler{requests: requests}
+}
+
+// WithCapture serves the exchanges sampled by recorder
+func (h *SupportHandler) WithCapture(recorder *capture.Recorder) *SupportHandler {
+	h.captures = recorder
+	return h
+}
+
+// LookupRequest returns the recently handled requests with the given request ID
+func (h *SupportHandler) LookupRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := chi.URLParam(r, "requestId")
+	if requestID == "" {
+		h.writeError(w, apierror.Validation("Request ID is required", models.InvalidField("requestId", "required", "is required")))
+		return
+	}
+
+	records := h.requests.Lookup(requestID)
+	if len(records) == 0 {
+		// Only recent requests are kept; older ones have to be found in the logs
+		h.writeError(w, apierror.New(apierror.CodeNotFound, "Request not found among recent requests", map[string]interface{}{
+			"requestId": requestID,
+			"logField":  "request_id",
+		}))
+		return
+	}
+
+	response := map[string]interface{}{
+		"requestId": requestID,
+		"requests":  records,
+	}
+
+	h.writeSuccessResponse(w, response, "Request retrieved successfully")
+}
+
+// GetCaptures lists captured exchanges, newest first. Filters: method, status,
+// pathPrefix and requestId; limit (default 50, at most 500).
+func (h *SupportHandler) GetCaptures(w http.ResponseWriter, r *http.Request) {
+	if h.captures == nil {
+		h.writeError(w, apierror.NotFound("Traffic capture is not enabled"))
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	status := 0
+	if s := query.Get("status"); s != "" {
+		var err error
+		if status, err = strconv.Atoi(s); err != nil {
+			h.writeError(w, apierror.Validation("Invalid status", models.InvalidField("status", "numeric", "must be an HTTP status code")))
+			return
+		}
+	}
+	method := strings.ToUpper(query.Get("method"))
+	pathPrefix := query.Get("pathPrefix")
+	requestID := query.Get("requestId")
+
+	exchanges := []capture.Exchange{}
+	for _, exchange := range h.captures.Recent(0) {
+		if len(exchanges) == limit {
+			break
+		}
+		if (method != "" && exchange.Method != method) ||
+			(status != 0 && exchange.Status != status) ||
+			(pathPrefix != "" && !strings.HasPrefix(exchange.Path, pathPrefix)) ||
+			(requestID != "" && exchange.RequestID != requestID) {
+			continue
+		}
+		exchanges = append(exchanges, exchange)
+	}
+
+	response := map[string]interface{}{
+		"captures": exchanges,
+		"stats":    h.captures.Stats(),
+	}
+
+	h.writeSuccessResponse(w, response, "Captures retrieved successfully")
+}
+
+// GetCapture returns one captured exchange
+func (h *SupportHandler) GetCapture(w http.ResponseWriter, r *http.Request) {
+	if h.captures == nil {
+		h.writeError(w, apierror.NotFound("Traffic capture is not enabled"))
+		return
+	}
+
+	captureID, err := uuid.Parse(chi.URLParam(r, "captureId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid capture ID", models.InvalidID("captureId")))
+		return
+	}
+
+	exchange, ok := h.captures.Get(captureID)
+	if !ok {
+		h.writeError(w, apierror.NotFound("Capture not found among recent captures"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"capture": exchange}, "Capture retrieved successfully")
+}
+
+// ClearCaptures drops every captured exchange, e.g. once an investigation is over
+func (h *SupportHandler) ClearCaptures(w http.ResponseWriter, r *http.Request) {
+	if h.captures == nil {
+		h.writeError(w, apierror.NotFound("Traffic capture is not enabled"))
+		return
+	}
+
+	h.captures.Clear()
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Captures cleared successfully"}, "Captures cleared successfully")
+}
+
+func (h *SupportHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *SupportHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/capture"
+	"bookwork-api/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestGetCapturesFilters(t *testing.T) {
+	recorder := capture.NewRecorder(10, 100, 1024)
+	recorder.Record(capture.Exchange{ID: uuid.New(), Method: http.MethodGet, Path: "/api/clubs", Status: http.StatusOK})
+	recorder.Record(capture.Exchange{ID: uuid.New(), Method: http.MethodPost, Path: "/api/events", Status: http.StatusBadRequest})
+	recorder.Record(capture.Exchange{ID: uuid.New(), Method: http.MethodGet, Path: "/api/events/1", Status: http.StatusOK})
+
+	handler := NewSupportHandler(middleware.NewRequestRecorder(10)).WithCapture(recorder)
+
+	tests := []struct {
+		query    string
+		status   int
+		expected int
+	}{
+		{"", http.StatusOK, 3},
+		{"?method=get", http.StatusOK, 2},
+		{"?pathPrefix=/api/events&status=200", http.StatusOK, 1},
+		{"?limit=1", http.StatusOK, 1},
+		{"?status=bad", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.GetCaptures(w, httptest.NewRequest(http.MethodGet, "/admin/captures"+tt.query, nil))
+		if w.Code != tt.status {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.status, w.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		var response struct {
+			Data struct {
+				Captures []capture.Exchange `json:"captures"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Data.Captures) != tt.expected {
+			t.Errorf("%q: expected %d captures, got %d", tt.query, tt.expected, len(response.Data.Captures))
+		}
+	}
+}
+
+func TestGetCapture(t *testing.T) {
+	recorder := capture.NewRecorder(10, 100, 1024)
+	id := uuid.New()
+	recorder.Record(capture.Exchange{ID: id, Method: http.MethodGet, Path: "/api/clubs", Status: http.StatusOK})
+
+	router := chi.NewRouter()
+	router.Get("/admin/captures/{captureId}", NewSupportHandler(middleware.NewRequestRecorder(10)).WithCapture(recorder).GetCapture)
+	disabled := chi.NewRouter()
+	disabled.Get("/admin/captures/{captureId}", NewSupportHandler(middleware.NewRequestRecorder(10)).GetCapture)
+
+	tests := []struct {
+		name     string
+		router   http.Handler
+		path     string
+		expected int
+	}{
+		{"held", router, "/admin/captures/" + id.String(), http.StatusOK},
+		{"evicted", router, "/admin/captures/" + uuid.New().String(), http.StatusNotFound},
+		{"invalid id", router, "/admin/captures/not-a-uuid", http.StatusBadRequest},
+		{"capture disabled", disabled, "/admin/captures/" + id.String(), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.expected, w.Code)
+		}
+	}
+}
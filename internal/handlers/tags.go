@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/tags"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxTagSuggestions bounds the limit of a typeahead request
+const maxTagSuggestions = 25
+
+// tagTaxonomy is the part of tags.Store the handler uses
+type tagTaxonomy interface {
+	List(ctx context.Context) ([]tags.Tag, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]tags.Suggestion, error)
+	Create(ctx context.Context, name string) (tags.Tag, error)
+	Rename(ctx context.Context, id uuid.UUID, name string) (tags.Tag, error)
+	Merge(ctx context.Context, sourceID, targetID uuid.UUID, dryRun bool) (tags.Tag, []store.Effect, error)
+	AddAlias(ctx context.Context, id uuid.UUID, alias string) (tags.Tag, error)
+	RemoveAlias(ctx context.Context, id uuid.UUID, alias string) error
+}
+
+// TagHandler suggests canonical club tags as members type, and lets global
+// admins curate them
+type TagHandler struct {
+	clocked
+
+	tags tagTaxonomy
+}
+
+func NewTagHandler(taxonomy tagTaxonomy) *TagHandler {
+	return &TagHandler{tags: taxonomy}
+}
+
+// SuggestTags returns canonical tags starting with q, matched by name, a word
+// of the name or an alias, the most used first
+func (h *TagHandler) SuggestTags(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxTagSuggestions {
+			h.writeError(w, apierror.Validation("limit must be between 1 and 25", models.InvalidField("limit", "range", "must be between 1 and 25")))
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := h.tags.Suggest(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying tag suggestions", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get tag suggestions"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"suggestions": suggestions}, "Tag suggestions retrieved successfully")
+}
+
+// ListTags returns every canonical tag with its aliases and usage
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	list, err := h.tags.List(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying tags", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get tags"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"tags": list}, "Tags retrieved successfully")
+}
+
+// CreateTag adds a canonical tag
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.decodeName(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tags.Create(r.Context(), name)
+	if err != nil {
+		h.writeTagError(w, r, err, "Failed to create tag")
+		return
+	}
+	audit.Describe(r.Context(), "tag", tag.ID.String(), audit.Diff(nil, tag))
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"tag": tag}, "Tag created successfully")
+}
+
+// RenameTag changes a tag's name everywhere; the old name becomes an alias
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagID, ok := h.tagID(w, r)
+	if !ok {
+		return
+	}
+	name, ok := h.decodeName(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tags.Rename(r.Context(), tagID, name)
+	if err != nil {
+		h.writeTagError(w, r, err, "Failed to rename tag")
+		return
+	}
+	audit.Describe(r.Context(), "tag", tagID.String(), audit.Changes{"name": {To: tag.Name}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"tag": tag}, "Tag renamed successfully")
+}
+
+// MergeTag folds a tag into another: its clubs get the other tag, and its name
+// and aliases become aliases of the other tag
+func (h *TagHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	tagID, ok := h.tagID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MergeTagRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	dryRun, derr := dryRunParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	tag, effects, err := h.tags.Merge(r.Context(), tagID, req.IntoID, dryRun)
+	if err != nil {
+		h.writeTagError(w, r, err, "Failed to merge tags")
+		return
+	}
+	if dryRun {
+		h.writeSuccessResponse(w, map[string]interface{}{"dryRun": true, "tag": tag, "effects": effects}, "Tags would be merged")
+		return
+	}
+	audit.Describe(r.Context(), "tag", tagID.String(), audit.Changes{"mergedInto": {To: req.IntoID}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"tag": tag}, "Tags merged successfully")
+}
+
+// AddAlias makes a name stand for a tag
+func (h *TagHandler) AddAlias(w http.ResponseWriter, r *http.Request) {
+	tagID, ok := h.tagID(w, r)
+	if !ok {
+		return
+	}
+	alias, ok := h.decodeName(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tags.AddAlias(r.Context(), tagID, alias)
+	if err != nil {
+		h.writeTagError(w, r, err, "Failed to add tag alias")
+		return
+	}
+	audit.Describe(r.Context(), "tag", tagID.String(), audit.Changes{"alias": {To: alias}})
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"tag": tag}, "Tag alias added successfully")
+}
+
+// RemoveAlias stops a name from standing for a tag
+func (h *TagHandler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	tagID, ok := h.tagID(w, r)
+	if !ok {
+		return
+	}
+	alias := tags.Normalize(chi.URLParam(r, "alias"))
+
+	if err := h.tags.RemoveAlias(r.Context(), tagID, alias); err != nil {
+		if errors.Is(err, tags.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Tag alias not found"))
+			return
+		}
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error removing tag alias", "error", err)
+		h.writeError(w, apierror.Internal("Failed to remove tag alias"))
+		return
+	}
+	audit.Describe(r.Context(), "tag", tagID.String(), audit.Changes{"alias": {From: alias}})
+
+	h.writeSuccessResponse(w, map[string]string{"message": "Tag alias removed successfully"}, "Tag alias removed successfully")
+}
+
+func (h *TagHandler) tagID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tagID, err := uuid.Parse(chi.URLParam(r, "tagId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid tag ID", models.InvalidID("tagId")))
+		return uuid.Nil, false
+	}
+	return tagID, true
+}
+
+// decodeName reads a TagNameRequest and returns the normalized name
+func (h *TagHandler) decodeName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req models.TagNameRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return "", false
+	}
+
+	name := tags.Normalize(req.Name)
+	if name == "" {
+		h.writeError(w, apierror.Validation("Tag name is required", models.InvalidField("name", "required", "is required")))
+		return "", false
+	}
+	return name, true
+}
+
+func (h *TagHandler) writeTagError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, tags.ErrNotFound):
+		h.writeError(w, apierror.NotFound("Tag not found"))
+	case errors.Is(err, tags.ErrTaken):
+		h.writeError(w, apierror.New(apierror.CodeTagTaken, "The name is already a tag or an alias; merge the tags instead", nil))
+	case errors.Is(err, tags.ErrSameTag):
+		h.writeError(w, apierror.Validation("Cannot merge a tag into itself", models.InvalidField("intoId", "ne", "must be another tag")))
+	default:
+		logging.FromContext(r.Context()).Error("error changing tags", "error", err)
+		h.writeError(w, apierror.Internal(message))
+	}
+}
+
+func (h *TagHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *TagHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TagHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
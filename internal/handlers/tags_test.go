@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/tags"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeTaxonomy keeps tags by ID and records the last name it was given
+type fakeTaxonomy struct {
+	tags     map[uuid.UUID]tags.Tag
+	lastName string
+	limit    int
+}
+
+func (f *fakeTaxonomy) List(ctx context.Context) ([]tags.Tag, error) {
+	list := []tags.Tag{}
+	for _, tag := range f.tags {
+		list = append(list, tag)
+	}
+	return list, nil
+}
+
+func (f *fakeTaxonomy) Suggest(ctx context.Context, prefix string, limit int) ([]tags.Suggestion, error) {
+	f.limit = limit
+	return []tags.Suggestion{}, nil
+}
+
+func (f *fakeTaxonomy) Create(ctx context.Context, name string) (tags.Tag, error) {
+	f.lastName = name
+	for _, tag := range f.tags {
+		if tag.Name == name {
+			return tags.Tag{}, tags.ErrTaken
+		}
+	}
+	tag := tags.Tag{ID: uuid.New(), Name: name, Aliases: []string{}}
+	f.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (f *fakeTaxonomy) Rename(ctx context.Context, id uuid.UUID, name string) (tags.Tag, error) {
+	f.lastName = name
+	tag, ok := f.tags[id]
+	if !ok {
+		return tags.Tag{}, tags.ErrNotFound
+	}
+	tag.Aliases = append(tag.Aliases, tag.Name)
+	tag.Name = name
+	f.tags[id] = tag
+	return tag, nil
+}
+
+func (f *fakeTaxonomy) Merge(ctx context.Context, sourceID, targetID uuid.UUID, dryRun bool) (tags.Tag, []store.Effect, error) {
+	if sourceID == targetID {
+		return tags.Tag{}, nil, tags.ErrSameTag
+	}
+	source, ok := f.tags[sourceID]
+	target, found := f.tags[targetID]
+	if !ok || !found {
+		return tags.Tag{}, nil, tags.ErrNotFound
+	}
+	target.Aliases = append(append(target.Aliases, source.Name), source.Aliases...)
+	effects := []store.Effect{{Table: "tags", Deleted: 1}}
+	if dryRun {
+		return target, effects, nil
+	}
+	f.tags[targetID] = target
+	delete(f.tags, sourceID)
+	return target, effects, nil
+}
+
+func (f *fakeTaxonomy) AddAlias(ctx context.Context, id uuid.UUID, alias string) (tags.Tag, error) {
+	tag, ok := f.tags[id]
+	if !ok {
+		return tags.Tag{}, tags.ErrNotFound
+	}
+	tag.Aliases = append(tag.Aliases, alias)
+	f.tags[id] = tag
+	return tag, nil
+}
+
+func (f *fakeTaxonomy) RemoveAlias(ctx context.Context, id uuid.UUID, alias string) error {
+	return tags.ErrNotFound
+}
+
+func setupTagTest() (*fakeTaxonomy, chi.Router) {
+	taxonomy := &fakeTaxonomy{tags: map[uuid.UUID]tags.Tag{}}
+	handler := NewTagHandler(taxonomy)
+
+	router := chi.NewRouter()
+	router.Get("/tags/suggest", handler.SuggestTags)
+	router.Post("/admin/tags", handler.CreateTag)
+	router.Put("/admin/tags/{tagId}", handler.RenameTag)
+	router.Post("/admin/tags/{tagId}/merge", handler.MergeTag)
+	router.Post("/admin/tags/{tagId}/aliases", handler.AddAlias)
+	router.Delete("/admin/tags/{tagId}/aliases/{alias}", handler.RemoveAlias)
+	return taxonomy, router
+}
+
+func serveTags(router chi.Router, method, path, body string) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+	return w.Code
+}
+
+func TestCreateTagNormalizesName(t *testing.T) {
+	taxonomy, router := setupTagTest()
+
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"name": "   "}`, http.StatusBadRequest},
+		{`{"name": "` + string(bytes.Repeat([]byte("a"), 51)) + `"}`, http.StatusBadRequest},
+		{`{"name": "  Science   Fiction "}`, http.StatusCreated},
+		{`{"name": "SCIENCE FICTION"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		if code := serveTags(router, "POST", "/admin/tags", tt.body); code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.expected, code)
+		}
+	}
+
+	if taxonomy.lastName != "science fiction" {
+		t.Errorf("Expected the name to be normalized, got %q", taxonomy.lastName)
+	}
+}
+
+func TestRenameAndMergeTags(t *testing.T) {
+	taxonomy, router := setupTagTest()
+	scifi, _ := taxonomy.Create(context.Background(), "sci-fi")
+	science, _ := taxonomy.Create(context.Background(), "science fiction")
+
+	if code := serveTags(router, "PUT", "/admin/tags/nope", `{"name": "x"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid tag ID to be rejected, got %d", code)
+	}
+	if code := serveTags(router, "PUT", "/admin/tags/"+uuid.NewString(), `{"name": "x"}`); code != http.StatusNotFound {
+		t.Errorf("Expected an unknown tag to be reported, got %d", code)
+	}
+	if code := serveTags(router, "PUT", "/admin/tags/"+science.ID.String(), `{"name": "Science-Fiction"}`); code != http.StatusOK {
+		t.Errorf("Expected the rename to succeed, got %d", code)
+	}
+
+	if code := serveTags(router, "POST", "/admin/tags/"+scifi.ID.String()+"/merge", `{"intoId": "`+scifi.ID.String()+`"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected merging a tag into itself to be rejected, got %d", code)
+	}
+	if code := serveTags(router, "POST", "/admin/tags/"+scifi.ID.String()+"/merge", `{}`); code != http.StatusBadRequest {
+		t.Errorf("Expected a missing target to be rejected, got %d", code)
+	}
+	if code := serveTags(router, "POST", "/admin/tags/"+scifi.ID.String()+"/merge?dryRun=maybe", `{"intoId": "`+science.ID.String()+`"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid dryRun to be rejected, got %d", code)
+	}
+	if code := serveTags(router, "POST", "/admin/tags/"+scifi.ID.String()+"/merge?dryRun=true", `{"intoId": "`+science.ID.String()+`"}`); code != http.StatusOK {
+		t.Errorf("Expected the dry run to succeed, got %d", code)
+	}
+	if _, ok := taxonomy.tags[scifi.ID]; !ok {
+		t.Fatal("Expected a dry run to leave the tags alone")
+	}
+	if code := serveTags(router, "POST", "/admin/tags/"+scifi.ID.String()+"/merge", `{"intoId": "`+science.ID.String()+`"}`); code != http.StatusOK {
+		t.Errorf("Expected the merge to succeed, got %d", code)
+	}
+
+	merged := taxonomy.tags[science.ID]
+	if merged.Name != "science-fiction" || len(merged.Aliases) != 2 {
+		t.Errorf("Expected the old name and the merged tag as aliases, got %+v", merged)
+	}
+	if _, ok := taxonomy.tags[scifi.ID]; ok {
+		t.Error("Expected the merged tag to be gone")
+	}
+}
+
+func TestTagAliases(t *testing.T) {
+	taxonomy, router := setupTagTest()
+	tag, _ := taxonomy.Create(context.Background(), "mystery")
+
+	if code := serveTags(router, "POST", "/admin/tags/"+tag.ID.String()+"/aliases", `{"name": "Whodunit"}`); code != http.StatusCreated {
+		t.Errorf("Expected the alias to be added, got %d", code)
+	}
+	if aliases := taxonomy.tags[tag.ID].Aliases; len(aliases) != 1 || aliases[0] != "whodunit" {
+		t.Errorf("Expected a normalized alias, got %v", aliases)
+	}
+	if code := serveTags(router, "DELETE", "/admin/tags/"+tag.ID.String()+"/aliases/crime", ""); code != http.StatusNotFound {
+		t.Errorf("Expected an unknown alias to be reported, got %d", code)
+	}
+}
+
+func TestSuggestTagsLimit(t *testing.T) {
+	taxonomy, router := setupTagTest()
+
+	if code := serveTags(router, "GET", "/tags/suggest?q=sci&limit=26", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected a limit over 25 to be rejected, got %d", code)
+	}
+	if code := serveTags(router, "GET", "/tags/suggest?q=sci", ""); code != http.StatusOK || taxonomy.limit != 10 {
+		t.Errorf("Expected the default limit of 10, got status %d and limit %d", code, taxonomy.limit)
+	}
+}
+
+func TestUpdateSettingsRejectsTooManyTags(t *testing.T) {
+	handler := NewClubHandler(database.NewMock())
+	router := chi.NewRouter()
+	router.Put("/club/{clubId}/settings", handler.UpdateSettings)
+
+	body := `{"tags": ["a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"]}`
+	if code := serveTags(router, "PUT", "/club/"+uuid.NewString()+"/settings", body); code != http.StatusBadRequest {
+		t.Errorf("Expected more than 10 tags to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// TrashHandler lets global admins list, restore and purge soft-deleted events
+// and clubs. Only soft-deleted rows can be purged; live ones are not found here.
+type TrashHandler struct {
+	clocked
+
+	db       *database.DB
+	removals store.RemovalStore
+	roles    roleCache // nil when member roles are not cached
+}
+
+func NewTrashHandler(db *database.DB, removals store.RemovalStore) *TrashHandler {
+	return &TrashHandler{db: db, removals: removals}
+}
+
+// WithRoleCache drops the cached roles of a club that is restored, whose
+// members lost them when it was deleted
+func (h *TrashHandler) WithRoleCache(roles roleCache) *TrashHandler {
+	h.roles = roles
+	return h
+}
+
+// ListEvents lists soft-deleted events, most recently deleted first
+func (h *TrashHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	limit, offset := trashPage(r)
+
+	query := `
+		SELECT e.id, e.club_id, e.title, e.event_date, e.deleted_at, e.deleted_by, c.deleted_at IS NOT NULL
+		FROM events e
+		JOIN clubs c ON c.id = e.club_id
+		WHERE e.deleted_at IS NOT NULL
+		ORDER BY e.deleted_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := h.db.QueryContext(r.Context(), query, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying deleted events", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get deleted events"))
+		return
+	}
+	defer rows.Close()
+
+	events := []models.DeletedEvent{}
+	for rows.Next() {
+		var event models.DeletedEvent
+		var date time.Time
+		if err := rows.Scan(&event.ID, &event.ClubID, &event.Title, &date, &event.DeletedAt, &event.DeletedBy, &event.ClubDeleted); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning deleted event", "error", err)
+			continue
+		}
+		event.Date = timeutil.FormatDate(date)
+		events = append(events, event)
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"events": events}, "Deleted events retrieved successfully")
+}
+
+// ListClubs lists soft-deleted clubs, most recently deleted first
+func (h *TrashHandler) ListClubs(w http.ResponseWriter, r *http.Request) {
+	limit, offset := trashPage(r)
+
+	query := `
+		SELECT c.id, c.name, c.owner_id,
+		       (SELECT COUNT(*) FROM club_members cm WHERE cm.club_id = c.id AND cm.is_active = true),
+		       c.deleted_at, c.deleted_by
+		FROM clubs c
+		WHERE c.deleted_at IS NOT NULL
+		ORDER BY c.deleted_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := h.db.QueryContext(r.Context(), query, limit, offset)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error querying deleted clubs", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get deleted clubs"))
+		return
+	}
+	defer rows.Close()
+
+	clubs := []models.DeletedClub{}
+	for rows.Next() {
+		var club models.DeletedClub
+		if err := rows.Scan(&club.ID, &club.Name, &club.OwnerID, &club.MemberCount, &club.DeletedAt, &club.DeletedBy); err != nil {
+			logging.FromContext(r.Context()).Error("error scanning deleted club", "error", err)
+			continue
+		}
+		clubs = append(clubs, club)
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"clubs": clubs}, "Deleted clubs retrieved successfully")
+}
+
+// RestoreEvent brings back a soft-deleted event with its items and availability.
+// An event in a soft-deleted club cannot be restored before the club.
+func (h *TrashHandler) RestoreEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	var clubDeleted bool
+	query := `
+		SELECT c.deleted_at IS NOT NULL
+		FROM events e
+		JOIN clubs c ON c.id = e.club_id
+		WHERE e.id = $1 AND e.deleted_at IS NOT NULL`
+
+	if err := h.db.QueryRowContext(r.Context(), query, eventID).Scan(&clubDeleted); err != nil {
+		if err == sql.ErrNoRows {
+			h.writeError(w, apierror.NotFound("Deleted event not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting deleted event", "error", err)
+		h.writeError(w, apierror.Internal("Failed to restore event"))
+		return
+	}
+
+	if clubDeleted {
+		h.writeError(w, apierror.New(apierror.CodeClubDeleted, "Restore the event's club first", nil))
+		return
+	}
+
+	h.apply(w, r, "event", eventID, `UPDATE events SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`,
+		"Deleted event not found", "Event restored successfully", nil)
+}
+
+// RestoreClub brings back a soft-deleted club. Events deleted on their own stay deleted.
+func (h *TrashHandler) RestoreClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.apply(w, r, "club", clubID, `UPDATE clubs SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`,
+		"Deleted club not found", "Club restored successfully", func() {
+			if h.roles != nil {
+				h.roles.ForgetClub(clubID)
+			}
+		})
+}
+
+// PurgeEvent permanently deletes a soft-deleted event with its items and availability
+func (h *TrashHandler) PurgeEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid event ID", models.InvalidID("eventId")))
+		return
+	}
+
+	h.purge(w, r, "event", eventID, h.removals.PurgeEvent, "Deleted event not found", "Event purged successfully")
+}
+
+// PurgeClub permanently deletes a soft-deleted club with its members and events
+func (h *TrashHandler) PurgeClub(w http.ResponseWriter, r *http.Request) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return
+	}
+
+	h.purge(w, r, "club", clubID, h.removals.PurgeClub, "Deleted club not found", "Club purged successfully")
+}
+
+// purge permanently deletes one soft-deleted row with everything that cascades
+// from it or, with ?dryRun=true, reports what that would delete
+func (h *TrashHandler) purge(w http.ResponseWriter, r *http.Request, entityType string, id uuid.UUID,
+	remove func(ctx context.Context, id uuid.UUID, dryRun bool) (*store.Removal, error), notFound, success string) {
+	dryRun, derr := dryRunParam(r)
+	if derr != nil {
+		h.writeError(w, derr)
+		return
+	}
+
+	removal, err := remove(r.Context(), id, dryRun)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, apierror.NotFound(notFound))
+			return
+		}
+		// Contributions are kept with their event, which then cannot be purged
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error purging deleted "+entityType, "error", err)
+		h.writeError(w, apierror.Internal("Failed to purge deleted "+entityType))
+		return
+	}
+	if dryRun {
+		h.writeSuccessResponse(w, removal, "Deleted "+entityType+" would be purged")
+		return
+	}
+	audit.Describe(r.Context(), entityType, id.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": success}, success)
+}
+
+// apply runs a restore of one soft-deleted row, writing notFound when
+// there is no such row. applied, if not nil, runs once the row is restored.
+func (h *TrashHandler) apply(w http.ResponseWriter, r *http.Request, entityType string, id uuid.UUID, query, notFound, success string, applied func()) {
+	result, err := h.db.ExecContext(r.Context(), query, id)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error updating deleted "+entityType, "error", err)
+		h.writeError(w, apierror.Internal("Failed to update deleted "+entityType))
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		h.writeError(w, apierror.NotFound(notFound))
+		return
+	}
+	if applied != nil {
+		applied()
+	}
+	audit.Describe(r.Context(), entityType, id.String(), nil)
+
+	h.writeSuccessResponse(w, map[string]string{"message": success}, success)
+}
+
+// trashPage reads page and limit (default 50, at most 200)
+func trashPage(r *http.Request) (limit, offset int) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	return limit, (page - 1) * limit
+}
+
+func (h *TrashHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TrashHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
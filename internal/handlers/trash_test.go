@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTrashPurgeAndRestore(t *testing.T) {
+	memory := store.NewMemory()
+	deletedAt := time.Now()
+	deletedEvent, deletedClub := uuid.New(), uuid.New()
+	memory.PutEvent(models.Event{ID: deletedEvent, ClubID: uuid.New(), DeletedAt: &deletedAt})
+	memory.PutDeletedClub(deletedClub)
+	handler := NewTrashHandler(database.NewMock(), memory.Stores().Removals)
+
+	router := chi.NewRouter()
+	router.Delete("/admin/deleted/events/{eventId}", handler.PurgeEvent)
+	router.Delete("/admin/deleted/clubs/{clubId}", handler.PurgeClub)
+	router.Post("/admin/deleted/clubs/{clubId}/restore", handler.RestoreClub)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{"DELETE", "/admin/deleted/events/not-a-uuid", http.StatusBadRequest},
+		{"DELETE", "/admin/deleted/events/" + uuid.New().String(), http.StatusNotFound},
+		{"DELETE", "/admin/deleted/events/" + deletedEvent.String(), http.StatusOK},
+		{"DELETE", "/admin/deleted/events/" + deletedEvent.String(), http.StatusNotFound},
+		{"DELETE", "/admin/deleted/clubs/" + deletedClub.String() + "?dryRun=perhaps", http.StatusBadRequest},
+		{"DELETE", "/admin/deleted/clubs/" + deletedClub.String(), http.StatusOK},
+		{"POST", "/admin/deleted/clubs/not-a-uuid/restore", http.StatusBadRequest},
+		{"POST", "/admin/deleted/clubs/" + uuid.New().String() + "/restore", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.expected {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.expected, w.Code)
+		}
+	}
+}
+
+func TestTrashPurgeDryRun(t *testing.T) {
+	memory := store.NewMemory()
+	clubID := uuid.New()
+	deletedAt := time.Now()
+	eventID := uuid.New()
+	memory.PutEvent(models.Event{ID: eventID, ClubID: clubID, DeletedAt: &deletedAt})
+	memory.PutMember(models.ClubMember{ID: uuid.New(), ClubID: clubID, UserID: uuid.New(), IsActive: true})
+	memory.PutDeletedClub(clubID)
+	handler := NewTrashHandler(database.NewMock(), memory.Stores().Removals)
+
+	router := chi.NewRouter()
+	router.Delete("/admin/deleted/clubs/{clubId}", handler.PurgeClub)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/deleted/clubs/"+clubID.String()+"?dryRun=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Data store.Removal `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	deleted := map[string]int64{}
+	for _, effect := range response.Data.Effects {
+		deleted[effect.Table] = effect.Deleted
+	}
+	if !response.Data.DryRun || deleted["clubs"] != 1 || deleted["club_members"] != 1 || deleted["events"] != 1 {
+		t.Errorf("Unexpected preview %+v", response.Data)
+	}
+
+	// Nothing was purged, so the club can still be
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/deleted/clubs/"+clubID.String(), nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the club to still be purgeable, got %d", w.Code)
+	}
+}
+
+// contributedRemovals refuses purges the way the database does for an event
+// that still has contributions
+type contributedRemovals struct{ store.RemovalStore }
+
+func (contributedRemovals) PurgeEvent(ctx context.Context, eventID uuid.UUID, dryRun bool) (*store.Removal, error) {
+	return nil, &pgconn.PgError{Code: "23503", Message: `update or delete on table "events" violates foreign key constraint "event_contributions_event_id_fkey" on table "event_contributions"`}
+}
+
+func TestTrashPurgeKeepsContributions(t *testing.T) {
+	handler := NewTrashHandler(database.NewMock(), contributedRemovals{store.NewMemory().Stores().Removals})
+
+	router := chi.NewRouter()
+	router.Delete("/admin/deleted/events/{eventId}", handler.PurgeEvent)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/deleted/events/"+uuid.New().String(), nil))
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "STILL_REFERENCED") {
+		t.Errorf("Expected 409 STILL_REFERENCED, got %d: %s", w.Code, w.Body.String())
+	}
+}
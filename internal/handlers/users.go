@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/localtime"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	clocked
+
+	db *database.DB
+}
+
+func NewUserHandler(db *database.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+// GetProfile returns the caller's profile including their preferences
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	user, err := h.profile(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting user profile", "error", err)
+		h.writeError(w, apierror.NotFound("User not found"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"user": user,
+	}
+
+	h.writeSuccessResponse(w, response, "Profile retrieved successfully")
+}
+
+// UpdateProfile changes the caller's name, phone, avatar or notification
+// preferences; fields left out keep their value
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	var notifyEmail, notifyPush *bool
+	if prefs := req.NotificationPreferences; prefs != nil {
+		notifyEmail, notifyPush = prefs.Email, prefs.Push
+	}
+	if req.Name == nil && req.Phone == nil && req.Avatar == nil && notifyEmail == nil && notifyPush == nil {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			h.writeError(w, apierror.Validation("Name cannot be blank", models.InvalidField("name", "required", "cannot be blank")))
+			return
+		}
+		req.Name = &name
+	}
+	if req.Avatar != nil && *req.Avatar != "" && !isWebURL(*req.Avatar) {
+		h.writeError(w, apierror.Validation("Avatar must be an http or https URL", models.InvalidField("avatar", "url", "must be an http or https URL")))
+		return
+	}
+
+	// Empty phone and avatar values remove them
+	query := `
+		UPDATE users SET
+			name = COALESCE($2, name),
+			phone = CASE WHEN $3::text IS NULL THEN phone ELSE NULLIF($3, '') END,
+			avatar = CASE WHEN $4::text IS NULL THEN avatar ELSE NULLIF($4, '') END,
+			notify_email = COALESCE($5, notify_email),
+			notify_push = COALESCE($6, notify_push),
+			updated_at = NOW()
+		WHERE id = $1 AND is_active = true`
+
+	if _, err := h.db.ExecContext(r.Context(), query, userID, req.Name, req.Phone, req.Avatar, notifyEmail, notifyPush); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating profile", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update profile"))
+		return
+	}
+	audit.Describe(r.Context(), "user", userID.String(), audit.Diff(nil, req))
+
+	user, err := h.profile(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error getting user profile", "error", err)
+		h.writeError(w, apierror.NotFound("User not found"))
+		return
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"user": user}, "Profile updated successfully")
+}
+
+// profile loads an active user's profile with their preferences and whether
+// email to them bounces
+func (h *UserHandler) profile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT u.id, u.name, u.email, u.phone, u.avatar, u.role, u.is_active, u.created_at, u.updated_at,
+		       COALESCE(u.timezone, 'UTC'), COALESCE(u.is_sandbox, false), u.sandbox_expires_at,
+		       u.notify_email, u.notify_push, es.reason, es.suppressed_at
+		FROM users u
+		LEFT JOIN email_suppressions es ON es.email = LOWER(u.email)
+		WHERE u.id = $1 AND u.is_active = true`
+
+	var user models.User
+	var prefs models.NotificationPreferences
+	var suppressedReason *string
+	var suppressedAt *time.Time
+	err := h.db.QueryRowContext(ctx, query, userID).Scan(
+		&user.ID, &user.Name, &user.Email, &user.Phone, &user.Avatar,
+		&user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.Timezone,
+		&user.IsSandbox, &user.SandboxExpiresAt,
+		&prefs.Email, &prefs.Push, &suppressedReason, &suppressedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.NotificationPreferences = &prefs
+	if suppressedReason != nil && suppressedAt != nil {
+		user.EmailUndeliverable = &models.EmailUndeliverable{Reason: *suppressedReason, Since: timeutil.FormatTimestamp(*suppressedAt)}
+	}
+	return &user, nil
+}
+
+// isWebURL reports whether value is an absolute http or https URL
+func isWebURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// UpdatePreferences updates the caller's preferences, currently their timezone
+func (h *UserHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	var req models.UpdatePreferencesRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	if req.Timezone == nil {
+		h.writeError(w, apierror.Validation("No fields to update", models.InvalidField("", "required", "Give at least one field to update")))
+		return
+	}
+
+	loc, err := localtime.LoadLocation(*req.Timezone)
+	if err != nil {
+		h.writeError(w, apierror.Validation("Timezone must be an IANA name such as Europe/London", models.InvalidField("timezone", "timezone", "must be an IANA name such as Europe/London")))
+		return
+	}
+
+	query := `UPDATE users SET timezone = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := h.db.ExecContext(r.Context(), query, loc.String(), userID); err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error updating preferences", "error", err)
+		h.writeError(w, apierror.Internal("Failed to update preferences"))
+		return
+	}
+
+	response := map[string]interface{}{
+		"preferences": map[string]interface{}{
+			"timezone": loc.String(),
+		},
+	}
+
+	h.writeSuccessResponse(w, response, "Preferences updated successfully")
+}
+
+// userLocation returns the caller's preferred timezone, defaulting to UTC
+func userLocation(ctx context.Context, db *database.DB, userID uuid.UUID) *time.Location {
+	var timezone string
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(timezone, 'UTC') FROM users WHERE id = $1`, userID).Scan(&timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return localtime.LocationOrUTC(timezone)
+}
+
+func (h *UserHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *UserHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateProfileValidation(t *testing.T) {
+	handler := NewUserHandler(database.NewMock())
+
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{`{}`, `""`},
+		{`{"notificationPreferences": {}}`, `""`},
+		{`{"name": "   "}`, `"name"`},
+		{`{"avatar": "javascript:alert(1)"}`, `"avatar"`},
+		{`{"avatar": "/avatars/me.png"}`, `"avatar"`},
+		{`{"phone": "` + strings.Repeat("1", 21) + `"}`, `"phone"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/me", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+
+		w := httptest.NewRecorder()
+		handler.UpdateProfile(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.body, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), `"field":`+tt.field) {
+			t.Errorf("%s: expected an error on %s, got %s", tt.body, tt.field, w.Body.String())
+		}
+	}
+}
+
+func TestIsWebURL(t *testing.T) {
+	for value, want := range map[string]bool{
+		"https://api.dicebear.com/7.x/initials/svg?seed=Ada": true,
+		"http://example.com/a.png":                           true,
+		"ftp://example.com/a.png":                            false,
+		"https://":                                           false,
+		"not a url":                                          false,
+	} {
+		if got := isWebURL(value); got != want {
+			t.Errorf("isWebURL(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the validate struct tags on request models. Fields are
+// reported by their JSON names so clients can match errors to their inputs.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// decodeAndValidate is decodeJSON followed by validateRequest
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, v interface{}) *apierror.Error {
+	if derr := decodeJSON(w, r, v); derr != nil {
+		return derr
+	}
+	return validateRequest(v)
+}
+
+// validateRequest checks the validate tags of a decoded request. Every failing
+// field is listed in the details by its JSON path (e.g. "item.name"), and the
+// message describes the first one.
+func validateRequest(v interface{}) *apierror.Error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return apierror.Validation("Invalid request", models.InvalidField("", "invalid", "Request is invalid"))
+	}
+
+	errs := make([]models.FieldError, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		errs = append(errs, models.FieldError{Field: fieldPath(fe), Code: fe.Tag(), Message: fieldMessage(fe)})
+	}
+
+	return apierror.Validation(errs[0].Field+" "+errs[0].Message, models.FieldErrorDetails(errs...))
+}
+
+// invalidField is a VALIDATION_ERROR for one input of a decoded request, for
+// checks that validate tags cannot express
+func invalidField(field, code, fieldMessage, message string) *apierror.Error {
+	return apierror.Validation(message, models.InvalidField(field, code, fieldMessage))
+}
+
+// fieldPath drops the struct name from the namespace: "CreateEventItemRequest.item.name" becomes "item.name"
+func fieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+// dateLayouts names the Go layouts used in datetime tags the way clients write them
+var dateLayouts = map[string]string{
+	timeutil.DateLayout: "YYYY-MM-DD",
+	timeutil.TimeLayout: "HH:MM",
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		// The parameter is the Go field name; JSON names start lowercase and spell ID as Id
+		param := fe.Param()
+		if strings.HasSuffix(param, "ID") {
+			param = strings.TrimSuffix(param, "ID") + "Id"
+		}
+		return "is required unless " + strings.ToLower(param[:1]) + param[1:] + " is given"
+	case "required_if":
+		// The parameter is the Go field name and the value that makes this field required
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return "is required when " + strings.ToLower(field[:1]) + field[1:] + " is " + value
+	case "email":
+		return "must be a valid email address"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "datetime":
+		if layout, ok := dateLayouts[fe.Param()]; ok {
+			return "must be in the format " + layout
+		}
+		return "must be in the format " + fe.Param()
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters", bound, fe.Param())
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if fe.Param() == "1" {
+				return fmt.Sprintf("must have %s 1 item", bound)
+			}
+			return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+		default:
+			return fmt.Sprintf("must be %s %s", bound, fe.Param())
+		}
+	}
+	return "is invalid"
+}
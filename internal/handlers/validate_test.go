@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDecodeAndValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		v       interface{}
+		fields  map[string]string
+		message string
+	}{
+		{
+			name: "valid event",
+			body: `{"title":"Book Night","date":"2030-01-15","time":"19:30","location":"Library","type":"discussion"}`,
+			v:    &models.CreateEventRequest{},
+		},
+		{
+			name: "invalid event",
+			body: `{"title":"` + strings.Repeat("a", 101) + `","date":"15/01/2030","time":"7pm","location":"Library","type":"party"}`,
+			v:    &models.CreateEventRequest{},
+			fields: map[string]string{
+				"title": "must be at most 100 characters",
+				"date":  "must be in the format YYYY-MM-DD",
+				"time":  "must be in the format HH:MM",
+			},
+			message: "title must be at most 100 characters",
+		},
+		{
+			name: "event starting at an ISO 8601 time",
+			body: `{"title":"Book Night","startsAt":"2030-01-15T19:30:00+01:00","location":"Library","type":"discussion"}`,
+			v:    &models.CreateEventRequest{},
+		},
+		{
+			name:    "event without a start",
+			body:    `{"title":"Book Night","location":"Library","type":"discussion"}`,
+			v:       &models.CreateEventRequest{},
+			fields:  map[string]string{"date": "is required unless startsAt is given", "time": "is required unless startsAt is given"},
+			message: "date is required unless startsAt is given",
+		},
+		{
+			name:    "event without a type or template",
+			body:    `{"title":"Book Night","date":"2030-01-15","time":"19:30","location":"Library"}`,
+			v:       &models.CreateEventRequest{},
+			fields:  map[string]string{"type": "is required unless templateId is given"},
+			message: "type is required unless templateId is given",
+		},
+		{
+			name:    "attendance correction without an event",
+			body:    `{"kind":"attendance","attended":true,"reason":"I was there"}`,
+			v:       &models.CorrectionRequest{},
+			fields:  map[string]string{"eventId": "is required when kind is attendance"},
+			message: "eventId is required when kind is attendance",
+		},
+		{
+			name:    "nested fields use JSON paths",
+			body:    `{"item":{"category":"food","unit":"` + strings.Repeat("g", 21) + `"}}`,
+			v:       &models.CreateEventItemRequest{},
+			fields:  map[string]string{"item.name": "is required", "item.unit": "must be at most 20 characters"},
+			message: "item.name is required",
+		},
+		{
+			name:    "login",
+			body:    `{"email":"not-an-email"}`,
+			v:       &models.LoginRequest{},
+			fields:  map[string]string{"email": "must be a valid email address", "password": "is required"},
+			message: "email must be a valid email address",
+		},
+		{
+			name:   "empty slice",
+			body:   `{"label":"Setup crew","itemIds":[]}`,
+			v:      &models.CreateHelperLinkRequest{},
+			fields: map[string]string{"itemIds": "must have at least 1 item"},
+		},
+		{
+			name:   "optional pointer",
+			body:   `{"name":"Book Blog","quotaPerMinute":0}`,
+			v:      &models.CreatePublisherRequest{},
+			fields: map[string]string{"quotaPerMinute": "must be at least 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			err := decodeAndValidate(httptest.NewRecorder(), req, tt.v)
+
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %+v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected a validation error")
+			}
+			if err.Status != http.StatusBadRequest || err.Code != "VALIDATION_ERROR" {
+				t.Errorf("Expected 400 VALIDATION_ERROR, got %d %s", err.Status, err.Code)
+			}
+			if tt.message != "" && err.Message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, err.Message)
+			}
+
+			errs, _ := err.Details["errors"].([]models.FieldError)
+			if len(errs) != len(tt.fields) {
+				t.Errorf("Expected fields %v, got %v", tt.fields, errs)
+			}
+			for _, fe := range errs {
+				if message, ok := tt.fields[fe.Field]; !ok || fe.Message != message {
+					t.Errorf("Field %s: expected %q, got %q", fe.Field, message, fe.Message)
+				}
+				if fe.Code == "" {
+					t.Errorf("Field %s: expected a code", fe.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginValidationDetails(t *testing.T) {
+	handler, _ := setupAuthTest(t)
+
+	req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"email":"","password":"secret"}`))
+	w := httptest.NewRecorder()
+	handler.Login(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	var response struct {
+		Details struct {
+			Errors []models.FieldError `json:"errors"`
+		} `json:"details"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := models.FieldError{Field: "email", Code: "required", Message: "is required"}
+	if errs := response.Details.Errors; len(errs) != 1 || errs[0] != want {
+		t.Errorf("Expected email to be reported as required, got %+v", errs)
+	}
+}
+
+func TestDecodeErrorDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want models.FieldError
+	}{
+		{"empty body", ``, models.FieldError{Field: "", Code: "required", Message: "Request body is required"}},
+		{"malformed", `{"email":`, models.FieldError{Field: "", Code: "json", Message: "Request body must be valid JSON"}},
+		{"wrong type", `{"email":42}`, models.FieldError{Field: "email", Code: "type", Message: "must be a string"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req models.LoginRequest
+			err := decodeJSON(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(tt.body)), &req)
+			if err == nil {
+				t.Fatal("Expected a decode error")
+			}
+			errs, _ := err.Details["errors"].([]models.FieldError)
+			if len(errs) != 1 || errs[0] != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, errs)
+			}
+		})
+	}
+}
+
+func TestInvalidIDDetails(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/api/admin/publishers/not-a-uuid", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("publisherId", "not-a-uuid")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	NewPublisherHandler(nil).RevokePublisher(w, req)
+
+	var response struct {
+		Details struct {
+			Errors []models.FieldError `json:"errors"`
+		} `json:"details"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := models.FieldError{Field: "publisherId", Code: "uuid", Message: "must be a valid ID"}
+	if w.Code != http.StatusBadRequest || len(response.Details.Errors) != 1 || response.Details.Errors[0] != want {
+		t.Errorf("Expected 400 with %+v, got %d %+v", want, w.Code, response.Details.Errors)
+	}
+}
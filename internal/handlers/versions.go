@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bookwork-api/internal/apierror"
+
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ifMatchVersion reads the version an edit is based on from If-Match, which
+// holds the ETag the resource was served with. Without the header, or with
+// "*", the edit applies to whatever version is current.
+func ifMatchVersion(r *http.Request) (*int, *apierror.Error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag, quoted := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+	version, err := strconv.Atoi(tag)
+	if !quoted || !closed || err != nil || version < 1 {
+		return nil, invalidField("If-Match", "etag", `must be the ETag the resource was served with, such as "3"`, "Invalid If-Match header")
+	}
+	return &version, nil
+}
+
+// versionETag is the strong entity tag of a resource version
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// versionConflict refuses an edit based on an outdated version. The details
+// carry the current resource, so the client can show what changed.
+func versionConflict(current interface{}, version int) *apierror.Error {
+	return apierror.New(apierror.CodeVersionConflict, "Someone else changed this in the meantime", map[string]interface{}{"current": current, "version": version})
+}
+
+// versionMismatch reports whether an edit based on ifVersion must be refused
+// for a resource now at version
+func versionMismatch(ifVersion *int, version int) bool {
+	return ifVersion != nil && *ifVersion != version
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/vocab"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// vocabularyReader is the part of vocab.Store that validates event types and
+// item categories
+type vocabularyReader interface {
+	List(ctx context.Context, clubID uuid.UUID, kind vocab.Kind) (vocab.Vocabulary, error)
+}
+
+// clubVocabulary is the part of vocab.Store the vocabulary handler uses
+type clubVocabulary interface {
+	vocabularyReader
+	Add(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, term vocab.Term) (vocab.Vocabulary, error)
+	Relabel(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, value, label string) (vocab.Vocabulary, error)
+	Remove(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, value string) (vocab.Vocabulary, error)
+	Reset(ctx context.Context, clubID uuid.UUID, kind vocab.Kind) (vocab.Vocabulary, error)
+}
+
+// checkTerm rejects value unless it is in the club's vocabulary of kind.
+// Without a vocabulary store the defaults apply.
+func checkTerm(ctx context.Context, vocabulary vocabularyReader, clubID uuid.UUID, kind vocab.Kind, field, value string) (*apierror.Error, error) {
+	v := vocab.Defaults(kind)
+	if vocabulary != nil {
+		var err error
+		if v, err = vocabulary.List(ctx, clubID, kind); err != nil {
+			return nil, err
+		}
+	}
+	if v.Has(value) {
+		return nil, nil
+	}
+
+	allowed := "must be one of: " + strings.Join(v.Values(), ", ")
+	return invalidField(field, "oneof", allowed, field+" "+allowed), nil
+}
+
+// VocabularyHandler lets club managers change the event types and item
+// categories their club picks from
+type VocabularyHandler struct {
+	clocked
+
+	vocabulary clubVocabulary
+}
+
+func NewVocabularyHandler(vocabulary clubVocabulary) *VocabularyHandler {
+	return &VocabularyHandler{vocabulary: vocabulary}
+}
+
+// GetVocabularies returns the club's event types and item categories
+func (h *VocabularyHandler) GetVocabularies(w http.ResponseWriter, r *http.Request) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return
+	}
+
+	response := map[string]interface{}{}
+	for key, kind := range map[string]vocab.Kind{"eventTypes": vocab.EventType, "itemCategories": vocab.ItemCategory} {
+		v, err := h.vocabulary.List(r.Context(), clubID, kind)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error querying vocabularies", "error", err)
+			h.writeError(w, apierror.Internal("Failed to get vocabularies"))
+			return
+		}
+		response[key] = v
+	}
+
+	h.writeSuccessResponse(w, response, "Vocabularies retrieved successfully")
+}
+
+// AddTerm appends an event type or item category
+func (h *VocabularyHandler) AddTerm(w http.ResponseWriter, r *http.Request) {
+	clubID, kind, ok := h.clubKind(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.VocabularyTermRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	value := vocab.NormalizeValue(req.Value)
+	if value == "" {
+		h.writeError(w, apierror.Validation("value must be letters, digits and underscores", models.InvalidField("value", "format", "must be letters, digits and underscores")))
+		return
+	}
+
+	v, err := h.vocabulary.Add(r.Context(), clubID, kind, vocab.Term{Value: value, Label: vocab.Label(value, req.Label)})
+	if err != nil {
+		h.writeVocabularyError(w, r, err, "Failed to add vocabulary term")
+		return
+	}
+	audit.Describe(r.Context(), "club_vocabulary", clubID.String(), audit.Changes{string(kind): {To: value}})
+
+	h.writeResponse(w, http.StatusCreated, map[string]interface{}{"vocabulary": v}, "Vocabulary term added successfully")
+}
+
+// RelabelTerm changes the label of an event type or item category
+func (h *VocabularyHandler) RelabelTerm(w http.ResponseWriter, r *http.Request) {
+	clubID, kind, ok := h.clubKind(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.VocabularyLabelRequest
+	if err := decodeAndValidate(w, r, &req); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	value := chi.URLParam(r, "value")
+	label := vocab.Label(value, req.Label)
+
+	v, err := h.vocabulary.Relabel(r.Context(), clubID, kind, value, label)
+	if err != nil {
+		h.writeVocabularyError(w, r, err, "Failed to relabel vocabulary term")
+		return
+	}
+	audit.Describe(r.Context(), "club_vocabulary", clubID.String(), audit.Changes{string(kind) + "." + value: {To: label}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"vocabulary": v}, "Vocabulary term relabeled successfully")
+}
+
+// RemoveTerm takes an event type or item category out of the club's list.
+// Events and items already using it keep it.
+func (h *VocabularyHandler) RemoveTerm(w http.ResponseWriter, r *http.Request) {
+	clubID, kind, ok := h.clubKind(w, r)
+	if !ok {
+		return
+	}
+	value := chi.URLParam(r, "value")
+
+	v, err := h.vocabulary.Remove(r.Context(), clubID, kind, value)
+	if err != nil {
+		h.writeVocabularyError(w, r, err, "Failed to remove vocabulary term")
+		return
+	}
+	audit.Describe(r.Context(), "club_vocabulary", clubID.String(), audit.Changes{string(kind): {From: value}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"vocabulary": v}, "Vocabulary term removed successfully")
+}
+
+// ResetVocabulary returns the club to the default event types or item categories
+func (h *VocabularyHandler) ResetVocabulary(w http.ResponseWriter, r *http.Request) {
+	clubID, kind, ok := h.clubKind(w, r)
+	if !ok {
+		return
+	}
+
+	v, err := h.vocabulary.Reset(r.Context(), clubID, kind)
+	if err != nil {
+		h.writeVocabularyError(w, r, err, "Failed to reset vocabulary")
+		return
+	}
+	audit.Describe(r.Context(), "club_vocabulary", clubID.String(), audit.Changes{string(kind): {To: "defaults"}})
+
+	h.writeSuccessResponse(w, map[string]interface{}{"vocabulary": v}, "Vocabulary reset successfully")
+}
+
+func (h *VocabularyHandler) clubID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, false
+	}
+	return clubID, true
+}
+
+func (h *VocabularyHandler) clubKind(w http.ResponseWriter, r *http.Request) (uuid.UUID, vocab.Kind, bool) {
+	clubID, ok := h.clubID(w, r)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	kind, ok := vocab.ParseKind(chi.URLParam(r, "kind"))
+	if !ok {
+		h.writeError(w, apierror.NotFound("Vocabulary not found; use event-types or item-categories"))
+		return uuid.Nil, "", false
+	}
+	return clubID, kind, true
+}
+
+func (h *VocabularyHandler) writeVocabularyError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, vocab.ErrNotFound):
+		h.writeError(w, apierror.NotFound("Vocabulary term not found"))
+	case errors.Is(err, vocab.ErrTaken):
+		h.writeError(w, apierror.New(apierror.CodeTermTaken, "The value is already in the vocabulary", nil))
+	case errors.Is(err, vocab.ErrFull):
+		h.writeError(w, apierror.New(apierror.CodeVocabularyFull, "A vocabulary can have at most 30 terms", nil))
+	case errors.Is(err, vocab.ErrLastTerm):
+		h.writeError(w, apierror.New(apierror.CodeLastTerm, "A vocabulary needs at least one term", nil))
+	default:
+		logging.FromContext(r.Context()).Error("error changing vocabulary", "error", err)
+		h.writeError(w, apierror.Internal(message))
+	}
+}
+
+func (h *VocabularyHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *VocabularyHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *VocabularyHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/vocab"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// fakeVocabulary keeps vocabularies by kind for a single club
+type fakeVocabulary struct {
+	lists map[vocab.Kind]vocab.Vocabulary
+}
+
+func (f *fakeVocabulary) List(ctx context.Context, clubID uuid.UUID, kind vocab.Kind) (vocab.Vocabulary, error) {
+	if v, ok := f.lists[kind]; ok {
+		return v, nil
+	}
+	return vocab.Defaults(kind), nil
+}
+
+func (f *fakeVocabulary) Add(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, term vocab.Term) (vocab.Vocabulary, error) {
+	v, _ := f.List(ctx, clubID, kind)
+	if v.Has(term.Value) {
+		return vocab.Vocabulary{}, vocab.ErrTaken
+	}
+	v.Terms, v.Custom = append(v.Terms, term), true
+	f.lists[kind] = v
+	return v, nil
+}
+
+func (f *fakeVocabulary) Relabel(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, value, label string) (vocab.Vocabulary, error) {
+	v, _ := f.List(ctx, clubID, kind)
+	for i := range v.Terms {
+		if v.Terms[i].Value == value {
+			v.Terms[i].Label, v.Custom = label, true
+			f.lists[kind] = v
+			return v, nil
+		}
+	}
+	return vocab.Vocabulary{}, vocab.ErrNotFound
+}
+
+func (f *fakeVocabulary) Remove(ctx context.Context, clubID uuid.UUID, kind vocab.Kind, value string) (vocab.Vocabulary, error) {
+	v, _ := f.List(ctx, clubID, kind)
+	if !v.Has(value) {
+		return vocab.Vocabulary{}, vocab.ErrNotFound
+	}
+	if len(v.Terms) == 1 {
+		return vocab.Vocabulary{}, vocab.ErrLastTerm
+	}
+	terms := []vocab.Term{}
+	for _, term := range v.Terms {
+		if term.Value != value {
+			terms = append(terms, term)
+		}
+	}
+	v.Terms, v.Custom = terms, true
+	f.lists[kind] = v
+	return v, nil
+}
+
+func (f *fakeVocabulary) Reset(ctx context.Context, clubID uuid.UUID, kind vocab.Kind) (vocab.Vocabulary, error) {
+	delete(f.lists, kind)
+	return vocab.Defaults(kind), nil
+}
+
+func setupVocabularyTest() (*fakeVocabulary, chi.Router) {
+	vocabulary := &fakeVocabulary{lists: map[vocab.Kind]vocab.Vocabulary{}}
+	handler := NewVocabularyHandler(vocabulary)
+
+	router := chi.NewRouter()
+	router.Get("/club/{clubId}/vocabularies", handler.GetVocabularies)
+	router.Post("/club/{clubId}/vocabularies/{kind}", handler.AddTerm)
+	router.Delete("/club/{clubId}/vocabularies/{kind}", handler.ResetVocabulary)
+	router.Put("/club/{clubId}/vocabularies/{kind}/{value}", handler.RelabelTerm)
+	router.Delete("/club/{clubId}/vocabularies/{kind}/{value}", handler.RemoveTerm)
+	return vocabulary, router
+}
+
+func serveVocabulary(router chi.Router, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+	return w
+}
+
+func TestGetVocabulariesDefaults(t *testing.T) {
+	_, router := setupVocabularyTest()
+
+	w := serveVocabulary(router, "GET", "/club/"+uuid.NewString()+"/vocabularies", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var response struct {
+		Data struct {
+			EventTypes     vocab.Vocabulary `json:"eventTypes"`
+			ItemCategories vocab.Vocabulary `json:"itemCategories"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.EventTypes.Custom || !response.Data.EventTypes.Has("discussion") || !response.Data.ItemCategories.Has("food") {
+		t.Errorf("Expected the default vocabularies, got %+v", response.Data)
+	}
+}
+
+func TestChangeVocabulary(t *testing.T) {
+	vocabulary, router := setupVocabularyTest()
+	base := "/club/" + uuid.NewString() + "/vocabularies/"
+
+	tests := []struct {
+		method, path, body string
+		expected           int
+	}{
+		{"POST", base + "colours", `{"value": "red"}`, http.StatusNotFound},
+		{"POST", base + "event-types", `{"value": "Q&A"}`, http.StatusBadRequest},
+		{"POST", base + "event-types", `{"value": "  Book Swap "}`, http.StatusCreated},
+		{"POST", base + "event-types", `{"value": "book-swap"}`, http.StatusConflict},
+		{"PUT", base + "event-types/book_swap", `{"label": "Swap night"}`, http.StatusOK},
+		{"PUT", base + "event-types/party", `{"label": "Party"}`, http.StatusNotFound},
+		{"DELETE", base + "item-categories/food", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serveVocabulary(router, tt.method, tt.path, tt.body); w.Code != tt.expected {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.path, tt.body, tt.expected, w.Code)
+		}
+	}
+
+	eventTypes := vocabulary.lists[vocab.EventType]
+	last := eventTypes.Terms[len(eventTypes.Terms)-1]
+	if last != (vocab.Term{Value: "book_swap", Label: "Swap night"}) {
+		t.Errorf("Expected the normalized, relabeled term, got %+v", last)
+	}
+	if vocabulary.lists[vocab.ItemCategory].Has("food") {
+		t.Error("Expected food to be removed")
+	}
+
+	if w := serveVocabulary(router, "DELETE", base+"event-types", ""); w.Code != http.StatusOK || vocabulary.lists[vocab.EventType].Custom {
+		t.Errorf("Expected the reset to restore the defaults, got %d", w.Code)
+	}
+}
+
+func TestCheckTerm(t *testing.T) {
+	ctx := context.Background()
+
+	if derr, err := checkTerm(ctx, nil, uuid.New(), vocab.EventType, "type", "social"); derr != nil || err != nil {
+		t.Errorf("Expected a default type to pass without a store, got %v %v", derr, err)
+	}
+
+	vocabulary := &fakeVocabulary{lists: map[vocab.Kind]vocab.Vocabulary{
+		vocab.EventType: {Kind: vocab.EventType, Terms: []vocab.Term{{Value: "book_swap", Label: "Book swap"}}, Custom: true},
+	}}
+	derr, err := checkTerm(ctx, vocabulary, uuid.New(), vocab.EventType, "type", "social")
+	if err != nil || derr == nil || derr.Message != "type must be one of: book_swap" {
+		t.Errorf("Expected a type outside the club's list to be rejected, got %+v %v", derr, err)
+	}
+}
+
+func TestCreateEventRejectsUnknownType(t *testing.T) {
+	vocabulary := &fakeVocabulary{lists: map[vocab.Kind]vocab.Vocabulary{
+		vocab.EventType: {Kind: vocab.EventType, Terms: []vocab.Term{{Value: "book_swap", Label: "Book swap"}}, Custom: true},
+	}}
+	handler := NewEventHandler(database.NewMock()).WithVocabulary(vocabulary)
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/events", handler.CreateEvent)
+
+	body := `{"title":"Book Night","date":"2030-01-15","time":"19:30","location":"Library","type":"discussion"}`
+	req := httptest.NewRequest("POST", "/club/"+uuid.NewString()+"/events", bytes.NewBufferString(body))
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest || !bytes.Contains(w.Body.Bytes(), []byte("book_swap")) {
+		t.Errorf("Expected the club's types to be required, got %d %s", w.Code, w.Body.String())
+	}
+}
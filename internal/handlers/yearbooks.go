@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/audit"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/signedurl"
+	"bookwork-api/internal/yearbook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// firstYearbookYear is the earliest year a yearbook can be asked for
+const firstYearbookYear = 2000
+
+// yearbookStore is the part of yearbook.Store the yearbook handler uses
+type yearbookStore interface {
+	Request(ctx context.Context, clubID uuid.UUID, year int, requestedBy uuid.UUID) (yearbook.Job, error)
+	Get(ctx context.Context, clubID uuid.UUID, year int) (yearbook.Job, error)
+	GetByID(ctx context.Context, id uuid.UUID) (yearbook.Job, error)
+}
+
+// YearbookHandler queues club yearbooks and serves them once compiled. The
+// report is returned as JSON with its status; the PDF is downloaded through a
+// signed link, which is also sent to the requester when the yearbook is ready.
+type YearbookHandler struct {
+	clocked
+
+	yearbooks yearbookStore
+	storage   attachments.Storage
+	links     yearbook.Links
+	compiler  interface{ Wake() } // nil when no compiler runs on this instance
+}
+
+func NewYearbookHandler(yearbooks yearbookStore, storage attachments.Storage, links yearbook.Links) *YearbookHandler {
+	return &YearbookHandler{yearbooks: yearbooks, storage: storage, links: links}
+}
+
+// WithCompiler starts compiling a requested yearbook at once instead of at the
+// compiler's next check
+func (h *YearbookHandler) WithCompiler(compiler interface{ Wake() }) *YearbookHandler {
+	h.compiler = compiler
+	return h
+}
+
+// RequestYearbook queues the club's yearbook for a year, compiling it afresh
+// if it was compiled before
+func (h *YearbookHandler) RequestYearbook(w http.ResponseWriter, r *http.Request) {
+	clubID, year, ok := h.clubYear(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(r.Context())
+	if err != nil {
+		h.writeError(w, apierror.Unauthorized("User not found in context"))
+		return
+	}
+
+	job, err := h.yearbooks.Request(r.Context(), clubID, year, userID)
+	if err != nil {
+		if verr := violationError(r.Context(), err); verr != nil {
+			h.writeError(w, verr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("error queuing yearbook", "error", err)
+		h.writeError(w, apierror.Internal("Failed to request yearbook"))
+		return
+	}
+	audit.Describe(r.Context(), "club_yearbook", job.ID.String(), audit.Changes{"year": {To: year}})
+	if h.compiler != nil {
+		h.compiler.Wake()
+	}
+
+	h.writeResponse(w, http.StatusAccepted, map[string]interface{}{"yearbook": job}, "Yearbook requested; you will be notified when it is ready")
+}
+
+// GetYearbook returns the status of the club's yearbook for a year and, once
+// it is ready, the report and a download link for the PDF
+func (h *YearbookHandler) GetYearbook(w http.ResponseWriter, r *http.Request) {
+	clubID, year, ok := h.clubYear(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.yearbooks.Get(r.Context(), clubID, year)
+	if err != nil {
+		if errors.Is(err, yearbook.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("No yearbook has been requested for this year"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error getting yearbook", "error", err)
+		h.writeError(w, apierror.Internal("Failed to get yearbook"))
+		return
+	}
+	if job.Status == yearbook.StatusReady {
+		h.links.Sign(&job, h.now())
+	}
+
+	h.writeSuccessResponse(w, map[string]interface{}{"yearbook": job}, "Yearbook retrieved successfully")
+}
+
+// Download serves a yearbook PDF to anyone holding a valid signed link
+func (h *YearbookHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id, err := h.links.Verify(chi.URLParam(r, "token"), h.now())
+	if err != nil {
+		if err == signedurl.ErrExpired {
+			h.writeError(w, apierror.New(apierror.CodeLinkExpired, "This download link has expired", nil))
+			return
+		}
+		h.writeError(w, apierror.NotFound("Yearbook not found"))
+		return
+	}
+
+	job, err := h.yearbooks.GetByID(r.Context(), id)
+	if err == nil && (job.Status != yearbook.StatusReady || job.StorageKey == "") {
+		err = yearbook.ErrNotFound
+	}
+	var body io.ReadCloser
+	if err == nil {
+		body, err = h.storage.Get(r.Context(), job.StorageKey)
+	}
+	if err != nil {
+		if errors.Is(err, yearbook.ErrNotFound) || errors.Is(err, attachments.ErrNotFound) {
+			h.writeError(w, apierror.NotFound("Yearbook not found"))
+			return
+		}
+		logging.FromContext(r.Context()).Error("error reading yearbook", "error", err)
+		h.writeError(w, apierror.Internal("Failed to download yearbook"))
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "yearbook-" + strconv.Itoa(job.Year) + ".pdf"}))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+func (h *YearbookHandler) clubYear(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, bool) {
+	clubID, err := uuid.Parse(chi.URLParam(r, "clubId"))
+	if err != nil {
+		h.writeError(w, apierror.Validation("Invalid club ID", models.InvalidID("clubId")))
+		return uuid.Nil, 0, false
+	}
+
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if current := h.now().Year(); err != nil || year < firstYearbookYear || year > current {
+		message := "must be a year from " + strconv.Itoa(firstYearbookYear) + " to " + strconv.Itoa(current)
+		h.writeError(w, apierror.Validation("year "+message, models.InvalidField("year", "range", message)))
+		return uuid.Nil, 0, false
+	}
+	return clubID, year, true
+}
+
+func (h *YearbookHandler) writeSuccessResponse(w http.ResponseWriter, data interface{}, message string) {
+	h.writeResponse(w, http.StatusOK, data, message)
+}
+
+func (h *YearbookHandler) writeResponse(w http.ResponseWriter, statusCode int, data interface{}, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := models.NewAPIResponse(true, data, message)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *YearbookHandler) writeError(w http.ResponseWriter, err *apierror.Error) {
+	apierror.Write(w, h.now(), err)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/auth"
+	"bookwork-api/internal/clock"
+	"bookwork-api/internal/signedurl"
+	"bookwork-api/internal/yearbook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type fakeYearbooks struct {
+	jobs      map[uuid.UUID]yearbook.Job
+	requested []int
+}
+
+func (f *fakeYearbooks) Request(ctx context.Context, clubID uuid.UUID, year int, requestedBy uuid.UUID) (yearbook.Job, error) {
+	f.requested = append(f.requested, year)
+	job := yearbook.Job{ID: uuid.New(), ClubID: clubID, Year: year, Status: yearbook.StatusPending, RequestedBy: &requestedBy}
+	f.jobs[job.ID] = job
+	return job, nil
+}
+
+func (f *fakeYearbooks) Get(ctx context.Context, clubID uuid.UUID, year int) (yearbook.Job, error) {
+	for _, job := range f.jobs {
+		if job.ClubID == clubID && job.Year == year {
+			return job, nil
+		}
+	}
+	return yearbook.Job{}, yearbook.ErrNotFound
+}
+
+func (f *fakeYearbooks) GetByID(ctx context.Context, id uuid.UUID) (yearbook.Job, error) {
+	if job, ok := f.jobs[id]; ok {
+		return job, nil
+	}
+	return yearbook.Job{}, yearbook.ErrNotFound
+}
+
+type wakeCounter int
+
+func (w *wakeCounter) Wake() { *w++ }
+
+func setupYearbookTest(t *testing.T) (*YearbookHandler, *fakeYearbooks, attachments.Storage, chi.Router) {
+	storage, err := attachments.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	store := &fakeYearbooks{jobs: map[uuid.UUID]yearbook.Job{}}
+	links := yearbook.NewLinks(signedurl.NewSigner("test-secret", "yearbook-download"), time.Hour)
+	handler := NewYearbookHandler(store, storage, links)
+	handler.clock = clock.NewFake(time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+
+	router := chi.NewRouter()
+	router.Post("/club/{clubId}/yearbooks/{year}", handler.RequestYearbook)
+	router.Get("/club/{clubId}/yearbooks/{year}", handler.GetYearbook)
+	router.Get("/yearbooks/{token}", handler.Download)
+	return handler, store, storage, router
+}
+
+func TestRequestYearbook(t *testing.T) {
+	handler, store, _, router := setupYearbookTest(t)
+	var wakes wakeCounter
+	handler.WithCompiler(&wakes)
+	clubID := uuid.New()
+
+	for _, year := range []string{"1999", "2027", "last"} {
+		req := httptest.NewRequest(http.MethodPost, "/club/"+clubID.String()+"/yearbooks/"+year, nil)
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected year %s to be rejected, got %d", year, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/club/"+clubID.String()+"/yearbooks/2025", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: uuid.New()}))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(store.requested) != 1 || store.requested[0] != 2025 || wakes != 1 {
+		t.Errorf("Expected 2025 to be queued and the compiler woken, got %v and %d wakes", store.requested, wakes)
+	}
+}
+
+func TestGetAndDownloadYearbook(t *testing.T) {
+	_, store, storage, router := setupYearbookTest(t)
+	clubID := uuid.New()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/yearbooks/2025", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before a yearbook is requested, got %d", w.Code)
+	}
+
+	pending := yearbook.Job{ID: uuid.New(), ClubID: clubID, Year: 2024, Status: yearbook.StatusPending}
+	ready := yearbook.Job{ID: uuid.New(), ClubID: clubID, Year: 2025, Status: yearbook.StatusReady, StorageKey: "yearbooks/club/2025.pdf"}
+	store.jobs[pending.ID], store.jobs[ready.ID] = pending, ready
+	storage.Put(context.Background(), ready.StorageKey, []byte("%PDF-1.3 yearbook"), "application/pdf")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/yearbooks/2024", nil))
+	if strings.Contains(w.Body.String(), "downloadUrl") {
+		t.Errorf("Expected no download link while pending, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/club/"+clubID.String()+"/yearbooks/2025", nil))
+	var response struct {
+		Data struct {
+			Yearbook yearbook.Job `json:"yearbook"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Data.Yearbook.DownloadURL == "" {
+		t.Fatalf("Expected a download link once ready, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(response.Data.Yearbook.DownloadURL, "/api"), nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || w.Body.String() != "%PDF-1.3 yearbook" {
+		t.Errorf("Expected the PDF, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Links to yearbooks that are not ready, and forged links, find nothing
+	links := yearbook.NewLinks(signedurl.NewSigner("test-secret", "yearbook-download"), time.Hour)
+	links.Sign(&pending, time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	for _, url := range []string{pending.DownloadURL, "/api/yearbooks/forged.token"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(url, "/api"), nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package health answers the orchestrator's liveness and readiness probes.
+//
+// /livez only says the process is up and serving: it touches nothing, so a
+// slow database never gets a healthy instance restarted. /readyz runs the
+// registered checks, such as the database being reachable and every migration
+// applied, and fails while any of them does or once shutdown has started, so
+// traffic is only routed to instances that can serve it. Check results are
+// cached briefly so frequent probes do not each hit the database.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe paths, answered ahead of the API's routes
+const (
+	LivePath  = "/livez"
+	ReadyPath = "/readyz"
+)
+
+// checkTimeout bounds each check, so a hung dependency fails the probe rather
+// than holding it past the orchestrator's own timeout
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable; a nil error means it is
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status     string `json:"status"` // ok or failed
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report is the readiness response
+type Report struct {
+	Status    string            `json:"status"` // ready or not_ready
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// Checker runs the readiness checks and caches the report for ttl
+type Checker struct {
+	checks   []Check
+	ttl      time.Duration
+	now      func() time.Time
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewChecker creates a checker running checks at most once per ttl
+func NewChecker(ttl time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, ttl: ttl, now: time.Now}
+}
+
+// Drain makes readiness fail from now on, so the instance is taken out of
+// rotation while it finishes its in-flight requests. It fits the signature of
+// lifecycle shutdown hooks.
+func (c *Checker) Drain(ctx context.Context) error {
+	c.draining.Store(true)
+	return nil
+}
+
+// Middleware answers the probe paths and passes every other request on. It
+// runs ahead of logging and rate limiting, which frequent probes would flood
+// and trip.
+func (c *Checker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			switch r.URL.Path {
+			case LivePath:
+				c.Live(w, r)
+				return
+			case ReadyPath:
+				c.Ready(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Live reports that the process is serving requests
+func (c *Checker) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Ready reports whether every check passes, with the result of each
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// Check returns the readiness report, running the checks again if the cached
+// one is older than ttl
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report == nil || c.now().Sub(c.report.CheckedAt) >= c.ttl {
+		c.report = c.run(ctx)
+	}
+
+	report := *c.report
+	if c.draining.Load() {
+		report.Status = "not_ready"
+		report.Checks = make(map[string]Result, len(c.report.Checks)+1)
+		for name, result := range c.report.Checks {
+			report.Checks[name] = result
+		}
+		report.Checks["shutdown"] = Result{Status: "failed", Error: "server is shutting down"}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context) *Report {
+	report := &Report{Status: "ready", Checks: make(map[string]Result, len(c.checks)), CheckedAt: c.now()}
+	for _, check := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		start := time.Now()
+		err := check.Run(checkCtx)
+		cancel()
+
+		result := Result{Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Status, result.Error = "failed", err.Error()
+			report.Status = "not_ready"
+		}
+		report.Checks[check.Name] = result
+	}
+	return report
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
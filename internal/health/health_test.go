@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyCachesChecks(t *testing.T) {
+	runs := 0
+	var failure error
+	checker := NewChecker(5*time.Second, Check{Name: "database", Run: func(ctx context.Context) error {
+		runs++
+		return failure
+	}})
+	now := time.Date(2030, 1, 15, 19, 30, 0, 0, time.UTC)
+	checker.now = func() time.Time { return now }
+
+	if report := checker.Check(context.Background()); report.Status != "ready" || report.Checks["database"].Status != "ok" {
+		t.Fatalf("Expected ready, got %+v", report)
+	}
+
+	failure = errors.New("database is unreachable")
+	checker.Check(context.Background())
+	if runs != 1 {
+		t.Errorf("Expected the cached report within the TTL, got %d runs", runs)
+	}
+
+	now = now.Add(5 * time.Second)
+	report := checker.Check(context.Background())
+	if runs != 2 || report.Status != "not_ready" || report.Checks["database"].Error != "database is unreachable" {
+		t.Errorf("Expected a fresh failing report after the TTL, got %d runs and %+v", runs, report)
+	}
+}
+
+func TestMiddlewareAnswersProbes(t *testing.T) {
+	checker := NewChecker(time.Minute, Check{Name: "migrations", Run: func(ctx context.Context) error { return nil }})
+	handler := checker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := serve(LivePath); w.Code != http.StatusOK {
+		t.Errorf("Expected live to answer 200, got %d", w.Code)
+	}
+	if w := serve("/api/clubs"); w.Code != http.StatusTeapot {
+		t.Errorf("Expected other paths to pass through, got %d", w.Code)
+	}
+	if w := serve(ReadyPath); w.Code != http.StatusOK {
+		t.Errorf("Expected ready to answer 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	checker.Drain(context.Background())
+	w := serve(ReadyPath)
+	var report Report
+	json.NewDecoder(w.Body).Decode(&report)
+	if w.Code != http.StatusServiceUnavailable || report.Status != "not_ready" || report.Checks["shutdown"].Status != "failed" {
+		t.Errorf("Expected readiness to fail while draining, got %d %+v", w.Code, report)
+	}
+	if report.Checks["migrations"].Status != "ok" {
+		t.Errorf("Expected the other checks to still be listed, got %+v", report.Checks)
+	}
+	if w := serve(LivePath); w.Code != http.StatusOK {
+		t.Errorf("Expected live to stay up while draining, got %d", w.Code)
+	}
+}
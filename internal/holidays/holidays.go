@@ -0,0 +1,202 @@
+// Package holidays provides a built-in dataset of national public holidays
+// used to warn organizers about events scheduled on a holiday.
+//
+// Dates are the holidays themselves; substitute ("observed") days that some
+// countries add when a holiday falls on a weekend are not included.
+package holidays
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/timeutil"
+)
+
+// Holiday is a public holiday on a specific date
+type Holiday struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Name    string `json:"name"`
+	Country string `json:"country"`
+}
+
+// rule computes the date of a holiday in a given year
+type rule func(year int) time.Time
+
+type definition struct {
+	name string
+	date rule
+}
+
+// calendars maps ISO 3166-1 alpha-2 country codes to their national holidays
+var calendars = map[string][]definition{
+	"US": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Martin Luther King Jr. Day", nthWeekday(time.January, time.Monday, 3)},
+		{"Presidents' Day", nthWeekday(time.February, time.Monday, 3)},
+		{"Memorial Day", nthWeekday(time.May, time.Monday, -1)},
+		{"Juneteenth", fixed(time.June, 19)},
+		{"Independence Day", fixed(time.July, 4)},
+		{"Labor Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Columbus Day", nthWeekday(time.October, time.Monday, 2)},
+		{"Veterans Day", fixed(time.November, 11)},
+		{"Thanksgiving Day", nthWeekday(time.November, time.Thursday, 4)},
+		{"Christmas Day", fixed(time.December, 25)},
+	},
+	"GB": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Easter Monday", easter(1)},
+		{"Early May Bank Holiday", nthWeekday(time.May, time.Monday, 1)},
+		{"Spring Bank Holiday", nthWeekday(time.May, time.Monday, -1)},
+		{"Summer Bank Holiday", nthWeekday(time.August, time.Monday, -1)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	"CA": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Victoria Day", weekdayOnOrBefore(time.May, 24, time.Monday)},
+		{"Canada Day", fixed(time.July, 1)},
+		{"Labour Day", nthWeekday(time.September, time.Monday, 1)},
+		{"Thanksgiving", nthWeekday(time.October, time.Monday, 2)},
+		{"Remembrance Day", fixed(time.November, 11)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	"AU": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Australia Day", fixed(time.January, 26)},
+		{"Good Friday", easter(-2)},
+		{"Easter Monday", easter(1)},
+		{"Anzac Day", fixed(time.April, 25)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	"DE": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Easter Monday", easter(1)},
+		{"Labour Day", fixed(time.May, 1)},
+		{"Ascension Day", easter(39)},
+		{"Whit Monday", easter(50)},
+		{"German Unity Day", fixed(time.October, 3)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Second Day of Christmas", fixed(time.December, 26)},
+	},
+	"FR": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Easter Monday", easter(1)},
+		{"Labour Day", fixed(time.May, 1)},
+		{"Victory in Europe Day", fixed(time.May, 8)},
+		{"Ascension Day", easter(39)},
+		{"Whit Monday", easter(50)},
+		{"Bastille Day", fixed(time.July, 14)},
+		{"Assumption Day", fixed(time.August, 15)},
+		{"All Saints' Day", fixed(time.November, 1)},
+		{"Armistice Day", fixed(time.November, 11)},
+		{"Christmas Day", fixed(time.December, 25)},
+	},
+}
+
+// Supported reports whether the dataset covers the country
+func Supported(country string) bool {
+	_, ok := calendars[strings.ToUpper(country)]
+	return ok
+}
+
+// Countries lists the supported country codes in alphabetical order
+func Countries() []string {
+	countries := make([]string, 0, len(calendars))
+	for country := range calendars {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// Lookup returns the public holiday on the given date, if there is one
+func Lookup(country string, date time.Time) (Holiday, bool) {
+	country = strings.ToUpper(country)
+	day := timeutil.FormatDate(date)
+
+	for _, def := range calendars[country] {
+		if timeutil.FormatDate(def.date(date.Year())) == day {
+			return Holiday{Date: day, Name: def.name, Country: country}, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// ForYear lists a country's public holidays for the year in date order
+func ForYear(country string, year int) []Holiday {
+	country = strings.ToUpper(country)
+
+	var list []Holiday
+	for _, def := range calendars[country] {
+		list = append(list, Holiday{
+			Date:    timeutil.FormatDate(def.date(year)),
+			Name:    def.name,
+			Country: country,
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Date < list[j].Date })
+	return list
+}
+
+func fixed(month time.Month, day int) rule {
+	return func(year int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nthWeekday returns the nth weekday of the month; n = -1 means the last one
+func nthWeekday(month time.Month, weekday time.Weekday, n int) rule {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+			return last.AddDate(0, 0, -offset)
+		}
+
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, offset+7*(n-1))
+	}
+}
+
+// weekdayOnOrBefore returns the last given weekday on or before month/day
+func weekdayOnOrBefore(month time.Month, day int, weekday time.Weekday) rule {
+	return func(year int) time.Time {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		offset := (int(date.Weekday()) - int(weekday) + 7) % 7
+		return date.AddDate(0, 0, -offset)
+	}
+}
+
+// easter returns a holiday offset in days from (Western) Easter Sunday
+func easter(offset int) rule {
+	return func(year int) time.Time {
+		return easterSunday(year).AddDate(0, 0, offset)
+	}
+}
+
+// easterSunday uses the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,83 @@
+package holidays
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestEasterSunday(t *testing.T) {
+	expected := map[int]string{
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+	}
+
+	for year, want := range expected {
+		if got := easterSunday(year).Format("2006-01-02"); got != want {
+			t.Errorf("Easter %d: expected %s, got %s", year, want, got)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		country string
+		date    string
+		name    string
+	}{
+		{"US", "2024-11-28", "Thanksgiving Day"},
+		{"US", "2024-05-27", "Memorial Day"},
+		{"us", "2024-07-04", "Independence Day"},
+		{"GB", "2024-03-29", "Good Friday"},
+		{"GB", "2024-08-26", "Summer Bank Holiday"},
+		{"CA", "2024-05-20", "Victoria Day"},
+		{"DE", "2024-05-09", "Ascension Day"},
+		{"FR", "2024-07-14", "Bastille Day"},
+	}
+
+	for _, tt := range tests {
+		holiday, ok := Lookup(tt.country, date(tt.date))
+		if !ok {
+			t.Errorf("Expected %s to be a holiday in %s", tt.date, tt.country)
+			continue
+		}
+		if holiday.Name != tt.name {
+			t.Errorf("Expected %s on %s in %s, got %s", tt.name, tt.date, tt.country, holiday.Name)
+		}
+	}
+
+	if _, ok := Lookup("US", date("2024-11-27")); ok {
+		t.Error("Did not expect a holiday on 2024-11-27 in the US")
+	}
+	if _, ok := Lookup("ZZ", date("2024-01-01")); ok {
+		t.Error("Did not expect holidays for an unsupported country")
+	}
+}
+
+func TestForYear(t *testing.T) {
+	list := ForYear("GB", 2024)
+	if len(list) != 8 {
+		t.Fatalf("Expected 8 holidays, got %d", len(list))
+	}
+
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Date > list[i].Date {
+			t.Errorf("Holidays not sorted: %s before %s", list[i-1].Date, list[i].Date)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	if !Supported("us") || !Supported("GB") {
+		t.Error("Expected US and GB to be supported")
+	}
+	if Supported("ZZ") {
+		t.Error("Did not expect ZZ to be supported")
+	}
+}
@@ -0,0 +1,113 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxDescription bounds how much of an event's description is posted
+const maxDescription = 500
+
+// heading introduces an announcement of kind
+func heading(kind string) string {
+	switch kind {
+	case KindUpdated:
+		return "Event updated"
+	case KindReminder:
+		return "Reminder"
+	default:
+		return "New event"
+	}
+}
+
+// localTime is the start of the event as the club would write it
+func (a Announcement) localTime() string {
+	loc, err := time.LoadLocation(a.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return a.StartsAt.In(loc).Format("Mon 2 Jan 2006, 15:04 MST")
+}
+
+// description is the start of the event's description, if it has one
+func (a Announcement) description() string {
+	if a.Description == nil {
+		return ""
+	}
+	text := strings.TrimSpace(*a.Description)
+	if utf8.RuneCountInString(text) <= maxDescription {
+		return text
+	}
+	return string([]rune(text)[:maxDescription-1]) + "…"
+}
+
+// slackEscaper escapes the characters Slack's mrkdwn treats as markup
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackMessage is an announcement as a Slack incoming webhook payload. Times
+// use Slack's date formatting, shown in each reader's own timezone.
+func slackMessage(a Announcement) map[string]interface{} {
+	title := slackEscaper.Replace(a.Title)
+	when := fmt.Sprintf("<!date^%d^{date_long_pretty} at {time}|%s>", a.StartsAt.Unix(), a.localTime())
+
+	lines := []string{
+		fmt.Sprintf("*%s: %s*", heading(a.Kind), title),
+		":calendar: " + when,
+	}
+	if a.Location != "" {
+		lines = append(lines, ":round_pushpin: "+slackEscaper.Replace(a.Location))
+	}
+	if a.Book != nil && *a.Book != "" {
+		lines = append(lines, ":books: "+slackEscaper.Replace(*a.Book))
+	}
+	if description := a.description(); description != "" {
+		lines = append(lines, "", slackEscaper.Replace(description))
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("%s: %s (%s)", heading(a.Kind), title, a.localTime()), // shown in notifications
+		"blocks": []map[string]interface{}{
+			{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": strings.Join(lines, "\n")}},
+			{"type": "context", "elements": []map[string]string{{"type": "mrkdwn", "text": slackEscaper.Replace(a.ClubName)}}},
+		},
+	}
+}
+
+// discordMessage is an announcement as a Discord webhook payload. Mentions
+// are disabled so an event title cannot ping the whole server.
+func discordMessage(a Announcement) map[string]interface{} {
+	fields := []map[string]interface{}{
+		{"name": "When", "value": fmt.Sprintf("<t:%d:F> (<t:%d:R>)", a.StartsAt.Unix(), a.StartsAt.Unix()), "inline": true},
+	}
+	if a.Location != "" {
+		fields = append(fields, map[string]interface{}{"name": "Where", "value": a.Location, "inline": true})
+	}
+	if a.Book != nil && *a.Book != "" {
+		fields = append(fields, map[string]interface{}{"name": "Book", "value": *a.Book, "inline": true})
+	}
+
+	embed := map[string]interface{}{
+		"title":     heading(a.Kind) + ": " + a.Title,
+		"fields":    fields,
+		"timestamp": a.StartsAt.UTC().Format(time.RFC3339),
+		"footer":    map[string]string{"text": a.ClubName},
+	}
+	if description := a.description(); description != "" {
+		embed["description"] = description
+	}
+
+	return map[string]interface{}{
+		"embeds":           []map[string]interface{}{embed},
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	}
+}
+
+// message is an announcement formatted for provider
+func message(provider string, a Announcement) map[string]interface{} {
+	if provider == ProviderDiscord {
+		return discordMessage(a)
+	}
+	return slackMessage(a)
+}
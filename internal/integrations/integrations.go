@@ -0,0 +1,283 @@
+// Package integrations announces club events in the chat tools clubs
+// coordinate in.
+//
+// A club moderator connects a Slack or Discord incoming webhook. New and
+// changed events are queued through the outbox with the change, and the
+// Reminder posts a reminder the configured number of hours before each
+// meeting. Posts are formatted for the provider: Slack gets mrkdwn blocks,
+// Discord an embed. A webhook the provider reports as gone is disabled so the
+// club can see why announcements stopped.
+package integrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+// Chat providers
+const (
+	ProviderSlack   = "slack"
+	ProviderDiscord = "discord"
+)
+
+// Kinds of announcement
+const (
+	KindCreated  = "event.created"
+	KindUpdated  = "event.updated"
+	KindReminder = "event.reminder"
+)
+
+// TopicAnnounce is the outbox topic of one announcement for one integration
+const TopicAnnounce = "integrations.announce"
+
+// ErrNotFound is returned for integrations that do not exist in the club
+var ErrNotFound = errors.New("integration not found")
+
+// Integration is a chat webhook a club's events are announced in
+type Integration struct {
+	ID                uuid.UUID  `json:"id"`
+	ClubID            uuid.UUID  `json:"clubId"`
+	Provider          string     `json:"provider"`
+	WebhookURL        string     `json:"-"` // holds the provider's token
+	Name              string     `json:"name"`
+	RemindBeforeHours int        `json:"remindBeforeHours"` // 0 sends no reminders
+	IsActive          bool       `json:"isActive"`
+	DisabledReason    *string    `json:"disabledReason,omitempty"` // why the integration was turned off, if not by the club
+	CreatedBy         *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// New holds the fields of an integration being connected
+type New struct {
+	Provider          string
+	WebhookURL        string
+	Name              string
+	RemindBeforeHours int
+	CreatedBy         uuid.UUID
+}
+
+// Update holds the integration fields that may change; nil fields are left as they are
+type Update struct {
+	WebhookURL        *string
+	Name              *string
+	RemindBeforeHours *int
+	IsActive          *bool
+}
+
+// Announcement is what is posted about one event
+type Announcement struct {
+	Kind        string    `json:"kind"`
+	ClubName    string    `json:"clubName"`
+	EventID     uuid.UUID `json:"eventId"`
+	Title       string    `json:"title"`
+	StartsAt    time.Time `json:"startsAt"`
+	Timezone    string    `json:"timezone"`
+	Location    string    `json:"location"`
+	Book        *string   `json:"book,omitempty"`
+	Description *string   `json:"description,omitempty"`
+}
+
+// NewAnnouncement describes event for an announcement of kind
+func NewAnnouncement(kind string, event *models.Event) Announcement {
+	return Announcement{
+		Kind:        kind,
+		EventID:     event.ID,
+		Title:       event.Title,
+		StartsAt:    event.StartTime(),
+		Timezone:    event.TimeLocation().String(),
+		Location:    event.Location,
+		Book:        event.Book,
+		Description: event.Description,
+	}
+}
+
+// announcement is the payload of a TopicAnnounce message
+type announcement struct {
+	IntegrationID uuid.UUID    `json:"integrationId"`
+	Announcement  Announcement `json:"announcement"`
+}
+
+// webhookHosts are the hosts each provider's incoming webhooks live on, with
+// the path they start with. Only these are accepted, so an integration
+// cannot make the server post anywhere else.
+var webhookHosts = map[string]map[string]string{
+	ProviderSlack: {
+		"hooks.slack.com": "/services/",
+	},
+	ProviderDiscord: {
+		"discord.com":        "/api/webhooks/",
+		"discordapp.com":     "/api/webhooks/",
+		"ptb.discord.com":    "/api/webhooks/",
+		"canary.discord.com": "/api/webhooks/",
+	},
+}
+
+// ValidateURL checks that raw is an incoming webhook URL of provider
+func ValidateURL(provider, raw string) error {
+	hosts, ok := webhookHosts[provider]
+	if !ok {
+		return errors.New("unknown provider")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return errors.New("must be an https URL")
+	}
+	prefix, ok := hosts[strings.ToLower(u.Host)]
+	if !ok || !strings.HasPrefix(u.Path, prefix) || len(u.Path) == len(prefix) {
+		return fmt.Errorf("must be a %s incoming webhook URL", provider)
+	}
+	return nil
+}
+
+// Store keeps clubs' chat integrations
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+const integrationColumns = `id, club_id, provider, webhook_url, name, remind_before_hours, is_active, disabled_reason, created_by, created_at, updated_at`
+
+func scanIntegration(row interface{ Scan(...interface{}) error }) (Integration, error) {
+	var i Integration
+	err := row.Scan(&i.ID, &i.ClubID, &i.Provider, &i.WebhookURL, &i.Name, &i.RemindBeforeHours,
+		&i.IsActive, &i.DisabledReason, &i.CreatedBy, &i.CreatedAt, &i.UpdatedAt)
+	return i, err
+}
+
+// List returns the club's integrations, oldest first
+func (s *Store) List(ctx context.Context, clubID uuid.UUID) ([]Integration, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+integrationColumns+` FROM chat_integrations WHERE club_id = $1 ORDER BY created_at, id`, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrations: %w", err)
+	}
+	defer rows.Close()
+
+	integrations := []Integration{}
+	for rows.Next() {
+		i, err := scanIntegration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read integration: %w", err)
+		}
+		integrations = append(integrations, i)
+	}
+	return integrations, rows.Err()
+}
+
+// Create connects an active integration
+func (s *Store) Create(ctx context.Context, clubID uuid.UUID, n New) (Integration, error) {
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO chat_integrations (id, club_id, provider, webhook_url, name, remind_before_hours, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+integrationColumns,
+		uuid.New(), clubID, n.Provider, n.WebhookURL, n.Name, n.RemindBeforeHours, n.CreatedBy)
+	i, err := scanIntegration(row)
+	if err != nil {
+		return Integration{}, fmt.Errorf("failed to create integration: %w", err)
+	}
+	return i, nil
+}
+
+// Update changes an integration of the club. Turning it back on clears the
+// reason it was disabled.
+func (s *Store) Update(ctx context.Context, clubID, integrationID uuid.UUID, update Update) (Integration, error) {
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE chat_integrations SET
+			webhook_url = COALESCE($3, webhook_url),
+			name = COALESCE($4, name),
+			remind_before_hours = COALESCE($5, remind_before_hours),
+			is_active = COALESCE($6, is_active),
+			disabled_reason = CASE WHEN $6 OR $3 IS NOT NULL THEN NULL ELSE disabled_reason END,
+			updated_at = NOW()
+		WHERE id = $1 AND club_id = $2
+		RETURNING `+integrationColumns,
+		integrationID, clubID, update.WebhookURL, update.Name, update.RemindBeforeHours, update.IsActive)
+	i, err := scanIntegration(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Integration{}, ErrNotFound
+	}
+	if err != nil {
+		return Integration{}, fmt.Errorf("failed to update integration: %w", err)
+	}
+	return i, nil
+}
+
+// Delete disconnects an integration of the club. Announcements still queued
+// for it are dropped.
+func (s *Store) Delete(ctx context.Context, clubID, integrationID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM chat_integrations WHERE id = $1 AND club_id = $2`, integrationID, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to delete integration: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Queue posts a, about one of the club's events, in each of its active
+// integrations once tx commits, and in none if it rolls back
+func (s *Store) Queue(ctx context.Context, tx *sql.Tx, clubID uuid.UUID, a Announcement) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.id, c.name
+		FROM chat_integrations i
+		JOIN clubs c ON c.id = i.club_id
+		WHERE i.club_id = $1 AND i.is_active = true`, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to query integrations: %w", err)
+	}
+	var integrationIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id, &a.ClubName); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read integration: %w", err)
+		}
+		integrationIDs = append(integrationIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read integrations: %w", err)
+	}
+
+	for _, id := range integrationIDs {
+		if _, err := outbox.Write(ctx, tx, TopicAnnounce, announcement{IntegrationID: id, Announcement: a}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// integration returns an integration by ID, whatever its club
+func (s *Store) integration(ctx context.Context, integrationID uuid.UUID) (Integration, error) {
+	i, err := scanIntegration(s.db.QueryRowContext(ctx, `SELECT `+integrationColumns+` FROM chat_integrations WHERE id = $1`, integrationID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Integration{}, ErrNotFound
+	}
+	return i, err
+}
+
+// disable turns off an integration the provider no longer accepts posts for
+func (s *Store) disable(ctx context.Context, integrationID uuid.UUID, reason string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE chat_integrations SET is_active = false, disabled_reason = $2, updated_at = NOW()
+		WHERE id = $1`, integrationID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to disable integration: %w", err)
+	}
+	return nil
+}
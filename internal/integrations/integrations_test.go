@@ -0,0 +1,119 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		provider string
+		url      string
+		valid    bool
+	}{
+		{ProviderSlack, "https://hooks.slack.com/services/T000/B000/XXXX", true},
+		{ProviderSlack, "http://hooks.slack.com/services/T000/B000/XXXX", false},
+		{ProviderSlack, "https://hooks.slack.com/services/", false},
+		{ProviderSlack, "https://discord.com/api/webhooks/123/abc", false},
+		{ProviderDiscord, "https://discord.com/api/webhooks/123/abc", true},
+		{ProviderDiscord, "https://Discordapp.com/api/webhooks/123/abc", true},
+		{ProviderDiscord, "https://discord.com.example.org/api/webhooks/123/abc", false},
+		{ProviderDiscord, "https://user@discord.com/api/webhooks/123/abc", false},
+		{"teams", "https://example.webhook.office.com/webhookb2/abc", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateURL(tt.provider, tt.url); (err == nil) != tt.valid {
+			t.Errorf("ValidateURL(%s, %q) = %v, want valid %v", tt.provider, tt.url, err, tt.valid)
+		}
+	}
+}
+
+func testAnnouncement() Announcement {
+	book := "The Left Hand of Darkness"
+	description := strings.Repeat("a", maxDescription+50)
+	return Announcement{
+		Kind:        KindCreated,
+		ClubName:    "Tuesday Readers",
+		EventID:     uuid.New(),
+		Title:       "Q&A <with> @everyone",
+		StartsAt:    time.Date(2030, 6, 4, 17, 30, 0, 0, time.UTC),
+		Timezone:    "Europe/London",
+		Location:    "Central Library",
+		Book:        &book,
+		Description: &description,
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	msg := slackMessage(testAnnouncement())
+	text := msg["blocks"].([]map[string]interface{})[0]["text"].(map[string]string)["text"]
+
+	if !strings.Contains(text, "*New event: Q&amp;A &lt;with&gt; @everyone*") {
+		t.Errorf("Expected mrkdwn markup in the title to be escaped, got %s", text)
+	}
+	if !strings.Contains(text, "<!date^1906824600^{date_long_pretty} at {time}|Tue 4 Jun 2030, 18:30 BST>") {
+		t.Errorf("Expected a Slack date with the club's local time as fallback, got %s", text)
+	}
+	if msg["text"] != "New event: Q&amp;A &lt;with&gt; @everyone (Tue 4 Jun 2030, 18:30 BST)" {
+		t.Errorf("Expected a plain-text fallback, got %q", msg["text"])
+	}
+}
+
+func TestDiscordMessage(t *testing.T) {
+	a := testAnnouncement()
+	a.Kind = KindReminder
+	msg := discordMessage(a)
+
+	embed := msg["embeds"].([]map[string]interface{})[0]
+	if embed["title"] != "Reminder: Q&A <with> @everyone" {
+		t.Errorf("Unexpected title %q", embed["title"])
+	}
+	if got := []rune(embed["description"].(string)); len(got) != maxDescription || got[len(got)-1] != '…' {
+		t.Errorf("Expected the description to be cut to %d characters, got %d", maxDescription, len(got))
+	}
+	if parse := msg["allowed_mentions"].(map[string]interface{})["parse"].([]string); len(parse) != 0 {
+		t.Errorf("Expected mentions to be disabled, got %v", parse)
+	}
+}
+
+func TestPost(t *testing.T) {
+	var received map[string]interface{}
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	poster := NewPoster(nil, time.Second, 3, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	integration := Integration{ID: uuid.New(), Provider: ProviderDiscord, WebhookURL: server.URL}
+
+	if err := poster.post(context.Background(), integration, testAnnouncement()); err != nil {
+		t.Fatalf("Expected the post to succeed, got %v", err)
+	}
+	if _, ok := received["embeds"]; !ok {
+		t.Errorf("Expected a Discord payload, got %v", received)
+	}
+
+	status = http.StatusNotFound
+	if err := poster.post(context.Background(), integration, testAnnouncement()); !errors.Is(err, errGone) {
+		t.Errorf("Expected a removed webhook to be reported as gone, got %v", err)
+	}
+
+	status = http.StatusTooManyRequests
+	if err := poster.post(context.Background(), integration, testAnnouncement()); err == nil || errors.Is(err, errGone) {
+		t.Errorf("Expected a rate limited post to fail and be retried, got %v", err)
+	}
+}
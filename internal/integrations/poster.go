@@ -0,0 +1,105 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/outbox"
+)
+
+// errGone is returned when the provider says the webhook no longer exists
+var errGone = errors.New("webhook no longer exists")
+
+// Poster posts queued announcements to chat. It is the outbox handler of
+// TopicAnnounce; a failed post is returned as an error so the outbox tries
+// again later, until the last attempt.
+type Poster struct {
+	store       *Store
+	client      *http.Client
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+// NewPoster gives each post timeout to be answered and gives up on an
+// announcement after maxAttempts, when it would be stale anyway
+func NewPoster(store *Store, timeout time.Duration, maxAttempts int, logger *slog.Logger) *Poster {
+	client := &http.Client{
+		Timeout: timeout,
+		// Webhook URLs are checked against the providers' hosts; a redirect would leave them
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &Poster{store: store, client: client, maxAttempts: maxAttempts, logger: logger}
+}
+
+// Announce posts the announcement in msg to its integration. Integrations
+// removed or turned off since it was queued are skipped, and those whose
+// webhook the provider reports as gone are turned off.
+func (p *Poster) Announce(ctx context.Context, msg outbox.Message) error {
+	var payload announcement
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid %s message: %w", TopicAnnounce, err)
+	}
+
+	integration, err := p.store.integration(ctx, payload.IntegrationID)
+	if errors.Is(err, ErrNotFound) || (err == nil && !integration.IsActive) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = p.post(ctx, integration, payload.Announcement)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errGone):
+		p.logger.Warn("disabling chat integration", "integration_id", integration.ID, "provider", integration.Provider, "error", err)
+		return p.store.disable(ctx, integration.ID, "The "+integration.Provider+" webhook no longer exists")
+	case msg.Attempts+1 >= p.maxAttempts:
+		p.logger.Warn("giving up on chat announcement", "integration_id", integration.ID, "event_id", payload.Announcement.EventID,
+			"kind", payload.Announcement.Kind, "attempts", msg.Attempts+1, "error", err)
+		return nil
+	default:
+		return err
+	}
+}
+
+// post sends an announcement formatted for the integration's provider
+func (p *Poster) post(ctx context.Context, integration Integration, a Announcement) error {
+	body, err := json.Marshal(message(integration.Provider, a))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, integration.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Bookwork-Integrations/1")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone ||
+		resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: status %d", errGone, resp.StatusCode)
+	default:
+		return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+	}
+}
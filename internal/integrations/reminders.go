@@ -0,0 +1,123 @@
+package integrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+// Reminder periodically queues reminders of upcoming events in the chat
+// integrations that ask for them. Several instances may run it; each reminder
+// is queued once.
+type Reminder struct {
+	db       *database.DB
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewReminder(db *database.DB, interval time.Duration, logger *slog.Logger) *Reminder {
+	return &Reminder{db: db, interval: interval, logger: logger}
+}
+
+// RemindDue queues a reminder in each active integration of every event
+// starting within its remindBeforeHours of now that it has not been reminded
+// of, and returns how many were queued. Events rescheduled since their
+// reminder are reminded of again.
+func (r *Reminder) RemindDue(ctx context.Context, now time.Time) (int, error) {
+	queued := 0
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT i.id, c.name, e.id, e.title, e.starts, e.timezone, e.location, e.book, e.description
+			FROM chat_integrations i
+			JOIN clubs c ON c.id = i.club_id
+			JOIN (
+				SELECT *, (event_date + event_time) AT TIME ZONE timezone AS starts
+				FROM events WHERE deleted_at IS NULL
+			) e ON e.club_id = i.club_id
+			WHERE i.is_active = true AND i.remind_before_hours > 0
+			  AND e.starts > $1 AND e.starts <= $1 + i.remind_before_hours * INTERVAL '1 hour'
+			  AND NOT EXISTS (
+				SELECT 1 FROM chat_reminders r
+				WHERE r.integration_id = i.id AND r.event_id = e.id AND r.starts_at = e.starts
+			  )
+			ORDER BY e.starts`, now)
+		if err != nil {
+			return fmt.Errorf("failed to query due reminders: %w", err)
+		}
+		var due []announcement
+		for rows.Next() {
+			a := announcement{Announcement: Announcement{Kind: KindReminder}}
+			if err := rows.Scan(&a.IntegrationID, &a.Announcement.ClubName, &a.Announcement.EventID, &a.Announcement.Title,
+				&a.Announcement.StartsAt, &a.Announcement.Timezone, &a.Announcement.Location, &a.Announcement.Book,
+				&a.Announcement.Description); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read due reminder: %w", err)
+			}
+			due = append(due, a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, a := range due {
+			ok, err := claimReminder(ctx, tx, a.IntegrationID, a.Announcement.EventID, a.Announcement.StartsAt)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue // queued by another instance
+			}
+			if _, err := outbox.Write(ctx, tx, TopicAnnounce, a); err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return queued, nil
+}
+
+// claimReminder records the reminder of an event starting at startsAt in an
+// integration, reporting false when it was already recorded
+func claimReminder(ctx context.Context, tx *sql.Tx, integrationID, eventID uuid.UUID, startsAt time.Time) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO chat_reminders (integration_id, event_id, starts_at) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`, integrationID, eventID, startsAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record reminder: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Run queues due reminders immediately and then every interval until ctx is cancelled
+func (r *Reminder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		queued, err := r.RemindDue(ctx, time.Now())
+		if err != nil {
+			r.logger.Error("error queueing event reminders", "error", err)
+		} else if queued > 0 {
+			r.logger.Info("queued event reminders", "reminders", queued)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
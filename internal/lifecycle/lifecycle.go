@@ -0,0 +1,138 @@
+// Package lifecycle coordinates graceful shutdown.
+//
+// Shutdown runs in phases, in this order:
+//
+//	http        stop accepting requests and wait for in-flight ones
+//	clients     tell long-lived connections (e.g. WebSockets) to reconnect elsewhere
+//	jobs        cancel background jobs and wait for them to return
+//	deliveries  send or give up on queued outbound deliveries
+//	storage     close the database and other stores
+//
+// Background jobs started with Go see their context cancelled in the jobs
+// phase. Jobs keep their work in the database and do it in transactions, so an
+// interrupted run rolls back and the work is picked up again on the next start.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Phase is a step of the shutdown sequence
+type Phase int
+
+const (
+	PhaseHTTP Phase = iota
+	PhaseClients
+	PhaseJobs
+	PhaseDeliveries
+	PhaseStorage
+)
+
+var phaseNames = []string{"http", "clients", "jobs", "deliveries", "storage"}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+type hook struct {
+	phase Phase
+	name  string
+	fn    func(ctx context.Context) error
+}
+
+// Manager runs background jobs and the hooks that stop the server, in phase order
+type Manager struct {
+	logger *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   sync.WaitGroup
+
+	mu      sync.Mutex
+	hooks   []hook
+	once    sync.Once
+	stopErr error
+}
+
+func New(logger *slog.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{logger: logger, ctx: ctx, cancel: cancel}
+}
+
+// Go runs a background job until the jobs phase of shutdown
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.jobs.Add(1)
+	go func() {
+		defer m.jobs.Done()
+		run(m.ctx)
+		m.logger.Info("background job stopped", "job", name)
+	}()
+}
+
+// OnShutdown registers fn to run in phase. Hooks of a phase run in the order
+// they were registered; those of the jobs phase run once the jobs have stopped.
+func (m *Manager) OnShutdown(phase Phase, name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{phase: phase, name: name, fn: fn})
+}
+
+// Shutdown runs every phase, even after failures, and returns the errors of
+// those that did not finish. Phases still running when ctx is done are cut
+// short through ctx. Later calls return the result of the first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.mu.Lock()
+		hooks := append([]hook(nil), m.hooks...)
+		m.mu.Unlock()
+
+		var errs []error
+		for phase := PhaseHTTP; phase <= PhaseStorage; phase++ {
+			if phase == PhaseJobs {
+				if err := m.stopJobs(ctx); err != nil {
+					errs = append(errs, err)
+				}
+			}
+
+			for _, h := range hooks {
+				if h.phase != phase {
+					continue
+				}
+				if err := h.fn(ctx); err != nil {
+					m.logger.Error("shutdown step failed", "phase", phase, "step", h.name, "error", err)
+					errs = append(errs, fmt.Errorf("%s: %s: %w", phase, h.name, err))
+					continue
+				}
+				m.logger.Info("shutdown step finished", "phase", phase, "step", h.name)
+			}
+		}
+		m.stopErr = errors.Join(errs...)
+	})
+	return m.stopErr
+}
+
+// stopJobs cancels the background jobs and waits for them to return
+func (m *Manager) stopJobs(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.logger.Error("background jobs did not stop in time", "error", ctx.Err())
+		return fmt.Errorf("%s: %w", PhaseJobs, ctx.Err())
+	}
+}
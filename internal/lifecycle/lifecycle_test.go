@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestManager() *Manager {
+	return New(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+}
+
+func TestShutdownRunsPhasesInOrder(t *testing.T) {
+	m := newTestManager()
+
+	var mu sync.Mutex
+	var steps []string
+	record := func(step string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			steps = append(steps, step)
+			return nil
+		}
+	}
+
+	m.Go("closer", func(ctx context.Context) {
+		<-ctx.Done()
+		record("job stopped")(ctx)
+	})
+	// Registered out of order on purpose
+	m.OnShutdown(PhaseStorage, "database", record("database"))
+	m.OnShutdown(PhaseDeliveries, "notifications", record("notifications"))
+	m.OnShutdown(PhaseJobs, "after jobs", record("after jobs"))
+	m.OnShutdown(PhaseClients, "hub", record("hub"))
+	m.OnShutdown(PhaseHTTP, "server", record("server"))
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+
+	expected := []string{"server", "hub", "job stopped", "after jobs", "notifications", "database"}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Expected steps %v, got %v", expected, steps)
+	}
+}
+
+func TestShutdownContinuesAfterFailures(t *testing.T) {
+	m := newTestManager()
+
+	closed := false
+	m.OnShutdown(PhaseDeliveries, "notifications", func(context.Context) error { return errors.New("provider down") })
+	m.OnShutdown(PhaseStorage, "database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	err := m.Shutdown(context.Background())
+	if err == nil || !closed {
+		t.Fatalf("Expected the error to be reported and the database still closed, got %v, closed=%v", err, closed)
+	}
+	if again := m.Shutdown(context.Background()); again != err {
+		t.Errorf("Expected a second Shutdown to return the first result, got %v", again)
+	}
+}
+
+func TestShutdownGivesUpOnStuckJobs(t *testing.T) {
+	m := newTestManager()
+
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(ctx context.Context) { <-release })
+
+	closed := false
+	m.OnShutdown(PhaseStorage, "database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be reported, got %v", err)
+	}
+	if !closed {
+		t.Error("Expected later phases to run after the deadline")
+	}
+}
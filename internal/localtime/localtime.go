@@ -0,0 +1,99 @@
+package localtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// The production image is built FROM scratch and has no zoneinfo files
+	_ "time/tzdata"
+)
+
+// DefaultTimezone is used for users who have not set a preference
+const DefaultTimezone = "UTC"
+
+// ErrUnknownTimezone is returned for names that are not IANA timezones
+var ErrUnknownTimezone = errors.New("unknown timezone")
+
+// LoadLocation resolves an IANA timezone name, falling back to UTC for empty names
+func LoadLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	// time.LoadLocation accepts "Local", which would leak the server's zone
+	if name == "Local" {
+		return nil, ErrUnknownTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrUnknownTimezone
+	}
+	return loc, nil
+}
+
+// LocationOrUTC resolves a stored preference, ignoring invalid values
+func LocationOrUTC(name string) *time.Location {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// RelativeHint describes t relative to now for notification text, e.g. "in 3 days".
+// Day distances are counted in calendar days of loc so "tomorrow" matches the reader's clock.
+func RelativeHint(now, t time.Time, loc *time.Location) string {
+	now = now.In(loc)
+	t = t.In(loc)
+
+	days := calendarDays(now, t)
+	switch {
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	case days > 1:
+		return fmt.Sprintf("in %d days", days)
+	case days < -1:
+		return fmt.Sprintf("%d days ago", -days)
+	}
+
+	d := t.Sub(now)
+	future := d >= 0
+	if !future {
+		d = -d
+	}
+
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return withDirection(plural(int(d/time.Minute), "minute"), future)
+	default:
+		return withDirection(plural(int(d/time.Hour), "hour"), future)
+	}
+}
+
+func calendarDays(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+func withDirection(s string, future bool) string {
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
@@ -0,0 +1,63 @@
+package localtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc != time.UTC {
+		t.Errorf("Expected UTC for empty name, got %v, %v", loc, err)
+	}
+	if _, err := LoadLocation("America/New_York"); err != nil {
+		t.Errorf("Expected America/New_York to load, got %v", err)
+	}
+	if _, err := LoadLocation("Mars/Olympus_Mons"); err != ErrUnknownTimezone {
+		t.Errorf("Expected ErrUnknownTimezone, got %v", err)
+	}
+	if _, err := LoadLocation("Local"); err != ErrUnknownTimezone {
+		t.Errorf("Expected Local to be rejected, got %v", err)
+	}
+}
+
+func TestRelativeHint(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at       time.Time
+		expected string
+	}{
+		{now.Add(30 * time.Second), "now"},
+		{now.Add(45 * time.Minute), "in 45 minutes"},
+		{now.Add(time.Hour), "in 1 hour"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{now.Add(24 * time.Hour), "tomorrow"},
+		{now.Add(-24 * time.Hour), "yesterday"},
+		{now.Add(3 * 24 * time.Hour), "in 3 days"},
+		{now.Add(-5 * 24 * time.Hour), "5 days ago"},
+	}
+
+	for _, tt := range tests {
+		if hint := RelativeHint(now, tt.at, time.UTC); hint != tt.expected {
+			t.Errorf("Expected %q for %v, got %q", tt.expected, tt.at, hint)
+		}
+	}
+}
+
+func TestRelativeHintUsesReaderCalendar(t *testing.T) {
+	// 23:00 UTC on the 10th is already the 11th in Tokyo
+	now := time.Date(2024, time.March, 10, 23, 0, 0, 0, time.UTC)
+	event := time.Date(2024, time.March, 11, 2, 0, 0, 0, time.UTC)
+
+	if hint := RelativeHint(now, event, time.UTC); hint != "tomorrow" {
+		t.Errorf("Expected tomorrow in UTC, got %q", hint)
+	}
+
+	tokyo, err := LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Timezone data not available: %v", err)
+	}
+	if hint := RelativeHint(now, event, tokyo); hint != "in 3 hours" {
+		t.Errorf("Expected in 3 hours in Tokyo, got %q", hint)
+	}
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"bogus":   slog.LevelInfo,
+	}
+
+	for input, expected := range tests {
+		if level := ParseLevel(input); level != expected {
+			t.Errorf("ParseLevel(%q): expected %v, got %v", input, expected, level)
+		}
+	}
+}
+
+func TestNewWritesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", "json")
+
+	logger.Debug("hidden")
+	logger.Info("visible", "request_id", "abc")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "visible" || record["request_id"] != "abc" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+}
+
+func TestContextHelpers(t *testing.T) {
+	ctx := context.Background()
+
+	if FromContext(ctx) != slog.Default() {
+		t.Error("Expected the default logger outside a request")
+	}
+	if RequestIDFromContext(ctx) != "" {
+		t.Error("Expected no request ID outside a request")
+	}
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx = NewContext(WithRequestID(ctx, "req-1"), logger)
+
+	if FromContext(ctx) != logger {
+		t.Error("Expected the request-scoped logger")
+	}
+	if RequestIDFromContext(ctx) != "req-1" {
+		t.Errorf("Expected request ID req-1, got %q", RequestIDFromContext(ctx))
+	}
+}
@@ -0,0 +1,387 @@
+// Package markdown renders the Markdown of club and event descriptions to
+// HTML. It supports a strict subset: paragraphs, headings, emphasis, strike
+// through, inline code and code blocks, lists, block quotes, rules and links.
+// Raw HTML is never passed through: all text is escaped, the only tags are the
+// ones the renderer writes, and the only attribute is href, for http, https,
+// mailto and relative links. Images are rendered as links to them.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxNesting bounds nested emphasis and block quotes; deeper content is
+// rendered as text
+const maxNesting = 8
+
+// linkSchemes are the URL schemes links may use; links without a scheme are
+// relative
+var linkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+var (
+	heading     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rule        = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	bulletItem  = regexp.MustCompile(`^ {0,3}[-*+][ \t]+(.*)$`)
+	orderedItem = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)][ \t]+(.*)$`)
+	fence       = regexp.MustCompile("^ {0,3}(```|~~~)")
+	quote       = regexp.MustCompile(`^ {0,3}>[ \t]?(.*)$`)
+)
+
+// Render returns the HTML for src
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), 0)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fence.MatchString(line):
+			marker := fence.FindStringSubmatch(line)[1]
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), marker) {
+				end++
+			}
+			b.WriteString("<pre><code>")
+			for _, code := range lines[i+1 : end] {
+				b.WriteString(html.EscapeString(code))
+				b.WriteString("\n")
+			}
+			b.WriteString("</code></pre>\n")
+			i = end + 1
+
+		case heading.MatchString(line):
+			parts := heading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(parts[1]))
+			b.WriteString("<h" + level + ">" + renderInline(parts[2], 0) + "</h" + level + ">\n")
+			i++
+
+		case rule.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quote.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quote.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quote.FindStringSubmatch(lines[i])[1])
+			}
+			b.WriteString("<blockquote>\n")
+			if depth < maxNesting {
+				renderBlocks(b, quoted, depth+1)
+			} else {
+				renderParagraph(b, quoted)
+			}
+			b.WriteString("</blockquote>\n")
+
+		case bulletItem.MatchString(line), orderedItem.MatchString(line):
+			i = renderList(b, lines, i)
+
+		default:
+			start := i
+			for i++; i < len(lines) && !startsBlock(lines[i]); i++ {
+			}
+			renderParagraph(b, lines[start:i])
+		}
+	}
+}
+
+// startsBlock reports whether line ends a paragraph
+func startsBlock(line string) bool {
+	return strings.TrimSpace(line) == "" || fence.MatchString(line) || heading.MatchString(line) ||
+		rule.MatchString(line) || quote.MatchString(line) || bulletItem.MatchString(line) || orderedItem.MatchString(line)
+}
+
+// renderList writes the list starting at lines[i], with lines that are
+// neither blank nor a new block continuing the item above, and returns the
+// index after it
+func renderList(b *strings.Builder, lines []string, i int) int {
+	ordered := orderedItem.MatchString(lines[i])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">\n")
+
+	for i < len(lines) {
+		var item []string
+		if ordered {
+			parts := orderedItem.FindStringSubmatch(lines[i])
+			if parts == nil {
+				break
+			}
+			item = append(item, parts[2])
+		} else {
+			parts := bulletItem.FindStringSubmatch(lines[i])
+			if parts == nil {
+				break
+			}
+			item = append(item, parts[1])
+		}
+		for i++; i < len(lines) && !startsBlock(lines[i]); i++ {
+			item = append(item, strings.TrimSpace(lines[i]))
+		}
+		b.WriteString("<li>" + renderLines(item) + "</li>\n")
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func renderParagraph(b *strings.Builder, lines []string) {
+	b.WriteString("<p>" + renderLines(lines) + "</p>\n")
+}
+
+// renderLines renders the inline content of lines joined by newlines, with a
+// line break where a line ends in two spaces or a backslash
+func renderLines(lines []string) string {
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		hardBreak := false
+		if i < len(lines)-1 {
+			if strings.HasSuffix(line, "  ") {
+				line, hardBreak = strings.TrimRight(line, " "), true
+			} else if strings.HasSuffix(line, "\\") {
+				line, hardBreak = strings.TrimSuffix(line, "\\"), true
+			}
+		}
+		rendered[i] = renderInline(strings.TrimRight(line, " \t"), 0)
+		if hardBreak {
+			rendered[i] += "<br>"
+		}
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// emphasis delimiters, longest first, and the tag each renders as
+var emphasis = []struct {
+	delim string
+	tag   string
+}{
+	{"**", "strong"}, {"__", "strong"}, {"~~", "del"}, {"*", "em"}, {"_", "em"},
+}
+
+// renderInline renders code spans, links, emphasis and backslash escapes,
+// escaping everything else
+func renderInline(s string, depth int) string {
+	if depth >= maxNesting {
+		return html.EscapeString(s)
+	}
+	in := &inline{s: s, depth: depth, closers: closerPositions(s), unclosedCode: make(map[int]bool)}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n, ok := in.code(&b, i); ok {
+			i = n
+			continue
+		}
+		if n, ok := in.link(&b, i); ok {
+			i = n
+			continue
+		}
+		if n, ok := in.emphasis(&b, i); ok {
+			i = n
+			continue
+		}
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!~<>|", s[i+1]) >= 0 {
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// inline is the state of rendering one line's inline content. The scans for
+// closing delimiters are remembered, so that text full of unclosed ones still
+// renders in linear time.
+type inline struct {
+	s     string
+	depth int
+
+	closers      map[string][]int // see closerPositions
+	unclosedCode map[int]bool     // lengths of backtick runs with no closing run left
+	searched     bool             // whether nextParen is set
+	nextParen    int              // the first ) at or after parenFrom, or -1
+	parenFrom    int
+}
+
+// code writes the code span starting at s[i], if any
+func (in *inline) code(b *strings.Builder, i int) (int, bool) {
+	s, unclosed := in.s, in.unclosedCode
+	if s[i] != '`' {
+		return i, false
+	}
+	n := i
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	ticks := s[i:n]
+	end := -1
+	if !unclosed[len(ticks)] {
+		end = strings.Index(s[n:], ticks)
+	}
+	if end < 0 {
+		unclosed[len(ticks)] = true
+		b.WriteString(ticks)
+		return n, true
+	}
+	b.WriteString("<code>" + html.EscapeString(strings.TrimSpace(s[n:n+end])) + "</code>")
+	return n + end + len(ticks), true
+}
+
+// link writes the [text](destination), ![alt](destination) or <destination>
+// link starting at s[i], if any. Links to unsafe destinations are written as
+// their text.
+func (in *inline) link(b *strings.Builder, i int) (int, bool) {
+	s := in.s
+	if s[i] == '<' {
+		end := strings.IndexAny(s[i+1:], "<> \t\n")
+		if end < 0 || s[i+1+end] != '>' {
+			return i, false
+		}
+		destination := s[i+1 : i+1+end]
+		if !strings.Contains(destination, ":") || !safeDestination(destination) {
+			return i, false
+		}
+		b.WriteString(anchor(destination, html.EscapeString(destination)))
+		return i + end + 2, true
+	}
+
+	start := i
+	if s[i] == '!' && i+1 < len(s) && s[i+1] == '[' {
+		i++
+	}
+	if s[i] != '[' {
+		return start, false
+	}
+	textEnd := strings.IndexAny(s[i+1:], "[]")
+	if textEnd < 0 || s[i+1+textEnd] != ']' {
+		return start, false
+	}
+	text := s[i+1 : i+1+textEnd]
+	open := i + 2 + textEnd
+	if open >= len(s) || s[open] != '(' {
+		return start, false
+	}
+	close := in.paren(open)
+	if close < 0 {
+		return start, false
+	}
+	destination := strings.Fields(s[open+1 : close])
+	next := close + 1
+
+	label := renderInline(text, in.depth+1)
+	if len(destination) == 0 || !safeDestination(destination[0]) {
+		b.WriteString(label)
+		return next, true
+	}
+	b.WriteString(anchor(strings.Trim(destination[0], "<>"), label))
+	return next, true
+}
+
+// paren returns the index of the first ) after from, or -1
+func (in *inline) paren(from int) int {
+	// No ) lies between the last search's start and its result
+	if in.searched && in.parenFrom <= from && (in.nextParen < 0 || in.nextParen >= from) {
+		return in.nextParen
+	}
+	in.searched, in.parenFrom, in.nextParen = true, from, strings.IndexByte(in.s[from:], ')')
+	if in.nextParen >= 0 {
+		in.nextParen += from
+	}
+	return in.nextParen
+}
+
+func anchor(destination, label string) string {
+	return `<a href="` + html.EscapeString(destination) + `" rel="nofollow noopener noreferrer">` + label + "</a>"
+}
+
+// safeDestination reports whether a destination is relative or uses an
+// allowed scheme, ignoring entities, whitespace and control characters that
+// browsers ignore too
+func safeDestination(destination string) bool {
+	destination = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(strings.Trim(destination, "<>")))
+
+	scheme, _, found := strings.Cut(destination, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	return linkSchemes[strings.ToLower(scheme)]
+}
+
+// closerPositions lists, for each emphasis delimiter, where in s it can close
+// emphasis: preceded by a character other than a space. Openers look their
+// closer up here rather than scanning s each time.
+func closerPositions(s string) map[string][]int {
+	positions := make(map[string][]int, len(emphasis))
+	for _, e := range emphasis {
+		for from := 1; from < len(s); {
+			n := strings.Index(s[from:], e.delim)
+			if n < 0 {
+				break
+			}
+			at := from + n
+			if !unicode.IsSpace(rune(s[at-1])) {
+				positions[e.delim] = append(positions[e.delim], at)
+			}
+			from = at + 1
+		}
+	}
+	return positions
+}
+
+// emphasis writes the emphasis opening at s[i], if any
+func (in *inline) emphasis(b *strings.Builder, i int) (int, bool) {
+	s, closers := in.s, in.closers
+	for _, e := range emphasis {
+		if !strings.HasPrefix(s[i:], e.delim) {
+			continue
+		}
+		contentStart := i + len(e.delim)
+		if contentStart >= len(s) || unicode.IsSpace(rune(s[contentStart])) {
+			return i, false
+		}
+		// Underscores inside words, as in snake_case, do not open emphasis
+		if e.delim[0] == '_' && i > 0 && isWordByte(s[i-1]) {
+			return i, false
+		}
+
+		positions := closers[e.delim]
+		k := sort.SearchInts(positions, contentStart+1)
+		for ; k < len(positions); k++ {
+			end := positions[k]
+			if e.delim[0] == '_' && end+len(e.delim) < len(s) && isWordByte(s[end+len(e.delim)]) {
+				continue
+			}
+			b.WriteString("<" + e.tag + ">" + renderInline(s[contentStart:end], in.depth+1) + "</" + e.tag + ">")
+			return end + len(e.delim), true
+		}
+		// No closer: the delimiter is text
+		b.WriteString(html.EscapeString(e.delim))
+		return contentStart, true
+	}
+	return i, false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,47 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		html     string
+	}{
+		{"paragraphs", "First line\nsame paragraph\n\nSecond", "<p>First line\nsame paragraph</p>\n<p>Second</p>"},
+		{"hard break", "Line one  \nLine two", "<p>Line one<br>\nLine two</p>"},
+		{"heading", "## Dune *night* ##", "<h2>Dune <em>night</em></h2>"},
+		{"hashtag is not a heading", "#bookclub", "<p>#bookclub</p>"},
+		{"emphasis", "**Bring** *one* __book__ ~~or two~~", "<p><strong>Bring</strong> <em>one</em> <strong>book</strong> <del>or two</del></p>"},
+		{"underscores in words", "snake_case_name and 2 * 3 * 4", "<p>snake_case_name and 2 * 3 * 4</p>"},
+		{"code", "Run `go <b>test</b>`\n```\n<script>alert(1)</script>\n```", "<p>Run <code>go &lt;b&gt;test&lt;/b&gt;</code></p>\n<pre><code>&lt;script&gt;alert(1)&lt;/script&gt;\n</code></pre>"},
+		{"lists", "- Snacks\n- Drinks\n\n1. Read\n2. Discuss", "<ul>\n<li>Snacks</li>\n<li>Drinks</li>\n</ul>\n<ol>\n<li>Read</li>\n<li>Discuss</li>\n</ol>"},
+		{"quote and rule", "> A *great* book\n\n---", "<blockquote>\n<p>A <em>great</em> book</p>\n</blockquote>\n<hr>"},
+		{"links", `[Map](https://maps.example.com/?q=a&b="c") and <https://bookwork.app>`, `<p><a href="https://maps.example.com/?q=a&amp;b=&#34;c&#34;" rel="nofollow noopener noreferrer">Map</a> and <a href="https://bookwork.app" rel="nofollow noopener noreferrer">https://bookwork.app</a></p>`},
+		{"relative link", "[Events](/clubs/1/events)", `<p><a href="/clubs/1/events" rel="nofollow noopener noreferrer">Events</a></p>`},
+		{"image as link", "![Cover](https://covers.example.com/dune.jpg)", `<p><a href="https://covers.example.com/dune.jpg" rel="nofollow noopener noreferrer">Cover</a></p>`},
+		{"unsafe links", "[a](javascript:alert(1)) [b](java&#x73;cript:x) <vbscript:x>", "<p>a) b &lt;vbscript:x&gt;</p>"},
+		{"raw HTML", `<img src=x onerror="alert(1)"> & <!-- c -->`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt; &amp; &lt;!-- c --&gt;</p>"},
+		{"escapes", `\*not emphasis\*`, "<p>*not emphasis*</p>"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.markdown); got != tt.html {
+			t.Errorf("%s: Render(%q)\n got %q\nwant %q", tt.name, tt.markdown, got, tt.html)
+		}
+	}
+}
+
+func TestRenderUnclosedDelimitersInLinearTime(t *testing.T) {
+	for _, unit := range []string{"*a ", "``a`", "[a](", "_a [b `c ", "<a"} {
+		src := strings.Repeat(unit, 200000)
+		start := time.Now()
+		Render(src)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Rendering %q repeated took %v", unit, elapsed)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7386) to the
+// patchable fields of a resource.
+//
+// A merge patch is a JSON object naming only what changes: a member set to
+// null clears the field, an object is merged into the field member by member,
+// and any other value replaces it. Unlike the RFC, which lets a patch add any
+// member, Apply refuses members the resource does not have, so a misspelt
+// field is reported instead of being ignored.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ContentType is the media type of merge patch documents
+const ContentType = "application/merge-patch+json"
+
+// ErrNotObject is returned for a patch that is not a JSON object. The RFC
+// reads such a patch as a replacement of the whole resource, which is not
+// supported.
+var ErrNotObject = errors.New("merge patch must be a JSON object")
+
+// ErrTrailingData is returned for a patch with more than one JSON value
+var ErrTrailingData = errors.New("merge patch must be a single JSON value")
+
+// UnknownFieldsError lists, by JSON path, the members of a patch that the
+// resource has no field for
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "unknown fields: " + strings.Join(e.Fields, ", ")
+}
+
+// Apply merges patch into target, a pointer to a struct holding the current
+// values of the resource's patchable fields. Every field of the struct must
+// be marshalled, without omitempty, so Apply knows which members exist.
+// Nothing is changed when the patch is invalid or names unknown members. It
+// returns the top-level members the patch names, sorted.
+//
+// A value of the wrong type is reported as a *json.UnmarshalTypeError.
+func Apply(target interface{}, patch []byte) ([]string, error) {
+	var changes interface{}
+	if err := decode(patch, &changes); err != nil {
+		return nil, err
+	}
+	object, ok := changes.(map[string]interface{})
+	if !ok {
+		return nil, ErrNotObject
+	}
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch target: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := decode(current, &doc); err != nil {
+		return nil, fmt.Errorf("failed to read patch target: %w", err)
+	}
+
+	if unknown := unknownFields("", doc, object); len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, &UnknownFieldsError{Fields: unknown}
+	}
+
+	merged, err := json.Marshal(merge(doc, object))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patched document: %w", err)
+	}
+	// Decode into a zero value, so cleared fields are left empty
+	result := reflect.New(reflect.TypeOf(target).Elem())
+	if err := json.Unmarshal(merged, result.Interface()); err != nil {
+		return nil, err
+	}
+	reflect.ValueOf(target).Elem().Set(result.Elem())
+
+	fields := make([]string, 0, len(object))
+	for name := range object {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// decode unmarshals JSON keeping numbers as written, so amounts such as
+// costs survive the round trip exactly. Anything but whitespace after the
+// value is refused, as json.Unmarshal would.
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// unknownFields returns the members of patch that doc does not have. Objects
+// are checked member by member where doc has an object to compare with.
+func unknownFields(prefix string, doc, patch map[string]interface{}) []string {
+	var unknown []string
+	for name, value := range patch {
+		existing, ok := doc[name]
+		if !ok {
+			unknown = append(unknown, prefix+name)
+			continue
+		}
+		nestedPatch, isObject := value.(map[string]interface{})
+		nestedDoc, hasObject := existing.(map[string]interface{})
+		if isObject && hasObject {
+			unknown = append(unknown, unknownFields(prefix+name+".", nestedDoc, nestedPatch)...)
+		}
+	}
+	return unknown
+}
+
+// merge is the MergePatch function of RFC 7386, section 2
+func merge(target interface{}, patch interface{}) interface{} {
+	object, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = map[string]interface{}{}
+	}
+	for name, value := range object {
+		if value == nil {
+			delete(doc, name)
+		} else {
+			doc[name] = merge(doc[name], value)
+		}
+	}
+	return doc
+}
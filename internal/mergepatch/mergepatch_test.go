@@ -0,0 +1,78 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City    string  `json:"city"`
+	Country *string `json:"country"`
+}
+
+type testResource struct {
+	Title   string      `json:"title"`
+	Notes   *string     `json:"notes"`
+	Cost    json.Number `json:"cost"`
+	Tags    []string    `json:"tags"`
+	Address testAddress `json:"address"`
+}
+
+func TestApply(t *testing.T) {
+	notes, country := "Bring a jumper", "FR"
+	target := testResource{Title: "Picnic", Notes: &notes, Cost: "12.50", Tags: []string{"outdoor"},
+		Address: testAddress{City: "Lyon", Country: &country}}
+
+	fields, err := Apply(&target, []byte(`{"notes": null, "tags": ["summer"], "address": {"city": "Paris"}, "cost": 12.505}`))
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !reflect.DeepEqual(fields, []string{"address", "cost", "notes", "tags"}) {
+		t.Errorf("Expected the patched members, got %v", fields)
+	}
+
+	expected := testResource{Title: "Picnic", Cost: "12.505", Tags: []string{"summer"},
+		Address: testAddress{City: "Paris", Country: &country}}
+	if !reflect.DeepEqual(target, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, target)
+	}
+}
+
+func TestApplyRefusesUnknownFields(t *testing.T) {
+	target := testResource{Title: "Picnic"}
+
+	_, err := Apply(&target, []byte(`{"title": "Party", "titel": "Party", "address": {"street": "Main St", "city": "Paris"}}`))
+	var unknown *UnknownFieldsError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Fields, []string{"address.street", "titel"}) {
+		t.Fatalf("Expected the unknown fields to be listed, got %v", err)
+	}
+	if target.Title != "Picnic" {
+		t.Errorf("Expected nothing to change, got %q", target.Title)
+	}
+}
+
+func TestApplyInvalidPatches(t *testing.T) {
+	target := testResource{Title: "Picnic"}
+
+	for _, patch := range []string{`["title"]`, `null`, `"Party"`} {
+		if _, err := Apply(&target, []byte(patch)); err != ErrNotObject {
+			t.Errorf("Apply(%s): expected ErrNotObject, got %v", patch, err)
+		}
+	}
+
+	for _, patch := range []string{`{"title": "Party"} garbage`, `{"title": "Party"}{"title": "Ball"}`} {
+		if _, err := Apply(&target, []byte(patch)); err != ErrTrailingData {
+			t.Errorf("Apply(%s): expected ErrTrailingData, got %v", patch, err)
+		}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if _, err := Apply(&target, []byte(`{"title": 3}`)); !errors.As(err, &typeErr) || typeErr.Field != "title" {
+		t.Errorf("Expected a type error for title, got %v", err)
+	}
+	if target.Title != "Picnic" {
+		t.Errorf("Expected nothing to change, got %q", target.Title)
+	}
+}
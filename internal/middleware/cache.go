@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// CachePolicy describes how clients and shared caches (CDNs, proxies) may cache a response
+type CachePolicy struct {
+	Public  bool          // shared caches may store the response
+	MaxAge  time.Duration // freshness for browsers
+	SMaxAge time.Duration // freshness for shared caches, public responses only
+
+	// Revalidate lets clients keep the response but check it with the server
+	// (If-None-Match) before every reuse, instead of a freshness lifetime
+	Revalidate bool
+}
+
+var (
+	// NoStore keeps responses out of every cache. It is the default for all routes.
+	NoStore = CachePolicy{}
+
+	// PrivateCache lets the caller's browser reuse a per-user response briefly
+	PrivateCache = CachePolicy{MaxAge: 30 * time.Second}
+
+	// PrivateRevalidate lets the caller keep a per-user response and reuse it
+	// after a 304 from ConditionalGET
+	PrivateRevalidate = CachePolicy{Revalidate: true}
+)
+
+// PublicCache lets browsers cache a response for maxAge and shared caches for sMaxAge
+func PublicCache(maxAge, sMaxAge time.Duration) CachePolicy {
+	return CachePolicy{Public: true, MaxAge: maxAge, SMaxAge: sMaxAge}
+}
+
+// Header renders the policy as a Cache-Control header value
+func (p CachePolicy) Header() string {
+	if p.MaxAge <= 0 && p.SMaxAge <= 0 && !p.Revalidate {
+		return "no-store"
+	}
+
+	directives := []string{"private"}
+	if p.Public {
+		directives = []string{"public"}
+	}
+	if p.Revalidate {
+		return strings.Join(append(directives, "no-cache"), ", ")
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge.Seconds())))
+	if p.Public && p.SMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(int(p.SMaxAge.Seconds())))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// CacheControl applies a cache policy to a route, replacing the no-store default
+// set by the security headers middleware. Server errors are never cached.
+func CacheControl(policy CachePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy == NoStore {
+				w.Header().Set("Cache-Control", "no-store")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Cache-Control", policy.Header())
+			w.Header().Del("Pragma")
+			w.Header().Del("Expires")
+
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// cacheControlWriter drops the cache policy when the response turns out to be a server error
+type cacheControlWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (cw *cacheControlWriter) WriteHeader(statusCode int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if statusCode >= http.StatusInternalServerError {
+			cw.Header().Set("Cache-Control", "no-store")
+		}
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *cacheControlWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// cachedResponse is a stored successful response
+type cachedResponse struct {
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// cachedHeaders are the representation headers stored with a response. The
+// others, such as CORS and rate limit headers, belong to each request and are
+// set on it by earlier middleware.
+var cachedHeaders = []string{"Content-Type", "ETag", "Cache-Control", "Last-Modified"}
+
+// ResponseCache keeps successful responses of hot public endpoints in memory.
+// Only anonymous GET requests are cached, so per-user responses never leak;
+// entries expire after the TTL rather than being invalidated on writes.
+type ResponseCache struct {
+	mu         sync.Mutex
+	entries    map[string]*cachedResponse
+	ttl        time.Duration
+	maxEntries int
+}
+
+// NewResponseCache creates a cache holding up to maxEntries responses for ttl each
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{
+		entries:    make(map[string]*cachedResponse),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// Middleware serves cached responses and stores new 200 responses
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI()
+		if entry, ok := c.get(key); ok {
+			// Headers the live response already has win over stored ones
+			for name, values := range entry.header {
+				if _, set := w.Header()[name]; !set {
+					w.Header()[name] = values
+				}
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(http.StatusOK)
+			w.Write(entry.body)
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+
+		var buf bytes.Buffer
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&buf)
+
+		next.ServeHTTP(ww, r)
+
+		if ww.Status() == http.StatusOK {
+			c.set(key, w.Header(), buf.Bytes())
+		}
+	})
+}
+
+func (c *ResponseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *ResponseCache) set(key string, header http.Header, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		// Make room by dropping expired entries, then an arbitrary one
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+
+	stored := http.Header{}
+	for _, name := range cachedHeaders {
+		for _, value := range header.Values(name) {
+			stored.Add(name, value)
+		}
+	}
+
+	c.entries[key] = &cachedResponse{
+		header:    stored,
+		body:      append([]byte(nil), body...),
+		expiresAt: now.Add(c.ttl),
+	}
+}
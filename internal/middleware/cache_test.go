@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachePolicyHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   CachePolicy
+		expected string
+	}{
+		{"no store", NoStore, "no-store"},
+		{"private", PrivateCache, "private, max-age=30"},
+		{"public", PublicCache(time.Minute, 5*time.Minute), "public, max-age=60, s-maxage=300"},
+		{"private revalidate", PrivateRevalidate, "private, no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if header := tt.policy.Header(); header != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, header)
+			}
+		})
+	}
+}
+
+func TestCacheControlOverridesSecurityDefault(t *testing.T) {
+	status := http.StatusOK
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+	handler := SecurityHeaders(CacheControl(PublicCache(time.Minute, 5*time.Minute))(testHandler))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/public/clubs/1", nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60, s-maxage=300" {
+		t.Errorf("Expected public cache policy, got %q", cc)
+	}
+	if w.Header().Get("Pragma") != "" {
+		t.Error("Expected Pragma to be removed for cacheable responses")
+	}
+
+	status = http.StatusInternalServerError
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/public/clubs/1", nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Expected server errors not to be cached, got %q", cc)
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	calls := 0
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	})
+	handler := NewResponseCache(time.Minute, 10).Middleware(testHandler)
+
+	for i, expected := range []string{"MISS", "HIT"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/public/clubs/1", nil))
+		if cache := w.Header().Get("X-Cache"); cache != expected {
+			t.Errorf("Request %d: expected X-Cache %s, got %q", i, expected, cache)
+		}
+		if w.Body.String() != `{"ok":true}` || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Request %d: unexpected response %q", i, w.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to be called once, got %d", calls)
+	}
+
+	// Authenticated requests bypass the cache
+	req := httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if calls != 2 || w.Header().Get("X-Cache") != "" {
+		t.Errorf("Expected authenticated request to bypass the cache, calls=%d X-Cache=%q", calls, w.Header().Get("X-Cache"))
+	}
+}
+
+func TestResponseCacheSkipsErrors(t *testing.T) {
+	calls := 0
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := NewResponseCache(time.Minute, 10).Middleware(testHandler)
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/public/clubs/missing", nil))
+	}
+	if calls != 2 {
+		t.Errorf("Expected error responses not to be cached, handler called %d times", calls)
+	}
+}
+
+func TestResponseCacheKeepsPerRequestHeaders(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"ok":true}`))
+	})
+	// Stand-ins for the CORS and rate limit middleware running before the cache
+	perRequest := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("X-RateLimit-Remaining", r.Header.Get("X-Test-Remaining"))
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := perRequest(NewResponseCache(time.Minute, 10).Middleware(testHandler))
+
+	for _, origin := range []string{"https://a.example.com", "https://b.example.com"} {
+		req := httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("X-Test-Remaining", origin[8:9])
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("Expected Access-Control-Allow-Origin %s, got %q", origin, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != origin[8:9] {
+			t.Errorf("%s: expected this request's rate limit header, got %q", origin, got)
+		}
+		if w.Header().Get("ETag") != `"v1"` || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected the representation headers, got %v", origin, w.Header())
+		}
+	}
+}
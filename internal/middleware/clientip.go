@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ClientAddress resolves the client address of every request once, for
+// ClientIP. It must run before anything that throttles, audits or checks
+// requests by address.
+func ClientAddress(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPKey{}, ForwardedClient(r, trustProxy))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ForwardedClient returns the address r came from. Without trustProxy it is
+// the connection's peer; with it, the last X-Forwarded-For entry, which the
+// nearest proxy sets and the client cannot forge. X-Real-IP and earlier
+// X-Forwarded-For entries are written by the client and never trusted.
+func ForwardedClient(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// ClientIP returns the client address ClientAddress resolved, or the
+// connection's peer on routes it does not cover
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return ForwardedClient(r, false)
+}
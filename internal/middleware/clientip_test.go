@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"peer", false, "", "", "203.0.113.5"},
+		{"untrusted forwarded header", false, "198.51.100.9", "198.51.100.10", "203.0.113.5"},
+		{"proxy entry", true, "198.51.100.9", "", "198.51.100.9"},
+		{"forged first entry", true, "192.0.2.77, 198.51.100.9", "192.0.2.78", "198.51.100.9"},
+		{"trusted proxy without header", true, "", "192.0.2.78", "203.0.113.5"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.5:4000"
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+
+		var got string
+		ClientAddress(tt.trustProxy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = ClientIP(r)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+
+	// Without ClientAddress the headers are not trusted either
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.5:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	if got := ClientIP(req); got != "203.0.113.5" {
+		t.Errorf("Expected the peer address, got %s", got)
+	}
+}
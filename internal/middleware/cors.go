@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// CORSConfig says which browser origins may call the API. Origins are exact,
+// like https://bookwork.app, or have a wildcard for the leftmost subdomain
+// labels, like https://*.bookwork.app. A lone "*" allows every origin.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowCredentials bool
+
+	// Origins allowed without cookies or auth headers, even when they also
+	// match AllowedOrigins, e.g. a public widget on a partner's subdomain
+	OriginsWithoutCredentials []string
+
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         int
+}
+
+// CORS returns the CORS middleware for config. It rejects origin patterns it
+// cannot parse, and "*" together with credentials, which would let any site
+// act with a signed-in member's cookies.
+func CORS(config CORSConfig) (func(http.Handler) http.Handler, error) {
+	credentialed, err := parseOrigins(config.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	if config.AllowCredentials && credentialed.any {
+		return nil, errors.New(`CORS origin "*" cannot be combined with credentials; list the origins or patterns instead`)
+	}
+	anonymous, err := parseOrigins(config.OriginsWithoutCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	options := cors.Options{
+		AllowedMethods: config.AllowedMethods,
+		AllowedHeaders: config.AllowedHeaders,
+		ExposedHeaders: config.ExposedHeaders,
+		MaxAge:         config.MaxAge,
+	}
+	withCredentials := options
+	withCredentials.AllowCredentials = config.AllowCredentials
+	withCredentials.AllowOriginFunc = func(r *http.Request, origin string) bool {
+		return credentialed.match(origin)
+	}
+	withoutCredentials := options
+	withoutCredentials.AllowOriginFunc = func(r *http.Request, origin string) bool {
+		return anonymous.match(origin)
+	}
+
+	return func(next http.Handler) http.Handler {
+		credentialedHandler := cors.Handler(withCredentials)(next)
+		anonymousHandler := cors.Handler(withoutCredentials)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if anonymous.match(r.Header.Get("Origin")) {
+				anonymousHandler.ServeHTTP(w, r)
+				return
+			}
+			credentialedHandler.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// originSet matches origins against exact origins and wildcard patterns
+type originSet struct {
+	any      bool
+	exact    map[string]bool
+	patterns []originPattern
+}
+
+// originPattern matches scheme://<subdomains>.suffix, with suffix including
+// the port if any
+type originPattern struct {
+	scheme string
+	suffix string
+}
+
+func parseOrigins(origins []string) (originSet, error) {
+	set := originSet{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			set.any = true
+			continue
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.ContainsAny(host, "/?#") {
+			return originSet{}, fmt.Errorf("invalid CORS origin %q: want scheme://host[:port]", origin)
+		}
+		if !strings.Contains(host, "*") {
+			set.exact[origin] = true
+			continue
+		}
+		suffix, ok := strings.CutPrefix(host, "*.")
+		if !ok || suffix == "" || strings.Contains(suffix, "*") {
+			return originSet{}, fmt.Errorf("invalid CORS origin %q: a wildcard may only replace the leftmost subdomain, as in https://*.example.com", origin)
+		}
+		set.patterns = append(set.patterns, originPattern{scheme: scheme + "://", suffix: "." + suffix})
+	}
+	return set, nil
+}
+
+func (s originSet) match(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if s.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if s.exact[origin] {
+		return true
+	}
+	for _, p := range s.patterns {
+		if !strings.HasPrefix(origin, p.scheme) || !strings.HasSuffix(origin, p.suffix) {
+			continue
+		}
+		if subdomain := origin[len(p.scheme) : len(origin)-len(p.suffix)]; isSubdomain(subdomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubdomain reports whether s is one or more non-empty DNS labels
+func isSubdomain(s string) bool {
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
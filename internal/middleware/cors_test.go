@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	cors, err := CORS(CORSConfig{
+		AllowedOrigins:            []string{"https://bookwork.app", "https://*.bookwork.app", "http://localhost:5173"},
+		AllowCredentials:          true,
+		OriginsWithoutCredentials: []string{"https://widgets.bookwork.app"},
+		AllowedMethods:            []string{"GET"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	handler := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin      string
+		allowed     bool
+		credentials bool
+	}{
+		{"https://bookwork.app", true, true},
+		{"https://club.bookwork.app", true, true},
+		{"https://eu.club.bookwork.app", true, true},
+		{"https://widgets.bookwork.app", true, false},
+		{"http://localhost:5173", true, true},
+		{"http://club.bookwork.app", false, false},
+		{"https://evilbookwork.app", false, false},
+		{"https://.bookwork.app", false, false},
+		{"https://bookwork.app.evil.com", false, false},
+		{"http://localhost:3000", false, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/clubs", nil)
+		req.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		allowed := w.Header().Get("Access-Control-Allow-Origin") == tt.origin
+		credentials := w.Header().Get("Access-Control-Allow-Credentials") == "true"
+		if allowed != tt.allowed || credentials != tt.credentials {
+			t.Errorf("%s: expected allowed=%v credentials=%v, got allowed=%v credentials=%v", tt.origin, tt.allowed, tt.credentials, allowed, credentials)
+		}
+	}
+}
+
+func TestCORSRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CORSConfig
+	}{
+		{"any origin with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+		{"missing scheme", CORSConfig{AllowedOrigins: []string{"bookwork.app"}}},
+		{"path", CORSConfig{AllowedOrigins: []string{"https://bookwork.app/app"}}},
+		{"wildcard inside a label", CORSConfig{AllowedOrigins: []string{"https://club-*.bookwork.app"}}},
+		{"bare wildcard domain", CORSConfig{AllowedOrigins: []string{"https://*"}}},
+		{"invalid origin without credentials", CORSConfig{OriginsWithoutCredentials: []string{"https://*.*.bookwork.app"}}},
+	}
+	for _, tt := range tests {
+		if _, err := CORS(tt.config); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, err := CORS(CORSConfig{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Errorf("Expected any origin without credentials to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// volatileFields are top-level response fields that change on every request
+// without the content changing, left out of the ETag
+var volatileFields = []string{"timestamp"}
+
+// ConditionalGET adds an ETag to successful GET responses and answers 304 Not
+// Modified when the request's If-None-Match already has it, so clients holding
+// the same payload do not download it again. The handler still runs; what is
+// saved is the transfer.
+//
+// The ETag is weak: it hashes the JSON body without its timestamp, so two
+// responses with the same data match even though their bytes differ.
+func ConditionalGET(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		if bw.status != http.StatusOK {
+			w.WriteHeader(bw.status)
+			w.Write(bw.body.Bytes())
+			return
+		}
+
+		etag := ETag(bw.body.Bytes())
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			for _, name := range []string{"Content-Type", "Content-Length"} {
+				w.Header().Del(name)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(bw.body.Bytes())
+	})
+}
+
+// ETag returns the weak entity tag of a response body
+func ETag(body []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		for _, name := range volatileFields {
+			delete(fields, name)
+		}
+		// Map keys marshal sorted, so equal content hashes equally
+		if stable, err := json.Marshal(fields); err == nil {
+			body = stable
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison of If-None-Match: any listed tag, or
+// *, matches when the opaque parts are equal
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLastModified sets Last-Modified to the latest of times, if any is set.
+// It is informational: ConditionalGET validates with the ETag, because
+// removing an item changes a list without changing any item's updated_at.
+func SetLastModified(w http.ResponseWriter, times ...time.Time) {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	if !latest.IsZero() {
+		w.Header().Set("Last-Modified", latest.UTC().Format(http.TimeFormat))
+	}
+}
+
+// bufferedWriter holds the response body until the handler is done, so the
+// status can still become 304
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (bw *bufferedWriter) WriteHeader(statusCode int) {
+	if !bw.wroteHeader {
+		bw.wroteHeader = true
+		bw.status = statusCode
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	bw.wroteHeader = true
+	return bw.body.Write(b)
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConditionalGET(t *testing.T) {
+	calls := 0
+	handler := ConditionalGET(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		// The timestamp differs on every response; the data does not
+		w.Write([]byte(`{"success":true,"data":{"events":[]},"timestamp":"2030-01-01T00:00:0` + string(rune('0'+calls)) + `Z"}`))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/club/1/events", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.Len() == 0 {
+		t.Fatalf("Expected a 200 with an ETag, got %d %q", w.Code, etag)
+	}
+
+	req := httptest.NewRequest("GET", "/api/club/1/events", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 without a body, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if w.Header().Get("ETag") != etag || w.Header().Get("Content-Type") != "" {
+		t.Errorf("Expected the ETag and no content type on a 304, got %v", w.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/api/club/1/events", nil)
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("Expected a full response for a stale ETag, got %d", w.Code)
+	}
+}
+
+func TestConditionalGETPassesErrorsAndWrites(t *testing.T) {
+	handler := ConditionalGET(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{"error":"NOT_FOUND"}`))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/events/1/availability", nil)
+	req.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" || w.Body.Len() == 0 {
+		t.Errorf("Expected errors to pass through untagged, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/events/1/availability", nil))
+	if w.Header().Get("ETag") != "" {
+		t.Error("Expected writes not to be tagged")
+	}
+}
+
+func TestETagIgnoresKeyOrderAndTimestamp(t *testing.T) {
+	a := ETag([]byte(`{"data":{"id":1},"success":true,"timestamp":"2030-01-01T00:00:00Z"}`))
+	b := ETag([]byte(`{"success":true,"data":{"id":1},"timestamp":"2031-06-01T12:00:00Z"}`))
+	c := ETag([]byte(`{"success":true,"data":{"id":2}}`))
+	if a != b || a == c {
+		t.Errorf("Expected equal data to share an ETag and different data not to: %s %s %s", a, b, c)
+	}
+}
+
+func TestSetLastModified(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetLastModified(w)
+	if w.Header().Get("Last-Modified") != "" {
+		t.Error("Expected no header without times")
+	}
+
+	SetLastModified(w, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2030, 1, 5, 0, 0, 0, 0, time.UTC), time.Time{})
+	if got := w.Header().Get("Last-Modified"); got != "Sat, 05 Jan 2030 00:00:00 GMT" {
+		t.Errorf("Expected the latest time, got %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/models"
+)
+
+// fieldSet is a parsed ?fields= value: each name maps to the fields selected
+// inside it, or nil for the whole value
+type fieldSet map[string]fieldSet
+
+// parseFields reads a comma-separated list of field names, where a dotted
+// name such as user.name selects inside a nested object. It reports false
+// when a name or a part of one is empty.
+func parseFields(raw string) (fieldSet, bool) {
+	fields := fieldSet{}
+	for _, name := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(name), ".")
+		set := fields
+		for i, part := range parts {
+			if part == "" {
+				return nil, false
+			}
+			sub, seen := set[part]
+			if i == len(parts)-1 {
+				// A name on its own selects all of it, whatever else was asked for inside
+				set[part] = nil
+				break
+			}
+			if seen && sub == nil {
+				break // already selected whole
+			}
+			if !seen {
+				sub = fieldSet{}
+				set[part] = sub
+			}
+			set = sub
+		}
+	}
+	return fields, true
+}
+
+// SparseFields trims list responses to the fields named in ?fields=, so a
+// client can ask for only what it shows, as in members?fields=id,name,avatar.
+// Every object in a list under data keeps just the named fields; the rest of
+// the response, such as pagination, is left as it is. Requests without fields
+// and responses that are not successful JSON pass through unchanged.
+func SparseFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.URL.Query().Get("fields")
+		if r.Method != http.MethodGet || raw == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fields, ok := parseFields(raw)
+		if !ok {
+			writeInvalidFields(w)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		body := bw.body.Bytes()
+		if bw.status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			body = selectFields(body, fields)
+			w.Header().Del("Content-Length")
+		}
+		w.WriteHeader(bw.status)
+		w.Write(body)
+	})
+}
+
+// selectFields applies fields to the lists in a response body's data: data
+// itself when it is a list, or each list directly inside it
+func selectFields(body []byte, fields fieldSet) []byte {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+	data := bytes.TrimSpace(response["data"])
+
+	switch {
+	case len(data) > 0 && data[0] == '[':
+		response["data"] = selectIn(data, fields)
+	case len(data) > 0 && data[0] == '{':
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return body
+		}
+		for key, value := range values {
+			if value = bytes.TrimSpace(value); len(value) > 0 && value[0] == '[' {
+				values[key] = selectIn(value, fields)
+			}
+		}
+		response["data"], _ = json.Marshal(values)
+	default:
+		return body
+	}
+
+	trimmed, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	// Match json.Encoder, which the handlers write with
+	return append(trimmed, '\n')
+}
+
+// selectIn keeps fields of an object, or of each object in a list; other
+// values are returned unchanged
+func selectIn(value json.RawMessage, fields fieldSet) json.RawMessage {
+	var list []json.RawMessage
+	if err := json.Unmarshal(value, &list); err == nil {
+		for i := range list {
+			list[i] = selectIn(list[i], fields)
+		}
+		selected, _ := json.Marshal(list)
+		return selected
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(value, &object); err != nil {
+		return value
+	}
+	kept := make(map[string]json.RawMessage, len(fields))
+	for name, inner := range fields {
+		if field, ok := object[name]; ok {
+			if inner != nil {
+				field = selectIn(field, inner)
+			}
+			kept[name] = field
+		}
+	}
+	selected, _ := json.Marshal(kept)
+	return selected
+}
+
+func writeInvalidFields(w http.ResponseWriter) {
+	apierror.Write(w, time.Now(), apierror.Validation("fields must be a comma-separated list of field names",
+		models.InvalidField("fields", "fields", "must be a comma-separated list of field names, such as id,name,user.avatar")))
+}
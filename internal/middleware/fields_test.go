@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, ok := parseFields("id, name,user.avatar,user.name,role.name,role")
+	expected := fieldSet{"id": nil, "name": nil, "user": {"avatar": nil, "name": nil}, "role": nil}
+	if !ok || !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v, got %v", expected, fields)
+	}
+
+	for _, raw := range []string{"id,,name", ".name", "user.", " "} {
+		if _, ok := parseFields(raw); ok {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestSparseFields(t *testing.T) {
+	handler := SparseFields(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"data":{"members":[
+			{"id":"1","name":"Ada","email":"ada@example.com","avatar":null,"user":{"name":"Ada","phone":"1"}},
+			{"id":"2","name":"Bo","email":"bo@example.com","permissions":["read"]}
+		],"pagination":{"page":1,"total":2}},"timestamp":"2030-01-01T00:00:00Z"}`))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/club/1/members?fields=id,avatar,user.name", nil))
+
+	var response struct {
+		Data struct {
+			Members    []map[string]interface{} `json:"members"`
+			Pagination map[string]interface{}   `json:"pagination"`
+		} `json:"data"`
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected JSON, got %s", w.Body.String())
+	}
+
+	expected := []map[string]interface{}{
+		{"id": "1", "avatar": nil, "user": map[string]interface{}{"name": "Ada"}},
+		{"id": "2"},
+	}
+	if !reflect.DeepEqual(response.Data.Members, expected) {
+		t.Errorf("Expected %v, got %v", expected, response.Data.Members)
+	}
+	if response.Data.Pagination["total"] != float64(2) || response.Timestamp == "" {
+		t.Errorf("Expected the rest of the response untouched, got %s", w.Body.String())
+	}
+}
+
+func TestSparseFieldsPassesThrough(t *testing.T) {
+	body := `{"success":false,"data":[{"id":"1","name":"Ada"}]}`
+	status := http.StatusNotFound
+	handler := SparseFields(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	tests := []struct {
+		name, path string
+		status     int
+		expected   string
+	}{
+		{"no fields", "/api/events", http.StatusOK, body},
+		{"error response", "/api/events?fields=id", http.StatusNotFound, body},
+		{"list as data", "/api/events?fields=id", http.StatusOK, `{"data":[{"id":"1"}],"success":false}` + "\n"},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.expected {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.name, tt.status, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/events?fields=id,,name", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected malformed fields to be rejected, got %d", w.Code)
+	}
+}
@@ -5,25 +5,53 @@ import (
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"bookwork-api/internal/models"
+	"bookwork-api/internal/apierror"
 )
 
-// RateLimiter implements rate limiting with sliding window algorithm
+// rateLimiterShards spreads clients over independently locked maps so that
+// concurrent requests from different clients rarely contend
+const rateLimiterShards = 64
+
+// RateLimiter implements rate limiting with fixed-window counters.
+// Each client has a counter updated with atomic operations; the per-shard
+// lock is only taken to look up the counter or to create a new one.
 type RateLimiter struct {
-	requests map[string][]time.Time
+	shards [rateLimiterShards]rateLimiterShard
+	epoch  time.Time
+	limit  int
+	window time.Duration
+	skip   func(*http.Request) bool
+	key    func(*http.Request) string
+}
+
+type rateLimiterShard struct {
 	mutex    sync.RWMutex
-	limit    int
-	window   time.Duration
+	counters map[string]*windowCounter
 }
 
+// windowCounter packs the window number (high 32 bits) and the request count
+// in that window (low 32 bits) so both can be updated in one compare-and-swap
+type windowCounter struct {
+	state atomic.Uint64
+}
+
+// retiredCounter is the state of a counter cleanup has removed. A request that
+// looked the counter up before it went finds this and looks it up again,
+// rather than counting on a counter no one else sees.
+const retiredCounter = ^uint64(0)
+
 // NewRateLimiter creates a new rate limiter instance
 func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
+		epoch:  time.Now(),
+		limit:  limit,
+		window: window,
+	}
+	for i := range rl.shards {
+		rl.shards[i].counters = make(map[string]*windowCounter)
 	}
 
 	// Start cleanup goroutine
@@ -32,92 +60,134 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	return rl
 }
 
-// cleanup removes old request entries periodically
+// WithSkip exempts requests for which skip returns true, e.g. traffic already
+// throttled by its own quota
+func (rl *RateLimiter) WithSkip(skip func(*http.Request) bool) *RateLimiter {
+	rl.skip = skip
+	return rl
+}
+
+// WithKey counts requests by what key returns rather than by client address,
+// e.g. by the authenticated user on routes behind authentication
+func (rl *RateLimiter) WithKey(key func(*http.Request) string) *RateLimiter {
+	rl.key = key
+	return rl
+}
+
+// currentWindow returns the number of the window containing now and the time it ends
+func (rl *RateLimiter) currentWindow(now time.Time) (uint32, time.Time) {
+	n := int64(now.Sub(rl.epoch) / rl.window)
+	return uint32(n), rl.epoch.Add(time.Duration(n+1) * rl.window)
+}
+
+// shard returns the shard holding clientKey (FNV-1a hash)
+func (rl *RateLimiter) shard(clientKey string) *rateLimiterShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(clientKey); i++ {
+		hash ^= uint32(clientKey[i])
+		hash *= 16777619
+	}
+	return &rl.shards[hash%rateLimiterShards]
+}
+
+// counter returns the counter for clientKey, creating it if needed
+func (rl *RateLimiter) counter(clientKey string) *windowCounter {
+	shard := rl.shard(clientKey)
+
+	shard.mutex.RLock()
+	counter, exists := shard.counters[clientKey]
+	shard.mutex.RUnlock()
+	if exists {
+		return counter
+	}
+
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if counter, exists = shard.counters[clientKey]; !exists {
+		counter = &windowCounter{}
+		shard.counters[clientKey] = counter
+	}
+	return counter
+}
+
+// cleanup periodically removes counters of idle clients
 func (rl *RateLimiter) cleanup() {
 	ticker := time.NewTicker(rl.window)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		rl.mutex.Lock()
-		now := time.Now()
-		for key, requests := range rl.requests {
-			// Remove requests older than the window
-			validRequests := make([]time.Time, 0, len(requests))
-			for _, reqTime := range requests {
-				if now.Sub(reqTime) < rl.window {
-					validRequests = append(validRequests, reqTime)
-				}
-			}
+		rl.sweep(time.Now())
+	}
+}
 
-			if len(validRequests) == 0 {
-				delete(rl.requests, key)
-			} else {
-				rl.requests[key] = validRequests
+// sweep removes counters of clients idle since before the window containing
+// now. A counter is retired first, so one a request has just counted on in the
+// current window is kept.
+func (rl *RateLimiter) sweep(now time.Time) {
+	current, _ := rl.currentWindow(now)
+	for i := range rl.shards {
+		shard := &rl.shards[i]
+		shard.mutex.Lock()
+		for key, counter := range shard.counters {
+			state := counter.state.Load()
+			if uint32(state>>32) != current && counter.state.CompareAndSwap(state, retiredCounter) {
+				delete(shard.counters, key)
 			}
 		}
-		rl.mutex.Unlock()
+		shard.mutex.Unlock()
 	}
 }
 
-// getClientKey extracts client identifier for rate limiting
+// getClientKey extracts client identifier for rate limiting. Without WithKey
+// it is the client address: credentials are not verified yet where the global
+// limiter runs, so anything taken from them could be made up per request.
 func (rl *RateLimiter) getClientKey(r *http.Request) string {
-	// Try to get user ID from Authorization header first
-	authHeader := r.Header.Get("Authorization")
-	if authHeader != "" {
-		// Use a hash of the auth header as the key
-		return fmt.Sprintf("auth_%s", authHeader[:min(10, len(authHeader))])
-	}
-
-	// Fallback to IP address
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.Header.Get("X-Real-IP")
+	if rl.key != nil {
+		return "key_" + rl.key(r)
 	}
-	if ip == "" {
-		ip = r.RemoteAddr
-	}
-
-	return fmt.Sprintf("ip_%s", ip)
+	return fmt.Sprintf("ip_%s", ClientIP(r))
 }
 
 // isAllowed checks if the request is within rate limits
 func (rl *RateLimiter) isAllowed(clientKey string) (bool, int, time.Time) {
-	rl.mutex.Lock()
-	defer rl.mutex.Unlock()
+	return rl.count(rl.counter(clientKey), clientKey, time.Now())
+}
 
-	now := time.Now()
+// count counts a request from clientKey on counter, unless the client is over
+// the limit
+func (rl *RateLimiter) count(counter *windowCounter, clientKey string, now time.Time) (bool, int, time.Time) {
+	current, resetTime := rl.currentWindow(now)
 
-	// Get existing requests for this client
-	requests, exists := rl.requests[clientKey]
-	if !exists {
-		requests = make([]time.Time, 0)
-	}
+	for {
+		state := counter.state.Load()
+		if state == retiredCounter {
+			counter = rl.counter(clientKey)
+			continue
+		}
+		count := uint32(state)
+		if uint32(state>>32) != current {
+			// First request in a new window
+			count = 0
+		}
 
-	// Remove requests older than the window
-	validRequests := make([]time.Time, 0, len(requests))
-	for _, reqTime := range requests {
-		if now.Sub(reqTime) < rl.window {
-			validRequests = append(validRequests, reqTime)
+		if int(count) >= rl.limit {
+			return false, 0, resetTime
 		}
-	}
 
-	// Check if we're within the limit
-	if len(validRequests) >= rl.limit {
-		// Calculate reset time (when the oldest request will expire)
-		resetTime := validRequests[0].Add(rl.window)
-		return false, rl.limit - len(validRequests), resetTime
+		if counter.state.CompareAndSwap(state, uint64(current)<<32|uint64(count+1)) {
+			return true, rl.limit - int(count) - 1, resetTime
+		}
 	}
-
-	// Add current request
-	validRequests = append(validRequests, now)
-	rl.requests[clientKey] = validRequests
-
-	return true, rl.limit - len(validRequests), now.Add(rl.window)
 }
 
 // Middleware returns the rate limiting middleware
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.skip != nil && rl.skip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		clientKey := rl.getClientKey(r)
 		allowed, remaining, resetTime := rl.isAllowed(clientKey)
 
@@ -128,27 +198,12 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 
 		if !allowed {
 			w.Header().Set("Retry-After", strconv.FormatInt(int64(time.Until(resetTime).Seconds()), 10))
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusTooManyRequests)
-
-			response := &models.FrontendErrorResponse{
-				Error:      "RateLimitExceeded",
-				Message:    "Too many requests. Please wait before trying again.",
-				StatusCode: http.StatusTooManyRequests,
-				Details: map[string]interface{}{
-					"limit":      rl.limit,
-					"window":     rl.window.String(),
-					"resetAt":    resetTime.Format(time.RFC3339),
-					"retryAfter": int64(time.Until(resetTime).Seconds()),
-				},
-				Timestamp: time.Now().UTC().Format(time.RFC3339),
-			}
-
-			// Use a simple JSON encoder since we can't import the full handlers package
-			jsonResponse := fmt.Sprintf(`{"error":"%s","message":"%s","statusCode":%d,"timestamp":"%s","details":{"limit":%d,"retryAfter":%d}}`,
-				response.Error, response.Message, response.StatusCode, response.Timestamp, rl.limit, int64(time.Until(resetTime).Seconds()))
-
-			w.Write([]byte(jsonResponse))
+			apierror.Write(w, time.Now(), apierror.New(apierror.CodeRateLimited, "Too many requests. Please wait before trying again.", map[string]interface{}{
+				"limit":      rl.limit,
+				"window":     rl.window.String(),
+				"resetAt":    resetTime.Format(time.RFC3339),
+				"retryAfter": int64(time.Until(resetTime).Seconds()),
+			}))
 			return
 		}
 
@@ -3,6 +3,9 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -71,69 +74,100 @@ func TestRateLimiterMiddleware(t *testing.T) {
 	}
 }
 
-func TestRateLimiterWithXRealIP(t *testing.T) {
-	// Create a test handler
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	// Create rate limiter with 1 request per second
-	limiter := NewRateLimiter(1, time.Second)
-	wrappedHandler := limiter.Middleware(testHandler)
-
-	// Test with X-Real-IP header
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Real-IP", "192.168.1.100")
-	w := httptest.NewRecorder()
-
-	wrappedHandler.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Request with X-Real-IP should succeed, got status %d", w.Code)
+func TestRateLimiterIgnoresForgedAddresses(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	handler := ClientAddress(false)(limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	// Each request claims another address, but they all come from one peer
+	for i, forged := range []string{"192.0.2.1", "192.0.2.2"} {
+		req := httptest.NewRequest("POST", "/api/public/clubs/1/contact", nil)
+		req.RemoteAddr = "203.0.113.5:4000"
+		req.Header.Set("X-Forwarded-For", forged)
+		req.Header.Set("X-Real-IP", forged)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		expected := http.StatusOK
+		if i > 0 {
+			expected = http.StatusTooManyRequests
+		}
+		if w.Code != expected {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, expected, w.Code)
+		}
 	}
+}
 
-	// Second request from same IP should be rate limited
-	req2 := httptest.NewRequest("GET", "/test", nil)
-	req2.Header.Set("X-Real-IP", "192.168.1.100")
-	w2 := httptest.NewRecorder()
+func TestRateLimiterBehindTrustedProxy(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	handler := ClientAddress(true)(limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	serve := func(forwarded string) int {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
 
-	wrappedHandler.ServeHTTP(w2, req2)
+	if status := serve("198.51.100.9"); status != http.StatusOK {
+		t.Errorf("Expected the first request to succeed, got %d", status)
+	}
+	// The proxy's entry is last; one the client wrote before it does not count
+	if status := serve("192.0.2.1, 198.51.100.9"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected the same client to be limited, got %d", status)
+	}
+	if status := serve("198.51.100.10"); status != http.StatusOK {
+		t.Errorf("Expected another client behind the proxy to succeed, got %d", status)
+	}
+}
 
-	if w2.Code != http.StatusTooManyRequests {
-		t.Errorf("Second request should be rate limited, got status %d", w2.Code)
+func TestRateLimiterIgnoresAuthorizationHeader(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Unverified tokens must not buy a fresh allowance
+	for i, token := range []string{"Bearer eyJhbGciOi.one", "Bearer eyJhbGciOi.two", "Bearer made-up"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "203.0.113.5:4000"
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		expected := http.StatusOK
+		if i > 0 {
+			expected = http.StatusTooManyRequests
+		}
+		if w.Code != expected {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, expected, w.Code)
+		}
 	}
 }
 
-func TestRateLimiterWithXForwardedFor(t *testing.T) {
-	// Create a test handler
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+func TestRateLimiterWithKey(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute).WithKey(func(r *http.Request) string {
+		return r.Header.Get("X-User")
 	})
-
-	// Create rate limiter with 1 request per second
-	limiter := NewRateLimiter(1, time.Second)
-	wrappedHandler := limiter.Middleware(testHandler)
-
-	// Test with X-Forwarded-For header
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Forwarded-For", "10.0.0.1, 192.168.1.1, 127.0.0.1")
-	w := httptest.NewRecorder()
-
-	wrappedHandler.ServeHTTP(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Request with X-Forwarded-For should succeed, got status %d", w.Code)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(user string) int {
+		req := httptest.NewRequest("POST", "/users/me/password", nil)
+		req.RemoteAddr = "203.0.113.5:4000"
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
 	}
 
-	// Second request from same original IP should be rate limited
-	req2 := httptest.NewRequest("GET", "/test", nil)
-	req2.Header.Set("X-Forwarded-For", "10.0.0.1, 192.168.1.1, 127.0.0.1")
-	w2 := httptest.NewRecorder()
-
-	wrappedHandler.ServeHTTP(w2, req2)
-
-	if w2.Code != http.StatusTooManyRequests {
-		t.Errorf("Second request should be rate limited, got status %d", w2.Code)
+	if status := serve("alice"); status != http.StatusOK {
+		t.Errorf("Expected the first user to succeed, got %d", status)
+	}
+	// Another user from the same address has an allowance of their own
+	if status := serve("bob"); status != http.StatusOK {
+		t.Errorf("Expected another user to succeed, got %d", status)
+	}
+	if status := serve("alice"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected the first user to be limited, got %d", status)
 	}
 }
 
@@ -227,3 +261,149 @@ func TestRateLimiterMultipleClients(t *testing.T) {
 		t.Errorf("First client's second request should be rate limited, got status %d", w3.Code)
 	}
 }
+
+func TestRateLimiterConcurrentRequests(t *testing.T) {
+	// Concurrent requests from one client must never exceed the limit
+	limiter := NewRateLimiter(50, time.Minute)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _, _ := limiter.isAllowed("ip_127.0.0.1"); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 50 {
+		t.Errorf("Expected exactly 50 allowed requests, got %d", allowed.Load())
+	}
+}
+
+func BenchmarkRateLimiterSingleClient(b *testing.B) {
+	limiter := NewRateLimiter(1<<30, time.Minute)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			limiter.isAllowed("ip_127.0.0.1")
+		}
+	})
+}
+
+func BenchmarkRateLimiterManyClients(b *testing.B) {
+	limiter := NewRateLimiter(100, time.Minute)
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "ip_10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			limiter.isAllowed(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func TestRateLimiterCountsAfterCleanup(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Hour)
+	limiter.isAllowed("ip_127.0.0.1")
+	now := time.Now().Add(time.Hour)
+
+	// A request that looked its counter up just before cleanup removed it
+	counter := limiter.counter("ip_127.0.0.1")
+	limiter.sweep(now)
+	if ok, _, _ := limiter.count(counter, "ip_127.0.0.1", now); !ok {
+		t.Fatal("Expected the first request in the new window to be allowed")
+	}
+
+	for i, expected := range []bool{true, false} {
+		if ok, _, _ := limiter.count(limiter.counter("ip_127.0.0.1"), "ip_127.0.0.1", now); ok != expected {
+			t.Errorf("Request %d: expected allowed %v, got %v", i+2, expected, ok)
+		}
+	}
+}
+
+func TestRateLimiterCleanupKeepsCounts(t *testing.T) {
+	keys := make([]string, 500)
+	for i := range keys {
+		keys[i] = "ip_10.0.0." + strconv.Itoa(i)
+	}
+
+	for round := 0; round < 20; round++ {
+		limiter := NewRateLimiter(2, time.Hour)
+		for _, key := range keys {
+			limiter.isAllowed(key)
+		}
+		// Move into the next window, leaving every counter idle as of the last one
+		limiter.epoch = limiter.epoch.Add(-time.Hour)
+
+		allowed := make([]atomic.Int32, len(keys))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			limiter.sweep(time.Now())
+		}()
+		for i := range keys {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 3; j++ {
+					if ok, _, _ := limiter.isAllowed(keys[i]); ok {
+						allowed[i].Add(1)
+					}
+				}
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		for i := range keys {
+			if got := allowed[i].Load(); got != 2 {
+				t.Fatalf("Expected 2 requests from %s allowed in the new window, got %d", keys[i], got)
+			}
+		}
+	}
+}
+
+func TestRateLimiterSkip(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Requests marked exempt are never counted
+	limiter := NewRateLimiter(1, time.Minute).WithSkip(func(r *http.Request) bool {
+		return r.Header.Get("X-Exempt") != ""
+	})
+	wrappedHandler := limiter.Middleware(testHandler)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "127.0.0.1:12345"
+		req.Header.Set("X-Exempt", "1")
+		w := httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Exempt request %d should succeed, got status %d", i+1, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	w := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("First counted request should succeed, got status %d", w.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bookwork-api/internal/logging"
+)
+
+func TestRequestIDPropagation(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var seenID string
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = logging.RequestIDFromContext(r.Context())
+		logging.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := RequestID(logger)(testHandler)
+
+	// A valid client-supplied ID is kept
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(logging.RequestIDHeader, "client-id-123")
+	w := httptest.NewRecorder()
+	wrappedHandler.ServeHTTP(w, req)
+
+	if seenID != "client-id-123" {
+		t.Errorf("Expected request ID client-id-123 in context, got %q", seenID)
+	}
+	if w.Header().Get(logging.RequestIDHeader) != "client-id-123" {
+		t.Errorf("Expected request ID echoed in response header, got %q", w.Header().Get(logging.RequestIDHeader))
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode log record: %v", err)
+	}
+	if record["request_id"] != "client-id-123" {
+		t.Errorf("Expected request_id in log line, got %v", record["request_id"])
+	}
+}
+
+func TestRequestIDGeneratedForInvalidInput(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	wrappedHandler := RequestID(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, input := range []string{"", "has spaces", "bad\nline", strings.Repeat("a", 200)} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set(logging.RequestIDHeader, input)
+		w := httptest.NewRecorder()
+		wrappedHandler.ServeHTTP(w, req)
+
+		id := w.Header().Get(logging.RequestIDHeader)
+		if id == "" || id == input {
+			t.Errorf("Expected a generated request ID for %q, got %q", input, id)
+		}
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	wrappedHandler := RequestID(logger)(RequestLogger(testHandler))
+
+	req := httptest.NewRequest("POST", "/brew", nil)
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode log record: %v", err)
+	}
+	if record["method"] != "POST" || record["path"] != "/brew" || record["status"] != float64(http.StatusTeapot) {
+		t.Errorf("Unexpected request log record: %v", record)
+	}
+	if record["request_id"] == nil {
+		t.Error("Expected request_id on the request log line")
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/logging"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestRecord summarizes a completed request for support lookups
+type RequestRecord struct {
+	RequestID  string    `json:"requestId"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Bytes      int       `json:"bytes"`
+	DurationMs int64     `json:"durationMs"`
+	RemoteAddr string    `json:"remoteAddr"`
+	StartedAt  time.Time `json:"startedAt"`
+}
+
+// RequestRecorder keeps the most recent requests in memory so support staff can
+// match the X-Request-ID from a bug report to what the server did with it
+type RequestRecorder struct {
+	mu      sync.Mutex
+	records []RequestRecord
+	next    int
+	full    bool
+}
+
+// NewRequestRecorder creates a recorder holding up to capacity requests
+func NewRequestRecorder(capacity int) *RequestRecorder {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RequestRecorder{records: make([]RequestRecord, capacity)}
+}
+
+// Middleware records every request once it completes. It must run after RequestID.
+func (rr *RequestRecorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		rr.Record(RequestRecord{
+			RequestID:  logging.RequestIDFromContext(r.Context()),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     status,
+			Bytes:      ww.BytesWritten(),
+			DurationMs: time.Since(start).Milliseconds(),
+			RemoteAddr: r.RemoteAddr,
+			StartedAt:  start,
+		})
+	})
+}
+
+// Record stores a request, evicting the oldest one when the recorder is full
+func (rr *RequestRecorder) Record(record RequestRecord) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.records[rr.next] = record
+	rr.next = (rr.next + 1) % len(rr.records)
+	if rr.next == 0 {
+		rr.full = true
+	}
+}
+
+// Lookup returns the recorded requests with the given ID, oldest first.
+// Client-supplied IDs may be reused (e.g. on retries), so there can be several.
+func (rr *RequestRecorder) Lookup(requestID string) []RequestRecord {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	start, count := 0, rr.next
+	if rr.full {
+		start, count = rr.next, len(rr.records)
+	}
+
+	matches := []RequestRecord{}
+	for i := 0; i < count; i++ {
+		record := rr.records[(start+i)%len(rr.records)]
+		if record.RequestID == requestID {
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+// Throughput counts the recorded requests started at or after since, and how
+// many of them failed with a server error. Counts stop at the recorder's
+// capacity, so busy servers should keep since recent.
+func (rr *RequestRecorder) Throughput(since time.Time) (total, serverErrors int) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	count := rr.next
+	if rr.full {
+		count = len(rr.records)
+	}
+
+	// Requests are recorded when they complete, so start times are not in
+	// order and every record has to be checked
+	for _, record := range rr.records[:count] {
+		if record.StartedAt.Before(since) {
+			continue
+		}
+		total++
+		if record.Status >= http.StatusInternalServerError {
+			serverErrors++
+		}
+	}
+	return total, serverErrors
+}
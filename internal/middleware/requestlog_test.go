@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/logging"
+)
+
+func TestRequestRecorderMiddleware(t *testing.T) {
+	recorder := NewRequestRecorder(10)
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	wrappedHandler := RequestID(logger)(recorder.Middleware(testHandler))
+
+	req := httptest.NewRequest("GET", "/api/events/missing", nil)
+	req.Header.Set(logging.RequestIDHeader, "support-ticket-42")
+	wrappedHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := recorder.Lookup("support-ticket-42")
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 recorded request, got %d", len(records))
+	}
+	if records[0].Status != http.StatusNotFound || records[0].Path != "/api/events/missing" || records[0].Method != "GET" {
+		t.Errorf("Unexpected request record: %+v", records[0])
+	}
+
+	if records := recorder.Lookup("unknown"); len(records) != 0 {
+		t.Errorf("Expected no records for unknown ID, got %d", len(records))
+	}
+}
+
+func TestRequestRecorderEvictsOldest(t *testing.T) {
+	recorder := NewRequestRecorder(3)
+
+	for _, id := range []string{"a", "b", "a", "c", "d"} {
+		recorder.Record(RequestRecord{RequestID: id, Path: "/" + id})
+	}
+
+	// "b" and the first "a" have been evicted
+	if records := recorder.Lookup("b"); len(records) != 0 {
+		t.Errorf("Expected evicted request to be gone, got %d records", len(records))
+	}
+	if records := recorder.Lookup("a"); len(records) != 1 {
+		t.Errorf("Expected 1 remaining record for reused ID, got %d", len(records))
+	}
+	if records := recorder.Lookup("d"); len(records) != 1 {
+		t.Errorf("Expected newest request to be recorded, got %d records", len(records))
+	}
+}
+
+func TestRequestRecorderThroughput(t *testing.T) {
+	recorder := NewRequestRecorder(10)
+	now := time.Now()
+
+	recorder.Record(RequestRecord{Status: http.StatusOK, StartedAt: now.Add(-2 * time.Minute)})
+	recorder.Record(RequestRecord{Status: http.StatusOK, StartedAt: now.Add(-10 * time.Second)})
+	recorder.Record(RequestRecord{Status: http.StatusInternalServerError, StartedAt: now.Add(-5 * time.Second)})
+	recorder.Record(RequestRecord{Status: http.StatusNotFound, StartedAt: now.Add(-20 * time.Second)})
+
+	total, serverErrors := recorder.Throughput(now.Add(-time.Minute))
+	if total != 3 || serverErrors != 1 {
+		t.Errorf("Expected 3 requests and 1 server error, got %d and %d", total, serverErrors)
+	}
+}
@@ -10,6 +10,14 @@ type SecurityConfig struct {
 	EnableHSTS      bool
 	HSTSMaxAge      int
 	EnableHTTPSOnly bool
+	PublicHost      string // where plain HTTP is redirected to with EnableHTTPSOnly
+}
+
+// httpsExemptPaths are the health checks, which load balancers make over plain
+// HTTP and which fail on a redirect
+var httpsExemptPaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
 }
 
 // SecurityHeaders adds security headers to all responses
@@ -26,6 +34,17 @@ func SecurityHeaders(next http.Handler) http.Handler {
 func SecurityHeadersWithConfig(config SecurityConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+
+			// Plain HTTP reaching the router came past a load balancer
+			// without TLS; with native TLS the HTTP listener redirects
+			// before the router. The target is the configured host, not
+			// whatever Host the client sent.
+			if config.EnableHTTPSOnly && !secure && !httpsExemptPaths[r.URL.Path] {
+				http.Redirect(w, r, "https://"+config.PublicHost+r.URL.RequestURI(), http.StatusPermanentRedirect)
+				return
+			}
+
 			// --- EXISTING HEADERS ---
 			// Prevent MIME type sniffing
 			w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -34,7 +53,7 @@ func SecurityHeadersWithConfig(config SecurityConfig) func(http.Handler) http.Ha
 			w.Header().Set("X-Frame-Options", "DENY")
 
 			// Force HTTPS in production
-			if config.EnableHSTS && (r.Header.Get("X-Forwarded-Proto") == "https" || r.TLS != nil) {
+			if config.EnableHSTS && secure {
 				w.Header().Set("Strict-Transport-Security", "max-age="+strconv.Itoa(config.HSTSMaxAge)+"; includeSubDomains; preload")
 			}
 
@@ -58,7 +77,7 @@ func SecurityHeadersWithConfig(config SecurityConfig) func(http.Handler) http.Ha
 			w.Header().Set("Cross-Origin-Embedder-Policy", "require-corp")
 			w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
 
-			// API specific headers
+			// API specific headers. Nothing is cached unless a route opts in with CacheControl.
 			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate")
 			w.Header().Set("Pragma", "no-cache")
 			w.Header().Set("Expires", "0")
@@ -137,3 +137,29 @@ func TestSecurityHeadersPreservesResponse(t *testing.T) {
 		t.Error("Security headers should be added")
 	}
 }
+
+func TestSecurityHeadersHTTPSOnly(t *testing.T) {
+	handler := SecurityHeadersWithConfig(SecurityConfig{EnableHTTPSOnly: true, PublicHost: "api.bookwork.app"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://evil.example.com/api/clubs?limit=5", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != "https://api.bookwork.app/api/clubs?limit=5" {
+		t.Errorf("Expected plain HTTP to be redirected to the public host, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	for _, path := range []string{"/health", "/healthz"} {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://10.0.0.5"+path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %s to be served over plain HTTP, got %d", path, w.Code)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "https://api.bookwork.app/api/clubs", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected HTTPS to be served, got %d", w.Code)
+	}
+}
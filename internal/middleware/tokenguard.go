@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// TokenGuardLimits bounds failed attempts on tokenized public endpoints
+type TokenGuardLimits struct {
+	MaxFailures int           // failures allowed per client IP and per token within Window
+	Window      time.Duration // period over which failures are counted
+	Lockout     time.Duration // how long a client IP or token is refused after MaxFailures
+}
+
+// DefaultTokenGuardLimits allow a mistyped link or two, not enumeration
+var DefaultTokenGuardLimits = TokenGuardLimits{MaxFailures: 10, Window: 15 * time.Minute, Lockout: 15 * time.Minute}
+
+// tokenGuardSweepSize is the number of tracked keys above which expired entries are dropped
+const tokenGuardSweepSize = 10000
+
+type tokenAttempts struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// TokenGuard throttles guessing on endpoints where a URL token is the credential
+// (helper links and similar). Failed attempts (401, 403, 404) are counted per
+// client IP and per token; once either reaches the limit it is locked out and
+// the lockout is written to the security audit log.
+type TokenGuard struct {
+	mu       sync.Mutex
+	attempts map[string]*tokenAttempts
+	limits   TokenGuardLimits
+	db       *database.DB
+}
+
+// NewTokenGuard creates a guard; lockouts are audited to db when it is not nil.
+// Zero limits fall back to DefaultTokenGuardLimits.
+func NewTokenGuard(db *database.DB, limits TokenGuardLimits) *TokenGuard {
+	if limits.MaxFailures <= 0 {
+		limits.MaxFailures = DefaultTokenGuardLimits.MaxFailures
+	}
+	if limits.Window <= 0 {
+		limits.Window = DefaultTokenGuardLimits.Window
+	}
+	if limits.Lockout <= 0 {
+		limits.Lockout = DefaultTokenGuardLimits.Lockout
+	}
+
+	return &TokenGuard{
+		attempts: make(map[string]*tokenAttempts),
+		limits:   limits,
+		db:       db,
+	}
+}
+
+// Middleware guards routes whose URL parameter param holds the token
+func (g *TokenGuard) Middleware(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Tokens are only kept hashed, so the guard never holds live credentials
+			sum := sha256.Sum256([]byte(chi.URLParam(r, param)))
+			tokenHash := hex.EncodeToString(sum[:])
+			ip := ClientIP(r)
+
+			now := time.Now()
+			if until := g.lockedUntil(now, "ip:"+ip, "token:"+tokenHash); !until.IsZero() {
+				writeTooManyAttempts(w, until)
+				return
+			}
+
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			switch ww.Status() {
+			case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+				g.recordFailure(r, now, ip, tokenHash)
+			}
+		})
+	}
+}
+
+// lockedUntil returns the latest lockout expiry among keys, or zero if none is locked
+func (g *TokenGuard) lockedUntil(now time.Time, keys ...string) time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var until time.Time
+	for _, key := range keys {
+		if a, ok := g.attempts[key]; ok && now.Before(a.lockedUntil) && a.lockedUntil.After(until) {
+			until = a.lockedUntil
+		}
+	}
+	return until
+}
+
+func (g *TokenGuard) recordFailure(r *http.Request, now time.Time, ip, tokenHash string) {
+	ipFailures, ipLocked := g.fail(now, "ip:"+ip)
+	tokenFailures, tokenLocked := g.fail(now, "token:"+tokenHash)
+	if !ipLocked && !tokenLocked {
+		return
+	}
+
+	failures := ipFailures
+	if tokenLocked && tokenFailures > failures {
+		failures = tokenFailures
+	}
+
+	logger := logging.FromContext(r.Context())
+	logger.Warn("token endpoint lockout",
+		"ip", ip, "token_hash", tokenHash, "path", r.URL.Path, "failures", failures,
+		"ip_locked", ipLocked, "token_locked", tokenLocked)
+
+	if g.db == nil {
+		return
+	}
+
+	query := `
+		INSERT INTO security_audit_events (event, ip_address, token_hash, path, failures, request_id)
+		VALUES ('token_lockout', $1, $2, $3, $4, $5)`
+
+	_, err := g.db.ExecContext(r.Context(), query, ip, tokenHash, r.URL.Path, failures, logging.RequestIDFromContext(r.Context()))
+	if err != nil {
+		logger.Error("error auditing token lockout", "error", err)
+	}
+}
+
+// fail counts a failure for key and reports the count and whether this failure locked the key
+func (g *TokenGuard) fail(now time.Time, key string) (int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.attempts) > tokenGuardSweepSize {
+		g.sweep(now)
+	}
+
+	a, ok := g.attempts[key]
+	if !ok || now.Sub(a.windowStart) >= g.limits.Window {
+		a = &tokenAttempts{windowStart: now}
+		g.attempts[key] = a
+	}
+
+	a.failures++
+	if a.failures >= g.limits.MaxFailures && !now.Before(a.lockedUntil) {
+		a.lockedUntil = now.Add(g.limits.Lockout)
+		return a.failures, true
+	}
+	return a.failures, false
+}
+
+// sweep drops entries that are neither counting failures nor locked out
+func (g *TokenGuard) sweep(now time.Time) {
+	for key, a := range g.attempts {
+		if now.Sub(a.windowStart) >= g.limits.Window && !now.Before(a.lockedUntil) {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+func writeTooManyAttempts(w http.ResponseWriter, until time.Time) {
+	retryAfter := int64(time.Until(until).Seconds()) + 1
+
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	apierror.Write(w, time.Now(), apierror.New(apierror.CodeTooManyAttempts,
+		"Too many failed attempts. Please wait before trying again.",
+		map[string]interface{}{"retryAfter": retryAfter}))
+}
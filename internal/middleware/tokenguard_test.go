@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupTokenGuardTest(validToken string) http.Handler {
+	guard := NewTokenGuard(nil, TokenGuardLimits{MaxFailures: 3, Window: time.Minute, Lockout: time.Minute})
+
+	r := chi.NewRouter()
+	r.Route("/helper/{token}", func(r chi.Router) {
+		r.Use(guard.Middleware("token"))
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			if chi.URLParam(r, "token") != validToken {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+	return r
+}
+
+func serveToken(handler http.Handler, token, ip string) int {
+	req := httptest.NewRequest("GET", "/helper/"+token+"/", nil)
+	req.RemoteAddr = ip + ":4000"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestTokenGuardLocksOutClientIP(t *testing.T) {
+	handler := setupTokenGuardTest("valid")
+
+	for i := 0; i < 3; i++ {
+		if code := serveToken(handler, "guess", "10.0.0.1"); code != http.StatusNotFound {
+			t.Fatalf("Attempt %d: expected 404, got %d", i+1, code)
+		}
+	}
+
+	// Locked out, even with a valid token
+	if code := serveToken(handler, "valid", "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected locked out client to get 429, got %d", code)
+	}
+
+	// Other clients are unaffected
+	if code := serveToken(handler, "valid", "10.0.0.2"); code != http.StatusOK {
+		t.Errorf("Expected other client to get 200, got %d", code)
+	}
+}
+
+func TestTokenGuardLocksOutToken(t *testing.T) {
+	handler := setupTokenGuardTest("valid")
+
+	// The same token guessed from many addresses
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if code := serveToken(handler, "guess", ip); code != http.StatusNotFound {
+			t.Fatalf("Attempt %d: expected 404, got %d", i+1, code)
+		}
+	}
+
+	if code := serveToken(handler, "guess", "10.0.0.4"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected locked out token to get 429, got %d", code)
+	}
+}
+
+func TestTokenGuardIgnoresSuccess(t *testing.T) {
+	handler := setupTokenGuardTest("valid")
+
+	for i := 0; i < 10; i++ {
+		if code := serveToken(handler, "valid", "10.0.0.1"); code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, code)
+		}
+	}
+}
+
+func TestTokenGuardIgnoresForgedAddresses(t *testing.T) {
+	handler := setupTokenGuardTest("valid")
+
+	// Each guess claims another address, but they all come from one peer
+	for i, forged := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		req := httptest.NewRequest("GET", "/helper/guess"+forged+"/", nil)
+		req.RemoteAddr = "10.0.0.9:4000"
+		req.Header.Set("X-Forwarded-For", forged)
+		req.Header.Set("X-Real-IP", forged)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("Guess %d: expected 404, got %d", i+1, w.Code)
+		}
+	}
+
+	if status := serveToken(handler, "valid", "10.0.0.9"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected the peer to be locked out, got %d", status)
+	}
+}
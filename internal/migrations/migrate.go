@@ -1,11 +1,13 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
-	"log"
-	"path/filepath"
+	"io/fs"
+	"log/slog"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,21 +17,57 @@ import (
 //go:embed sql/*.sql
 var sqlFiles embed.FS
 
+// Phase says whether a migration is safe while the previous app version is
+// still serving traffic. Expand migrations only add (tables, nullable columns,
+// indexes) so old and new versions both work against the result. Contract
+// migrations remove or rename what the old version still uses, so they may only
+// run once every instance of it has been retired.
+//
+// A migration's phase comes from a "-- phase: expand" or "-- phase: contract"
+// line in the leading comments of its up SQL, and is expand if untagged.
+type Phase string
+
+const (
+	Expand   Phase = "expand"
+	Contract Phase = "contract"
+)
+
+// phasePrefix starts the comment line that tags a migration's phase
+const phasePrefix = "-- phase:"
+
+// ErrContractNotAllowed is returned when pending migrations include a contract
+// migration and the migrator has not been told old app versions are retired
+var ErrContractNotAllowed = errors.New("contract migrations are pending but old app versions have not been confirmed retired")
+
 type Migration struct {
 	Version   int
 	Name      string
 	SQL       string
+	DownSQL   string
 	AppliedAt *time.Time
+	// Reversible is set for migrations with a NNN_name.down.sql file
+	Reversible bool
+	Phase      Phase
+	// Tagged is set when Phase was declared rather than defaulted
+	Tagged bool
 }
 
 type Migrator struct {
-	db *sql.DB
+	db            *sql.DB
+	allowContract bool
 }
 
 func NewMigrator(db *sql.DB) *Migrator {
 	return &Migrator{db: db}
 }
 
+// WithContract allows contract migrations to run. Only set it once no instance
+// of an app version older than the pending contract migrations is running.
+func (m *Migrator) WithContract(allowed bool) *Migrator {
+	m.allowContract = allowed
+	return m
+}
+
 // RunMigrations executes all pending migrations
 func (m *Migrator) RunMigrations() error {
 	// Create migrations table if it doesn't exist
@@ -54,20 +92,60 @@ func (m *Migrator) RunMigrations() error {
 		appliedSet[version] = true
 	}
 
+	// Refuse the whole run rather than stop partway at a contract migration
+	if err := m.checkContract(migrations, appliedSet, -1); err != nil {
+		return err
+	}
+
 	// Apply pending migrations
 	for _, migration := range migrations {
 		if !appliedSet[migration.Version] {
 			if err := m.applyMigration(migration); err != nil {
 				return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
 			}
-			log.Printf("Applied migration %03d_%s", migration.Version, migration.Name)
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
 		}
 	}
 
 	return nil
 }
 
-// RollbackMigration rolls back the last applied migration
+// Pending returns how many migrations have not been applied yet. Unlike
+// GetMigrationStatus it only reads, so it suits frequent readiness checks.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return 0, fmt.Errorf("failed to scan migration row: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	pending := 0
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// RollbackMigration rolls back the last applied migration by running its down SQL
 func (m *Migrator) RollbackMigration() error {
 	applied, err := m.getAppliedMigrations()
 	if err != nil {
@@ -78,20 +156,106 @@ func (m *Migrator) RollbackMigration() error {
 		return fmt.Errorf("no migrations to rollback")
 	}
 
-	// Get the last applied migration
-	sort.Sort(sort.Reverse(sort.IntSlice(applied)))
-	lastVersion := applied[0]
+	// Roll back to just below the last applied migration
+	sort.Ints(applied)
+	lastVersion := applied[len(applied)-1]
 
-	// Remove from migrations table
-	_, err = m.db.Exec("DELETE FROM schema_migrations WHERE version = $1", lastVersion)
+	migrations, err := m.loadMigrations()
 	if err != nil {
-		return fmt.Errorf("failed to remove migration record: %w", err)
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	plan, err := rollbackPlan(migrations, applied, lastVersion-1)
+	if err != nil {
+		return err
+	}
+
+	return m.rollback(plan)
+}
+
+// MigrateDownTo rolls back every applied migration above targetVersion, newest
+// first. Version 0 rolls back all migrations. Nothing is rolled back unless every
+// migration in the way has a down file.
+func (m *Migrator) MigrateDownTo(targetVersion int) error {
+	// Create migrations table if it doesn't exist
+	if err := m.createMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	if targetVersion != 0 {
+		var targetExists bool
+		for _, migration := range migrations {
+			if migration.Version == targetVersion {
+				targetExists = true
+				break
+			}
+		}
+		if !targetExists {
+			return fmt.Errorf("migration version %d does not exist", targetVersion)
+		}
+	}
+
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	plan, err := rollbackPlan(migrations, applied, targetVersion)
+	if err != nil {
+		return err
+	}
+
+	if len(plan) == 0 {
+		slog.Info("no migrations to roll back", "target_version", targetVersion)
+		return nil
+	}
+
+	return m.rollback(plan)
+}
+
+// rollbackPlan returns the applied migrations above targetVersion, newest first.
+// It fails if any of them cannot be reversed, so a rollback never stops halfway
+// at a migration without a down file.
+func rollbackPlan(migrations []Migration, applied []int, targetVersion int) ([]Migration, error) {
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	versions := append([]int(nil), applied...)
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	var plan []Migration
+	for _, version := range versions {
+		if version <= targetVersion {
+			break
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d has no migration file", version)
+		}
+		if !migration.Reversible {
+			return nil, fmt.Errorf("migration %d (%s) has no down file and cannot be rolled back", version, migration.Name)
+		}
+		plan = append(plan, migration)
 	}
 
-	log.Printf("Rolled back migration %03d", lastVersion)
-	log.Println("WARNING: Schema changes were not automatically reversed.")
-	log.Println("Manual rollback may be required for data integrity.")
+	return plan, nil
+}
 
+func (m *Migrator) rollback(plan []Migration) error {
+	for _, migration := range plan {
+		if err := m.revertMigration(migration); err != nil {
+			return fmt.Errorf("failed to roll back migration %d: %w", migration.Version, err)
+		}
+		slog.Info("rolled back migration", "version", migration.Version, "name", migration.Name)
+	}
 	return nil
 }
 
@@ -131,13 +295,17 @@ func (m *Migrator) MigrateTo(targetVersion int) error {
 		appliedSet[version] = true
 	}
 
+	if err := m.checkContract(migrations, appliedSet, targetVersion); err != nil {
+		return err
+	}
+
 	// Apply migrations up to target version
 	for _, migration := range migrations {
 		if migration.Version <= targetVersion && !appliedSet[migration.Version] {
 			if err := m.applyMigration(migration); err != nil {
 				return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
 			}
-			log.Printf("Applied migration %03d_%s", migration.Version, migration.Name)
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
 		}
 	}
 
@@ -187,6 +355,30 @@ func (m *Migrator) GetMigrationStatus() ([]Migration, []Migration, error) {
 	return applied, pending, nil
 }
 
+// checkContract fails with ErrContractNotAllowed if any pending migration up to
+// targetVersion (-1 for all) is a contract migration and contract migrations
+// are not allowed
+func (m *Migrator) checkContract(migrations []Migration, applied map[int]bool, targetVersion int) error {
+	if m.allowContract {
+		return nil
+	}
+
+	var blocked []string
+	for _, migration := range migrations {
+		if targetVersion >= 0 && migration.Version > targetVersion {
+			break
+		}
+		if !applied[migration.Version] && migration.Phase == Contract {
+			blocked = append(blocked, fmt.Sprintf("%03d_%s", migration.Version, migration.Name))
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("%w: %s", ErrContractNotAllowed, strings.Join(blocked, ", "))
+	}
+	return nil
+}
+
 func (m *Migrator) createMigrationsTable() error {
 	query := `
 	CREATE TABLE IF NOT EXISTS schema_migrations (
@@ -199,12 +391,24 @@ func (m *Migrator) createMigrationsTable() error {
 }
 
 func (m *Migrator) loadMigrations() ([]Migration, error) {
-	entries, err := sqlFiles.ReadDir("sql")
+	dir, err := fs.Sub(sqlFiles, "sql")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read migration directory: %w", err)
 	}
+	return parseMigrations(dir)
+}
 
-	var migrations []Migration
+// parseMigrations reads the migrations in fsys. A migration is either a single
+// NNN_name.sql file, which cannot be rolled back, or a NNN_name.up.sql file with
+// an optional NNN_name.down.sql that reverses it.
+func parseMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	hasUp := make(map[int]bool)
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
@@ -222,17 +426,50 @@ func (m *Migrator) loadMigrations() ([]Migration, error) {
 		}
 
 		name := strings.TrimSuffix(parts[1], ".sql")
+		down := false
+		if strings.HasSuffix(name, ".down") {
+			name, down = strings.TrimSuffix(name, ".down"), true
+		} else {
+			name = strings.TrimSuffix(name, ".up")
+		}
 
-		content, err := sqlFiles.ReadFile(filepath.Join("sql", filename))
+		content, err := fs.ReadFile(fsys, filename)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read migration file %s: %w", filename, err)
 		}
 
-		migrations = append(migrations, Migration{
-			Version: version,
-			Name:    name,
-			SQL:     string(content),
-		})
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has files with different names: %s and %s", version, migration.Name, name)
+		}
+
+		if down {
+			if migration.Reversible {
+				return nil, fmt.Errorf("migration %d has more than one down file", version)
+			}
+			migration.DownSQL = string(content)
+			migration.Reversible = true
+		} else {
+			if hasUp[version] {
+				return nil, fmt.Errorf("migration %d has more than one up file", version)
+			}
+			migration.SQL = string(content)
+			if migration.Phase, migration.Tagged, err = parsePhase(migration.SQL); err != nil {
+				return nil, fmt.Errorf("migration %d: %w", version, err)
+			}
+			hasUp[version] = true
+		}
+	}
+
+	var migrations []Migration
+	for version, migration := range byVersion {
+		if !hasUp[version] {
+			return nil, fmt.Errorf("migration %d has a down file but no up file", version)
+		}
+		migrations = append(migrations, *migration)
 	}
 
 	// Sort by version
@@ -243,6 +480,29 @@ func (m *Migrator) loadMigrations() ([]Migration, error) {
 	return migrations, nil
 }
 
+// parsePhase reads the phase tag from the leading comments of a migration's up
+// SQL, reporting whether one was found
+func parsePhase(sql string) (Phase, bool, error) {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, phasePrefix); ok {
+			switch phase := Phase(strings.TrimSpace(value)); phase {
+			case Expand, Contract:
+				return phase, true, nil
+			default:
+				return "", false, fmt.Errorf("unknown phase %q", phase)
+			}
+		}
+	}
+	return Expand, false, nil
+}
+
 func (m *Migrator) getAppliedMigrations() ([]int, error) {
 	rows, err := m.db.Query("SELECT version FROM schema_migrations ORDER BY version")
 	if err != nil {
@@ -283,3 +543,25 @@ func (m *Migrator) applyMigration(migration Migration) error {
 
 	return tx.Commit()
 }
+
+func (m *Migrator) revertMigration(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Execute down SQL; an empty down file only removes the migration record
+	if strings.TrimSpace(migration.DownSQL) != "" {
+		if _, err := tx.Exec(migration.DownSQL); err != nil {
+			return fmt.Errorf("failed to execute down migration SQL: %w", err)
+		}
+	}
+
+	// Record migration as no longer applied
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("failed to remove migration record: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,227 @@
+package migrations
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMigrationsPairsUpAndDownFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_initial.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"002_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets ();")},
+		"002_widgets.down.sql": {Data: []byte("DROP TABLE widgets;")},
+		"003_no_down.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"README.md":            {Data: []byte("not a migration")},
+	}
+
+	migrations, err := parseMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Failed to parse migrations: %v", err)
+	}
+
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Reversible || migrations[2].Reversible {
+		t.Error("Expected migrations without down files to be irreversible")
+	}
+	widgets := migrations[1]
+	if widgets.Name != "widgets" || !widgets.Reversible || widgets.SQL != "CREATE TABLE widgets ();" || widgets.DownSQL != "DROP TABLE widgets;" {
+		t.Errorf("Unexpected paired migration: %+v", widgets)
+	}
+}
+
+func TestParseMigrationsRejectsInvalidPairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		error string
+	}{
+		{"down without up", fstest.MapFS{"002_widgets.down.sql": {}}, "no up file"},
+		{"mismatched names", fstest.MapFS{"002_widgets.up.sql": {}, "002_gadgets.down.sql": {}}, "different names"},
+		{"legacy and up file", fstest.MapFS{"002_widgets.sql": {}, "002_widgets.up.sql": {}}, "more than one up file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMigrations(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestRollbackPlan(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "initial"},
+		{Version: 2, Name: "widgets", Reversible: true},
+		{Version: 3, Name: "gadgets", Reversible: true},
+	}
+
+	plan, err := rollbackPlan(migrations, []int{1, 2, 3}, 1)
+	if err != nil {
+		t.Fatalf("Failed to plan rollback: %v", err)
+	}
+	if len(plan) != 2 || plan[0].Version != 3 || plan[1].Version != 2 {
+		t.Errorf("Expected to roll back 3 then 2, got %+v", plan)
+	}
+
+	// Rolling back past an irreversible migration fails before anything runs
+	if _, err := rollbackPlan(migrations, []int{1, 2, 3}, 0); err == nil {
+		t.Error("Expected rollback through an irreversible migration to fail")
+	}
+
+	if plan, err := rollbackPlan(migrations, []int{1}, 1); err != nil || len(plan) != 0 {
+		t.Errorf("Expected nothing to roll back, got %+v, %v", plan, err)
+	}
+}
+
+func TestEmbeddedMigrationsParse(t *testing.T) {
+	migrations, err := NewMigrator(nil).loadMigrations()
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+}
+
+func TestParseMigrationsReadsPhase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_initial.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"002_widgets.up.sql":   {Data: []byte("-- Widgets replace gadgets\n-- phase: contract\n\nDROP TABLE gadgets;")},
+		"002_widgets.down.sql": {Data: []byte("-- phase: expand\nCREATE TABLE gadgets ();")},
+		"003_tagged.up.sql":    {Data: []byte("-- phase: expand\nCREATE TABLE b ();")},
+	}
+
+	migrations, err := parseMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Failed to parse migrations: %v", err)
+	}
+	for i, want := range []Phase{Expand, Contract, Expand} {
+		if migrations[i].Phase != want {
+			t.Errorf("Expected migration %d to be %s, got %s", migrations[i].Version, want, migrations[i].Phase)
+		}
+	}
+
+	// Tags after the leading comments are ignored
+	if phase, tagged, err := parsePhase("CREATE TABLE c ();\n-- phase: contract"); err != nil || phase != Expand || tagged {
+		t.Errorf("Expected a tag below the SQL to be ignored, got %s, %v, %v", phase, tagged, err)
+	}
+	if migrations[0].Tagged || !migrations[2].Tagged {
+		t.Error("Expected only declared phases to be marked as tagged")
+	}
+
+	if _, err := parseMigrations(fstest.MapFS{"004_x.up.sql": {Data: []byte("-- phase: shrink")}}); err == nil {
+		t.Error("Expected an unknown phase to be rejected")
+	}
+}
+
+func TestCheckContract(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "initial", Phase: Expand},
+		{Version: 2, Name: "drop_gadgets", Phase: Contract},
+		{Version: 3, Name: "widgets", Phase: Expand},
+	}
+
+	err := NewMigrator(nil).checkContract(migrations, map[int]bool{1: true}, -1)
+	if !errors.Is(err, ErrContractNotAllowed) || !strings.Contains(err.Error(), "002_drop_gadgets") {
+		t.Errorf("Expected the pending contract migration to be refused, got %v", err)
+	}
+
+	if err := NewMigrator(nil).checkContract(migrations, map[int]bool{1: true}, 1); err != nil {
+		t.Errorf("Expected migrating below the contract migration to be allowed, got %v", err)
+	}
+	if err := NewMigrator(nil).checkContract(migrations, map[int]bool{1: true, 2: true}, -1); err != nil {
+		t.Errorf("Expected an applied contract migration to be ignored, got %v", err)
+	}
+	if err := NewMigrator(nil).WithContract(true).checkContract(migrations, map[int]bool{1: true}, -1); err != nil {
+		t.Errorf("Expected contract migrations to run once allowed, got %v", err)
+	}
+}
+
+// destructiveSQL matches statements that break an app version still reading the old schema
+var destructiveSQL = regexp.MustCompile(`(?i)\b(DROP\s+(TABLE|COLUMN)|RENAME\s+(TO|COLUMN)|ALTER\s+COLUMN\s+\w+\s+(TYPE|SET\s+NOT\s+NULL))\b`)
+
+// TestExpandMigrationsAreNotDestructive refuses type changes, drops and
+// renames in expand migrations, including those that default to expand: they
+// run while the previous app version still serves traffic, so they belong in a
+// contract migration
+func TestExpandMigrationsAreNotDestructive(t *testing.T) {
+	migrations, err := NewMigrator(nil).loadMigrations()
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+
+	for _, migration := range migrations {
+		if migration.Phase != Expand {
+			continue
+		}
+		if match := destructiveSQL.FindString(migration.SQL); match != "" {
+			t.Errorf("Expand migration %03d_%s runs %q; tag it contract or add new schema instead", migration.Version, migration.Name, match)
+		}
+	}
+}
+
+// tableColumns replays the CREATE TABLE and ADD, DROP and RENAME COLUMN
+// statements of migrations on table and returns the columns it ends up with
+func tableColumns(migrations []Migration, table string) map[string]bool {
+	statement := regexp.MustCompile(`(?is)CREATE TABLE (?:IF NOT EXISTS )?` + table + `\s*\((.*?)\n\)[^;]*;` +
+		`|ALTER TABLE (?:IF EXISTS )?` + table + `\s+(ADD|DROP|RENAME) COLUMN (?:IF (?:NOT )?EXISTS )?(\w+)(?:\s+TO\s+(\w+))?`)
+
+	columns := map[string]bool{}
+	for _, migration := range migrations {
+		for _, match := range statement.FindAllStringSubmatch(migration.SQL, -1) {
+			switch strings.ToUpper(match[2]) {
+			case "ADD":
+				columns[match[3]] = true
+			case "DROP":
+				delete(columns, match[3])
+			case "RENAME":
+				delete(columns, match[3])
+				columns[match[4]] = true
+			default:
+				for _, line := range strings.Split(match[1], "\n") {
+					fields := strings.Fields(line)
+					if len(fields) == 0 || strings.HasPrefix(fields[0], "--") {
+						continue
+					}
+					switch strings.ToUpper(fields[0]) {
+					case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "EXCLUDE":
+						continue
+					}
+					columns[fields[0]] = true
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// TestEventArchiveKeepsEveryColumn checks that an event moved into the
+// archive keeps every column it had in the hot table
+func TestEventArchiveKeepsEveryColumn(t *testing.T) {
+	migrations, err := NewMigrator(nil).loadMigrations()
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+
+	events := tableColumns(migrations, "events")
+	archive := tableColumns(migrations, "events_archive")
+	if !events["title"] || !archive["title"] {
+		t.Fatalf("Expected to find the events and events_archive tables, got %v and %v", events, archive)
+	}
+	for column := range events {
+		// Soft-deleted events are never archived
+		if column == "deleted_at" || column == "deleted_by" {
+			continue
+		}
+		if !archive[column] {
+			t.Errorf("events_archive has no %s column, so archiving an event would lose it", column)
+		}
+	}
+}
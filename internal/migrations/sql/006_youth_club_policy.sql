@@ -0,0 +1,9 @@
+-- Youth club safety mode
+-- Clubs flagged as youth clubs get the stricter policy bundle, which
+-- requires a guardian email on file for every member
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS youth_mode BOOLEAN DEFAULT false;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS guardian_email VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_clubs_youth_mode ON clubs(youth_mode) WHERE youth_mode = true;
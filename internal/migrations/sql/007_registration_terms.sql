@@ -0,0 +1,16 @@
+-- Registration age gate and terms acceptance audit
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE;
+
+-- Terms Acceptances Table - Audit trail of accepted terms of service versions
+CREATE TABLE IF NOT EXISTS terms_acceptances (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    terms_version VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(500),
+    accepted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, terms_version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_terms_acceptances_user_id ON terms_acceptances(user_id);
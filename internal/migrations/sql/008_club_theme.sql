@@ -0,0 +1,4 @@
+-- Club theming used for printed materials (name tags, sign-in sheets)
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS brand_color VARCHAR(7)
+    CHECK (brand_color IS NULL OR brand_color ~ '^#[0-9A-Fa-f]{6}$');
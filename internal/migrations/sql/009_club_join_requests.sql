@@ -0,0 +1,19 @@
+-- Self-service club membership: approval queue for private clubs
+
+-- Club Join Requests Table - Pending requests to join private clubs
+CREATE TABLE IF NOT EXISTS club_join_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    message TEXT,
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only one open request per user and club
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_join_requests_pending
+    ON club_join_requests(club_id, user_id) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_club_join_requests_club_status ON club_join_requests(club_id, status);
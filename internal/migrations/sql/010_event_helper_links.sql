@@ -0,0 +1,17 @@
+-- Signed, expiring links that give non-members (e.g. a venue contact)
+-- access to selected coordination items of a single event
+
+CREATE TABLE IF NOT EXISTS event_helper_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID REFERENCES events(id) ON DELETE CASCADE,
+    label VARCHAR(255) NOT NULL,
+    item_ids UUID[] NOT NULL DEFAULT '{}',
+    can_update BOOLEAN DEFAULT false,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_helper_links_event_id ON event_helper_links(event_id);
@@ -0,0 +1,3 @@
+-- Per-user timezone preference (IANA name) used to localize event times
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) DEFAULT 'UTC';
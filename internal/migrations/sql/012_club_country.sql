@@ -0,0 +1,4 @@
+-- Club country (ISO 3166-1 alpha-2) used for public holiday warnings
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS country VARCHAR(2)
+    CHECK (country IS NULL OR country ~ '^[A-Z]{2}$');
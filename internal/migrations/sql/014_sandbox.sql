@@ -0,0 +1,11 @@
+-- Sandbox accounts and clubs let integrators test against production.
+-- They are kept apart from real data and purged after sandbox_expires_at.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_sandbox BOOLEAN DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sandbox_expires_at TIMESTAMP;
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS is_sandbox BOOLEAN DEFAULT false;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS sandbox_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_sandbox_expires_at ON users(sandbox_expires_at) WHERE is_sandbox = true;
+CREATE INDEX IF NOT EXISTS idx_clubs_sandbox_expires_at ON clubs(sandbox_expires_at) WHERE is_sandbox = true;
@@ -0,0 +1,13 @@
+-- Refresh tokens are rotated on every refresh and looked up by their token ID (jti claim).
+-- Tokens issued from one login form a family; presenting an already rotated token
+-- revokes the whole family, since the token must have been stolen.
+-- Tokens issued before this migration have no token ID and must be replaced by logging in again.
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS token_id UUID UNIQUE;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS replaced_by UUID;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
+
+UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW() WHERE token_id IS NULL AND is_revoked = false;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS availability_counts_trigger ON availability;
+DROP FUNCTION IF EXISTS repair_availability_counts();
+DROP FUNCTION IF EXISTS update_availability_counts();
+DROP TABLE IF EXISTS availability_counts;
@@ -0,0 +1,71 @@
+-- Availability counters per event and status, so summaries of large events
+-- do not scan every response. Maintained by a trigger on availability and
+-- corrected periodically by repair_availability_counts().
+
+CREATE TABLE IF NOT EXISTS availability_counts (
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (event_id, status)
+);
+
+CREATE OR REPLACE FUNCTION update_availability_counts()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.event_id IS NOT NULL THEN
+        UPDATE availability_counts SET count = count - 1
+        WHERE event_id = OLD.event_id AND status = OLD.status;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.event_id IS NOT NULL THEN
+        INSERT INTO availability_counts (event_id, status, count)
+        VALUES (NEW.event_id, NEW.status, 1)
+        ON CONFLICT (event_id, status) DO UPDATE SET count = availability_counts.count + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS availability_counts_trigger ON availability;
+CREATE TRIGGER availability_counts_trigger
+    AFTER INSERT OR DELETE OR UPDATE OF event_id, status ON availability
+    FOR EACH ROW EXECUTE FUNCTION update_availability_counts();
+
+-- Recompute counters that drifted from the availability rows; returns the number corrected
+CREATE OR REPLACE FUNCTION repair_availability_counts()
+RETURNS INTEGER AS $$
+DECLARE
+    repaired_count INTEGER;
+BEGIN
+    -- Block writes while comparing so concurrent trigger updates are not overwritten
+    LOCK TABLE availability IN SHARE MODE;
+
+    WITH actual AS (
+        SELECT event_id, status, COUNT(*)::INTEGER AS count
+        FROM availability
+        WHERE event_id IS NOT NULL
+        GROUP BY event_id, status
+    ),
+    drift AS (
+        SELECT COALESCE(a.event_id, c.event_id) AS event_id,
+               COALESCE(a.status, c.status) AS status,
+               COALESCE(a.count, 0) AS count
+        FROM actual a
+        FULL OUTER JOIN availability_counts c ON c.event_id = a.event_id AND c.status = a.status
+        WHERE COALESCE(a.count, 0) <> COALESCE(c.count, 0)
+    )
+    INSERT INTO availability_counts (event_id, status, count)
+    SELECT event_id, status, count FROM drift
+    ON CONFLICT (event_id, status) DO UPDATE SET count = EXCLUDED.count;
+
+    GET DIAGNOSTICS repaired_count = ROW_COUNT;
+
+    DELETE FROM availability_counts WHERE count = 0;
+
+    RETURN repaired_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Backfill counters for existing responses
+SELECT repair_availability_counts();
@@ -0,0 +1,18 @@
+-- Archived events cannot be moved back into the hot tables, so refuse to drop
+-- an archive that still holds them. Partitions already detached into
+-- standalone tables (e.g. events_archive_2019) are left alone.
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM events_archive) OR EXISTS (SELECT 1 FROM availability_archive) THEN
+        RAISE EXCEPTION 'event archive is not empty; export or detach archived events before rolling back';
+    END IF;
+END;
+$$;
+
+DROP FUNCTION IF EXISTS detach_event_archive_partitions(INTEGER);
+DROP FUNCTION IF EXISTS archive_events_before(DATE);
+DROP FUNCTION IF EXISTS ensure_event_archive_partitions(INTEGER);
+
+DROP TABLE IF EXISTS availability_archive;
+DROP TABLE IF EXISTS events_archive;
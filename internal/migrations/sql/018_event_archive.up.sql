@@ -0,0 +1,113 @@
+-- Cold storage for past events, keeping the hot events and availability tables small
+-- as long-running clubs accumulate history.
+--
+-- The hot tables are not partitioned themselves: several tables reference events(id),
+-- and foreign keys to a partitioned table must include its partition key. Instead,
+-- old events and their availability move into archive tables partitioned by year,
+-- and old yearly partitions can be detached into standalone tables for export or removal.
+
+CREATE TABLE IF NOT EXISTS events_archive (
+    id UUID NOT NULL,
+    club_id UUID,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    event_date DATE NOT NULL,
+    event_time TIME NOT NULL,
+    location VARCHAR(255) NOT NULL,
+    book VARCHAR(255),
+    type VARCHAR(50),
+    max_attendees INTEGER,
+    is_public BOOLEAN DEFAULT false,
+    created_by UUID,
+    attendees UUID[] DEFAULT '{}',
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, event_date)
+) PARTITION BY RANGE (event_date);
+
+CREATE INDEX IF NOT EXISTS idx_events_archive_club_date ON events_archive(club_id, event_date);
+
+CREATE TABLE IF NOT EXISTS availability_archive (
+    id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_date DATE NOT NULL,
+    user_id UUID,
+    status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    updated_at TIMESTAMP,
+    PRIMARY KEY (id, event_date)
+) PARTITION BY RANGE (event_date);
+
+CREATE INDEX IF NOT EXISTS idx_availability_archive_event ON availability_archive(event_id);
+
+-- Create the yearly archive partitions for archive_year if they do not exist
+CREATE OR REPLACE FUNCTION ensure_event_archive_partitions(archive_year INTEGER)
+RETURNS VOID AS $$
+BEGIN
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF events_archive FOR VALUES FROM (%L) TO (%L)',
+        'events_archive_' || archive_year, make_date(archive_year, 1, 1), make_date(archive_year + 1, 1, 1));
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF availability_archive FOR VALUES FROM (%L) TO (%L)',
+        'availability_archive_' || archive_year, make_date(archive_year, 1, 1), make_date(archive_year + 1, 1, 1));
+END;
+$$ LANGUAGE plpgsql;
+
+-- Move events dated before cutoff, with their availability, into the archive.
+-- Deleting the hot rows cascades to event items, helper links and notifications.
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+BEGIN
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events WHERE event_date < cutoff
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.event_date < cutoff
+    ON CONFLICT DO NOTHING;
+
+    WITH moved AS (
+        DELETE FROM events WHERE event_date < cutoff
+        RETURNING id, club_id, title, description, event_date, event_time, location, book, type,
+                  max_attendees, is_public, created_by, attendees, created_at, updated_at
+    )
+    INSERT INTO events_archive (id, club_id, title, description, event_date, event_time, location, book, type,
+                                max_attendees, is_public, created_by, attendees, created_at, updated_at)
+    SELECT * FROM moved
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Detach archive partitions for years before before_year. Detached partitions stay
+-- as standalone tables (e.g. events_archive_2019) until dumped or dropped by an operator.
+CREATE OR REPLACE FUNCTION detach_event_archive_partitions(before_year INTEGER)
+RETURNS INTEGER AS $$
+DECLARE
+    part RECORD;
+    detached_count INTEGER := 0;
+BEGIN
+    FOR part IN
+        SELECT child.relname AS partition_name, parent.relname AS parent_name
+        FROM pg_inherits i
+        JOIN pg_class child ON child.oid = i.inhrelid
+        JOIN pg_class parent ON parent.oid = i.inhparent
+        WHERE parent.relname IN ('events_archive', 'availability_archive')
+          AND substring(child.relname FROM '_(\d{4})$')::INTEGER < before_year
+    LOOP
+        EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', part.parent_name, part.partition_name);
+        detached_count := detached_count + 1;
+    END LOOP;
+
+    RETURN detached_count;
+END;
+$$ LANGUAGE plpgsql;
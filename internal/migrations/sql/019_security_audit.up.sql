@@ -0,0 +1,15 @@
+-- Audit trail of security events, such as lockouts after repeated
+-- failed attempts on tokenized public links
+
+CREATE TABLE IF NOT EXISTS security_audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45),
+    token_hash VARCHAR(64),
+    path TEXT,
+    failures INTEGER,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_audit_events_created ON security_audit_events(created_at DESC);
@@ -0,0 +1,11 @@
+-- Waitlist entries have no equivalent before this migration and are cancelled
+
+UPDATE club_join_requests SET status = 'cancelled', decided_at = NOW() WHERE status = 'waitlisted';
+
+DROP INDEX IF EXISTS idx_club_join_requests_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_join_requests_pending
+    ON club_join_requests(club_id, user_id) WHERE status = 'pending';
+
+ALTER TABLE club_join_requests DROP CONSTRAINT IF EXISTS club_join_requests_status_check;
+ALTER TABLE club_join_requests ADD CONSTRAINT club_join_requests_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled'));
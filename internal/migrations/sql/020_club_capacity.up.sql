@@ -0,0 +1,11 @@
+-- Club membership caps: members can join a waitlist when a club is full.
+-- Waitlist entries are join requests with status 'waitlisted'.
+
+ALTER TABLE club_join_requests DROP CONSTRAINT IF EXISTS club_join_requests_status_check;
+ALTER TABLE club_join_requests ADD CONSTRAINT club_join_requests_status_check
+    CHECK (status IN ('pending', 'waitlisted', 'approved', 'rejected', 'cancelled'));
+
+-- Only one open request (pending or waitlisted) per user and club
+DROP INDEX IF EXISTS idx_club_join_requests_pending;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_join_requests_open
+    ON club_join_requests(club_id, user_id) WHERE status IN ('pending', 'waitlisted');
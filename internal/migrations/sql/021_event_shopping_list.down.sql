@@ -0,0 +1,14 @@
+-- Items in categories the original check does not know become 'other'
+
+DROP TABLE IF EXISTS event_shopping_lists;
+
+ALTER TABLE event_items DROP COLUMN IF EXISTS cost;
+ALTER TABLE event_items DROP COLUMN IF EXISTS unit;
+ALTER TABLE event_items DROP COLUMN IF EXISTS quantity;
+
+UPDATE event_items SET category = 'other'
+WHERE category NOT IN ('agenda', 'task', 'material', 'note', 'other');
+
+ALTER TABLE event_items DROP CONSTRAINT IF EXISTS event_items_category_check;
+ALTER TABLE event_items ADD CONSTRAINT event_items_category_check
+    CHECK (category IN ('agenda', 'task', 'material', 'note', 'other'));
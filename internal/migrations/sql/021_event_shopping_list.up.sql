@@ -0,0 +1,19 @@
+-- Quantities and costs on event items, so an event's food items can be
+-- consolidated into one shopping list, plus an optional shopper for the list
+-- as a whole. The category check is widened to the categories the API accepts
+-- (e.g. 'food'), which the original check rejected.
+
+ALTER TABLE event_items DROP CONSTRAINT IF EXISTS event_items_category_check;
+ALTER TABLE event_items ADD CONSTRAINT event_items_category_check
+    CHECK (category IN ('agenda', 'task', 'material', 'note', 'other',
+                        'food', 'materials', 'logistics', 'discussion', 'presentation'));
+
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS quantity NUMERIC(10, 2) CHECK (quantity > 0);
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS unit VARCHAR(20);
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS cost NUMERIC(10, 2) CHECK (cost >= 0);
+
+CREATE TABLE IF NOT EXISTS event_shopping_lists (
+    event_id UUID PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
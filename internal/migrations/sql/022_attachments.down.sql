@@ -0,0 +1,3 @@
+-- Stored file contents are not removed; delete them from the storage backend separately
+
+DROP TABLE IF EXISTS attachments;
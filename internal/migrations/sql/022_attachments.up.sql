@@ -0,0 +1,18 @@
+-- File attachments on event items (e.g. discussion guides) and club resources
+-- (e.g. venue maps). Contents live in the configured storage backend under
+-- storage_key; rows without an event item are club resources.
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    event_item_id UUID REFERENCES event_items(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_club ON attachments(club_id) WHERE event_item_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_attachments_event_item ON attachments(event_item_id);
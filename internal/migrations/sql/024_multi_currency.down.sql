@@ -0,0 +1,9 @@
+-- Costs are kept as entered; their currency and rate snapshot are dropped
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE event_items DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE event_items DROP COLUMN IF EXISTS cost_currency;
+ALTER TABLE event_items ALTER COLUMN cost TYPE NUMERIC(10, 2);
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS currency;
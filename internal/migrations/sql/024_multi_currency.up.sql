@@ -0,0 +1,26 @@
+-- Clubs keep their books in one currency. Item costs may be entered in another
+-- currency; the exchange rate to the club currency is snapshotted with the cost,
+-- so later rate changes never alter past costs. Rates are maintained by admins.
+-- Widening cost keeps every existing value, but it rewrites event_items under
+-- an exclusive lock, so it runs as a contract migration like any other retype.
+-- phase: contract
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Room for currencies with three decimal places (e.g. KWD)
+ALTER TABLE event_items ALTER COLUMN cost TYPE NUMERIC(14, 3);
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS cost_currency CHAR(3);
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC(20, 10) CHECK (exchange_rate > 0);
+
+-- Existing costs were entered in the default club currency
+UPDATE event_items SET cost_currency = 'USD', exchange_rate = 1
+WHERE cost IS NOT NULL AND cost_currency IS NULL;
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency)
+);
@@ -0,0 +1,12 @@
+-- Treasurers go back to being plain members
+
+DROP TABLE IF EXISTS dues_payments;
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS dues_required_for_rsvp;
+ALTER TABLE clubs DROP COLUMN IF EXISTS dues_period;
+ALTER TABLE clubs DROP COLUMN IF EXISTS dues_amount;
+
+UPDATE club_members SET role = 'member' WHERE role = 'treasurer';
+ALTER TABLE club_members DROP CONSTRAINT IF EXISTS club_members_role_check;
+ALTER TABLE club_members ADD CONSTRAINT club_members_role_check
+    CHECK (role IN ('admin', 'moderator', 'member', 'guest'));
@@ -0,0 +1,31 @@
+-- Optional membership dues. A club charges dues_amount (in the club currency)
+-- every dues_period; each payment covers one period, identified by its first day.
+-- Treasurers are club members who record payments and see who has paid.
+
+ALTER TABLE club_members DROP CONSTRAINT IF EXISTS club_members_role_check;
+ALTER TABLE club_members ADD CONSTRAINT club_members_role_check
+    CHECK (role IN ('admin', 'moderator', 'member', 'guest', 'treasurer'));
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS dues_amount NUMERIC(14, 3) CHECK (dues_amount > 0);
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS dues_period VARCHAR(10)
+    CHECK (dues_period IN ('monthly', 'quarterly', 'yearly'));
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS dues_required_for_rsvp BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS dues_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(14, 3) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    period_start DATE NOT NULL,
+    method VARCHAR(10) NOT NULL CHECK (method IN ('manual', 'stripe')),
+    -- The provider's payment ID, so a redelivered webhook is recorded once
+    external_id VARCHAR(255) UNIQUE,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    paid_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dues_payments_member ON dues_payments(club_id, user_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_dues_payments_club ON dues_payments(club_id, paid_at DESC);
@@ -0,0 +1,46 @@
+-- Soft-deleted rows are purged: without deleted_at they would reappear
+
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+BEGIN
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events WHERE event_date < cutoff
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.event_date < cutoff
+    ON CONFLICT DO NOTHING;
+
+    WITH moved AS (
+        DELETE FROM events WHERE event_date < cutoff
+        RETURNING id, club_id, title, description, event_date, event_time, location, book, type,
+                  max_attendees, is_public, created_by, attendees, created_at, updated_at
+    )
+    INSERT INTO events_archive (id, club_id, title, description, event_date, event_time, location, book, type,
+                                max_attendees, is_public, created_by, attendees, created_at, updated_at)
+    SELECT * FROM moved
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
+
+DELETE FROM events WHERE deleted_at IS NOT NULL;
+DELETE FROM clubs WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_clubs_deleted_at;
+DROP INDEX IF EXISTS idx_events_deleted_at;
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE clubs DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
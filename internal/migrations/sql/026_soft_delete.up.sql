@@ -0,0 +1,47 @@
+-- Soft delete for events and clubs. Deleting sets deleted_at and keeps the row and
+-- everything hanging off it (items, availability, members) so a global admin can
+-- restore it; purging removes it for good. Soft-deleted rows are hidden from every
+-- query, and soft-deleted events are left out of archiving until restored or purged.
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_deleted_at ON events(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_clubs_deleted_at ON clubs(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+BEGIN
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events
+        WHERE event_date < cutoff AND deleted_at IS NULL
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.event_date < cutoff AND e.deleted_at IS NULL
+    ON CONFLICT DO NOTHING;
+
+    WITH moved AS (
+        DELETE FROM events WHERE event_date < cutoff AND deleted_at IS NULL
+        RETURNING id, club_id, title, description, event_date, event_time, location, book, type,
+                  max_attendees, is_public, created_by, attendees, created_at, updated_at
+    )
+    INSERT INTO events_archive (id, club_id, title, description, event_date, event_time, location, book, type,
+                                max_attendees, is_public, created_by, attendees, created_at, updated_at)
+    SELECT * FROM moved
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_contributions;
+DROP TABLE IF EXISTS event_contribution_goals;
@@ -0,0 +1,33 @@
+-- One-off contributions towards an event cost such as venue rental. An event has at
+-- most one goal: a target in the club currency and, optionally, a Stripe Payment Link
+-- members are sent to. Contributions are recorded by treasurers or from Stripe.
+-- Anonymous contributions keep the contributor for the club's books but hide them
+-- from other members.
+
+CREATE TABLE IF NOT EXISTS event_contribution_goals (
+    event_id UUID PRIMARY KEY REFERENCES events(id) ON DELETE CASCADE,
+    target_amount NUMERIC(14, 3) NOT NULL CHECK (target_amount > 0),
+    currency CHAR(3) NOT NULL,
+    description TEXT,
+    payment_link TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS event_contributions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    contributor_name VARCHAR(255),
+    amount NUMERIC(14, 3) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    anonymous BOOLEAN NOT NULL DEFAULT false,
+    method VARCHAR(10) NOT NULL CHECK (method IN ('manual', 'stripe')),
+    external_id VARCHAR(255) UNIQUE,
+    notes TEXT,
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_contributions_event ON event_contributions(event_id, created_at DESC);
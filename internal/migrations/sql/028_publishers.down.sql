@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS publisher_usage;
+DROP TABLE IF EXISTS publishers;
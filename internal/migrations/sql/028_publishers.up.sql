@@ -0,0 +1,31 @@
+-- Publishers are third-party sites embedding club widgets. Every request from a
+-- publisher carries its public key; server-side integrations also sign requests
+-- with the secret, which is kept in plain text because HMAC verification needs it.
+-- Signed requests are throttled by the publisher's own quota instead of the
+-- per-client limit.
+
+CREATE TABLE IF NOT EXISTS publishers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    api_key VARCHAR(64) NOT NULL UNIQUE,
+    secret VARCHAR(128) NOT NULL,
+    quota_per_minute INTEGER NOT NULL CHECK (quota_per_minute > 0),
+    replay_window_seconds INTEGER NOT NULL CHECK (replay_window_seconds > 0),
+    require_signature BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Daily request counts per publisher and route pattern. Requests refused before
+-- routing (bad signatures, exhausted quota) are counted under the route '*'.
+CREATE TABLE IF NOT EXISTS publisher_usage (
+    publisher_id UUID NOT NULL REFERENCES publishers(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    signed BIGINT NOT NULL DEFAULT 0,
+    throttled BIGINT NOT NULL DEFAULT 0,
+    rejected BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (publisher_id, day, route)
+);
@@ -0,0 +1,12 @@
+-- Events are moving from separate event_date and event_time columns to a single
+-- starts_at timestamp (the club's local wall-clock time, like the old columns).
+-- Both are written while the refactor is shadowed (SHADOW_EVENT_STARTS_AT); once
+-- reads have run clean in cutover mode, a later contract migration drops the old
+-- columns.
+-- phase: expand
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP;
+
+-- Rows written by app versions that predate dual writes are reported as missing
+-- on shadow reads; rerunning this backfill fills them in
+UPDATE events SET starts_at = event_date + event_time WHERE starts_at IS NULL;
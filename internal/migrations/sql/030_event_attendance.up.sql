@@ -0,0 +1,16 @@
+-- Headcounts recorded for past events, with the RSVPs each event had at the
+-- time, for forecasting the attendance of upcoming events from a club's history.
+-- Rows are keyed by club rather than referencing events so the history survives
+-- events moving to the archive.
+
+CREATE TABLE IF NOT EXISTS event_attendance (
+    event_id UUID PRIMARY KEY,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    event_date DATE NOT NULL,
+    rsvps INTEGER NOT NULL CHECK (rsvps >= 0),
+    attended INTEGER NOT NULL CHECK (attended >= 0),
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_attendance_club ON event_attendance(club_id, event_date DESC);
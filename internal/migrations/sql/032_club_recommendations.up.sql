@@ -0,0 +1,17 @@
+-- "Clubs like this" for public club pages, recomputed by a scheduled job from
+-- tag overlap, shared books and location. Each row keeps the score of every
+-- signal so recommendations can explain themselves.
+
+CREATE TABLE IF NOT EXISTS club_recommendations (
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    similar_club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL CHECK (rank > 0),
+    score NUMERIC(5, 4) NOT NULL,
+    tag_score NUMERIC(5, 4) NOT NULL,
+    book_score NUMERIC(5, 4) NOT NULL,
+    location_score NUMERIC(5, 4) NOT NULL,
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (club_id, similar_club_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_recommendations_rank ON club_recommendations(club_id, rank);
@@ -0,0 +1,17 @@
+-- Shareable invite links to a club. The link's token is only stored hashed;
+-- it is shown once when the invite is created. max_uses is 1 for a single-use
+-- link and NULL for a link anyone holding it can use until it expires.
+
+CREATE TABLE IF NOT EXISTS club_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    max_uses INTEGER CHECK (max_uses > 0),
+    use_count INTEGER NOT NULL DEFAULT 0 CHECK (use_count >= 0),
+    expires_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_invites_club ON club_invites(club_id, created_at DESC);
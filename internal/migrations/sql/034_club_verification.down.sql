@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS club_verification_requests;
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS verified_at;
+ALTER TABLE clubs DROP COLUMN IF EXISTS verification_type;
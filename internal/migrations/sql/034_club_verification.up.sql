@@ -0,0 +1,28 @@
+-- Verified clubs: libraries, bookstores and official partners confirmed by a
+-- platform admin. Owners apply through club_verification_requests; an admin
+-- approving the application, or verifying the club directly, sets the badge.
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS verification_type VARCHAR(20)
+    CHECK (verification_type IN ('library', 'bookstore', 'partner'));
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS club_verification_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    verification_type VARCHAR(20) NOT NULL CHECK (verification_type IN ('library', 'bookstore', 'partner')),
+    organization VARCHAR(255) NOT NULL,
+    website VARCHAR(500),
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    review_note TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A club has at most one application waiting for review
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_verification_requests_pending
+    ON club_verification_requests(club_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_club_verification_requests_status
+    ON club_verification_requests(status, created_at);
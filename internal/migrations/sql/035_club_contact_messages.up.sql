@@ -0,0 +1,14 @@
+-- Messages from prospective members sent through a public club's contact form.
+-- They reach the owner as notifications; the sender's address is kept so the
+-- owner can reply, and the recent ones throttle repeated messages.
+
+CREATE TABLE IF NOT EXISTS club_contact_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    sender_name VARCHAR(100) NOT NULL,
+    sender_email VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_contact_messages_club ON club_contact_messages(club_id, created_at);
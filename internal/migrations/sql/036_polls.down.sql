@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS poll_votes;
+ALTER TABLE IF EXISTS polls DROP CONSTRAINT IF EXISTS fk_polls_winner;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
@@ -0,0 +1,42 @@
+-- Book polls: moderators list candidate books and members vote for the next
+-- one. A single-choice ballot has one vote at rank 1; a ranked ballot lists
+-- candidates by rank. Polls close at closes_at, or earlier when a moderator
+-- closes them, and the winner may become the club's current book.
+
+CREATE TABLE IF NOT EXISTS polls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    kind VARCHAR(10) NOT NULL DEFAULT 'single' CHECK (kind IN ('single', 'ranked')),
+    closes_at TIMESTAMP NOT NULL,
+    set_current_book BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    winner_option_id UUID
+);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    author VARCHAR(255),
+    position INTEGER NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+ALTER TABLE polls ADD CONSTRAINT fk_polls_winner
+    FOREIGN KEY (winner_option_id) REFERENCES poll_options(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL CHECK (rank > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (poll_id, user_id, rank),
+    UNIQUE (poll_id, user_id, option_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_club ON polls(club_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_polls_open ON polls(closes_at) WHERE closed_at IS NULL;
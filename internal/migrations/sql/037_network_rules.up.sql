@@ -0,0 +1,15 @@
+-- Network ranges added by admins at runtime: 'deny' refuses clients on every
+-- route, 'admin_allow' limits the admin routes to the listed ranges. Ranges
+-- from the configuration are not stored here.
+
+CREATE TABLE IF NOT EXISTS network_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    list VARCHAR(20) NOT NULL CHECK (list IN ('deny', 'admin_allow')),
+    cidr CIDR NOT NULL,
+    note VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_rules_list ON network_rules(list, created_at);
@@ -0,0 +1,44 @@
+-- Archiving goes back to leaving out the timezone and end
+
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+BEGIN
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events
+        WHERE event_date < cutoff AND deleted_at IS NULL
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.event_date < cutoff AND e.deleted_at IS NULL
+    ON CONFLICT DO NOTHING;
+
+    WITH moved AS (
+        DELETE FROM events WHERE event_date < cutoff AND deleted_at IS NULL
+        RETURNING id, club_id, title, description, event_date, event_time, location, book, type,
+                  max_attendees, is_public, created_by, attendees, created_at, updated_at
+    )
+    INSERT INTO events_archive (id, club_id, title, description, event_date, event_time, location, book, type,
+                                max_attendees, is_public, created_by, attendees, created_at, updated_at)
+    SELECT * FROM moved
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE events_archive DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE events_archive DROP COLUMN IF EXISTS timezone;
+
+-- starts_at goes back to the wall-clock time in the event's timezone
+ALTER TABLE events ALTER COLUMN starts_at TYPE TIMESTAMP USING starts_at AT TIME ZONE timezone;
+ALTER TABLE events DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE events DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,52 @@
+-- Events are scheduled in an IANA timezone. event_date and event_time stay the
+-- wall-clock date and time there; starts_at becomes the instant they name
+-- (TIMESTAMPTZ), and ends_at optionally records when the event ends. Existing
+-- events were read as UTC, so they keep that timezone and the same instants.
+--
+-- Retyping starts_at rewrites events under an exclusive lock, and the previous
+-- version writes it as a wall-clock TIMESTAMP, which the server would then read
+-- in its session timezone. Run it once no old instance is left.
+-- phase: contract
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ;
+ALTER TABLE events ALTER COLUMN starts_at TYPE TIMESTAMPTZ USING starts_at AT TIME ZONE timezone;
+
+ALTER TABLE events_archive ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE events_archive ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ;
+
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+BEGIN
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events
+        WHERE event_date < cutoff AND deleted_at IS NULL
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.event_date < cutoff AND e.deleted_at IS NULL
+    ON CONFLICT DO NOTHING;
+
+    WITH moved AS (
+        DELETE FROM events WHERE event_date < cutoff AND deleted_at IS NULL
+        RETURNING id, club_id, title, description, event_date, event_time, location, book, type,
+                  max_attendees, is_public, created_by, attendees, created_at, updated_at, timezone, ends_at
+    )
+    INSERT INTO events_archive (id, club_id, title, description, event_date, event_time, location, book, type,
+                                max_attendees, is_public, created_by, attendees, created_at, updated_at,
+                                timezone, ends_at)
+    SELECT * FROM moved
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
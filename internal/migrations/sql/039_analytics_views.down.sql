@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS analytics_refreshes;
+DROP MATERIALIZED VIEW IF EXISTS active_members_per_week;
+DROP MATERIALIZED VIEW IF EXISTS rsvps_per_day;
+DROP MATERIALIZED VIEW IF EXISTS events_per_day;
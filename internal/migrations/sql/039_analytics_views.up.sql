@@ -0,0 +1,63 @@
+-- Daily and weekly activity summaries for the analytics endpoints, kept as
+-- materialized views so reads do not aggregate events and availability live.
+-- A scheduled job refreshes them and records when in analytics_refreshes.
+-- Archived events and RSVPs are included; sandbox clubs are not.
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS events_per_day AS
+SELECT e.club_id, e.event_date AS day, COUNT(*)::INTEGER AS events
+FROM (
+    SELECT club_id, event_date FROM events WHERE deleted_at IS NULL
+    UNION ALL
+    SELECT club_id, event_date FROM events_archive
+) e
+JOIN clubs c ON c.id = e.club_id AND COALESCE(c.is_sandbox, false) = false
+GROUP BY e.club_id, e.event_date;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_per_day ON events_per_day(club_id, day);
+
+-- RSVPs by the day they were last given
+CREATE MATERIALIZED VIEW IF NOT EXISTS rsvps_per_day AS
+SELECT r.club_id, r.updated_at::DATE AS day,
+       COUNT(*) FILTER (WHERE r.status = 'available')::INTEGER AS available,
+       COUNT(*) FILTER (WHERE r.status = 'maybe')::INTEGER AS maybe,
+       COUNT(*) FILTER (WHERE r.status = 'unavailable')::INTEGER AS unavailable
+FROM (
+    SELECT e.club_id, a.status, a.updated_at
+    FROM availability a JOIN events e ON e.id = a.event_id
+    WHERE e.deleted_at IS NULL
+    UNION ALL
+    SELECT e.club_id, a.status, a.updated_at
+    FROM availability_archive a JOIN events_archive e ON e.id = a.event_id AND e.event_date = a.event_date
+) r
+JOIN clubs c ON c.id = r.club_id AND COALESCE(c.is_sandbox, false) = false
+WHERE r.updated_at IS NOT NULL
+GROUP BY r.club_id, r.updated_at::DATE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rsvps_per_day ON rsvps_per_day(club_id, day);
+
+-- Members who gave an RSVP during the week (starting Monday). The row with a
+-- NULL club_id counts each member once across all clubs.
+CREATE MATERIALIZED VIEW IF NOT EXISTS active_members_per_week AS
+SELECT r.club_id, DATE_TRUNC('week', r.updated_at)::DATE AS week, COUNT(DISTINCT r.user_id)::INTEGER AS members
+FROM (
+    SELECT e.club_id, a.user_id, a.updated_at
+    FROM availability a JOIN events e ON e.id = a.event_id
+    WHERE e.deleted_at IS NULL
+    UNION ALL
+    SELECT e.club_id, a.user_id, a.updated_at
+    FROM availability_archive a JOIN events_archive e ON e.id = a.event_id AND e.event_date = a.event_date
+) r
+JOIN clubs c ON c.id = r.club_id AND COALESCE(c.is_sandbox, false) = false
+WHERE r.updated_at IS NOT NULL AND r.user_id IS NOT NULL
+GROUP BY GROUPING SETS ((r.club_id, DATE_TRUNC('week', r.updated_at)::DATE), (DATE_TRUNC('week', r.updated_at)::DATE));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_active_members_per_week ON active_members_per_week(club_id, week);
+
+CREATE TABLE IF NOT EXISTS analytics_refreshes (
+    view_name VARCHAR(64) PRIMARY KEY,
+    refreshed_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO analytics_refreshes (view_name, refreshed_at)
+VALUES ('events_per_day', CURRENT_TIMESTAMP), ('rsvps_per_day', CURRENT_TIMESTAMP), ('active_members_per_week', CURRENT_TIMESTAMP)
+ON CONFLICT (view_name) DO NOTHING;
@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS canonical_tags(TEXT[]);
+DROP INDEX IF EXISTS idx_clubs_tags;
+DROP TABLE IF EXISTS tag_aliases;
+DROP TABLE IF EXISTS tags;
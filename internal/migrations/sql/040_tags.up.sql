@@ -0,0 +1,51 @@
+-- Canonical club tags and their aliases. clubs.tags keeps holding tag names;
+-- canonical_tags maps aliases to their tag so "science fiction" and "sci-fi"
+-- end up as one. Existing tags are normalized (lower case, single spaces) and
+-- registered as canonical tags.
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tags_name_pattern ON tags(name text_pattern_ops);
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias VARCHAR(50) PRIMARY KEY,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag_id);
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_pattern ON tag_aliases(alias text_pattern_ops);
+
+CREATE INDEX IF NOT EXISTS idx_clubs_tags ON clubs USING GIN (tags);
+
+-- Replace aliases with their tag and drop duplicates, keeping the first
+-- position of each tag. Names are expected normalized.
+CREATE OR REPLACE FUNCTION canonical_tags(names TEXT[]) RETURNS TEXT[] AS $$
+    SELECT COALESCE(ARRAY_AGG(resolved.name ORDER BY resolved.first), '{}')
+    FROM (
+        SELECT COALESCE(t.name, n.name) AS name, MIN(n.ord) AS first
+        FROM UNNEST(names) WITH ORDINALITY AS n(name, ord)
+        LEFT JOIN tag_aliases a ON a.alias = n.name
+        LEFT JOIN tags t ON t.id = a.tag_id
+        GROUP BY COALESCE(t.name, n.name)
+    ) resolved
+$$ LANGUAGE SQL STABLE;
+
+UPDATE clubs SET tags = ARRAY(
+    SELECT normalized FROM (
+        SELECT LEFT(LOWER(BTRIM(REGEXP_REPLACE(t, '\s+', ' ', 'g'))), 50) AS normalized, MIN(ord) AS first
+        FROM UNNEST(tags) WITH ORDINALITY AS u(t, ord)
+        WHERE BTRIM(t) <> ''
+        GROUP BY 1
+    ) n ORDER BY first
+)
+WHERE tags IS NOT NULL AND CARDINALITY(tags) > 0;
+
+INSERT INTO tags (name)
+SELECT DISTINCT UNNEST(tags) FROM clubs
+ON CONFLICT (name) DO NOTHING;
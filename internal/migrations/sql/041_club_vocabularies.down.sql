@@ -0,0 +1,19 @@
+-- Types and categories the original checks do not know become 'other'
+
+DROP TABLE IF EXISTS club_vocabulary_terms;
+
+UPDATE events SET type = 'other'
+WHERE type NOT IN ('discussion', 'meeting', 'social', 'planning', 'other');
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_type_check;
+ALTER TABLE events ADD CONSTRAINT events_type_check
+    CHECK (type IN ('discussion', 'meeting', 'social', 'planning', 'other'));
+
+UPDATE event_items SET category = 'other'
+WHERE category NOT IN ('agenda', 'task', 'material', 'note', 'other',
+                       'food', 'materials', 'logistics', 'discussion', 'presentation');
+
+ALTER TABLE event_items DROP CONSTRAINT IF EXISTS event_items_category_check;
+ALTER TABLE event_items ADD CONSTRAINT event_items_category_check
+    CHECK (category IN ('agenda', 'task', 'material', 'note', 'other',
+                        'food', 'materials', 'logistics', 'discussion', 'presentation'));
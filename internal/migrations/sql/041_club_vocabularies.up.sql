@@ -0,0 +1,17 @@
+-- Per-club vocabularies of event types and item categories. A club without
+-- terms of a kind uses the defaults built into the API; its first edit copies
+-- them here. The fixed check constraints on events.type and
+-- event_items.category are dropped: the API validates against the club's list.
+
+CREATE TABLE IF NOT EXISTS club_vocabulary_terms (
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('event_type', 'item_category')),
+    value VARCHAR(50) NOT NULL,
+    label VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (club_id, kind, value)
+);
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_type_check;
+ALTER TABLE event_items DROP CONSTRAINT IF EXISTS event_items_category_check;
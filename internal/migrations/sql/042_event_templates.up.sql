@@ -0,0 +1,26 @@
+-- Event templates: a type, duration, agenda and item set a club starts events
+-- from. Shared templates are listed in a library every club can browse and
+-- apply; uses counts the events made from each. Events keep the agenda they
+-- were given.
+
+CREATE TABLE IF NOT EXISTS event_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    type VARCHAR(50) NOT NULL,
+    duration_minutes INTEGER CHECK (duration_minutes > 0),
+    agenda JSONB NOT NULL DEFAULT '[]',
+    items JSONB NOT NULL DEFAULT '[]',
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    shared_at TIMESTAMP,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_templates_club ON event_templates(club_id);
+CREATE INDEX IF NOT EXISTS idx_event_templates_library ON event_templates(uses DESC) WHERE shared;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS agenda JSONB;
@@ -0,0 +1,21 @@
+-- Club yearbooks: end-of-year reports compiled in the background. A request
+-- queues a pending row; a worker claims it, stores the report as JSON here and
+-- the rendered PDF in attachment storage, then notifies the member who asked.
+-- Requesting a year again recompiles it in place.
+
+CREATE TABLE IF NOT EXISTS club_yearbooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    year INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'compiling', 'ready', 'failed')),
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    report JSONB,
+    storage_key TEXT,
+    error TEXT,
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    UNIQUE (club_id, year)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_yearbooks_queue ON club_yearbooks(requested_at) WHERE status IN ('pending', 'compiling');
@@ -0,0 +1,29 @@
+-- Corrections members ask for to records they cannot edit themselves: their
+-- books read count, or whether they attended an event. Moderators approve or
+-- reject each one; approving applies the proposed value and keeps the value
+-- it replaced, so the table doubles as a history of corrected records.
+-- event_id has no foreign key so that history outlives archived events.
+
+CREATE TABLE IF NOT EXISTS member_corrections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('books_read', 'attendance')),
+    event_id UUID,
+    proposed JSONB NOT NULL,
+    previous JSONB,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    decision_note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((kind = 'attendance') = (event_id IS NOT NULL))
+);
+
+-- One open correction per record
+CREATE UNIQUE INDEX IF NOT EXISTS idx_member_corrections_pending
+    ON member_corrections(club_id, user_id, kind, COALESCE(event_id, '00000000-0000-0000-0000-000000000000'))
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_member_corrections_club_status ON member_corrections(club_id, status, created_at);
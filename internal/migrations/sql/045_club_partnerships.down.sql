@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS partnership_messages;
+DROP TABLE IF EXISTS partnership_events;
+DROP TABLE IF EXISTS club_partnerships;
@@ -0,0 +1,49 @@
+-- Partnerships in which two clubs read the same book together. One club
+-- proposes and the other's moderators accept or decline; while active, each
+-- club can share its events with the partner club's members, and members of
+-- both clubs discuss the book in one thread. Ended partnerships are kept with
+-- their shared events and thread as a record of the joint read.
+
+CREATE TABLE IF NOT EXISTS club_partnerships (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    partner_club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    book VARCHAR(255) NOT NULL,
+    message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'declined', 'cancelled', 'ended')),
+    proposed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    responded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    responded_at TIMESTAMP,
+    ended_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ended_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (club_id <> partner_club_id)
+);
+
+-- One open partnership per pair of clubs, whichever of them proposed it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_club_partnerships_open
+    ON club_partnerships(LEAST(club_id, partner_club_id), GREATEST(club_id, partner_club_id))
+    WHERE status IN ('pending', 'active');
+
+CREATE INDEX IF NOT EXISTS idx_club_partnerships_club ON club_partnerships(club_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_club_partnerships_partner ON club_partnerships(partner_club_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS partnership_events (
+    partnership_id UUID NOT NULL REFERENCES club_partnerships(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    shared_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    shared_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (partnership_id, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS partnership_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    partnership_id UUID NOT NULL REFERENCES club_partnerships(id) ON DELETE CASCADE,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_partnership_messages_thread ON partnership_messages(partnership_id, created_at, id);
@@ -0,0 +1,21 @@
+-- Archives of everything the API stores about a user, which users can ask
+-- for themselves. A request queues a pending row; a worker claims it, writes
+-- the archive to attachment storage and notifies the user. Archives hold
+-- personal data, so a ready one expires after a retention period and its file
+-- is deleted. Requesting again recompiles the user's archive in place.
+
+CREATE TABLE IF NOT EXISTS user_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'compiling', 'ready', 'failed', 'expired')),
+    storage_key TEXT,
+    size_bytes BIGINT,
+    error TEXT,
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_exports_queue ON user_exports(requested_at) WHERE status IN ('pending', 'compiling');
+CREATE INDEX IF NOT EXISTS idx_user_exports_expiry ON user_exports(expires_at) WHERE status = 'ready';
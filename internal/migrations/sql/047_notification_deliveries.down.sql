@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_club_created;
+DROP TABLE IF EXISTS notification_deliveries;
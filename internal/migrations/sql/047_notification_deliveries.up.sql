@@ -0,0 +1,16 @@
+-- What happened to each notification at each external delivery provider, so
+-- club owners can see why members say they never got a reminder. The
+-- dispatcher writes a row once a provider accepts or fails a batch, or when a
+-- delivery is dropped because the provider's queue is full. A provider that
+-- learns of a bounce later reports it again, replacing the earlier outcome.
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed', 'bounced', 'dropped')),
+    error TEXT,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_club_created ON notifications(club_id, created_at DESC) WHERE club_id IS NOT NULL;
@@ -0,0 +1,6 @@
+DELETE FROM notification_deliveries WHERE status = 'suppressed';
+ALTER TABLE notification_deliveries DROP CONSTRAINT IF EXISTS notification_deliveries_status_check;
+ALTER TABLE notification_deliveries ADD CONSTRAINT notification_deliveries_status_check
+    CHECK (status IN ('sent', 'failed', 'bounced', 'dropped'));
+
+DROP TABLE IF EXISTS email_suppressions;
@@ -0,0 +1,17 @@
+-- Addresses the email service provider reported as undeliverable (hard
+-- bounces) or whose owners marked our mail as spam (complaints). Email is not
+-- sent to them again; club owners and moderators are told to follow up with
+-- the member another way. Addresses are stored lowercased, as users' are.
+
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email VARCHAR(255) PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('bounce', 'complaint')),
+    detail TEXT,
+    event_id VARCHAR(255),
+    suppressed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deliveries skipped because the recipient is suppressed at the provider
+ALTER TABLE notification_deliveries DROP CONSTRAINT IF EXISTS notification_deliveries_status_check;
+ALTER TABLE notification_deliveries ADD CONSTRAINT notification_deliveries_status_check
+    CHECK (status IN ('sent', 'failed', 'bounced', 'dropped', 'suppressed'));
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS notify_push;
+ALTER TABLE users DROP COLUMN IF EXISTS notify_email;
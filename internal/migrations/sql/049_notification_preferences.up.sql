@@ -0,0 +1,5 @@
+-- Channels each user wants notifications through besides the in-app feed.
+-- The dispatcher skips a provider for users who turned its channel off.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_email BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_push BOOLEAN NOT NULL DEFAULT true;
@@ -0,0 +1,5 @@
+-- Avatars users upload are stored resized under keys that include a hash of
+-- the upload. The version of the current one is kept so its files can be
+-- removed when it is replaced; users.avatar holds its URL as before.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_version VARCHAR(16);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS endpoint_usage_daily;
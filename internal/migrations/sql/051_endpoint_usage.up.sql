@@ -0,0 +1,15 @@
+-- Requests per endpoint per day, so maintainers can see which API surfaces
+-- are used before changing them. Rows are keyed by route pattern and the
+-- feature-flag variants the requests were served with; no caller, path or
+-- query string is stored.
+
+CREATE TABLE IF NOT EXISTS endpoint_usage_daily (
+    day DATE NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    variant VARCHAR(255) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    client_errors BIGINT NOT NULL DEFAULT 0,
+    server_errors BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, method, route, variant)
+);
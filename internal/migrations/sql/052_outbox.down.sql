@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_outbox_user;
+ALTER TABLE notifications DROP COLUMN IF EXISTS outbox_id;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,24 @@
+-- Messages reporting domain changes, such as notifications to send, written in
+-- the same transaction as the change. The outbox poller hands each to the
+-- handler of its topic and marks it published once that succeeds; failures
+-- are retried with backoff. Published messages are pruned after a retention.
+--
+-- Notifications written for a message record its ID, so a message handled
+-- twice does not notify anyone twice.
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    topic VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox(next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published ON outbox(published_at) WHERE published_at IS NOT NULL;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS outbox_id UUID;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_outbox_user ON notifications(outbox_id, user_id) WHERE outbox_id IS NOT NULL;
@@ -0,0 +1,35 @@
+-- Endpoints clubs register to receive their events, signed with a per-webhook
+-- secret, and a log of every delivery attempt. Deliveries themselves are
+-- queued in the outbox, one message per event and webhook; delivery_id is
+-- that message's ID, which receivers see in X-Bookwork-Delivery.
+
+CREATE TABLE IF NOT EXISTS club_webhooks (
+    id UUID PRIMARY KEY,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    events TEXT[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (cardinality(events) > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_webhooks_club ON club_webhooks(club_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES club_webhooks(id) ON DELETE CASCADE,
+    delivery_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    attempt INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'retrying', 'failed')),
+    status_code INTEGER,
+    error TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_created ON webhook_deliveries(webhook_id, created_at DESC);
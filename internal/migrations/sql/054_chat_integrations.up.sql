@@ -0,0 +1,27 @@
+-- Slack and Discord incoming webhooks clubs connect to have their events
+-- announced in chat, and the reminders already posted to each. A reminder is
+-- keyed on the event's start, so a rescheduled event is reminded of again.
+
+CREATE TABLE IF NOT EXISTS chat_integrations (
+    id UUID PRIMARY KEY,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL CHECK (provider IN ('slack', 'discord')),
+    webhook_url TEXT NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    remind_before_hours INTEGER NOT NULL DEFAULT 24 CHECK (remind_before_hours BETWEEN 0 AND 168),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    disabled_reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_integrations_club ON chat_integrations(club_id);
+
+CREATE TABLE IF NOT EXISTS chat_reminders (
+    integration_id UUID NOT NULL REFERENCES chat_integrations(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (integration_id, event_id, starts_at)
+);
@@ -0,0 +1,15 @@
+-- A short log of what changed in each club, numbered in order, for clients
+-- following a club over an event stream to resume from the last change they
+-- saw. Entries past the retention are pruned.
+
+CREATE TABLE IF NOT EXISTS club_changes (
+    id BIGSERIAL PRIMARY KEY,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    event_id UUID NOT NULL,
+    entity_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_club_changes_club ON club_changes(club_id, id);
+CREATE INDEX IF NOT EXISTS idx_club_changes_created ON club_changes(created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_event_items_order;
+
+ALTER TABLE event_items DROP COLUMN IF EXISTS sort_order;
+ALTER TABLE event_items DROP COLUMN IF EXISTS due_date;
@@ -0,0 +1,14 @@
+-- Due dates on event items, and the order an event's checklist is shown in.
+-- Existing items keep the order they were created in.
+
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS due_date TIMESTAMP;
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS sort_order INTEGER NOT NULL DEFAULT 0;
+
+UPDATE event_items SET sort_order = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY event_id ORDER BY created_at, id) AS position
+    FROM event_items
+) ordered
+WHERE event_items.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_event_items_order ON event_items(event_id, sort_order);
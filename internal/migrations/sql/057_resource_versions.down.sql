@@ -0,0 +1,3 @@
+ALTER TABLE club_members DROP COLUMN IF EXISTS version;
+ALTER TABLE event_items DROP COLUMN IF EXISTS version;
+ALTER TABLE events DROP COLUMN IF EXISTS version;
@@ -0,0 +1,7 @@
+-- Version numbers for optimistic concurrency: each edit of an event, item or
+-- membership increments it, and clients send the version they last saw in
+-- If-Match so an edit made meanwhile is not overwritten.
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE event_items ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE club_members ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
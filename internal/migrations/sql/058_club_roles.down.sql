@@ -0,0 +1,9 @@
+-- Members holding a custom role go back to being plain members
+
+UPDATE club_members SET role = 'member'
+    WHERE role NOT IN ('admin', 'moderator', 'member', 'guest', 'treasurer');
+ALTER TABLE club_members DROP CONSTRAINT IF EXISTS club_members_role_check;
+ALTER TABLE club_members ADD CONSTRAINT club_members_role_check
+    CHECK (role IN ('admin', 'moderator', 'member', 'guest', 'treasurer'));
+
+DROP TABLE IF EXISTS club_roles;
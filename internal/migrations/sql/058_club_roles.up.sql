@@ -0,0 +1,19 @@
+-- Custom club roles. Besides the built-in roles, a club can define roles
+-- granting any set of capabilities; members hold them by name in
+-- club_members.role, which therefore no longer has a fixed set of values.
+
+CREATE TABLE IF NOT EXISTS club_roles (
+    id UUID PRIMARY KEY,
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    name VARCHAR(20) NOT NULL,
+    capabilities TEXT[] NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (club_id, name),
+    CHECK (name NOT IN ('owner', 'admin', 'moderator', 'member', 'guest', 'treasurer')),
+    CHECK (cardinality(capabilities) > 0)
+);
+
+ALTER TABLE club_members DROP CONSTRAINT IF EXISTS club_members_role_check;
+ALTER TABLE club_members ADD CONSTRAINT club_members_role_check CHECK (role <> '');
@@ -0,0 +1,75 @@
+-- Archiving copied a fixed list of columns, so columns added to events later
+-- (starts_at, agenda, version) were lost on the way into the archive, and
+-- deleting the hot rows cascaded to the event's contribution records.
+--
+-- events_archive now has every column of events except the soft-delete ones,
+-- since soft-deleted events are never archived, and archive_events_before
+-- copies the columns the two tables share by name. It refuses to run while
+-- events has a column the archive lacks, so a new column cannot be dropped
+-- silently. Events with contribution records are kept in the hot tables, as
+-- those records are the club's books.
+-- phase: expand
+
+ALTER TABLE events_archive ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ;
+ALTER TABLE events_archive ADD COLUMN IF NOT EXISTS agenda JSONB;
+ALTER TABLE events_archive ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION archive_events_before(cutoff DATE)
+RETURNS INTEGER AS $$
+DECLARE
+    archive_year INTEGER;
+    archived_count INTEGER;
+    missing TEXT;
+    column_list TEXT;
+    event_ids UUID[];
+BEGIN
+    SELECT string_agg(e.attname, ', ' ORDER BY e.attnum) INTO missing
+    FROM pg_attribute e
+    WHERE e.attrelid = 'events'::regclass AND e.attnum > 0 AND NOT e.attisdropped
+      AND e.attname NOT IN ('deleted_at', 'deleted_by')
+      AND NOT EXISTS (
+          SELECT 1 FROM pg_attribute a
+          WHERE a.attrelid = 'events_archive'::regclass AND a.attname = e.attname
+            AND a.attnum > 0 AND NOT a.attisdropped
+      );
+    IF missing IS NOT NULL THEN
+        RAISE EXCEPTION 'events_archive has no column for %; add it before archiving', missing;
+    END IF;
+
+    SELECT string_agg(quote_ident(e.attname), ', ' ORDER BY e.attnum) INTO column_list
+    FROM pg_attribute e
+    JOIN pg_attribute a ON a.attrelid = 'events_archive'::regclass AND a.attname = e.attname
+                       AND a.attnum > 0 AND NOT a.attisdropped
+    WHERE e.attrelid = 'events'::regclass AND e.attnum > 0 AND NOT e.attisdropped;
+
+    event_ids := ARRAY(
+        SELECT e.id FROM events e
+        WHERE e.event_date < cutoff AND e.deleted_at IS NULL
+          AND NOT EXISTS (SELECT 1 FROM event_contributions c WHERE c.event_id = e.id)
+          AND NOT EXISTS (SELECT 1 FROM event_contribution_goals g WHERE g.event_id = e.id)
+    );
+
+    FOR archive_year IN
+        SELECT DISTINCT EXTRACT(YEAR FROM event_date)::INTEGER FROM events WHERE id = ANY(event_ids)
+    LOOP
+        PERFORM ensure_event_archive_partitions(archive_year);
+    END LOOP;
+
+    INSERT INTO availability_archive (id, event_id, event_date, user_id, status, notes, updated_at)
+    SELECT a.id, a.event_id, e.event_date, a.user_id, a.status, a.notes, a.updated_at
+    FROM availability a
+    JOIN events e ON e.id = a.event_id
+    WHERE e.id = ANY(event_ids)
+    ON CONFLICT DO NOTHING;
+
+    EXECUTE format(
+        'WITH moved AS (DELETE FROM events WHERE id = ANY($1) RETURNING %1$s)
+         INSERT INTO events_archive (%1$s) SELECT %1$s FROM moved
+         ON CONFLICT DO NOTHING',
+        column_list)
+    USING event_ids;
+
+    GET DIAGNOSTICS archived_count = ROW_COUNT;
+    RETURN archived_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,4 @@
+ALTER TABLE event_contributions
+    DROP CONSTRAINT IF EXISTS event_contributions_event_id_fkey,
+    ADD CONSTRAINT event_contributions_event_id_fkey
+        FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE;
@@ -0,0 +1,11 @@
+-- Contributions are money the club received, so deleting an event no longer
+-- deletes them with it: purging an event or club that has contributions is
+-- refused until they are dealt with, and archiving leaves such events in the
+-- hot tables. A contribution goal without contributions still goes with its
+-- event.
+-- phase: expand
+
+ALTER TABLE event_contributions
+    DROP CONSTRAINT IF EXISTS event_contributions_event_id_fkey,
+    ADD CONSTRAINT event_contributions_event_id_fkey
+        FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE RESTRICT;
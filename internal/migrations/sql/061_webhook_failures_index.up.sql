@@ -0,0 +1,6 @@
+-- The admin overview counts webhook deliveries given up on in the last hour,
+-- which would otherwise scan the whole delivery log.
+-- phase: expand
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_failed_created
+    ON webhook_deliveries(created_at) WHERE status = 'failed';
@@ -2,25 +2,34 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
+	"bookwork-api/internal/localtime"
+	"bookwork-api/internal/money"
+	"bookwork-api/internal/timeutil"
+
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
 // StringArray is a custom type for PostgreSQL text arrays
 type StringArray []string
 
 func (a StringArray) Value() (driver.Value, error) {
-	return pq.Array([]string(a)).Value()
+	if a == nil {
+		return nil, nil
+	}
+	return arrayLiteral(a), nil
 }
 
 func (a *StringArray) Scan(value interface{}) error {
-	arr := pq.StringArray{}
-	if err := arr.Scan(value); err != nil {
+	arr, err := parseArray(value)
+	if err != nil {
 		return err
 	}
-	*a = StringArray([]string(arr))
+	*a = StringArray(arr)
 	return nil
 }
 
@@ -36,12 +45,12 @@ func (a UUIDArray) Value() (driver.Value, error) {
 	for i, u := range a {
 		uuidStrings[i] = u.String()
 	}
-	return pq.Array(uuidStrings).Value()
+	return arrayLiteral(uuidStrings), nil
 }
 
 func (a *UUIDArray) Scan(value interface{}) error {
-	arr := pq.StringArray{}
-	if err := arr.Scan(value); err != nil {
+	arr, err := parseArray(value)
+	if err != nil {
 		return err
 	}
 
@@ -56,33 +65,198 @@ func (a *UUIDArray) Scan(value interface{}) error {
 	return nil
 }
 
+// arrayLiteral writes elems as a PostgreSQL array in text form, quoting every
+// element so commas, braces and quotes inside one stay part of it
+func arrayLiteral(elems []string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, elem := range elems {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		escape.WriteString(&b, elem)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// parseArray reads a one-dimensional PostgreSQL array in text form, such as
+// {a,"b c"}, as the driver returns it. NULL reads as a nil slice; NULL
+// elements and nested arrays are refused.
+func parseArray(value interface{}) ([]string, error) {
+	var src string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		src = string(v)
+	case string:
+		src = v
+	default:
+		return nil, fmt.Errorf("cannot scan %T into an array", value)
+	}
+	if len(src) < 2 || src[0] != '{' || src[len(src)-1] != '}' {
+		return nil, fmt.Errorf("invalid array %q", src)
+	}
+
+	body := src[1 : len(src)-1]
+	elems := []string{}
+	if body == "" {
+		return elems, nil
+	}
+	for i := 0; ; i++ { // i++ steps over the comma after each element
+		if i == len(body) {
+			return nil, fmt.Errorf("invalid array %q", src)
+		}
+		var elem strings.Builder
+		if body[i] == '"' {
+			for i++; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' {
+					i++
+				}
+				if i < len(body) {
+					elem.WriteByte(body[i])
+				}
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("invalid array %q", src)
+			}
+			i++
+		} else {
+			start := i
+			for i < len(body) && body[i] != ',' {
+				i++
+			}
+			raw := body[start:i]
+			if raw == "NULL" || strings.ContainsAny(raw, `{}"`) {
+				return nil, fmt.Errorf("unsupported array %q: only non-NULL one-dimensional arrays can be read", src)
+			}
+			elem.WriteString(raw)
+		}
+		elems = append(elems, elem.String())
+
+		if i == len(body) {
+			return elems, nil
+		}
+		if body[i] != ',' {
+			return nil, fmt.Errorf("invalid array %q", src)
+		}
+	}
+}
+
+// AgendaItem is one part of an event's running order
+type AgendaItem struct {
+	Title   string  `json:"title" validate:"required,max=200" sanitize:"text"`
+	Minutes *int    `json:"minutes,omitempty" validate:"omitempty,min=1,max=600"`
+	Notes   *string `json:"notes,omitempty" validate:"omitempty,max=1000" sanitize:"markdown"`
+}
+
+// Agenda is an event's running order, stored as JSONB
+type Agenda []AgendaItem
+
+func (a Agenda) Value() (driver.Value, error) {
+	return jsonValue(a, len(a))
+}
+
+func (a *Agenda) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+// TemplateItem is an item an event template adds to each event made from it
+type TemplateItem struct {
+	Name     string   `json:"name" validate:"required,max=200" sanitize:"text"`
+	Category string   `json:"category" validate:"required,max=50"`
+	Quantity *float64 `json:"quantity,omitempty" validate:"omitempty,gt=0"`
+	Unit     *string  `json:"unit,omitempty" validate:"omitempty,max=20" sanitize:"text"`
+	Notes    *string  `json:"notes,omitempty" validate:"omitempty,max=1000" sanitize:"markdown"`
+}
+
+// TemplateItems is the item set of an event template, stored as JSONB
+type TemplateItems []TemplateItem
+
+func (t TemplateItems) Value() (driver.Value, error) {
+	return jsonValue(t, len(t))
+}
+
+func (t *TemplateItems) Scan(value interface{}) error {
+	return scanJSON(value, t)
+}
+
+// jsonValue marshals a JSONB list, writing [] rather than null when it is empty
+func jsonValue(v interface{}, length int) (driver.Value, error) {
+	if length == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(v)
+}
+
+// scanJSON unmarshals a JSONB column into v; NULL leaves v empty
+func scanJSON(value interface{}, v interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("cannot scan %T as JSON", value)
+	}
+}
+
 // User represents a user in the system
 type User struct {
-	ID           uuid.UUID  `json:"id" db:"id"`
-	Name         string     `json:"name" db:"name"`
-	Email        string     `json:"email" db:"email"`
-	PasswordHash string     `json:"-" db:"password_hash"`
-	Phone        *string    `json:"phone,omitempty" db:"phone"`
-	Avatar       *string    `json:"avatar,omitempty" db:"avatar"`
-	Role         string     `json:"role" db:"role"`
-	IsActive     bool       `json:"isActive" db:"is_active"`
-	LastLoginAt  *time.Time `json:"lastLoginAt,omitempty" db:"last_login_at"`
-	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
-	JoinedDate   *time.Time `json:"joinedDate,omitempty"` // For API compatibility
+	ID               uuid.UUID  `json:"id" db:"id"`
+	Name             string     `json:"name" db:"name"`
+	Email            string     `json:"email" db:"email"`
+	PasswordHash     string     `json:"-" db:"password_hash"`
+	Phone            *string    `json:"phone,omitempty" db:"phone"`
+	Avatar           *string    `json:"avatar,omitempty" db:"avatar"`
+	Role             string     `json:"role" db:"role"`
+	IsActive         bool       `json:"isActive" db:"is_active"`
+	LastLoginAt      *time.Time `json:"lastLoginAt,omitempty" db:"last_login_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+	JoinedDate       *time.Time `json:"joinedDate,omitempty"` // For API compatibility
+	GuardianEmail    *string    `json:"guardianEmail,omitempty" db:"guardian_email"`
+	DateOfBirth      *string    `json:"dateOfBirth,omitempty" db:"date_of_birth"`
+	Timezone         string     `json:"timezone,omitempty" db:"timezone"`
+	IsSandbox        bool       `json:"sandbox,omitempty" db:"is_sandbox"` // throwaway test account
+	SandboxExpiresAt *time.Time `json:"sandboxExpiresAt,omitempty" db:"sandbox_expires_at"`
+
+	EmailUndeliverable      *EmailUndeliverable      `json:"emailUndeliverable,omitempty"` // email to the address bounced or was marked as spam
+	NotificationPreferences *NotificationPreferences `json:"notificationPreferences,omitempty"`
+}
+
+// NotificationPreferences are the channels a user wants notifications
+// through besides the in-app feed
+type NotificationPreferences struct {
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+}
+
+// EmailUndeliverable says why email is no longer sent to a user's address
+type EmailUndeliverable struct {
+	Reason string `json:"reason"` // bounce or complaint
+	Since  string `json:"since"`
 }
 
 // PublicUser returns user info without sensitive data
 func (u *User) PublicUser() *User {
 	return &User{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Phone:     u.Phone,
-		Avatar:    u.Avatar,
-		Role:      u.Role,
-		IsActive:  u.IsActive,
-		CreatedAt: u.CreatedAt,
+		ID:               u.ID,
+		Name:             u.Name,
+		Email:            u.Email,
+		Phone:            u.Phone,
+		Avatar:           u.Avatar,
+		Role:             u.Role,
+		IsActive:         u.IsActive,
+		CreatedAt:        u.CreatedAt,
+		IsSandbox:        u.IsSandbox,
+		SandboxExpiresAt: u.SandboxExpiresAt,
 	}
 }
 
@@ -90,7 +264,8 @@ func (u *User) PublicUser() *User {
 type Club struct {
 	ID               uuid.UUID   `json:"id" db:"id"`
 	Name             string      `json:"name" db:"name"`
-	Description      string      `json:"description" db:"description"`
+	Description      string      `json:"description" db:"description"` // Markdown
+	DescriptionHTML  *string     `json:"descriptionHtml,omitempty"`    // rendered for ?format=html
 	OwnerID          uuid.UUID   `json:"ownerId" db:"owner_id"`
 	MemberCount      int         `json:"memberCount"`
 	IsPublic         bool        `json:"isPublic" db:"is_public"`
@@ -99,8 +274,79 @@ type Club struct {
 	CurrentBook      *string     `json:"currentBook,omitempty" db:"current_book"`
 	Tags             StringArray `json:"tags" db:"tags"`
 	Location         *string     `json:"location,omitempty" db:"location"`
+	YouthMode        bool        `json:"youthMode" db:"youth_mode"`
+	BrandColor       *string     `json:"brandColor,omitempty" db:"brand_color"`
+	Country          *string     `json:"country,omitempty" db:"country"`
+	IsSandbox        bool        `json:"sandbox,omitempty" db:"is_sandbox"`
+	Verification     *string     `json:"verification,omitempty" db:"verification_type"` // set for verified clubs
+	SandboxExpiresAt *time.Time  `json:"sandboxExpiresAt,omitempty" db:"sandbox_expires_at"`
 	CreatedAt        time.Time   `json:"createdAt" db:"created_at"`
 	UpdatedAt        time.Time   `json:"updatedAt" db:"updated_at"`
+	DeletedAt        *time.Time  `json:"deletedAt,omitempty" db:"deleted_at"` // set while the club is soft-deleted
+}
+
+// ClubCapacity describes how full a club is against its member limit.
+// A limit lowered below the current member count keeps everyone (grace)
+// but admits nobody new until members leave.
+type ClubCapacity struct {
+	MaxMembers    *int `json:"maxMembers"` // nil means unlimited
+	ActiveMembers int  `json:"activeMembers"`
+	Remaining     *int `json:"remaining"` // nil when unlimited
+	OverCapacity  bool `json:"overCapacity"`
+}
+
+// NewClubCapacity computes the capacity of a club with maxMembers and active members
+func NewClubCapacity(maxMembers *int, activeMembers int) ClubCapacity {
+	capacity := ClubCapacity{MaxMembers: maxMembers, ActiveMembers: activeMembers}
+	if maxMembers != nil {
+		remaining := *maxMembers - activeMembers
+		if remaining < 0 {
+			remaining = 0
+		}
+		capacity.Remaining = &remaining
+		capacity.OverCapacity = activeMembers > *maxMembers
+	}
+	return capacity
+}
+
+// IsFull reports whether the club cannot admit another member
+func (c ClubCapacity) IsFull() bool {
+	return c.Remaining != nil && *c.Remaining == 0
+}
+
+// Details returns the capacity as error details for CLUB_FULL responses
+func (c ClubCapacity) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"maxMembers":    c.MaxMembers,
+		"activeMembers": c.ActiveMembers,
+		"remaining":     c.Remaining,
+		"overCapacity":  c.OverCapacity,
+	}
+}
+
+// PublicClub is the anonymous, cacheable view of a public club
+type PublicClub struct {
+	ID               uuid.UUID   `json:"id"`
+	Name             string      `json:"name"`
+	Description      string      `json:"description"`               // Markdown
+	DescriptionHTML  *string     `json:"descriptionHtml,omitempty"` // rendered for ?format=html
+	MemberCount      int         `json:"memberCount"`
+	MeetingFrequency *string     `json:"meetingFrequency,omitempty"`
+	CurrentBook      *string     `json:"currentBook,omitempty"`
+	Tags             StringArray `json:"tags"`
+	Location         *string     `json:"location,omitempty"`
+	Verification     *string     `json:"verification,omitempty"`
+	CreatedAt        time.Time   `json:"createdAt"`
+}
+
+// SimilarClub is a public club recommended alongside another, with how
+// strongly each signal matched, from 0 to 1
+type SimilarClub struct {
+	PublicClub
+	Score         float64 `json:"score"`
+	TagScore      float64 `json:"tagScore"`
+	BookScore     float64 `json:"bookScore"`
+	LocationScore float64 `json:"locationScore"`
 }
 
 // ClubMember represents a membership in a club
@@ -112,40 +358,88 @@ type ClubMember struct {
 	JoinedDate time.Time `json:"joinedDate" db:"joined_date"`
 	BooksRead  int       `json:"booksRead" db:"books_read"`
 	IsActive   bool      `json:"isActive" db:"is_active"`
+	Version    int       `json:"version,omitempty" db:"version"` // incremented by each edit; set where loaded
 	User       *User     `json:"user,omitempty"`
 }
 
+// ClubRole is a role a club defines beyond the built-in ones, granting the
+// capabilities listed. Members hold it by name.
+type ClubRole struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	ClubID       uuid.UUID   `json:"clubId" db:"club_id"`
+	Name         string      `json:"name" db:"name"`
+	Capabilities StringArray `json:"capabilities" db:"capabilities"`
+	CreatedBy    *uuid.UUID  `json:"createdBy,omitempty" db:"created_by"`
+	CreatedAt    time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
 // Event represents a club event
 type Event struct {
-	ID           uuid.UUID `json:"id" db:"id"`
-	ClubID       uuid.UUID `json:"clubId" db:"club_id"`
-	Title        string    `json:"title" db:"title"`
-	Description  *string   `json:"description,omitempty" db:"description"`
-	Date         string    `json:"date" db:"event_date"`
-	Time         string    `json:"time" db:"event_time"`
-	Location     string    `json:"location" db:"location"`
-	Book         *string   `json:"book,omitempty" db:"book"`
-	Type         string    `json:"type" db:"type"`
-	MaxAttendees *int      `json:"maxAttendees,omitempty" db:"max_attendees"`
-	IsPublic     bool      `json:"isPublic" db:"is_public"`
-	CreatedBy    uuid.UUID `json:"createdBy" db:"created_by"`
-	Attendees    UUIDArray `json:"attendees" db:"attendees"`
-	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	ClubID          uuid.UUID  `json:"clubId" db:"club_id"`
+	Title           string     `json:"title" db:"title"`
+	Description     *string    `json:"description,omitempty" db:"description"` // Markdown
+	DescriptionHTML *string    `json:"descriptionHtml,omitempty"`              // rendered for ?format=html
+	Date            string     `json:"date" db:"event_date"`                   // wall-clock date in Timezone
+	Time            string     `json:"time" db:"event_time"`                   // wall-clock time in Timezone
+	Timezone        string     `json:"timezone" db:"timezone"`                 // IANA name; empty means UTC
+	EndsAt          *time.Time `json:"endsAt,omitempty" db:"ends_at"`
+	Location        string     `json:"location" db:"location"`
+	Book            *string    `json:"book,omitempty" db:"book"`
+	Type            string     `json:"type" db:"type"`
+	MaxAttendees    *int       `json:"maxAttendees,omitempty" db:"max_attendees"`
+	IsPublic        bool       `json:"isPublic" db:"is_public"`
+	CreatedBy       uuid.UUID  `json:"createdBy" db:"created_by"`
+	Attendees       UUIDArray  `json:"attendees" db:"attendees"`
+	Agenda          Agenda     `json:"agenda,omitempty" db:"agenda"`   // loaded with a single event and with archived events
+	Version         int        `json:"version,omitempty" db:"version"` // incremented by each edit; loaded with a single event
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" db:"deleted_at"` // set while the event is soft-deleted
+}
+
+// DeletedEvent is a soft-deleted event awaiting restore or purge
+type DeletedEvent struct {
+	ID          uuid.UUID  `json:"id"`
+	ClubID      uuid.UUID  `json:"clubId"`
+	Title       string     `json:"title"`
+	Date        string     `json:"date"`
+	DeletedAt   time.Time  `json:"deletedAt"`
+	DeletedBy   *uuid.UUID `json:"deletedBy,omitempty"`
+	ClubDeleted bool       `json:"clubDeleted"` // the event cannot be restored before its club
+}
+
+// DeletedClub is a soft-deleted club awaiting restore or purge
+type DeletedClub struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	OwnerID     *uuid.UUID `json:"ownerId,omitempty"`
+	MemberCount int        `json:"memberCount"`
+	DeletedAt   time.Time  `json:"deletedAt"`
+	DeletedBy   *uuid.UUID `json:"deletedBy,omitempty"`
 }
 
 // EventItem represents a coordination item for an event
 type EventItem struct {
-	ID         uuid.UUID  `json:"id" db:"id"`
-	EventID    uuid.UUID  `json:"eventId" db:"event_id"`
-	Name       string     `json:"name" db:"name"`
-	Category   string     `json:"category" db:"category"`
-	AssignedTo *uuid.UUID `json:"assignedTo,omitempty" db:"assigned_to"`
-	Status     string     `json:"status" db:"status"`
-	Notes      *string    `json:"notes,omitempty" db:"notes"`
-	CreatedBy  uuid.UUID  `json:"createdBy" db:"created_by"`
-	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	ID         uuid.UUID    `json:"id" db:"id"`
+	EventID    uuid.UUID    `json:"eventId" db:"event_id"`
+	Name       string       `json:"name" db:"name"`
+	Category   string       `json:"category" db:"category"`
+	AssignedTo *uuid.UUID   `json:"assignedTo,omitempty" db:"assigned_to"`
+	Status     string       `json:"status" db:"status"`
+	Notes      *string      `json:"notes,omitempty" db:"notes"`
+	Quantity   *float64     `json:"quantity,omitempty" db:"quantity"`
+	Unit       *string      `json:"unit,omitempty" db:"unit"`
+	Cost       *money.Money `json:"cost,omitempty" db:"cost"`
+	// ExchangeRate converts Cost to the club currency, snapshotted when the cost was set
+	ExchangeRate *string    `json:"exchangeRate,omitempty" db:"exchange_rate"`
+	DueDate      *time.Time `json:"dueDate,omitempty" db:"due_date"` // before the event starts
+	SortOrder    int        `json:"sortOrder" db:"sort_order"`       // position in the event's checklist, from 1
+	Version      int        `json:"version" db:"version"`            // incremented by each edit
+	CreatedBy    uuid.UUID  `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
 }
 
 // Availability represents a user's availability for an event
@@ -174,6 +468,27 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RegisterRequest struct {
+	Name         string `json:"name" validate:"required" sanitize:"text"`
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required,min=8"`
+	DateOfBirth  string `json:"dateOfBirth" validate:"required,datetime=2006-01-02"`
+	AcceptTerms  bool   `json:"acceptTerms"`
+	TermsVersion string `json:"termsVersion,omitempty"`
+	Sandbox      bool   `json:"sandbox,omitempty"`
+	CaptchaToken string `json:"captchaToken,omitempty"`
+}
+
+// TermsAcceptance records a user's acceptance of a terms of service version
+type TermsAcceptance struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"userId" db:"user_id"`
+	TermsVersion string    `json:"termsVersion" db:"terms_version"`
+	IPAddress    *string   `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent    *string   `json:"userAgent,omitempty" db:"user_agent"`
+	AcceptedAt   time.Time `json:"acceptedAt" db:"accepted_at"`
+}
+
 type RefreshRequest struct {
 	RefreshToken string `json:"refreshToken" validate:"required"`
 }
@@ -183,9 +498,10 @@ type LogoutRequest struct {
 }
 
 type TokenResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ExpiresIn    int    `json:"expiresIn"`
+	AccessToken    string    `json:"accessToken"`
+	RefreshToken   string    `json:"refreshToken"`
+	RefreshTokenID uuid.UUID `json:"-"` // jti claim of the refresh token
+	ExpiresIn      int       `json:"expiresIn"`
 }
 
 type LoginResponse struct {
@@ -198,16 +514,26 @@ type ValidateResponse struct {
 	Valid bool  `json:"valid"`
 }
 
+// CreateEventRequest gives the start as an ISO 8601 startsAt or, as older
+// clients do, a date and time; either is read in Timezone unless startsAt has an
+// offset. Timezone defaults to the creator's preferred one. With TemplateID the
+// template supplies the type, end, agenda and description the request leaves
+// out, and its items are added to the event.
 type CreateEventRequest struct {
-	Title        string  `json:"title" validate:"required,min=1,max=100"`
-	Description  *string `json:"description,omitempty"`
-	Date         string  `json:"date" validate:"required"`
-	Time         string  `json:"time" validate:"required"`
-	Location     string  `json:"location" validate:"required,min=1,max=200"`
-	Book         *string `json:"book,omitempty"`
-	Type         string  `json:"type" validate:"required"`
-	MaxAttendees *int    `json:"maxAttendees,omitempty"`
-	IsPublic     bool    `json:"isPublic"`
+	Title        string     `json:"title" validate:"required,min=1,max=100" sanitize:"text"`
+	Description  *string    `json:"description,omitempty" sanitize:"markdown"`
+	Date         string     `json:"date" validate:"required_without=StartsAt,omitempty,datetime=2006-01-02"`
+	Time         string     `json:"time" validate:"required_without=StartsAt,omitempty,datetime=15:04"`
+	StartsAt     *string    `json:"startsAt,omitempty"`
+	EndsAt       *string    `json:"endsAt,omitempty"`
+	Timezone     *string    `json:"timezone,omitempty"`
+	Location     string     `json:"location" validate:"required,min=1,max=200" sanitize:"text"`
+	Book         *string    `json:"book,omitempty" sanitize:"text"`
+	Type         string     `json:"type" validate:"required_without=TemplateID,omitempty,max=50"` // one of the club's event types
+	MaxAttendees *int       `json:"maxAttendees,omitempty"`
+	IsPublic     bool       `json:"isPublic"`
+	Agenda       Agenda     `json:"agenda,omitempty" validate:"max=30,dive"`
+	TemplateID   *uuid.UUID `json:"templateId,omitempty"`
 }
 
 type CreateEventItemRequest struct {
@@ -215,21 +541,349 @@ type CreateEventItemRequest struct {
 }
 
 type EventItemRequest struct {
-	Name       string     `json:"name" validate:"required"`
-	Category   string     `json:"category" validate:"required"`
-	AssignedTo *uuid.UUID `json:"assignedTo,omitempty"`
-	Notes      *string    `json:"notes,omitempty"`
+	Name       string         `json:"name" validate:"required" sanitize:"text"`
+	Category   string         `json:"category" validate:"required,max=50"` // one of the club's item categories
+	AssignedTo *uuid.UUID     `json:"assignedTo,omitempty"`
+	Notes      *string        `json:"notes,omitempty" sanitize:"markdown"`
+	Quantity   *float64       `json:"quantity,omitempty"`
+	Unit       *string        `json:"unit,omitempty" validate:"omitempty,max=20" sanitize:"text"`
+	Cost       *money.Decimal `json:"cost,omitempty"`
+	// CostCurrency defaults to the club currency
+	CostCurrency *string `json:"costCurrency,omitempty"`
+	// DueDate is ISO 8601, read in the event's timezone without an offset
+	DueDate *string `json:"dueDate,omitempty"`
 }
 
 type UpdateEventItemRequest struct {
-	Status string  `json:"status,omitempty"`
-	Notes  *string `json:"notes,omitempty"`
+	Status       string         `json:"status,omitempty"`
+	AssignedTo   *uuid.UUID     `json:"assignedTo,omitempty"` // a member of the club; they and the previous assignee are notified
+	Notes        *string        `json:"notes,omitempty" sanitize:"markdown"`
+	Quantity     *float64       `json:"quantity,omitempty"`
+	Cost         *money.Decimal `json:"cost,omitempty"`
+	CostCurrency *string        `json:"costCurrency,omitempty"`
+	DueDate      *string        `json:"dueDate,omitempty"` // an empty string removes it
+}
+
+// BulkEventItemsRequest changes many of an event's items in one transaction
+type BulkEventItemsRequest struct {
+	Operations []BulkEventItemOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+// BulkEventItemOperation creates item, or applies update to or deletes the item itemId
+type BulkEventItemOperation struct {
+	Op     string                  `json:"op" validate:"required,oneof=create update delete"`
+	ItemID *uuid.UUID              `json:"itemId,omitempty"`
+	Item   *EventItemRequest       `json:"item,omitempty"`
+	Update *UpdateEventItemRequest `json:"update,omitempty"`
+}
+
+// ReorderEventItemsRequest lists all of an event's items in their new order
+type ReorderEventItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"itemIds" validate:"required,min=1"`
+}
+
+// AssignShoppingListRequest sets who shops for an event's whole list; a null userId clears it
+type AssignShoppingListRequest struct {
+	UserID *uuid.UUID `json:"userId"`
+}
+
+// Attachment is an uploaded file on an event item, or a club resource when EventItemID is nil
+type Attachment struct {
+	ID                   uuid.UUID  `json:"id" db:"id"`
+	ClubID               uuid.UUID  `json:"clubId" db:"club_id"`
+	EventItemID          *uuid.UUID `json:"eventItemId,omitempty" db:"event_item_id"`
+	FileName             string     `json:"fileName" db:"file_name"`
+	ContentType          string     `json:"contentType" db:"content_type"`
+	SizeBytes            int64      `json:"sizeBytes" db:"size_bytes"`
+	StorageKey           string     `json:"-" db:"storage_key"`
+	UploadedBy           *uuid.UUID `json:"uploadedBy,omitempty" db:"uploaded_by"`
+	CreatedAt            time.Time  `json:"createdAt" db:"created_at"`
+	DownloadURL          string     `json:"downloadUrl,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"downloadUrlExpiresAt,omitempty"`
+}
+
+// ShoppingList consolidates an event's food items into one list. Costs are in
+// the club currency.
+type ShoppingList struct {
+	EventID    uuid.UUID          `json:"eventId"`
+	AssignedTo *uuid.UUID         `json:"assignedTo"` // shopper for the whole list
+	Currency   string             `json:"currency"`
+	Lines      []ShoppingListLine `json:"lines"`
+	Assignees  []ShoppingAssignee `json:"assignees"`
+	TotalCost  money.Money        `json:"totalCost"`
+	CostSplit  []CostShare        `json:"costSplit"`
+}
+
+// ShoppingListLine is one product on the list, merged from items with the same name and unit
+type ShoppingListLine struct {
+	Name      string      `json:"name"`
+	Unit      string      `json:"unit,omitempty"`
+	Quantity  float64     `json:"quantity"`
+	Cost      money.Money `json:"cost"`
+	ItemIDs   []uuid.UUID `json:"itemIds"`
+	Completed bool        `json:"completed"`
+}
+
+// ShoppingAssignee is the part of the list one person buys. UserID is nil for
+// items nobody is assigned to.
+type ShoppingAssignee struct {
+	UserID  *uuid.UUID  `json:"userId"`
+	ItemIDs []uuid.UUID `json:"itemIds"`
+	Cost    money.Money `json:"cost"`
+}
+
+// CostShare is one participant's even share of the list's cost. Balance is what
+// they paid minus their share: positive means they are owed money.
+type CostShare struct {
+	UserID  uuid.UUID   `json:"userId"`
+	Paid    money.Money `json:"paid"`
+	Share   money.Money `json:"share"`
+	Balance money.Money `json:"balance"`
+}
+
+// Forecast bases: the club's attendance history, or the RSVPs alone while
+// there is too little history
+const (
+	ForecastBasisHistory = "history"
+	ForecastBasisRSVPs   = "rsvps"
+)
+
+// AttendanceForecast projects an event's headcount from its RSVPs and the
+// club's past conversion of RSVPs into attendance. Low and High bound the
+// expected headcount by the worst and best conversion seen.
+type AttendanceForecast struct {
+	RSVPs          int      `json:"rsvps"`
+	Maybes         int      `json:"maybes"`
+	Expected       int      `json:"expected"`
+	Low            int      `json:"low"`
+	High           int      `json:"high"`
+	ConversionRate *float64 `json:"conversionRate"` // attendees per RSVP; nil without enough history
+	SampleEvents   int      `json:"sampleEvents"`
+	Basis          string   `json:"basis"`
+}
+
+// EventAttendance is the headcount recorded for a past event, with the RSVPs
+// it had when the headcount was recorded
+type EventAttendance struct {
+	EventID    uuid.UUID  `json:"eventId"`
+	RSVPs      int        `json:"rsvps"`
+	Attended   int        `json:"attended"`
+	RecordedBy *uuid.UUID `json:"recordedBy,omitempty"`
+	RecordedAt time.Time  `json:"recordedAt"`
+}
+
+// RecordAttendanceRequest records how many people came to a past event
+type RecordAttendanceRequest struct {
+	Attended *int `json:"attended" validate:"required,min=0,max=100000"`
+}
+
+// ExchangeRate says how many units of Quote one unit of Base is worth
+type ExchangeRate struct {
+	Base      string     `json:"base" db:"base_currency"`
+	Quote     string     `json:"quote" db:"quote_currency"`
+	Rate      string     `json:"rate" db:"rate"` // exact decimal
+	UpdatedBy *uuid.UUID `json:"updatedBy,omitempty" db:"updated_by"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// SetExchangeRateRequest sets how many units of Quote one unit of Base is worth
+type SetExchangeRateRequest struct {
+	Base  string        `json:"base"`
+	Quote string        `json:"quote"`
+	Rate  money.Decimal `json:"rate"`
+}
+
+// UpdateDuesRequest replaces a club's dues policy; a null amount stops charging dues
+type UpdateDuesRequest struct {
+	Amount          *money.Decimal `json:"amount"`
+	Period          string         `json:"period,omitempty"` // monthly, quarterly or yearly
+	RequiredForRSVP bool           `json:"requiredForRsvp"`
+}
+
+// RecordDuesPaymentRequest records a payment taken outside the API, e.g. cash at a meeting
+type RecordDuesPaymentRequest struct {
+	UserID      uuid.UUID     `json:"userId"`
+	Amount      money.Decimal `json:"amount"`
+	PeriodStart string        `json:"periodStart,omitempty"` // any date in the period; defaults to the current one
+	Notes       *string       `json:"notes,omitempty" sanitize:"markdown"`
+}
+
+// UpdateContributionGoalRequest sets what an event is raising money for; a null target removes the goal
+type UpdateContributionGoalRequest struct {
+	Target      *money.Decimal `json:"target"`
+	Description *string        `json:"description,omitempty" sanitize:"markdown"`
+	PaymentLink *string        `json:"paymentLink,omitempty"` // https Stripe Payment Link
+}
+
+// RecordContributionRequest records a contribution taken outside the API. The
+// contributor is a member (userId) or someone outside the club (name).
+type RecordContributionRequest struct {
+	UserID    *uuid.UUID    `json:"userId,omitempty"`
+	Name      *string       `json:"name,omitempty" sanitize:"text"`
+	Amount    money.Decimal `json:"amount"`
+	Anonymous bool          `json:"anonymous"`
+	Notes     *string       `json:"notes,omitempty" sanitize:"markdown"`
+}
+
+// TagNameRequest names a new tag, a tag's new name, or an alias for a tag
+type TagNameRequest struct {
+	Name string `json:"name" validate:"required,max=50" sanitize:"text"`
+}
+
+// MergeTagRequest names the tag another tag is merged into
+type MergeTagRequest struct {
+	IntoID uuid.UUID `json:"intoId" validate:"required"`
+}
+
+// EventTemplateRequest saves an event template: what events made from it
+// start with
+type EventTemplateRequest struct {
+	Name            string        `json:"name" validate:"required,max=100" sanitize:"text"`
+	Description     *string       `json:"description,omitempty" validate:"omitempty,max=2000" sanitize:"markdown"`
+	Type            string        `json:"type" validate:"required,max=50"`
+	DurationMinutes *int          `json:"durationMinutes,omitempty" validate:"omitempty,min=1,max=1440"`
+	Agenda          Agenda        `json:"agenda" validate:"max=30,dive"`
+	Items           TemplateItems `json:"items" validate:"max=50,dive"`
+	Shared          bool          `json:"shared"` // listed in the library for other clubs
+}
+
+// CorrectionRequest asks moderators to correct one of the member's own
+// records: their books read count, or whether they attended an event
+type CorrectionRequest struct {
+	Kind      string     `json:"kind" validate:"required,oneof=books_read attendance"`
+	EventID   *uuid.UUID `json:"eventId,omitempty" validate:"required_if=Kind attendance"`
+	BooksRead *int       `json:"booksRead,omitempty" validate:"required_if=Kind books_read,omitempty,min=0,max=10000"`
+	Attended  *bool      `json:"attended,omitempty" validate:"required_if=Kind attendance"`
+	Reason    string     `json:"reason" validate:"required,max=1000" sanitize:"markdown"`
+}
+
+// CorrectionDecisionRequest approves or rejects a correction, optionally
+// telling the member why
+type CorrectionDecisionRequest struct {
+	Note *string `json:"note,omitempty" validate:"omitempty,max=1000" sanitize:"markdown"`
+}
+
+// PartnershipRequest proposes a joint read to another club
+type PartnershipRequest struct {
+	PartnerClubID uuid.UUID `json:"partnerClubId" validate:"required"`
+	Book          string    `json:"book" validate:"required,max=255" sanitize:"text"`
+	Message       *string   `json:"message,omitempty" validate:"omitempty,max=1000" sanitize:"markdown"`
+}
+
+// PartnershipBookRequest changes the book of a partnership, optionally making
+// it the current book of both clubs
+type PartnershipBookRequest struct {
+	Book           string `json:"book" validate:"required,max=255" sanitize:"text"`
+	SetCurrentBook bool   `json:"setCurrentBook"`
+}
+
+// ShareEventRequest shares one of the club's events with a partnership
+type ShareEventRequest struct {
+	EventID uuid.UUID `json:"eventId" validate:"required"`
+}
+
+// PartnershipMessageRequest posts to a partnership's discussion thread
+type PartnershipMessageRequest struct {
+	Body string `json:"body" validate:"required,max=4000" sanitize:"markdown"`
+}
+
+// VocabularyTermRequest adds an event type or item category to a club's
+// vocabulary; the label defaults to one made from the value
+type VocabularyTermRequest struct {
+	Value string `json:"value" validate:"required,max=50"`
+	Label string `json:"label" validate:"max=50" sanitize:"text"`
+}
+
+// VocabularyLabelRequest relabels an event type or item category
+type VocabularyLabelRequest struct {
+	Label string `json:"label" validate:"required,max=50" sanitize:"text"`
+}
+
+// CreateNetworkRuleRequest adds a range to the deny list or the admin allow
+// list; cidr is a CIDR range or a single address
+type CreateNetworkRuleRequest struct {
+	List           string `json:"list" validate:"required,oneof=deny admin_allow"`
+	CIDR           string `json:"cidr" validate:"required,max=50"`
+	Note           string `json:"note,omitempty" validate:"max=255" sanitize:"text"`
+	ExpiresInHours *int   `json:"expiresInHours,omitempty" validate:"omitempty,min=1,max=8760"`
+}
+
+// CreatePublisherRequest registers a site embedding club widgets; omitted
+// limits take the configured defaults
+type CreatePublisherRequest struct {
+	Name                string `json:"name" validate:"required,max=255" sanitize:"text"`
+	QuotaPerMinute      *int   `json:"quotaPerMinute,omitempty" validate:"omitempty,min=1,max=100000"`
+	ReplayWindowSeconds *int   `json:"replayWindowSeconds,omitempty" validate:"omitempty,min=1,max=3600"`
+	RequireSignature    bool   `json:"requireSignature"`
+}
+
+// CreateWebhookRequest registers an endpoint for some of a club's events
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,max=10,dive,oneof=event.created member.joined availability.updated"`
+}
+
+// UpdateWebhookRequest changes a webhook; omitted fields are left as they are
+type UpdateWebhookRequest struct {
+	URL      *string  `json:"url,omitempty" validate:"omitempty,max=2048"`
+	Events   []string `json:"events,omitempty" validate:"omitempty,min=1,max=10,dive,oneof=event.created member.joined availability.updated"`
+	IsActive *bool    `json:"isActive,omitempty"`
+}
+
+// CreateIntegrationRequest connects a Slack or Discord incoming webhook to a club
+type CreateIntegrationRequest struct {
+	Provider          string `json:"provider" validate:"required,oneof=slack discord"`
+	WebhookURL        string `json:"webhookUrl" validate:"required,max=2048"`
+	Name              string `json:"name" validate:"max=100" sanitize:"text"`
+	RemindBeforeHours *int   `json:"remindBeforeHours,omitempty" validate:"omitempty,min=0,max=168"` // defaults to 24
+}
+
+// UpdateIntegrationRequest changes an integration; omitted fields are left as they are
+type UpdateIntegrationRequest struct {
+	WebhookURL        *string `json:"webhookUrl,omitempty" validate:"omitempty,max=2048"`
+	Name              *string `json:"name,omitempty" validate:"omitempty,max=100" sanitize:"text"`
+	RemindBeforeHours *int    `json:"remindBeforeHours,omitempty" validate:"omitempty,min=0,max=168"`
+	IsActive          *bool   `json:"isActive,omitempty"`
 }
 
 type AvailabilityRequest struct {
-	UserID uuid.UUID `json:"userId" validate:"required"`
-	Status string    `json:"status" validate:"required"`
-	Notes  *string   `json:"notes,omitempty"`
+	UserID uuid.UUID `json:"userId"` // defaults to the caller
+	Status string    `json:"status" validate:"required,oneof=available maybe unavailable"`
+	Notes  *string   `json:"notes,omitempty" sanitize:"markdown"`
+}
+
+// EventItemsSummary counts an event's items per status and category
+type EventItemsSummary struct {
+	Total      int            `json:"total"`
+	Pending    int            `json:"pending"`
+	InProgress int            `json:"inProgress"`
+	Completed  int            `json:"completed"`
+	Cancelled  int            `json:"cancelled"`
+	Unassigned int            `json:"unassigned"` // open items nobody has taken on
+	ByCategory map[string]int `json:"byCategory"`
+}
+
+// SummarizeEventItems counts items per status and category
+func SummarizeEventItems(items []EventItem) *EventItemsSummary {
+	summary := &EventItemsSummary{ByCategory: map[string]int{}}
+	for _, item := range items {
+		switch item.Status {
+		case "pending":
+			summary.Pending++
+		case "in_progress":
+			summary.InProgress++
+		case "completed":
+			summary.Completed++
+		case "cancelled":
+			summary.Cancelled++
+		}
+		if item.AssignedTo == nil && (item.Status == "pending" || item.Status == "in_progress") {
+			summary.Unassigned++
+		}
+		summary.ByCategory[item.Category]++
+		summary.Total++
+	}
+	return summary
 }
 
 type AvailabilitySummary struct {
@@ -239,6 +893,19 @@ type AvailabilitySummary struct {
 	Total       int `json:"total"`
 }
 
+// Add counts count responses with status
+func (s *AvailabilitySummary) Add(status string, count int) {
+	switch status {
+	case "available":
+		s.Available += count
+	case "maybe":
+		s.Maybe += count
+	case "unavailable":
+		s.Unavailable += count
+	}
+	s.Total += count
+}
+
 type AvailabilityResponse struct {
 	Availability map[string]*Availability `json:"availability"`
 	Summary      *AvailabilitySummary     `json:"summary"`
@@ -246,7 +913,18 @@ type AvailabilityResponse struct {
 
 type AddMemberRequest struct {
 	UserID uuid.UUID `json:"userId" validate:"required"`
-	Role   string    `json:"role" validate:"required"`
+	Role   string    `json:"role" validate:"required,max=20"` // built-in or one of the club's roles
+}
+
+// CreateClubRoleRequest defines a custom club role
+type CreateClubRoleRequest struct {
+	Name         string   `json:"name" validate:"required,min=2,max=20"`
+	Capabilities []string `json:"capabilities" validate:"required,min=1,max=10,dive,oneof=manage_club manage_members manage_events manage_items post_announcements manage_dues manage_contributions"`
+}
+
+// UpdateClubRoleRequest replaces the capabilities of a custom club role
+type UpdateClubRoleRequest struct {
+	Capabilities []string `json:"capabilities" validate:"required,min=1,max=10,dive,oneof=manage_club manage_members manage_events manage_items post_announcements manage_dues manage_contributions"`
 }
 
 type UpdateMemberRequest struct {
@@ -254,6 +932,315 @@ type UpdateMemberRequest struct {
 	IsActive *bool   `json:"isActive,omitempty"`
 }
 
+// ClubJoinRequest is a pending request to join a private club
+type ClubJoinRequest struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ClubID    uuid.UUID  `json:"clubId" db:"club_id"`
+	UserID    uuid.UUID  `json:"userId" db:"user_id"`
+	Status    string     `json:"status" db:"status"`
+	Message   *string    `json:"message,omitempty" db:"message"`
+	DecidedBy *uuid.UUID `json:"decidedBy,omitempty" db:"decided_by"`
+	DecidedAt *time.Time `json:"decidedAt,omitempty" db:"decided_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	User      *User      `json:"user,omitempty"`
+}
+
+type JoinClubRequest struct {
+	Message *string `json:"message,omitempty" sanitize:"markdown"`
+	// Waitlist joins the club's waitlist instead of failing when the club is full
+	Waitlist bool `json:"waitlist,omitempty"`
+}
+
+// Kinds of verified club, shown as a trust badge
+const (
+	VerificationLibrary   = "library"
+	VerificationBookstore = "bookstore"
+	VerificationPartner   = "partner"
+)
+
+// ClubVerificationRequest is a club's application for a verified badge
+type ClubVerificationRequest struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ClubID       uuid.UUID  `json:"clubId" db:"club_id"`
+	ClubName     string     `json:"clubName,omitempty"`
+	RequestedBy  *uuid.UUID `json:"requestedBy,omitempty" db:"requested_by"`
+	Type         string     `json:"type" db:"verification_type"`
+	Organization string     `json:"organization" db:"organization"`
+	Website      *string    `json:"website,omitempty" db:"website"`
+	Details      *string    `json:"details,omitempty" db:"details"`
+	Status       string     `json:"status" db:"status"`
+	ReviewNote   *string    `json:"reviewNote,omitempty" db:"review_note"`
+	ReviewedBy   *uuid.UUID `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ApplyForVerificationRequest asks for a club to be verified as the organization named
+type ApplyForVerificationRequest struct {
+	Type         string  `json:"type" validate:"required,oneof=library bookstore partner"`
+	Organization string  `json:"organization" validate:"required,max=255" sanitize:"text"`
+	Website      *string `json:"website,omitempty" validate:"omitempty,url,max=500"`
+	Details      *string `json:"details,omitempty" validate:"omitempty,max=2000" sanitize:"markdown"`
+}
+
+// ReviewVerificationRequest decides a verification application; the note is shown to the club owner
+type ReviewVerificationRequest struct {
+	Note *string `json:"note,omitempty" validate:"omitempty,max=1000" sanitize:"markdown"`
+}
+
+// SetClubVerificationRequest verifies a club directly, without an application
+type SetClubVerificationRequest struct {
+	Type string `json:"type" validate:"required,oneof=library bookstore partner"`
+}
+
+// ContactClubRequest is a prospective member's message to a public club's owner.
+// Website is a honeypot: it is hidden from people, so only bots fill it in.
+type ContactClubRequest struct {
+	Name         string `json:"name" validate:"required,max=100" sanitize:"text"`
+	Email        string `json:"email" validate:"required,email,max=255"`
+	Message      string `json:"message" validate:"required,min=10,max=2000" sanitize:"markdown"`
+	CaptchaToken string `json:"captchaToken,omitempty"`
+	Website      string `json:"website,omitempty"`
+}
+
+// ClubInvite is a shareable link to join a club. MaxUses is 1 for a
+// single-use link and nil for an unlimited one.
+type ClubInvite struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ClubID    uuid.UUID  `json:"clubId" db:"club_id"`
+	MaxUses   *int       `json:"maxUses" db:"max_uses"`
+	UseCount  int        `json:"useCount" db:"use_count"`
+	ExpiresAt time.Time  `json:"expiresAt" db:"expires_at"`
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// Usable reports whether the invite can still be accepted at now
+func (i ClubInvite) Usable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && (i.MaxUses == nil || i.UseCount < *i.MaxUses)
+}
+
+// RemainingUses is how many more times the invite can be accepted, or nil for unlimited
+func (i ClubInvite) RemainingUses() *int {
+	if i.MaxUses == nil {
+		return nil
+	}
+	remaining := max(*i.MaxUses-i.UseCount, 0)
+	return &remaining
+}
+
+// CreateClubInviteRequest configures a new invite link; without maxUses it
+// can be used any number of times until it expires
+type CreateClubInviteRequest struct {
+	MaxUses        *int `json:"maxUses,omitempty" validate:"omitempty,min=1,max=10000"`
+	ExpiresInHours *int `json:"expiresInHours,omitempty" validate:"omitempty,min=1,max=2160"`
+}
+
+// ClubInvitePreview is what someone holding an invite link sees of the club
+// before accepting, whether or not the club is public
+type ClubInvitePreview struct {
+	Club          PublicClub `json:"club"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	RemainingUses *int       `json:"remainingUses"` // nil for unlimited
+}
+
+// Poll is a vote among candidate books for a club's next read
+type Poll struct {
+	ID             uuid.UUID    `json:"id" db:"id"`
+	ClubID         uuid.UUID    `json:"clubId" db:"club_id"`
+	Title          string       `json:"title" db:"title"`
+	Kind           string       `json:"kind" db:"kind"` // single or ranked
+	ClosesAt       time.Time    `json:"closesAt" db:"closes_at"`
+	SetCurrentBook bool         `json:"setCurrentBook" db:"set_current_book"`
+	CreatedBy      *uuid.UUID   `json:"createdBy,omitempty" db:"created_by"`
+	CreatedAt      time.Time    `json:"createdAt" db:"created_at"`
+	ClosedAt       *time.Time   `json:"closedAt,omitempty" db:"closed_at"`
+	WinnerOptionID *uuid.UUID   `json:"winnerOptionId,omitempty" db:"winner_option_id"`
+	Options        []PollOption `json:"options"`
+	Voters         int          `json:"voters"`
+}
+
+// IsOpen reports whether members can still vote at now
+func (p Poll) IsOpen(now time.Time) bool {
+	return p.ClosedAt == nil && now.Before(p.ClosesAt)
+}
+
+// PollOption is a candidate book in a poll
+type PollOption struct {
+	ID       uuid.UUID `json:"id" db:"id"`
+	Title    string    `json:"title" db:"title"`
+	Author   *string   `json:"author,omitempty" db:"author"`
+	Position int       `json:"position" db:"position"`
+}
+
+type CreatePollRequest struct {
+	Title          string              `json:"title" validate:"required,max=255" sanitize:"text"`
+	Kind           string              `json:"kind" validate:"omitempty,oneof=single ranked"`
+	ClosesAt       time.Time           `json:"closesAt" validate:"required"`
+	SetCurrentBook bool                `json:"setCurrentBook"`
+	Options        []PollOptionRequest `json:"options" validate:"required,min=2,max=20,dive"`
+}
+
+type PollOptionRequest struct {
+	Title  string  `json:"title" validate:"required,max=255" sanitize:"text"`
+	Author *string `json:"author,omitempty" validate:"omitempty,max=255" sanitize:"text"`
+}
+
+// PollVoteRequest is a member's ballot: one option for a single-choice poll,
+// or options in order of preference for a ranked one
+type PollVoteRequest struct {
+	OptionIDs []uuid.UUID `json:"optionIds" validate:"required,min=1,max=20"`
+}
+
+// EventHelperLink grants a non-member temporary access to selected event items
+type EventHelperLink struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	EventID    uuid.UUID  `json:"eventId" db:"event_id"`
+	Label      string     `json:"label" db:"label"`
+	ItemIDs    UUIDArray  `json:"itemIds" db:"item_ids"`
+	CanUpdate  bool       `json:"canUpdate" db:"can_update"`
+	CreatedBy  uuid.UUID  `json:"createdBy" db:"created_by"`
+	ExpiresAt  time.Time  `json:"expiresAt" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+type CreateHelperLinkRequest struct {
+	Label     string      `json:"label" validate:"required" sanitize:"text"`
+	ItemIDs   []uuid.UUID `json:"itemIds" validate:"required,min=1"`
+	CanUpdate bool        `json:"canUpdate"`
+	ExpiresAt *time.Time  `json:"expiresAt,omitempty"`
+}
+
+type CreateSandboxClubRequest struct {
+	Name        string `json:"name" validate:"required,max=255" sanitize:"text"`
+	Description string `json:"description" sanitize:"markdown"`
+	IsPublic    bool   `json:"isPublic"`
+}
+
+type UpdatePreferencesRequest struct {
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+// UpdateProfileRequest changes the given fields of the caller's profile; an
+// empty phone or avatar removes it
+type UpdateProfileRequest struct {
+	Name                    *string                         `json:"name,omitempty" validate:"omitempty,min=1,max=255" sanitize:"text"`
+	Phone                   *string                         `json:"phone,omitempty" validate:"omitempty,max=20"`
+	Avatar                  *string                         `json:"avatar,omitempty" validate:"omitempty,max=500"`
+	NotificationPreferences *NotificationPreferencesRequest `json:"notificationPreferences,omitempty"`
+}
+
+type NotificationPreferencesRequest struct {
+	Email *bool `json:"email,omitempty"`
+	Push  *bool `json:"push,omitempty"`
+}
+
+// ChangePasswordRequest replaces the caller's password after checking the current one
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72"`
+}
+
+type UpdateClubSettingsRequest struct {
+	YouthMode  *bool   `json:"youthMode,omitempty"`
+	BrandColor *string `json:"brandColor,omitempty"`
+	Country    *string `json:"country,omitempty"`
+	MaxMembers *int    `json:"maxMembers,omitempty"` // 0 removes the limit
+	Currency   *string `json:"currency,omitempty"`   // ISO 4217 code
+	// Tags replace the club's tags; aliases are stored as their canonical tag
+	Tags *[]string `json:"tags,omitempty" sanitize:"text"`
+}
+
+// Announcement is a platform-wide message published by a global admin
+type Announcement struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Title      string     `json:"title" db:"title"`
+	Body       string     `json:"body" db:"body"`
+	Severity   string     `json:"severity" db:"severity"`
+	Audience   string     `json:"audience" db:"audience"`
+	ShowBanner bool       `json:"showBanner" db:"show_banner"`
+	StartsAt   time.Time  `json:"startsAt" db:"starts_at"`
+	EndsAt     *time.Time `json:"endsAt,omitempty" db:"ends_at"`
+	CreatedBy  uuid.UUID  `json:"createdBy" db:"created_by"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	Status     string     `json:"status,omitempty"`
+	Read       *bool      `json:"read,omitempty"`
+}
+
+// Notification is a per-user notice about club activity, such as a new event
+type Notification struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"userId" db:"user_id"`
+	Type      string     `json:"type" db:"type"`
+	Title     string     `json:"title" db:"title"`
+	Body      string     `json:"body" db:"body"`
+	ClubID    *uuid.UUID `json:"clubId,omitempty" db:"club_id"`
+	EventID   *uuid.UUID `json:"eventId,omitempty" db:"event_id"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	ReadAt    *time.Time `json:"readAt,omitempty" db:"read_at"`
+}
+
+type CreateAnnouncementRequest struct {
+	Title      string     `json:"title" validate:"required,max=255" sanitize:"text"`
+	Body       string     `json:"body" sanitize:"markdown"`
+	Severity   string     `json:"severity"`
+	Audience   string     `json:"audience"`
+	ShowBanner bool       `json:"showBanner"`
+	StartsAt   *time.Time `json:"startsAt,omitempty"`
+	EndsAt     *time.Time `json:"endsAt,omitempty"`
+}
+
+type UpdateAnnouncementRequest struct {
+	Title      *string    `json:"title,omitempty" sanitize:"text"`
+	Body       *string    `json:"body,omitempty" sanitize:"markdown"`
+	Severity   *string    `json:"severity,omitempty"`
+	Audience   *string    `json:"audience,omitempty"`
+	ShowBanner *bool      `json:"showBanner,omitempty"`
+	StartsAt   *time.Time `json:"startsAt,omitempty"`
+	EndsAt     *time.Time `json:"endsAt,omitempty"`
+}
+
+// Warning is a non-blocking issue returned alongside a successful response
+type Warning struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// FieldError is one invalid input in a VALIDATION_ERROR. Field is the input's
+// JSON path, path parameter or query parameter name, or empty when the request
+// as a whole is invalid. Code is machine-readable (e.g. "required", "uuid") and
+// Message describes the problem relative to the field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldErrorDetails returns field errors as error details, listed under "errors"
+func FieldErrorDetails(errs ...FieldError) map[string]interface{} {
+	return map[string]interface{}{"errors": errs}
+}
+
+// InvalidField returns the error details for a single invalid input
+func InvalidField(field, code, message string) map[string]interface{} {
+	return FieldErrorDetails(FieldError{Field: field, Code: code, Message: message})
+}
+
+// InvalidID returns the error details for a path or query parameter that is not a UUID
+func InvalidID(field string) map[string]interface{} {
+	return InvalidField(field, "uuid", "must be a valid ID")
+}
+
+// ClubTheme holds the branding applied to a club's printed materials
+type ClubTheme struct {
+	BrandColor string `json:"brandColor"`
+}
+
 type Pagination struct {
 	Page       int `json:"page"`
 	Limit      int `json:"limit"`
@@ -261,6 +1248,14 @@ type Pagination struct {
 	TotalPages int `json:"totalPages"`
 }
 
+// CursorPagination describes a page fetched with ?cursor=. Pass NextCursor as
+// ?cursor= for the next page; it is null on the last page.
+type CursorPagination struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"nextCursor"`
+	HasMore    bool    `json:"hasMore"`
+}
+
 // Standard API Response structures
 type APIResponse struct {
 	Success   bool        `json:"success"`
@@ -276,16 +1271,6 @@ type APIError struct {
 	Details map[string]interface{} `json:"details,omitempty"`
 }
 
-// Standard Error Response structure (matches frontend expectations)
-type ErrorResponse struct {
-	Error     string                 `json:"error"`
-	Code      string                 `json:"code"`
-	Message   string                 `json:"message"`
-	Details   map[string]interface{} `json:"details,omitempty"`
-	Timestamp time.Time              `json:"timestamp"`
-	Success   bool                   `json:"success"`
-}
-
 func NewAPIResponse(success bool, data interface{}, message string) *APIResponse {
 	return &APIResponse{
 		Success:   success,
@@ -295,17 +1280,6 @@ func NewAPIResponse(success bool, data interface{}, message string) *APIResponse
 	}
 }
 
-func NewErrorResponse(code, message string, details map[string]interface{}) *ErrorResponse {
-	return &ErrorResponse{
-		Error:     message,
-		Code:      code,
-		Message:   message,
-		Details:   details,
-		Timestamp: time.Now().UTC(),
-		Success:   false,
-	}
-}
-
 func NewAPIErrorResponse(code, message string, details map[string]interface{}) *APIResponse {
 	return &APIResponse{
 		Success: false,
@@ -322,15 +1296,17 @@ func NewAPIErrorResponse(code, message string, details map[string]interface{}) *
 
 // FrontendLoginResponse matches the frontend authentication response format
 type FrontendLoginResponse struct {
-	Token     string `json:"token"`
-	User      *User  `json:"user"`
-	ExpiresAt string `json:"expiresAt"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         *User  `json:"user"`
+	ExpiresAt    string `json:"expiresAt"`
 }
 
 // FrontendRefreshResponse matches the frontend token refresh response format
 type FrontendRefreshResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt string `json:"expiresAt"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"` // replaces the refresh token that was presented
+	ExpiresAt    string `json:"expiresAt"`
 }
 
 // FrontendErrorResponse matches the frontend error handling format
@@ -341,41 +1317,73 @@ type FrontendErrorResponse struct {
 	StatusCode int         `json:"statusCode"`
 	Details    interface{} `json:"details,omitempty"`
 	Timestamp  string      `json:"timestamp,omitempty"`
+	RequestID  string      `json:"requestId,omitempty"`
 }
 
 // FrontendClubMember matches the frontend club member format with flattened user data
 type FrontendClubMember struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Email       string   `json:"email"`
-	Avatar      *string  `json:"avatar,omitempty"`
-	Role        string   `json:"role"`
-	JoinDate    string   `json:"joinDate"`
-	Status      string   `json:"status"`
-	Permissions []string `json:"permissions"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Email       string       `json:"email"`
+	Avatar      *string      `json:"avatar,omitempty"`
+	Role        string       `json:"role"`
+	JoinDate    string       `json:"joinDate"`
+	Status      string       `json:"status"`
+	Permissions []string     `json:"permissions"`
+	Stats       *MemberStats `json:"stats,omitempty"` // with ?include=stats
+
+	EmailUndeliverable *EmailUndeliverable `json:"emailUndeliverable,omitempty"` // shown to owners and moderators
+}
+
+// MemberStats summarizes a member's participation in their club
+type MemberStats struct {
+	EventsAttended int        `json:"eventsAttended"` // past events, archived ones included
+	ItemsCompleted int        `json:"itemsCompleted"` // event items assigned to them and completed
+	BooksRead      int        `json:"booksRead"`
+	BooksTrend     BooksTrend `json:"booksTrend"`
+}
+
+// BooksTrend compares the books of the events a member attended in the last
+// period with the period before it
+type BooksTrend struct {
+	PeriodDays int    `json:"periodDays"`
+	Recent     int    `json:"recent"`
+	Previous   int    `json:"previous"`
+	Direction  string `json:"direction"` // up, down or steady
 }
 
 // FrontendEvent matches the frontend event format with combined datetime
 type FrontendEvent struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`
-	Description *string `json:"description,omitempty"`
-	Date        string  `json:"date"` // ISO 8601 combined datetime
-	Location    *string `json:"location,omitempty"`
-	Type        string  `json:"type"`
-	Status      string  `json:"status"`
-	OrganizerID string  `json:"organizerId"`
+	ID              string  `json:"id"`
+	Title           string  `json:"title"`
+	Description     *string `json:"description,omitempty"`     // Markdown
+	DescriptionHTML *string `json:"descriptionHtml,omitempty"` // rendered for ?format=html
+	Date            string  `json:"date"`                      // ISO 8601 combined datetime (UTC)
+	EndDate         string  `json:"endDate,omitempty"`         // ISO 8601 (UTC)
+	EventTimezone   string  `json:"eventTimezone"`             // the timezone the event is scheduled in
+	LocalDate       string  `json:"localDate,omitempty"`       // Same instant in the caller's timezone
+	Timezone        string  `json:"timezone,omitempty"`
+	RelativeHint    string  `json:"relativeHint,omitempty"` // e.g. "in 3 days", for notification text
+	Location        *string `json:"location,omitempty"`
+	Type            string  `json:"type"`
+	Status          string  `json:"status"`
+	OrganizerID     string  `json:"organizerId"`
 }
 
 // FrontendEventItem matches the frontend event item format
 type FrontendEventItem struct {
-	ID          string  `json:"id"`
-	Title       string  `json:"title"`                 // Maps from "name"
-	Description *string `json:"description,omitempty"` // Maps from "notes"
-	Type        string  `json:"type"`                  // Maps from "category"
-	Status      string  `json:"status"`
-	AssigneeID  *string `json:"assigneeId,omitempty"`
-	DueDate     *string `json:"dueDate,omitempty"`
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`                 // Maps from "name"
+	Description *string      `json:"description,omitempty"` // Maps from "notes"
+	Type        string       `json:"type"`                  // Maps from "category"
+	Status      string       `json:"status"`
+	AssigneeID  *string      `json:"assigneeId,omitempty"`
+	DueDate     *string      `json:"dueDate,omitempty"`
+	SortOrder   int          `json:"sortOrder"`
+	Version     int          `json:"version"`
+	Quantity    *float64     `json:"quantity,omitempty"`
+	Unit        *string      `json:"unit,omitempty"`
+	Cost        *money.Money `json:"cost,omitempty"`
 }
 
 // FrontendAvailability matches the frontend availability format
@@ -419,20 +1427,47 @@ func (cm *ClubMember) ToFrontendFormat() *FrontendClubMember {
 		Email:       cm.User.Email,
 		Avatar:      cm.User.Avatar,
 		Role:        cm.Role,
-		JoinDate:    cm.JoinedDate.UTC().Format(time.RFC3339),
+		JoinDate:    timeutil.FormatTimestamp(cm.JoinedDate),
 		Status:      status,
 		Permissions: permissions,
+
+		EmailUndeliverable: cm.User.EmailUndeliverable,
 	}
 }
 
-// ToFrontendFormat converts an Event to frontend-compatible format
-func (e *Event) ToFrontendFormat() *FrontendEvent {
-	// Combine date and time into ISO 8601 format
-	datetime, err := time.Parse("2006-01-02 15:04:05", e.Date+" "+e.Time)
+// TimeLocation returns the event's timezone, UTC if it has none or an unknown one
+func (e *Event) TimeLocation() *time.Location {
+	return localtime.LocationOrUTC(e.Timezone)
+}
+
+// StartTime combines the stored date and time of the event, which are the
+// wall-clock date and time in its timezone
+func (e *Event) StartTime() time.Time {
+	loc := e.TimeLocation()
+	datetime, err := timeutil.CombineDateTimeIn(e.Date, e.Time, loc)
 	if err != nil {
 		// Fallback to just the date if time parsing fails
-		datetime, _ = time.Parse("2006-01-02", e.Date)
+		date, _ := timeutil.ParseDate(e.Date)
+		datetime = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
 	}
+	return datetime
+}
+
+// StatusAt reports whether the announcement is scheduled, active or expired at now
+func (a *Announcement) StatusAt(now time.Time) string {
+	switch {
+	case now.Before(a.StartsAt):
+		return "scheduled"
+	case a.EndsAt != nil && !now.Before(*a.EndsAt):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+// ToFrontendFormat converts an Event to frontend-compatible format
+func (e *Event) ToFrontendFormat() *FrontendEvent {
+	datetime := e.StartTime()
 
 	// Determine status (adding basic logic for event status)
 	status := "scheduled"
@@ -441,18 +1476,37 @@ func (e *Event) ToFrontendFormat() *FrontendEvent {
 		status = "completed"
 	}
 
+	var endDate string
+	if e.EndsAt != nil {
+		endDate = timeutil.FormatTimestamp(*e.EndsAt)
+	}
+
 	return &FrontendEvent{
-		ID:          e.ID.String(),
-		Title:       e.Title,
-		Description: e.Description,
-		Date:        datetime.UTC().Format(time.RFC3339),
-		Location:    &e.Location,
-		Type:        e.Type,
-		Status:      status,
-		OrganizerID: e.CreatedBy.String(),
+		ID:              e.ID.String(),
+		Title:           e.Title,
+		Description:     e.Description,
+		DescriptionHTML: e.DescriptionHTML,
+		Date:            timeutil.FormatTimestamp(datetime),
+		EndDate:         endDate,
+		EventTimezone:   e.TimeLocation().String(),
+		Location:        &e.Location,
+		Type:            e.Type,
+		Status:          status,
+		OrganizerID:     e.CreatedBy.String(),
 	}
 }
 
+// ToLocalizedFrontendFormat adds the event time in the caller's timezone and a relative hint
+func (e *Event) ToLocalizedFrontendFormat(loc *time.Location, now time.Time) *FrontendEvent {
+	fe := e.ToFrontendFormat()
+	datetime := e.StartTime()
+
+	fe.LocalDate = timeutil.FormatTimestampIn(datetime, loc)
+	fe.Timezone = loc.String()
+	fe.RelativeHint = localtime.RelativeHint(now, datetime, loc)
+	return fe
+}
+
 // ToFrontendFormat converts an EventItem to frontend-compatible format
 func (ei *EventItem) ToFrontendFormat() *FrontendEventItem {
 	var assigneeID *string
@@ -462,10 +1516,8 @@ func (ei *EventItem) ToFrontendFormat() *FrontendEventItem {
 	}
 
 	var dueDate *string
-	if !ei.CreatedAt.IsZero() {
-		// For now, use creation date as due date; in a real implementation,
-		// you might have a separate due_date field
-		date := ei.UpdatedAt.UTC().Format(time.RFC3339)
+	if ei.DueDate != nil {
+		date := timeutil.FormatTimestamp(*ei.DueDate)
 		dueDate = &date
 	}
 
@@ -477,6 +1529,11 @@ func (ei *EventItem) ToFrontendFormat() *FrontendEventItem {
 		Status:      ei.Status,
 		AssigneeID:  assigneeID,
 		DueDate:     dueDate,
+		SortOrder:   ei.SortOrder,
+		Version:     ei.Version,
+		Quantity:    ei.Quantity,
+		Unit:        ei.Unit,
+		Cost:        ei.Cost,
 	}
 }
 
@@ -486,6 +1543,6 @@ func (a *Availability) ToFrontendFormat() *FrontendAvailability {
 		UserID:    a.UserID.String(),
 		Status:    a.Status,
 		Note:      a.Notes,
-		UpdatedAt: a.UpdatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: timeutil.FormatTimestamp(a.UpdatedAt),
 	}
 }
@@ -61,6 +61,41 @@ func TestStringArrayScan(t *testing.T) {
 	}
 }
 
+func TestStringArrayRoundTrip(t *testing.T) {
+	arr := StringArray{"plain", "with space", `quote " and \ backslash`, "a,b", "{braces}", "NULL", ""}
+	val, err := arr.Value()
+	if err != nil {
+		t.Fatalf("Value() failed: %v", err)
+	}
+
+	var scanned StringArray
+	if err := scanned.Scan(val); err != nil {
+		t.Fatalf("Scanning %v failed: %v", val, err)
+	}
+	if len(scanned) != len(arr) {
+		t.Fatalf("Expected %d elements, got %d: %q", len(arr), len(scanned), scanned)
+	}
+	for i := range arr {
+		if scanned[i] != arr[i] {
+			t.Errorf("Expected element %d to be %q, got %q", i, arr[i], scanned[i])
+		}
+	}
+
+	// As PostgreSQL writes them: unquoted unless they need quotes
+	if err := scanned.Scan([]byte(`{plain,"with space","a\\b"}`)); err != nil {
+		t.Fatalf("Scanning server output failed: %v", err)
+	}
+	if len(scanned) != 3 || scanned[1] != "with space" || scanned[2] != `a\b` {
+		t.Errorf("Unexpected elements: %q", scanned)
+	}
+
+	for _, invalid := range []string{"plain", "{a,}", `{"open}`, "{a,NULL}", "{{a},{b}}"} {
+		if err := scanned.Scan(invalid); err == nil {
+			t.Errorf("Expected %q to be refused", invalid)
+		}
+	}
+}
+
 func TestUUIDArrayValue(t *testing.T) {
 	// Test empty array
 	empty := UUIDArray{}
@@ -123,6 +158,37 @@ func TestUUIDArrayScan(t *testing.T) {
 	}
 }
 
+func TestAgendaValueAndScan(t *testing.T) {
+	empty, err := Agenda(nil).Value()
+	if err != nil || empty != "[]" {
+		t.Errorf("Expected an empty agenda to be [], got %v %v", empty, err)
+	}
+
+	minutes := 20
+	value, err := Agenda{{Title: "Welcome", Minutes: &minutes}}.Value()
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+
+	var agenda Agenda
+	if err := agenda.Scan(value); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(agenda) != 1 || agenda[0].Title != "Welcome" || *agenda[0].Minutes != 20 {
+		t.Errorf("Expected the agenda to round-trip, got %+v", agenda)
+	}
+
+	agenda = nil
+	if err := agenda.Scan(nil); err != nil || agenda != nil {
+		t.Errorf("Expected NULL to leave the agenda empty, got %+v %v", agenda, err)
+	}
+
+	var items TemplateItems
+	if err := items.Scan(`[{"name":"Tea","category":"food"}]`); err != nil || len(items) != 1 || items[0].Category != "food" {
+		t.Errorf("Expected template items to scan from a string, got %+v %v", items, err)
+	}
+}
+
 func TestUserModel(t *testing.T) {
 	// Test user creation
 	user := &User{
@@ -215,3 +281,206 @@ func TestAPIResponse(t *testing.T) {
 		t.Errorf("Expected error code 'VALIDATION_ERROR', got %s", errorResponse.Error.Code)
 	}
 }
+
+func TestEventToLocalizedFrontendFormat(t *testing.T) {
+	event := &Event{
+		ID:        uuid.New(),
+		Title:     "Book Night",
+		Date:      "2024-03-13",
+		Time:      "18:30:00",
+		Location:  "Library",
+		Type:      "meeting",
+		CreatedBy: uuid.New(),
+	}
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("Failed to load timezone: %v", err)
+	}
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	fe := event.ToLocalizedFrontendFormat(loc, now)
+
+	if fe.Date != "2024-03-13T18:30:00Z" {
+		t.Errorf("Expected UTC date 2024-03-13T18:30:00Z, got %s", fe.Date)
+	}
+	if fe.LocalDate != "2024-03-13T14:30:00-04:00" {
+		t.Errorf("Expected local date 2024-03-13T14:30:00-04:00, got %s", fe.LocalDate)
+	}
+	if fe.Timezone != "America/New_York" {
+		t.Errorf("Expected timezone America/New_York, got %s", fe.Timezone)
+	}
+	if fe.RelativeHint != "in 3 days" {
+		t.Errorf("Expected relative hint 'in 3 days', got %s", fe.RelativeHint)
+	}
+}
+
+func TestEventStartTime(t *testing.T) {
+	want := time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC)
+
+	// Dates and times as clients send them, and as DATE and TIME columns scan into strings
+	for _, event := range []Event{
+		{Date: "2030-01-15", Time: "19:30"},
+		{Date: "2030-01-15", Time: "19:30:00"},
+		{Date: "2030-01-15T00:00:00Z", Time: "0000-01-01T19:30:00Z"},
+	} {
+		if got := event.StartTime(); !got.Equal(want) {
+			t.Errorf("StartTime(%q, %q) = %v, want %v", event.Date, event.Time, got, want)
+		}
+	}
+
+	// A missing time falls back to midnight
+	event := Event{Date: "2030-01-15"}
+	if got := event.StartTime(); !got.Equal(time.Date(2030, time.January, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected midnight without a time, got %v", got)
+	}
+}
+
+func TestEventStartTimeInTimezone(t *testing.T) {
+	event := Event{Date: "2030-07-15", Time: "19:30", Timezone: "Europe/Berlin"}
+	want := time.Date(2030, time.July, 15, 17, 30, 0, 0, time.UTC)
+	if got := event.StartTime(); !got.Equal(want) {
+		t.Errorf("Expected 19:30 Berlin summer time to be %v, got %v", want, got)
+	}
+
+	end := want.Add(2 * time.Hour)
+	event.EndsAt = &end
+	fe := event.ToFrontendFormat()
+	if fe.Date != "2030-07-15T17:30:00Z" || fe.EndDate != "2030-07-15T19:30:00Z" || fe.EventTimezone != "Europe/Berlin" {
+		t.Errorf("Unexpected frontend times %q to %q in %q", fe.Date, fe.EndDate, fe.EventTimezone)
+	}
+
+	// Events stored before timezones were recorded are read as UTC
+	legacy := Event{Date: "2030-07-15", Time: "19:30"}
+	if fe := legacy.ToFrontendFormat(); fe.Date != "2030-07-15T19:30:00Z" || fe.EventTimezone != "UTC" {
+		t.Errorf("Expected a legacy event in UTC, got %q in %q", fe.Date, fe.EventTimezone)
+	}
+}
+
+func TestAnnouncementStatusAt(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	a := &Announcement{StartsAt: start, EndsAt: &end}
+
+	tests := []struct {
+		now      time.Time
+		expected string
+	}{
+		{start.Add(-time.Minute), "scheduled"},
+		{start, "active"},
+		{end.Add(-time.Minute), "active"},
+		{end, "expired"},
+	}
+
+	for _, tt := range tests {
+		if got := a.StatusAt(tt.now); got != tt.expected {
+			t.Errorf("StatusAt(%v) = %q, expected %q", tt.now, got, tt.expected)
+		}
+	}
+
+	open := &Announcement{StartsAt: start}
+	if got := open.StatusAt(start.AddDate(1, 0, 0)); got != "active" {
+		t.Errorf("Expected announcement without end to stay active, got %q", got)
+	}
+}
+
+func TestClubCapacity(t *testing.T) {
+	unlimited := NewClubCapacity(nil, 40)
+	if unlimited.IsFull() || unlimited.Remaining != nil {
+		t.Errorf("Expected unlimited club to have room, got %+v", unlimited)
+	}
+
+	limit := 10
+	tests := []struct {
+		active    int
+		remaining int
+		full      bool
+		over      bool
+	}{
+		{active: 7, remaining: 3},
+		{active: 10, remaining: 0, full: true},
+		// Limit lowered below the member count: existing members stay
+		{active: 12, remaining: 0, full: true, over: true},
+	}
+
+	for _, tt := range tests {
+		capacity := NewClubCapacity(&limit, tt.active)
+		if *capacity.Remaining != tt.remaining || capacity.IsFull() != tt.full || capacity.OverCapacity != tt.over {
+			t.Errorf("Unexpected capacity for %d active members: %+v", tt.active, capacity)
+		}
+	}
+}
+
+func TestClubInviteUsable(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	limit := 2
+
+	tests := []struct {
+		name      string
+		invite    ClubInvite
+		usable    bool
+		remaining *int
+	}{
+		{"unlimited", ClubInvite{ExpiresAt: now.Add(time.Hour)}, true, nil},
+		{"uses left", ClubInvite{ExpiresAt: now.Add(time.Hour), MaxUses: &limit, UseCount: 1}, true, &[]int{1}[0]},
+		{"used up", ClubInvite{ExpiresAt: now.Add(time.Hour), MaxUses: &limit, UseCount: 2}, false, &[]int{0}[0]},
+		{"expired", ClubInvite{ExpiresAt: now}, false, nil},
+		{"revoked", ClubInvite{ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, false, nil},
+	}
+
+	for _, tt := range tests {
+		if got := tt.invite.Usable(now); got != tt.usable {
+			t.Errorf("%s: Usable = %v, expected %v", tt.name, got, tt.usable)
+		}
+		got := tt.invite.RemainingUses()
+		if (got == nil) != (tt.remaining == nil) || (got != nil && *got != *tt.remaining) {
+			t.Errorf("%s: unexpected remaining uses %v", tt.name, got)
+		}
+	}
+}
+
+func TestSummarizeEventItems(t *testing.T) {
+	assignee := uuid.New()
+	items := []EventItem{
+		{Category: "agenda", Status: "pending"},
+		{Category: "task", Status: "in_progress", AssignedTo: &assignee},
+		{Category: "task", Status: "completed"},
+		{Category: "material", Status: "cancelled"},
+	}
+
+	summary := SummarizeEventItems(items)
+	if summary.Total != 4 || summary.Pending != 1 || summary.InProgress != 1 || summary.Completed != 1 || summary.Cancelled != 1 {
+		t.Errorf("Unexpected status counts: %+v", summary)
+	}
+	if summary.Unassigned != 1 {
+		t.Errorf("Expected only the open unassigned item to count as unassigned, got %d", summary.Unassigned)
+	}
+	if summary.ByCategory["task"] != 2 || summary.ByCategory["agenda"] != 1 {
+		t.Errorf("Unexpected category counts: %v", summary.ByCategory)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	notes := "<b>Two</b> loaves"
+	tags := []string{"<i>sci-fi</i>", "classics"}
+	req := EventTemplateRequest{
+		Name:        "<script>x</script>Potluck",
+		Description: &notes,
+		Type:        "<b>meeting</b>",
+		Items:       TemplateItems{{Name: "<u>Bread</u>", Category: "food", Notes: &notes}},
+	}
+	settings := UpdateClubSettingsRequest{Tags: &tags}
+
+	Sanitize(&req)
+	Sanitize(&settings)
+
+	if req.Name != "Potluck" || *req.Description != "Two loaves" || req.Items[0].Name != "Bread" {
+		t.Errorf("Expected tagged fields to be sanitized, got %+v", req)
+	}
+	if req.Type != "<b>meeting</b>" {
+		t.Errorf("Expected untagged fields to be left alone, got %q", req.Type)
+	}
+	if tags[0] != "sci-fi" || tags[1] != "classics" {
+		t.Errorf("Expected tagged string slices to be sanitized, got %q", tags)
+	}
+}
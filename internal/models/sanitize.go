@@ -0,0 +1,41 @@
+package models
+
+import (
+	"reflect"
+
+	"bookwork-api/internal/sanitize"
+)
+
+// Sanitize cleans the free-text fields of a decoded request in place. Fields
+// opt in with a sanitize tag naming the policy: `sanitize:"text"` strips all
+// HTML, for titles, names and labels; `sanitize:"markdown"` keeps Markdown but
+// no HTML or unsafe links, for notes, descriptions and messages. Tagged fields
+// are strings, string pointers or string slices; nested structs, pointers and
+// slices are walked.
+func Sanitize(v interface{}) {
+	sanitizeValue(reflect.ValueOf(v), "")
+}
+
+func sanitizeValue(v reflect.Value, policy string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			sanitizeValue(v.Elem(), policy)
+		}
+	case reflect.String:
+		if policy != "" && v.CanSet() {
+			v.SetString(sanitize.Apply(policy, v.String()))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			sanitizeValue(v.Index(i), policy)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.IsExported() {
+				sanitizeValue(v.Field(i), field.Tag.Get("sanitize"))
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package money
+
+import "strings"
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code   string
+	Digits int // decimal places of the minor unit
+	Symbol string
+}
+
+// currencies are the currencies clubs can use. Add entries as clubs need them.
+var currencies = map[string]Currency{
+	"AUD": {"AUD", 2, "A$"},
+	"BHD": {"BHD", 3, "BD"},
+	"BRL": {"BRL", 2, "R$"},
+	"CAD": {"CAD", 2, "CA$"},
+	"CHF": {"CHF", 2, "CHF"},
+	"CNY": {"CNY", 2, "CN¥"},
+	"CZK": {"CZK", 2, "Kč"},
+	"DKK": {"DKK", 2, "kr."},
+	"EUR": {"EUR", 2, "€"},
+	"GBP": {"GBP", 2, "£"},
+	"HKD": {"HKD", 2, "HK$"},
+	"HUF": {"HUF", 2, "Ft"},
+	"IDR": {"IDR", 2, "Rp"},
+	"ILS": {"ILS", 2, "₪"},
+	"INR": {"INR", 2, "₹"},
+	"ISK": {"ISK", 0, "kr"},
+	"JPY": {"JPY", 0, "¥"},
+	"KRW": {"KRW", 0, "₩"},
+	"KWD": {"KWD", 3, "KD"},
+	"MXN": {"MXN", 2, "MX$"},
+	"NOK": {"NOK", 2, "kr"},
+	"NZD": {"NZD", 2, "NZ$"},
+	"PLN": {"PLN", 2, "zł"},
+	"SEK": {"SEK", 2, "kr"},
+	"SGD": {"SGD", 2, "S$"},
+	"TRY": {"TRY", 2, "₺"},
+	"USD": {"USD", 2, "$"},
+	"ZAR": {"ZAR", 2, "R"},
+}
+
+// DefaultCurrency is used for clubs that have not chosen one
+const DefaultCurrency = "USD"
+
+// LookupCurrency finds a currency by code, case-insensitively
+func LookupCurrency(code string) (Currency, bool) {
+	c, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
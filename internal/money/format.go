@@ -0,0 +1,145 @@
+package money
+
+import (
+	"strings"
+)
+
+// Format tells clients how to display amounts of one currency in one locale
+type Format struct {
+	Locale           string `json:"locale"`
+	Currency         string `json:"currency"`
+	Symbol           string `json:"symbol"`
+	SymbolPosition   string `json:"symbolPosition"` // "prefix" or "suffix"
+	SymbolSpacing    bool   `json:"symbolSpacing"`  // a space between symbol and number
+	DecimalSeparator string `json:"decimalSeparator"`
+	GroupSeparator   string `json:"groupSeparator"`
+	FractionDigits   int    `json:"fractionDigits"`
+}
+
+// localeStyle is how a locale writes numbers and places currency symbols.
+// Spaces are non-breaking so a formatted amount never wraps across lines.
+type localeStyle struct {
+	decimal, group string
+	suffix, spaced bool
+}
+
+// DefaultLocale is used when the client sends no supported locale
+const DefaultLocale = "en-US"
+
+var locales = map[string]localeStyle{
+	"en-US": {".", ",", false, false},
+	"en-GB": {".", ",", false, false},
+	"en-AU": {".", ",", false, false},
+	"en-CA": {".", ",", false, false},
+	"en-IN": {".", ",", false, false},
+	"de-DE": {",", ".", true, true},
+	"de-AT": {",", ".", false, true},
+	"de-CH": {".", "’", false, true},
+	"fr-FR": {",", "\u202f", true, true},
+	"fr-CA": {",", "\u00a0", true, true},
+	"es-ES": {",", ".", true, true},
+	"es-MX": {".", ",", false, false},
+	"it-IT": {",", ".", true, true},
+	"nl-NL": {",", ".", false, true},
+	"pt-BR": {",", ".", false, true},
+	"pt-PT": {",", "\u00a0", true, true},
+	"sv-SE": {",", "\u00a0", true, true},
+	"nb-NO": {",", "\u00a0", true, true},
+	"da-DK": {",", ".", true, true},
+	"pl-PL": {",", "\u00a0", true, true},
+	"ja-JP": {".", ",", false, false},
+	"zh-CN": {".", ",", false, false},
+	"ko-KR": {".", ",", false, false},
+}
+
+// languageDefaults resolves a bare language ("de") to its usual locale
+var languageDefaults = map[string]string{
+	"en": "en-US", "de": "de-DE", "fr": "fr-FR", "es": "es-ES", "it": "it-IT", "nl": "nl-NL",
+	"pt": "pt-BR", "sv": "sv-SE", "nb": "nb-NO", "no": "nb-NO", "da": "da-DK", "pl": "pl-PL",
+	"ja": "ja-JP", "zh": "zh-CN", "ko": "ko-KR",
+}
+
+// NegotiateLocale picks the first supported locale from an Accept-Language
+// header, falling back to the language's usual locale and then DefaultLocale.
+// Quality values are ignored; browsers list languages in preference order.
+func NegotiateLocale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		language, region, _ := strings.Cut(tag, "-")
+		if language == "" {
+			continue
+		}
+		language = strings.ToLower(language)
+
+		locale := language + "-" + strings.ToUpper(region)
+		if _, ok := locales[locale]; ok {
+			return locale
+		}
+		if locale, ok := languageDefaults[language]; ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// FormatFor returns display metadata for currency in locale
+func FormatFor(currency, locale string) Format {
+	c, ok := LookupCurrency(currency)
+	if !ok {
+		c = Currency{Code: strings.ToUpper(currency), Digits: 2, Symbol: strings.ToUpper(currency)}
+	}
+	style, ok := locales[locale]
+	if !ok {
+		locale = DefaultLocale
+		style = locales[DefaultLocale]
+	}
+
+	position := "prefix"
+	if style.suffix {
+		position = "suffix"
+	}
+	return Format{
+		Locale:           locale,
+		Currency:         c.Code,
+		Symbol:           c.Symbol,
+		SymbolPosition:   position,
+		SymbolSpacing:    style.spaced,
+		DecimalSeparator: style.decimal,
+		GroupSeparator:   style.group,
+		FractionDigits:   c.Digits,
+	}
+}
+
+// Apply formats m for display, e.g. "$1,234.50" or "1.234,50 €"
+func (f Format) Apply(m Money) string {
+	s := m.String()
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	var grouped strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(f.GroupSeparator)
+		}
+		grouped.WriteRune(r)
+	}
+	number := grouped.String()
+	if fraction != "" {
+		number += f.DecimalSeparator + fraction
+	}
+
+	space := ""
+	if f.SymbolSpacing {
+		space = "\u00a0"
+	}
+	if f.SymbolPosition == "suffix" {
+		number = number + space + f.Symbol
+	} else {
+		number = f.Symbol + space + number
+	}
+	if negative {
+		number = "-" + number
+	}
+	return number
+}
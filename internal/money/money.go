@@ -0,0 +1,249 @@
+// Package money represents amounts of money without floating point math.
+//
+// A Money is an integer number of the currency's minor units (cents for USD,
+// yen for JPY, fils for KWD) plus an ISO 4217 code. Amounts enter the system
+// as decimal strings and are parsed exactly; conversions between currencies
+// use exact rational arithmetic and round once, half away from zero.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooPrecise       = errors.New("amount has more decimal places than the currency allows")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrInvalidRate      = errors.New("invalid exchange rate")
+)
+
+// Money is an amount in a currency's minor units
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// New returns minor units of currency
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Parse reads a decimal amount such as "12.50" or "-3" in currency. It rejects
+// amounts with more decimal places than the currency has, rather than rounding them.
+func Parse(amount, currency string) (Money, error) {
+	c, ok := LookupCurrency(currency)
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || (strings.Contains(amount, ".") && fraction == "") {
+		return Money{}, ErrInvalidAmount
+	}
+
+	// Trailing zeros beyond the currency's precision are harmless ("1.50" in JPY is not, "1.00" is)
+	trimmed := strings.TrimRight(fraction, "0")
+	if len(trimmed) > c.Digits {
+		return Money{}, ErrTooPrecise
+	}
+	digits := whole + trimmed + strings.Repeat("0", c.Digits-len(trimmed))
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: c.Code}, nil
+}
+
+// String formats the amount as a plain decimal, e.g. "12.50", suitable for
+// NUMERIC columns and API payloads
+func (m Money) String() string {
+	digits := 2
+	if c, ok := LookupCurrency(m.Currency); ok {
+		digits = c.Digits
+	}
+
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	s := strconv.FormatInt(minor, 10)
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// Add returns m + other; both must be in the same currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Minor: m.Minor + other.Minor, Currency: m.Currency}, nil
+}
+
+// Allocate splits m into n parts that differ by at most one minor unit and add
+// up to m exactly. Leftover units go to the first parts.
+func (m Money) Allocate(n int) []Money {
+	if n < 1 {
+		return nil
+	}
+	each := m.Minor / int64(n)
+	leftover := m.Minor % int64(n)
+
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{Minor: each, Currency: m.Currency}
+		if leftover > 0 && int64(i) < leftover {
+			parts[i].Minor++
+		} else if leftover < 0 && int64(i) < -leftover {
+			parts[i].Minor--
+		}
+	}
+	return parts
+}
+
+// Convert changes m into currency at rate, where one unit of m's currency is
+// worth rate units of currency. rate is a decimal string such as "0.9215".
+func (m Money) Convert(rate, currency string) (Money, error) {
+	to, ok := LookupCurrency(currency)
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+	from, ok := LookupCurrency(m.Currency)
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+	r, ok := ParseRate(rate)
+	if !ok {
+		return Money{}, ErrInvalidRate
+	}
+
+	// minor_to = minor_from * rate * 10^(to.Digits - from.Digits)
+	value := new(big.Rat).SetInt64(m.Minor)
+	value.Mul(value, r)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(to.Digits-from.Digits))), nil))
+	if to.Digits >= from.Digits {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	minor := roundHalfAwayFromZero(value)
+	if !minor.IsInt64() {
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{Minor: minor.Int64(), Currency: to.Code}, nil
+}
+
+// ParseRate reads a positive decimal exchange rate exactly
+func ParseRate(rate string) (*big.Rat, bool) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 || strings.ContainsAny(rate, "/eE") {
+		return nil, false
+	}
+	return r, true
+}
+
+// InvertRate returns 1/rate as a decimal string with 10 decimal places
+func InvertRate(rate string) (string, bool) {
+	r, ok := ParseRate(rate)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimRight(strings.TrimRight(new(big.Rat).Inv(r).FloatString(10), "0"), "."), true
+}
+
+// MarshalJSON writes the amount as an exact decimal string alongside its
+// currency and minor units, so clients never have to parse a float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+		Minor    int64  `json:"minor"`
+	}{m.String(), m.Currency, m.Minor})
+}
+
+// UnmarshalJSON reads the form written by MarshalJSON
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Decimal is an amount in a request body. It accepts a JSON number or string
+// and keeps its exact text, so 0.1 stays 0.1 rather than becoming a float.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	if _, ok := new(big.Rat).SetString(text); !ok || strings.ContainsAny(text, "/eE") {
+		return ErrInvalidAmount
+	}
+	*d = Decimal(text)
+	return nil
+}
+
+func roundHalfAwayFromZero(r *big.Rat) *big.Int {
+	num, den := new(big.Int).Set(r.Num()), r.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Mul(remainder, big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+	return quotient
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,171 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		err      error
+	}{
+		{"12.50", "usd", 1250, nil},
+		{"0.1", "EUR", 10, nil},
+		{"-3", "EUR", -300, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1.00", "JPY", 1, nil},
+		{"1.5", "JPY", 0, ErrTooPrecise},
+		{"1.234", "KWD", 1234, nil},
+		{"1.239", "USD", 0, ErrTooPrecise},
+		{"abc", "USD", 0, ErrInvalidAmount},
+		{"1.", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"1", "XXX", 0, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.amount, tt.currency)
+		if err != tt.err {
+			t.Errorf("Parse(%q, %q): expected error %v, got %v", tt.amount, tt.currency, tt.err, err)
+			continue
+		}
+		if err == nil && m.Minor != tt.minor {
+			t.Errorf("Parse(%q, %q): expected %d minor units, got %d", tt.amount, tt.currency, tt.minor, m.Minor)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := map[Money]string{
+		New(1250, "USD"): "12.50",
+		New(5, "USD"):    "0.05",
+		New(-5, "EUR"):   "-0.05",
+		New(1500, "JPY"): "1500",
+		New(1, "KWD"):    "0.001",
+	}
+	for m, expected := range tests {
+		if got := m.String(); got != expected {
+			t.Errorf("%+v.String() = %q, expected %q", m, got, expected)
+		}
+	}
+}
+
+func TestAllocateAddsUp(t *testing.T) {
+	for _, m := range []Money{New(1000, "USD"), New(-1000, "USD"), New(2, "USD")} {
+		parts := m.Allocate(3)
+		var total int64
+		for _, part := range parts {
+			total += part.Minor
+		}
+		if total != m.Minor {
+			t.Errorf("Allocate(%d) parts %v do not add up", m.Minor, parts)
+		}
+		if parts[0].Minor-parts[2].Minor > 1 || parts[2].Minor-parts[0].Minor > 1 {
+			t.Errorf("Allocate(%d) parts %v are uneven", m.Minor, parts)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from     Money
+		rate     string
+		currency string
+		minor    int64
+	}{
+		{New(1000, "USD"), "0.92", "EUR", 920},
+		{New(1000, "USD"), "151.234", "JPY", 1512}, // 1512.34 rounds down
+		{New(1050, "USD"), "1.5", "JPY", 16},       // 15.75 rounds up
+		{New(500, "JPY"), "0.0062", "USD", 310},    // 3.10
+		{New(-1, "EUR"), "1.5", "USD", -2},         // -1.5 rounds away from zero
+		{New(1000, "KWD"), "3.25", "USD", 325},     // 1.000 KWD = 3.25 USD
+	}
+
+	for _, tt := range tests {
+		got, err := tt.from.Convert(tt.rate, tt.currency)
+		if err != nil {
+			t.Errorf("Convert(%+v, %s): %v", tt.from, tt.rate, err)
+			continue
+		}
+		if got.Minor != tt.minor || got.Currency != tt.currency {
+			t.Errorf("Convert(%+v, %s) = %+v, expected %d %s", tt.from, tt.rate, got, tt.minor, tt.currency)
+		}
+	}
+
+	for _, rate := range []string{"0", "-1", "1/3", "1e2", "abc"} {
+		if _, err := New(100, "USD").Convert(rate, "EUR"); err != ErrInvalidRate {
+			t.Errorf("Expected ErrInvalidRate for %q, got %v", rate, err)
+		}
+	}
+}
+
+func TestInvertRate(t *testing.T) {
+	if inverse, ok := InvertRate("0.8"); !ok || inverse != "1.25" {
+		t.Errorf("Expected 1.25, got %q", inverse)
+	}
+	if inverse, _ := InvertRate("3"); inverse != "0.3333333333" {
+		t.Errorf("Expected 10 decimal places, got %q", inverse)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(1250, "EUR"))
+	if err != nil || string(data) != `{"amount":"12.50","currency":"EUR","minor":1250}` {
+		t.Fatalf("Unexpected JSON %s, %v", data, err)
+	}
+
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil || m != New(1250, "EUR") {
+		t.Errorf("Round trip failed: %+v, %v", m, err)
+	}
+
+	var req struct {
+		Cost Decimal `json:"cost"`
+	}
+	for _, body := range []string{`{"cost": 0.10}`, `{"cost": "0.10"}`} {
+		if err := json.Unmarshal([]byte(body), &req); err != nil || req.Cost != "0.10" {
+			t.Errorf("Expected exact decimal from %s, got %q, %v", body, req.Cost, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"cost": "ten"}`), &req); err == nil {
+		t.Error("Expected an error for a non-numeric amount")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		m        Money
+		locale   string
+		expected string
+	}{
+		{New(123450, "USD"), "en-US", "$1,234.50"},
+		{New(123450, "EUR"), "de-DE", "1.234,50\u00a0€"},
+		{New(-123450, "EUR"), "fr-FR", "-1\u202f234,50\u00a0€"},
+		{New(1234567, "JPY"), "ja-JP", "¥1,234,567"},
+		{New(99, "GBP"), "xx-XX", "£0.99"},
+	}
+	for _, tt := range tests {
+		if got := FormatFor(tt.m.Currency, tt.locale).Apply(tt.m); got != tt.expected {
+			t.Errorf("Format %+v in %s = %q, expected %q", tt.m, tt.locale, got, tt.expected)
+		}
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := map[string]string{
+		"de-DE,de;q=0.9,en;q=0.8": "de-DE",
+		"fr":                      "fr-FR",
+		"en-gb":                   "en-GB",
+		"xx-YY, es-MX":            "es-MX",
+		"":                        DefaultLocale,
+		"*":                       DefaultLocale,
+	}
+	for header, expected := range tests {
+		if got := NegotiateLocale(header); got != expected {
+			t.Errorf("NegotiateLocale(%q) = %q, expected %q", header, got, expected)
+		}
+	}
+}
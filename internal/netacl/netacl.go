@@ -0,0 +1,330 @@
+// Package netacl restricts clients by network address. The deny list refuses
+// known abusers on every route; the admin allow list, when not empty, limits
+// the admin routes to office and VPN networks.
+//
+// Rules are CIDR ranges or single addresses. Rules from the configuration are
+// fixed; rules added by admins are stored in network_rules and reloaded
+// periodically, so every instance picks them up.
+package netacl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/logging"
+	"bookwork-api/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// Lists a rule can belong to
+const (
+	ListDeny       = "deny"
+	ListAdminAllow = "admin_allow"
+)
+
+// ErrNotFound is returned when removing a rule that does not exist, including
+// rules from the configuration
+var ErrNotFound = errors.New("network rule not found")
+
+// Rule is one network range on a list
+type Rule struct {
+	ID        uuid.UUID  `json:"id"`
+	List      string     `json:"list"`
+	CIDR      string     `json:"cidr"`
+	Note      string     `json:"note,omitempty"`
+	Static    bool       `json:"static"` // from the configuration; cannot be removed at runtime
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// NewRule describes a rule to add
+type NewRule struct {
+	List      string
+	CIDR      string
+	Note      string
+	CreatedBy *uuid.UUID
+	ExpiresAt *time.Time
+}
+
+// ParsePrefix accepts a CIDR range or a single IPv4 or IPv6 address
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ACL holds the rules in memory so requests are checked without a database round trip
+type ACL struct {
+	db         *database.DB
+	static     []Rule
+	trustProxy bool
+	interval   time.Duration
+	logger     *slog.Logger
+
+	// changing serializes Load, Add and Remove from reading the stored
+	// rules to setting them, so none undoes another's change. Requests only
+	// wait on mu, which is not held while the database is queried.
+	changing sync.Mutex
+
+	mu    sync.RWMutex
+	rules []Rule
+	deny  []netip.Prefix
+	admin []netip.Prefix
+}
+
+// New creates an ACL with the configured ranges. With trustProxy, the client
+// address is taken from the X-Forwarded-For entry added by the nearest proxy.
+func New(db *database.DB, deny, adminAllow []string, trustProxy bool, interval time.Duration, logger *slog.Logger) (*ACL, error) {
+	acl := &ACL{db: db, trustProxy: trustProxy, interval: interval, logger: logger}
+	for _, list := range []struct {
+		name  string
+		cidrs []string
+	}{{ListDeny, deny}, {ListAdminAllow, adminAllow}} {
+		for _, cidr := range list.cidrs {
+			if strings.TrimSpace(cidr) == "" {
+				continue
+			}
+			prefix, err := ParsePrefix(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s range %q: %w", list.name, cidr, err)
+			}
+			acl.static = append(acl.static, Rule{List: list.name, CIDR: prefix.String(), Static: true})
+		}
+	}
+	acl.set(nil)
+	return acl, nil
+}
+
+// set replaces the stored rules, keeping the static ones
+func (acl *ACL) set(stored []Rule) {
+	rules := append(append([]Rule{}, acl.static...), stored...)
+	var deny, admin []netip.Prefix
+	for _, rule := range rules {
+		prefix, err := ParsePrefix(rule.CIDR)
+		if err != nil {
+			continue // validated when added
+		}
+		if rule.List == ListDeny {
+			deny = append(deny, prefix)
+		} else {
+			admin = append(admin, prefix)
+		}
+	}
+
+	acl.mu.Lock()
+	defer acl.mu.Unlock()
+	acl.rules, acl.deny, acl.admin = rules, deny, admin
+}
+
+// Load replaces the in-memory rules with the unexpired ones in the database
+func (acl *ACL) Load(ctx context.Context) error {
+	acl.changing.Lock()
+	defer acl.changing.Unlock()
+
+	rows, err := acl.db.QueryContext(ctx, `
+		SELECT id, list, cidr, COALESCE(note, ''), created_by, created_at, expires_at
+		FROM network_rules
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at`)
+	if err != nil {
+		return fmt.Errorf("failed to query network rules: %w", err)
+	}
+	defer rows.Close()
+
+	stored := []Rule{}
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.ID, &rule.List, &rule.CIDR, &rule.Note, &rule.CreatedBy, &rule.CreatedAt, &rule.ExpiresAt); err != nil {
+			return fmt.Errorf("failed to scan network rule: %w", err)
+		}
+		stored = append(stored, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	acl.set(stored)
+	return nil
+}
+
+// Rules returns the rules in force, static ones first
+func (acl *ACL) Rules() []Rule {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	return append([]Rule{}, acl.rules...)
+}
+
+// Add stores a rule and applies it on this instance immediately
+func (acl *ACL) Add(ctx context.Context, nr NewRule) (Rule, error) {
+	prefix, err := ParsePrefix(nr.CIDR)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule := Rule{
+		ID:        uuid.New(),
+		List:      nr.List,
+		CIDR:      prefix.String(),
+		Note:      nr.Note,
+		CreatedBy: nr.CreatedBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: nr.ExpiresAt,
+	}
+
+	acl.changing.Lock()
+	defer acl.changing.Unlock()
+
+	_, err = acl.db.ExecContext(ctx, `
+		INSERT INTO network_rules (id, list, cidr, note, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		rule.ID, rule.List, rule.CIDR, rule.Note, rule.CreatedBy, rule.CreatedAt, rule.ExpiresAt)
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to create network rule: %w", err)
+	}
+
+	acl.set(append(acl.stored(), rule))
+	return rule, nil
+}
+
+// Remove deletes a stored rule and stops applying it on this instance immediately
+func (acl *ACL) Remove(ctx context.Context, id uuid.UUID) (Rule, error) {
+	acl.changing.Lock()
+	defer acl.changing.Unlock()
+
+	var removed *Rule
+	remaining := []Rule{}
+	for _, rule := range acl.stored() {
+		if rule.ID == id {
+			r := rule
+			removed = &r
+			continue
+		}
+		remaining = append(remaining, rule)
+	}
+
+	result, err := acl.db.ExecContext(ctx, `DELETE FROM network_rules WHERE id = $1`, id)
+	if err != nil {
+		return Rule{}, fmt.Errorf("failed to delete network rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 && removed == nil {
+		return Rule{}, ErrNotFound
+	}
+
+	acl.set(remaining)
+	if removed == nil {
+		return Rule{ID: id}, nil
+	}
+	return *removed, nil
+}
+
+// stored returns the rules that are not static
+func (acl *ACL) stored() []Rule {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	return append([]Rule{}, acl.rules[len(acl.static):]...)
+}
+
+// Denied reports whether addr is on the deny list
+func (acl *ACL) Denied(addr netip.Addr) bool {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	return contains(acl.deny, addr)
+}
+
+// AdminAllowed reports whether addr may use the admin routes; anyone may while
+// the admin allow list is empty
+func (acl *ACL) AdminAllowed(addr netip.Addr) bool {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	return len(acl.admin) == 0 || contains(acl.admin, addr)
+}
+
+// AdminRestricted reports whether the admin allow list has any ranges
+func (acl *ACL) AdminRestricted() bool {
+	acl.mu.RLock()
+	defer acl.mu.RUnlock()
+	return len(acl.admin) > 0
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientAddr is the address the request came from. Without trustProxy it is
+// the connection's peer; with it, the last X-Forwarded-For entry, which the
+// nearest proxy sets and the client cannot forge.
+func (acl *ACL) ClientAddr(r *http.Request) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(middleware.ForwardedClient(r, acl.trustProxy))
+	return addr, err == nil
+}
+
+// Middleware refuses clients on the deny list. It runs before rate limiting so
+// denied clients do not use up limiter state.
+func (acl *ACL) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := acl.ClientAddr(r); ok && acl.Denied(addr) {
+			logging.FromContext(r.Context()).Warn("refused denied client", "client_ip", addr.String())
+			apierror.Write(w, time.Now(), apierror.New(apierror.CodeAccessDenied, "Access from your network is not allowed", nil))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdminNetwork refuses clients outside the admin allow list
+func (acl *ACL) RequireAdminNetwork(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, ok := acl.ClientAddr(r)
+		if !ok || !acl.AdminAllowed(addr) {
+			logging.FromContext(r.Context()).Warn("refused admin request from outside the allowed networks", "client_ip", addr.String())
+			apierror.Write(w, time.Now(), apierror.New(apierror.CodeAccessDenied, "Admin access is not allowed from your network", nil))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run reloads the stored rules immediately and then every interval until ctx is cancelled
+func (acl *ACL) Run(ctx context.Context) {
+	ticker := time.NewTicker(acl.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := acl.Load(ctx); err != nil {
+			acl.logger.Error("error loading network rules", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
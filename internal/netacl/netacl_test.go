@@ -0,0 +1,124 @@
+package netacl
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/database"
+)
+
+func TestParsePrefix(t *testing.T) {
+	tests := map[string]string{
+		"10.1.2.3/8":    "10.0.0.0/8",
+		" 192.0.2.7 ":   "192.0.2.7/32",
+		"2001:db8::/32": "2001:db8::/32",
+		"2001:db8::1":   "2001:db8::1/128",
+	}
+	for in, expected := range tests {
+		prefix, err := ParsePrefix(in)
+		if err != nil || prefix.String() != expected {
+			t.Errorf("ParsePrefix(%q) = %v, %v; expected %s", in, prefix, err, expected)
+		}
+	}
+
+	for _, in := range []string{"", "10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParsePrefix(in); err == nil {
+			t.Errorf("Expected ParsePrefix(%q) to fail", in)
+		}
+	}
+}
+
+func newTestACL(t *testing.T, deny, admin []string, trustProxy bool) *ACL {
+	acl, err := New(database.NewMock(), deny, admin, trustProxy, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return acl
+}
+
+func TestACLLists(t *testing.T) {
+	acl := newTestACL(t, []string{"198.51.100.0/24"}, nil, false)
+
+	if !acl.Denied(netip.MustParseAddr("198.51.100.20")) || acl.Denied(netip.MustParseAddr("203.0.113.5")) {
+		t.Error("Expected only the denied range to be denied")
+	}
+	// IPv4 clients on a dual-stack listener arrive as mapped IPv6 addresses
+	if !acl.Denied(netip.MustParseAddr("::ffff:198.51.100.20")) {
+		t.Error("Expected a mapped address in the denied range to be denied")
+	}
+	if !acl.AdminAllowed(netip.MustParseAddr("203.0.113.5")) {
+		t.Error("Expected admin routes open to all without an allow list")
+	}
+
+	rule, err := acl.Add(context.Background(), NewRule{List: ListAdminAllow, CIDR: "10.8.0.0/16", Note: "VPN"})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if !acl.AdminAllowed(netip.MustParseAddr("10.8.4.2")) || acl.AdminAllowed(netip.MustParseAddr("203.0.113.5")) {
+		t.Error("Expected admin routes limited to the allowed range")
+	}
+	if rules := acl.Rules(); len(rules) != 2 || !rules[0].Static || rules[1].ID != rule.ID {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	if _, err := acl.Remove(context.Background(), rule.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if !acl.AdminAllowed(netip.MustParseAddr("203.0.113.5")) {
+		t.Error("Expected admin routes open again once the allow list is empty")
+	}
+
+	if _, err := New(database.NewMock(), []string{"bogus"}, nil, false, time.Minute, nil); err == nil {
+		t.Error("Expected an invalid configured range to be rejected")
+	}
+}
+
+func TestACLMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remoteAddr string
+		forwarded  string
+		expected   int
+	}{
+		{"denied peer", false, "198.51.100.9:4000", "", http.StatusForbidden},
+		{"allowed peer", false, "203.0.113.5:4000", "", http.StatusOK},
+		// Without trusted proxies the header is ignored
+		{"untrusted header", false, "203.0.113.5:4000", "198.51.100.9", http.StatusOK},
+		{"allowed behind proxy", true, "10.0.0.2:4000", "203.0.113.5", http.StatusOK},
+		// A denied client cannot hide behind an entry it wrote itself before the proxy's
+		{"forged first entry", true, "10.0.0.2:4000", "203.0.113.5, 198.51.100.9", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		acl := newTestACL(t, []string{"198.51.100.0/24"}, nil, tt.trustProxy)
+		req := httptest.NewRequest(http.MethodGet, "/api/clubs", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+
+		w := httptest.NewRecorder()
+		acl.Middleware(ok).ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.expected, w.Code)
+		}
+	}
+
+	acl := newTestACL(t, nil, []string{"10.8.0.0/16"}, false)
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/overview", nil)
+	req.RemoteAddr = "203.0.113.5:4000"
+	w := httptest.NewRecorder()
+	acl.RequireAdminNetwork(ok).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected admin request from outside the allow list to be refused, got %d", w.Code)
+	}
+}
@@ -0,0 +1,361 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery is one notification to send to one user
+type Delivery struct {
+	NotificationID uuid.UUID
+	UserID         uuid.UUID
+	Type           string
+	Title          string
+	Body           string
+}
+
+// Provider delivers notifications outside the app, e.g. push or email
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, batch []Delivery) error
+}
+
+// Outcomes of a delivery at a provider
+const (
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliveryBounced = "bounced"
+	DeliveryDropped = "dropped"
+	// DeliverySuppressed means the provider was not asked, because the
+	// recipient must not be reached through it, e.g. their email bounced or
+	// they turned email off
+	DeliverySuppressed = "suppressed"
+)
+
+// Names email and push providers register under, which suppressions and
+// users' channel preferences apply to
+const (
+	ProviderEmail = "email"
+	ProviderPush  = "push"
+)
+
+// ErrBounced marks a delivery the recipient's side refused, such as mail to
+// an address that does not exist; wrap it in a BatchError entry
+var ErrBounced = errors.New("delivery bounced")
+
+// BatchError is returned by a provider's Send when only some deliveries of a
+// batch failed, keyed by notification ID; deliveries not in it were sent
+type BatchError map[uuid.UUID]error
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("%d deliveries failed", len(e))
+}
+
+// Outcome is what happened to one notification at one provider
+type Outcome struct {
+	NotificationID uuid.UUID
+	Status         string
+	Error          string
+}
+
+// OutcomeRecorder keeps delivery outcomes, for delivery health reports
+type OutcomeRecorder interface {
+	RecordOutcomes(ctx context.Context, provider string, outcomes []Outcome) error
+}
+
+// Suppressor tells which users a provider must not deliver to
+type Suppressor interface {
+	Suppressed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// ProviderLimits bounds how often and with how much a provider is called
+type ProviderLimits struct {
+	BatchSize int           // most deliveries per Send call
+	Interval  time.Duration // least time between Send calls
+	QueueSize int           // deliveries waiting beyond this are dropped
+}
+
+// Default limits for providers registered without them
+var DefaultLimits = ProviderLimits{BatchSize: 100, Interval: time.Second, QueueSize: 10000}
+
+// Dispatcher queues deliveries for every registered provider and sends them in
+// rate-limited batches, one worker per provider
+type Dispatcher struct {
+	logger      *slog.Logger
+	queues      []*providerQueue
+	recorder    OutcomeRecorder // nil when outcomes are only logged
+	suppressors map[string][]Suppressor
+}
+
+type providerQueue struct {
+	provider Provider
+	limits   ProviderLimits
+	jobs     chan Delivery
+}
+
+func NewDispatcher(logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{logger: logger, suppressors: map[string][]Suppressor{}}
+}
+
+// WithRecorder records the outcome of every delivery, including those dropped
+// because a provider's queue was full
+func (d *Dispatcher) WithRecorder(recorder OutcomeRecorder) *Dispatcher {
+	d.recorder = recorder
+	return d
+}
+
+// WithSuppressor skips deliveries to the users suppressor names at the
+// provider registered under provider; a provider may have several
+func (d *Dispatcher) WithSuppressor(provider string, suppressor Suppressor) *Dispatcher {
+	d.suppressors[provider] = append(d.suppressors[provider], suppressor)
+	return d
+}
+
+// Register adds a provider. Providers must be registered before Run.
+func (d *Dispatcher) Register(provider Provider, limits ProviderLimits) {
+	if limits.BatchSize <= 0 {
+		limits.BatchSize = DefaultLimits.BatchSize
+	}
+	if limits.Interval <= 0 {
+		limits.Interval = DefaultLimits.Interval
+	}
+	if limits.QueueSize <= 0 {
+		limits.QueueSize = DefaultLimits.QueueSize
+	}
+
+	d.queues = append(d.queues, &providerQueue{
+		provider: provider,
+		limits:   limits,
+		jobs:     make(chan Delivery, limits.QueueSize),
+	})
+}
+
+// Enqueue queues deliveries for every provider without blocking; deliveries
+// that do not fit in a provider's queue are dropped and logged
+func (d *Dispatcher) Enqueue(deliveries []Delivery) {
+	for _, q := range d.queues {
+		var dropped []Outcome
+		for _, delivery := range deliveries {
+			select {
+			case q.jobs <- delivery:
+			default:
+				dropped = append(dropped, Outcome{NotificationID: delivery.NotificationID, Status: DeliveryDropped, Error: "queue full"})
+			}
+		}
+		if len(dropped) > 0 {
+			d.logger.Warn("notification queue full, dropped deliveries", "provider", q.provider.Name(), "dropped", len(dropped))
+			d.record(context.Background(), q.provider.Name(), dropped)
+		}
+	}
+}
+
+// Pending returns the number of deliveries waiting across all provider queues
+func (d *Dispatcher) Pending() int {
+	pending := 0
+	for _, q := range d.queues {
+		pending += len(q.jobs)
+	}
+	return pending
+}
+
+// Run sends queued deliveries until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, q := range d.queues {
+		wg.Add(1)
+		go func(q *providerQueue) {
+			defer wg.Done()
+			d.run(ctx, q)
+		}(q)
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) run(ctx context.Context, q *providerQueue) {
+	limiter := time.NewTicker(q.limits.Interval)
+	defer limiter.Stop()
+
+	batch := make([]Delivery, 0, q.limits.BatchSize)
+	for {
+		// Wait for the first delivery of the next batch
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-q.jobs:
+			batch = append(batch[:0], delivery)
+		}
+
+		// Fill the batch with whatever else is already queued
+		batch = q.fill(batch)
+
+		// Respect the provider's rate limit
+		select {
+		case <-ctx.Done():
+			return
+		case <-limiter.C:
+		}
+
+		d.send(ctx, q, batch)
+	}
+}
+
+// send hands a batch to the provider and records what became of each delivery
+func (d *Dispatcher) send(ctx context.Context, q *providerQueue, batch []Delivery) {
+	batch = d.suppress(ctx, q.provider.Name(), batch)
+	if len(batch) == 0 {
+		return
+	}
+
+	err := q.provider.Send(ctx, batch)
+	if err != nil {
+		d.logger.Error("error sending notifications", "provider", q.provider.Name(), "deliveries", len(batch), "error", err)
+	}
+	d.record(ctx, q.provider.Name(), outcomes(batch, err))
+}
+
+// suppress removes deliveries to suppressed users from batch and records them.
+// A suppressor that cannot be read suppresses nobody.
+func (d *Dispatcher) suppress(ctx context.Context, provider string, batch []Delivery) []Delivery {
+	suppressors := d.suppressors[provider]
+	if len(suppressors) == 0 {
+		return batch
+	}
+
+	userIDs := make([]uuid.UUID, len(batch))
+	for i, delivery := range batch {
+		userIDs[i] = delivery.UserID
+	}
+	suppressed := map[uuid.UUID]bool{}
+	for _, suppressor := range suppressors {
+		users, err := suppressor.Suppressed(ctx, userIDs)
+		if err != nil {
+			d.logger.Error("error reading suppressed recipients", "provider", provider, "error", err)
+			continue
+		}
+		for userID, ok := range users {
+			if ok {
+				suppressed[userID] = true
+			}
+		}
+	}
+
+	kept := batch[:0]
+	var skipped []Outcome
+	for _, delivery := range batch {
+		if suppressed[delivery.UserID] {
+			skipped = append(skipped, Outcome{NotificationID: delivery.NotificationID, Status: DeliverySuppressed})
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	d.record(ctx, provider, skipped)
+	return kept
+}
+
+// outcomes reads a provider's Send result as one outcome per delivery: all
+// sent, all failed, or per delivery when the provider returned a BatchError
+func outcomes(batch []Delivery, err error) []Outcome {
+	var failures BatchError
+	partial := errors.As(err, &failures)
+
+	result := make([]Outcome, len(batch))
+	for i, delivery := range batch {
+		outcome := Outcome{NotificationID: delivery.NotificationID, Status: DeliverySent}
+		failure := err
+		if partial {
+			failure = failures[delivery.NotificationID]
+		}
+		if failure != nil {
+			outcome.Status = DeliveryFailed
+			if errors.Is(failure, ErrBounced) {
+				outcome.Status = DeliveryBounced
+			}
+			outcome.Error = failure.Error()
+		}
+		result[i] = outcome
+	}
+	return result
+}
+
+// record hands outcomes to the recorder, if there is one; delivery goes on
+// when recording fails
+func (d *Dispatcher) record(ctx context.Context, provider string, outcomes []Outcome) {
+	if d.recorder == nil || len(outcomes) == 0 {
+		return
+	}
+	if err := d.recorder.RecordOutcomes(ctx, provider, outcomes); err != nil {
+		d.logger.Error("error recording notification outcomes", "provider", provider, "outcomes", len(outcomes), "error", err)
+	}
+}
+
+// fill adds already queued deliveries to batch, up to the batch size
+func (q *providerQueue) fill(batch []Delivery) []Delivery {
+	for len(batch) < q.limits.BatchSize {
+		select {
+		case delivery := <-q.jobs:
+			batch = append(batch, delivery)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// Drain sends what is still queued, within each provider's rate limit, until
+// the queues are empty or ctx is done. Call it once Run has returned, at
+// shutdown. Deliveries it cannot send are put back and reported; the in-app
+// notifications they belong to are already stored.
+func (d *Dispatcher) Drain(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, q := range d.queues {
+		wg.Add(1)
+		go func(q *providerQueue) {
+			defer wg.Done()
+			d.drain(ctx, q)
+		}(q)
+	}
+	wg.Wait()
+
+	if pending := d.Pending(); pending > 0 {
+		return fmt.Errorf("%d notification deliveries not sent", pending)
+	}
+	return nil
+}
+
+func (d *Dispatcher) drain(ctx context.Context, q *providerQueue) {
+	limiter := time.NewTicker(q.limits.Interval)
+	defer limiter.Stop()
+
+	batch := make([]Delivery, 0, q.limits.BatchSize)
+	for {
+		if batch = q.fill(batch[:0]); len(batch) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			q.requeue(batch)
+			return
+		case <-limiter.C:
+		}
+
+		d.send(ctx, q, batch)
+	}
+}
+
+// requeue puts a batch taken by drain back; there is room, since nothing else
+// enqueues during shutdown
+func (q *providerQueue) requeue(batch []Delivery) {
+	for _, delivery := range batch {
+		select {
+		case q.jobs <- delivery:
+		default:
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type recordingProvider struct {
+	mu      sync.Mutex
+	batches [][]Delivery
+	sent    chan struct{}
+}
+
+func (p *recordingProvider) Name() string { return "recording" }
+
+func (p *recordingProvider) Send(ctx context.Context, batch []Delivery) error {
+	p.mu.Lock()
+	p.batches = append(p.batches, append([]Delivery(nil), batch...))
+	p.mu.Unlock()
+	p.sent <- struct{}{}
+	return nil
+}
+
+func TestDispatcherBatchesDeliveries(t *testing.T) {
+	provider := &recordingProvider{sent: make(chan struct{}, 10)}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	dispatcher.Register(provider, ProviderLimits{BatchSize: 3, Interval: time.Millisecond})
+
+	deliveries := make([]Delivery, 7)
+	for i := range deliveries {
+		deliveries[i] = Delivery{NotificationID: uuid.New(), UserID: uuid.New(), Type: TypeEventCreated}
+	}
+	// Queue before starting so batches are filled deterministically
+	dispatcher.Enqueue(deliveries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-provider.sent:
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for batch %d", i+1)
+		}
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	sizes := []int{}
+	for _, batch := range provider.batches {
+		sizes = append(sizes, len(batch))
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("Expected batches of 3, 3 and 1, got %v", sizes)
+	}
+}
+
+func TestDispatcherDropsWhenQueueFull(t *testing.T) {
+	provider := &recordingProvider{sent: make(chan struct{}, 10)}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	dispatcher.Register(provider, ProviderLimits{QueueSize: 2})
+
+	dispatcher.Enqueue(make([]Delivery, 5))
+
+	if queued := dispatcher.Pending(); queued != 2 {
+		t.Errorf("Expected 2 queued deliveries, got %d", queued)
+	}
+}
+
+func TestDispatcherDrainSendsQueuedDeliveries(t *testing.T) {
+	provider := &recordingProvider{sent: make(chan struct{}, 10)}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	dispatcher.Register(provider, ProviderLimits{BatchSize: 2, Interval: time.Millisecond})
+	dispatcher.Enqueue(make([]Delivery, 5))
+
+	if err := dispatcher.Drain(context.Background()); err != nil {
+		t.Fatalf("Expected every delivery to be sent, got %v", err)
+	}
+	if len(provider.batches) != 3 || dispatcher.Pending() != 0 {
+		t.Errorf("Expected 3 batches and an empty queue, got %d batches and %d pending", len(provider.batches), dispatcher.Pending())
+	}
+}
+
+func TestDispatcherDrainRequeuesWhenOutOfTime(t *testing.T) {
+	provider := &recordingProvider{sent: make(chan struct{}, 10)}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	dispatcher.Register(provider, ProviderLimits{BatchSize: 2, Interval: time.Hour})
+	dispatcher.Enqueue(make([]Delivery, 3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Drain(ctx); err == nil {
+		t.Fatal("Expected unsent deliveries to be reported")
+	}
+	if dispatcher.Pending() != 3 {
+		t.Errorf("Expected the unsent deliveries to be put back, got %d pending", dispatcher.Pending())
+	}
+}
+
+type failingProvider struct {
+	err error
+}
+
+func (p *failingProvider) Name() string { return "failing" }
+
+func (p *failingProvider) Send(ctx context.Context, batch []Delivery) error { return p.err }
+
+type outcomeLog struct {
+	mu       sync.Mutex
+	outcomes map[uuid.UUID]Outcome
+}
+
+func (l *outcomeLog) RecordOutcomes(ctx context.Context, provider string, outcomes []Outcome) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, outcome := range outcomes {
+		l.outcomes[outcome.NotificationID] = outcome
+	}
+	return nil
+}
+
+func TestDispatcherRecordsOutcomes(t *testing.T) {
+	deliveries := []Delivery{{NotificationID: uuid.New()}, {NotificationID: uuid.New()}, {NotificationID: uuid.New()}}
+	provider := &failingProvider{err: BatchError{
+		deliveries[1].NotificationID: errors.New("device token expired"),
+		deliveries[2].NotificationID: fmt.Errorf("%w: mailbox unavailable", ErrBounced),
+	}}
+	log := &outcomeLog{outcomes: map[uuid.UUID]Outcome{}}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).WithRecorder(log)
+	dispatcher.Register(provider, ProviderLimits{Interval: time.Millisecond})
+	dispatcher.Enqueue(deliveries)
+
+	if err := dispatcher.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	want := []string{DeliverySent, DeliveryFailed, DeliveryBounced}
+	for i, delivery := range deliveries {
+		if got := log.outcomes[delivery.NotificationID].Status; got != want[i] {
+			t.Errorf("Delivery %d: expected %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+func TestDispatcherRecordsBatchFailure(t *testing.T) {
+	deliveries := []Delivery{{NotificationID: uuid.New()}, {NotificationID: uuid.New()}}
+	log := &outcomeLog{outcomes: map[uuid.UUID]Outcome{}}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).WithRecorder(log)
+	dispatcher.Register(&failingProvider{err: errors.New("provider unavailable")}, ProviderLimits{Interval: time.Millisecond})
+	dispatcher.Enqueue(deliveries)
+
+	if err := dispatcher.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	for i, delivery := range deliveries {
+		outcome := log.outcomes[delivery.NotificationID]
+		if outcome.Status != DeliveryFailed || outcome.Error != "provider unavailable" {
+			t.Errorf("Delivery %d: expected a failure, got %+v", i, outcome)
+		}
+	}
+}
+
+func TestDispatcherRecordsDrops(t *testing.T) {
+	log := &outcomeLog{outcomes: map[uuid.UUID]Outcome{}}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).WithRecorder(log)
+	dispatcher.Register(&recordingProvider{sent: make(chan struct{}, 10)}, ProviderLimits{QueueSize: 1})
+
+	dropped := Delivery{NotificationID: uuid.New()}
+	dispatcher.Enqueue([]Delivery{{NotificationID: uuid.New()}, dropped})
+
+	if len(log.outcomes) != 1 || log.outcomes[dropped.NotificationID].Status != DeliveryDropped {
+		t.Errorf("Expected only the second delivery to be recorded as dropped, got %+v", log.outcomes)
+	}
+}
+
+type suppressedUsers map[uuid.UUID]bool
+
+func (s suppressedUsers) Suppressed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return s, nil
+}
+
+type emailProvider struct {
+	recordingProvider
+}
+
+func (p *emailProvider) Name() string { return ProviderEmail }
+
+func TestDispatcherSkipsSuppressedRecipients(t *testing.T) {
+	bounced := Delivery{NotificationID: uuid.New(), UserID: uuid.New()}
+	reachable := Delivery{NotificationID: uuid.New(), UserID: uuid.New()}
+	provider := &emailProvider{recordingProvider{sent: make(chan struct{}, 10)}}
+	log := &outcomeLog{outcomes: map[uuid.UUID]Outcome{}}
+	dispatcher := NewDispatcher(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))).
+		WithRecorder(log).
+		WithSuppressor(ProviderEmail, suppressedUsers{bounced.UserID: true})
+	dispatcher.Register(provider, ProviderLimits{Interval: time.Millisecond})
+	dispatcher.Enqueue([]Delivery{bounced, reachable})
+
+	if err := dispatcher.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	if len(provider.batches) != 1 || len(provider.batches[0]) != 1 || provider.batches[0][0].UserID != reachable.UserID {
+		t.Errorf("Expected only the reachable user to be sent to, got %+v", provider.batches)
+	}
+	if got := log.outcomes[bounced.NotificationID].Status; got != DeliverySuppressed {
+		t.Errorf("Expected the suppressed delivery to be recorded, got %q", got)
+	}
+	if got := log.outcomes[reachable.NotificationID].Status; got != DeliverySent {
+		t.Errorf("Expected the other delivery to be sent, got %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// FeedLimit bounds how many notifications one read of the feed returns
+const FeedLimit = 50
+
+// Feed reads users' notifications for clients catching up on them
+type Feed struct {
+	db *database.DB
+}
+
+func NewFeed(db *database.DB) *Feed {
+	return &Feed{db: db}
+}
+
+// Since returns the user's notifications created after since, oldest first
+func (f *Feed) Since(ctx context.Context, userID uuid.UUID, since time.Time) ([]models.Notification, error) {
+	rows, err := f.db.QueryContext(ctx, `
+		SELECT id, user_id, type, title, body, club_id, event_id, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND created_at > $2
+		ORDER BY created_at, id
+		LIMIT $3`,
+		userID, since, FeedLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.ClubID, &n.EventID, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	return notifications, nil
+}
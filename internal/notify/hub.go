@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrHubFull means the instance already holds as many waiting clients as it allows
+	ErrHubFull = errors.New("too many clients waiting for notifications")
+	// ErrUserWaiting means the user already has as many waiting clients as they may
+	ErrUserWaiting = errors.New("too many clients waiting for this user's notifications")
+)
+
+// Hub tells clients waiting on this instance that their user has new
+// notifications. It only carries a wake-up: clients read the notifications
+// themselves, and those written by other instances are found by rechecking.
+type Hub struct {
+	maxWaiters int
+	maxPerUser int
+
+	mu      sync.Mutex
+	waiters map[uuid.UUID]map[chan struct{}]struct{}
+	count   int
+	closed  bool
+
+	done chan struct{}
+}
+
+// NewHub holds at most maxWaiters clients, and maxPerUser per user
+func NewHub(maxWaiters, maxPerUser int) *Hub {
+	return &Hub{
+		maxWaiters: maxWaiters,
+		maxPerUser: maxPerUser,
+		waiters:    make(map[uuid.UUID]map[chan struct{}]struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives when userID is notified, and the
+// function that gives the place back. Every successful Subscribe must be
+// followed by a call to it.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan struct{}, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count >= h.maxWaiters {
+		return nil, nil, ErrHubFull
+	}
+	if len(h.waiters[userID]) >= h.maxPerUser {
+		return nil, nil, ErrUserWaiting
+	}
+
+	wake := make(chan struct{}, 1)
+	if h.waiters[userID] == nil {
+		h.waiters[userID] = make(map[chan struct{}]struct{})
+	}
+	h.waiters[userID][wake] = struct{}{}
+	h.count++
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.waiters[userID][wake]; !ok {
+			return
+		}
+		delete(h.waiters[userID], wake)
+		if len(h.waiters[userID]) == 0 {
+			delete(h.waiters, userID)
+		}
+		h.count--
+	}
+	return wake, unsubscribe, nil
+}
+
+// Publish wakes the clients waiting for any of userIDs
+func (h *Hub) Publish(userIDs []uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for wake := range h.waiters[userID] {
+			signal(wake)
+		}
+	}
+}
+
+// Waiting reports how many clients are waiting
+func (h *Hub) Waiting() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Done is closed when the hub closes
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Close tells waiting clients to stop waiting, so they answer and reconnect
+// to another instance when the server shuts down. It may be called again.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.closed {
+		h.closed = true
+		close(h.done)
+	}
+}
+
+// signal wakes a client unless a wake-up is already pending
+func signal(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
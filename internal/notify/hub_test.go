@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubCapsWaiters(t *testing.T) {
+	hub := NewHub(3, 2)
+	alice, bob := uuid.New(), uuid.New()
+
+	_, unsubscribe, err := hub.Subscribe(alice)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if _, _, err := hub.Subscribe(alice); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if _, _, err := hub.Subscribe(alice); !errors.Is(err, ErrUserWaiting) {
+		t.Fatalf("expected ErrUserWaiting, got %v", err)
+	}
+	if _, _, err := hub.Subscribe(bob); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if _, _, err := hub.Subscribe(uuid.New()); !errors.Is(err, ErrHubFull) {
+		t.Fatalf("expected ErrHubFull, got %v", err)
+	}
+
+	unsubscribe()
+	unsubscribe() // gives the place back only once
+	if hub.Waiting() != 2 {
+		t.Fatalf("expected 2 waiting, got %d", hub.Waiting())
+	}
+	if _, _, err := hub.Subscribe(alice); err != nil {
+		t.Fatalf("Subscribe after unsubscribe failed: %v", err)
+	}
+}
+
+func TestHubPublishWakesUsersWaiters(t *testing.T) {
+	hub := NewHub(10, 10)
+	alice, bob := uuid.New(), uuid.New()
+	first, _, _ := hub.Subscribe(alice)
+	second, _, _ := hub.Subscribe(alice)
+	other, _, _ := hub.Subscribe(bob)
+
+	// Publishing twice before anyone reads leaves one wake-up pending
+	hub.Publish([]uuid.UUID{alice})
+	hub.Publish([]uuid.UUID{alice})
+
+	for _, wake := range []<-chan struct{}{first, second} {
+		select {
+		case <-wake:
+		default:
+			t.Fatal("expected alice's waiters to be woken")
+		}
+		select {
+		case <-wake:
+			t.Fatal("expected a single pending wake-up")
+		default:
+		}
+	}
+	select {
+	case <-other:
+		t.Fatal("expected bob's waiter to keep waiting")
+	default:
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub(10, 10)
+	hub.Close()
+	hub.Close()
+
+	select {
+	case <-hub.Done():
+	default:
+		t.Fatal("expected Done to be closed")
+	}
+}
@@ -0,0 +1,152 @@
+// Package notify writes in-app notifications for club activity and fans them
+// out to external delivery providers (push, email).
+//
+// In-app rows for a whole club are written with one set-based INSERT rather
+// than one write per member. External delivery never happens on the request
+// path: deliveries are queued, grouped into batches per provider and sent no
+// faster than each provider's rate limit allows.
+package notify
+
+import (
+	"context"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	TypeEventCreated       = "event_created"
+	TypeClubAtCapacity     = "club_at_capacity"
+	TypeClubVerification   = "club_verification"
+	TypeClubContact        = "club_contact"
+	TypePollCreated        = "poll_created"
+	TypePollClosed         = "poll_closed"
+	TypeClubYearbook       = "club_yearbook"
+	TypeCorrectionDecided  = "correction_decided"
+	TypeClubPartnership    = "club_partnership"
+	TypeDataExport         = "data_export"
+	TypeEmailUndeliverable = "email_undeliverable"
+	TypeItemAssigned       = "item_assigned"
+	TypeItemReassigned     = "item_reassigned"
+	TypeItemClaimed        = "item_claimed"
+)
+
+// Notifier records notifications and hands them to the dispatcher for delivery
+type Notifier struct {
+	db         *database.DB
+	dispatcher *Dispatcher
+	hub        *Hub // nil when no client waits for notifications
+}
+
+func NewNotifier(db *database.DB, dispatcher *Dispatcher) *Notifier {
+	return &Notifier{db: db, dispatcher: dispatcher}
+}
+
+// WithHub wakes the clients waiting for their users' notifications
+func (n *Notifier) WithHub(hub *Hub) *Notifier {
+	n.hub = hub
+	return n
+}
+
+// NotifyClubMembers notifies every active member of clubID except exceptUserID
+// (usually the member who caused the notification) and returns how many were notified
+func (n *Notifier) NotifyClubMembers(ctx context.Context, clubID, exceptUserID uuid.UUID, notification models.Notification) (int, error) {
+	return n.notifyClubMembers(ctx, nil, clubID, exceptUserID, notification)
+}
+
+// notifyClubMembers is NotifyClubMembers for an outbox message, which skips
+// the members the message already notified
+func (n *Notifier) notifyClubMembers(ctx context.Context, outboxID *uuid.UUID, clubID, exceptUserID uuid.UUID, notification models.Notification) (int, error) {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, club_id, event_id, outbox_id)
+		SELECT cm.user_id, $3, $4, $5, $1, $6, $7
+		FROM club_members cm
+		WHERE cm.club_id = $1 AND cm.is_active = true AND cm.user_id <> $2
+		ON CONFLICT (outbox_id, user_id) WHERE outbox_id IS NOT NULL DO NOTHING
+		RETURNING id, user_id`
+
+	return n.insert(ctx, notification, query,
+		clubID, exceptUserID, notification.Type, notification.Title, notification.Body, notification.EventID, outboxID,
+	)
+}
+
+// NotifyClubOwner notifies the owner of clubID, if the club has one
+func (n *Notifier) NotifyClubOwner(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error) {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, club_id, event_id)
+		SELECT c.owner_id, $2, $3, $4, c.id, $5
+		FROM clubs c
+		WHERE c.id = $1 AND c.owner_id IS NOT NULL
+		RETURNING id, user_id`
+
+	return n.insert(ctx, notification, query,
+		clubID, notification.Type, notification.Title, notification.Body, notification.EventID,
+	)
+}
+
+// NotifyClubManagers notifies the active members of clubID who manage its
+// members: owners, moderators and custom roles granting manage_members
+func (n *Notifier) NotifyClubManagers(ctx context.Context, clubID uuid.UUID, notification models.Notification) (int, error) {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, club_id, event_id)
+		SELECT cm.user_id, $2, $3, $4, $1, $5
+		FROM club_members cm
+		WHERE cm.club_id = $1 AND cm.is_active = true AND (cm.role IN ('owner', 'moderator') OR EXISTS (
+			SELECT 1 FROM club_roles r
+			WHERE r.club_id = cm.club_id AND r.name = cm.role AND 'manage_members' = ANY(r.capabilities)))
+		RETURNING id, user_id`
+
+	return n.insert(ctx, notification, query,
+		clubID, notification.Type, notification.Title, notification.Body, notification.EventID,
+	)
+}
+
+// NotifyUser notifies one user, such as the member who asked for a report
+func (n *Notifier) NotifyUser(ctx context.Context, userID uuid.UUID, notification models.Notification) (int, error) {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, club_id, event_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id`
+
+	return n.insert(ctx, notification, query,
+		userID, notification.Type, notification.Title, notification.Body, notification.ClubID, notification.EventID,
+	)
+}
+
+// insert runs an INSERT ... RETURNING id, user_id into notifications and queues
+// the inserted rows for external delivery
+func (n *Notifier) insert(ctx context.Context, notification models.Notification, query string, args ...interface{}) (int, error) {
+	rows, err := n.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		delivery := Delivery{Type: notification.Type, Title: notification.Title, Body: notification.Body}
+		if err := rows.Scan(&delivery.NotificationID, &delivery.UserID); err != nil {
+			return 0, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if n.dispatcher != nil {
+		n.dispatcher.Enqueue(deliveries)
+	}
+	if n.hub != nil {
+		userIDs := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			userIDs[i] = delivery.UserID
+		}
+		n.hub.Publish(userIDs)
+	}
+
+	return len(deliveries), nil
+}
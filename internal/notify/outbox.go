@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+// TopicClubMembers is the outbox topic of notifications to a club's members
+const TopicClubMembers = "notify.club_members"
+
+// clubMembersMessage is the payload of a TopicClubMembers message
+type clubMembersMessage struct {
+	ClubID       uuid.UUID           `json:"clubId"`
+	ExceptUserID uuid.UUID           `json:"exceptUserId"`
+	Notification models.Notification `json:"notification"`
+}
+
+// QueueClubMembers is NotifyClubMembers for a change being written in tx: the
+// members are notified once tx commits, and not at all if it rolls back
+func (n *Notifier) QueueClubMembers(ctx context.Context, tx *sql.Tx, clubID, exceptUserID uuid.UUID, notification models.Notification) error {
+	_, err := outbox.Write(ctx, tx, TopicClubMembers, clubMembersMessage{
+		ClubID:       clubID,
+		ExceptUserID: exceptUserID,
+		Notification: notification,
+	})
+	return err
+}
+
+// HandleOutbox registers the notifier with poller for the topics it queues
+func (n *Notifier) HandleOutbox(poller *outbox.Poller) {
+	poller.Handle(TopicClubMembers, n.handleClubMembers)
+}
+
+func (n *Notifier) handleClubMembers(ctx context.Context, msg outbox.Message) error {
+	var payload clubMembersMessage
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid %s message: %w", TopicClubMembers, err)
+	}
+	_, err := n.notifyClubMembers(ctx, &msg.ID, payload.ClubID, payload.ExceptUserID, payload.Notification)
+	return err
+}
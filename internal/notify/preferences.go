@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// OptOuts suppresses deliveries through a provider to users who turned its
+// channel off in their notification preferences
+type OptOuts struct {
+	db     *database.DB
+	column string // users column saying whether the user wants the channel
+}
+
+// EmailOptOuts suppresses email to users who turned email off
+func EmailOptOuts(db *database.DB) *OptOuts {
+	return &OptOuts{db: db, column: "notify_email"}
+}
+
+// PushOptOuts suppresses push notifications to users who turned them off
+func PushOptOuts(db *database.DB) *OptOuts {
+	return &OptOuts{db: db, column: "notify_push"}
+}
+
+// Suppressed reports which of userIDs turned the channel off
+func (o *OptOuts) Suppressed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := o.db.QueryContext(ctx,
+		`SELECT id FROM users WHERE id = ANY($1::uuid[]) AND NOT `+o.column,
+		models.UUIDArray(userIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	optedOut := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		optedOut[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notification preferences: %w", err)
+	}
+	return optedOut, nil
+}
@@ -0,0 +1,277 @@
+// Package oauth signs members in with an external identity provider using the
+// OAuth 2.0 authorization code flow.
+//
+// The start endpoint sends the browser to the provider with a signed state; the
+// provider sends it back to the callback with a code, which is exchanged for an
+// access token and then for the member's identity. Identities are linked to
+// accounts by the provider's subject, or on first use by a verified email.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownProvider is returned for a provider that is not configured
+	ErrUnknownProvider = errors.New("unknown OAuth provider")
+	// ErrExchange is returned when the provider refuses the code or the token
+	ErrExchange = errors.New("OAuth provider rejected the request")
+	// ErrNoEmail is returned when the provider reports no email for the identity
+	ErrNoEmail = errors.New("OAuth identity has no email")
+)
+
+// Supported providers
+const (
+	Google = "google"
+	GitHub = "github"
+)
+
+// maxResponseBytes bounds the provider responses read
+const maxResponseBytes = 1 << 20
+
+// Identity is the member as the provider knows them
+type Identity struct {
+	Provider      string
+	Subject       string // the provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is one identity provider's endpoints and this deployment's client
+// credentials. The endpoints are fields so tests can point them at a fake.
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	APIURL       string // base of the provider's user API
+	Scopes       []string
+
+	// identify fetches the identity for an access token
+	identify func(ctx context.Context, client *http.Client, p *Provider, accessToken string) (Identity, error)
+}
+
+// NewGoogle configures Google sign-in through OpenID Connect's userinfo endpoint
+func NewGoogle(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         Google,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		APIURL:       "https://openidconnect.googleapis.com",
+		Scopes:       []string{"openid", "email", "profile"},
+		identify:     identifyGoogle,
+	}
+}
+
+// NewGitHub configures GitHub sign-in. GitHub lists every address on the
+// account with whether it is verified, so the primary verified one is used.
+func NewGitHub(clientID, clientSecret string) *Provider {
+	return &Provider{
+		Name:         GitHub,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		APIURL:       "https://api.github.com",
+		Scopes:       []string{"read:user", "user:email"},
+		identify:     identifyGitHub,
+	}
+}
+
+// AuthCodeURL is where the browser is sent to sign in with the provider
+func (p *Provider) AuthCodeURL(state, redirectURI string) string {
+	params := url.Values{
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURI},
+		"response_type": {"code"},
+		"scope":         {strings.Join(p.Scopes, " ")},
+		"state":         {state},
+	}
+	return p.AuthURL + "?" + params.Encode()
+}
+
+// Exchange trades the code from the callback for the member's identity
+func (p *Provider) Exchange(ctx context.Context, client *http.Client, code, redirectURI string) (Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := do(client, req, &token); err != nil {
+		return Identity{}, err
+	}
+	// GitHub reports a refused code with 200 and an error field
+	if token.AccessToken == "" {
+		return Identity{}, fmt.Errorf("%w: %s", ErrExchange, token.Error)
+	}
+
+	identity, err := p.identify(ctx, client, p, token.AccessToken)
+	if err != nil {
+		return Identity{}, err
+	}
+	identity.Provider = p.Name
+	identity.Email = strings.TrimSpace(strings.ToLower(identity.Email))
+	if identity.Email == "" {
+		return Identity{}, ErrNoEmail
+	}
+	return identity, nil
+}
+
+func identifyGoogle(ctx context.Context, client *http.Client, p *Provider, accessToken string) (Identity, error) {
+	var info struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := getJSON(ctx, client, p.APIURL+"/v1/userinfo", accessToken, &info); err != nil {
+		return Identity{}, err
+	}
+	if info.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: no subject in userinfo", ErrExchange)
+	}
+
+	return Identity{Subject: info.Subject, Email: info.Email, EmailVerified: info.EmailVerified, Name: info.Name}, nil
+}
+
+func identifyGitHub(ctx context.Context, client *http.Client, p *Provider, accessToken string) (Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, client, p.APIURL+"/user", accessToken, &user); err != nil {
+		return Identity{}, err
+	}
+	if user.ID == 0 {
+		return Identity{}, fmt.Errorf("%w: no user ID", ErrExchange)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, p.APIURL+"/user/emails", accessToken, &emails); err != nil {
+		return Identity{}, err
+	}
+
+	// Verified addresses first, the primary one ahead of the others
+	sort.SliceStable(emails, func(i, j int) bool {
+		if emails[i].Verified != emails[j].Verified {
+			return emails[i].Verified
+		}
+		return emails[i].Primary && !emails[j].Primary
+	})
+
+	identity := Identity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	if len(emails) > 0 {
+		identity.Email = emails[0].Email
+		identity.EmailVerified = emails[0].Verified
+	}
+	return identity, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return do(client, req, v)
+}
+
+func do(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach OAuth provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read OAuth provider response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrExchange, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: invalid response from %s", ErrExchange, req.URL.Path)
+	}
+	return nil
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]*Provider
+	client    *http.Client
+}
+
+// NewRegistry keeps the providers with a client ID; the others are disabled
+func NewRegistry(providers ...*Provider) *Registry {
+	reg := &Registry{
+		providers: make(map[string]*Provider),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, p := range providers {
+		if p.ClientID != "" {
+			reg.providers[p.Name] = p
+		}
+	}
+	return reg
+}
+
+// Provider returns a configured provider
+func (reg *Registry) Provider(name string) (*Provider, error) {
+	if p, ok := reg.providers[name]; ok {
+		return p, nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+// Names lists the configured providers
+func (reg *Registry) Names() []string {
+	names := make([]string, 0, len(reg.providers))
+	for name := range reg.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exchange trades a code for the identity with the registry's HTTP client
+func (reg *Registry) Exchange(ctx context.Context, p *Provider, code, redirectURI string) (Identity, error) {
+	return p.Exchange(ctx, reg.client, code, redirectURI)
+}
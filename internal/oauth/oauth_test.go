@@ -0,0 +1,127 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// fakeProvider serves a token endpoint accepting the code "good" and the user
+// API of both providers
+func fakeProvider(t *testing.T, githubEmails string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_secret") != "secret" || r.Form.Get("redirect_uri") != "https://api.example/cb" {
+			t.Errorf("Unexpected token request: %v", r.Form)
+		}
+		if r.Form.Get("code") != "good" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at"})
+	})
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer at" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("/v1/userinfo", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sub": "g-1", "email": "Reader@Example.com", "email_verified": true, "name": "Reader"}`))
+	}))
+	mux.HandleFunc("/user", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 42, "login": "reader", "name": ""}`))
+	}))
+	mux.HandleFunc("/user/emails", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(githubEmails))
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func pointAt(p *Provider, server *httptest.Server) *Provider {
+	p.TokenURL = server.URL + "/token"
+	p.APIURL = server.URL
+	return p
+}
+
+func TestGoogleExchange(t *testing.T) {
+	server := fakeProvider(t, "[]")
+	p := pointAt(NewGoogle("id", "secret"), server)
+
+	identity, err := p.Exchange(context.Background(), server.Client(), "good", "https://api.example/cb")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := Identity{Provider: Google, Subject: "g-1", Email: "reader@example.com", EmailVerified: true, Name: "Reader"}
+	if identity != want {
+		t.Errorf("Expected %+v, got %+v", want, identity)
+	}
+}
+
+func TestGitHubExchangePrefersPrimaryVerifiedEmail(t *testing.T) {
+	server := fakeProvider(t, `[
+		{"email": "old@example.com", "primary": false, "verified": true},
+		{"email": "unverified@example.com", "primary": true, "verified": false},
+		{"email": "reader@example.com", "primary": true, "verified": true}
+	]`)
+	p := pointAt(NewGitHub("id", "secret"), server)
+
+	identity, err := p.Exchange(context.Background(), server.Client(), "good", "https://api.example/cb")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := Identity{Provider: GitHub, Subject: "42", Email: "reader@example.com", EmailVerified: true, Name: "reader"}
+	if identity != want {
+		t.Errorf("Expected %+v, got %+v", want, identity)
+	}
+}
+
+func TestExchangeErrors(t *testing.T) {
+	server := fakeProvider(t, "[]")
+
+	p := pointAt(NewGitHub("id", "secret"), server)
+	if _, err := p.Exchange(context.Background(), server.Client(), "bad", "https://api.example/cb"); !errors.Is(err, ErrExchange) {
+		t.Errorf("Expected ErrExchange for a refused code, got %v", err)
+	}
+
+	// GitHub accounts can hide every address from the API
+	if _, err := p.Exchange(context.Background(), server.Client(), "good", "https://api.example/cb"); !errors.Is(err, ErrNoEmail) {
+		t.Errorf("Expected ErrNoEmail, got %v", err)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	p := NewGitHub("client", "secret")
+
+	u, err := url.Parse(p.AuthCodeURL("st", "https://api.example/cb"))
+	if err != nil {
+		t.Fatalf("Invalid URL: %v", err)
+	}
+	q := u.Query()
+	if u.Host != "github.com" || q.Get("client_id") != "client" || q.Get("state") != "st" ||
+		q.Get("redirect_uri") != "https://api.example/cb" || q.Get("scope") != "read:user user:email" {
+		t.Errorf("Unexpected auth URL: %s", u)
+	}
+}
+
+func TestRegistrySkipsUnconfiguredProviders(t *testing.T) {
+	reg := NewRegistry(NewGoogle("", ""), NewGitHub("id", "secret"))
+
+	if names := reg.Names(); len(names) != 1 || names[0] != GitHub {
+		t.Errorf("Expected only github, got %v", names)
+	}
+	if _, err := reg.Provider(Google); err != ErrUnknownProvider {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+}
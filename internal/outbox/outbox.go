@@ -0,0 +1,243 @@
+// Package outbox publishes what domain writes cause, such as notifications,
+// without the two getting out of step.
+//
+// A message is written to the outbox table in the same transaction as the
+// change it reports, so it exists if and only if the change committed. A
+// poller hands each message to the handler registered for its topic and marks
+// it published once the handler succeeds; failures are retried with backoff.
+// Delivery is at least once: a handler that succeeds just before the poller
+// stops sees the message again, so handlers must tolerate repeats, e.g. by
+// keying what they write on the message ID.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxBackoff bounds the wait before retrying a message that keeps failing
+	maxBackoff = time.Hour
+	// pruneInterval is how often published messages past the retention are deleted
+	pruneInterval = time.Hour
+)
+
+// Message is one entry in the outbox
+type Message struct {
+	ID        uuid.UUID
+	Topic     string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	Attempts  int // failed attempts before this one
+}
+
+// Handler publishes a message, e.g. by notifying users or calling a webhook
+type Handler func(ctx context.Context, msg Message) error
+
+// Write adds a message with payload encoded as JSON to the outbox in tx
+func Write(ctx context.Context, tx *sql.Tx, topic string, payload interface{}) (uuid.UUID, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to encode outbox message: %w", err)
+	}
+
+	id := uuid.New()
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox (id, topic, payload) VALUES ($1, $2, $3)`, id, topic, data)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to write outbox message: %w", err)
+	}
+	return id, nil
+}
+
+// Poller publishes outbox messages through the handlers of their topics.
+// Several instances may poll at once; each message is locked by the one
+// publishing it.
+type Poller struct {
+	db        *database.DB
+	interval  time.Duration
+	batchSize int
+	retention time.Duration
+	logger    *slog.Logger
+	routes    map[string]route
+}
+
+// route is how messages of one topic are published
+type route struct {
+	handler Handler
+	backoff time.Duration // wait before the first retry, doubled after each failure
+}
+
+// NewPoller publishes up to batchSize messages every interval and deletes
+// published messages after retention
+func NewPoller(db *database.DB, interval time.Duration, batchSize int, retention time.Duration, logger *slog.Logger) *Poller {
+	return &Poller{db: db, interval: interval, batchSize: batchSize, retention: retention, logger: logger, routes: map[string]route{}}
+}
+
+// Handle publishes messages of topic through handler, retrying failures
+// after the poll interval at first. Handlers must be registered before Run.
+func (p *Poller) Handle(topic string, handler Handler) *Poller {
+	return p.HandleWithBackoff(topic, handler, p.interval)
+}
+
+// HandleWithBackoff is Handle for handlers calling services that may be down
+// for a while, whose failures are first retried after base
+func (p *Poller) HandleWithBackoff(topic string, handler Handler, base time.Duration) *Poller {
+	p.routes[topic] = route{handler: handler, backoff: base}
+	return p
+}
+
+// Poll publishes the messages that are due, oldest first, and returns how
+// many were published. A message whose handler fails is tried again later.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	published := 0
+	err := p.db.WithTx(ctx, func(tx *sql.Tx) error {
+		messages, err := due(ctx, tx, p.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			if perr := p.publish(ctx, msg); perr != nil {
+				p.logger.Warn("error publishing outbox message", "id", msg.ID, "topic", msg.Topic, "attempts", msg.Attempts+1, "error", perr)
+				_, err := tx.ExecContext(ctx, `
+					UPDATE outbox SET attempts = attempts + 1, last_error = $2,
+						next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+					WHERE id = $1`, msg.ID, perr.Error(), backoff(p.retryBase(msg.Topic), msg.Attempts+1).Milliseconds())
+				if err != nil {
+					return fmt.Errorf("failed to reschedule outbox message: %w", err)
+				}
+				continue
+			}
+
+			_, err := tx.ExecContext(ctx, `UPDATE outbox SET published_at = NOW(), last_error = NULL WHERE id = $1`, msg.ID)
+			if err != nil {
+				return fmt.Errorf("failed to mark outbox message published: %w", err)
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+// due locks the messages ready to be published, skipping those another
+// poller holds
+func due(ctx context.Context, tx *sql.Tx, limit int) ([]Message, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, topic, payload, created_at, attempts
+		FROM outbox
+		WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY created_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.Topic, &msg.Payload, &msg.CreatedAt, &msg.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to read outbox message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	return messages, nil
+}
+
+// publish runs the handler of the message's topic, turning a panic into an
+// error so one bad message cannot stop the others
+func (p *Poller) publish(ctx context.Context, msg Message) (err error) {
+	route, ok := p.routes[msg.Topic]
+	if !ok {
+		return fmt.Errorf("no handler for topic %q", msg.Topic)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return route.handler(ctx, msg)
+}
+
+// retryBase is the first wait before retrying a message of topic
+func (p *Poller) retryBase(topic string) time.Duration {
+	if route, ok := p.routes[topic]; ok {
+		return route.backoff
+	}
+	return p.interval
+}
+
+// backoff is the wait before the next attempt at a message that failed
+// attempts times: base, doubled after each failure up to an hour
+func backoff(base time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// Prune deletes messages published more than the retention ago and returns
+// how many were deleted
+func (p *Poller) Prune(ctx context.Context) (int64, error) {
+	result, err := p.db.ExecContext(ctx,
+		`DELETE FROM outbox WHERE published_at < NOW() - $1 * INTERVAL '1 millisecond'`, p.retention.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Run publishes due messages every interval until ctx is cancelled. A full
+// batch is followed straight away by the next, so a backlog drains without
+// waiting out the interval.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			published, err := p.Poll(ctx)
+			if err != nil {
+				p.logger.Error("error polling outbox", "error", err)
+				break
+			}
+			if published < p.batchSize {
+				break
+			}
+		}
+
+		if time.Since(pruned) < pruneInterval {
+			continue
+		}
+		pruned = time.Now()
+		if count, err := p.Prune(ctx); err != nil {
+			p.logger.Error("error pruning outbox", "error", err)
+		} else if count > 0 {
+			p.logger.Info("pruned published outbox messages", "count", count)
+		}
+	}
+}
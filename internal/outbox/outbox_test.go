@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{13, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(time.Second, tt.attempts); got != tt.want {
+			t.Errorf("backoff after %d failures = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	poller := NewPoller(nil, time.Second, 10, time.Hour, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+
+	var handled []uuid.UUID
+	poller.Handle("ok", func(ctx context.Context, msg Message) error {
+		handled = append(handled, msg.ID)
+		return nil
+	})
+	poller.Handle("failing", func(ctx context.Context, msg Message) error {
+		return errors.New("webhook unreachable")
+	})
+	poller.Handle("panicking", func(ctx context.Context, msg Message) error {
+		panic("bad payload")
+	})
+
+	ctx := context.Background()
+	msg := Message{ID: uuid.New(), Topic: "ok"}
+	if err := poller.publish(ctx, msg); err != nil || len(handled) != 1 || handled[0] != msg.ID {
+		t.Errorf("Expected the topic's handler to publish the message, got %v, %v", handled, err)
+	}
+
+	if err := poller.publish(ctx, Message{Topic: "failing"}); err == nil || err.Error() != "webhook unreachable" {
+		t.Errorf("Expected the handler's error, got %v", err)
+	}
+	if err := poller.publish(ctx, Message{Topic: "panicking"}); err == nil || !strings.Contains(err.Error(), "bad payload") {
+		t.Errorf("Expected a panic to be reported as an error, got %v", err)
+	}
+	if err := poller.publish(ctx, Message{Topic: "unknown"}); err == nil {
+		t.Error("Expected a message without a handler to fail, so it is kept for a later release")
+	}
+}
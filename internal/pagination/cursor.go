@@ -0,0 +1,42 @@
+// Package pagination encodes the opaque cursors of keyset pagination.
+//
+// A cursor holds the sort key of the last row of a page, and the next page
+// starts after that row. Unlike page/limit, rows added or removed meanwhile do
+// not shift later pages, and deep pages cost no more than the first. Cursors
+// are base64url-encoded JSON so clients treat them as opaque strings.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ErrInvalidCursor is returned for cursors this package did not produce
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns the cursor for a row with the given sort key
+func EncodeCursor(key ...string) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the sort key in cursor, which must have n parts
+func DecodeCursor(cursor string, n int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var key []string
+	if err := json.Unmarshal(data, &key); err != nil || len(key) != n {
+		return nil, ErrInvalidCursor
+	}
+	return key, nil
+}
+
+// CursorRequested reports whether the request asked for cursor pagination with
+// ?cursor=; an empty cursor asks for the first page
+func CursorRequested(r *http.Request) bool {
+	return r.URL.Query().Has("cursor")
+}
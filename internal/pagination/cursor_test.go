@@ -0,0 +1,39 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := EncodeCursor("2024-05-01", "19:00:00", "c0ffee")
+
+	key, err := DecodeCursor(cursor, 3)
+	if err != nil || len(key) != 3 || key[0] != "2024-05-01" || key[2] != "c0ffee" {
+		t.Errorf("Expected the key back, got %v, %v", key, err)
+	}
+
+	if _, err := DecodeCursor(cursor, 2); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor for the wrong key length, got %v", err)
+	}
+	for _, bad := range []string{"not base64!", "e30", "bnVsbA"} {
+		if _, err := DecodeCursor(bad, 3); err != ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestCursorRequested(t *testing.T) {
+	tests := map[string]bool{
+		"/members":            false,
+		"/members?page=2":     false,
+		"/members?cursor":     true,
+		"/members?cursor=":    true,
+		"/members?cursor=abc": true,
+	}
+	for url, expected := range tests {
+		if got := CursorRequested(httptest.NewRequest("GET", url, nil)); got != expected {
+			t.Errorf("CursorRequested(%s) = %v, expected %v", url, got, expected)
+		}
+	}
+}
@@ -0,0 +1,496 @@
+// Package partnerships links two clubs for a joint read of one book.
+//
+// A club proposes a partnership to another, naming the book; the other
+// club's moderators accept or decline it. While it is active, each club can
+// share its events with the partner club's members, and members of both clubs
+// discuss the book in one thread. Moderators of either club can change the
+// book or end the partnership; the proposing club can withdraw a proposal
+// that has not been answered. Ended partnerships keep their shared events and
+// thread.
+package partnerships
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a partnership
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+	StatusEnded     = "ended"
+)
+
+// Actions that move a partnership between statuses
+const (
+	ActionAccept  = "accept"
+	ActionDecline = "decline"
+	ActionEnd     = "end"
+)
+
+var (
+	// ErrNotFound means neither side of any partnership with the ID is the club
+	ErrNotFound = errors.New("partnership not found")
+	// ErrClubNotFound means the club proposed to does not exist
+	ErrClubNotFound = errors.New("partner club not found")
+	// ErrOpen means the two clubs already have a pending or active partnership
+	ErrOpen = errors.New("the clubs already have an open partnership")
+	// ErrTransition means the club cannot take the action in the partnership's status
+	ErrTransition = errors.New("action not allowed in the partnership's status")
+	// ErrNotActive means the partnership is not active, so nothing can be shared or posted
+	ErrNotActive = errors.New("partnership is not active")
+	// ErrEventNotFound means the club has no live event with the ID
+	ErrEventNotFound = errors.New("event not found")
+	// ErrMessageNotFound means the thread has no message with the ID
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// Partnership links a proposing club with its partner club
+type Partnership struct {
+	ID              uuid.UUID  `json:"id"`
+	ClubID          uuid.UUID  `json:"clubId"` // the club that proposed
+	ClubName        string     `json:"clubName"`
+	PartnerClubID   uuid.UUID  `json:"partnerClubId"`
+	PartnerClubName string     `json:"partnerClubName"`
+	Book            string     `json:"book"`
+	Message         *string    `json:"message,omitempty"`
+	Status          string     `json:"status"`
+	ProposedBy      *uuid.UUID `json:"proposedBy,omitempty"`
+	RespondedBy     *uuid.UUID `json:"respondedBy,omitempty"`
+	RespondedAt     *time.Time `json:"respondedAt,omitempty"`
+	EndedBy         *uuid.UUID `json:"endedBy,omitempty"`
+	EndedAt         *time.Time `json:"endedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// Other returns the club on the other side of the partnership from clubID
+func (p Partnership) Other(clubID uuid.UUID) uuid.UUID {
+	if clubID == p.ClubID {
+		return p.PartnerClubID
+	}
+	return p.ClubID
+}
+
+// Next returns the status a partnership moves to when clubID takes action.
+// Only the partner club answers a proposal; the proposing club withdraws it
+// by ending it, which cancels it. Either club ends an active partnership.
+func Next(p Partnership, clubID uuid.UUID, action string) (string, error) {
+	switch {
+	case p.Status == StatusPending && action == ActionAccept && clubID == p.PartnerClubID:
+		return StatusActive, nil
+	case p.Status == StatusPending && action == ActionDecline && clubID == p.PartnerClubID:
+		return StatusDeclined, nil
+	case p.Status == StatusPending && action == ActionEnd && clubID == p.ClubID:
+		return StatusCancelled, nil
+	case p.Status == StatusActive && action == ActionEnd:
+		return StatusEnded, nil
+	}
+	return "", ErrTransition
+}
+
+// SharedEvent is an event one of the clubs shared with the partnership
+type SharedEvent struct {
+	EventID  uuid.UUID `json:"eventId"`
+	ClubID   uuid.UUID `json:"clubId"`
+	ClubName string    `json:"clubName"`
+	Title    string    `json:"title"`
+	Date     string    `json:"date"`
+	Time     string    `json:"time"`
+	Timezone string    `json:"timezone"`
+	Location string    `json:"location"`
+	Book     *string   `json:"book,omitempty"`
+	SharedAt time.Time `json:"sharedAt"`
+}
+
+// Message is a post in a partnership's discussion thread
+type Message struct {
+	ID        uuid.UUID  `json:"id"`
+	ClubID    uuid.UUID  `json:"clubId"` // the author's club
+	ClubName  string     `json:"clubName"`
+	UserID    *uuid.UUID `json:"userId,omitempty"` // nil once the author's account is deleted
+	UserName  *string    `json:"userName,omitempty"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Store reads and changes partnerships
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+const selectPartnerships = `
+	SELECT p.id, p.club_id, c.name, p.partner_club_id, pc.name, p.book, p.message, p.status,
+	       p.proposed_by, p.responded_by, p.responded_at, p.ended_by, p.ended_at, p.created_at
+	FROM club_partnerships p
+	JOIN clubs c ON c.id = p.club_id
+	JOIN clubs pc ON pc.id = p.partner_club_id`
+
+// Propose creates a pending partnership from p.ClubID to p.PartnerClubID
+func (s *Store) Propose(ctx context.Context, p Partnership) (Partnership, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM clubs WHERE id = $1 AND deleted_at IS NULL)`, p.PartnerClubID).Scan(&exists)
+	if err != nil {
+		return Partnership{}, fmt.Errorf("failed to check partner club: %w", err)
+	}
+	if !exists {
+		return Partnership{}, ErrClubNotFound
+	}
+
+	var id uuid.UUID
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO club_partnerships (club_id, partner_club_id, book, message, proposed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING id`,
+		p.ClubID, p.PartnerClubID, p.Book, p.Message, p.ProposedBy,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return Partnership{}, ErrOpen
+	}
+	if err != nil {
+		return Partnership{}, fmt.Errorf("failed to create partnership: %w", err)
+	}
+	return s.Get(ctx, p.ClubID, id)
+}
+
+// List returns the partnerships a club is on either side of, newest first
+func (s *Store) List(ctx context.Context, clubID uuid.UUID, status string) ([]Partnership, error) {
+	rows, err := s.db.QueryContext(ctx, selectPartnerships+`
+		WHERE (p.club_id = $1 OR p.partner_club_id = $1) AND ($2 = '' OR p.status = $2)
+		ORDER BY p.created_at DESC, p.id`, clubID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query partnerships: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Partnership{}
+	for rows.Next() {
+		p, err := scanPartnership(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partnerships: %w", err)
+	}
+	return list, nil
+}
+
+// Get returns a partnership the club is on either side of
+func (s *Store) Get(ctx context.Context, clubID, id uuid.UUID) (Partnership, error) {
+	p, err := scanPartnership(s.db.QueryRowContext(ctx, selectPartnerships+`
+		WHERE p.id = $2 AND (p.club_id = $1 OR p.partner_club_id = $1)`, clubID, id))
+	if err == sql.ErrNoRows {
+		return Partnership{}, ErrNotFound
+	}
+	return p, err
+}
+
+// Change takes action on behalf of clubID and returns the partnership with its
+// new status and the status it had before
+func (s *Store) Change(ctx context.Context, clubID, id, userID uuid.UUID, action string) (Partnership, string, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	p := Partnership{ID: id}
+	err = tx.QueryRowContext(ctx, `
+		SELECT club_id, partner_club_id, status FROM club_partnerships
+		WHERE id = $1 AND (club_id = $2 OR partner_club_id = $2)
+		FOR UPDATE`, id, clubID,
+	).Scan(&p.ClubID, &p.PartnerClubID, &p.Status)
+	if err == sql.ErrNoRows {
+		return Partnership{}, "", ErrNotFound
+	}
+	if err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to get partnership: %w", err)
+	}
+
+	status, err := Next(p, clubID, action)
+	if err != nil {
+		return Partnership{}, "", err
+	}
+
+	query := `UPDATE club_partnerships SET status = $2, ended_by = $3, ended_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if action != ActionEnd {
+		query = `UPDATE club_partnerships SET status = $2, responded_by = $3, responded_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	}
+	if _, err := tx.ExecContext(ctx, query, id, status, userID); err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to update partnership: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to commit partnership: %w", err)
+	}
+
+	changed, err := s.Get(ctx, clubID, id)
+	return changed, p.Status, err
+}
+
+// SetBook changes the book of a pending or active partnership. With
+// setCurrentBook, an active partnership's book also becomes the current book
+// of both clubs.
+func (s *Store) SetBook(ctx context.Context, clubID, id uuid.UUID, book string, setCurrentBook bool) (Partnership, string, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	p := Partnership{ID: id}
+	err = tx.QueryRowContext(ctx, `
+		SELECT club_id, partner_club_id, status, book FROM club_partnerships
+		WHERE id = $1 AND (club_id = $2 OR partner_club_id = $2)
+		FOR UPDATE`, id, clubID,
+	).Scan(&p.ClubID, &p.PartnerClubID, &p.Status, &p.Book)
+	if err == sql.ErrNoRows {
+		return Partnership{}, "", ErrNotFound
+	}
+	if err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to get partnership: %w", err)
+	}
+	if p.Status != StatusPending && p.Status != StatusActive {
+		return Partnership{}, "", ErrTransition
+	}
+	if setCurrentBook && p.Status != StatusActive {
+		return Partnership{}, "", ErrNotActive
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE club_partnerships SET book = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, book)
+	if err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to update partnership book: %w", err)
+	}
+	if setCurrentBook {
+		_, err = tx.ExecContext(ctx, `UPDATE clubs SET current_book = $1, updated_at = NOW() WHERE id IN ($2, $3)`,
+			book, p.ClubID, p.PartnerClubID)
+		if err != nil {
+			return Partnership{}, "", fmt.Errorf("failed to set current book: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Partnership{}, "", fmt.Errorf("failed to commit partnership: %w", err)
+	}
+
+	changed, err := s.Get(ctx, clubID, id)
+	return changed, p.Book, err
+}
+
+// status returns the status of a partnership the club is on either side of
+func (s *Store) status(ctx context.Context, clubID, id uuid.UUID) (string, error) {
+	var status string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT status FROM club_partnerships WHERE id = $1 AND (club_id = $2 OR partner_club_id = $2)`, id, clubID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get partnership: %w", err)
+	}
+	return status, nil
+}
+
+// ShareEvent shares one of the club's events with an active partnership.
+// Sharing an event twice is not an error.
+func (s *Store) ShareEvent(ctx context.Context, clubID, id, eventID, userID uuid.UUID) error {
+	status, err := s.status(ctx, clubID, id)
+	if err != nil {
+		return err
+	}
+	if status != StatusActive {
+		return ErrNotActive
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO partnership_events (partnership_id, event_id, shared_by)
+		SELECT $1, e.id, $3 FROM events e
+		WHERE e.id = $2 AND e.club_id = $4 AND e.deleted_at IS NULL
+		ON CONFLICT DO NOTHING`, id, eventID, userID, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to share event: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Either the event is not the club's or it is already shared
+		var shared bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM partnership_events pe JOIN events e ON e.id = pe.event_id
+			              WHERE pe.partnership_id = $1 AND pe.event_id = $2 AND e.club_id = $3)`, id, eventID, clubID,
+		).Scan(&shared)
+		if err != nil {
+			return fmt.Errorf("failed to check shared event: %w", err)
+		}
+		if !shared {
+			return ErrEventNotFound
+		}
+	}
+	return nil
+}
+
+// UnshareEvent stops sharing one of the club's events with a partnership
+func (s *Store) UnshareEvent(ctx context.Context, clubID, id, eventID uuid.UUID) error {
+	if _, err := s.status(ctx, clubID, id); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM partnership_events pe USING events e
+		WHERE pe.partnership_id = $1 AND pe.event_id = $2 AND e.id = pe.event_id AND e.club_id = $3`, id, eventID, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to unshare event: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+// Events returns the events both clubs shared with a partnership, in date
+// order. Events are gone from the list once deleted or archived.
+func (s *Store) Events(ctx context.Context, clubID, id uuid.UUID) ([]SharedEvent, error) {
+	if _, err := s.status(ctx, clubID, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.club_id, c.name, e.title, e.event_date, e.event_time, COALESCE(e.timezone, ''), e.location, e.book, pe.shared_at
+		FROM partnership_events pe
+		JOIN events e ON e.id = pe.event_id
+		JOIN clubs c ON c.id = e.club_id
+		WHERE pe.partnership_id = $1 AND e.deleted_at IS NULL
+		ORDER BY e.event_date, e.event_time, e.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shared events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []SharedEvent{}
+	for rows.Next() {
+		var e SharedEvent
+		var date, clock time.Time
+		if err := rows.Scan(&e.EventID, &e.ClubID, &e.ClubName, &e.Title, &date, &clock, &e.Timezone, &e.Location, &e.Book, &e.SharedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shared event: %w", err)
+		}
+		e.Date, e.Time = date.Format("2006-01-02"), clock.Format("15:04")
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shared events: %w", err)
+	}
+	return events, nil
+}
+
+// Messages returns up to limit messages of a partnership's thread posted
+// before the given time (all, when zero), oldest first
+func (s *Store) Messages(ctx context.Context, clubID, id uuid.UUID, before time.Time, limit int) ([]Message, error) {
+	if _, err := s.status(ctx, clubID, id); err != nil {
+		return nil, err
+	}
+	var beforeArg interface{}
+	if !before.IsZero() {
+		beforeArg = before
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT m.id, m.club_id, c.name, m.user_id, u.name, m.body, m.created_at
+			FROM partnership_messages m
+			JOIN clubs c ON c.id = m.club_id
+			LEFT JOIN users u ON u.id = m.user_id
+			WHERE m.partnership_id = $1 AND ($2::timestamp IS NULL OR m.created_at < $2)
+			ORDER BY m.created_at DESC, m.id DESC
+			LIMIT $3
+		) latest ORDER BY created_at, id`, id, beforeArg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.ClubID, &m.ClubName, &m.UserID, &m.UserName, &m.Body, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	return messages, nil
+}
+
+// PostMessage adds a message by a member of clubID to an active partnership's thread
+func (s *Store) PostMessage(ctx context.Context, clubID, id, userID uuid.UUID, body string) (Message, error) {
+	status, err := s.status(ctx, clubID, id)
+	if err != nil {
+		return Message{}, err
+	}
+	if status != StatusActive {
+		return Message{}, ErrNotActive
+	}
+
+	m := Message{ClubID: clubID, UserID: &userID, Body: body}
+	err = s.db.QueryRowContext(ctx, `
+		WITH inserted AS (
+			INSERT INTO partnership_messages (partnership_id, club_id, user_id, body)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		)
+		SELECT i.id, i.created_at, c.name, u.name
+		FROM inserted i, clubs c, users u
+		WHERE c.id = $2 AND u.id = $3`, id, clubID, userID, body,
+	).Scan(&m.ID, &m.CreatedAt, &m.ClubName, &m.UserName)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to post message: %w", err)
+	}
+	return m, nil
+}
+
+// DeleteMessage removes a message from a partnership's thread: the author's
+// own, or any message when moderate is set
+func (s *Store) DeleteMessage(ctx context.Context, clubID, id, messageID, userID uuid.UUID, moderate bool) error {
+	if _, err := s.status(ctx, clubID, id); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM partnership_messages
+		WHERE id = $1 AND partnership_id = $2 AND ($3::boolean OR user_id = $4)`, messageID, id, moderate, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPartnership(row scanner) (Partnership, error) {
+	var p Partnership
+	err := row.Scan(
+		&p.ID, &p.ClubID, &p.ClubName, &p.PartnerClubID, &p.PartnerClubName, &p.Book, &p.Message, &p.Status,
+		&p.ProposedBy, &p.RespondedBy, &p.RespondedAt, &p.EndedBy, &p.EndedAt, &p.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return Partnership{}, err
+	}
+	if err != nil {
+		return Partnership{}, fmt.Errorf("failed to scan partnership: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,56 @@
+package partnerships
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNext(t *testing.T) {
+	proposer, partner := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		status string
+		club   uuid.UUID
+		action string
+		want   string
+	}{
+		{"partner accepts", StatusPending, partner, ActionAccept, StatusActive},
+		{"partner declines", StatusPending, partner, ActionDecline, StatusDeclined},
+		{"proposer withdraws", StatusPending, proposer, ActionEnd, StatusCancelled},
+		{"proposer ends", StatusActive, proposer, ActionEnd, StatusEnded},
+		{"partner ends", StatusActive, partner, ActionEnd, StatusEnded},
+		{"proposer cannot accept", StatusPending, proposer, ActionAccept, ""},
+		{"proposer cannot decline", StatusPending, proposer, ActionDecline, ""},
+		{"partner cannot withdraw", StatusPending, partner, ActionEnd, ""},
+		{"active cannot be accepted again", StatusActive, partner, ActionAccept, ""},
+		{"ended cannot be ended again", StatusEnded, proposer, ActionEnd, ""},
+		{"declined cannot be accepted", StatusDeclined, partner, ActionAccept, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Partnership{ClubID: proposer, PartnerClubID: partner, Status: tt.status}
+			got, err := Next(p, tt.club, tt.action)
+			if tt.want == "" {
+				if err != ErrTransition {
+					t.Errorf("Expected ErrTransition, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestOther(t *testing.T) {
+	proposer, partner := uuid.New(), uuid.New()
+	p := Partnership{ClubID: proposer, PartnerClubID: partner}
+
+	if p.Other(proposer) != partner || p.Other(partner) != proposer {
+		t.Errorf("Expected each club's other side to be the other club")
+	}
+}
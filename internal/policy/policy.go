@@ -0,0 +1,67 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrGuardianEmailRequired is returned when a youth club member has no guardian email on file
+var ErrGuardianEmailRequired = errors.New("guardian email is required for youth club members")
+
+// ClubPolicy is the bundle of privacy and safety rules applied to a club
+type ClubPolicy struct {
+	YouthMode             bool `json:"youthMode"`
+	PublicMemberDirectory bool `json:"publicMemberDirectory"`
+	RequireGuardianEmail  bool `json:"requireGuardianEmail"`
+	AllowDirectMessages   bool `json:"allowDirectMessages"`
+	ContentFilter         bool `json:"contentFilter"`
+}
+
+// Default returns the policy bundle for regular clubs
+func Default() ClubPolicy {
+	return ClubPolicy{
+		YouthMode:             false,
+		PublicMemberDirectory: true,
+		RequireGuardianEmail:  false,
+		AllowDirectMessages:   true,
+		ContentFilter:         false,
+	}
+}
+
+// Youth returns the stricter policy bundle for youth clubs
+func Youth() ClubPolicy {
+	return ClubPolicy{
+		YouthMode:             true,
+		PublicMemberDirectory: false,
+		RequireGuardianEmail:  true,
+		AllowDirectMessages:   false,
+		ContentFilter:         true,
+	}
+}
+
+// ForClub selects the policy bundle based on the club's youth mode setting
+func ForClub(youthMode bool) ClubPolicy {
+	if youthMode {
+		return Youth()
+	}
+	return Default()
+}
+
+// CanViewMemberDirectory reports whether a member may list the club's members.
+// Owners and moderators can always see the directory.
+func (p ClubPolicy) CanViewMemberDirectory(isManager bool) bool {
+	return p.PublicMemberDirectory || isManager
+}
+
+// CanDirectMessage reports whether members may message each other directly
+func (p ClubPolicy) CanDirectMessage() bool {
+	return p.AllowDirectMessages
+}
+
+// CheckNewMember validates that a user satisfies the policy before joining
+func (p ClubPolicy) CheckNewMember(guardianEmail *string) error {
+	if p.RequireGuardianEmail && (guardianEmail == nil || strings.TrimSpace(*guardianEmail) == "") {
+		return ErrGuardianEmailRequired
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package policy
+
+import "testing"
+
+func TestForClub(t *testing.T) {
+	if ForClub(false) != Default() {
+		t.Error("Expected default policy for regular clubs")
+	}
+
+	youth := ForClub(true)
+	if youth != Youth() {
+		t.Error("Expected youth policy for youth clubs")
+	}
+
+	if youth.PublicMemberDirectory {
+		t.Error("Youth clubs should not have a public member directory")
+	}
+	if youth.AllowDirectMessages {
+		t.Error("Youth clubs should not allow direct messages")
+	}
+	if !youth.ContentFilter {
+		t.Error("Youth clubs should always have the content filter enabled")
+	}
+}
+
+func TestCanViewMemberDirectory(t *testing.T) {
+	if !Default().CanViewMemberDirectory(false) {
+		t.Error("Regular members should see the directory of a regular club")
+	}
+	if Youth().CanViewMemberDirectory(false) {
+		t.Error("Regular members should not see the directory of a youth club")
+	}
+	if !Youth().CanViewMemberDirectory(true) {
+		t.Error("Managers should always see the member directory")
+	}
+}
+
+func TestCheckNewMember(t *testing.T) {
+	guardian := "parent@example.com"
+	blank := "  "
+
+	if err := Default().CheckNewMember(nil); err != nil {
+		t.Errorf("Regular clubs should not require a guardian email, got %v", err)
+	}
+	if err := Youth().CheckNewMember(nil); err != ErrGuardianEmailRequired {
+		t.Errorf("Expected ErrGuardianEmailRequired, got %v", err)
+	}
+	if err := Youth().CheckNewMember(&blank); err != ErrGuardianEmailRequired {
+		t.Errorf("Expected ErrGuardianEmailRequired for blank email, got %v", err)
+	}
+	if err := Youth().CheckNewMember(&guardian); err != nil {
+		t.Errorf("Expected guardian email to satisfy policy, got %v", err)
+	}
+}
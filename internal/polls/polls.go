@@ -0,0 +1,313 @@
+// Package polls decides book polls, in which club members vote for the next
+// book among candidates chosen by the club's moderators.
+//
+// Single-choice polls are won by the candidate with the most votes. Ranked
+// polls use instant-runoff voting: each ballot counts for its highest-ranked
+// candidate still standing, and the candidate with the fewest votes is
+// eliminated until one has a majority of the ballots still counting. Ties are
+// settled by the order the candidates are listed in, so the same votes always
+// give the same result.
+//
+// Polls close at their deadline, by the scheduled Closer, or earlier when a
+// moderator closes them.
+package polls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of poll
+const (
+	KindSingle = "single"
+	KindRanked = "ranked"
+)
+
+// ErrNotOpen is returned when closing a poll that does not exist or is already closed
+var ErrNotOpen = errors.New("poll not found or already closed")
+
+// Round is one count of the ballots. Single-choice polls have one round.
+type Round struct {
+	Counts     map[uuid.UUID]int `json:"counts"`
+	Exhausted  int               `json:"exhausted"`            // ballots with no candidate left standing
+	Eliminated *uuid.UUID        `json:"eliminated,omitempty"` // the candidate dropped after this round
+}
+
+// Tally is the outcome of a poll
+type Tally struct {
+	Winner  *uuid.UUID `json:"winner"` // nil when nobody voted
+	Ballots int        `json:"ballots"`
+	Rounds  []Round    `json:"rounds"`
+}
+
+// Count tallies ballots, each listing candidates in order of preference, for
+// candidates listed in the order ties are settled by. Single-choice polls only
+// count each ballot's first choice. Candidates not in the poll are ignored.
+func Count(kind string, candidates []uuid.UUID, ballots [][]uuid.UUID) Tally {
+	tally := Tally{Ballots: len(ballots)}
+
+	standing := make(map[uuid.UUID]bool, len(candidates))
+	for _, c := range candidates {
+		standing[c] = true
+	}
+
+	for {
+		round := Round{Counts: make(map[uuid.UUID]int, len(standing))}
+		for c := range standing {
+			round.Counts[c] = 0
+		}
+
+		for _, ballot := range ballots {
+			if kind != KindRanked && len(ballot) > 1 {
+				ballot = ballot[:1]
+			}
+			counted := false
+			for _, choice := range ballot {
+				if standing[choice] {
+					round.Counts[choice]++
+					counted = true
+					break
+				}
+			}
+			if !counted {
+				round.Exhausted++
+			}
+		}
+
+		// The leader and the last placed, ties going to the candidate listed first
+		var leader, last *uuid.UUID
+		for i := range candidates {
+			c := &candidates[i]
+			if !standing[*c] {
+				continue
+			}
+			if leader == nil || round.Counts[*c] > round.Counts[*leader] {
+				leader = c
+			}
+			if last == nil || round.Counts[*c] <= round.Counts[*last] {
+				last = c
+			}
+		}
+
+		counting := tally.Ballots - round.Exhausted
+		if leader == nil || counting == 0 {
+			tally.Rounds = append(tally.Rounds, round)
+			return tally
+		}
+
+		if kind != KindRanked || len(standing) == 1 || 2*round.Counts[*leader] > counting {
+			winner := *leader
+			tally.Winner = &winner
+			tally.Rounds = append(tally.Rounds, round)
+			return tally
+		}
+
+		eliminated := *last
+		round.Eliminated = &eliminated
+		delete(standing, eliminated)
+		tally.Rounds = append(tally.Rounds, round)
+	}
+}
+
+// Closed is a poll that has just been closed
+type Closed struct {
+	PollID       uuid.UUID
+	ClubID       uuid.UUID
+	Tally        Tally
+	WinnerTitle  *string
+	SetAsCurrent bool // the winner became the club's current book
+}
+
+// Close tallies an open poll, records the winner and, if the poll asks for it,
+// makes the winner the club's current book
+func Close(ctx context.Context, db *database.DB, pollID uuid.UUID, now time.Time) (*Closed, error) {
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	closed := Closed{PollID: pollID}
+	var kind string
+	var setCurrentBook bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT club_id, kind, set_current_book FROM polls
+		WHERE id = $1 AND closed_at IS NULL
+		FOR UPDATE`, pollID,
+	).Scan(&closed.ClubID, &kind, &setCurrentBook)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotOpen
+		}
+		return nil, fmt.Errorf("failed to lock poll: %w", err)
+	}
+
+	candidates, titles, err := options(ctx, tx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	ballots, err := Ballots(ctx, tx, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	closed.Tally = Count(kind, candidates, ballots)
+	_, err = tx.ExecContext(ctx, `UPDATE polls SET closed_at = $1, winner_option_id = $2 WHERE id = $3`,
+		now, closed.Tally.Winner, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close poll: %w", err)
+	}
+
+	if closed.Tally.Winner != nil {
+		title := titles[*closed.Tally.Winner]
+		closed.WinnerTitle = &title
+		if setCurrentBook {
+			_, err := tx.ExecContext(ctx, `UPDATE clubs SET current_book = $1, updated_at = NOW() WHERE id = $2`,
+				title, closed.ClubID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set current book: %w", err)
+			}
+			closed.SetAsCurrent = true
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit poll close: %w", err)
+	}
+	return &closed, nil
+}
+
+// querier is satisfied by both *database.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// options returns the poll's candidates in listed order, and their titles
+func options(ctx context.Context, db querier, pollID uuid.UUID) ([]uuid.UUID, map[uuid.UUID]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, title FROM poll_options WHERE poll_id = $1 ORDER BY position`, pollID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query poll options: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []uuid.UUID
+	titles := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var title string
+		if err := rows.Scan(&id, &title); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan poll option: %w", err)
+		}
+		candidates = append(candidates, id)
+		titles[id] = title
+	}
+	return candidates, titles, rows.Err()
+}
+
+// Ballots returns every member's choices, in order of preference
+func Ballots(ctx context.Context, db querier, pollID uuid.UUID) ([][]uuid.UUID, error) {
+	rows, err := db.QueryContext(ctx, `SELECT user_id, option_id FROM poll_votes WHERE poll_id = $1 ORDER BY user_id, rank`, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query poll votes: %w", err)
+	}
+	defer rows.Close()
+
+	var ballots [][]uuid.UUID
+	var voter uuid.UUID
+	for rows.Next() {
+		var userID, optionID uuid.UUID
+		if err := rows.Scan(&userID, &optionID); err != nil {
+			return nil, fmt.Errorf("failed to scan poll vote: %w", err)
+		}
+		if len(ballots) == 0 || userID != voter {
+			ballots = append(ballots, nil)
+			voter = userID
+		}
+		ballots[len(ballots)-1] = append(ballots[len(ballots)-1], optionID)
+	}
+	return ballots, rows.Err()
+}
+
+// Closer periodically closes polls whose deadline has passed
+type Closer struct {
+	db       *database.DB
+	interval time.Duration
+	logger   *slog.Logger
+	onClose  func(ctx context.Context, closed *Closed)
+}
+
+func NewCloser(db *database.DB, interval time.Duration, logger *slog.Logger) *Closer {
+	return &Closer{db: db, interval: interval, logger: logger}
+}
+
+// OnClose is called for every poll the closer closes, e.g. to notify the club
+func (c *Closer) OnClose(fn func(ctx context.Context, closed *Closed)) *Closer {
+	c.onClose = fn
+	return c
+}
+
+// CloseDue closes the polls past their deadline and returns how many were closed
+func (c *Closer) CloseDue(ctx context.Context, now time.Time) (int, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT id FROM polls WHERE closed_at IS NULL AND closes_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query due polls: %w", err)
+	}
+	var due []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan due poll: %w", err)
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	closedCount := 0
+	for _, id := range due {
+		closed, err := Close(ctx, c.db, id, now)
+		if err == ErrNotOpen {
+			continue // closed by a moderator in the meantime
+		}
+		if err != nil {
+			return closedCount, err
+		}
+		closedCount++
+		if c.onClose != nil {
+			c.onClose(ctx, closed)
+		}
+	}
+	return closedCount, nil
+}
+
+// Run closes due polls immediately and then every interval until ctx is cancelled
+func (c *Closer) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		closed, err := c.CloseDue(ctx, time.Now())
+		if err != nil {
+			c.logger.Error("error closing polls", "error", err)
+		} else if closed > 0 {
+			c.logger.Info("closed polls", "polls", closed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package polls
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCountSingle(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	candidates := []uuid.UUID{a, b, c}
+
+	// Later choices on a single-choice ballot are ignored
+	tally := Count(KindSingle, candidates, [][]uuid.UUID{{b}, {a}, {b, a}, {c}})
+	if tally.Winner == nil || *tally.Winner != b {
+		t.Errorf("Expected b to win, got %v", tally.Winner)
+	}
+	if len(tally.Rounds) != 1 || tally.Rounds[0].Counts[b] != 2 || tally.Ballots != 4 {
+		t.Errorf("Unexpected tally %+v", tally)
+	}
+
+	// Ties go to the candidate listed first
+	tally = Count(KindSingle, candidates, [][]uuid.UUID{{c}, {b}})
+	if tally.Winner == nil || *tally.Winner != b {
+		t.Errorf("Expected the tie to go to b, got %v", tally.Winner)
+	}
+
+	if tally := Count(KindSingle, candidates, nil); tally.Winner != nil {
+		t.Errorf("Expected no winner without votes, got %v", tally.Winner)
+	}
+}
+
+func TestCountRanked(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	candidates := []uuid.UUID{a, b, c}
+
+	// a leads the first round without a majority; c is eliminated and its
+	// voters' second choice, b, wins the runoff
+	ballots := [][]uuid.UUID{
+		{a, b}, {a, c}, {a},
+		{b, a}, {b, c},
+		{c, b}, {c, b},
+	}
+	tally := Count(KindRanked, candidates, ballots)
+	if tally.Winner == nil || *tally.Winner != b {
+		t.Fatalf("Expected b to win the runoff, got %v", tally.Winner)
+	}
+	if len(tally.Rounds) != 2 {
+		t.Fatalf("Expected 2 rounds, got %d", len(tally.Rounds))
+	}
+	if eliminated := tally.Rounds[0].Eliminated; eliminated == nil || *eliminated != c {
+		t.Errorf("Expected c to be eliminated first, got %v", eliminated)
+	}
+	if tally.Rounds[1].Counts[b] != 4 || tally.Rounds[1].Counts[a] != 3 {
+		t.Errorf("Unexpected runoff counts %v", tally.Rounds[1].Counts)
+	}
+
+	// A first-round majority wins outright
+	tally = Count(KindRanked, candidates, [][]uuid.UUID{{c}, {c, a}, {a}})
+	if tally.Winner == nil || *tally.Winner != c || len(tally.Rounds) != 1 {
+		t.Errorf("Expected c to win in one round, got %+v", tally)
+	}
+
+	// Ballots whose choices are all eliminated stop counting, and a tie for
+	// last place eliminates the candidate listed last
+	tally = Count(KindRanked, candidates, [][]uuid.UUID{{a}, {a}, {b}, {b}, {c}})
+	if tally.Winner == nil || *tally.Winner != a || len(tally.Rounds) != 3 {
+		t.Fatalf("Expected a to win in 3 rounds, got %+v", tally)
+	}
+	if round := tally.Rounds[1]; round.Exhausted != 1 || round.Eliminated == nil || *round.Eliminated != b {
+		t.Errorf("Expected c's ballot exhausted and b eliminated in round 2, got %+v", round)
+	}
+}
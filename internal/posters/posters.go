@@ -0,0 +1,101 @@
+// Package posters links shareable event posters to the guest RSVP page.
+//
+// A poster's QR code opens the frontend's guest page with a signed token for
+// the event, which the page exchanges for the event's public details. Tokens
+// expire at the end of the event day where the event happens, and stop
+// working earlier if the event is made private or deleted.
+package posters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound means the event or its club does not exist or is deleted
+var ErrNotFound = errors.New("event not found")
+
+// Event is what guests may see of a public event
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Title      string    `json:"title"`
+	Date       string    `json:"date"`
+	Time       string    `json:"time"`
+	Timezone   string    `json:"timezone"`
+	Location   string    `json:"location"`
+	ClubName   string    `json:"clubName"`
+	BrandColor string    `json:"-"`
+	IsPublic   bool      `json:"-"`
+	EndsAt     time.Time `json:"-"` // end of the event day, when guest links expire
+}
+
+// Links signs and checks the guest links posters point at
+type Links struct {
+	signer  *signedurl.Signer
+	pageURL string
+}
+
+// NewLinks links to the guest RSVP page at pageURL, which the token is appended to
+func NewLinks(signer *signedurl.Signer, pageURL string) Links {
+	return Links{signer: signer, pageURL: strings.TrimSuffix(pageURL, "/")}
+}
+
+// Link is the guest page URL for an event, valid until the event day ends
+func (l Links) Link(event Event) string {
+	return l.pageURL + "/" + l.signer.Sign(event.ID.String(), event.EndsAt)
+}
+
+// Verify returns the ID of the event a guest token was issued for
+func (l Links) Verify(token string, now time.Time) (uuid.UUID, error) {
+	subject, _, err := l.signer.Verify(token, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, signedurl.ErrMalformed
+	}
+	return id, nil
+}
+
+// Store reads events for posters and guest pages
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// Event loads an event, public or not, or returns ErrNotFound
+func (s *Store) Event(ctx context.Context, eventID uuid.UUID) (Event, error) {
+	query := `
+		SELECT e.id, e.title, to_char(e.event_date, 'YYYY-MM-DD'), to_char(e.event_time, 'HH24:MI'),
+		       e.timezone, e.location, e.is_public, (e.event_date + INTERVAL '1 day') AT TIME ZONE e.timezone,
+		       c.name, COALESCE(c.brand_color, '')
+		FROM events e
+		JOIN clubs c ON c.id = e.club_id AND c.deleted_at IS NULL
+		WHERE e.id = $1 AND e.deleted_at IS NULL`
+
+	var event Event
+	err := s.db.QueryRowContext(ctx, query, eventID).Scan(
+		&event.ID, &event.Title, &event.Date, &event.Time,
+		&event.Timezone, &event.Location, &event.IsPublic, &event.EndsAt,
+		&event.ClubName, &event.BrandColor,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, ErrNotFound
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to get event: %w", err)
+	}
+	return event, nil
+}
@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/apierror"
+	"bookwork-api/internal/logging"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// maxSignedBodyBytes bounds the body read to verify a signature
+const maxSignedBodyBytes = 1 << 20
+
+// unroutedRoute counts requests refused before routing
+const unroutedRoute = "*"
+
+// Middleware attributes requests under pathPrefix carrying X-Publisher-Key.
+// Requests without the header pass through untouched. Signed requests are
+// verified and counted against the publisher quota; unsigned ones are refused
+// if the publisher requires signing. It must run before the per-client rate
+// limiter so the limiter can leave signed requests to the publisher quota.
+func (reg *Registry) Middleware(pathPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(KeyHeader)
+			if key == "" || !strings.HasPrefix(r.URL.Path, pathPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, ok := reg.Lookup(key)
+			if !ok {
+				apierror.Write(w, time.Now(), apierror.New(apierror.CodeInvalidPublisherKey, "Unknown or revoked publisher key", nil))
+				return
+			}
+
+			now := time.Now()
+			signed := r.Header.Get(SignatureHeader) != ""
+			if signed || p.RequireSignature {
+				if err := reg.verify(p, r, now); err != nil {
+					reg.record(p.ID, now, unroutedRoute, func(u *Usage) { u.Rejected++ })
+					logging.FromContext(r.Context()).Warn("rejected publisher request", "publisher_id", p.ID, "error", err)
+					apierror.Write(w, time.Now(), apierror.New(apierror.CodeInvalidPublisherSignature, signatureMessage(err), nil))
+					return
+				}
+			}
+
+			if signed {
+				allowed, remaining, reset := reg.allow(p, now)
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(p.QuotaPerMinute))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+				if !allowed {
+					reg.record(p.ID, now, unroutedRoute, func(u *Usage) { u.Throttled++ })
+					retryAfter := int64(time.Until(reset).Seconds()) + 1
+					w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+					apierror.Write(w, time.Now(), apierror.New(apierror.CodePublisherQuotaExceeded, "Publisher request quota exceeded", map[string]interface{}{"limit": p.QuotaPerMinute, "retryAfter": retryAfter}))
+					return
+				}
+			}
+
+			ctx := NewContext(r.Context(), Caller{Publisher: p, Signed: signed})
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// The route pattern is only known once the router has matched the request
+			route := unroutedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			reg.record(p.ID, now, route, func(u *Usage) {
+				u.Requests++
+				if signed {
+					u.Signed++
+				}
+			})
+		})
+	}
+}
+
+// verify reads the body to check the signature, then restores it for the handler
+func (reg *Registry) verify(p Publisher, r *http.Request, now time.Time) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+		if err != nil || len(body) > maxSignedBodyBytes {
+			return ErrInvalidSignature
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return Verify(p, r, body, now)
+}
+
+func signatureMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrSignatureRequired):
+		return "This publisher key only accepts signed requests"
+	case errors.Is(err, ErrStaleSignature):
+		return "Request timestamp is outside the allowed window"
+	default:
+		return "Invalid request signature"
+	}
+}
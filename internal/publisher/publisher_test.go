@@ -0,0 +1,184 @@
+package publisher
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func testPublisher(quota int, requireSignature bool) Publisher {
+	return Publisher{
+		ID:                  uuid.New(),
+		Name:                "Book Blog",
+		Key:                 "pk_test",
+		Secret:              "sk_test",
+		QuotaPerMinute:      quota,
+		ReplayWindowSeconds: 300,
+		RequireSignature:    requireSignature,
+	}
+}
+
+func signedRequest(p Publisher, method, target, body string, at time.Time) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set(KeyHeader, p.Key)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(p.Secret, at.Unix(), method, req.URL.RequestURI(), []byte(body)))
+	return req
+}
+
+func TestVerify(t *testing.T) {
+	p := testPublisher(10, false)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		expected error
+	}{
+		{"valid", func() *http.Request {
+			return signedRequest(p, "GET", "/api/public/clubs/1?x=1", "", now)
+		}, nil},
+		{"unsigned", func() *http.Request {
+			return httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+		}, ErrSignatureRequired},
+		{"other query", func() *http.Request {
+			req := signedRequest(p, "GET", "/api/public/clubs/1?x=1", "", now)
+			req.URL.RawQuery = "x=2"
+			return req
+		}, ErrInvalidSignature},
+		{"wrong secret", func() *http.Request {
+			other := p
+			other.Secret = "sk_other"
+			return signedRequest(other, "GET", "/api/public/clubs/1", "", now)
+		}, ErrInvalidSignature},
+		{"outside replay window", func() *http.Request {
+			return signedRequest(p, "GET", "/api/public/clubs/1", "", now.Add(-6*time.Minute))
+		}, ErrStaleSignature},
+		{"future timestamp", func() *http.Request {
+			return signedRequest(p, "GET", "/api/public/clubs/1", "", now.Add(6*time.Minute))
+		}, ErrStaleSignature},
+	}
+
+	for _, tt := range tests {
+		req := tt.request()
+		body, _ := io.ReadAll(req.Body)
+		if err := Verify(p, req, body, now); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	// The body is part of the signature
+	req := signedRequest(p, "POST", "/api/public/widgets", `{"a":1}`, now)
+	if err := Verify(p, req, []byte(`{"a":2}`), now); err != ErrInvalidSignature {
+		t.Errorf("Expected tampered body to be rejected, got %v", err)
+	}
+}
+
+func setupMiddleware(p Publisher) (*Registry, http.Handler) {
+	reg := NewRegistry(nil, Settings{DefaultQuota: 600, DefaultReplayWindow: 5 * time.Minute, RefreshInterval: time.Minute}, slog.Default())
+	reg.put(p)
+
+	router := chi.NewRouter()
+	router.Use(reg.Middleware("/api/public/"))
+	router.HandleFunc("/api/public/clubs/{clubId}", func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := FromContext(r.Context())
+		if !ok || caller.Publisher.ID != p.ID {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// Handlers still see the body after it was read for verification
+		io.Copy(w, r.Body)
+	})
+	return reg, router
+}
+
+func TestMiddleware(t *testing.T) {
+	p := testPublisher(2, false)
+	reg, router := setupMiddleware(p)
+	now := time.Now()
+
+	// Requests without a key pass through without attribution
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/public/clubs/1", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected anonymous request to reach the handler unattributed, got %d", w.Code)
+	}
+
+	// Unknown keys are refused
+	req := httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+	req.Header.Set(KeyHeader, "pk_unknown")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unknown key to be refused, got %d", w.Code)
+	}
+
+	// Key-only requests are attributed but not counted against the quota
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+		req.Header.Set(KeyHeader, p.Key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected key-only request to succeed, got %d", w.Code)
+		}
+	}
+
+	// Signed requests use the publisher quota
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, signedRequest(p, "POST", "/api/public/clubs/1", "hello", now))
+		if w.Code != expected {
+			t.Fatalf("Signed request %d: expected %d, got %d", i+1, expected, w.Code)
+		}
+		if expected == http.StatusOK && w.Body.String() != "hello" {
+			t.Errorf("Expected the handler to read the signed body, got %q", w.Body.String())
+		}
+	}
+
+	// A bad signature is refused even with quota left elsewhere
+	bad := signedRequest(p, "GET", "/api/public/clubs/1", "", now)
+	bad.Header.Set(SignatureHeader, "00")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, bad)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected bad signature to be refused, got %d", w.Code)
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	routed := reg.usage[usageKey{publisherID: p.ID, day: day, route: "/api/public/clubs/{clubId}"}]
+	if routed == nil || routed.Requests != 5 || routed.Signed != 2 {
+		t.Errorf("Unexpected routed usage: %+v", routed)
+	}
+	refused := reg.usage[usageKey{publisherID: p.ID, day: day, route: unroutedRoute}]
+	if refused == nil || refused.Throttled != 1 || refused.Rejected != 1 {
+		t.Errorf("Unexpected refused usage: %+v", refused)
+	}
+}
+
+func TestMiddlewareRequireSignature(t *testing.T) {
+	p := testPublisher(10, true)
+	_, router := setupMiddleware(p)
+
+	req := httptest.NewRequest("GET", "/api/public/clubs/1", nil)
+	req.Header.Set(KeyHeader, p.Key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned request to be refused, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, signedRequest(p, "GET", "/api/public/clubs/1", "", time.Now()))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected signed request to succeed, got %d", w.Code)
+	}
+}
@@ -0,0 +1,344 @@
+package publisher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/timeutil"
+
+	"github.com/google/uuid"
+)
+
+// Settings applied to publishers created without their own values
+type Settings struct {
+	DefaultQuota        int           // signed requests per minute
+	DefaultReplayWindow time.Duration // tolerance for signed timestamps
+	RefreshInterval     time.Duration // how often publishers are reloaded and usage is written
+}
+
+// NewPublisher describes a publisher to create; zero values take the defaults
+type NewPublisher struct {
+	Name                string
+	QuotaPerMinute      int
+	ReplayWindowSeconds int
+	RequireSignature    bool
+	CreatedBy           *uuid.UUID
+}
+
+// Registry keeps active publishers in memory so requests are verified without
+// a database round trip. Quota windows and usage counters are also kept in
+// memory; usage is written to publisher_usage every refresh interval.
+type Registry struct {
+	db       *database.DB
+	settings Settings
+	logger   *slog.Logger
+
+	mu     sync.RWMutex
+	byKey  map[string]Publisher
+	quotas map[uuid.UUID]*quotaWindow
+	usage  map[usageKey]*Usage
+}
+
+// quotaWindow counts a publisher's signed requests in the current minute
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+type usageKey struct {
+	publisherID uuid.UUID
+	day         string
+	route       string
+}
+
+func NewRegistry(db *database.DB, settings Settings, logger *slog.Logger) *Registry {
+	return &Registry{
+		db:       db,
+		settings: settings,
+		logger:   logger,
+		byKey:    make(map[string]Publisher),
+		quotas:   make(map[uuid.UUID]*quotaWindow),
+		usage:    make(map[usageKey]*Usage),
+	}
+}
+
+// Lookup returns the active publisher holding key
+func (reg *Registry) Lookup(key string) (Publisher, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	p, ok := reg.byKey[key]
+	return p, ok
+}
+
+// put makes p available to Lookup
+func (reg *Registry) put(p Publisher) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.byKey[p.Key] = p
+}
+
+// Load replaces the in-memory publishers with the active ones in the database
+func (reg *Registry) Load(ctx context.Context) error {
+	publishers, err := reg.query(ctx, `WHERE revoked_at IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	byKey := make(map[string]Publisher, len(publishers))
+	for _, p := range publishers {
+		byKey[p.Key] = p
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.byKey = byKey
+	return nil
+}
+
+// List returns every publisher, including revoked ones, newest first
+func (reg *Registry) List(ctx context.Context) ([]Publisher, error) {
+	return reg.query(ctx, `ORDER BY created_at DESC`)
+}
+
+func (reg *Registry) query(ctx context.Context, clause string) ([]Publisher, error) {
+	query := `
+		SELECT id, name, api_key, secret, quota_per_minute, replay_window_seconds, require_signature,
+		       created_by, created_at, revoked_at
+		FROM publishers ` + clause
+
+	rows, err := reg.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query publishers: %w", err)
+	}
+	defer rows.Close()
+
+	publishers := []Publisher{}
+	for rows.Next() {
+		var p Publisher
+		if err := rows.Scan(&p.ID, &p.Name, &p.Key, &p.Secret, &p.QuotaPerMinute, &p.ReplayWindowSeconds,
+			&p.RequireSignature, &p.CreatedBy, &p.CreatedAt, &p.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan publisher: %w", err)
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers, rows.Err()
+}
+
+// Create stores a new publisher with a generated key and secret
+func (reg *Registry) Create(ctx context.Context, np NewPublisher) (Publisher, error) {
+	key, err := randomToken("pk_")
+	if err != nil {
+		return Publisher{}, err
+	}
+	secret, err := randomToken("sk_")
+	if err != nil {
+		return Publisher{}, err
+	}
+
+	p := Publisher{
+		ID:                  uuid.New(),
+		Name:                np.Name,
+		Key:                 key,
+		Secret:              secret,
+		QuotaPerMinute:      np.QuotaPerMinute,
+		ReplayWindowSeconds: np.ReplayWindowSeconds,
+		RequireSignature:    np.RequireSignature,
+		CreatedBy:           np.CreatedBy,
+		CreatedAt:           time.Now(),
+	}
+	if p.QuotaPerMinute <= 0 {
+		p.QuotaPerMinute = reg.settings.DefaultQuota
+	}
+	if p.ReplayWindowSeconds <= 0 {
+		p.ReplayWindowSeconds = int(reg.settings.DefaultReplayWindow.Seconds())
+	}
+
+	query := `
+		INSERT INTO publishers (id, name, api_key, secret, quota_per_minute, replay_window_seconds,
+		                        require_signature, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = reg.db.ExecContext(ctx, query, p.ID, p.Name, p.Key, p.Secret, p.QuotaPerMinute,
+		p.ReplayWindowSeconds, p.RequireSignature, p.CreatedBy, p.CreatedAt)
+	if err != nil {
+		return Publisher{}, fmt.Errorf("failed to create publisher: %w", err)
+	}
+
+	reg.put(p)
+	return p, nil
+}
+
+// Revoke disables a publisher's key immediately; its usage history is kept
+func (reg *Registry) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := reg.db.ExecContext(ctx,
+		`UPDATE publishers SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke publisher: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for key, p := range reg.byKey {
+		if p.ID == id {
+			delete(reg.byKey, key)
+		}
+	}
+	delete(reg.quotas, id)
+	return nil
+}
+
+// Usage returns a publisher's daily usage between from and to inclusive,
+// writing pending counters first so the figures are current
+func (reg *Registry) Usage(ctx context.Context, id uuid.UUID, from, to time.Time) ([]Usage, error) {
+	if err := reg.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT day, route, requests, signed, throttled, rejected
+		FROM publisher_usage
+		WHERE publisher_id = $1 AND day BETWEEN $2::date AND $3::date
+		ORDER BY day, route`
+
+	rows, err := reg.db.QueryContext(ctx, query, id, timeutil.FormatDate(from), timeutil.FormatDate(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query publisher usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []Usage{}
+	for rows.Next() {
+		var u Usage
+		var day time.Time
+		if err := rows.Scan(&day, &u.Route, &u.Requests, &u.Signed, &u.Throttled, &u.Rejected); err != nil {
+			return nil, fmt.Errorf("failed to scan publisher usage: %w", err)
+		}
+		u.Day = timeutil.FormatDate(day)
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// allow counts a signed request against the publisher quota, returning whether
+// it is allowed, the requests left and when the window resets
+func (reg *Registry) allow(p Publisher, now time.Time) (bool, int, time.Time) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	window, ok := reg.quotas[p.ID]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &quotaWindow{start: now.Truncate(time.Minute)}
+		reg.quotas[p.ID] = window
+	}
+	reset := window.start.Add(time.Minute)
+
+	if window.count >= p.QuotaPerMinute {
+		return false, 0, reset
+	}
+	window.count++
+	return true, p.QuotaPerMinute - window.count, reset
+}
+
+// record adds one request to the publisher's usage counters
+func (reg *Registry) record(id uuid.UUID, now time.Time, route string, count func(*Usage)) {
+	key := usageKey{publisherID: id, day: timeutil.FormatDate(now.UTC()), route: route}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	u, ok := reg.usage[key]
+	if !ok {
+		u = &Usage{Day: key.day, Route: route}
+		reg.usage[key] = u
+	}
+	count(u)
+}
+
+// Flush adds the pending usage counters to publisher_usage. Counters that
+// could not be written are kept for the next flush.
+func (reg *Registry) Flush(ctx context.Context) error {
+	reg.mu.Lock()
+	pending := reg.usage
+	reg.usage = make(map[usageKey]*Usage)
+	reg.mu.Unlock()
+
+	query := `
+		INSERT INTO publisher_usage (publisher_id, day, route, requests, signed, throttled, rejected)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (publisher_id, day, route) DO UPDATE SET
+			requests = publisher_usage.requests + EXCLUDED.requests,
+			signed = publisher_usage.signed + EXCLUDED.signed,
+			throttled = publisher_usage.throttled + EXCLUDED.throttled,
+			rejected = publisher_usage.rejected + EXCLUDED.rejected`
+
+	var firstErr error
+	for key, u := range pending {
+		_, err := reg.db.ExecContext(ctx, query, key.publisherID, key.day, key.route,
+			u.Requests, u.Signed, u.Throttled, u.Rejected)
+		if err == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("failed to write publisher usage: %w", err)
+		}
+		reg.restore(key, u)
+	}
+	return firstErr
+}
+
+// restore merges unwritten counters back into the pending usage
+func (reg *Registry) restore(key usageKey, unwritten *Usage) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	u, ok := reg.usage[key]
+	if !ok {
+		reg.usage[key] = unwritten
+		return
+	}
+	u.Requests += unwritten.Requests
+	u.Signed += unwritten.Signed
+	u.Throttled += unwritten.Throttled
+	u.Rejected += unwritten.Rejected
+}
+
+// Run loads publishers immediately and then, every refresh interval until ctx
+// is cancelled, writes pending usage and reloads publishers
+func (reg *Registry) Run(ctx context.Context) {
+	ticker := time.NewTicker(reg.settings.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := reg.Load(ctx); err != nil {
+			reg.logger.Error("error loading publishers", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := reg.Flush(context.Background()); err != nil {
+				reg.logger.Error("error writing publisher usage", "error", err)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := reg.Flush(ctx); err != nil {
+			reg.logger.Error("error writing publisher usage", "error", err)
+		}
+	}
+}
+
+func randomToken(prefix string) (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate publisher credentials: %w", err)
+	}
+	return prefix + hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,412 @@
+// Package qrcode encodes short texts, such as links, as QR codes.
+//
+// Texts are encoded as bytes at error correction level M, which recovers
+// about 15% of the code, enough for printed posters that get a little worn.
+// Versions 1 to 10 are supported, which hold up to 213 bytes.
+package qrcode
+
+import (
+	"errors"
+)
+
+// ErrTooLong means a text does not fit in the largest supported version
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// Code is a square grid of modules; Dark reports the colour of each
+type Code struct {
+	Size    int // modules per side, without the quiet zone
+	Version int
+	modules [][]bool
+}
+
+// Dark reports whether the module in row y, column x is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// versionInfo is the level M block structure of a version
+type versionInfo struct {
+	ecPerBlock int
+	blocks     []int // data codewords of each block
+	alignment  []int // alignment pattern centres, in both directions
+}
+
+var versions = [...]versionInfo{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// dataCodewords is how many data codewords the version holds
+func (v versionInfo) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// Encode returns the smallest code that holds text
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	for version := 1; version < len(versions); version++ {
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*versions[version].dataCodewords() {
+			continue
+		}
+		codewords := encodeData(data, countBits, versions[version].dataCodewords())
+		return build(version, addErrorCorrection(codewords, versions[version])), nil
+	}
+	return nil, ErrTooLong
+}
+
+// encodeData lays text out as a byte mode segment padded to capacity codewords
+func encodeData(data []byte, countBits, capacity int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4) // byte mode
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	// Terminator, then zeros to the next byte
+	terminator := 8*capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+
+	codewords := bits.bytes()
+	for pad := byte(0xec); len(codewords) < capacity; pad ^= 0xec ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// addErrorCorrection splits data into the version's blocks, computes each
+// block's error correction codewords and interleaves the lot
+func addErrorCorrection(data []byte, info versionInfo) []byte {
+	generator := rsGenerator(info.ecPerBlock)
+	dataBlocks := make([][]byte, len(info.blocks))
+	ecBlocks := make([][]byte, len(info.blocks))
+	offset := 0
+	for i, size := range info.blocks {
+		dataBlocks[i] = data[offset : offset+size]
+		ecBlocks[i] = rsRemainder(dataBlocks[i], generator)
+		offset += size
+	}
+
+	result := make([]byte, 0, len(data)+len(info.blocks)*info.ecPerBlock)
+	longest := info.blocks[len(info.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// grid is a code being built; function modules are not data
+type grid struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newGrid(size int) *grid {
+	g := &grid{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for y := range g.modules {
+		g.modules[y] = make([]bool, size)
+		g.function[y] = make([]bool, size)
+	}
+	return g
+}
+
+func (g *grid) setFunction(x, y int, dark bool) {
+	g.modules[y][x] = dark
+	g.function[y][x] = true
+}
+
+// build places the codewords of version, choosing the mask with the lowest penalty
+func build(version int, codewords []byte) *Code {
+	size := 17 + 4*version
+	var best *grid
+	bestPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		g := newGrid(size)
+		g.drawFunctionPatterns(version)
+		g.drawCodewords(codewords)
+		g.applyMask(mask)
+		g.drawFormat(mask)
+		if penalty := g.penalty(); best == nil || penalty < bestPenalty {
+			best, bestPenalty = g, penalty
+		}
+	}
+	return &Code{Size: size, Version: version, modules: best.modules}
+}
+
+func (g *grid) drawFunctionPatterns(version int) {
+	// Timing patterns
+	for i := 0; i < g.size; i++ {
+		g.setFunction(6, i, i%2 == 0)
+		g.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with their separators
+	g.drawFinder(3, 3)
+	g.drawFinder(g.size-4, 3)
+	g.drawFinder(3, g.size-4)
+
+	// Alignment patterns, except where they would overlap the finders
+	positions := versions[version].alignment
+	for i, y := range positions {
+		for j, x := range positions {
+			last := len(positions) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			g.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas; drawFormat fills them in after masking
+	g.drawFormat(0)
+	g.drawVersion(version)
+}
+
+// drawFinder draws a finder pattern centred on (cx, cy) and its light border
+func (g *grid) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= g.size || y < 0 || y >= g.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			g.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (g *grid) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			g.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of the level M format bits for mask
+func (g *grid) drawFormat(mask int) {
+	data := mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		g.setFunction(8, i, bit(bits, i))
+	}
+	g.setFunction(8, 7, bit(bits, 6))
+	g.setFunction(8, 8, bit(bits, 7))
+	g.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		g.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		g.setFunction(g.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		g.setFunction(8, g.size-15+i, bit(bits, i))
+	}
+	g.setFunction(8, g.size-8, true) // always dark
+}
+
+// drawVersion draws both copies of the version bits, which versions 7 and up carry
+func (g *grid) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := g.size-11+i%3, i/3
+		g.setFunction(a, b, bit(bits, i))
+		g.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order, two columns at a
+// time from the bottom right, skipping the vertical timing pattern
+func (g *grid) drawCodewords(codewords []byte) {
+	i := 0
+	for right := g.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < g.size; vert++ {
+			y := vert
+			if upward {
+				y = g.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if g.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				g.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules the mask pattern selects
+func (g *grid) applyMask(mask int) {
+	for y := 0; y < g.size; y++ {
+		for x := 0; x < g.size; x++ {
+			if !g.function[y][x] && masked(mask, x, y) {
+				g.modules[y][x] = !g.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores how hard the grid is to scan, by the rules of the standard:
+// runs of one colour, 2×2 blocks, finder-like sequences and colour imbalance
+func (g *grid) penalty() int {
+	penalty := 0
+	dark := 0
+	for a := 0; a < g.size; a++ {
+		penalty += g.linePenalty(func(b int) bool { return g.modules[a][b] })
+		penalty += g.linePenalty(func(b int) bool { return g.modules[b][a] })
+	}
+	for y := 0; y < g.size; y++ {
+		for x := 0; x < g.size; x++ {
+			if g.modules[y][x] {
+				dark++
+			}
+			if x+1 < g.size && y+1 < g.size {
+				c := g.modules[y][x]
+				if c == g.modules[y][x+1] && c == g.modules[y+1][x] && c == g.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := g.size * g.size
+	penalty += abs(dark*20-total*10) / total * 10
+	return penalty
+}
+
+var finderLike = [...][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores one row or column, read through at
+func (g *grid) linePenalty(at func(int) bool) int {
+	penalty := 0
+	run := 1
+	for i := 1; i <= g.size; i++ {
+		if i < g.size && at(i) == at(i-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			penalty += run - 2
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= g.size; i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for k, dark := range pattern {
+				if at(i+k) != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				penalty += 40
+			}
+		}
+	}
+	return penalty
+}
+
+// bitBuffer collects bits, most significant first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, set := range b {
+		if set {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+func bit(value, i int) bool {
+	return value>>i&1 == 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,198 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorCorrectionMatchesStandardExample(t *testing.T) {
+	// "01234567" at 1-M, from the worked example of ISO/IEC 18004
+	data := []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	want := []byte{0xa5, 0x24, 0xd4, 0xc1, 0xed, 0x36, 0xc7, 0x87, 0x2c, 0x55}
+
+	if got := rsRemainder(data, rsGenerator(10)); !bytes.Equal(got, want) {
+		t.Fatalf("expected %x, got %x", want, got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	texts := []string{
+		"",
+		"https://bookwork.example/guest/abc",
+		"https://bookwork.example/guest/" + strings.Repeat("x", 120),
+		strings.Repeat("é", 106), // 212 bytes
+	}
+	for _, text := range texts {
+		code, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes) failed: %v", len(text), err)
+		}
+		if code.Size != 17+4*code.Version {
+			t.Fatalf("size %d does not match version %d", code.Size, code.Version)
+		}
+		if got := decode(t, code); got != text {
+			t.Fatalf("decoded %q, expected %q", got, text)
+		}
+	}
+}
+
+func TestEncodeChoosesSmallestVersion(t *testing.T) {
+	for _, tc := range []struct {
+		length  int
+		version int
+	}{{14, 1}, {15, 2}, {62, 4}, {63, 5}, {213, 10}} {
+		code, err := Encode(strings.Repeat("a", tc.length))
+		if err != nil {
+			t.Fatalf("Encode(%d) failed: %v", tc.length, err)
+		}
+		if code.Version != tc.version {
+			t.Errorf("%d bytes: expected version %d, got %d", tc.length, tc.version, code.Version)
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("a", 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestFinderPatterns(t *testing.T) {
+	code, _ := Encode("finder")
+	rows := []string{"#######.", "#.....#.", "#.###.#.", "#.###.#.", "#.###.#.", "#.....#.", "#######.", "........"}
+	for y, row := range rows {
+		for x, c := range row {
+			want := c == '#'
+			if code.Dark(x, y) != want || code.Dark(code.Size-1-x, y) != want || code.Dark(x, code.Size-1-y) != want {
+				t.Fatalf("finder module (%d, %d) is wrong", x, y)
+			}
+		}
+	}
+}
+
+// decode reads a code back the way a scanner would, checking its format
+// bits and error correction on the way
+func decode(t *testing.T, code *Code) string {
+	t.Helper()
+	info := versions[code.Version]
+
+	// The format bits name the mask; both copies must agree
+	mask := -1
+	for m := 0; m < 8; m++ {
+		g := newGrid(code.Size)
+		g.drawFormat(m)
+		matches := true
+		for y := 0; y < code.Size && matches; y++ {
+			for x := 0; x < code.Size; x++ {
+				if g.function[y][x] && g.modules[y][x] != code.Dark(x, y) {
+					matches = false
+					break
+				}
+			}
+		}
+		if matches {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatal("format bits match no mask")
+	}
+
+	g := newGrid(code.Size)
+	g.drawFunctionPatterns(code.Version)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if g.function[y][x] && g.modules[y][x] != code.Dark(x, y) && !isFormat(code.Size, x, y) {
+				t.Fatalf("function module (%d, %d) is wrong", x, y)
+			}
+			if !g.function[y][x] {
+				g.modules[y][x] = code.Dark(x, y) != masked(mask, x, y)
+			}
+		}
+	}
+
+	// Read the codewords in placement order
+	total := info.dataCodewords() + len(info.blocks)*info.ecPerBlock
+	codewords := make([]byte, total)
+	i := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < code.Size; vert++ {
+			y := vert
+			if upward {
+				y = code.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if g.function[y][x] || i >= total*8 {
+					continue
+				}
+				if g.modules[y][x] {
+					codewords[i/8] |= 0x80 >> (i % 8)
+				}
+				i++
+			}
+		}
+	}
+
+	// Undo the interleaving and check each block
+	blocks := make([][]byte, len(info.blocks))
+	k := 0
+	for n := 0; n < info.blocks[len(info.blocks)-1]; n++ {
+		for b, size := range info.blocks {
+			if n < size {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	generator := rsGenerator(info.ecPerBlock)
+	for b := range blocks {
+		ec := make([]byte, info.ecPerBlock)
+		for n := range ec {
+			ec[n] = codewords[k+n*len(blocks)+b]
+		}
+		if !bytes.Equal(rsRemainder(blocks[b], generator), ec) {
+			t.Fatalf("block %d fails its error correction", b)
+		}
+		data = append(data, blocks[b]...)
+	}
+
+	// Parse the byte mode segment
+	if data[0]>>4 != 0b0100 {
+		t.Fatalf("unexpected mode %04b", data[0]>>4)
+	}
+	var bits bitBuffer
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	read := func(from, n int) int {
+		v := 0
+		for _, set := range bits[from : from+n] {
+			v <<= 1
+			if set {
+				v |= 1
+			}
+		}
+		return v
+	}
+	countBits := 8
+	if code.Version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	text := make([]byte, length)
+	for n := range text {
+		text[n] = byte(read(4+countBits+8*n, 8))
+	}
+	return string(text)
+}
+
+// isFormat reports whether a module holds format bits
+func isFormat(size, x, y int) bool {
+	return (y == 8 && (x <= 8 || x >= size-8)) || (x == 8 && (y <= 8 || y >= size-8))
+}
@@ -0,0 +1,250 @@
+// Package recommend finds public clubs similar to each other for "clubs like
+// this" on the public club page.
+//
+// Similarity combines three signals, each between 0 and 1:
+//
+//	tags      overlap of the clubs' tags (Jaccard index)
+//	books     overlap of the books the clubs have read and are reading
+//	location  1 for the same location, 0.5 for the same country only
+//
+// Locations are free text, so proximity is matched on the normalised location
+// rather than a distance. Comparing every pair of clubs is quadratic, so
+// recommendations are computed offline by a scheduled job into
+// club_recommendations and read from there.
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Weights of the signals in the total score
+const (
+	TagWeight      = 0.4
+	BookWeight     = 0.4
+	LocationWeight = 0.2
+)
+
+// MinScore is the lowest total score recommended; below it clubs only share
+// a location or a single common tag among many
+const MinScore = 0.15
+
+// Profile is what a club is compared on
+type Profile struct {
+	ClubID   uuid.UUID
+	Tags     []string
+	Books    []string
+	Location string
+	Country  string
+}
+
+// Score is how similar two clubs are, overall and per signal
+type Score struct {
+	Total    float64
+	Tags     float64
+	Books    float64
+	Location float64
+}
+
+// Recommendation is a club similar to another
+type Recommendation struct {
+	ClubID uuid.UUID
+	Score  Score
+}
+
+// Similarity scores two clubs
+func Similarity(a, b Profile) Score {
+	score := Score{
+		Tags:  jaccard(a.Tags, b.Tags),
+		Books: jaccard(a.Books, b.Books),
+	}
+
+	switch {
+	case normalize(a.Location) != "" && normalize(a.Location) == normalize(b.Location):
+		score.Location = 1
+	case a.Country != "" && strings.EqualFold(a.Country, b.Country):
+		score.Location = 0.5
+	}
+
+	score.Total = TagWeight*score.Tags + BookWeight*score.Books + LocationWeight*score.Location
+	return score
+}
+
+// Recommend returns up to perClub of the most similar clubs for each club,
+// best first, leaving out those scoring below MinScore
+func Recommend(profiles []Profile, perClub int) map[uuid.UUID][]Recommendation {
+	recommendations := make(map[uuid.UUID][]Recommendation, len(profiles))
+	for i := range profiles {
+		for j := i + 1; j < len(profiles); j++ {
+			score := Similarity(profiles[i], profiles[j])
+			if score.Total < MinScore {
+				continue
+			}
+			a, b := profiles[i].ClubID, profiles[j].ClubID
+			recommendations[a] = append(recommendations[a], Recommendation{ClubID: b, Score: score})
+			recommendations[b] = append(recommendations[b], Recommendation{ClubID: a, Score: score})
+		}
+	}
+
+	for clubID, recs := range recommendations {
+		// Ties are broken by club ID so runs over the same data agree
+		sort.Slice(recs, func(i, j int) bool {
+			if recs[i].Score.Total != recs[j].Score.Total {
+				return recs[i].Score.Total > recs[j].Score.Total
+			}
+			return recs[i].ClubID.String() < recs[j].ClubID.String()
+		})
+		if len(recs) > perClub {
+			recommendations[clubID] = recs[:perClub]
+		}
+	}
+	return recommendations
+}
+
+// jaccard is the size of the intersection over the size of the union, of the
+// normalised values
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		if v = normalize(v); v != "" {
+			set[v] = true
+		}
+	}
+
+	union := len(set)
+	common := 0
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		v = normalize(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		if set[v] {
+			common++
+		} else {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// normalize lowercases and collapses whitespace so "The Hobbit " and "the  hobbit" match
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Job periodically recomputes the recommendations of every public club
+type Job struct {
+	db       *database.DB
+	perClub  int
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewJob(db *database.DB, perClub int, interval time.Duration, logger *slog.Logger) *Job {
+	return &Job{db: db, perClub: perClub, interval: interval, logger: logger}
+}
+
+// Compute replaces the stored recommendations and returns how many were written
+func (j *Job) Compute(ctx context.Context) (int, error) {
+	profiles, err := j.profiles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	recommendations := Recommend(profiles, j.perClub)
+
+	tx, err := j.db.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Readers see the previous recommendations until the new ones are committed
+	if _, err := tx.ExecContext(ctx, `DELETE FROM club_recommendations`); err != nil {
+		return 0, fmt.Errorf("failed to clear club recommendations: %w", err)
+	}
+
+	query := `
+		INSERT INTO club_recommendations (club_id, similar_club_id, rank, score, tag_score, book_score, location_score)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	written := 0
+	for clubID, recs := range recommendations {
+		for rank, rec := range recs {
+			_, err := tx.ExecContext(ctx, query, clubID, rec.ClubID, rank+1,
+				rec.Score.Total, rec.Score.Tags, rec.Score.Books, rec.Score.Location)
+			if err != nil {
+				return 0, fmt.Errorf("failed to write club recommendation: %w", err)
+			}
+			written++
+		}
+	}
+
+	return written, tx.Commit()
+}
+
+// profiles loads every public club with the books of its events, archived ones included
+func (j *Job) profiles(ctx context.Context) ([]Profile, error) {
+	query := `
+		SELECT c.id, COALESCE(c.tags, '{}'), COALESCE(c.location, ''), COALESCE(c.country, ''),
+		       ARRAY_REMOVE(ARRAY(
+		           SELECT c.current_book
+		           UNION SELECT e.book FROM events e WHERE e.club_id = c.id AND e.deleted_at IS NULL
+		           UNION SELECT a.book FROM events_archive a WHERE a.club_id = c.id
+		       ), NULL)
+		FROM clubs c
+		WHERE c.is_public = true AND COALESCE(c.is_sandbox, false) = false AND c.deleted_at IS NULL`
+
+	rows, err := j.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query club profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		var p Profile
+		var tags, books models.StringArray
+		if err := rows.Scan(&p.ClubID, &tags, &p.Location, &p.Country, &books); err != nil {
+			return nil, fmt.Errorf("failed to scan club profile: %w", err)
+		}
+		p.Tags, p.Books = tags, books
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// Run computes immediately and then every interval until ctx is cancelled
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		written, err := j.Compute(ctx)
+		if err != nil {
+			j.logger.Error("error computing club recommendations", "error", err)
+		} else {
+			j.logger.Info("computed club recommendations", "recommendations", written)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestSimilarity(t *testing.T) {
+	a := Profile{
+		Tags:     []string{"Fantasy", "sci-fi", "classics"},
+		Books:    []string{"The Hobbit", "Dune"},
+		Location: "Portland, OR",
+		Country:  "US",
+	}
+	b := Profile{
+		Tags:     []string{"fantasy", "Sci-Fi"},
+		Books:    []string{"the  hobbit", "Emma", "Dune", "Dune"},
+		Location: " portland, or",
+		Country:  "US",
+	}
+
+	score := Similarity(a, b)
+
+	// Tags: 2 shared of 3; books: 2 shared of 3; same location
+	if !near(score.Tags, 2.0/3) || !near(score.Books, 2.0/3) || score.Location != 1 {
+		t.Errorf("Unexpected signal scores: %+v", score)
+	}
+	if want := TagWeight*2/3 + BookWeight*2/3 + LocationWeight; !near(score.Total, want) {
+		t.Errorf("Expected total %v, got %v", want, score.Total)
+	}
+
+	b.Location = "Seattle, WA"
+	if score := Similarity(a, b); score.Location != 0.5 {
+		t.Errorf("Expected 0.5 for the same country, got %v", score.Location)
+	}
+
+	if score := Similarity(Profile{}, Profile{}); score.Total != 0 {
+		t.Errorf("Expected empty profiles to score 0, got %+v", score)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	fantasy1 := Profile{ClubID: uuid.New(), Tags: []string{"fantasy"}, Books: []string{"The Hobbit"}}
+	fantasy2 := Profile{ClubID: uuid.New(), Tags: []string{"fantasy"}, Books: []string{"The Hobbit", "Emma"}}
+	fantasy3 := Profile{ClubID: uuid.New(), Tags: []string{"fantasy", "horror"}}
+	unrelated := Profile{ClubID: uuid.New(), Tags: []string{"poetry"}, Books: []string{"Odes"}}
+
+	recs := Recommend([]Profile{fantasy1, fantasy2, fantasy3, unrelated}, 1)
+
+	if got := recs[fantasy1.ClubID]; len(got) != 1 || got[0].ClubID != fantasy2.ClubID {
+		t.Errorf("Expected the club sharing tags and books first, got %+v", got)
+	}
+	if got := recs[fantasy3.ClubID]; len(got) != 1 {
+		t.Errorf("Expected one recommendation per club, got %+v", got)
+	}
+	if got := recs[unrelated.ClubID]; len(got) != 0 {
+		t.Errorf("Expected nothing below MinScore, got %+v", got)
+	}
+}
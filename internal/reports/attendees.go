@@ -0,0 +1,167 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// DefaultBrandColor is used when a club has not configured a theme color
+const DefaultBrandColor = "#2F4858"
+
+// AttendeeSheet describes the event and attendees for name tags and sign-in sheets
+type AttendeeSheet struct {
+	ClubName    string
+	BrandColor  string
+	EventTitle  string
+	EventDate   string
+	EventTime   string
+	GeneratedAt time.Time
+	Attendees   []string
+}
+
+// name tag grid on A4 portrait: 2 columns x 5 rows of 90x50mm tags
+const (
+	tagWidth   = 90.0
+	tagHeight  = 50.0
+	tagColumns = 2
+	tagRows    = 5
+	tagMarginX = 15.0
+	tagMarginY = 23.5
+)
+
+// WriteNameTagsPDF renders one branded name tag per attendee
+func WriteNameTagsPDF(w io.Writer, sheet AttendeeSheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle(sheet.EventTitle+" - Name Tags", true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	red, green, blue := ParseHexColor(sheet.BrandColor)
+
+	perPage := tagColumns * tagRows
+	for i, name := range sheet.Attendees {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+
+		slot := i % perPage
+		x := tagMarginX + float64(slot%tagColumns)*tagWidth
+		y := tagMarginY + float64(slot/tagColumns)*tagHeight
+
+		// Tag outline and brand band
+		pdf.SetDrawColor(180, 180, 180)
+		pdf.Rect(x, y, tagWidth, tagHeight, "D")
+		pdf.SetFillColor(red, green, blue)
+		pdf.Rect(x, y, tagWidth, 12, "F")
+
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetXY(x, y+2)
+		pdf.CellFormat(tagWidth, 8, truncate(pdf, tr(sheet.ClubName), tagWidth-4), "", 0, "C", false, 0, "")
+
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "B", 20)
+		pdf.SetXY(x, y+19)
+		pdf.CellFormat(tagWidth, 12, truncate(pdf, tr(name), tagWidth-6), "", 0, "C", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(x, y+38)
+		pdf.CellFormat(tagWidth, 6, truncate(pdf, tr(sheet.EventTitle), tagWidth-6), "", 0, "C", false, 0, "")
+	}
+
+	if len(sheet.Attendees) == 0 {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "I", 12)
+		pdf.CellFormat(0, 10, "No attendees to print", "", 0, "C", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// WriteSignInSheetPDF renders a branded sign-in sheet listing every attendee
+func WriteSignInSheetPDF(w io.Writer, sheet AttendeeSheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetTitle(sheet.EventTitle+" - Sign-in Sheet", true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	red, green, blue := ParseHexColor(sheet.BrandColor)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - Page %d", sheet.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	writeHeader := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFillColor(red, green, blue)
+		pdf.SetTextColor(255, 255, 255)
+		pdf.CellFormat(10, 8, "#", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(80, 8, "Name", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(90, 8, "Signature", "1", 1, "L", true, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "", 10)
+	}
+
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(red, green, blue)
+	pdf.CellFormat(0, 7, tr(sheet.ClubName), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(0, 8, tr(sheet.EventTitle), "", "L", false)
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s at %s", sheet.EventDate, sheet.EventTime)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	writeHeader()
+
+	_, pageHeight := pdf.GetPageSize()
+	for i, name := range sheet.Attendees {
+		if pdf.GetY()+10 > pageHeight-15 {
+			pdf.AddPage()
+			writeHeader()
+		}
+		pdf.CellFormat(10, 10, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(80, 10, truncate(pdf, tr(name), 78), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(90, 10, "", "1", 1, "L", false, 0, "")
+	}
+
+	// Blank rows for walk-ins
+	for i := 0; i < 5; i++ {
+		if pdf.GetY()+10 > pageHeight-15 {
+			break
+		}
+		pdf.CellFormat(10, 10, "", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(80, 10, "", "1", 0, "L", false, 0, "")
+		pdf.CellFormat(90, 10, "", "1", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// ParseHexColor converts a #RRGGBB color into its RGB components,
+// falling back to DefaultBrandColor when the value is malformed
+func ParseHexColor(color string) (int, int, int) {
+	if !IsHexColor(color) {
+		color = DefaultBrandColor
+	}
+	value, _ := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF)
+}
+
+// IsHexColor reports whether color is in #RRGGBB form
+func IsHexColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	_, err := strconv.ParseUint(color[1:], 16, 32)
+	return err == nil
+}
@@ -0,0 +1,51 @@
+package reports
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseHexColor(t *testing.T) {
+	r, g, b := ParseHexColor("#FF8000")
+	if r != 255 || g != 128 || b != 0 {
+		t.Errorf("Expected (255, 128, 0), got (%d, %d, %d)", r, g, b)
+	}
+
+	// Invalid colors fall back to the default brand color
+	dr, dg, db := ParseHexColor(DefaultBrandColor)
+	r, g, b = ParseHexColor("orange")
+	if r != dr || g != dg || b != db {
+		t.Errorf("Expected default color for invalid input, got (%d, %d, %d)", r, g, b)
+	}
+}
+
+func TestWriteAttendeeSheets(t *testing.T) {
+	sheet := AttendeeSheet{
+		ClubName:    "Classic Literature Club",
+		BrandColor:  "#8B0000",
+		EventTitle:  "Discussion: Pride and Prejudice",
+		EventDate:   "2024-03-14",
+		EventTime:   "19:00",
+		GeneratedAt: time.Now(),
+	}
+	for i := 0; i < 23; i++ {
+		sheet.Attendees = append(sheet.Attendees, "Jane Smith")
+	}
+
+	var tags bytes.Buffer
+	if err := WriteNameTagsPDF(&tags, sheet); err != nil {
+		t.Fatalf("Failed to write name tags: %v", err)
+	}
+	if !bytes.HasPrefix(tags.Bytes(), []byte("%PDF-")) {
+		t.Error("Name tags output should start with a PDF header")
+	}
+
+	var signIn bytes.Buffer
+	if err := WriteSignInSheetPDF(&signIn, sheet); err != nil {
+		t.Fatalf("Failed to write sign-in sheet: %v", err)
+	}
+	if !bytes.HasPrefix(signIn.Bytes(), []byte("%PDF-")) {
+		t.Error("Sign-in sheet output should start with a PDF header")
+	}
+}
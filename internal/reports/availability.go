@@ -0,0 +1,122 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// AvailabilityRow is a single member line in the availability roster
+type AvailabilityRow struct {
+	Name   string
+	Status string
+	Notes  string
+}
+
+// AvailabilitySheet describes the event the roster is printed for
+type AvailabilitySheet struct {
+	EventTitle  string
+	EventDate   string
+	EventTime   string
+	Location    string
+	GeneratedAt time.Time
+	Rows        []AvailabilityRow
+}
+
+// availability sheet column widths in millimetres (A4 portrait, 15mm margins)
+var availabilityColumns = []struct {
+	header string
+	width  float64
+}{
+	{"Name", 55},
+	{"Status", 25},
+	{"Notes", 60},
+	{"Signature", 40},
+}
+
+// WriteAvailabilityPDF renders a printable roster/sign-in grid for an event
+func WriteAvailabilityPDF(w io.Writer, sheet AvailabilitySheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetTitle(sheet.EventTitle, true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - Page %d", sheet.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+
+	// Event header
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(0, 8, tr(sheet.EventTitle), "", "L", false)
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s at %s", sheet.EventDate, sheet.EventTime)), "", 1, "L", false, 0, "")
+	if sheet.Location != "" {
+		pdf.CellFormat(0, 6, tr(sheet.Location), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	writeHeader := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFillColor(230, 230, 230)
+		for _, col := range availabilityColumns {
+			pdf.CellFormat(col.width, 8, col.header, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 10)
+	}
+
+	writeHeader()
+
+	_, pageHeight := pdf.GetPageSize()
+	for _, row := range sheet.Rows {
+		// Repeat the header when the row would overflow onto the next page
+		if pdf.GetY()+9 > pageHeight-15 {
+			pdf.AddPage()
+			writeHeader()
+		}
+
+		values := []string{row.Name, statusLabel(row.Status), row.Notes, ""}
+		for i, col := range availabilityColumns {
+			pdf.CellFormat(col.width, 9, truncate(pdf, tr(values[i]), col.width-2), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if len(sheet.Rows) == 0 {
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.CellFormat(0, 9, "No members to list", "1", 1, "C", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case "available":
+		return "Available"
+	case "maybe":
+		return "Maybe"
+	case "unavailable":
+		return "Unavailable"
+	default:
+		return "No response"
+	}
+}
+
+// truncate shortens text so it fits in the given cell width
+func truncate(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
@@ -0,0 +1,46 @@
+package reports
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteAvailabilityPDF(t *testing.T) {
+	sheet := AvailabilitySheet{
+		EventTitle:  "Discussion: Pride and Prejudice",
+		EventDate:   "2024-03-14",
+		EventTime:   "19:00",
+		Location:    "Downtown Library",
+		GeneratedAt: time.Now(),
+		Rows: []AvailabilityRow{
+			{Name: "Jane Smith", Status: "available", Notes: "Bringing snacks"},
+			{Name: "Bob Johnson", Status: "maybe"},
+			{Name: "Zoë Adams", Status: ""},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAvailabilityPDF(&buf, sheet); err != nil {
+		t.Fatalf("Failed to write PDF: %v", err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("Output should start with a PDF header")
+	}
+}
+
+func TestWriteAvailabilityPDFManyRows(t *testing.T) {
+	sheet := AvailabilitySheet{
+		EventTitle:  "Large Event",
+		GeneratedAt: time.Now(),
+	}
+	for i := 0; i < 100; i++ {
+		sheet.Rows = append(sheet.Rows, AvailabilityRow{Name: "Member", Status: "available"})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAvailabilityPDF(&buf, sheet); err != nil {
+		t.Fatalf("Failed to write multi-page PDF: %v", err)
+	}
+}
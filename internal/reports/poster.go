@@ -0,0 +1,213 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/qrcode"
+)
+
+// EventPoster describes a public event for a shareable poster. Link is what
+// the QR code opens, the event's guest RSVP page.
+type EventPoster struct {
+	ClubName   string
+	BrandColor string
+	EventTitle string
+	EventDate  string // YYYY-MM-DD
+	EventTime  string // HH:MM or HH:MM:SS
+	Location   string
+	Link       string
+}
+
+// poster layout in pixels; the SVG uses the same coordinates
+const (
+	posterWidth      = 1200
+	posterHeight     = 1600
+	posterMargin     = 80
+	posterBandHeight = 220
+	posterTitleTop   = 290
+	posterWhenTop    = 590
+	posterQRTop      = 800
+	posterQRSize     = 640 // including the quiet zone
+	posterQuietZone  = 4   // modules of light border scanners need
+	posterCaptionTop = 1480
+	posterCaption    = "Scan to RSVP"
+)
+
+// text scales of the PNG, in image pixels per font pixel
+const (
+	clubScale    = 6
+	titleScale   = 8
+	detailsScale = 5
+)
+
+// When is the event's date and time as shown on the poster
+func (p EventPoster) When() string {
+	date, err := time.Parse("2006-01-02", p.EventDate)
+	if err != nil {
+		return strings.TrimSpace(p.EventDate + " " + p.EventTime)
+	}
+	when := date.Format("Monday 2 January 2006")
+	if clock := p.EventTime; clock != "" {
+		if len(clock) > 5 {
+			clock = clock[:5] // seconds are never set
+		}
+		when += " at " + clock
+	}
+	return when
+}
+
+// WritePosterPNG renders the poster as a PNG. Text is drawn in a built-in
+// bitmap font, which has no accents or non-Latin letters; the SVG keeps them.
+func WritePosterPNG(w io.Writer, poster EventPoster) error {
+	code, err := qrcode.Encode(poster.Link)
+	if err != nil {
+		return fmt.Errorf("failed to encode poster link: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, posterWidth, posterHeight))
+	fillRect(img, 0, 0, posterWidth, posterHeight, color.White)
+	red, green, blue := ParseHexColor(poster.BrandColor)
+	brand := color.RGBA{uint8(red), uint8(green), uint8(blue), 0xff}
+	ink := color.RGBA{0x22, 0x22, 0x22, 0xff}
+
+	fillRect(img, 0, 0, posterWidth, posterBandHeight, brand)
+	club := wrapText(rasterText(poster.ClubName), lineChars(clubScale), 1)
+	drawCentred(img, club, (posterBandHeight-8*clubScale)/2, clubScale, color.White)
+
+	title := wrapText(rasterText(poster.EventTitle), lineChars(titleScale), 3)
+	drawCentred(img, title, posterTitleTop, titleScale, ink)
+
+	details := wrapText(rasterText(poster.When()), lineChars(detailsScale), 2)
+	details = append(details, wrapText(rasterText(poster.Location), lineChars(detailsScale), 2)...)
+	drawCentred(img, details, posterWhenTop, detailsScale, ink)
+
+	module := posterQRSize / (code.Size + 2*posterQuietZone)
+	left := (posterWidth - code.Size*module) / 2
+	top := posterQRTop + (posterQRSize-code.Size*module)/2
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Dark(x, y) {
+				fillRect(img, left+x*module, top+y*module, module, module, color.Black)
+			}
+		}
+	}
+
+	drawCentred(img, []string{posterCaption}, posterCaptionTop, detailsScale, brand)
+
+	return png.Encode(w, img)
+}
+
+// WritePosterSVG renders the poster as an SVG, which prints sharply at any size
+func WritePosterSVG(w io.Writer, poster EventPoster) error {
+	code, err := qrcode.Encode(poster.Link)
+	if err != nil {
+		return fmt.Errorf("failed to encode poster link: %w", err)
+	}
+	red, green, blue := ParseHexColor(poster.BrandColor)
+	brand := fmt.Sprintf("#%02X%02X%02X", red, green, blue)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", posterWidth, posterHeight, posterWidth, posterHeight)
+	fmt.Fprintf(&b, "<title>%s</title>\n", escapeXML(poster.EventTitle))
+	b.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/>` + "\n")
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", posterWidth, posterBandHeight, brand)
+	b.WriteString(`<g font-family="Helvetica, Arial, sans-serif" text-anchor="middle">` + "\n")
+
+	// Lines wrap at the widths the PNG uses, which suit proportional text too
+	writeSVGLines(&b, wrapText(poster.ClubName, lineChars(clubScale), 1), (posterBandHeight+8*clubScale)/2, 8*clubScale, "#FFFFFF", "bold")
+	writeSVGLines(&b, wrapText(poster.EventTitle, lineChars(titleScale), 3), posterTitleTop+8*titleScale, 8*titleScale, "#222222", "bold")
+	details := wrapText(poster.When(), lineChars(detailsScale), 2)
+	details = append(details, wrapText(poster.Location, lineChars(detailsScale), 2)...)
+	writeSVGLines(&b, details, posterWhenTop+8*detailsScale, 8*detailsScale, "#222222", "normal")
+	writeSVGLines(&b, []string{posterCaption}, posterCaptionTop+8*detailsScale, 8*detailsScale, brand, "bold")
+	b.WriteString("</g>\n")
+
+	// The QR code is one path of unit squares, scaled to size
+	scale := float64(posterQRSize) / float64(code.Size+2*posterQuietZone)
+	offset := float64(posterWidth-posterQRSize)/2 + scale*posterQuietZone
+	fmt.Fprintf(&b, `<path transform="translate(%.2f %.2f) scale(%.4f)" shape-rendering="crispEdges" fill="#000000" d="`, offset, float64(posterQRTop)+scale*posterQuietZone, scale)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Dark(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/>` + "\n</svg>\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// lineChars is how many glyphs fit across the poster at scale
+func lineChars(scale int) int {
+	return (posterWidth - 2*posterMargin) / (glyphAdvance * scale)
+}
+
+// lineHeight is the distance between the tops of lines at scale
+func lineHeight(scale int) int {
+	return (glyphHeight + 3) * scale
+}
+
+func drawCentred(img *image.RGBA, lines []string, top, scale int, c color.Color) {
+	for i, line := range lines {
+		x := (posterWidth - textWidth(line, scale)) / 2
+		drawText(img, line, x, top+i*lineHeight(scale), scale, c)
+	}
+}
+
+func writeSVGLines(b *strings.Builder, lines []string, baseline, size int, fill, weight string) {
+	for i, line := range lines {
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="%d" font-weight="%s" fill="%s">%s</text>`+"\n",
+			posterWidth/2, baseline+i*lineHeight(size/8), size, weight, fill, escapeXML(line))
+	}
+}
+
+// wrapText breaks text into at most maxLines lines of at most width
+// characters, at spaces where it can, ending with ... when it is cut short
+func wrapText(text string, width, maxLines int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > 0 {
+			if len(line) > 0 && len(line)+1+len(w) <= width {
+				line = append(append(line, ' '), w...)
+				w = nil
+				continue
+			}
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			n := min(len(w), width)
+			line, w = append(line, w[:n]...), w[n:]
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+
+	if len(lines) > maxLines {
+		last := []rune(lines[maxLines-1])
+		if len(last) > width-3 {
+			last = last[:width-3]
+		}
+		lines = append(lines[:maxLines-1], strings.TrimRight(string(last), " ")+"...")
+	}
+	return lines
+}
+
+func escapeXML(text string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
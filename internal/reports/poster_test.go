@@ -0,0 +1,69 @@
+package reports
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWritePoster(t *testing.T) {
+	poster := EventPoster{
+		ClubName:   "Classic Literature Club",
+		BrandColor: "#8B0000",
+		EventTitle: "Pride & Prejudice <night>",
+		EventDate:  "2024-03-14",
+		EventTime:  "19:00:00",
+		Location:   "Café Müller",
+		Link:       "https://bookwork.example/guest/events/token",
+	}
+
+	var raster bytes.Buffer
+	if err := WritePosterPNG(&raster, poster); err != nil {
+		t.Fatalf("Failed to write PNG poster: %v", err)
+	}
+	img, err := png.Decode(&raster)
+	if err != nil {
+		t.Fatalf("PNG poster should decode: %v", err)
+	}
+	if img.Bounds().Dx() != posterWidth || img.Bounds().Dy() != posterHeight {
+		t.Errorf("Unexpected poster size %v", img.Bounds())
+	}
+
+	var vector bytes.Buffer
+	if err := WritePosterSVG(&vector, poster); err != nil {
+		t.Fatalf("Failed to write SVG poster: %v", err)
+	}
+	svg := vector.String()
+	for _, want := range []string{"Pride &amp; Prejudice &lt;night&gt;", "Café Müller", "Thursday 14 March 2024 at 19:00", `fill="#8B0000"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG poster should contain %q", want)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text     string
+		width    int
+		maxLines int
+		want     []string
+	}{
+		{"Pride and Prejudice", 10, 3, []string{"Pride and", "Prejudice"}},
+		{"Supercalifragilistic", 8, 3, []string{"Supercal", "ifragili", "stic"}},
+		{"one two three four five", 9, 2, []string{"one two", "three..."}},
+		{"", 10, 2, nil},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.text, tt.width, tt.maxLines); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrapText(%q) = %q, expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRasterText(t *testing.T) {
+	if got := rasterText("Café “Müller” – 東京"); got != `Cafe "Muller" - ??` {
+		t.Errorf("Unexpected raster text %q", got)
+	}
+}
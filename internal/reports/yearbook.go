@@ -0,0 +1,134 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// YearbookSheet is a club's end-of-year report laid out for print
+type YearbookSheet struct {
+	ClubName    string
+	BrandColor  string
+	Year        int
+	GeneratedAt time.Time
+	Highlights  []YearbookStat // large figures under the title
+	Sections    []YearbookSection
+}
+
+// YearbookStat is one headline figure, such as "Events held: 24"
+type YearbookStat struct {
+	Label string
+	Value string
+}
+
+// YearbookSection is a titled table of the yearbook
+type YearbookSection struct {
+	Title   string
+	Columns []YearbookColumn
+	Rows    [][]string
+	Empty   string // shown instead of the table when there are no rows
+}
+
+// YearbookColumn is a table column; widths of a section should add up to 180mm
+type YearbookColumn struct {
+	Header string
+	Width  float64
+}
+
+// WriteYearbookPDF renders a branded yearbook: a cover band with the club and
+// year, the highlights, then each section as a table
+func WriteYearbookPDF(w io.Writer, sheet YearbookSheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetTitle(fmt.Sprintf("%s - %d Yearbook", sheet.ClubName, sheet.Year), true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	red, green, blue := ParseHexColor(sheet.BrandColor)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - Page %d", sheet.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+
+	// Cover band
+	pageWidth, pageHeight := pdf.GetPageSize()
+	pdf.SetFillColor(red, green, blue)
+	pdf.Rect(0, 0, pageWidth, 40, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(15, 10)
+	pdf.SetFont("Helvetica", "B", 22)
+	pdf.CellFormat(0, 10, truncate(pdf, tr(sheet.ClubName), pageWidth-30), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 14)
+	pdf.CellFormat(0, 8, strconv.Itoa(sheet.Year)+" Yearbook", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetY(48)
+
+	// Highlights, four to a row
+	const statWidth, statHeight = 45.0, 18.0
+	top := pdf.GetY()
+	for i, stat := range sheet.Highlights {
+		pdf.SetXY(15+float64(i%4)*statWidth, top+float64(i/4)*statHeight)
+		pdf.SetFont("Helvetica", "B", 18)
+		pdf.SetTextColor(red, green, blue)
+		pdf.CellFormat(statWidth, 9, truncate(pdf, tr(stat.Value), statWidth-2), "", 2, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(statWidth, 5, truncate(pdf, tr(stat.Label), statWidth-2), "", 0, "L", false, 0, "")
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(15, top+float64((len(sheet.Highlights)+3)/4)*statHeight)
+
+	for _, section := range sheet.Sections {
+		// Keep a section title with at least its header and first row
+		if pdf.GetY()+30 > pageHeight-15 {
+			pdf.AddPage()
+		}
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.SetTextColor(red, green, blue)
+		pdf.CellFormat(0, 8, tr(section.Title), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+
+		if len(section.Rows) == 0 {
+			pdf.SetFont("Helvetica", "I", 10)
+			pdf.CellFormat(0, 8, tr(section.Empty), "", 1, "L", false, 0, "")
+			continue
+		}
+
+		writeHeader := func() {
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.SetFillColor(230, 230, 230)
+			for _, col := range section.Columns {
+				pdf.CellFormat(col.Width, 7, tr(col.Header), "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+			pdf.SetFont("Helvetica", "", 10)
+		}
+
+		writeHeader()
+		for _, row := range section.Rows {
+			if pdf.GetY()+7 > pageHeight-15 {
+				pdf.AddPage()
+				writeHeader()
+			}
+			for i, col := range section.Columns {
+				value := ""
+				if i < len(row) {
+					value = row[i]
+				}
+				pdf.CellFormat(col.Width, 7, truncate(pdf, tr(value), col.Width-2), "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	return pdf.Output(w)
+}
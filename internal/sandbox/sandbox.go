@@ -0,0 +1,91 @@
+// Package sandbox purges expired sandbox accounts and clubs.
+//
+// Sandbox data is created by integrators testing against production and is
+// kept apart from real data; once sandbox_expires_at passes it is deleted,
+// and foreign key cascades remove memberships, events and items with it.
+// Contributions do not cascade, so those of sandbox events go first.
+package sandbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"bookwork-api/internal/database"
+)
+
+// Result counts the rows removed by a purge
+type Result struct {
+	Clubs int64
+	Users int64
+}
+
+// Purger periodically deletes expired sandbox data
+type Purger struct {
+	db       *database.DB
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewPurger(db *database.DB, interval time.Duration, logger *slog.Logger) *Purger {
+	return &Purger{db: db, interval: interval, logger: logger}
+}
+
+// Purge deletes expired sandbox clubs, then expired sandbox users
+func (p *Purger) Purge(ctx context.Context) (Result, error) {
+	var result Result
+
+	err := p.db.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM event_contributions
+			WHERE event_id IN (
+				SELECT e.id FROM events e JOIN clubs c ON c.id = e.club_id
+				WHERE c.is_sandbox = true AND c.sandbox_expires_at < NOW()
+			)`)
+		if err != nil {
+			return err
+		}
+		clubs, err := tx.ExecContext(ctx,
+			`DELETE FROM clubs WHERE is_sandbox = true AND sandbox_expires_at < NOW()`)
+		if err != nil {
+			return err
+		}
+		result.Clubs, _ = clubs.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to purge sandbox clubs: %w", err)
+	}
+
+	users, err := p.db.ExecContext(ctx,
+		`DELETE FROM users WHERE is_sandbox = true AND sandbox_expires_at < NOW()`)
+	if err != nil {
+		return result, fmt.Errorf("failed to purge sandbox users: %w", err)
+	}
+	result.Users, _ = users.RowsAffected()
+
+	return result, nil
+}
+
+// Run purges immediately and then every interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := p.Purge(ctx)
+		if err != nil {
+			p.logger.Error("error purging sandbox data", "error", err)
+		} else if result.Clubs > 0 || result.Users > 0 {
+			p.logger.Info("purged expired sandbox data", "clubs", result.Clubs, "users", result.Users)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package sanitize cleans free text from clients before it is stored, so text
+// echoed back to browsers cannot carry markup. Two policies exist: Text strips
+// every HTML tag, for titles, names and labels; Markdown also strips raw HTML
+// but keeps Markdown syntax, dropping links whose scheme is not on the
+// allowlist, for notes, descriptions and messages.
+//
+// Both are idempotent, so values already cleaned, like the current values a
+// merge patch is applied to, come out unchanged.
+package sanitize
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Policies, as named in sanitize struct tags
+const (
+	PolicyText     = "text"
+	PolicyMarkdown = "markdown"
+)
+
+// Apply cleans s with the named policy; unknown policies return s unchanged
+func Apply(policy, s string) string {
+	switch policy {
+	case PolicyText:
+		return Text(s)
+	case PolicyMarkdown:
+		return Markdown(s)
+	}
+	return s
+}
+
+var (
+	// Elements whose content is code rather than text, dropped with it
+	rawTextElement = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed|noscript|template|textarea|title|xmp)\b[^>]*>.*?(</(script|style|iframe|object|embed|noscript|template|textarea|title|xmp)\s*>|$)`)
+	comment        = regexp.MustCompile(`(?s)<!--.*?(-->|$)|<![^>]*(>|$)|<\?[^>]*(>|$)`)
+	// A tag name followed by attributes, to the closing > or, for a tag left
+	// open, to the end. "<https://…>" and "a <3 b" are not tags.
+	tag = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:[\s/][^>]*)?(?:>|$)`)
+)
+
+// Text strips HTML tags, comments and script content, and control characters
+// other than newlines and tabs. Text between tags is kept as typed.
+func Text(s string) string {
+	for {
+		cleaned := stripControl(stripHTML(s))
+		if cleaned == s {
+			return s
+		}
+		s = cleaned
+	}
+}
+
+var (
+	// [text](destination) and ![alt](destination)
+	inlineLink = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\(\s*([^)\s]*)[^)]*\)`)
+	// <scheme:…>
+	autolink = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*)>`)
+	// [label]: destination
+	linkDefinition = regexp.MustCompile(`(?m)^ {0,3}\[[^\]]+\]:[ \t]*(\S+).*$`)
+)
+
+// linkSchemes are the URL schemes Markdown links may use; links without a
+// scheme are relative and kept
+var linkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Markdown strips HTML like Text and drops links and images whose destination
+// has a scheme other than http, https or mailto, keeping the link text
+func Markdown(s string) string {
+	for {
+		cleaned := Text(s)
+		cleaned = inlineLink.ReplaceAllStringFunc(cleaned, func(link string) string {
+			parts := inlineLink.FindStringSubmatch(link)
+			if safeDestination(parts[3]) {
+				return link
+			}
+			return parts[2]
+		})
+		cleaned = autolink.ReplaceAllStringFunc(cleaned, func(link string) string {
+			if safeDestination(link[1 : len(link)-1]) {
+				return link
+			}
+			return link[1 : len(link)-1]
+		})
+		cleaned = linkDefinition.ReplaceAllStringFunc(cleaned, func(definition string) string {
+			if safeDestination(linkDefinition.FindStringSubmatch(definition)[1]) {
+				return definition
+			}
+			return ""
+		})
+		if cleaned == s {
+			return s
+		}
+		s = cleaned
+	}
+}
+
+// safeDestination reports whether a link destination is relative or uses an
+// allowed scheme. Renderers decode entities and ignore whitespace and control
+// characters in destinations, so "java&#x73;cript:" is caught too.
+func safeDestination(destination string) bool {
+	destination = strings.Trim(destination, "<>")
+	destination = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(destination))
+
+	scheme, _, found := strings.Cut(destination, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	return linkSchemes[strings.ToLower(scheme)]
+}
+
+func stripHTML(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	s = rawTextElement.ReplaceAllString(s, "")
+	s = comment.ReplaceAllString(s, "")
+	return tag.ReplaceAllString(s, "")
+}
+
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
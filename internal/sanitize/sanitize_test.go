@@ -0,0 +1,48 @@
+package sanitize
+
+import "testing"
+
+func TestText(t *testing.T) {
+	tests := map[string]string{
+		"The Left Hand of Darkness":                    "The Left Hand of Darkness",
+		"Tom & Jerry, 3 < 4, I <3 books":               "Tom & Jerry, 3 < 4, I <3 books",
+		"<b>Bold</b> move":                             "Bold move",
+		`Hi <img src=x onerror="alert(1)">there`:       "Hi there",
+		"Before<script>alert(1)</script>after":         "Beforeafter",
+		"<scr<script>x</script>ipt>alert(1)</script>":  "alert(1)",
+		"Note <!-- hidden --> shown":                   "Note  shown",
+		"Unclosed <a href='javascript:alert(1)' title": "Unclosed ",
+		"Line one\nLine\ttwo\x00\x07\r":                "Line one\nLine\ttwo",
+	}
+	for input, expected := range tests {
+		if got := Text(input); got != expected {
+			t.Errorf("Text(%q) = %q, expected %q", input, got, expected)
+		}
+		if got := Text(expected); got != expected {
+			t.Errorf("Text is not idempotent for %q: got %q", expected, got)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"**Bring** snacks, see [the list](https://bookwork.app/list)": "**Bring** snacks, see [the list](https://bookwork.app/list)",
+		"[relative](/clubs/1) and <https://bookwork.app>":             "[relative](/clubs/1) and <https://bookwork.app>",
+		"Mail [us](mailto:club@bookwork.app)":                         "Mail [us](mailto:club@bookwork.app)",
+		"[click](javascript:alert(1))":                                "click)",
+		"[click]( JaVaScRiPt:alert`1`)":                               "click",
+		"[click](java&#x73;cript:alert`1`)":                           "click",
+		"![pixel](data:image/svg+xml,evil)":                           "pixel",
+		"<javascript:alert`1`>":                                       "javascript:alert`1`",
+		"[ref]: vbscript:msgbox\n[ref]: https://bookwork.app":         "\n[ref]: https://bookwork.app",
+		"<div onclick=alert(1)>*Notes*</div>":                         "*Notes*",
+	}
+	for input, expected := range tests {
+		if got := Markdown(input); got != expected {
+			t.Errorf("Markdown(%q) = %q, expected %q", input, got, expected)
+		}
+		if got := Markdown(expected); got != expected {
+			t.Errorf("Markdown is not idempotent for %q: got %q", expected, got)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Package scaling audits whether the server can run as several instances
+// behind a load balancer without session affinity.
+//
+// Some features keep state in process memory. With several instances, each
+// keeps its own copy: for some that only narrows what an instance can see (a
+// debugging buffer, a cache), for others it breaks a guarantee (a rate limit
+// multiplied by the instance count, a queue lost with its instance). The audit
+// lists those features and the shared backend each would need; in clustered
+// mode the server refuses to start while any feature of the second kind is
+// still in process memory.
+package scaling
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"bookwork-api/internal/config"
+)
+
+// Mode is how the server is deployed
+type Mode string
+
+const (
+	Single    Mode = "single"    // one instance, or several with session affinity
+	Clustered Mode = "clustered" // several instances, any of which may serve any request
+)
+
+// ParseMode validates a configured deployment mode
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case Single, Clustered:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown deployment mode %q", s)
+}
+
+// Backend is shared infrastructure that replaces process memory
+type Backend string
+
+const (
+	Redis Backend = "redis" // shared counters and caches
+	NATS  Backend = "nats"  // shared queues and fan-out
+)
+
+// Impact is what goes wrong when a feature runs on several instances
+type Impact string
+
+const (
+	// Unsafe features lose a guarantee, such as a limit or a delivery
+	Unsafe Impact = "unsafe"
+	// Partial features keep working, each instance seeing only its own traffic
+	Partial Impact = "partial"
+)
+
+// Feature is a part of the server that may keep state in process memory
+type Feature struct {
+	Name   string  `json:"name"`
+	State  string  `json:"state"`  // what is kept
+	Impact Impact  `json:"impact"` // with several instances
+	Effect string  `json:"effect"` // with several instances
+	Needs  Backend `json:"needs,omitempty"`
+	Shared bool    `json:"shared"` // the state is already outside the process
+}
+
+// Features lists what the server runs with cfg
+func Features(cfg *config.Config) []Feature {
+	features := []Feature{
+		{
+			Name:   "rate limiter",
+			State:  "requests per client in the current window",
+			Impact: Unsafe,
+			Effect: "each instance allows the full limit, so clients get the limit times the number of instances",
+			Needs:  Redis,
+		},
+		{
+			Name:   "contact form limiter",
+			State:  "contact messages per client in the current hour",
+			Impact: Unsafe,
+			Effect: "each instance allows the full limit, so clients get the limit times the number of instances",
+			Needs:  Redis,
+		},
+		{
+			Name:   "password change limiter",
+			State:  "password changes per user in the current hour",
+			Impact: Unsafe,
+			Effect: "each instance allows the full limit, so users get the limit times the number of instances",
+			Needs:  Redis,
+		},
+		{
+			Name:   "token guard",
+			State:  "failed attempts per client and token on tokenized links",
+			Impact: Unsafe,
+			Effect: "guesses spread over instances are counted separately and lock out later, if at all",
+			Needs:  Redis,
+		},
+		{
+			Name:   "publisher quotas",
+			State:  "signed requests per publisher in the current minute",
+			Impact: Unsafe,
+			Effect: "each instance allows the full quota, so publishers get it times the number of instances",
+			Needs:  Redis,
+		},
+		{
+			Name:   "notification dispatcher",
+			State:  "queued push and email deliveries",
+			Impact: Unsafe,
+			Effect: "deliveries queued on an instance are lost when it stops; other instances cannot take them over",
+			Needs:  NATS,
+		},
+		{
+			Name:   "public response cache",
+			State:  "responses of public endpoints, until their TTL",
+			Impact: Partial,
+			Effect: "each instance caches separately, so hit rates are lower; entries expire by TTL either way",
+			Needs:  Redis,
+		},
+		{
+			Name:   "notification hub",
+			State:  "long polls waiting for their users' notifications",
+			Impact: Partial,
+			Effect: "notifications written through another instance reach a poll at its next recheck; each instance has its own poll limits",
+			Needs:  NATS,
+		},
+		{
+			Name:   "SSE hub",
+			State:  "open club change streams",
+			Impact: Partial,
+			Effect: "changes made through another instance reach a stream at its next recheck; each instance has its own stream limits",
+			Needs:  NATS,
+		},
+		{
+			Name:   "request recorder",
+			State:  "recent requests for support lookups",
+			Impact: Partial,
+			Effect: "a request ID is only found on the instance that served it",
+		},
+		{
+			Name:   "shadow reports",
+			State:  "dual writes, compared reads and mismatches of shadowed refactors since startup",
+			Impact: Partial,
+			Effect: "each instance reports only the reads it compared, so a refactor is checked instance by instance",
+		},
+		{
+			Name:   "network rules",
+			State:  "deny and admin allow lists",
+			Impact: Partial,
+			Effect: "runtime rules reach other instances at their next refresh",
+			Shared: true,
+		},
+		{
+			Name:   "yearbook compiler",
+			State:  "queued yearbook requests",
+			Impact: Partial,
+			Effect: "a request wakes only the instance that took it; others compile it at their next check",
+			Shared: true,
+		},
+		{
+			Name:   "data export compiler",
+			State:  "queued data export requests",
+			Impact: Partial,
+			Effect: "a request wakes only the instance that took it; others compile it at their next check",
+			Shared: true,
+		},
+	}
+
+	if cfg.Security.RoleCacheTTL > 0 {
+		features = append(features, Feature{
+			Name:   "role cache",
+			State:  "club roles checked by authorization, until their TTL",
+			Impact: Unsafe,
+			Effect: "a role changed or revoked on one instance is still honoured by the others until the TTL runs out",
+			Needs:  Redis,
+		})
+	}
+	if cfg.Analytics.UsageEnabled {
+		features = append(features, Feature{
+			Name:   "usage counter",
+			State:  "requests per route not yet flushed to the database",
+			Impact: Partial,
+			Effect: "each instance adds its own counts, so totals stay right; counts not yet flushed are lost if an instance dies without shutting down",
+		})
+	}
+	if cfg.Capture.Enabled {
+		features = append(features, Feature{
+			Name:   "traffic capture",
+			State:  "sampled request/response pairs",
+			Impact: Partial,
+			Effect: "each instance captures and serves only its own traffic",
+		})
+	}
+	return features
+}
+
+// Report is the result of an audit
+type Report struct {
+	Mode            Mode      `json:"mode"`
+	Ready           bool      `json:"ready"`           // safe to run in Mode
+	Blocking        []Feature `json:"blocking"`        // unsafe features still in process memory
+	Degraded        []Feature `json:"degraded"`        // partial features still in process memory
+	MissingBackends []Backend `json:"missingBackends"` // needed by blocking features and not configured
+}
+
+// Audit checks features against the deployment mode. configured lists the
+// backends with connection settings; a configured backend does not help a
+// feature that does not use it yet.
+func Audit(mode Mode, features []Feature, configured map[Backend]bool) Report {
+	report := Report{Mode: mode, Blocking: []Feature{}, Degraded: []Feature{}, MissingBackends: []Backend{}}
+
+	missing := map[Backend]bool{}
+	for _, feature := range features {
+		if feature.Shared {
+			continue
+		}
+		if feature.Impact == Unsafe {
+			report.Blocking = append(report.Blocking, feature)
+			if feature.Needs != "" && !configured[feature.Needs] {
+				missing[feature.Needs] = true
+			}
+		} else {
+			report.Degraded = append(report.Degraded, feature)
+		}
+	}
+	for backend := range missing {
+		report.MissingBackends = append(report.MissingBackends, backend)
+	}
+	sort.Slice(report.MissingBackends, func(i, j int) bool { return report.MissingBackends[i] < report.MissingBackends[j] })
+
+	report.Ready = mode == Single || len(report.Blocking) == 0
+	return report
+}
+
+// Log writes the report: details at debug level for a single instance, and
+// as warnings and errors in clustered mode
+func (r Report) Log(logger *slog.Logger) {
+	blockingLevel, degradedLevel := slog.LevelDebug, slog.LevelDebug
+	if r.Mode == Clustered {
+		blockingLevel, degradedLevel = slog.LevelError, slog.LevelWarn
+	}
+
+	for _, feature := range r.Blocking {
+		logger.Log(context.Background(), blockingLevel, "feature keeps state in process memory",
+			"feature", feature.Name, "impact", feature.Impact, "effect", feature.Effect, "needs", feature.Needs)
+	}
+	for _, feature := range r.Degraded {
+		logger.Log(context.Background(), degradedLevel, "feature keeps state in process memory",
+			"feature", feature.Name, "impact", feature.Impact, "effect", feature.Effect)
+	}
+	logger.Info("scaling audit",
+		"mode", r.Mode, "ready", r.Ready, "blocking", len(r.Blocking), "degraded", len(r.Degraded), "missing_backends", r.MissingBackends)
+}
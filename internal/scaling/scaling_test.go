@@ -0,0 +1,192 @@
+package scaling
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/config"
+)
+
+func TestParseMode(t *testing.T) {
+	if mode, err := ParseMode("clustered"); err != nil || mode != Clustered {
+		t.Errorf("Expected clustered, got %q, %v", mode, err)
+	}
+	if _, err := ParseMode("multi"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+func TestAuditClusteredReportsBlockingFeatures(t *testing.T) {
+	features := Features(&config.Config{})
+	report := Audit(Clustered, features, map[Backend]bool{NATS: true})
+
+	if report.Ready {
+		t.Fatal("Expected clustered mode not to be ready while limits are kept in memory")
+	}
+	if !reflect.DeepEqual(report.MissingBackends, []Backend{Redis}) {
+		t.Errorf("Expected only Redis to be missing, got %v", report.MissingBackends)
+	}
+	for _, feature := range report.Blocking {
+		if feature.Impact != Unsafe {
+			t.Errorf("Expected only unsafe features to block, got %+v", feature)
+		}
+	}
+	for _, feature := range append(report.Blocking, report.Degraded...) {
+		if feature.Shared {
+			t.Errorf("Expected shared features to be left out, got %+v", feature)
+		}
+	}
+}
+
+func TestAuditSingleIsAlwaysReady(t *testing.T) {
+	report := Audit(Single, Features(&config.Config{}), nil)
+	if !report.Ready || len(report.Blocking) == 0 {
+		t.Errorf("Expected a ready report still listing blocking features, got %+v", report)
+	}
+}
+
+func TestAuditClusteredWithPartialFeaturesOnly(t *testing.T) {
+	features := []Feature{
+		{Name: "cache", Impact: Partial, Needs: Redis},
+		{Name: "rules", Impact: Unsafe, Shared: true},
+	}
+	report := Audit(Clustered, features, nil)
+	if !report.Ready || len(report.Degraded) != 1 || len(report.MissingBackends) != 0 {
+		t.Errorf("Expected partial features not to block, got %+v", report)
+	}
+}
+
+func TestFeaturesIncludeCaptureWhenEnabled(t *testing.T) {
+	has := func(features []Feature, name string) bool {
+		for _, feature := range features {
+			if feature.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if has(Features(&config.Config{}), "traffic capture") {
+		t.Error("Expected traffic capture to be listed only when enabled")
+	}
+	if !has(Features(&config.Config{Capture: config.CaptureConfig{Enabled: true}}), "traffic capture") {
+		t.Error("Expected traffic capture to be listed when enabled")
+	}
+}
+
+func TestFeaturesIncludeRoleCacheWhenEnabled(t *testing.T) {
+	for _, feature := range Features(&config.Config{}) {
+		if feature.Name == "role cache" {
+			t.Fatal("Expected the role cache to be listed only when enabled")
+		}
+	}
+
+	for _, feature := range Features(&config.Config{Security: config.SecurityConfig{RoleCacheTTL: time.Minute}}) {
+		if feature.Name == "role cache" {
+			if feature.Impact != Unsafe {
+				t.Errorf("Expected the role cache to be unsafe when clustered, got %s", feature.Impact)
+			}
+			return
+		}
+	}
+	t.Error("Expected the role cache to be listed when enabled")
+}
+
+// inProcess maps each constructor in cmd/api that keeps per-process state to
+// the features its calls there create, in any order
+var inProcess = map[string][]string{
+	"customMiddleware.NewRateLimiter":     {"rate limiter", "contact form limiter", "password change limiter"},
+	"customMiddleware.NewTokenGuard":      {"token guard"},
+	"customMiddleware.NewResponseCache":   {"public response cache"},
+	"customMiddleware.NewRequestRecorder": {"request recorder"},
+	"publisher.NewRegistry":               {"publisher quotas"},
+	"notify.NewDispatcher":                {"notification dispatcher"},
+	"notify.NewHub":                       {"notification hub", "SSE hub"},
+	"netacl.New":                          {"network rules"},
+	"yearbook.NewCompiler":                {"yearbook compiler"},
+	"dataexport.NewCompiler":              {"data export compiler"},
+	"store.NewCachedClubs":                {"role cache"},
+	"store.NewCachedClubRoles":            {"role cache"},
+	"analytics.NewUsageCounter":           {"usage counter"},
+	"capture.NewRecorder":                 {"traffic capture"},
+	"shadow.NewRegistry":                  {"shadow reports"},
+}
+
+// stateless lists constructors in cmd/api whose state, if any, is outside the
+// process or belongs to the instance itself, such as its health and shutdown
+var stateless = map[string]bool{
+	"analytics.NewRefresher": true, "analytics.NewStore": true, "archive.NewArchiver": true,
+	"attachments.NewLocalStorage": true, "attachments.NewS3Storage": true, "audit.NewLog": true,
+	"auth.NewKeyRing": true, "auth.NewService": true, "authz.New": true, "availability.NewRepairer": true,
+	"avatars.NewStore": true, "bounces.NewStore": true, "captcha.New": true, "changes.NewLog": true,
+	"chi.NewRouter": true, "contributions.NewLedger": true, "corrections.NewStore": true,
+	"database.New": true, "database.NewMock": true, "dataexport.NewLinks": true, "dataexport.NewStore": true,
+	"deliveryhealth.NewStore": true, "dues.NewLedger": true, "errors.New": true, "health.NewChecker": true,
+	"integrations.NewPoster": true, "integrations.NewReminder": true, "integrations.NewStore": true,
+	"json.NewEncoder": true, "lifecycle.New": true, "logging.New": true, "migrations.NewMigrator": true,
+	"notify.NewFeed": true, "notify.NewNotifier": true, "oauth.NewGitHub": true, "oauth.NewGoogle": true,
+	"oauth.NewRegistry": true, "outbox.NewPoller": true, "partnerships.NewStore": true, "polls.NewCloser": true,
+	"posters.NewLinks": true, "posters.NewStore": true, "recommend.NewJob": true, "sandbox.NewPurger": true,
+	"signedurl.NewSigner": true, "store.NewMemory": true, "store.NewPostgres": true,
+	"tags.NewStore": true, "templates.NewStore": true, "tlsserve.New": true, "vocab.NewStore": true,
+	"webhooks.NewDeliverer": true, "webhooks.NewStore": true, "yearbook.NewLinks": true, "yearbook.NewStore": true,
+}
+
+// TestFeaturesListEveryInProcessComponent fails when cmd/api constructs a
+// component that Features does not account for. Handlers only hold what they
+// are given; anything else must be listed in inProcess or stateless.
+func TestFeaturesListEveryInProcessComponent(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../../cmd/api/main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse cmd/api: %v", err)
+	}
+
+	calls := map[string]int{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !strings.HasPrefix(selector.Sel.Name, "New") {
+			return true
+		}
+		if pkg, ok := selector.X.(*ast.Ident); ok && pkg.Name != "handlers" {
+			calls[pkg.Name+"."+selector.Sel.Name]++
+		}
+		return true
+	})
+
+	listed := map[string]bool{}
+	for _, feature := range Features(&config.Config{
+		Security:  config.SecurityConfig{RoleCacheTTL: time.Minute},
+		Analytics: config.AnalyticsConfig{UsageEnabled: true},
+		Capture:   config.CaptureConfig{Enabled: true},
+	}) {
+		listed[feature.Name] = true
+	}
+
+	for constructor, count := range calls {
+		if stateless[constructor] {
+			continue
+		}
+		names, ok := inProcess[constructor]
+		if !ok {
+			t.Errorf("cmd/api calls %s; list its per-process state in Features and inProcess, or mark it stateless", constructor)
+			continue
+		}
+		if count > len(names) {
+			t.Errorf("cmd/api calls %s %d times but only %d features are listed for it", constructor, count, len(names))
+		}
+		for _, name := range names {
+			if !listed[name] {
+				t.Errorf("Expected Features to list %q, created by %s", name, constructor)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package shadow supports incremental schema refactors by writing both the old
+// and the new representation of a value and comparing them on read.
+//
+// A refactor moves through modes, set per deployment:
+//
+//	off          only the old columns are used
+//	dual_write   writes fill both; reads use the old columns
+//	shadow_read  as dual_write, and reads compare the new columns against the old
+//	cutover      reads use the new columns and compare them against the old
+//
+// Mismatches are counted, logged and kept as recent samples so a refactor can
+// be cut over once it has run clean, and rolled back if it does not.
+package shadow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bookwork-api/internal/logging"
+)
+
+// Mode is the phase a refactor is in
+type Mode string
+
+const (
+	Off        Mode = "off"
+	DualWrite  Mode = "dual_write"
+	ShadowRead Mode = "shadow_read"
+	Cutover    Mode = "cutover"
+)
+
+// maxSamples is the number of recent mismatches kept per refactor
+const maxSamples = 20
+
+// ParseMode validates a configured mode
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case Off, DualWrite, ShadowRead, Cutover:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown shadow mode %q", s)
+}
+
+// Mismatch is one read where the old and new representations disagreed. New
+// is empty when the new columns had not been written.
+type Mismatch struct {
+	Key string    `json:"key"`
+	Old string    `json:"old"`
+	New string    `json:"new"`
+	At  time.Time `json:"at"`
+}
+
+// Stats reports a refactor's mode and counters since startup
+type Stats struct {
+	Name       string     `json:"name"`
+	Mode       Mode       `json:"mode"`
+	Writes     int64      `json:"writes"`
+	Reads      int64      `json:"reads"` // compared reads
+	Mismatches int64      `json:"mismatches"`
+	Missing    int64      `json:"missing"` // compared reads where the new columns were empty
+	Recent     []Mismatch `json:"recentMismatches"`
+}
+
+// Refactor tracks one schema refactor. A nil *Refactor behaves as Off, so
+// handlers without one configured keep using the old columns only.
+type Refactor struct {
+	name string
+	mode Mode
+
+	writes     atomic.Int64
+	reads      atomic.Int64
+	mismatches atomic.Int64
+	missing    atomic.Int64
+
+	mu      sync.Mutex
+	samples []Mismatch
+}
+
+// Name is the name the refactor was registered under
+func (r *Refactor) Name() string {
+	if r == nil {
+		return ""
+	}
+	return r.name
+}
+
+// Mode is the phase the refactor is in
+func (r *Refactor) Mode() Mode {
+	if r == nil {
+		return Off
+	}
+	return r.mode
+}
+
+// Writes reports whether the new columns should be written
+func (r *Refactor) Writes() bool {
+	return r != nil && r.mode != Off
+}
+
+// Compares reports whether reads should compare the old and new columns
+func (r *Refactor) Compares() bool {
+	return r != nil && (r.mode == ShadowRead || r.mode == Cutover)
+}
+
+// ReadsNew reports whether reads should be served from the new columns
+func (r *Refactor) ReadsNew() bool {
+	return r != nil && r.mode == Cutover
+}
+
+// RecordWrite counts a write to the new columns
+func (r *Refactor) RecordWrite() {
+	if r != nil {
+		r.writes.Add(1)
+	}
+}
+
+// RecordMissing counts a compared read where the new columns were empty,
+// typically a row written before dual writes began and not yet backfilled
+func (r *Refactor) RecordMissing(ctx context.Context, key string, old interface{}) {
+	if r == nil {
+		return
+	}
+	r.reads.Add(1)
+	r.missing.Add(1)
+	r.sample(ctx, Mismatch{Key: key, Old: fmt.Sprint(old), At: time.Now()})
+}
+
+// Compare counts a read of key and reports whether old and new agree
+func Compare[T any](ctx context.Context, r *Refactor, key string, old, new T, equal func(a, b T) bool) bool {
+	if r == nil {
+		return true
+	}
+	r.reads.Add(1)
+	if equal(old, new) {
+		return true
+	}
+	r.mismatches.Add(1)
+	r.sample(ctx, Mismatch{Key: key, Old: fmt.Sprint(old), New: fmt.Sprint(new), At: time.Now()})
+	return false
+}
+
+func (r *Refactor) sample(ctx context.Context, m Mismatch) {
+	logging.FromContext(ctx).Warn("shadow read mismatch",
+		"refactor", r.name, "mode", r.mode, "key", m.Key, "old", m.Old, "new", m.New)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.samples) == maxSamples {
+		r.samples = r.samples[1:]
+	}
+	r.samples = append(r.samples, m)
+}
+
+// Stats returns the refactor's counters and recent mismatches, newest first
+func (r *Refactor) Stats() Stats {
+	r.mu.Lock()
+	recent := make([]Mismatch, len(r.samples))
+	for i, m := range r.samples {
+		recent[len(r.samples)-1-i] = m
+	}
+	r.mu.Unlock()
+
+	return Stats{
+		Name:       r.name,
+		Mode:       r.mode,
+		Writes:     r.writes.Load(),
+		Reads:      r.reads.Load(),
+		Mismatches: r.mismatches.Load(),
+		Missing:    r.missing.Load(),
+		Recent:     recent,
+	}
+}
+
+// Registry holds the refactors in progress so they can be reported together
+type Registry struct {
+	mu        sync.Mutex
+	refactors map[string]*Refactor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{refactors: make(map[string]*Refactor)}
+}
+
+// Register starts tracking a refactor in the given mode
+func (reg *Registry) Register(name string, mode Mode) *Refactor {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	r := &Refactor{name: name, mode: mode}
+	reg.refactors[name] = r
+	return r
+}
+
+// Stats reports every registered refactor, by name
+func (reg *Registry) Stats() []Stats {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	stats := make([]Stats, 0, len(reg.refactors))
+	for _, r := range reg.refactors {
+		stats = append(stats, r.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,91 @@
+package shadow
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"off", "dual_write", "shadow_read", "cutover"} {
+		if mode, err := ParseMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseMode("on"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+func TestModes(t *testing.T) {
+	reg := NewRegistry()
+
+	tests := []struct {
+		refactor                  *Refactor
+		writes, compares, readNew bool
+	}{
+		{nil, false, false, false},
+		{reg.Register("off", Off), false, false, false},
+		{reg.Register("dual", DualWrite), true, false, false},
+		{reg.Register("shadow", ShadowRead), true, true, false},
+		{reg.Register("cutover", Cutover), true, true, true},
+	}
+
+	for i, tt := range tests {
+		if tt.refactor.Writes() != tt.writes || tt.refactor.Compares() != tt.compares || tt.refactor.ReadsNew() != tt.readNew {
+			t.Errorf("Case %d: unexpected Writes/Compares/ReadsNew %v/%v/%v", i,
+				tt.refactor.Writes(), tt.refactor.Compares(), tt.refactor.ReadsNew())
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	reg := NewRegistry()
+	r := reg.Register("events.starts_at", ShadowRead)
+	ctx := context.Background()
+	at := time.Date(2030, 1, 15, 19, 30, 0, 0, time.UTC)
+
+	r.RecordWrite()
+	if !Compare(ctx, r, "a", at, at.In(time.FixedZone("", 0)), time.Time.Equal) {
+		t.Error("Expected equal instants in different locations to match")
+	}
+	if Compare(ctx, r, "b", at, at.Add(time.Hour), time.Time.Equal) {
+		t.Error("Expected different instants to mismatch")
+	}
+	r.RecordMissing(ctx, "c", at)
+
+	stats := reg.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected one refactor, got %d", len(stats))
+	}
+	s := stats[0]
+	if s.Writes != 1 || s.Reads != 3 || s.Mismatches != 1 || s.Missing != 1 {
+		t.Errorf("Unexpected counters: %+v", s)
+	}
+	if len(s.Recent) != 2 || s.Recent[0].Key != "c" || s.Recent[0].New != "" || s.Recent[1].Key != "b" {
+		t.Errorf("Expected the newest mismatches first, got %+v", s.Recent)
+	}
+
+	// A nil refactor never compares or records
+	if !Compare(ctx, (*Refactor)(nil), "d", 1, 2, func(a, b int) bool { return a == b }) {
+		t.Error("Expected a nil refactor to report a match")
+	}
+}
+
+func TestSamplesAreBounded(t *testing.T) {
+	r := NewRegistry().Register("events.starts_at", Cutover)
+	ctx := context.Background()
+
+	for i := 0; i < maxSamples+5; i++ {
+		Compare(ctx, r, strconv.Itoa(i), i, -1, func(a, b int) bool { return a == b })
+	}
+
+	stats := r.Stats()
+	if stats.Mismatches != maxSamples+5 || len(stats.Recent) != maxSamples {
+		t.Errorf("Expected %d mismatches and %d samples, got %d and %d", maxSamples+5, maxSamples, stats.Mismatches, len(stats.Recent))
+	}
+	if stats.Recent[0].Key != strconv.Itoa(maxSamples+4) {
+		t.Errorf("Expected the newest sample first, got %s", stats.Recent[0].Key)
+	}
+}
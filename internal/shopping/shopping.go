@@ -0,0 +1,174 @@
+// Package shopping consolidates an event's food items into one shopping list.
+//
+// Items with the same name and unit (case-insensitive) become one line with
+// their quantities added up; items without a quantity count as one. Costs are
+// converted to the club currency with each item's snapshotted exchange rate,
+// added up in minor units and split evenly among the participants, with leftover
+// units going to the first participants so the shares always add up to the total.
+package shopping
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// ErrMissingRate is returned for a cost in another currency without an exchange rate snapshot
+var ErrMissingRate = errors.New("item cost has no exchange rate to the club currency")
+
+// Category is the item category that goes on the shopping list
+const Category = "food"
+
+// Build returns the shopping list for an event with costs in currency, the club
+// currency. assignedTo is the shopper for the whole list, who buys every item
+// without an assignee of its own. participants are the people sharing the cost,
+// usually the attendees; everyone who pays for an item shares the cost too.
+func Build(eventID uuid.UUID, items []models.EventItem, assignedTo *uuid.UUID, participants []uuid.UUID, currency string) (models.ShoppingList, error) {
+	list := models.ShoppingList{
+		EventID:    eventID,
+		AssignedTo: assignedTo,
+		Currency:   currency,
+		Lines:      []models.ShoppingListLine{},
+		Assignees:  []models.ShoppingAssignee{},
+		CostSplit:  []models.CostShare{},
+	}
+
+	lines := make(map[string]int)
+	lineCents := []int64{}
+	assignees := make(map[uuid.UUID]int)
+	assigneeCents := []int64{}
+	unassigned := -1
+	var totalCents int64
+
+	for _, item := range items {
+		if item.Category != Category || item.Status == "cancelled" {
+			continue
+		}
+
+		quantity := 1.0
+		if item.Quantity != nil {
+			quantity = *item.Quantity
+		}
+		var cents int64
+		if item.Cost != nil {
+			cost, err := inCurrency(item, currency)
+			if err != nil {
+				return models.ShoppingList{}, err
+			}
+			cents = cost.Minor
+		}
+		totalCents += cents
+
+		// Merge into the line with the same name and unit
+		name := strings.TrimSpace(item.Name)
+		unit := ""
+		if item.Unit != nil {
+			unit = strings.TrimSpace(*item.Unit)
+		}
+		key := strings.ToLower(name) + "\x00" + strings.ToLower(unit)
+		i, ok := lines[key]
+		if !ok {
+			i = len(list.Lines)
+			lines[key] = i
+			list.Lines = append(list.Lines, models.ShoppingListLine{Name: name, Unit: unit, ItemIDs: []uuid.UUID{}, Completed: true})
+			lineCents = append(lineCents, 0)
+		}
+		line := &list.Lines[i]
+		line.Quantity += quantity
+		line.ItemIDs = append(line.ItemIDs, item.ID)
+		line.Completed = line.Completed && item.Status == "completed"
+		lineCents[i] += cents
+
+		// Attribute the item to its assignee, falling back to the list's shopper
+		buyer := item.AssignedTo
+		if buyer == nil {
+			buyer = assignedTo
+		}
+		var j int
+		if buyer == nil {
+			if unassigned < 0 {
+				unassigned = len(list.Assignees)
+				list.Assignees = append(list.Assignees, models.ShoppingAssignee{ItemIDs: []uuid.UUID{}})
+				assigneeCents = append(assigneeCents, 0)
+			}
+			j = unassigned
+		} else {
+			if j, ok = assignees[*buyer]; !ok {
+				j = len(list.Assignees)
+				assignees[*buyer] = j
+				userID := *buyer
+				list.Assignees = append(list.Assignees, models.ShoppingAssignee{UserID: &userID, ItemIDs: []uuid.UUID{}})
+				assigneeCents = append(assigneeCents, 0)
+			}
+		}
+		list.Assignees[j].ItemIDs = append(list.Assignees[j].ItemIDs, item.ID)
+		assigneeCents[j] += cents
+	}
+
+	for i := range list.Lines {
+		list.Lines[i].Quantity = math.Round(list.Lines[i].Quantity*100) / 100
+		list.Lines[i].Cost = money.New(lineCents[i], currency)
+	}
+	for j := range list.Assignees {
+		list.Assignees[j].Cost = money.New(assigneeCents[j], currency)
+	}
+	list.TotalCost = money.New(totalCents, currency)
+
+	list.CostSplit = split(list.TotalCost, list.Assignees, assigneeCents, participants)
+	return list, nil
+}
+
+// inCurrency converts an item's cost to currency with the rate snapshotted when the cost was set
+func inCurrency(item models.EventItem, currency string) (money.Money, error) {
+	if item.Cost.Currency == currency {
+		return *item.Cost, nil
+	}
+	if item.ExchangeRate == nil {
+		return money.Money{}, ErrMissingRate
+	}
+	return item.Cost.Convert(*item.ExchangeRate, currency)
+}
+
+// split shares total evenly among the buyers and participants
+func split(total money.Money, assignees []models.ShoppingAssignee, assigneeCents []int64, participants []uuid.UUID) []models.CostShare {
+	paid := make(map[uuid.UUID]int64)
+	var people []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for j, assignee := range assignees {
+		if assignee.UserID == nil {
+			continue
+		}
+		paid[*assignee.UserID] = assigneeCents[j]
+		if !seen[*assignee.UserID] {
+			seen[*assignee.UserID] = true
+			people = append(people, *assignee.UserID)
+		}
+	}
+	for _, userID := range participants {
+		if !seen[userID] {
+			seen[userID] = true
+			people = append(people, userID)
+		}
+	}
+
+	shares := []models.CostShare{}
+	if total.Minor == 0 || len(people) == 0 {
+		return shares
+	}
+
+	for i, share := range total.Allocate(len(people)) {
+		userID := people[i]
+		shares = append(shares, models.CostShare{
+			UserID:  userID,
+			Paid:    money.New(paid[userID], total.Currency),
+			Share:   share,
+			Balance: money.New(paid[userID]-share.Minor, total.Currency),
+		})
+	}
+	return shares
+}
@@ -0,0 +1,125 @@
+package shopping
+
+import (
+	"testing"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func usd(minor int64) *money.Money {
+	m := money.New(minor, "USD")
+	return &m
+}
+
+func TestBuildConsolidatesFoodItems(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	items := []models.EventItem{
+		{ID: uuid.New(), Name: "Apples", Category: "food", Quantity: ptr(2.0), Unit: ptr("kg"), Cost: usd(450), AssignedTo: &alice, Status: "completed"},
+		{ID: uuid.New(), Name: "apples ", Category: "food", Quantity: ptr(1.5), Unit: ptr("KG"), Cost: usd(300), Status: "pending"},
+		{ID: uuid.New(), Name: "Apples", Category: "food", Status: "pending"}, // no unit: separate line, counts as one
+		{ID: uuid.New(), Name: "Cider", Category: "food", Cost: usd(600), AssignedTo: &bob, Status: "completed"},
+		{ID: uuid.New(), Name: "Name tags", Category: "materials"},
+		{ID: uuid.New(), Name: "Cake", Category: "food", Cost: usd(2000), Status: "cancelled"},
+	}
+
+	list, err := Build(uuid.New(), items, nil, nil, "USD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(list.Lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %+v", list.Lines)
+	}
+	apples := list.Lines[0]
+	if apples.Name != "Apples" || apples.Unit != "kg" || apples.Quantity != 3.5 || apples.Cost.Minor != 750 || len(apples.ItemIDs) != 2 || apples.Completed {
+		t.Errorf("Unexpected merged line: %+v", apples)
+	}
+	if list.Lines[1].Quantity != 1 || list.Lines[1].Unit != "" {
+		t.Errorf("Expected item without quantity to count as one, got %+v", list.Lines[1])
+	}
+	if !list.Lines[2].Completed {
+		t.Errorf("Expected line with only completed items to be completed, got %+v", list.Lines[2])
+	}
+	if list.TotalCost != money.New(1350, "USD") {
+		t.Errorf("Expected total cost 13.50, got %v", list.TotalCost)
+	}
+
+	// Alice, the unassigned items, then Bob, in order of first appearance
+	if len(list.Assignees) != 3 || *list.Assignees[0].UserID != alice || list.Assignees[1].UserID != nil || *list.Assignees[2].UserID != bob {
+		t.Fatalf("Unexpected assignees: %+v", list.Assignees)
+	}
+	if list.Assignees[1].Cost.Minor != 300 || len(list.Assignees[1].ItemIDs) != 2 {
+		t.Errorf("Unexpected unassigned breakdown: %+v", list.Assignees[1])
+	}
+}
+
+func TestBuildAssignsWholeListToShopper(t *testing.T) {
+	shopper, helper := uuid.New(), uuid.New()
+	items := []models.EventItem{
+		{ID: uuid.New(), Name: "Bread", Category: "food", Cost: usd(300)},
+		{ID: uuid.New(), Name: "Cheese", Category: "food", Cost: usd(700), AssignedTo: &helper},
+	}
+
+	list, _ := Build(uuid.New(), items, &shopper, nil, "USD")
+
+	if len(list.Assignees) != 2 || *list.Assignees[0].UserID != shopper || *list.Assignees[1].UserID != helper {
+		t.Errorf("Expected unassigned items to go to the shopper, got %+v", list.Assignees)
+	}
+}
+
+func TestBuildSplitsCostEvenly(t *testing.T) {
+	payer, guest1, guest2 := uuid.New(), uuid.New(), uuid.New()
+	items := []models.EventItem{
+		{ID: uuid.New(), Name: "Pizza", Category: "food", Cost: usd(1000), AssignedTo: &payer},
+	}
+
+	// The payer is also listed as an attendee and must only be counted once
+	list, _ := Build(uuid.New(), items, nil, []uuid.UUID{guest1, payer, guest2}, "USD")
+
+	if len(list.CostSplit) != 3 {
+		t.Fatalf("Expected 3 shares, got %+v", list.CostSplit)
+	}
+
+	var total, balance int64
+	for _, share := range list.CostSplit {
+		total += share.Share.Minor
+		balance += share.Balance.Minor
+	}
+	if total != 1000 {
+		t.Errorf("Expected shares to add up to the total, got %d", total)
+	}
+	if balance != 0 {
+		t.Errorf("Expected balances to cancel out, got %d", balance)
+	}
+
+	first := list.CostSplit[0]
+	if first.UserID != payer || first.Paid.Minor != 1000 || first.Share.Minor != 334 || first.Balance.Minor != 666 {
+		t.Errorf("Unexpected payer share: %+v", first)
+	}
+}
+
+func TestBuildConvertsToClubCurrency(t *testing.T) {
+	eur := money.New(1000, "EUR")
+	items := []models.EventItem{
+		{ID: uuid.New(), Name: "Wine", Category: "food", Cost: &eur, ExchangeRate: ptr("1.10")},
+		{ID: uuid.New(), Name: "Bread", Category: "food", Cost: usd(250)},
+	}
+
+	list, err := Build(uuid.New(), items, nil, nil, "USD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if list.Currency != "USD" || list.TotalCost != money.New(1350, "USD") {
+		t.Errorf("Expected 13.50 USD, got %+v", list.TotalCost)
+	}
+
+	items[0].ExchangeRate = nil
+	if _, err := Build(uuid.New(), items, nil, nil, "USD"); err != ErrMissingRate {
+		t.Errorf("Expected ErrMissingRate, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package signedurl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signer := NewSigner("test-secret", "test-purpose")
+	now := time.Now()
+
+	token := signer.Sign("subject-123", now.Add(time.Hour))
+
+	subject, expiresAt, err := signer.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+	if subject != "subject-123" {
+		t.Errorf("Expected subject subject-123, got %s", subject)
+	}
+	if expiresAt.Unix() != now.Add(time.Hour).Unix() {
+		t.Errorf("Expected expiry %v, got %v", now.Add(time.Hour), expiresAt)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	signer := NewSigner("test-secret", "test-purpose")
+	now := time.Now()
+
+	token := signer.Sign("subject-123", now.Add(-time.Minute))
+
+	if _, _, err := signer.Verify(token, now); err != ErrExpired {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+}
+
+func TestVerifyRejectsOtherSigners(t *testing.T) {
+	now := time.Now()
+	token := NewSigner("test-secret", "purpose-a").Sign("subject-123", now.Add(time.Hour))
+
+	if _, _, err := NewSigner("test-secret", "purpose-b").Verify(token, now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for another purpose, got %v", err)
+	}
+	if _, _, err := NewSigner("other-secret", "purpose-a").Verify(token, now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for another secret, got %v", err)
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	signer := NewSigner("test-secret", "test-purpose")
+
+	for _, token := range []string{"", "no-dot", "!!!.???", "YWJj.YWJj"} {
+		if _, _, err := signer.Verify(token, time.Now()); err == nil {
+			t.Errorf("Expected error for token %q", token)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"bookwork-api/internal/cache"
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// memberKey is one user's membership of one club
+type memberKey struct {
+	clubID uuid.UUID
+	userID uuid.UUID
+}
+
+// CachedClubs is a ClubStore that remembers member roles, which authorization
+// looks up on every club request. Not being a member is remembered too, as an
+// empty role. Whoever changes a membership must Forget it; anything changed
+// without that, such as by another instance, is seen once the TTL runs out.
+type CachedClubs struct {
+	ClubStore
+	roles *cache.LRU[memberKey, string]
+}
+
+// NewCachedClubs caches up to size roles from clubs for ttl each
+func NewCachedClubs(clubs ClubStore, size int, ttl time.Duration) *CachedClubs {
+	return &CachedClubs{ClubStore: clubs, roles: cache.NewLRU[memberKey, string](size, ttl)}
+}
+
+func (c *CachedClubs) MemberRole(ctx context.Context, clubID, userID uuid.UUID) (string, error) {
+	key := memberKey{clubID: clubID, userID: userID}
+	if role, ok := c.roles.Get(key); ok {
+		if role == "" {
+			return "", ErrNotFound
+		}
+		return role, nil
+	}
+
+	role, err := c.ClubStore.MemberRole(ctx, clubID, userID)
+	if err != nil && err != ErrNotFound {
+		return "", err
+	}
+	c.roles.Set(key, role)
+	return role, err
+}
+
+// Forget drops the cached role of a user in a club
+func (c *CachedClubs) Forget(clubID, userID uuid.UUID) {
+	c.roles.Delete(memberKey{clubID: clubID, userID: userID})
+}
+
+// ForgetClub drops every cached role in a club, for changes made by member
+// ID or to the club as a whole
+func (c *CachedClubs) ForgetClub(clubID uuid.UUID) {
+	c.roles.DeleteFunc(func(key memberKey) bool { return key.clubID == clubID })
+}
+
+// roleKey is one custom role of one club
+type roleKey struct {
+	clubID uuid.UUID
+	name   string
+}
+
+// CachedClubRoles is a ClubRoleStore that remembers custom roles, which
+// authorization looks up on every club request by a member holding one. A
+// missing role is remembered too, as nil. Changes made through it drop the
+// club's roles; changes by another instance are seen once the TTL runs out.
+type CachedClubRoles struct {
+	ClubRoleStore
+	roles   *cache.LRU[roleKey, *models.ClubRole]
+	members *CachedClubs
+}
+
+// NewCachedClubRoles caches up to size roles from roles for ttl each
+func NewCachedClubRoles(roles ClubRoleStore, size int, ttl time.Duration) *CachedClubRoles {
+	return &CachedClubRoles{ClubRoleStore: roles, roles: cache.NewLRU[roleKey, *models.ClubRole](size, ttl)}
+}
+
+// WithMembers drops the club's cached member roles from members when a role
+// is deleted, as deleting one gives its holders the member role
+func (c *CachedClubRoles) WithMembers(members *CachedClubs) *CachedClubRoles {
+	c.members = members
+	return c
+}
+
+func (c *CachedClubRoles) Get(ctx context.Context, clubID uuid.UUID, name string) (*models.ClubRole, error) {
+	key := roleKey{clubID: clubID, name: name}
+	if role, ok := c.roles.Get(key); ok {
+		if role == nil {
+			return nil, ErrNotFound
+		}
+		copied := *role
+		return &copied, nil
+	}
+
+	role, err := c.ClubRoleStore.Get(ctx, clubID, name)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	c.roles.Set(key, role)
+	return role, err
+}
+
+func (c *CachedClubRoles) Create(ctx context.Context, role *models.ClubRole) error {
+	defer c.forget(role.ClubID)
+	return c.ClubRoleStore.Create(ctx, role)
+}
+
+func (c *CachedClubRoles) Update(ctx context.Context, clubID, roleID uuid.UUID, capabilities []string) (*models.ClubRole, error) {
+	defer c.forget(clubID)
+	return c.ClubRoleStore.Update(ctx, clubID, roleID, capabilities)
+}
+
+func (c *CachedClubRoles) Delete(ctx context.Context, clubID, roleID uuid.UUID) error {
+	defer c.forget(clubID)
+	if err := c.ClubRoleStore.Delete(ctx, clubID, roleID); err != nil {
+		return err
+	}
+	if c.members != nil {
+		c.members.ForgetClub(clubID)
+	}
+	return nil
+}
+
+func (c *CachedClubRoles) forget(clubID uuid.UUID) {
+	c.roles.DeleteFunc(func(key roleKey) bool { return key.clubID == clubID })
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// countingClubs counts the role lookups that reach the wrapped store
+type countingClubs struct {
+	ClubStore
+	lookups int
+}
+
+func (c *countingClubs) MemberRole(ctx context.Context, clubID, userID uuid.UUID) (string, error) {
+	c.lookups++
+	return c.ClubStore.MemberRole(ctx, clubID, userID)
+}
+
+func TestCachedClubsMemberRole(t *testing.T) {
+	mem := NewMemory()
+	clubID, memberID, strangerID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: memberID, Role: "moderator", IsActive: true})
+
+	counting := &countingClubs{ClubStore: mem.Stores().Clubs}
+	cached := NewCachedClubs(counting, 100, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if role, err := cached.MemberRole(ctx, clubID, memberID); err != nil || role != "moderator" {
+			t.Fatalf("Expected moderator, got %q, %v", role, err)
+		}
+		if _, err := cached.MemberRole(ctx, clubID, strangerID); err != ErrNotFound {
+			t.Fatalf("Expected ErrNotFound for a non-member, got %v", err)
+		}
+	}
+	if counting.lookups != 2 {
+		t.Errorf("Expected one lookup per membership, got %d", counting.lookups)
+	}
+}
+
+func TestCachedClubsForget(t *testing.T) {
+	mem := NewMemory()
+	clubID, memberID, otherID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: memberID, Role: "member", IsActive: true})
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: otherID, Role: "member", IsActive: true})
+
+	cached := NewCachedClubs(mem.Stores().Clubs, 100, time.Minute)
+	ctx := context.Background()
+	cached.MemberRole(ctx, clubID, memberID)
+	cached.MemberRole(ctx, clubID, otherID)
+
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: memberID, Role: "moderator", IsActive: true})
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: otherID, Role: "member", IsActive: false})
+
+	if role, _ := cached.MemberRole(ctx, clubID, memberID); role != "member" {
+		t.Fatalf("Expected the cached role before forgetting, got %q", role)
+	}
+
+	cached.Forget(clubID, memberID)
+	if role, _ := cached.MemberRole(ctx, clubID, memberID); role != "moderator" {
+		t.Errorf("Expected the new role once forgotten, got %q", role)
+	}
+	if role, _ := cached.MemberRole(ctx, clubID, otherID); role != "member" {
+		t.Errorf("Expected other members to stay cached, got %q", role)
+	}
+
+	cached.ForgetClub(clubID)
+	if _, err := cached.MemberRole(ctx, clubID, otherID); err != ErrNotFound {
+		t.Errorf("Expected the deactivated member to be seen once the club is forgotten, got %v", err)
+	}
+}
+
+func TestCachedClubRoles(t *testing.T) {
+	mem := NewMemory()
+	clubID, memberID := uuid.New(), uuid.New()
+	roles := mem.Stores().ClubRoles
+	members := NewCachedClubs(mem.Stores().Clubs, 100, time.Minute)
+	cached := NewCachedClubRoles(roles, 100, time.Minute).WithMembers(members)
+	ctx := context.Background()
+
+	if _, err := cached.Get(ctx, clubID, "host"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound for a missing role, got %v", err)
+	}
+	host := &models.ClubRole{ID: uuid.New(), ClubID: clubID, Name: "host", Capabilities: models.StringArray{"manage_events"}}
+	if err := cached.Create(ctx, host); err != nil {
+		t.Fatal(err)
+	}
+	if role, err := cached.Get(ctx, clubID, "host"); err != nil || len(role.Capabilities) != 1 {
+		t.Fatalf("Expected the created role, got %+v, %v", role, err)
+	}
+
+	// Changes made underneath are seen once changes through the cache drop the club
+	roles.Update(ctx, clubID, host.ID, []string{"manage_events", "manage_items"})
+	if role, _ := cached.Get(ctx, clubID, "host"); len(role.Capabilities) != 1 {
+		t.Fatalf("Expected the cached role, got %+v", role)
+	}
+	if _, err := cached.Update(ctx, clubID, host.ID, []string{"manage_items"}); err != nil {
+		t.Fatal(err)
+	}
+	if role, _ := cached.Get(ctx, clubID, "host"); len(role.Capabilities) != 1 || role.Capabilities[0] != "manage_items" {
+		t.Errorf("Expected the updated role, got %+v", role)
+	}
+
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: memberID, Role: "host", IsActive: true})
+	if err := cached.Delete(ctx, clubID, host.ID); err != ErrRoleInUse {
+		t.Fatalf("Expected ErrRoleInUse while a member holds the role, got %v", err)
+	}
+	if role, _ := members.MemberRole(ctx, clubID, memberID); role != "host" {
+		t.Fatalf("Expected the member's role cached, got %q", role)
+	}
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: memberID, Role: "host", IsActive: false})
+	if err := cached.Delete(ctx, clubID, host.ID); err != nil {
+		t.Fatal(err)
+	}
+	if role, err := members.MemberRole(ctx, clubID, memberID); err != ErrNotFound {
+		t.Errorf("Expected the club's member roles dropped with the role, got %q, %v", role, err)
+	}
+	if _, err := cached.Get(ctx, clubID, "host"); err != ErrNotFound {
+		t.Errorf("Expected the deleted role to be gone, got %v", err)
+	}
+	if mem.members[clubID][memberID].Role != "member" {
+		t.Errorf("Expected the inactive holder to become a member, got %q", mem.members[clubID][memberID].Role)
+	}
+}
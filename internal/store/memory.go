@@ -0,0 +1,721 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// Memory holds in-memory data for tests and the mock data mode.
+// Seed it with the Put* methods, then hand out Stores() to handlers.
+type Memory struct {
+	mu           sync.RWMutex
+	users        map[uuid.UUID]models.User
+	members      map[uuid.UUID]map[uuid.UUID]models.ClubMember // club -> user -> membership
+	currencies   map[uuid.UUID]string                          // club -> currency, when not the default
+	roles        map[uuid.UUID]models.ClubRole                 // custom club roles by ID
+	events       map[uuid.UUID]models.Event
+	items        map[uuid.UUID]models.EventItem
+	shoppers     map[uuid.UUID]uuid.UUID                         // event -> shopper for the whole list
+	availability map[uuid.UUID]map[uuid.UUID]models.Availability // event -> user -> response
+	rates        map[[2]string]models.ExchangeRate               // base, quote -> rate
+	deletedClubs map[uuid.UUID]bool                              // soft-deleted clubs
+}
+
+// NewMemory creates an empty in-memory data set
+func NewMemory() *Memory {
+	return &Memory{
+		users:        make(map[uuid.UUID]models.User),
+		members:      make(map[uuid.UUID]map[uuid.UUID]models.ClubMember),
+		currencies:   make(map[uuid.UUID]string),
+		roles:        make(map[uuid.UUID]models.ClubRole),
+		events:       make(map[uuid.UUID]models.Event),
+		items:        make(map[uuid.UUID]models.EventItem),
+		shoppers:     make(map[uuid.UUID]uuid.UUID),
+		availability: make(map[uuid.UUID]map[uuid.UUID]models.Availability),
+		rates:        make(map[[2]string]models.ExchangeRate),
+		deletedClubs: make(map[uuid.UUID]bool),
+	}
+}
+
+// Stores returns the in-memory stores sharing this data set
+func (m *Memory) Stores() *Stores {
+	return &Stores{
+		Users:         memoryUsers{m},
+		Clubs:         memoryClubs{m},
+		ClubRoles:     memoryClubRoles{m},
+		Events:        memoryEvents{m},
+		EventItems:    memoryEventItems{m},
+		Availability:  memoryAvailability{m},
+		ExchangeRates: memoryExchangeRates{m},
+		Removals:      memoryRemovals{m},
+	}
+}
+
+// PutUser adds or replaces a user
+func (m *Memory) PutUser(user models.User) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[user.ID] = user
+}
+
+// PutMember adds or replaces a club membership
+func (m *Memory) PutMember(member models.ClubMember) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.members[member.ClubID] == nil {
+		m.members[member.ClubID] = make(map[uuid.UUID]models.ClubMember)
+	}
+	m.members[member.ClubID][member.UserID] = member
+}
+
+// PutClubCurrency sets the currency of a club; clubs default to money.DefaultCurrency
+func (m *Memory) PutClubCurrency(clubID uuid.UUID, currency string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.currencies[clubID] = currency
+}
+
+// PutEvent adds or replaces an event
+func (m *Memory) PutEvent(event models.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[event.ID] = event
+}
+
+// PutDeletedClub marks a club soft-deleted, so it can be purged
+func (m *Memory) PutDeletedClub(clubID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedClubs[clubID] = true
+}
+
+type memoryUsers struct{ *Memory }
+
+func (s memoryUsers) GetByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok || !user.IsActive {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s memoryUsers) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.IsActive && strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type memoryClubs struct{ *Memory }
+
+func (s memoryClubs) MemberRole(ctx context.Context, clubID, userID uuid.UUID) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.members[clubID][userID]
+	if !ok || !member.IsActive {
+		return "", ErrNotFound
+	}
+	return member.Role, nil
+}
+
+func (s memoryClubs) Currency(ctx context.Context, clubID uuid.UUID) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if currency, ok := s.currencies[clubID]; ok {
+		return currency, nil
+	}
+	return money.DefaultCurrency, nil
+}
+
+type memoryClubRoles struct{ *Memory }
+
+func (s memoryClubRoles) List(ctx context.Context, clubID uuid.UUID) ([]models.ClubRole, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := []models.ClubRole{}
+	for _, role := range s.roles {
+		if role.ClubID == clubID {
+			roles = append(roles, role)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (s memoryClubRoles) Get(ctx context.Context, clubID uuid.UUID, name string) (*models.ClubRole, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, role := range s.roles {
+		if role.ClubID == clubID && role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s memoryClubRoles) Create(ctx context.Context, role *models.ClubRole) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	role.UpdatedAt = role.CreatedAt
+	s.roles[role.ID] = *role
+	return nil
+}
+
+func (s memoryClubRoles) Update(ctx context.Context, clubID, roleID uuid.UUID, capabilities []string) (*models.ClubRole, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	role, ok := s.roles[roleID]
+	if !ok || role.ClubID != clubID {
+		return nil, ErrNotFound
+	}
+	role.Capabilities = capabilities
+	role.UpdatedAt = time.Now()
+	s.roles[roleID] = role
+	return &role, nil
+}
+
+func (s memoryClubRoles) Delete(ctx context.Context, clubID, roleID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	role, ok := s.roles[roleID]
+	if !ok || role.ClubID != clubID {
+		return ErrNotFound
+	}
+	for _, member := range s.members[clubID] {
+		if member.Role == role.Name && member.IsActive {
+			return ErrRoleInUse
+		}
+	}
+	for userID, member := range s.members[clubID] {
+		if member.Role == role.Name {
+			member.Role = "member"
+			s.members[clubID][userID] = member
+		}
+	}
+	delete(s.roles, roleID)
+	return nil
+}
+
+type memoryEvents struct{ *Memory }
+
+func (s memoryEvents) GetByID(ctx context.Context, eventID uuid.UUID) (*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event, ok := s.events[eventID]
+	if !ok || event.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &event, nil
+}
+
+type memoryEventItems struct{ *Memory }
+
+func (s memoryEventItems) ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.EventItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []models.EventItem
+	for _, item := range s.items {
+		if item.EventID == eventID {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].SortOrder != items[j].SortOrder {
+			return items[i].SortOrder < items[j].SortOrder
+		}
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (s memoryEventItems) Get(ctx context.Context, eventID, itemID uuid.UUID) (*models.EventItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[itemID]
+	if !ok || item.EventID != eventID {
+		return nil, ErrNotFound
+	}
+	return &item, nil
+}
+
+func (s memoryEventItems) Create(ctx context.Context, item *models.EventItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(item)
+}
+
+func (s memoryEventItems) create(item *models.EventItem) error {
+	last := 0
+	for _, other := range s.items {
+		if other.EventID == item.EventID {
+			last = max(last, other.SortOrder)
+		}
+	}
+	item.SortOrder = last + 1
+	item.Version = 1
+
+	now := time.Now()
+	stored := *item
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	s.items[item.ID] = stored
+	return nil
+}
+
+func (s memoryEventItems) Update(ctx context.Context, eventID, itemID uuid.UUID, update EventItemUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(eventID, itemID, update)
+}
+
+func (s memoryEventItems) update(eventID, itemID uuid.UUID, update EventItemUpdate) error {
+	item, ok := s.items[itemID]
+	if !ok || item.EventID != eventID {
+		return ErrNotFound
+	}
+	if update.IfVersion != nil && *update.IfVersion != item.Version {
+		return ErrVersionConflict
+	}
+
+	if update.Status != nil {
+		item.Status = *update.Status
+	}
+	if update.ClearNotes {
+		item.Notes = nil
+	} else if update.Notes != nil {
+		notes := *update.Notes
+		item.Notes = &notes
+	}
+	if update.ClearQuantity {
+		item.Quantity = nil
+	} else if update.Quantity != nil {
+		quantity := *update.Quantity
+		item.Quantity = &quantity
+	}
+	if update.ClearCost {
+		item.Cost, item.ExchangeRate = nil, nil
+	} else if update.Cost != nil {
+		cost := *update.Cost
+		item.Cost = &cost
+		item.ExchangeRate = update.ExchangeRate
+	}
+	if update.ClearDueDate {
+		item.DueDate = nil
+	} else if update.DueDate != nil {
+		dueDate := *update.DueDate
+		item.DueDate = &dueDate
+	}
+	item.Version++
+	item.UpdatedAt = time.Now()
+
+	s.items[itemID] = item
+	return nil
+}
+
+func (s memoryEventItems) Delete(ctx context.Context, eventID, itemID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(eventID, itemID)
+}
+
+func (s memoryEventItems) delete(eventID, itemID uuid.UUID) error {
+	item, ok := s.items[itemID]
+	if !ok || item.EventID != eventID {
+		return ErrNotFound
+	}
+
+	delete(s.items, itemID)
+	return nil
+}
+
+func (s memoryEventItems) Apply(ctx context.Context, eventID uuid.UUID, ops []EventItemOp) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the items as they were, to put back when an operation fails
+	saved := make(map[uuid.UUID]models.EventItem, len(s.items))
+	for id, item := range s.items {
+		saved[id] = item
+	}
+
+	for i, op := range ops {
+		var err error
+		switch {
+		case op.Create != nil:
+			err = s.create(op.Create)
+		case op.Delete:
+			err = s.delete(eventID, op.ItemID)
+		case op.Update != nil:
+			err = s.update(eventID, op.ItemID, *op.Update)
+		}
+		if err != nil {
+			s.Memory.items = saved
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+func (s memoryEventItems) Reorder(ctx context.Context, eventID uuid.UUID, itemIDs []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, item := range s.items {
+		if item.EventID == eventID {
+			count++
+		}
+	}
+	positions := map[uuid.UUID]int{}
+	for i, itemID := range itemIDs {
+		item, ok := s.items[itemID]
+		if !ok || item.EventID != eventID {
+			return ErrItemsChanged
+		}
+		positions[itemID] = i + 1
+	}
+	if len(positions) != len(itemIDs) || len(positions) != count {
+		return ErrItemsChanged
+	}
+
+	for itemID, position := range positions {
+		item := s.items[itemID]
+		item.SortOrder = position
+		s.items[itemID] = item
+	}
+	return nil
+}
+
+func (s memoryEventItems) Claim(ctx context.Context, eventID, itemID, userID uuid.UUID) (*models.EventItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok || item.EventID != eventID {
+		return nil, ErrNotFound
+	}
+	if item.AssignedTo != nil && *item.AssignedTo != userID {
+		return nil, ErrAlreadyAssigned
+	}
+
+	item.AssignedTo = &userID
+	if item.Status == "pending" {
+		item.Status = "assigned"
+	}
+	item.Version++
+	item.UpdatedAt = time.Now()
+	s.items[itemID] = item
+	return &item, nil
+}
+
+func (s memoryEventItems) Assign(ctx context.Context, eventID, itemID uuid.UUID, userID *uuid.UUID, ifVersion *int) (*models.EventItem, *uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemID]
+	if !ok || item.EventID != eventID {
+		return nil, nil, ErrNotFound
+	}
+	if ifVersion != nil && *ifVersion != item.Version {
+		return nil, nil, ErrVersionConflict
+	}
+
+	previous := item.AssignedTo
+	item.AssignedTo = nil
+	if userID != nil {
+		assignee := *userID
+		item.AssignedTo = &assignee
+	}
+	switch {
+	case userID == nil && item.Status == "assigned":
+		item.Status = "pending"
+	case userID != nil && item.Status == "pending":
+		item.Status = "assigned"
+	}
+	item.Version++
+	item.UpdatedAt = time.Now()
+	s.items[itemID] = item
+	return &item, previous, nil
+}
+
+func (s memoryEventItems) ShoppingListAssignee(ctx context.Context, eventID uuid.UUID) (*uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID, ok := s.shoppers[eventID]
+	if !ok {
+		return nil, nil
+	}
+	return &userID, nil
+}
+
+func (s memoryEventItems) AssignShoppingList(ctx context.Context, eventID uuid.UUID, userID *uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if userID == nil {
+		delete(s.shoppers, eventID)
+		return nil
+	}
+	s.shoppers[eventID] = *userID
+	return nil
+}
+
+type memoryAvailability struct{ *Memory }
+
+func (s memoryAvailability) ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.Availability, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var availability []models.Availability
+	for _, avail := range s.availability[eventID] {
+		availability = append(availability, avail)
+	}
+
+	sort.Slice(availability, func(i, j int) bool {
+		return availability[i].UpdatedAt.After(availability[j].UpdatedAt)
+	})
+	return availability, nil
+}
+
+func (s memoryAvailability) Upsert(ctx context.Context, availability *models.Availability) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.availability[availability.EventID] == nil {
+		s.availability[availability.EventID] = make(map[uuid.UUID]models.Availability)
+	}
+
+	stored := *availability
+	if existing, ok := s.availability[availability.EventID][availability.UserID]; ok {
+		stored.ID = existing.ID
+	} else {
+		stored.ID = uuid.New()
+	}
+	stored.UpdatedAt = time.Now()
+
+	s.availability[availability.EventID][availability.UserID] = stored
+	return nil
+}
+
+func (s memoryAvailability) Summary(ctx context.Context, eventID uuid.UUID) (*models.AvailabilitySummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := &models.AvailabilitySummary{}
+	for _, avail := range s.availability[eventID] {
+		summary.Add(avail.Status, 1)
+	}
+	return summary, nil
+}
+
+// RepairSummaries is a no-op: in-memory summaries are computed on demand and never drift
+func (s memoryAvailability) RepairSummaries(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (s memoryAvailability) Roster(ctx context.Context, eventID, clubID uuid.UUID) ([]RosterEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var roster []RosterEntry
+	for userID, member := range s.members[clubID] {
+		if !member.IsActive {
+			continue
+		}
+
+		entry := RosterEntry{Name: s.users[userID].Name}
+		if avail, ok := s.availability[eventID][userID]; ok {
+			entry.Status = avail.Status
+			if avail.Notes != nil {
+				entry.Notes = *avail.Notes
+			}
+		}
+		roster = append(roster, entry)
+	}
+
+	sort.Slice(roster, func(i, j int) bool { return roster[i].Name < roster[j].Name })
+	return roster, nil
+}
+
+type memoryExchangeRates struct{ *Memory }
+
+func (s memoryExchangeRates) Get(ctx context.Context, base, quote string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rate, ok := s.rates[[2]string{base, quote}]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return rate.Rate, nil
+}
+
+func (s memoryExchangeRates) Set(ctx context.Context, rate models.ExchangeRate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rate.UpdatedAt = time.Now()
+	s.rates[[2]string{rate.Base, rate.Quote}] = rate
+	return nil
+}
+
+func (s memoryExchangeRates) List(ctx context.Context) ([]models.ExchangeRate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := []models.ExchangeRate{}
+	for _, rate := range s.rates {
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+	return rates, nil
+}
+
+type memoryRemovals struct{ *Memory }
+
+func (s memoryRemovals) DeleteEvent(ctx context.Context, eventID, deletedBy uuid.UUID, dryRun bool) (*Removal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[eventID]
+	if !ok || event.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	removal := &Removal{DryRun: dryRun, Effects: []Effect{
+		{Table: "availability", Hidden: int64(len(s.availability[eventID]))},
+		{Table: "event_items", Hidden: int64(s.countItems(eventID))},
+		{Table: "events", Updated: 1},
+	}}
+
+	if !dryRun {
+		now := time.Now()
+		event.DeletedAt = &now
+		s.events[eventID] = event
+	}
+	return removal, nil
+}
+
+func (s memoryRemovals) RemoveMember(ctx context.Context, clubID, memberID uuid.UUID, dryRun bool) (*Removal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, member := range s.members[clubID] {
+		if member.ID != memberID {
+			continue
+		}
+		if !dryRun {
+			delete(s.members[clubID], userID)
+		}
+		return &Removal{DryRun: dryRun, Effects: []Effect{{Table: "club_members", Deleted: 1}}}, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s memoryRemovals) PurgeEvent(ctx context.Context, eventID uuid.UUID, dryRun bool) (*Removal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.events[eventID]
+	if !ok || event.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+	return &Removal{DryRun: dryRun, Effects: s.purgeEvents([]uuid.UUID{eventID}, dryRun)}, nil
+}
+
+func (s memoryRemovals) PurgeClub(ctx context.Context, clubID uuid.UUID, dryRun bool) (*Removal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.deletedClubs[clubID] {
+		return nil, ErrNotFound
+	}
+
+	var eventIDs []uuid.UUID
+	for id, event := range s.events {
+		if event.ClubID == clubID {
+			eventIDs = append(eventIDs, id)
+		}
+	}
+	effects := []Effect{
+		{Table: "club_members", Deleted: int64(len(s.members[clubID]))},
+		{Table: "clubs", Deleted: 1},
+	}
+	effects = mergeEffects(effects, s.purgeEvents(eventIDs, dryRun))
+
+	if !dryRun {
+		delete(s.members, clubID)
+		delete(s.currencies, clubID)
+		delete(s.deletedClubs, clubID)
+	}
+	return &Removal{DryRun: dryRun, Effects: effects}, nil
+}
+
+// purgeEvents deletes events with their items, shoppers and availability,
+// unless dryRun, and returns the rows deleted. The caller holds the lock.
+func (s memoryRemovals) purgeEvents(eventIDs []uuid.UUID, dryRun bool) []Effect {
+	var items, responses int
+	for _, eventID := range eventIDs {
+		items += s.countItems(eventID)
+		responses += len(s.availability[eventID])
+		if dryRun {
+			continue
+		}
+		for id, item := range s.items {
+			if item.EventID == eventID {
+				delete(s.items, id)
+			}
+		}
+		delete(s.availability, eventID)
+		delete(s.shoppers, eventID)
+		delete(s.events, eventID)
+	}
+	return []Effect{
+		{Table: "availability", Deleted: int64(responses)},
+		{Table: "event_items", Deleted: int64(items)},
+		{Table: "events", Deleted: int64(len(eventIDs))},
+	}
+}
+
+// countItems counts an event's items. The caller holds the lock.
+func (s memoryRemovals) countItems(eventID uuid.UUID) int {
+	n := 0
+	for _, item := range s.items {
+		if item.EventID == eventID {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryMemberRole(t *testing.T) {
+	mem := NewMemory()
+	stores := mem.Stores()
+
+	clubID, activeID, inactiveID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: activeID, Role: "moderator", IsActive: true})
+	mem.PutMember(models.ClubMember{ClubID: clubID, UserID: inactiveID, Role: "member", IsActive: false})
+
+	role, err := stores.Clubs.MemberRole(context.Background(), clubID, activeID)
+	if err != nil || role != "moderator" {
+		t.Errorf("Expected moderator, got %q, %v", role, err)
+	}
+
+	if _, err := stores.Clubs.MemberRole(context.Background(), clubID, inactiveID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for inactive member, got %v", err)
+	}
+	if _, err := stores.Clubs.MemberRole(context.Background(), clubID, uuid.New()); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for non-member, got %v", err)
+	}
+}
+
+func TestMemoryAvailabilityRoster(t *testing.T) {
+	mem := NewMemory()
+	stores := mem.Stores()
+	ctx := context.Background()
+
+	clubID, eventID := uuid.New(), uuid.New()
+	alice := models.User{ID: uuid.New(), Name: "Alice", IsActive: true}
+	bob := models.User{ID: uuid.New(), Name: "Bob", IsActive: true}
+
+	for _, user := range []models.User{bob, alice} {
+		mem.PutUser(user)
+		mem.PutMember(models.ClubMember{ClubID: clubID, UserID: user.ID, Role: "member", IsActive: true})
+	}
+
+	notes := "Running late"
+	stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: bob.ID, Status: "maybe"})
+	stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: bob.ID, Status: "available", Notes: &notes})
+
+	responses, _ := stores.Availability.ListByEvent(ctx, eventID)
+	if len(responses) != 1 || responses[0].Status != "available" {
+		t.Fatalf("Expected a single upserted response, got %+v", responses)
+	}
+
+	roster, err := stores.Availability.Roster(ctx, eventID, clubID)
+	if err != nil {
+		t.Fatalf("Roster failed: %v", err)
+	}
+
+	expected := []RosterEntry{
+		{Name: "Alice"},
+		{Name: "Bob", Status: "available", Notes: "Running late"},
+	}
+	if len(roster) != len(expected) {
+		t.Fatalf("Expected %d roster entries, got %d", len(expected), len(roster))
+	}
+	for i := range expected {
+		if roster[i] != expected[i] {
+			t.Errorf("Roster entry %d: expected %+v, got %+v", i, expected[i], roster[i])
+		}
+	}
+}
+
+func TestMemoryAvailabilitySummary(t *testing.T) {
+	stores := NewMemory().Stores()
+	ctx := context.Background()
+	eventID := uuid.New()
+
+	for _, status := range []string{"available", "available", "maybe", "unavailable"} {
+		stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: uuid.New(), Status: status})
+	}
+	// Changing a response moves it between statuses rather than adding one
+	userID := uuid.New()
+	stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: userID, Status: "maybe"})
+	stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: userID, Status: "available"})
+
+	summary, err := stores.Availability.Summary(ctx, eventID)
+	if err != nil {
+		t.Fatalf("Summary failed: %v", err)
+	}
+
+	expected := models.AvailabilitySummary{Available: 3, Maybe: 1, Unavailable: 1, Total: 5}
+	if *summary != expected {
+		t.Errorf("Expected summary %+v, got %+v", expected, *summary)
+	}
+}
+
+func TestMemoryEventItemNotFound(t *testing.T) {
+	stores := NewMemory().Stores()
+	ctx := context.Background()
+
+	status := "completed"
+	if err := stores.EventItems.Update(ctx, uuid.New(), uuid.New(), EventItemUpdate{Status: &status}); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound on update, got %v", err)
+	}
+	if err := stores.EventItems.Delete(ctx, uuid.New(), uuid.New()); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound on delete, got %v", err)
+	}
+}
+
+func TestMemoryEventItemAssign(t *testing.T) {
+	stores := NewMemory().Stores()
+	ctx := context.Background()
+
+	eventID, first, second := uuid.New(), uuid.New(), uuid.New()
+	item := models.EventItem{ID: uuid.New(), EventID: eventID, Name: "Tea", Status: "pending"}
+	stores.EventItems.Create(ctx, &item)
+
+	claimed, err := stores.EventItems.Claim(ctx, eventID, item.ID, first)
+	if err != nil || claimed.Status != "assigned" {
+		t.Fatalf("Expected the item to be claimed, got %+v, %v", claimed, err)
+	}
+	if _, err := stores.EventItems.Claim(ctx, eventID, item.ID, second); err != ErrAlreadyAssigned {
+		t.Errorf("Expected ErrAlreadyAssigned, got %v", err)
+	}
+
+	assigned, previous, err := stores.EventItems.Assign(ctx, eventID, item.ID, &second, nil)
+	if err != nil || previous == nil || *previous != first || *assigned.AssignedTo != second {
+		t.Errorf("Expected the item to move from the first member to the second, got %+v, %v", assigned, err)
+	}
+	cleared, _, _ := stores.EventItems.Assign(ctx, eventID, item.ID, nil, nil)
+	if cleared.AssignedTo != nil || cleared.Status != "pending" {
+		t.Errorf("Expected a cleared item to be pending again, got %+v", cleared)
+	}
+	if _, _, err := stores.EventItems.Assign(ctx, uuid.New(), item.ID, &first, nil); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for another event's item, got %v", err)
+	}
+}
+
+func TestMemoryEventItemVersions(t *testing.T) {
+	stores := NewMemory().Stores()
+	ctx := context.Background()
+
+	eventID := uuid.New()
+	item := models.EventItem{ID: uuid.New(), EventID: eventID, Name: "Tea", Status: "pending"}
+	stores.EventItems.Create(ctx, &item)
+	if item.Version != 1 {
+		t.Fatalf("Expected a new item at version 1, got %d", item.Version)
+	}
+
+	stale, notes := 1, "Green"
+	if err := stores.EventItems.Update(ctx, eventID, item.ID, EventItemUpdate{Notes: &notes, IfVersion: &stale}); err != nil {
+		t.Fatalf("Expected the update of the current version to succeed, got %v", err)
+	}
+	if err := stores.EventItems.Update(ctx, eventID, item.ID, EventItemUpdate{Notes: &notes, IfVersion: &stale}); err != ErrVersionConflict {
+		t.Errorf("Expected ErrVersionConflict for an outdated version, got %v", err)
+	}
+	if _, _, err := stores.EventItems.Assign(ctx, eventID, item.ID, nil, &stale); err != ErrVersionConflict {
+		t.Errorf("Expected ErrVersionConflict when assigning, got %v", err)
+	}
+
+	current, _ := stores.EventItems.Get(ctx, eventID, item.ID)
+	if current.Version != 2 || *current.Notes != notes {
+		t.Errorf("Expected only the first update to be made, got version %d", current.Version)
+	}
+}
+
+func TestMemoryEventSoftDeleted(t *testing.T) {
+	mem := NewMemory()
+	stores := mem.Stores()
+
+	deletedAt := time.Now()
+	liveID, deletedID := uuid.New(), uuid.New()
+	mem.PutEvent(models.Event{ID: liveID, Title: "Book Night"})
+	mem.PutEvent(models.Event{ID: deletedID, Title: "Cancelled", DeletedAt: &deletedAt})
+
+	if _, err := stores.Events.GetByID(context.Background(), liveID); err != nil {
+		t.Errorf("Expected the live event, got %v", err)
+	}
+	if _, err := stores.Events.GetByID(context.Background(), deletedID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a soft-deleted event, got %v", err)
+	}
+}
+
+func TestMemoryRemovalsDryRun(t *testing.T) {
+	mem := NewMemory()
+	stores := mem.Stores()
+	ctx := context.Background()
+
+	clubID, eventID, memberID := uuid.New(), uuid.New(), uuid.New()
+	mem.PutEvent(models.Event{ID: eventID, ClubID: clubID})
+	mem.PutMember(models.ClubMember{ID: memberID, ClubID: clubID, UserID: uuid.New(), IsActive: true})
+	stores.EventItems.Create(ctx, &models.EventItem{EventID: eventID, Name: "Wine"})
+	stores.Availability.Upsert(ctx, &models.Availability{EventID: eventID, UserID: uuid.New(), Status: "available"})
+
+	removal, err := stores.Removals.DeleteEvent(ctx, eventID, uuid.New(), true)
+	if err != nil {
+		t.Fatalf("DeleteEvent dry run failed: %v", err)
+	}
+	want := []Effect{{Table: "availability", Hidden: 1}, {Table: "event_items", Hidden: 1}, {Table: "events", Updated: 1}}
+	if !removal.DryRun || len(removal.Effects) != len(want) {
+		t.Fatalf("Unexpected preview %+v", removal)
+	}
+	for i := range want {
+		if removal.Effects[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], removal.Effects[i])
+		}
+	}
+	if _, err := stores.Events.GetByID(ctx, eventID); err != nil {
+		t.Errorf("Expected the event to survive a dry run, got %v", err)
+	}
+
+	if _, err := stores.Removals.RemoveMember(ctx, clubID, memberID, true); err != nil {
+		t.Fatalf("RemoveMember dry run failed: %v", err)
+	}
+	if _, err := stores.Removals.RemoveMember(ctx, clubID, memberID, false); err != nil {
+		t.Errorf("Expected the member to survive a dry run, got %v", err)
+	}
+	if _, err := stores.Removals.RemoveMember(ctx, clubID, memberID, false); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound once removed, got %v", err)
+	}
+
+	if _, err := stores.Removals.PurgeEvent(ctx, eventID, true); err != ErrNotFound {
+		t.Errorf("Expected a live event not to be purgeable, got %v", err)
+	}
+}
+
+func TestMergeEffects(t *testing.T) {
+	merged := mergeEffects(
+		[]Effect{{Table: "events", Updated: 1}, {Table: "availability_counts", Deleted: 2}},
+		[]Effect{{Table: "event_items", Hidden: 3}, {Table: "events", Hidden: 1}},
+	)
+	want := []Effect{{Table: "availability_counts", Deleted: 2}, {Table: "event_items", Hidden: 3}, {Table: "events", Updated: 1, Hidden: 1}}
+	if len(merged) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], merged[i])
+		}
+	}
+}
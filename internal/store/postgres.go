@@ -0,0 +1,802 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// NewPostgres returns the PostgreSQL-backed stores
+func NewPostgres(db *database.DB) *Stores {
+	return &Stores{
+		Users:         &postgresUsers{db: db},
+		Clubs:         &postgresClubs{db: db},
+		ClubRoles:     &postgresClubRoles{db: db},
+		Events:        &postgresEvents{db: db},
+		EventItems:    &postgresEventItems{db: db},
+		Availability:  &postgresAvailability{db: db},
+		ExchangeRates: &postgresExchangeRates{db: db},
+		Removals:      &postgresRemovals{db: db},
+	}
+}
+
+// notFound maps sql.ErrNoRows to ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+type postgresUsers struct {
+	db *database.DB
+}
+
+func (s *postgresUsers) GetByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	return s.getUser(ctx, `id = $1`, userID)
+}
+
+func (s *postgresUsers) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.getUser(ctx, `email = $1`, email)
+}
+
+func (s *postgresUsers) getUser(ctx context.Context, where string, arg interface{}) (*models.User, error) {
+	query := `
+		SELECT id, name, email, password_hash, phone, avatar, role, is_active,
+		       last_login_at, created_at, updated_at, COALESCE(is_sandbox, false), sandbox_expires_at
+		FROM users
+		WHERE ` + where + ` AND is_active = true`
+
+	var user models.User
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(
+		&user.ID, &user.Name, &user.Email, &user.PasswordHash,
+		&user.Phone, &user.Avatar, &user.Role, &user.IsActive,
+		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &user.IsSandbox, &user.SandboxExpiresAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+type postgresClubs struct {
+	db *database.DB
+}
+
+// MemberRole reports the club's owner as owner, though club_members holds
+// them as a moderator
+func (s *postgresClubs) MemberRole(ctx context.Context, clubID, userID uuid.UUID) (string, error) {
+	query := `
+		SELECT CASE WHEN c.owner_id = cm.user_id THEN 'owner' ELSE cm.role END
+		FROM club_members cm
+		JOIN clubs c ON c.id = cm.club_id AND c.deleted_at IS NULL
+		WHERE cm.club_id = $1 AND cm.user_id = $2 AND cm.is_active = true`
+
+	var role string
+	if err := s.db.QueryRowContext(ctx, query, clubID, userID).Scan(&role); err != nil {
+		return "", notFound(err)
+	}
+	return role, nil
+}
+
+func (s *postgresClubs) Currency(ctx context.Context, clubID uuid.UUID) (string, error) {
+	var currency string
+	if err := s.db.QueryRowContext(ctx, `SELECT currency FROM clubs WHERE id = $1 AND deleted_at IS NULL`, clubID).Scan(&currency); err != nil {
+		return "", notFound(err)
+	}
+	return currency, nil
+}
+
+type postgresClubRoles struct {
+	db *database.DB
+}
+
+const clubRoleColumns = `id, club_id, name, capabilities, created_by, created_at, updated_at`
+
+func scanClubRole(row interface{ Scan(...interface{}) error }) (*models.ClubRole, error) {
+	var role models.ClubRole
+	err := row.Scan(&role.ID, &role.ClubID, &role.Name, &role.Capabilities, &role.CreatedBy, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *postgresClubRoles) List(ctx context.Context, clubID uuid.UUID) ([]models.ClubRole, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+clubRoleColumns+` FROM club_roles WHERE club_id = $1 ORDER BY name`, clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.ClubRole{}
+	for rows.Next() {
+		role, err := scanClubRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+func (s *postgresClubRoles) Get(ctx context.Context, clubID uuid.UUID, name string) (*models.ClubRole, error) {
+	role, err := scanClubRole(s.db.QueryRowContext(ctx,
+		`SELECT `+clubRoleColumns+` FROM club_roles WHERE club_id = $1 AND name = $2`, clubID, name))
+	return role, notFound(err)
+}
+
+func (s *postgresClubRoles) Create(ctx context.Context, role *models.ClubRole) error {
+	query := `
+		INSERT INTO club_roles (id, club_id, name, capabilities, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)`
+
+	_, err := s.db.ExecContext(ctx, query, role.ID, role.ClubID, role.Name, role.Capabilities, role.CreatedBy, role.CreatedAt)
+	return err
+}
+
+func (s *postgresClubRoles) Update(ctx context.Context, clubID, roleID uuid.UUID, capabilities []string) (*models.ClubRole, error) {
+	role, err := scanClubRole(s.db.QueryRowContext(ctx, `
+		UPDATE club_roles SET capabilities = $3, updated_at = NOW()
+		WHERE id = $1 AND club_id = $2
+		RETURNING `+clubRoleColumns, roleID, clubID, models.StringArray(capabilities)))
+	return role, notFound(err)
+}
+
+// Delete refuses to remove a role active members hold. Inactive members
+// holding it become plain members.
+func (s *postgresClubRoles) Delete(ctx context.Context, clubID, roleID uuid.UUID) error {
+	return s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		var name string
+		err := tx.QueryRowContext(ctx, `SELECT name FROM club_roles WHERE id = $1 AND club_id = $2`, roleID, clubID).Scan(&name)
+		if err != nil {
+			return notFound(err)
+		}
+
+		var held bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM club_members WHERE club_id = $1 AND role = $2 AND is_active = true)`,
+			clubID, name).Scan(&held)
+		if err != nil {
+			return err
+		}
+		if held {
+			return ErrRoleInUse
+		}
+
+		// Former members who come back do so as plain members
+		_, err = tx.ExecContext(ctx, `UPDATE club_members SET role = 'member' WHERE club_id = $1 AND role = $2`, clubID, name)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM club_roles WHERE id = $1`, roleID)
+		return err
+	})
+}
+
+type postgresEvents struct {
+	db *database.DB
+}
+
+func (s *postgresEvents) GetByID(ctx context.Context, eventID uuid.UUID) (*models.Event, error) {
+	query := `
+		SELECT e.id, e.club_id, e.title, e.description, e.event_date, e.event_time, e.location,
+		       e.book, e.type, e.max_attendees, e.is_public, e.created_by, e.attendees, e.created_at, e.updated_at,
+		       e.timezone, e.ends_at
+		FROM events e
+		JOIN clubs c ON c.id = e.club_id AND c.deleted_at IS NULL
+		WHERE e.id = $1 AND e.deleted_at IS NULL`
+
+	var event models.Event
+	err := s.db.QueryRowContext(ctx, query, eventID).Scan(
+		&event.ID, &event.ClubID, &event.Title, &event.Description,
+		&event.Date, &event.Time, &event.Location, &event.Book,
+		&event.Type, &event.MaxAttendees, &event.IsPublic, &event.CreatedBy,
+		&event.Attendees, &event.CreatedAt, &event.UpdatedAt,
+		&event.Timezone, &event.EndsAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &event, nil
+}
+
+type postgresEventItems struct {
+	db *database.DB
+}
+
+// eventItemColumns are the columns scanEventItem reads, in order
+const eventItemColumns = `id, event_id, name, category, assigned_to, status, notes, quantity, unit,
+	cost::text, cost_currency, exchange_rate::text, due_date, sort_order, version, created_by, created_at, updated_at`
+
+// scanEventItem reads the eventItemColumns of a row, then any extra columns into extra
+func scanEventItem(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.EventItem, error) {
+	var item models.EventItem
+	var cost, currency sql.NullString
+	dest := append([]interface{}{
+		&item.ID, &item.EventID, &item.Name, &item.Category,
+		&item.AssignedTo, &item.Status, &item.Notes, &item.Quantity, &item.Unit,
+		&cost, &currency, &item.ExchangeRate, &item.DueDate, &item.SortOrder, &item.Version,
+		&item.CreatedBy, &item.CreatedAt, &item.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	// NUMERIC is read as text so costs never pass through a float
+	if cost.Valid {
+		parsed, err := money.Parse(cost.String, currency.String)
+		if err != nil {
+			return nil, err
+		}
+		item.Cost = &parsed
+	}
+	return &item, nil
+}
+
+func (s *postgresEventItems) ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.EventItem, error) {
+	query := `
+		SELECT ` + eventItemColumns + `
+		FROM event_items
+		WHERE event_id = $1
+		ORDER BY sort_order, created_at ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.EventItem
+	for rows.Next() {
+		item, err := scanEventItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *postgresEventItems) Get(ctx context.Context, eventID, itemID uuid.UUID) (*models.EventItem, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+eventItemColumns+` FROM event_items WHERE id = $1 AND event_id = $2`, itemID, eventID)
+	item, err := scanEventItem(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return item, err
+}
+
+// execer runs statements on the database or in a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *postgresEventItems) Create(ctx context.Context, item *models.EventItem) error {
+	return createEventItem(ctx, s.db, item)
+}
+
+func createEventItem(ctx context.Context, db execer, item *models.EventItem) error {
+	query := `
+		INSERT INTO event_items (id, event_id, name, category, assigned_to, status, notes, quantity, unit,
+		                         cost, cost_currency, exchange_rate, due_date, created_by, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+		        (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM event_items WHERE event_id = $2))
+		RETURNING sort_order, version`
+
+	var cost, currency interface{}
+	if item.Cost != nil {
+		cost, currency = item.Cost.String(), item.Cost.Currency
+	}
+
+	return db.QueryRowContext(ctx, query,
+		item.ID, item.EventID, item.Name, item.Category, item.AssignedTo, item.Status, item.Notes,
+		item.Quantity, item.Unit, cost, currency, item.ExchangeRate, item.DueDate, item.CreatedBy,
+	).Scan(&item.SortOrder, &item.Version)
+}
+
+func (s *postgresEventItems) Update(ctx context.Context, eventID, itemID uuid.UUID, update EventItemUpdate) error {
+	return updateEventItem(ctx, s.db, eventID, itemID, update)
+}
+
+func updateEventItem(ctx context.Context, db execer, eventID, itemID uuid.UUID, update EventItemUpdate) error {
+	setParts := []string{}
+	args := []interface{}{}
+
+	if update.Status != nil {
+		args = append(args, *update.Status)
+		setParts = append(setParts, "status = $"+strconv.Itoa(len(args)))
+	}
+	if update.ClearNotes {
+		setParts = append(setParts, "notes = NULL")
+	} else if update.Notes != nil {
+		args = append(args, *update.Notes)
+		setParts = append(setParts, "notes = $"+strconv.Itoa(len(args)))
+	}
+	if update.ClearQuantity {
+		setParts = append(setParts, "quantity = NULL")
+	} else if update.Quantity != nil {
+		args = append(args, *update.Quantity)
+		setParts = append(setParts, "quantity = $"+strconv.Itoa(len(args)))
+	}
+	if update.ClearCost {
+		setParts = append(setParts, "cost = NULL", "cost_currency = NULL", "exchange_rate = NULL")
+	} else if update.Cost != nil {
+		args = append(args, update.Cost.String(), update.Cost.Currency, update.ExchangeRate)
+		setParts = append(setParts,
+			"cost = $"+strconv.Itoa(len(args)-2),
+			"cost_currency = $"+strconv.Itoa(len(args)-1),
+			"exchange_rate = $"+strconv.Itoa(len(args)),
+		)
+	}
+	if update.ClearDueDate {
+		setParts = append(setParts, "due_date = NULL")
+	} else if update.DueDate != nil {
+		args = append(args, *update.DueDate)
+		setParts = append(setParts, "due_date = $"+strconv.Itoa(len(args)))
+	}
+
+	args = append(args, itemID, eventID)
+	query := `UPDATE event_items SET ` + strings.Join(append(setParts, "version = version + 1", "updated_at = NOW()"), ", ") +
+		` WHERE id = $` + strconv.Itoa(len(args)-1) + ` AND event_id = $` + strconv.Itoa(len(args))
+	if update.IfVersion != nil {
+		args = append(args, *update.IfVersion)
+		query += ` AND version = $` + strconv.Itoa(len(args))
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(result); err != nil && update.IfVersion != nil {
+		return unchangedEventItem(ctx, db, eventID, itemID, ErrVersionConflict)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// unchangedEventItem explains a conditional write that touched no row: it
+// returns ErrNotFound for a missing item, or else reason
+func unchangedEventItem(ctx context.Context, db execer, eventID, itemID uuid.UUID, reason error) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM event_items WHERE id = $1 AND event_id = $2)`, itemID, eventID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return reason
+}
+
+func (s *postgresEventItems) Delete(ctx context.Context, eventID, itemID uuid.UUID) error {
+	return deleteEventItem(ctx, s.db, eventID, itemID)
+}
+
+func deleteEventItem(ctx context.Context, db execer, eventID, itemID uuid.UUID) error {
+	result, err := db.ExecContext(ctx, `DELETE FROM event_items WHERE id = $1 AND event_id = $2`, itemID, eventID)
+	if err != nil {
+		return err
+	}
+	return requireRow(result)
+}
+
+func (s *postgresEventItems) Apply(ctx context.Context, eventID uuid.UUID, ops []EventItemOp) (int, error) {
+	failed := -1
+	err := s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		for i, op := range ops {
+			var err error
+			switch {
+			case op.Create != nil:
+				err = createEventItem(ctx, tx, op.Create)
+			case op.Delete:
+				err = deleteEventItem(ctx, tx, eventID, op.ItemID)
+			case op.Update != nil:
+				err = updateEventItem(ctx, tx, eventID, op.ItemID, *op.Update)
+			}
+			if err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	return failed, err
+}
+
+func (s *postgresEventItems) Reorder(ctx context.Context, eventID uuid.UUID, itemIDs []uuid.UUID) error {
+	return s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		// Lock the event's items, so none is added or removed while checking the list
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM event_items WHERE event_id = $1 FOR UPDATE`, eventID)
+		if err != nil {
+			return err
+		}
+		current := map[uuid.UUID]bool{}
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			current[id] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		listed := map[uuid.UUID]bool{}
+		for _, id := range itemIDs {
+			if !current[id] || listed[id] {
+				return ErrItemsChanged
+			}
+			listed[id] = true
+		}
+		if len(listed) != len(current) {
+			return ErrItemsChanged
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE event_items SET sort_order = ordered.position
+			FROM UNNEST($2::uuid[]) WITH ORDINALITY AS ordered(id, position)
+			WHERE event_items.id = ordered.id AND event_items.event_id = $1`,
+			eventID, models.UUIDArray(itemIDs))
+		return err
+	})
+}
+
+func (s *postgresEventItems) Claim(ctx context.Context, eventID, itemID, userID uuid.UUID) (*models.EventItem, error) {
+	query := `
+		UPDATE event_items
+		SET assigned_to = $3,
+		    status = CASE WHEN status = 'pending' THEN 'assigned' ELSE status END,
+		    version = version + 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND event_id = $2 AND (assigned_to IS NULL OR assigned_to = $3)
+		RETURNING ` + eventItemColumns
+
+	item, err := scanEventItem(s.db.QueryRowContext(ctx, query, itemID, eventID, userID))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return item, err
+	}
+
+	// Nothing was claimed: the item is missing or someone else has it
+	return nil, unchangedEventItem(ctx, s.db, eventID, itemID, ErrAlreadyAssigned)
+}
+
+func (s *postgresEventItems) Assign(ctx context.Context, eventID, itemID uuid.UUID, userID *uuid.UUID, ifVersion *int) (*models.EventItem, *uuid.UUID, error) {
+	// The subquery locks the row and keeps its assignee from before the update
+	query := `
+		UPDATE event_items
+		SET assigned_to = $3,
+		    status = CASE
+		        WHEN $3::uuid IS NULL AND status = 'assigned' THEN 'pending'
+		        WHEN $3::uuid IS NOT NULL AND status = 'pending' THEN 'assigned'
+		        ELSE status END,
+		    version = version + 1,
+		    updated_at = NOW()
+		FROM (SELECT id AS old_id, assigned_to AS previous FROM event_items
+		      WHERE id = $1 AND event_id = $2 AND ($4::int IS NULL OR version = $4) FOR UPDATE) old
+		WHERE id = old.old_id
+		RETURNING ` + eventItemColumns + `, old.previous`
+
+	var previous *uuid.UUID
+	item, err := scanEventItem(s.db.QueryRowContext(ctx, query, itemID, eventID, userID, ifVersion), &previous)
+	if errors.Is(err, sql.ErrNoRows) && ifVersion != nil {
+		return nil, nil, unchangedEventItem(ctx, s.db, eventID, itemID, ErrVersionConflict)
+	}
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	return item, previous, nil
+}
+
+func (s *postgresEventItems) ShoppingListAssignee(ctx context.Context, eventID uuid.UUID) (*uuid.UUID, error) {
+	var assignedTo *uuid.UUID
+	err := s.db.QueryRowContext(ctx, `SELECT assigned_to FROM event_shopping_lists WHERE event_id = $1`, eventID).Scan(&assignedTo)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return assignedTo, err
+}
+
+func (s *postgresEventItems) AssignShoppingList(ctx context.Context, eventID uuid.UUID, userID *uuid.UUID) error {
+	query := `
+		INSERT INTO event_shopping_lists (event_id, assigned_to, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (event_id) DO UPDATE SET assigned_to = EXCLUDED.assigned_to, updated_at = NOW()`
+
+	_, err := s.db.ExecContext(ctx, query, eventID, userID)
+	return err
+}
+
+// requireRow returns ErrNotFound when a statement did not touch any row
+func requireRow(result sql.Result) error {
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type postgresAvailability struct {
+	db *database.DB
+}
+
+func (s *postgresAvailability) ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.Availability, error) {
+	query := `
+		SELECT user_id, status, notes, updated_at
+		FROM availability
+		WHERE event_id = $1
+		ORDER BY updated_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var availability []models.Availability
+	for rows.Next() {
+		avail := models.Availability{EventID: eventID}
+		if err := rows.Scan(&avail.UserID, &avail.Status, &avail.Notes, &avail.UpdatedAt); err != nil {
+			return nil, err
+		}
+		availability = append(availability, avail)
+	}
+
+	return availability, rows.Err()
+}
+
+func (s *postgresAvailability) Upsert(ctx context.Context, availability *models.Availability) error {
+	query := `
+		INSERT INTO availability (id, event_id, user_id, status, notes, updated_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, NOW())
+		ON CONFLICT (event_id, user_id)
+		DO UPDATE SET status = $3, notes = $4, updated_at = NOW()`
+
+	_, err := s.db.ExecContext(ctx, query,
+		availability.EventID, availability.UserID, availability.Status, availability.Notes,
+	)
+	return err
+}
+
+func (s *postgresAvailability) Roster(ctx context.Context, eventID, clubID uuid.UUID) ([]RosterEntry, error) {
+	query := `
+		SELECT u.name, COALESCE(a.status, ''), COALESCE(a.notes, '')
+		FROM club_members cm
+		JOIN users u ON cm.user_id = u.id
+		LEFT JOIN availability a ON a.user_id = cm.user_id AND a.event_id = $1
+		WHERE cm.club_id = $2 AND cm.is_active = true
+		ORDER BY u.name ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, eventID, clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roster []RosterEntry
+	for rows.Next() {
+		var entry RosterEntry
+		if err := rows.Scan(&entry.Name, &entry.Status, &entry.Notes); err != nil {
+			return nil, err
+		}
+		roster = append(roster, entry)
+	}
+
+	return roster, rows.Err()
+}
+
+func (s *postgresAvailability) Summary(ctx context.Context, eventID uuid.UUID) (*models.AvailabilitySummary, error) {
+	query := `
+		SELECT status, count
+		FROM availability_counts
+		WHERE event_id = $1`
+
+	rows, err := s.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := &models.AvailabilitySummary{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		summary.Add(status, count)
+	}
+
+	return summary, rows.Err()
+}
+
+func (s *postgresAvailability) RepairSummaries(ctx context.Context) (int, error) {
+	var repaired int
+	err := s.db.QueryRowContext(ctx, `SELECT repair_availability_counts()`).Scan(&repaired)
+	return repaired, err
+}
+
+type postgresExchangeRates struct {
+	db *database.DB
+}
+
+func (s *postgresExchangeRates) Get(ctx context.Context, base, quote string) (string, error) {
+	query := `SELECT rate::text FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2`
+
+	var rate string
+	if err := s.db.QueryRowContext(ctx, query, base, quote).Scan(&rate); err != nil {
+		return "", notFound(err)
+	}
+	return trimDecimal(rate), nil
+}
+
+func (s *postgresExchangeRates) Set(ctx context.Context, rate models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = NOW()`
+
+	_, err := s.db.ExecContext(ctx, query, rate.Base, rate.Quote, rate.Rate, rate.UpdatedBy)
+	return err
+}
+
+func (s *postgresExchangeRates) List(ctx context.Context) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate::text, updated_by, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.Base, &rate.Quote, &rate.Rate, &rate.UpdatedBy, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.Rate = trimDecimal(rate.Rate)
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+type postgresRemovals struct {
+	db *database.DB
+}
+
+func (s *postgresRemovals) DeleteEvent(ctx context.Context, eventID, deletedBy uuid.UUID, dryRun bool) (*Removal, error) {
+	return s.remove(ctx, dryRun, func(tx *sql.Tx) ([]Effect, error) {
+		result, err := tx.ExecContext(ctx,
+			`UPDATE events SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`, eventID, deletedBy)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireRow(result); err != nil {
+			return nil, err
+		}
+
+		// Items and availability stay so a global admin can restore the event
+		var items, responses int64
+		err = tx.QueryRowContext(ctx, `
+			SELECT (SELECT COUNT(*) FROM event_items WHERE event_id = $1),
+			       (SELECT COUNT(*) FROM availability WHERE event_id = $1)`,
+			eventID,
+		).Scan(&items, &responses)
+		if err != nil {
+			return nil, err
+		}
+		return []Effect{{Table: "availability", Hidden: responses}, {Table: "event_items", Hidden: items}}, nil
+	})
+}
+
+func (s *postgresRemovals) RemoveMember(ctx context.Context, clubID, memberID uuid.UUID, dryRun bool) (*Removal, error) {
+	return s.remove(ctx, dryRun, func(tx *sql.Tx) ([]Effect, error) {
+		result, err := tx.ExecContext(ctx, `DELETE FROM club_members WHERE id = $1 AND club_id = $2`, memberID, clubID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, requireRow(result)
+	})
+}
+
+func (s *postgresRemovals) PurgeEvent(ctx context.Context, eventID uuid.UUID, dryRun bool) (*Removal, error) {
+	return s.remove(ctx, dryRun, func(tx *sql.Tx) ([]Effect, error) {
+		result, err := tx.ExecContext(ctx, `DELETE FROM events WHERE id = $1 AND deleted_at IS NOT NULL`, eventID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, requireRow(result)
+	})
+}
+
+func (s *postgresRemovals) PurgeClub(ctx context.Context, clubID uuid.UUID, dryRun bool) (*Removal, error) {
+	return s.remove(ctx, dryRun, func(tx *sql.Tx) ([]Effect, error) {
+		result, err := tx.ExecContext(ctx, `DELETE FROM clubs WHERE id = $1 AND deleted_at IS NOT NULL`, clubID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, requireRow(result)
+	})
+}
+
+// errDryRun rolls back a dry run's transaction once its effects are known
+var errDryRun = errors.New("dry run")
+
+// remove runs apply in a transaction and reports the rows it changed, with
+// any further effects apply returns. A dry run is rolled back.
+func (s *postgresRemovals) remove(ctx context.Context, dryRun bool, apply func(tx *sql.Tx) ([]Effect, error)) (*Removal, error) {
+	var removal *Removal
+	err := s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		extra, err := apply(tx)
+		if err != nil {
+			return err
+		}
+		effects, err := TxEffects(ctx, tx)
+		if err != nil {
+			return err
+		}
+		removal = &Removal{DryRun: dryRun, Effects: mergeEffects(effects, extra)}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return removal, nil
+}
+
+// TxEffects reports the rows each table has had inserted, updated or deleted
+// so far in tx, cascades and triggers included, from PostgreSQL's
+// per-transaction statistics
+func TxEffects(ctx context.Context, tx *sql.Tx) ([]Effect, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT relname, n_tup_ins, n_tup_upd, n_tup_del
+		FROM pg_stat_xact_user_tables
+		WHERE n_tup_ins + n_tup_upd + n_tup_del > 0
+		ORDER BY relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	effects := []Effect{}
+	for rows.Next() {
+		var effect Effect
+		if err := rows.Scan(&effect.Table, &effect.Inserted, &effect.Updated, &effect.Deleted); err != nil {
+			return nil, err
+		}
+		effects = append(effects, effect)
+	}
+	return effects, rows.Err()
+}
+
+// trimDecimal drops the trailing zeros NUMERIC pads fractions with ("0.9200000000" -> "0.92")
+func trimDecimal(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
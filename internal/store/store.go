@@ -0,0 +1,228 @@
+// Package store decouples handlers from raw SQL. Each aggregate has a store
+// interface with a PostgreSQL implementation for production and an in-memory
+// implementation for tests and the mock data mode.
+//
+// The stores cover users, club membership and currency, club roles, events,
+// event items, availability, exchange rates and destructive removals. The
+// availability, event item, club role and exchange rate handlers use them
+// alone; the club, event, auth, dues, contribution, tag and trash handlers use
+// them for those reads and writes and still run their other queries directly.
+// The remaining handlers (announcements, polls, invites, verification,
+// partnerships, attachments, templates and the rest) have no store yet and
+// take *database.DB, so they are tested through pure helpers rather than the
+// memory stores. Health checks and the admin overview read the database
+// itself and are meant to stay that way.
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/money"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrItemsChanged is returned when a new order does not list exactly the event's items
+var ErrItemsChanged = errors.New("items do not match the event's items")
+
+// ErrVersionConflict is returned when a record is no longer at the version an
+// edit was based on
+var ErrVersionConflict = errors.New("record was changed since it was read")
+
+// ErrRoleInUse is returned when deleting a club role active members still hold
+var ErrRoleInUse = errors.New("role is held by members")
+
+// ErrAlreadyAssigned is returned when claiming an item another member is assigned to
+var ErrAlreadyAssigned = errors.New("item already assigned")
+
+// UserStore reads user accounts
+type UserStore interface {
+	GetByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+// ClubStore reads club membership and settings
+type ClubStore interface {
+	// MemberRole returns the role of an active member, owner for the club's
+	// owner, or ErrNotFound (also for soft-deleted clubs)
+	MemberRole(ctx context.Context, clubID, userID uuid.UUID) (string, error)
+	// Currency returns the ISO 4217 code the club keeps its books in
+	Currency(ctx context.Context, clubID uuid.UUID) (string, error)
+}
+
+// ClubRoleStore manages the custom roles clubs define
+type ClubRoleStore interface {
+	// List returns the club's custom roles ordered by name
+	List(ctx context.Context, clubID uuid.UUID) ([]models.ClubRole, error)
+	// Get returns the club's custom role called name, or ErrNotFound
+	Get(ctx context.Context, clubID uuid.UUID, name string) (*models.ClubRole, error)
+	Create(ctx context.Context, role *models.ClubRole) error
+	// Update replaces the capabilities of a role and returns it, or ErrNotFound
+	Update(ctx context.Context, clubID, roleID uuid.UUID, capabilities []string) (*models.ClubRole, error)
+	// Delete removes a role, or returns ErrRoleInUse while active members hold
+	// it, or ErrNotFound
+	Delete(ctx context.Context, clubID, roleID uuid.UUID) error
+}
+
+// EventStore reads events
+type EventStore interface {
+	// GetByID returns an event, or ErrNotFound when it or its club is soft-deleted
+	GetByID(ctx context.Context, eventID uuid.UUID) (*models.Event, error)
+}
+
+// EventItemUpdate holds the item fields that may change; nil fields are left as they are.
+// Cost and ExchangeRate (to the club currency) are set together.
+type EventItemUpdate struct {
+	Status        *string
+	Notes         *string
+	Quantity      *float64
+	Cost          *money.Money
+	ExchangeRate  *string
+	DueDate       *time.Time
+	ClearNotes    bool // removes the notes; Notes is then ignored
+	ClearQuantity bool // removes the quantity; Quantity is then ignored
+	ClearCost     bool // removes the cost and its exchange rate; Cost is then ignored
+	ClearDueDate  bool // removes the due date; DueDate is then ignored
+	// IfVersion makes the update only while the item is at this version, else
+	// it returns ErrVersionConflict. Nil updates any version.
+	IfVersion *int
+}
+
+// EventItemOp is one change in a batch: an item to create, or an update or
+// deletion of the event's item ItemID
+type EventItemOp struct {
+	Create *models.EventItem
+	ItemID uuid.UUID
+	Update *EventItemUpdate
+	Delete bool
+}
+
+// EventItemStore manages an event's coordination items
+type EventItemStore interface {
+	// ListByEvent returns the event's items in their checklist order
+	ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.EventItem, error)
+	// Get returns one of the event's items, or ErrNotFound
+	Get(ctx context.Context, eventID, itemID uuid.UUID) (*models.EventItem, error)
+	// Create adds an item at the end of the event's checklist, setting its SortOrder
+	Create(ctx context.Context, item *models.EventItem) error
+	Update(ctx context.Context, eventID, itemID uuid.UUID, update EventItemUpdate) error
+	Delete(ctx context.Context, eventID, itemID uuid.UUID) error
+	// Apply makes all of ops in one transaction, in order. When one fails none
+	// is made, and failed is its index (ErrNotFound for a missing item), or -1
+	// when the transaction itself failed.
+	Apply(ctx context.Context, eventID uuid.UUID, ops []EventItemOp) (failed int, err error)
+	// Reorder puts the event's items in the order of itemIDs, which must list
+	// each of them once, or returns ErrItemsChanged
+	Reorder(ctx context.Context, eventID uuid.UUID, itemIDs []uuid.UUID) error
+	// Claim assigns an unassigned item to userID, or returns ErrAlreadyAssigned
+	// when another member has it. A pending item becomes assigned.
+	Claim(ctx context.Context, eventID, itemID, userID uuid.UUID) (*models.EventItem, error)
+	// Assign sets who an item is assigned to, nil to clear it, and returns the
+	// item with who had it before. Pending and assigned statuses follow. With
+	// ifVersion, it returns ErrVersionConflict unless the item is at that version.
+	Assign(ctx context.Context, eventID, itemID uuid.UUID, userID *uuid.UUID, ifVersion *int) (item *models.EventItem, previous *uuid.UUID, err error)
+	// ShoppingListAssignee returns who shops for the event's whole shopping list, or nil
+	ShoppingListAssignee(ctx context.Context, eventID uuid.UUID) (*uuid.UUID, error)
+	// AssignShoppingList sets the shopper for the event's whole list; nil clears it
+	AssignShoppingList(ctx context.Context, eventID uuid.UUID, userID *uuid.UUID) error
+}
+
+// RosterEntry is one active club member and their response for an event
+type RosterEntry struct {
+	Name   string
+	Status string
+	Notes  string
+}
+
+// AvailabilityStore manages members' availability responses for events
+type AvailabilityStore interface {
+	ListByEvent(ctx context.Context, eventID uuid.UUID) ([]models.Availability, error)
+	Upsert(ctx context.Context, availability *models.Availability) error
+	// Roster lists every active member of the club, with or without a response, ordered by name
+	Roster(ctx context.Context, eventID, clubID uuid.UUID) ([]RosterEntry, error)
+	// Summary counts the event's responses per status from precomputed counters
+	Summary(ctx context.Context, eventID uuid.UUID) (*models.AvailabilitySummary, error)
+	// RepairSummaries recomputes counters that drifted from the responses and returns how many were corrected
+	RepairSummaries(ctx context.Context) (int, error)
+}
+
+// ExchangeRateStore manages the admin-maintained exchange rates
+type ExchangeRateStore interface {
+	// Get returns how many units of quote one unit of base is worth, or ErrNotFound
+	Get(ctx context.Context, base, quote string) (string, error)
+	Set(ctx context.Context, rate models.ExchangeRate) error
+	List(ctx context.Context) ([]models.ExchangeRate, error)
+}
+
+// Effect is how many rows of a table an operation inserted, updated or
+// deleted, including rows deleted by ON DELETE CASCADE and changed by
+// triggers. Hidden rows are kept but go out of sight with a soft-deleted parent.
+type Effect struct {
+	Table    string `json:"table"`
+	Inserted int64  `json:"inserted"`
+	Updated  int64  `json:"updated"`
+	Deleted  int64  `json:"deleted"`
+	Hidden   int64  `json:"hidden,omitempty"`
+}
+
+// Removal is what a destructive operation did or, on a dry run, would do
+type Removal struct {
+	DryRun  bool     `json:"dryRun"`
+	Effects []Effect `json:"effects"` // ordered by table
+}
+
+// RemovalStore carries out destructive operations. On a dry run each runs in
+// full and is then rolled back, so the Removal shows exactly what committing
+// would have done.
+type RemovalStore interface {
+	// DeleteEvent soft-deletes a live event, or returns ErrNotFound
+	DeleteEvent(ctx context.Context, eventID, deletedBy uuid.UUID, dryRun bool) (*Removal, error)
+	// RemoveMember deletes a club membership, or returns ErrNotFound
+	RemoveMember(ctx context.Context, clubID, memberID uuid.UUID, dryRun bool) (*Removal, error)
+	// PurgeEvent permanently deletes a soft-deleted event, or returns ErrNotFound
+	PurgeEvent(ctx context.Context, eventID uuid.UUID, dryRun bool) (*Removal, error)
+	// PurgeClub permanently deletes a soft-deleted club, or returns ErrNotFound
+	PurgeClub(ctx context.Context, clubID uuid.UUID, dryRun bool) (*Removal, error)
+}
+
+// Stores bundles the store for each aggregate
+type Stores struct {
+	Users         UserStore
+	Clubs         ClubStore
+	ClubRoles     ClubRoleStore
+	Events        EventStore
+	EventItems    EventItemStore
+	Availability  AvailabilityStore
+	ExchangeRates ExchangeRateStore
+	Removals      RemovalStore
+}
+
+// mergeEffects adds extra to effects, table by table, ordered by table
+func mergeEffects(effects, extra []Effect) []Effect {
+	merged := append([]Effect{}, effects...)
+	for _, e := range extra {
+		found := false
+		for i := range merged {
+			if merged[i].Table == e.Table {
+				merged[i].Inserted += e.Inserted
+				merged[i].Updated += e.Updated
+				merged[i].Deleted += e.Deleted
+				merged[i].Hidden += e.Hidden
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, e)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Table < merged[j].Table })
+	return merged
+}
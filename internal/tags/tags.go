@@ -0,0 +1,384 @@
+// Package tags keeps the canonical set of club tags.
+//
+// Clubs store tag names. Each canonical tag may have aliases, names that
+// stand for it: when a club is tagged with an alias it gets the tag instead,
+// and searching by an alias finds the tag's clubs. Renaming a tag keeps the
+// old name as an alias, and merging one tag into another makes the merged
+// tag's name and aliases aliases of the other. Both update the clubs using
+// the old names.
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// MaxLength is the longest tag name, in characters
+const MaxLength = 50
+
+var (
+	// ErrNotFound means no tag has the given ID
+	ErrNotFound = errors.New("tag not found")
+	// ErrTaken means the name is already a tag or an alias
+	ErrTaken = errors.New("tag name already in use")
+	// ErrSameTag means a tag was to be merged into itself
+	ErrSameTag = errors.New("cannot merge a tag into itself")
+)
+
+// Tag is a canonical tag
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases"`
+	Clubs     int       `json:"clubs"` // clubs tagged with it
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Suggestion is a tag matching typed text, directly or through an alias
+type Suggestion struct {
+	Name      string  `json:"name"`
+	MatchedBy *string `json:"matchedBy,omitempty"` // the alias that matched, if not the name
+	Clubs     int     `json:"clubs"`
+}
+
+// Normalize lower-cases a tag and collapses its whitespace
+func Normalize(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// NormalizeAll normalizes names, dropping empty ones and duplicates
+func NormalizeAll(names []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, name := range names {
+		if name = Normalize(name); name != "" && !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+// Store reads and changes the tag taxonomy
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// List returns every tag with its aliases, by name
+func (s *Store) List(ctx context.Context) ([]Tag, error) {
+	query := `
+		SELECT t.id, t.name, t.created_at,
+		       ARRAY(SELECT a.alias FROM tag_aliases a WHERE a.tag_id = t.id ORDER BY a.alias),
+		       (SELECT COUNT(*) FROM clubs c WHERE c.tags @> ARRAY[t.name::TEXT] AND c.deleted_at IS NULL)
+		FROM tags t
+		ORDER BY t.name`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		var aliases models.StringArray
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &aliases, &tag.Clubs); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tag.Aliases = append([]string{}, aliases...)
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Get returns one tag
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (Tag, error) {
+	return s.get(ctx, s.db.QueryRowContext, id)
+}
+
+// Suggest returns up to limit tags whose name, a word of it, or an alias
+// starts with prefix, the most used first
+func (s *Store) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	prefix = Normalize(prefix)
+	if prefix == "" {
+		return []Suggestion{}, nil
+	}
+
+	query := `
+		SELECT name, alias, clubs FROM (
+			SELECT DISTINCT ON (t.id) t.name, m.alias,
+			       (SELECT COUNT(*) FROM clubs c WHERE c.tags @> ARRAY[t.name::TEXT] AND c.deleted_at IS NULL) AS clubs
+			FROM (
+				SELECT id AS tag_id, NULL::TEXT AS alias FROM tags WHERE name LIKE $1 || '%' OR name LIKE '% ' || $1 || '%'
+				UNION ALL
+				SELECT tag_id, alias FROM tag_aliases WHERE alias LIKE $1 || '%'
+			) m
+			JOIN tags t ON t.id = m.tag_id
+			ORDER BY t.id, m.alias NULLS FIRST
+		) matches
+		ORDER BY clubs DESC, name
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, escapeLike(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+	for rows.Next() {
+		var suggestion Suggestion
+		if err := rows.Scan(&suggestion.Name, &suggestion.MatchedBy, &suggestion.Clubs); err != nil {
+			return nil, fmt.Errorf("failed to scan tag suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// Create adds a canonical tag
+func (s *Store) Create(ctx context.Context, name string) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkFree(ctx, tx, name, uuid.Nil); err != nil {
+		return Tag{}, err
+	}
+
+	var id uuid.UUID
+	if err := tx.QueryRowContext(ctx, `INSERT INTO tags (name) VALUES ($1) RETURNING id`, name).Scan(&id); err != nil {
+		return Tag{}, fmt.Errorf("failed to create tag: %w", err)
+	}
+	// Clubs already carrying the name as an unregistered tag count at once
+	tag, err := s.get(ctx, tx.QueryRowContext, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	return tag, tx.Commit()
+}
+
+// Rename changes a tag's name. The old name becomes an alias, so clubs and
+// links using it keep working.
+func (s *Store) Rename(ctx context.Context, id uuid.UUID, name string) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := lockTag(ctx, tx, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	if old == name {
+		tag, err := s.get(ctx, tx.QueryRowContext, id)
+		return tag, err
+	}
+	if err := checkFree(ctx, tx, name, id); err != nil {
+		return Tag{}, err
+	}
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		// The new name may have been one of the tag's own aliases
+		{`DELETE FROM tag_aliases WHERE alias = $1`, []interface{}{name}},
+		{`UPDATE tags SET name = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, []interface{}{id, name}},
+		{`INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)`, []interface{}{old, id}},
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return Tag{}, fmt.Errorf("failed to rename tag: %w", err)
+		}
+	}
+	if err := recanonicalize(ctx, tx, []string{old}); err != nil {
+		return Tag{}, err
+	}
+
+	tag, err := s.get(ctx, tx.QueryRowContext, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	return tag, tx.Commit()
+}
+
+// Merge folds source into target: source's name and aliases become aliases of
+// target, clubs tagged with source get target, and source is deleted. It
+// returns the rows changed, cascades included; a dry run is rolled back.
+func (s *Store) Merge(ctx context.Context, sourceID, targetID uuid.UUID, dryRun bool) (Tag, []store.Effect, error) {
+	if sourceID == targetID {
+		return Tag{}, nil, ErrSameTag
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Tag{}, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	source, err := lockTag(ctx, tx, sourceID)
+	if err != nil {
+		return Tag{}, nil, err
+	}
+	if _, err := lockTag(ctx, tx, targetID); err != nil {
+		return Tag{}, nil, err
+	}
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE tag_aliases SET tag_id = $2 WHERE tag_id = $1`, []interface{}{sourceID, targetID}},
+		{`DELETE FROM tags WHERE id = $1`, []interface{}{sourceID}},
+		{`INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)`, []interface{}{source, targetID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return Tag{}, nil, fmt.Errorf("failed to merge tags: %w", err)
+		}
+	}
+	if err := recanonicalize(ctx, tx, []string{source}); err != nil {
+		return Tag{}, nil, err
+	}
+
+	tag, err := s.get(ctx, tx.QueryRowContext, targetID)
+	if err != nil {
+		return Tag{}, nil, err
+	}
+	effects, err := store.TxEffects(ctx, tx)
+	if err != nil {
+		return Tag{}, nil, fmt.Errorf("failed to read merge effects: %w", err)
+	}
+	if dryRun {
+		return tag, effects, nil
+	}
+	return tag, effects, tx.Commit()
+}
+
+// AddAlias makes alias stand for a tag. Clubs tagged with the alias get the tag.
+func (s *Store) AddAlias(ctx context.Context, id uuid.UUID, alias string) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTag(ctx, tx, id); err != nil {
+		return Tag{}, err
+	}
+	if err := checkFree(ctx, tx, alias, uuid.Nil); err != nil {
+		return Tag{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)`, alias, id); err != nil {
+		return Tag{}, fmt.Errorf("failed to add tag alias: %w", err)
+	}
+	if err := recanonicalize(ctx, tx, []string{alias}); err != nil {
+		return Tag{}, err
+	}
+
+	tag, err := s.get(ctx, tx.QueryRowContext, id)
+	if err != nil {
+		return Tag{}, err
+	}
+	return tag, tx.Commit()
+}
+
+// RemoveAlias stops alias from standing for a tag; clubs keep the tag
+func (s *Store) RemoveAlias(ctx context.Context, id uuid.UUID, alias string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM tag_aliases WHERE tag_id = $1 AND alias = $2`, id, alias)
+	if err != nil {
+		return fmt.Errorf("failed to remove tag alias: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type queryRower func(ctx context.Context, query string, args ...interface{}) *sql.Row
+
+func (s *Store) get(ctx context.Context, queryRow queryRower, id uuid.UUID) (Tag, error) {
+	query := `
+		SELECT t.id, t.name, t.created_at,
+		       ARRAY(SELECT a.alias FROM tag_aliases a WHERE a.tag_id = t.id ORDER BY a.alias),
+		       (SELECT COUNT(*) FROM clubs c WHERE c.tags @> ARRAY[t.name::TEXT] AND c.deleted_at IS NULL)
+		FROM tags t
+		WHERE t.id = $1`
+
+	var tag Tag
+	var aliases models.StringArray
+	err := queryRow(ctx, query, id).Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &aliases, &tag.Clubs)
+	if err == sql.ErrNoRows {
+		return Tag{}, ErrNotFound
+	}
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to get tag: %w", err)
+	}
+	tag.Aliases = append([]string{}, aliases...)
+	return tag, nil
+}
+
+// lockTag returns a tag's name, locking it until the transaction ends
+func lockTag(ctx context.Context, tx *sql.Tx, id uuid.UUID) (string, error) {
+	var name string
+	err := tx.QueryRowContext(ctx, `SELECT name FROM tags WHERE id = $1 FOR UPDATE`, id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag: %w", err)
+	}
+	return name, nil
+}
+
+// checkFree fails with ErrTaken if name is another tag, or an alias of a tag
+// other than except
+func checkFree(ctx context.Context, tx *sql.Tx, name string, except uuid.UUID) error {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM tags WHERE name = $1 AND id <> $2)
+		    OR EXISTS (SELECT 1 FROM tag_aliases WHERE alias = $1 AND tag_id <> $2)`
+
+	var taken bool
+	if err := tx.QueryRowContext(ctx, query, name, except).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check tag name: %w", err)
+	}
+	if taken {
+		return ErrTaken
+	}
+	return nil
+}
+
+// recanonicalize replaces names that are now aliases in the clubs using them
+func recanonicalize(ctx context.Context, tx *sql.Tx, names []string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE clubs SET tags = canonical_tags(tags), updated_at = CURRENT_TIMESTAMP WHERE tags && $1`,
+		models.StringArray(names))
+	if err != nil {
+		return fmt.Errorf("failed to update club tags: %w", err)
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcard characters in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
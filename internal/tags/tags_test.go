@@ -0,0 +1,33 @@
+package tags
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Sci-Fi":               "sci-fi",
+		"  Science   Fiction ": "science fiction",
+		"\t":                   "",
+	}
+	for input, expected := range tests {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestNormalizeAll(t *testing.T) {
+	got := NormalizeAll([]string{"Mystery", " ", "mystery", "Book  Club", "sci-fi"})
+	expected := []string{"mystery", "book club", "sci-fi"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("Unexpected escaped pattern %q", got)
+	}
+}
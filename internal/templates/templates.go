@@ -0,0 +1,252 @@
+// Package templates keeps event templates: the type, duration, agenda and
+// item set a club starts similar events from.
+//
+// A template belongs to the club that saved it. Shared templates are also
+// listed in a library other clubs browse and apply, credited to the club and
+// member who wrote them; uses counts the events made from each.
+package templates
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/vocab"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound means no template has the ID, or the club cannot see it
+var ErrNotFound = errors.New("event template not found")
+
+// Template is a saved event template
+type Template struct {
+	ID              uuid.UUID            `json:"id"`
+	ClubID          uuid.UUID            `json:"clubId"`
+	ClubName        string               `json:"clubName"` // attribution in the library
+	CreatedBy       *uuid.UUID           `json:"createdBy,omitempty"`
+	CreatedByName   *string              `json:"createdByName,omitempty"`
+	Name            string               `json:"name"`
+	Description     *string              `json:"description,omitempty"`
+	Type            string               `json:"type"`
+	DurationMinutes *int                 `json:"durationMinutes,omitempty"`
+	Agenda          models.Agenda        `json:"agenda"`
+	Items           models.TemplateItems `json:"items"`
+	Shared          bool                 `json:"shared"`
+	SharedAt        *time.Time           `json:"sharedAt,omitempty"`
+	Uses            int                  `json:"uses"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+}
+
+// VisibleTo reports whether a club may view and apply the template: its own,
+// or any shared one
+func (t Template) VisibleTo(clubID uuid.UUID) bool {
+	return t.Shared || t.ClubID == clubID
+}
+
+// Duration is how long events made from the template last, or 0 when unset
+func (t Template) Duration() time.Duration {
+	if t.DurationMinutes == nil {
+		return 0
+	}
+	return time.Duration(*t.DurationMinutes) * time.Minute
+}
+
+// ItemsFor returns the template's items with categories the club does not
+// use replaced by "other", or by its first category when it has no "other",
+// so a template from another club still applies
+func (t Template) ItemsFor(categories vocab.Vocabulary) models.TemplateItems {
+	fallback := "other"
+	if !categories.Has(fallback) && len(categories.Terms) > 0 {
+		fallback = categories.Terms[0].Value
+	}
+
+	items := make(models.TemplateItems, len(t.Items))
+	for i, item := range t.Items {
+		if !categories.Has(item.Category) {
+			item.Category = fallback
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// LibraryQuery selects a page of shared templates
+type LibraryQuery struct {
+	Search string // matches the name or description
+	Type   string
+	Sort   string // "popular" (most used first, the default) or "newest"
+	Limit  int
+	Offset int
+}
+
+// Store reads and changes event templates
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+const selectTemplates = `
+	SELECT t.id, t.club_id, c.name, t.created_by, u.name, t.name, t.description, t.type,
+	       t.duration_minutes, t.agenda, t.items, t.shared, t.shared_at, t.uses, t.created_at, t.updated_at
+	FROM event_templates t
+	JOIN clubs c ON c.id = t.club_id
+	LEFT JOIN users u ON u.id = t.created_by`
+
+// ListByClub returns a club's own templates, by name
+func (s *Store) ListByClub(ctx context.Context, clubID uuid.UUID) ([]Template, error) {
+	rows, err := s.db.QueryContext(ctx, selectTemplates+` WHERE t.club_id = $1 ORDER BY t.name, t.id`, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event templates: %w", err)
+	}
+	return scanTemplates(rows)
+}
+
+// Library returns a page of shared templates from clubs that are not deleted,
+// with the number of templates matching the query
+func (s *Store) Library(ctx context.Context, q LibraryQuery) ([]Template, int, error) {
+	where := ` WHERE t.shared AND c.deleted_at IS NULL`
+	args := []interface{}{}
+	if q.Search != "" {
+		args = append(args, "%"+escapeLike(q.Search)+"%")
+		n := strconv.Itoa(len(args))
+		where += ` AND (t.name ILIKE $` + n + ` OR t.description ILIKE $` + n + `)`
+	}
+	if q.Type != "" {
+		args = append(args, q.Type)
+		where += ` AND t.type = $` + strconv.Itoa(len(args))
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_templates t JOIN clubs c ON c.id = t.club_id`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count shared event templates: %w", err)
+	}
+
+	order := ` ORDER BY t.uses DESC, t.shared_at DESC, t.id`
+	if q.Sort == "newest" {
+		order = ` ORDER BY t.shared_at DESC, t.id`
+	}
+	args = append(args, q.Limit, q.Offset)
+	query := selectTemplates + where + order + ` LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query shared event templates: %w", err)
+	}
+	list, err := scanTemplates(rows)
+	return list, total, err
+}
+
+// Get returns a template
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (Template, error) {
+	rows, err := s.db.QueryContext(ctx, selectTemplates+` WHERE t.id = $1`, id)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to query event template: %w", err)
+	}
+	list, err := scanTemplates(rows)
+	if err != nil {
+		return Template{}, err
+	}
+	if len(list) == 0 {
+		return Template{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+// Create saves a new template for t.ClubID and returns it as stored
+func (s *Store) Create(ctx context.Context, t Template) (Template, error) {
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO event_templates (club_id, created_by, name, description, type, duration_minutes,
+		                             agenda, items, shared, shared_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CASE WHEN $9 THEN CURRENT_TIMESTAMP END)
+		RETURNING id`,
+		t.ClubID, t.CreatedBy, t.Name, t.Description, t.Type, t.DurationMinutes, t.Agenda, t.Items, t.Shared,
+	).Scan(&id)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to create event template: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Update replaces a club's template with t. Sharing it again moves it to the
+// top of the newest templates; unsharing takes it out of the library.
+func (s *Store) Update(ctx context.Context, t Template) (Template, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE event_templates
+		SET name = $3, description = $4, type = $5, duration_minutes = $6, agenda = $7, items = $8,
+		    shared_at = CASE WHEN NOT $9 THEN NULL WHEN shared THEN shared_at ELSE CURRENT_TIMESTAMP END,
+		    shared = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND club_id = $2`,
+		t.ID, t.ClubID, t.Name, t.Description, t.Type, t.DurationMinutes, t.Agenda, t.Items, t.Shared,
+	)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to update event template: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return Template{}, ErrNotFound
+	}
+	return s.Get(ctx, t.ID)
+}
+
+// Delete removes a club's template. Events made from it are unaffected.
+func (s *Store) Delete(ctx context.Context, clubID, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM event_templates WHERE id = $1 AND club_id = $2`, id, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to delete event template: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordUse counts an event made from the template
+func (s *Store) RecordUse(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE event_templates SET uses = uses + 1 WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to record event template use: %w", err)
+	}
+	return nil
+}
+
+func scanTemplates(rows *sql.Rows) ([]Template, error) {
+	defer rows.Close()
+
+	list := []Template{}
+	for rows.Next() {
+		var t Template
+		err := rows.Scan(
+			&t.ID, &t.ClubID, &t.ClubName, &t.CreatedBy, &t.CreatedByName, &t.Name, &t.Description, &t.Type,
+			&t.DurationMinutes, &t.Agenda, &t.Items, &t.Shared, &t.SharedAt, &t.Uses, &t.CreatedAt, &t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event template: %w", err)
+		}
+		if t.Agenda == nil {
+			t.Agenda = models.Agenda{}
+		}
+		if t.Items == nil {
+			t.Items = models.TemplateItems{}
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event templates: %w", err)
+	}
+	return list, nil
+}
+
+// escapeLike makes s match literally in a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
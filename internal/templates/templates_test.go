@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/vocab"
+
+	"github.com/google/uuid"
+)
+
+func TestVisibleTo(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	template := Template{ClubID: owner}
+
+	if !template.VisibleTo(owner) || template.VisibleTo(other) {
+		t.Error("Expected an unshared template to be visible to its club only")
+	}
+	template.Shared = true
+	if !template.VisibleTo(other) {
+		t.Error("Expected a shared template to be visible to every club")
+	}
+}
+
+func TestDuration(t *testing.T) {
+	minutes := 90
+	if got := (Template{DurationMinutes: &minutes}).Duration(); got != 90*time.Minute {
+		t.Errorf("Expected 90 minutes, got %s", got)
+	}
+	if got := (Template{}).Duration(); got != 0 {
+		t.Errorf("Expected no duration, got %s", got)
+	}
+}
+
+func TestItemsFor(t *testing.T) {
+	template := Template{Items: models.TemplateItems{
+		{Name: "Tea", Category: "food"},
+		{Name: "Raffle tickets", Category: "raffle"},
+	}}
+
+	items := template.ItemsFor(vocab.Defaults(vocab.ItemCategory))
+	if items[0].Category != "food" || items[1].Category != "other" {
+		t.Errorf("Expected unknown categories to become other, got %+v", items)
+	}
+	if template.Items[1].Category != "raffle" {
+		t.Error("Expected the template itself to be unchanged")
+	}
+
+	custom := vocab.Vocabulary{Terms: []vocab.Term{{Value: "snacks"}, {Value: "supplies"}}, Custom: true}
+	items = template.ItemsFor(custom)
+	if items[0].Category != "snacks" || items[1].Category != "snacks" {
+		t.Errorf("Expected the club's first category without other, got %+v", items)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("Unexpected escaped pattern %q", got)
+	}
+}
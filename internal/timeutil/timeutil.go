@@ -0,0 +1,111 @@
+// Package timeutil parses and formats the dates and times the API exchanges.
+//
+// Clients send dates as YYYY-MM-DD and times of day as HH:MM. Responses carry
+// instants as RFC 3339 in UTC. Event dates and times are read from DATE and
+// TIME columns, which the driver scans into strings as RFC 3339 timestamps
+// (e.g. "2030-01-15T00:00:00Z" and "0000-01-01T19:30:00Z"); the parsers accept
+// those as well as the client formats. Clients may also send ISO 8601
+// date-times, with or without an offset.
+package timeutil
+
+import (
+	"errors"
+	"time"
+)
+
+// Layouts of dates and times in requests and responses
+const (
+	DateLayout        = "2006-01-02"
+	TimeLayout        = "15:04"
+	TimeSecondsLayout = "15:04:05"
+)
+
+// ErrInvalid is returned for values in none of the accepted layouts
+var ErrInvalid = errors.New("invalid date or time")
+
+// ParseDate parses a YYYY-MM-DD date, or the date of a scanned DATE column, as
+// midnight UTC
+func ParseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, ErrInvalid
+}
+
+// ParseTime parses an HH:MM or HH:MM:SS time of day, or a scanned TIME column,
+// as that time on January 1st of year 0 in UTC
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range []string{TimeLayout, TimeSecondsLayout, "15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return time.Date(0, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC), nil
+	}
+	return time.Time{}, ErrInvalid
+}
+
+// CombineDateTime joins a date and a time of day, as accepted by ParseDate and
+// ParseTime, into one UTC instant
+func CombineDateTime(date, clock string) (time.Time, error) {
+	return CombineDateTimeIn(date, clock, time.UTC)
+}
+
+// CombineDateTimeIn joins a date and a time of day into the instant they name
+// on the wall clock of loc
+func CombineDateTimeIn(date, clock string, loc *time.Location) (time.Time, error) {
+	d, err := ParseDate(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	c, err := ParseTime(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), loc), nil
+}
+
+// ParseTimestamp parses an RFC 3339 instant
+func ParseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, ErrInvalid
+	}
+	return t, nil
+}
+
+// localTimestampLayouts are ISO 8601 date-times without an offset
+var localTimestampLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04"}
+
+// ParseTimestampIn parses an ISO 8601 date-time. One with an offset or Z names
+// an instant; one without is read on the wall clock of loc.
+func ParseTimestampIn(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalid
+}
+
+// FormatDate formats the date of t as YYYY-MM-DD, in t's location
+func FormatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
+
+// FormatTimestamp formats t as RFC 3339 in UTC
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FormatTimestampIn formats t as RFC 3339 in loc, for times shown in a reader's timezone
+func FormatTimestampIn(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(time.RFC3339)
+}
@@ -0,0 +1,123 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2030, time.January, 15, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{"2030-01-15", "2030-01-15T00:00:00Z"} {
+		if got, err := ParseDate(s); err != nil || !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"", "15/01/2030", "2030-13-01"} {
+		if _, err := ParseDate(s); err != ErrInvalid {
+			t.Errorf("Expected ParseDate(%q) to fail, got %v", s, err)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"19:30", time.Date(0, time.January, 1, 19, 30, 0, 0, time.UTC)},
+		{"19:30:15", time.Date(0, time.January, 1, 19, 30, 15, 0, time.UTC)},
+		{"0000-01-01T19:30:00Z", time.Date(0, time.January, 1, 19, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got, err := ParseTime(tt.in); err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseTime("7pm"); err != ErrInvalid {
+		t.Errorf("Expected an invalid time to fail, got %v", err)
+	}
+}
+
+func TestCombineDateTime(t *testing.T) {
+	want := time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC)
+
+	// Client formats and the strings DATE and TIME columns scan into
+	for _, pair := range [][2]string{
+		{"2030-01-15", "19:30"},
+		{"2030-01-15", "19:30:00"},
+		{"2030-01-15T00:00:00Z", "0000-01-01T19:30:00Z"},
+	} {
+		if got, err := CombineDateTime(pair[0], pair[1]); err != nil || !got.Equal(want) {
+			t.Errorf("CombineDateTime(%q, %q) = %v, %v", pair[0], pair[1], got, err)
+		}
+	}
+	if _, err := CombineDateTime("2030-01-15", ""); err == nil {
+		t.Error("Expected a missing time to fail")
+	}
+}
+
+func TestCombineDateTimeIn(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 19:30 in Berlin is 18:30 UTC in winter and 17:30 UTC in summer
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"2030-01-15", time.Date(2030, time.January, 15, 18, 30, 0, 0, time.UTC)},
+		{"2030-07-15", time.Date(2030, time.July, 15, 17, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got, err := CombineDateTimeIn(tt.date, "19:30", loc); err != nil || !got.Equal(tt.want) {
+			t.Errorf("CombineDateTimeIn(%q) = %v, %v, want %v", tt.date, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseTimestampIn(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"2030-01-15T19:30:00Z", time.Date(2030, time.January, 15, 19, 30, 0, 0, time.UTC)},
+		{"2030-01-15T19:30:00+01:00", time.Date(2030, time.January, 15, 18, 30, 0, 0, time.UTC)},
+		// Without an offset the time is read on the wall clock of loc
+		{"2030-01-15T19:30:00", time.Date(2030, time.January, 16, 0, 30, 0, 0, time.UTC)},
+		{"2030-01-15T19:30", time.Date(2030, time.January, 16, 0, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got, err := ParseTimestampIn(tt.s, loc); err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTimestampIn(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+	for _, s := range []string{"", "2030-01-15", "19:30", "15/01/2030 19:30"} {
+		if _, err := ParseTimestampIn(s, loc); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2030, time.January, 15, 19, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	if got := FormatDate(at); got != "2030-01-15" {
+		t.Errorf("FormatDate = %s", got)
+	}
+	if got := FormatTimestamp(at); got != "2030-01-16T00:30:00Z" {
+		t.Errorf("FormatTimestamp = %s", got)
+	}
+	if got := FormatTimestampIn(at, time.UTC); got != "2030-01-16T00:30:00Z" {
+		t.Errorf("FormatTimestampIn = %s", got)
+	}
+	if got, err := ParseTimestamp(FormatTimestamp(at)); err != nil || !got.Equal(at) {
+		t.Errorf("Expected timestamps to round-trip, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package tlsserve lets the API terminate TLS itself, for deployments without a
+// load balancer in front. Certificates come either from files or from Let's
+// Encrypt through autocert. A plain HTTP listener next to the HTTPS one
+// redirects every request to HTTPS and, with autocert, answers the ACME HTTP-01
+// challenges.
+package tlsserve
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options says where certificates come from. CertFile and KeyFile serve a
+// fixed certificate, read once at startup; AutocertDomains gets certificates
+// for those domains from Let's Encrypt, cached in AutocertCacheDir.
+type Options struct {
+	CertFile string
+	KeyFile  string
+
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string // contact for expiry notices; optional
+}
+
+// Setup is the TLS configuration of the HTTPS server and the handler for the
+// plain HTTP listener
+type Setup struct {
+	TLSConfig *tls.Config
+
+	// HTTPHandler redirects to HTTPS, answering ACME challenges first with
+	// autocert
+	HTTPHandler http.Handler
+}
+
+// New builds the TLS setup for options. httpsPort is the port the HTTPS
+// server listens on, for the redirects.
+func New(options Options, httpsPort string) (*Setup, error) {
+	redirect := Redirect(httpsPort)
+
+	switch {
+	case len(options.AutocertDomains) > 0:
+		if options.CertFile != "" || options.KeyFile != "" {
+			return nil, errors.New("use either a certificate file or autocert domains, not both")
+		}
+		if options.AutocertCacheDir == "" {
+			return nil, errors.New("autocert needs a cache directory, or every restart requests new certificates")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(options.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(options.AutocertDomains...),
+			Email:      options.AutocertEmail,
+		}
+		config := manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return &Setup{TLSConfig: config, HTTPHandler: manager.HTTPHandler(redirect)}, nil
+
+	case options.CertFile != "" || options.KeyFile != "":
+		if options.CertFile == "" || options.KeyFile == "" {
+			return nil, errors.New("a certificate file needs a key file, and the other way round")
+		}
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load certificate: %w", err)
+		}
+		config := &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+		return &Setup{TLSConfig: config, HTTPHandler: redirect}, nil
+	}
+
+	return nil, errors.New("no certificate file or autocert domains configured")
+}
+
+// Redirect permanently redirects every request to the same host and path over
+// HTTPS on httpsPort. 308 keeps the method and body, so API clients posting to
+// the plain HTTP address are redirected too.
+func Redirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,107 @@
+package tlsserve
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		port     string
+		host     string
+		target   string
+		location string
+	}{
+		{"443", "api.bookwork.app", "/api/clubs?limit=5", "https://api.bookwork.app/api/clubs?limit=5"},
+		{"8443", "api.bookwork.app:8080", "/api/clubs", "https://api.bookwork.app:8443/api/clubs"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		Redirect(tt.port).ServeHTTP(w, req)
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tt.location {
+			t.Errorf("Expected a 308 to %s, got %d to %s", tt.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestNewWithCertificateFiles(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	setup, err := New(Options{CertFile: certFile, KeyFile: keyFile}, "443")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(setup.TLSConfig.Certificates) != 1 || setup.HTTPHandler == nil {
+		t.Errorf("Expected the certificate and a redirect handler, got %+v", setup)
+	}
+
+	if _, err := New(Options{CertFile: certFile}, "443"); err == nil {
+		t.Error("Expected a certificate without a key to be refused")
+	}
+	if _, err := New(Options{CertFile: certFile, KeyFile: keyFile, AutocertDomains: []string{"api.bookwork.app"}}, "443"); err == nil {
+		t.Error("Expected a certificate file and autocert together to be refused")
+	}
+	if _, err := New(Options{}, "443"); err == nil {
+		t.Error("Expected no certificate source to be refused")
+	}
+}
+
+func TestNewWithAutocert(t *testing.T) {
+	setup, err := New(Options{AutocertDomains: []string{"api.bookwork.app"}, AutocertCacheDir: t.TempDir()}, "443")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if setup.TLSConfig.GetCertificate == nil {
+		t.Error("Expected certificates to be fetched on demand")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/clubs", nil)
+	req.Host = "api.bookwork.app"
+	w := httptest.NewRecorder()
+	setup.HTTPHandler.ServeHTTP(w, req)
+	if w.Code != http.StatusPermanentRedirect {
+		t.Errorf("Expected requests other than ACME challenges to be redirected, got %d", w.Code)
+	}
+}
+
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.bookwork.app"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
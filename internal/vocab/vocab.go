@@ -0,0 +1,291 @@
+// Package vocab keeps each club's vocabularies: the event types and item
+// categories its members pick from.
+//
+// A club starts with the defaults below. Its first change copies them into
+// club_vocabulary_terms, after which the stored list is the club's
+// vocabulary; resetting deletes the stored list. Events and items keep the
+// value they were created with when it is later removed from the list.
+package vocab
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bookwork-api/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Kind names a vocabulary
+type Kind string
+
+const (
+	EventType    Kind = "event_type"
+	ItemCategory Kind = "item_category"
+)
+
+const (
+	// MaxLength is the longest value or label, in characters
+	MaxLength = 50
+	// MaxTerms bounds the size of a club's vocabulary of one kind
+	MaxTerms = 30
+)
+
+var (
+	// ErrNotFound means the value is not in the vocabulary
+	ErrNotFound = errors.New("vocabulary term not found")
+	// ErrTaken means the value is already in the vocabulary
+	ErrTaken = errors.New("vocabulary term already exists")
+	// ErrFull means the vocabulary already has MaxTerms terms
+	ErrFull = errors.New("vocabulary is full")
+	// ErrLastTerm means the only term of a vocabulary was to be removed
+	ErrLastTerm = errors.New("cannot remove the last vocabulary term")
+)
+
+// Term is one entry of a vocabulary: the value stored on events or items and
+// the label shown for it
+type Term struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// Vocabulary is a club's list of terms of one kind, in display order
+type Vocabulary struct {
+	Kind   Kind   `json:"kind"`
+	Terms  []Term `json:"terms"`
+	Custom bool   `json:"custom"` // false while the club uses the defaults
+}
+
+// Has reports whether value is one of the terms
+func (v Vocabulary) Has(value string) bool {
+	for _, term := range v.Terms {
+		if term.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Values lists the terms' values
+func (v Vocabulary) Values() []string {
+	values := make([]string, len(v.Terms))
+	for i, term := range v.Terms {
+		values[i] = term.Value
+	}
+	return values
+}
+
+var defaults = map[Kind][]Term{
+	EventType: {
+		{Value: "discussion", Label: "Discussion"},
+		{Value: "meeting", Label: "Meeting"},
+		{Value: "social", Label: "Social"},
+		{Value: "author_event", Label: "Author event"},
+		{Value: "planning", Label: "Planning"},
+		{Value: "other", Label: "Other"},
+	},
+	ItemCategory: {
+		{Value: "food", Label: "Food"},
+		{Value: "materials", Label: "Materials"},
+		{Value: "logistics", Label: "Logistics"},
+		{Value: "discussion", Label: "Discussion"},
+		{Value: "presentation", Label: "Presentation"},
+		{Value: "other", Label: "Other"},
+	},
+}
+
+// Defaults returns the vocabulary of a club that has not changed it
+func Defaults(kind Kind) Vocabulary {
+	return Vocabulary{Kind: kind, Terms: append([]Term{}, defaults[kind]...)}
+}
+
+// ParseKind reads a kind from its URL form, "event-types" or "item-categories"
+func ParseKind(path string) (Kind, bool) {
+	switch path {
+	case "event-types":
+		return EventType, true
+	case "item-categories":
+		return ItemCategory, true
+	}
+	return "", false
+}
+
+var separators = regexp.MustCompile(`[\s-]+`)
+
+var validValue = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// NormalizeValue lower-cases a value and joins its words with underscores, so
+// "Author Event" becomes "author_event". It returns "" when the result is not
+// a valid value.
+func NormalizeValue(value string) string {
+	value = separators.ReplaceAllString(strings.ToLower(strings.TrimSpace(value)), "_")
+	if len(value) > MaxLength || !validValue.MatchString(value) {
+		return ""
+	}
+	return value
+}
+
+// Label returns label with its whitespace collapsed or, when it is empty, a
+// label made from value
+func Label(value, label string) string {
+	if label = strings.Join(strings.Fields(label), " "); label != "" {
+		return label
+	}
+	label = strings.ReplaceAll(value, "_", " ")
+	if label == "" {
+		return ""
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// Store reads and changes club vocabularies
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// List returns a club's vocabulary of a kind
+func (s *Store) List(ctx context.Context, clubID uuid.UUID, kind Kind) (Vocabulary, error) {
+	return list(ctx, s.db.QueryContext, clubID, kind)
+}
+
+// Add appends a term
+func (s *Store) Add(ctx context.Context, clubID uuid.UUID, kind Kind, term Term) (Vocabulary, error) {
+	return s.edit(ctx, clubID, kind, func(tx *sql.Tx, v Vocabulary) error {
+		if v.Has(term.Value) {
+			return ErrTaken
+		}
+		if len(v.Terms) >= MaxTerms {
+			return ErrFull
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO club_vocabulary_terms (club_id, kind, value, label, position)
+			SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0)
+			FROM club_vocabulary_terms WHERE club_id = $1 AND kind = $2`, clubID, kind, term.Value, term.Label)
+		if err != nil {
+			return fmt.Errorf("failed to add vocabulary term: %w", err)
+		}
+		return nil
+	})
+}
+
+// Relabel changes the label of a term. Values cannot change, since events and
+// items store them.
+func (s *Store) Relabel(ctx context.Context, clubID uuid.UUID, kind Kind, value, label string) (Vocabulary, error) {
+	return s.edit(ctx, clubID, kind, func(tx *sql.Tx, v Vocabulary) error {
+		if !v.Has(value) {
+			return ErrNotFound
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE club_vocabulary_terms SET label = $4
+			WHERE club_id = $1 AND kind = $2 AND value = $3`, clubID, kind, value, label)
+		if err != nil {
+			return fmt.Errorf("failed to relabel vocabulary term: %w", err)
+		}
+		return nil
+	})
+}
+
+// Remove takes a term out of the vocabulary. Events and items with the value
+// keep it, but new ones cannot use it.
+func (s *Store) Remove(ctx context.Context, clubID uuid.UUID, kind Kind, value string) (Vocabulary, error) {
+	return s.edit(ctx, clubID, kind, func(tx *sql.Tx, v Vocabulary) error {
+		if !v.Has(value) {
+			return ErrNotFound
+		}
+		if len(v.Terms) == 1 {
+			return ErrLastTerm
+		}
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM club_vocabulary_terms WHERE club_id = $1 AND kind = $2 AND value = $3`, clubID, kind, value)
+		if err != nil {
+			return fmt.Errorf("failed to remove vocabulary term: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reset returns a club to the default vocabulary of a kind
+func (s *Store) Reset(ctx context.Context, clubID uuid.UUID, kind Kind) (Vocabulary, error) {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM club_vocabulary_terms WHERE club_id = $1 AND kind = $2`, clubID, kind)
+	if err != nil {
+		return Vocabulary{}, fmt.Errorf("failed to reset vocabulary: %w", err)
+	}
+	return Defaults(kind), nil
+}
+
+// edit applies change to a club's vocabulary in a transaction, first copying
+// the defaults when the club has not changed it before
+func (s *Store) edit(ctx context.Context, clubID uuid.UUID, kind Kind, change func(tx *sql.Tx, v Vocabulary) error) (Vocabulary, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return Vocabulary{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Edits of a club's vocabularies queue behind each other
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM clubs WHERE id = $1 FOR UPDATE`, clubID); err != nil {
+		return Vocabulary{}, fmt.Errorf("failed to lock club: %w", err)
+	}
+
+	v, err := list(ctx, tx.QueryContext, clubID, kind)
+	if err != nil {
+		return Vocabulary{}, err
+	}
+	if !v.Custom {
+		for i, term := range v.Terms {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO club_vocabulary_terms (club_id, kind, value, label, position)
+				VALUES ($1, $2, $3, $4, $5)`, clubID, kind, term.Value, term.Label, i)
+			if err != nil {
+				return Vocabulary{}, fmt.Errorf("failed to copy default vocabulary: %w", err)
+			}
+		}
+	}
+
+	if err := change(tx, v); err != nil {
+		return Vocabulary{}, err
+	}
+
+	if v, err = list(ctx, tx.QueryContext, clubID, kind); err != nil {
+		return Vocabulary{}, err
+	}
+	return v, tx.Commit()
+}
+
+type querier func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+
+func list(ctx context.Context, query querier, clubID uuid.UUID, kind Kind) (Vocabulary, error) {
+	rows, err := query(ctx, `
+		SELECT value, label FROM club_vocabulary_terms
+		WHERE club_id = $1 AND kind = $2
+		ORDER BY position`, clubID, kind)
+	if err != nil {
+		return Vocabulary{}, fmt.Errorf("failed to query vocabulary: %w", err)
+	}
+	defer rows.Close()
+
+	v := Vocabulary{Kind: kind, Terms: []Term{}, Custom: true}
+	for rows.Next() {
+		var term Term
+		if err := rows.Scan(&term.Value, &term.Label); err != nil {
+			return Vocabulary{}, fmt.Errorf("failed to scan vocabulary term: %w", err)
+		}
+		v.Terms = append(v.Terms, term)
+	}
+	if err := rows.Err(); err != nil {
+		return Vocabulary{}, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	if len(v.Terms) == 0 {
+		return Defaults(kind), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,63 @@
+package vocab
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := map[string]string{
+		"Author Event":          "author_event",
+		"  book-swap ":          "book_swap",
+		"reading_circle":        "reading_circle",
+		"Q&A":                   "",
+		"_hidden":               "",
+		"":                      "",
+		strings.Repeat("a", 51): "",
+	}
+	for input, expected := range tests {
+		if got := NormalizeValue(input); got != expected {
+			t.Errorf("NormalizeValue(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if got := Label("book_swap", ""); got != "Book swap" {
+		t.Errorf("Expected a label made from the value, got %q", got)
+	}
+	if got := Label("book_swap", "  Book   Swap Night "); got != "Book Swap Night" {
+		t.Errorf("Expected the given label collapsed, got %q", got)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if kind, ok := ParseKind("event-types"); !ok || kind != EventType {
+		t.Errorf("Expected event-types to be EventType, got %q", kind)
+	}
+	if kind, ok := ParseKind("item-categories"); !ok || kind != ItemCategory {
+		t.Errorf("Expected item-categories to be ItemCategory, got %q", kind)
+	}
+	if _, ok := ParseKind("event_type"); ok {
+		t.Error("Expected only the URL forms to parse")
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	v := Defaults(EventType)
+	if v.Custom || !v.Has("discussion") || !v.Has("author_event") || v.Has("food") {
+		t.Errorf("Unexpected default event types %v", v.Values())
+	}
+
+	// Changing a copy leaves the defaults alone
+	v.Terms[0].Value = "changed"
+	if Defaults(EventType).Terms[0].Value != "discussion" {
+		t.Error("Expected Defaults to return a copy")
+	}
+
+	expected := []string{"food", "materials", "logistics", "discussion", "presentation", "other"}
+	if got := Defaults(ItemCategory).Values(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected item categories %v, got %v", expected, got)
+	}
+}
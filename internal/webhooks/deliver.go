@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"bookwork-api/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+// errBlockedAddress is returned when a webhook host resolves to an address
+// inside the server's own network
+var errBlockedAddress = errors.New("webhook address is not publicly routable")
+
+// Deliverer sends queued events to webhooks. It is the outbox handler of
+// TopicDeliver; a failed attempt is returned as an error so the outbox tries
+// again later, until the last attempt.
+type Deliverer struct {
+	store       *Store
+	client      *http.Client
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+// NewDeliverer gives each attempt timeout to answer and gives up on a
+// delivery after maxAttempts. Unless insecure is set, for local development,
+// webhooks resolving to loopback, private or link-local addresses are
+// refused, so a webhook cannot reach into the server's own network.
+func NewDeliverer(store *Store, timeout time.Duration, maxAttempts int, insecure bool, logger *slog.Logger) *Deliverer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !insecure {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// A redirect would send the signed event somewhere the club did not register
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &Deliverer{store: store, client: client, maxAttempts: maxAttempts, logger: logger}
+}
+
+// refusePrivate refuses connections to addresses that are not publicly routable
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// Deliver sends the event in msg to its webhook and logs the attempt.
+// Webhooks deleted or deactivated since the event was queued are skipped.
+func (d *Deliverer) Deliver(ctx context.Context, msg outbox.Message) error {
+	var payload delivery
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid %s message: %w", TopicDeliver, err)
+	}
+
+	webhook, err := d.store.webhook(ctx, payload.WebhookID)
+	if errors.Is(err, ErrNotFound) || (err == nil && !webhook.IsActive) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	attempt := Delivery{
+		ID:         uuid.New(),
+		DeliveryID: msg.ID,
+		EventID:    payload.Event.ID,
+		EventType:  payload.Event.Type,
+		Attempt:    msg.Attempts + 1,
+	}
+	sendErr := d.send(ctx, webhook, msg.ID, payload.Event, &attempt)
+
+	switch {
+	case sendErr == nil:
+		attempt.Status = StatusSucceeded
+	case attempt.Attempt < d.maxAttempts:
+		attempt.Status = StatusRetrying
+	default:
+		attempt.Status = StatusFailed
+		d.logger.Warn("giving up on webhook delivery", "webhook_id", webhook.ID, "delivery_id", msg.ID, "attempts", attempt.Attempt, "error", sendErr)
+	}
+	if err := d.store.recordDelivery(ctx, webhook.ID, attempt); err != nil {
+		d.logger.Error("error recording webhook delivery", "webhook_id", webhook.ID, "error", err)
+	}
+
+	if attempt.Status == StatusRetrying {
+		return sendErr
+	}
+	return nil
+}
+
+// send POSTs the signed event, filling in the attempt's response details
+func (d *Deliverer) send(ctx context.Context, webhook Webhook, deliveryID uuid.UUID, event Event, attempt *Delivery) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Bookwork-Webhooks/1")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, time.Now().Unix(), body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+		return errors.New(attempt.Error)
+	}
+	return nil
+}
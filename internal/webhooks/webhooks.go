@@ -0,0 +1,345 @@
+// Package webhooks tells clubs' own integrations what happens in their club.
+//
+// Club managers register HTTPS endpoints and pick the event types each one
+// receives. Events are queued through the outbox in the transaction of the
+// change they report, one message per subscribed webhook, so every endpoint
+// is retried on its own. Each delivery is a POST of the event as JSON, signed
+// with the webhook's secret in X-Bookwork-Signature:
+//
+//	t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// X-Bookwork-Delivery stays the same across retries of one delivery, so
+// receivers can drop repeats. Every attempt is logged for the club to read.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/outbox"
+
+	"github.com/google/uuid"
+)
+
+// Event types clubs can subscribe to
+const (
+	EventCreated        = "event.created"
+	MemberJoined        = "member.joined"
+	AvailabilityUpdated = "availability.updated"
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []string{EventCreated, MemberJoined, AvailabilityUpdated}
+
+// TopicDeliver is the outbox topic of one event for one webhook
+const TopicDeliver = "webhooks.deliver"
+
+// Request headers of a delivery
+const (
+	EventHeader     = "X-Bookwork-Event"
+	DeliveryHeader  = "X-Bookwork-Delivery"
+	SignatureHeader = "X-Bookwork-Signature"
+)
+
+// Outcomes of a delivery attempt
+const (
+	StatusSucceeded = "succeeded"
+	StatusRetrying  = "retrying" // failed; it will be tried again
+	StatusFailed    = "failed"   // failed for the last time
+)
+
+// ErrNotFound is returned for webhooks that do not exist in the club
+var ErrNotFound = errors.New("webhook not found")
+
+// Webhook is an endpoint a club's events are sent to
+type Webhook struct {
+	ID        uuid.UUID  `json:"id"`
+	ClubID    uuid.UUID  `json:"clubId"`
+	URL       string     `json:"url"`
+	Secret    string     `json:"-"`
+	Events    []string   `json:"events"`
+	IsActive  bool       `json:"isActive"`
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Update holds the webhook fields that may change; nil fields are left as they are
+type Update struct {
+	URL      *string
+	Events   []string
+	IsActive *bool
+}
+
+// Event is the body of a delivery
+type Event struct {
+	ID        uuid.UUID       `json:"id"` // the same for every webhook receiving the event
+	Type      string          `json:"type"`
+	ClubID    uuid.UUID       `json:"clubId"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivery is one attempt at sending an event to a webhook
+type Delivery struct {
+	ID         uuid.UUID `json:"id"`
+	DeliveryID uuid.UUID `json:"deliveryId"` // X-Bookwork-Delivery, shared by retries
+	EventID    uuid.UUID `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	Attempt    int       `json:"attempt"`
+	Status     string    `json:"status"`
+	StatusCode *int      `json:"statusCode,omitempty"` // nil when no response was received
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// delivery is the payload of a TopicDeliver message
+type delivery struct {
+	WebhookID uuid.UUID `json:"webhookId"`
+	Event     Event     `json:"event"`
+}
+
+// Sign returns the X-Bookwork-Signature of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "t=" + strconv.FormatInt(timestamp, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateURL checks that a webhook URL is absolute and carries no
+// credentials. Only https is accepted unless insecure is set, for local
+// development.
+func ValidateURL(raw string, insecure bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("must be an absolute URL")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	if u.Scheme != "https" && !(insecure && u.Scheme == "http") {
+		return errors.New("must use https")
+	}
+	return nil
+}
+
+// Store keeps clubs' webhooks and their delivery log
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+const webhookColumns = `id, club_id, url, secret, events, is_active, created_by, created_at, updated_at`
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (Webhook, error) {
+	var w Webhook
+	var events models.StringArray
+	err := row.Scan(&w.ID, &w.ClubID, &w.URL, &w.Secret, &events, &w.IsActive, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+	w.Events = []string(events)
+	return w, err
+}
+
+// List returns the club's webhooks, oldest first
+func (s *Store) List(ctx context.Context, clubID uuid.UUID) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM club_webhooks WHERE club_id = $1 ORDER BY created_at, id`, clubID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// Create registers an active webhook with a new secret
+func (s *Store) Create(ctx context.Context, clubID uuid.UUID, rawURL string, events []string, createdBy uuid.UUID) (Webhook, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO club_webhooks (id, club_id, url, secret, events, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookColumns,
+		uuid.New(), clubID, rawURL, secret, models.StringArray(events), createdBy)
+	w, err := scanWebhook(row)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return w, nil
+}
+
+// Update changes a webhook of the club
+func (s *Store) Update(ctx context.Context, clubID, webhookID uuid.UUID, update Update) (Webhook, error) {
+	var events interface{}
+	if update.Events != nil {
+		events = models.StringArray(update.Events)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE club_webhooks SET
+			url = COALESCE($3, url),
+			events = COALESCE($4, events),
+			is_active = COALESCE($5, is_active),
+			updated_at = NOW()
+		WHERE id = $1 AND club_id = $2
+		RETURNING `+webhookColumns,
+		webhookID, clubID, update.URL, events, update.IsActive)
+	w, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return w, nil
+}
+
+// Delete removes a webhook of the club with its delivery log. Deliveries
+// still queued for it are dropped.
+func (s *Store) Delete(ctx context.Context, clubID, webhookID uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM club_webhooks WHERE id = $1 AND club_id = $2`, webhookID, clubID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Deliveries returns the latest delivery attempts to a webhook of the club,
+// newest first
+func (s *Store) Deliveries(ctx context.Context, clubID, webhookID uuid.UUID, limit int) ([]Delivery, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM club_webhooks WHERE id = $1 AND club_id = $2)`, webhookID, clubID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, delivery_id, event_id, event_type, attempt, status, status_code, COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, attempt DESC
+		LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.DeliveryID, &d.EventID, &d.EventType, &d.Attempt, &d.Status, &d.StatusCode, &d.Error, &d.DurationMs, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Queue sends an event with data to the club's active webhooks subscribed to
+// eventType once tx commits, and to none if it rolls back
+func (s *Store) Queue(ctx context.Context, tx *sql.Tx, clubID uuid.UUID, eventType string, data interface{}) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM club_webhooks
+		WHERE club_id = $1 AND is_active = true AND $2 = ANY(events)`, clubID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	var webhookIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read webhook: %w", err)
+		}
+		webhookIDs = append(webhookIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	if len(webhookIDs) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	event := Event{ID: uuid.New(), Type: eventType, ClubID: clubID, CreatedAt: time.Now().UTC(), Data: encoded}
+	for _, id := range webhookIDs {
+		if _, err := outbox.Write(ctx, tx, TopicDeliver, delivery{WebhookID: id, Event: event}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish is Queue for a change that is not written in a transaction of the
+// caller's. The change is already committed, so a crash in between loses
+// the event.
+func (s *Store) Publish(ctx context.Context, clubID uuid.UUID, eventType string, data interface{}) error {
+	return s.db.WithTx(ctx, func(tx *sql.Tx) error {
+		return s.Queue(ctx, tx, clubID, eventType, data)
+	})
+}
+
+// webhook returns a webhook by ID, whatever its club
+func (s *Store) webhook(ctx context.Context, webhookID uuid.UUID) (Webhook, error) {
+	w, err := scanWebhook(s.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM club_webhooks WHERE id = $1`, webhookID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrNotFound
+	}
+	return w, err
+}
+
+// recordDelivery logs one delivery attempt
+func (s *Store) recordDelivery(ctx context.Context, webhookID uuid.UUID, d Delivery) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, webhook_id, delivery_id, event_id, event_type, attempt, status, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)`,
+		d.ID, webhookID, d.DeliveryID, d.EventID, d.EventType, d.Attempt, d.Status, d.StatusCode, d.Error, d.DurationMs)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,702 @@
+// Package yearbook compiles a club's end-of-year report: the books it read,
+// its best-attended events, attendance figures and member milestones.
+//
+// Reports are compiled in the background. A request queues a row in
+// club_yearbooks; a Compiler on any instance claims it, stores the report as
+// JSON with the row and the rendered PDF in attachment storage, then notifies
+// the member who asked with a signed download link. Archived events count
+// towards the year they were held in.
+package yearbook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookwork-api/internal/attachments"
+	"bookwork-api/internal/database"
+	"bookwork-api/internal/models"
+	"bookwork-api/internal/notify"
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound means the club has not asked for a yearbook of the year
+var ErrNotFound = errors.New("yearbook not found")
+
+// Statuses of a yearbook
+const (
+	StatusPending   = "pending"
+	StatusCompiling = "compiling"
+	StatusReady     = "ready"
+	StatusFailed    = "failed"
+)
+
+const (
+	// TopEvents is how many of the best-attended events are listed
+	TopEvents = 5
+	// MostActive is how many of the members attending most are listed
+	MostActive = 5
+	// perfectAttendanceMin is the fewest events that make attending all of them a milestone
+	perfectAttendanceMin = 3
+	// StaleAfter is how long a compile may run before it is assumed lost with
+	// its instance and another one takes it over
+	StaleAfter = 15 * time.Minute
+)
+
+// Yearbook is a club's report for one calendar year
+type Yearbook struct {
+	ClubID      uuid.UUID        `json:"clubId"`
+	ClubName    string           `json:"clubName"`
+	Year        int              `json:"year"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Books       []Book           `json:"books"`
+	TopEvents   []EventHighlight `json:"topEvents"`
+	Attendance  Attendance       `json:"attendance"`
+	Milestones  []Milestone      `json:"milestones"`
+}
+
+// Book is a book the club met about, in the order it was first discussed
+type Book struct {
+	Title     string `json:"title"`
+	Events    int    `json:"events"`
+	FirstDate string `json:"firstDate"`
+}
+
+// EventHighlight is one of the year's best-attended events
+type EventHighlight struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Date      string    `json:"date"`
+	Type      string    `json:"type"`
+	Book      *string   `json:"book,omitempty"`
+	Attendees int       `json:"attendees"`
+}
+
+// Attendance sums up who came to the year's events
+type Attendance struct {
+	Events          int            `json:"events"`
+	Attendances     int            `json:"attendances"`
+	AveragePerEvent float64        `json:"averagePerEvent"`
+	BusiestMonth    string         `json:"busiestMonth,omitempty"` // the month with the most attendances
+	MostActive      []MemberEvents `json:"mostActive"`
+}
+
+// MemberEvents is how many of the year's events a member attended
+type MemberEvents struct {
+	UserID uuid.UUID `json:"userId"`
+	Name   string    `json:"name"`
+	Events int       `json:"events"`
+}
+
+// Milestone kinds
+const (
+	MilestoneJoined            = "joined"
+	MilestoneAnniversary       = "anniversary"
+	MilestonePerfectAttendance = "perfect_attendance"
+)
+
+// Milestone is something a member reached during the year
+type Milestone struct {
+	UserID uuid.UUID `json:"userId"`
+	Name   string    `json:"name"`
+	Kind   string    `json:"kind"`
+	Years  int       `json:"years,omitempty"` // membership years, for anniversaries
+	Date   string    `json:"date"`
+}
+
+// Source is what a yearbook is compiled from
+type Source struct {
+	ClubID     uuid.UUID
+	ClubName   string
+	BrandColor string
+	Year       int
+	Events     []EventRow  // events held in the year, by date
+	Members    []MemberRow // active members who joined before the year ended, by join date
+}
+
+// EventRow is an event held in the year, with how many attended it
+type EventRow struct {
+	ID        uuid.UUID
+	Title     string
+	Date      time.Time
+	Type      string
+	Book      *string
+	Attendees int
+}
+
+// MemberRow is an active member and how many of the year's events they attended
+type MemberRow struct {
+	UserID   uuid.UUID
+	Name     string
+	JoinedAt time.Time
+	Events   int
+}
+
+// Compile builds the yearbook from its source
+func Compile(src Source, now time.Time) Yearbook {
+	y := Yearbook{
+		ClubID:      src.ClubID,
+		ClubName:    src.ClubName,
+		Year:        src.Year,
+		GeneratedAt: now,
+		Books:       []Book{},
+		TopEvents:   []EventHighlight{},
+		Attendance:  Attendance{Events: len(src.Events), MostActive: []MemberEvents{}},
+		Milestones:  []Milestone{},
+	}
+
+	// Books, matched regardless of case and spacing
+	books := map[string]int{}
+	monthly := map[time.Month]int{}
+	for _, event := range src.Events {
+		y.Attendance.Attendances += event.Attendees
+		monthly[event.Date.Month()] += event.Attendees
+
+		if event.Book == nil || strings.TrimSpace(*event.Book) == "" {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(*event.Book), " "))
+		if i, ok := books[key]; ok {
+			y.Books[i].Events++
+			continue
+		}
+		books[key] = len(y.Books)
+		y.Books = append(y.Books, Book{Title: strings.TrimSpace(*event.Book), Events: 1, FirstDate: event.Date.Format("2006-01-02")})
+	}
+
+	if len(src.Events) > 0 {
+		average := float64(y.Attendance.Attendances) / float64(len(src.Events))
+		y.Attendance.AveragePerEvent = math.Round(average*10) / 10
+	}
+	busiest := 0
+	for month := time.January; month <= time.December; month++ {
+		if monthly[month] > busiest {
+			busiest = monthly[month]
+			y.Attendance.BusiestMonth = month.String()
+		}
+	}
+
+	// Best-attended events, earlier first on a tie
+	ranked := append([]EventRow{}, src.Events...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Attendees > ranked[j].Attendees })
+	for _, event := range ranked {
+		if len(y.TopEvents) == TopEvents || event.Attendees == 0 {
+			break
+		}
+		y.TopEvents = append(y.TopEvents, EventHighlight{
+			ID:        event.ID,
+			Title:     event.Title,
+			Date:      event.Date.Format("2006-01-02"),
+			Type:      event.Type,
+			Book:      event.Book,
+			Attendees: event.Attendees,
+		})
+	}
+
+	members := append([]MemberRow{}, src.Members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Events != members[j].Events {
+			return members[i].Events > members[j].Events
+		}
+		return members[i].Name < members[j].Name
+	})
+	for _, member := range members {
+		if len(y.Attendance.MostActive) == MostActive || member.Events == 0 {
+			break
+		}
+		y.Attendance.MostActive = append(y.Attendance.MostActive, MemberEvents{UserID: member.UserID, Name: member.Name, Events: member.Events})
+	}
+
+	y.Milestones = milestones(src)
+	return y
+}
+
+// milestones lists members who joined during the year, membership
+// anniversaries, and members who attended every event, by date
+func milestones(src Source) []Milestone {
+	list := []Milestone{}
+	for _, member := range src.Members {
+		joined := member.JoinedAt.UTC()
+		switch years := src.Year - joined.Year(); {
+		case years == 0:
+			list = append(list, Milestone{UserID: member.UserID, Name: member.Name, Kind: MilestoneJoined, Date: joined.Format("2006-01-02")})
+		case years > 0:
+			list = append(list, Milestone{
+				UserID: member.UserID,
+				Name:   member.Name,
+				Kind:   MilestoneAnniversary,
+				Years:  years,
+				Date:   joined.AddDate(years, 0, 0).Format("2006-01-02"),
+			})
+		}
+
+		// Only members who were in the club for the first event can have attended them all
+		if n := len(src.Events); n >= perfectAttendanceMin && member.Events == n && joined.Before(src.Events[0].Date.AddDate(0, 0, 1)) {
+			list = append(list, Milestone{
+				UserID: member.UserID,
+				Name:   member.Name,
+				Kind:   MilestonePerfectAttendance,
+				Date:   src.Events[n-1].Date.Format("2006-01-02"),
+			})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Date != list[j].Date {
+			return list[i].Date < list[j].Date
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Sheet lays the yearbook out for WriteYearbookPDF
+func Sheet(y Yearbook, brandColor string) reports.YearbookSheet {
+	sheet := reports.YearbookSheet{
+		ClubName:    y.ClubName,
+		BrandColor:  brandColor,
+		Year:        y.Year,
+		GeneratedAt: y.GeneratedAt,
+		Highlights: []reports.YearbookStat{
+			{Label: "Events held", Value: strconv.Itoa(y.Attendance.Events)},
+			{Label: "Books read", Value: strconv.Itoa(len(y.Books))},
+			{Label: "Attendances", Value: strconv.Itoa(y.Attendance.Attendances)},
+			{Label: "Average per event", Value: strconv.FormatFloat(y.Attendance.AveragePerEvent, 'f', 1, 64)},
+		},
+	}
+
+	books := reports.YearbookSection{
+		Title:   "Books read",
+		Columns: []reports.YearbookColumn{{Header: "Title", Width: 120}, {Header: "Meetings", Width: 25}, {Header: "First met", Width: 35}},
+		Empty:   "No books were discussed this year.",
+	}
+	for _, book := range y.Books {
+		books.Rows = append(books.Rows, []string{book.Title, strconv.Itoa(book.Events), book.FirstDate})
+	}
+
+	events := reports.YearbookSection{
+		Title:   "Top events",
+		Columns: []reports.YearbookColumn{{Header: "Event", Width: 95}, {Header: "Date", Width: 30}, {Header: "Type", Width: 30}, {Header: "Attended", Width: 25}},
+		Empty:   "No attended events this year.",
+	}
+	for _, event := range y.TopEvents {
+		events.Rows = append(events.Rows, []string{event.Title, event.Date, event.Type, strconv.Itoa(event.Attendees)})
+	}
+
+	active := reports.YearbookSection{
+		Title:   "Most active members",
+		Columns: []reports.YearbookColumn{{Header: "Member", Width: 140}, {Header: "Events", Width: 40}},
+		Empty:   "No attendance was recorded this year.",
+	}
+	for _, member := range y.Attendance.MostActive {
+		active.Rows = append(active.Rows, []string{member.Name, strconv.Itoa(member.Events)})
+	}
+
+	milestones := reports.YearbookSection{
+		Title:   "Member milestones",
+		Columns: []reports.YearbookColumn{{Header: "Member", Width: 80}, {Header: "Milestone", Width: 65}, {Header: "Date", Width: 35}},
+		Empty:   "No milestones this year.",
+	}
+	for _, m := range y.Milestones {
+		milestones.Rows = append(milestones.Rows, []string{m.Name, describe(m), m.Date})
+	}
+
+	sheet.Sections = []reports.YearbookSection{books, events, active, milestones}
+	return sheet
+}
+
+func describe(m Milestone) string {
+	switch m.Kind {
+	case MilestoneJoined:
+		return "Joined the club"
+	case MilestoneAnniversary:
+		if m.Years == 1 {
+			return "1 year with the club"
+		}
+		return strconv.Itoa(m.Years) + " years with the club"
+	case MilestonePerfectAttendance:
+		return "Attended every event"
+	}
+	return m.Kind
+}
+
+// Job is a club's yearbook for a year and where compiling it has got to
+type Job struct {
+	ID                   uuid.UUID  `json:"id"`
+	ClubID               uuid.UUID  `json:"clubId"`
+	Year                 int        `json:"year"`
+	Status               string     `json:"status"`
+	RequestedBy          *uuid.UUID `json:"requestedBy,omitempty"`
+	RequestedAt          time.Time  `json:"requestedAt"`
+	CompletedAt          *time.Time `json:"completedAt,omitempty"`
+	Error                *string    `json:"error,omitempty"`
+	Report               *Yearbook  `json:"report,omitempty"` // set once ready
+	DownloadURL          string     `json:"downloadUrl,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"downloadUrlExpiresAt,omitempty"`
+	StorageKey           string     `json:"-"`
+}
+
+// Links signs and checks yearbook download links
+type Links struct {
+	signer *signedurl.Signer
+	ttl    time.Duration
+}
+
+func NewLinks(signer *signedurl.Signer, ttl time.Duration) Links {
+	return Links{signer: signer, ttl: ttl}
+}
+
+// Sign gives a ready job a download URL valid for the link TTL from now
+func (l Links) Sign(job *Job, now time.Time) {
+	expiresAt := now.Add(l.ttl)
+	job.DownloadURL = "/api/yearbooks/" + l.signer.Sign(job.ID.String(), expiresAt)
+	job.DownloadURLExpiresAt = &expiresAt
+}
+
+// Verify returns the ID of the yearbook a download token was issued for
+func (l Links) Verify(token string, now time.Time) (uuid.UUID, error) {
+	subject, _, err := l.signer.Verify(token, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(subject)
+	if err != nil {
+		return uuid.Nil, signedurl.ErrMalformed
+	}
+	return id, nil
+}
+
+// Store reads yearbook sources and tracks yearbook jobs
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+// yearEvents selects the club's ($1) events from $2 up to $3, hot or
+// archived, and who attended each: members listed on it or available for it
+const yearEvents = `
+	WITH year_events AS (
+		SELECT id, title, event_date, COALESCE(type, '') AS type, book, attendees FROM events
+		WHERE club_id = $1 AND deleted_at IS NULL AND event_date >= $2 AND event_date < $3
+		UNION ALL
+		SELECT id, title, event_date, COALESCE(type, ''), book, attendees FROM events_archive
+		WHERE club_id = $1 AND event_date >= $2 AND event_date < $3
+	),
+	attended AS (
+		SELECT ye.id AS event_id, a.user_id FROM year_events ye, unnest(ye.attendees) AS a(user_id)
+		UNION
+		SELECT av.event_id, av.user_id FROM availability av
+		JOIN year_events ye ON ye.id = av.event_id
+		WHERE av.status = 'available'
+		UNION
+		SELECT av.event_id, av.user_id FROM availability_archive av
+		JOIN year_events ye ON ye.id = av.event_id AND ye.event_date = av.event_date
+		WHERE av.status = 'available'
+	)`
+
+// Load reads what a club's yearbook for year is compiled from. For the
+// current year only events held up to now count.
+func (s *Store) Load(ctx context.Context, clubID uuid.UUID, year int, now time.Time) (Source, error) {
+	src := Source{ClubID: clubID, Year: year, Events: []EventRow{}, Members: []MemberRow{}}
+	err := s.db.QueryRowContext(ctx, `SELECT name, COALESCE(brand_color, '') FROM clubs WHERE id = $1`, clubID).
+		Scan(&src.ClubName, &src.BrandColor)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to get club: %w", err)
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(1, 0, 0)
+	if tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC); tomorrow.Before(until) {
+		until = tomorrow
+	}
+
+	rows, err := s.db.QueryContext(ctx, yearEvents+`
+		SELECT ye.id, ye.title, ye.event_date, ye.type, ye.book, COUNT(at.user_id)
+		FROM year_events ye
+		LEFT JOIN attended at ON at.event_id = ye.id
+		GROUP BY ye.id, ye.title, ye.event_date, ye.type, ye.book
+		ORDER BY ye.event_date, ye.id`, clubID, from, until)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to query yearbook events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event EventRow
+		if err := rows.Scan(&event.ID, &event.Title, &event.Date, &event.Type, &event.Book, &event.Attendees); err != nil {
+			return Source{}, fmt.Errorf("failed to scan yearbook event: %w", err)
+		}
+		src.Events = append(src.Events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return Source{}, fmt.Errorf("failed to read yearbook events: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, yearEvents+`
+		SELECT u.id, u.name, cm.joined_date, COUNT(DISTINCT at.event_id)
+		FROM club_members cm
+		JOIN users u ON u.id = cm.user_id
+		LEFT JOIN attended at ON at.user_id = cm.user_id
+		WHERE cm.club_id = $1 AND cm.is_active = true AND cm.joined_date < $3
+		GROUP BY u.id, u.name, cm.joined_date
+		ORDER BY cm.joined_date, u.name`, clubID, from, until)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to query yearbook members: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var member MemberRow
+		if err := rows.Scan(&member.UserID, &member.Name, &member.JoinedAt, &member.Events); err != nil {
+			return Source{}, fmt.Errorf("failed to scan yearbook member: %w", err)
+		}
+		src.Members = append(src.Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return Source{}, fmt.Errorf("failed to read yearbook members: %w", err)
+	}
+
+	return src, nil
+}
+
+// Request queues the club's yearbook for year. Asking again for a ready or
+// failed yearbook compiles it afresh; asking while it is queued or compiling
+// changes nothing.
+func (s *Store) Request(ctx context.Context, clubID uuid.UUID, year int, requestedBy uuid.UUID) (Job, error) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO club_yearbooks (club_id, year, requested_by) VALUES ($1, $2, $3)
+		ON CONFLICT (club_id, year) DO UPDATE
+		SET status = 'pending', requested_by = EXCLUDED.requested_by, requested_at = CURRENT_TIMESTAMP,
+		    started_at = NULL, error = NULL
+		WHERE club_yearbooks.status IN ('ready', 'failed')`, clubID, year, requestedBy)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to queue yearbook: %w", err)
+	}
+	return s.Get(ctx, clubID, year)
+}
+
+const selectJobs = `
+	SELECT id, club_id, year, status, requested_by, requested_at, completed_at, error, report, COALESCE(storage_key, '')
+	FROM club_yearbooks`
+
+// Get returns the club's yearbook for year
+func (s *Store) Get(ctx context.Context, clubID uuid.UUID, year int) (Job, error) {
+	return s.get(ctx, selectJobs+` WHERE club_id = $1 AND year = $2`, clubID, year)
+}
+
+// GetByID returns a yearbook by its ID
+func (s *Store) GetByID(ctx context.Context, id uuid.UUID) (Job, error) {
+	return s.get(ctx, selectJobs+` WHERE id = $1`, id)
+}
+
+func (s *Store) get(ctx context.Context, query string, args ...interface{}) (Job, error) {
+	var job Job
+	var report []byte
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&job.ID, &job.ClubID, &job.Year, &job.Status, &job.RequestedBy, &job.RequestedAt,
+		&job.CompletedAt, &job.Error, &report, &job.StorageKey,
+	)
+	if err == sql.ErrNoRows {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to get yearbook: %w", err)
+	}
+	// A yearbook being compiled again keeps its old report until the new one is ready
+	if report != nil && job.Status == StatusReady {
+		job.Report = &Yearbook{}
+		if err := json.Unmarshal(report, job.Report); err != nil {
+			return Job{}, fmt.Errorf("failed to decode yearbook report: %w", err)
+		}
+	}
+	return job, nil
+}
+
+// claim marks the oldest queued yearbook, or one whose compile went stale, as
+// compiling and returns it; ok is false when there is none
+func (s *Store) claim(ctx context.Context, now time.Time) (job Job, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, `
+		UPDATE club_yearbooks SET status = 'compiling', started_at = $1
+		WHERE id = (
+			SELECT id FROM club_yearbooks
+			WHERE status = 'pending' OR (status = 'compiling' AND started_at < $2)
+			ORDER BY requested_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, club_id, year, status, requested_by, requested_at`, now, now.Add(-StaleAfter),
+	).Scan(&job.ID, &job.ClubID, &job.Year, &job.Status, &job.RequestedBy, &job.RequestedAt)
+	if err == sql.ErrNoRows {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("failed to claim yearbook: %w", err)
+	}
+	return job, true, nil
+}
+
+func (s *Store) complete(ctx context.Context, id uuid.UUID, report Yearbook, storageKey string) error {
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode yearbook report: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE club_yearbooks
+		SET status = 'ready', report = $2, storage_key = $3, error = NULL, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, encoded, storageKey)
+	if err != nil {
+		return fmt.Errorf("failed to save yearbook: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) fail(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE club_yearbooks SET status = 'failed', error = $2, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, message)
+	if err != nil {
+		return fmt.Errorf("failed to mark yearbook failed: %w", err)
+	}
+	return nil
+}
+
+// notifier is the part of notify.Notifier that tells members their yearbook is done
+type notifier interface {
+	NotifyUser(ctx context.Context, userID uuid.UUID, notification models.Notification) (int, error)
+}
+
+// Compiler compiles queued yearbooks one at a time. It checks for work every
+// interval, and at once when woken by a request on this instance.
+type Compiler struct {
+	store    *Store
+	storage  attachments.Storage
+	links    Links
+	notifier notifier
+	interval time.Duration
+	logger   *slog.Logger
+	wake     chan struct{}
+}
+
+func NewCompiler(store *Store, storage attachments.Storage, links Links, interval time.Duration, logger *slog.Logger) *Compiler {
+	return &Compiler{
+		store:    store,
+		storage:  storage,
+		links:    links,
+		interval: interval,
+		logger:   logger,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// WithNotifier notifies whoever asked for a yearbook when it is ready or has failed
+func (c *Compiler) WithNotifier(n notifier) *Compiler {
+	c.notifier = n
+	return c
+}
+
+// Wake makes Run look for queued yearbooks now rather than at the next interval
+func (c *Compiler) Wake() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CompileNext compiles one queued yearbook and reports whether there was one
+func (c *Compiler) CompileNext(ctx context.Context, now time.Time) (bool, error) {
+	job, ok, err := c.store.claim(ctx, now)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	report, brandColor, err := c.compile(ctx, job, now)
+	if err == nil {
+		key := fmt.Sprintf("yearbooks/%s/%d.pdf", job.ClubID, job.Year)
+		var pdf bytes.Buffer
+		if err = reports.WriteYearbookPDF(&pdf, Sheet(report, brandColor)); err == nil {
+			if err = c.storage.Put(ctx, key, pdf.Bytes(), "application/pdf"); err == nil {
+				err = c.store.complete(ctx, job.ID, report, key)
+			}
+		}
+	}
+	if err != nil {
+		if ferr := c.store.fail(ctx, job.ID, "The yearbook could not be compiled. Please ask for it again."); ferr != nil {
+			c.logger.Error("error marking yearbook failed", "yearbook_id", job.ID, "error", ferr)
+		}
+		c.notify(ctx, job, report.ClubName, false, now)
+		return true, fmt.Errorf("failed to compile yearbook %s: %w", job.ID, err)
+	}
+
+	c.notify(ctx, job, report.ClubName, true, now)
+	return true, nil
+}
+
+func (c *Compiler) compile(ctx context.Context, job Job, now time.Time) (Yearbook, string, error) {
+	src, err := c.store.Load(ctx, job.ClubID, job.Year, now)
+	if err != nil {
+		return Yearbook{}, "", err
+	}
+	return Compile(src, now), src.BrandColor, nil
+}
+
+// notify tells the member who asked for the yearbook how it went
+func (c *Compiler) notify(ctx context.Context, job Job, clubName string, ready bool, now time.Time) {
+	if c.notifier == nil || job.RequestedBy == nil {
+		return
+	}
+
+	year := strconv.Itoa(job.Year)
+	notification := models.Notification{Type: notify.TypeClubYearbook, ClubID: &job.ClubID}
+	if ready {
+		c.links.Sign(&job, now)
+		notification.Title = "Your " + year + " yearbook is ready"
+		notification.Body = fmt.Sprintf("The %s yearbook for %s is ready. Download the PDF before %s: %s",
+			year, clubName, job.DownloadURLExpiresAt.UTC().Format("2006-01-02 15:04 MST"), job.DownloadURL)
+	} else {
+		notification.Title = "Your " + year + " yearbook could not be compiled"
+		notification.Body = "Something went wrong compiling the " + year + " yearbook. Please ask for it again."
+	}
+
+	if _, err := c.notifier.NotifyUser(ctx, *job.RequestedBy, notification); err != nil {
+		c.logger.Error("error notifying yearbook requester", "yearbook_id", job.ID, "error", err)
+	}
+}
+
+// Run compiles queued yearbooks until none are left, then waits for the
+// next interval or a wake, until ctx is cancelled
+func (c *Compiler) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			compiled, err := c.CompileNext(ctx, time.Now())
+			if err != nil {
+				c.logger.Error("error compiling yearbook", "error", err)
+			}
+			if !compiled || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.wake:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package yearbook
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"bookwork-api/internal/reports"
+	"bookwork-api/internal/signedurl"
+
+	"github.com/google/uuid"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func testSource() Source {
+	dune, duneAgain, emma := "Dune", "  dune ", "Emma"
+	ada, bo, cy := uuid.New(), uuid.New(), uuid.New()
+	return Source{
+		ClubName: "Classic Literature Club",
+		Year:     2025,
+		Events: []EventRow{
+			{ID: uuid.New(), Title: "Dune, part one", Date: date("2025-02-10"), Type: "discussion", Book: &dune, Attendees: 4},
+			{ID: uuid.New(), Title: "Dune, part two", Date: date("2025-03-10"), Type: "discussion", Book: &duneAgain, Attendees: 6},
+			{ID: uuid.New(), Title: "Picnic", Date: date("2025-06-01"), Type: "social", Attendees: 6},
+			{ID: uuid.New(), Title: "Emma", Date: date("2025-09-15"), Type: "discussion", Book: &emma, Attendees: 0},
+		},
+		Members: []MemberRow{
+			{UserID: ada, Name: "Ada", JoinedAt: date("2021-01-20"), Events: 4},
+			{UserID: bo, Name: "Bo", JoinedAt: date("2024-05-02"), Events: 2},
+			{UserID: cy, Name: "Cy", JoinedAt: date("2025-04-01"), Events: 0},
+		},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+	src := testSource()
+	y := Compile(src, now)
+
+	expectedBooks := []Book{{Title: "Dune", Events: 2, FirstDate: "2025-02-10"}, {Title: "Emma", Events: 1, FirstDate: "2025-09-15"}}
+	if !reflect.DeepEqual(y.Books, expectedBooks) {
+		t.Errorf("Expected books %v, got %v", expectedBooks, y.Books)
+	}
+
+	// Ties go to the earlier event; events nobody attended are left out
+	var top []string
+	for _, event := range y.TopEvents {
+		top = append(top, event.Title)
+	}
+	if !reflect.DeepEqual(top, []string{"Dune, part two", "Picnic", "Dune, part one"}) {
+		t.Errorf("Expected top events by attendance, got %v", top)
+	}
+
+	if y.Attendance.Events != 4 || y.Attendance.Attendances != 16 || y.Attendance.AveragePerEvent != 4 || y.Attendance.BusiestMonth != "March" {
+		t.Errorf("Unexpected attendance %+v", y.Attendance)
+	}
+	if len(y.Attendance.MostActive) != 2 || y.Attendance.MostActive[0].Name != "Ada" {
+		t.Errorf("Expected Ada then Bo as most active, got %v", y.Attendance.MostActive)
+	}
+
+	expected := []Milestone{
+		{UserID: src.Members[0].UserID, Name: "Ada", Kind: MilestoneAnniversary, Years: 4, Date: "2025-01-20"},
+		{UserID: src.Members[2].UserID, Name: "Cy", Kind: MilestoneJoined, Date: "2025-04-01"},
+		{UserID: src.Members[1].UserID, Name: "Bo", Kind: MilestoneAnniversary, Years: 1, Date: "2025-05-02"},
+		{UserID: src.Members[0].UserID, Name: "Ada", Kind: MilestonePerfectAttendance, Date: "2025-09-15"},
+	}
+	if !reflect.DeepEqual(y.Milestones, expected) {
+		t.Errorf("Expected milestones %+v, got %+v", expected, y.Milestones)
+	}
+}
+
+func TestCompileEmptyYear(t *testing.T) {
+	y := Compile(Source{Year: 2025}, time.Now())
+	if y.Books == nil || y.TopEvents == nil || y.Milestones == nil || y.Attendance.MostActive == nil {
+		t.Error("Expected empty lists rather than nulls")
+	}
+	if y.Attendance.AveragePerEvent != 0 || y.Attendance.BusiestMonth != "" {
+		t.Errorf("Expected no attendance figures, got %+v", y.Attendance)
+	}
+}
+
+func TestSheetRendersPDF(t *testing.T) {
+	y := Compile(testSource(), time.Now())
+	sheet := Sheet(y, "#8B0000")
+	if len(sheet.Sections) != 4 || len(sheet.Sections[3].Rows) != 4 || sheet.Sections[3].Rows[2][1] != "1 year with the club" {
+		t.Errorf("Unexpected sheet sections %+v", sheet.Sections)
+	}
+
+	var pdf bytes.Buffer
+	if err := reports.WriteYearbookPDF(&pdf, sheet); err != nil {
+		t.Fatalf("Failed to write yearbook: %v", err)
+	}
+	if !bytes.HasPrefix(pdf.Bytes(), []byte("%PDF-")) {
+		t.Error("Yearbook output should start with a PDF header")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	now := time.Now()
+	links := NewLinks(signedurl.NewSigner("test-secret", "yearbook-download"), time.Hour)
+	job := Job{ID: uuid.New()}
+	links.Sign(&job, now)
+
+	token := job.DownloadURL[len("/api/yearbooks/"):]
+	if id, err := links.Verify(token, now); err != nil || id != job.ID {
+		t.Errorf("Expected the link to verify as %s, got %s, %v", job.ID, id, err)
+	}
+	if _, err := links.Verify(token, now.Add(2*time.Hour)); err != signedurl.ErrExpired {
+		t.Errorf("Expected an expired link, got %v", err)
+	}
+}